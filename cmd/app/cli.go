@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/internal/auth"
+	"github.com/melnik-dev/go_todo_jwt/internal/user"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/sirupsen/logrus"
+)
+
+const usage = `usage: app [command] [arguments] [--output text|json]
+
+commands:
+  serve                                       start the HTTP server (default)
+  migrate up|down [N]|status|goto VERSION     manage the database schema
+  user create USERNAME [--password P]         create a user
+  user list                                   list users
+  user disable|enable USERNAME                block or unblock logins of a user
+  user reset-password USERNAME [--password P] set a new password
+  token issue USERNAME [--ttl D] [--workspace ID]
+                                              issue a JWT for debugging
+  config print                                print configuration with secrets redacted
+
+Without --password the password is read from stdin.`
+
+// commands - подкоманды кроме serve; все, кроме config, работают с базой
+var commands = map[string]bool{"migrate": true, "user": true, "token": true, "config": true}
+
+var errUsage = errors.New(usage)
+
+// runCommand выполняет подкоманду администрирования с аргументами args
+func runCommand(ctx context.Context, command string, args []string, cfg *configs.Config, database *db.Db, logger *logrus.Logger) error {
+	switch command {
+	case "migrate":
+		return runMigrate(ctx, database, logger, args)
+	case "user":
+		return runUser(ctx, auth.NewService(userRepository(database, logger), logger), args)
+	case "token":
+		return runToken(ctx, cfg, auth.NewService(userRepository(database, logger), logger), args)
+	}
+	return errUsage
+}
+
+// userRepository - репозиторий пользователей для драйвера базы
+func userRepository(database *db.Db, logger *logrus.Logger) user.IRepository {
+	if database.DriverName() == db.DriverSQLite {
+		return user.NewSQLiteRepository(database, logger)
+	}
+	return user.NewRepository(database, logger)
+}
+
+// newFlagSet создаёт набор флагов подкоманды с общим флагом --output
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	output := fs.String("output", "text", "output format: text or json")
+	return fs, output
+}
+
+// parseFlags разбирает args и возвращает позиционные аргументы;
+// флаги допускаются и до, и после них
+func parseFlags(fs *flag.FlagSet, output *string, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, fmt.Errorf("%s: %w", fs.Name(), err)
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if *output != "text" && *output != "json" {
+		return nil, fmt.Errorf("unknown output format %q", *output)
+	}
+	return positional, nil
+}
+
+// printOutput выводит v в stdout: JSON для --output json, иначе таблицей через text
+func printOutput(output string, v any, text func(w io.Writer)) error {
+	if output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	text(w)
+	return w.Flush()
+}
+
+// readPassword возвращает пароль из флага, а без него - первую строку stdin,
+// чтобы пароль не попадал в историю команд
+func readPassword(password string) (string, error) {
+	if password != "" {
+		return password, nil
+	}
+	if fi, err := os.Stdin.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprint(os.Stderr, "Password: ")
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	if password = strings.TrimRight(line, "\r\n"); password == "" {
+		return "", errors.New("password is required: pass --password or write it to stdin")
+	}
+	return password, nil
+}
+
+// runConfig выводит итоговую конфигурацию с учётом переменных окружения
+func runConfig(cfg *configs.Config, args []string) error {
+	fs, output := newFlagSet("config")
+	positional, err := parseFlags(fs, output, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 || positional[0] != "print" {
+		return errUsage
+	}
+
+	settings := cfg.Settings()
+	values := make(map[string]any, len(settings))
+	for _, s := range settings {
+		values[s.Key] = s.Value
+	}
+	return printOutput(*output, values, func(w io.Writer) {
+		for _, s := range settings {
+			fmt.Fprintf(w, "%s\t%v\n", s.Key, s.Value)
+		}
+	})
+}
@@ -2,25 +2,42 @@ package main
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"github.com/melnik-dev/go_todo_jwt/internal/attachment"
 	"github.com/melnik-dev/go_todo_jwt/internal/auth"
+	"github.com/melnik-dev/go_todo_jwt/internal/caldav"
+	"github.com/melnik-dev/go_todo_jwt/internal/calendar"
+	"github.com/melnik-dev/go_todo_jwt/internal/comment"
+	"github.com/melnik-dev/go_todo_jwt/internal/events"
+	"github.com/melnik-dev/go_todo_jwt/internal/health"
+	"github.com/melnik-dev/go_todo_jwt/internal/idempotency"
+	"github.com/melnik-dev/go_todo_jwt/internal/notification"
+	"github.com/melnik-dev/go_todo_jwt/internal/project"
+	"github.com/melnik-dev/go_todo_jwt/internal/realtime"
+	"github.com/melnik-dev/go_todo_jwt/internal/reminder"
+	"github.com/melnik-dev/go_todo_jwt/internal/share"
 	"github.com/melnik-dev/go_todo_jwt/internal/task"
+	"github.com/melnik-dev/go_todo_jwt/internal/tasksync"
 	"github.com/melnik-dev/go_todo_jwt/internal/user"
+	"github.com/melnik-dev/go_todo_jwt/internal/webhook"
+	"github.com/melnik-dev/go_todo_jwt/internal/workspace"
+	"github.com/melnik-dev/go_todo_jwt/migrations"
 	"log"
 	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
+	"sync"
 	"time"
 
-	"github.com/gin-contrib/requestid"
-	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
 	"github.com/melnik-dev/go_todo_jwt/configs"
 	"github.com/melnik-dev/go_todo_jwt/pkg/db"
 	"github.com/melnik-dev/go_todo_jwt/pkg/logger"
-	"github.com/melnik-dev/go_todo_jwt/pkg/middleware"
+	"github.com/melnik-dev/go_todo_jwt/pkg/mailer"
+	"github.com/melnik-dev/go_todo_jwt/pkg/metrics"
+	"github.com/melnik-dev/go_todo_jwt/pkg/migrate"
+	"github.com/melnik-dev/go_todo_jwt/pkg/safehttp"
+	"github.com/melnik-dev/go_todo_jwt/pkg/storage"
+	"github.com/melnik-dev/go_todo_jwt/pkg/tracing"
 	"github.com/sirupsen/logrus"
 )
 
@@ -38,75 +55,285 @@ func main() {
 	}
 	mainLogger := logger.GetLogger()
 
+	// Подкоманда из первого аргумента; без аргументов запускается сервер
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+	if command != "serve" {
+		if !commands[command] {
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(2)
+		}
+		// stdout остаётся для результата команды, в том числе --output json
+		mainLogger.SetOutput(os.Stderr)
+	}
+	if command == "config" {
+		exitOnError(runConfig(cfg, args))
+		return
+	}
+
 	mainLogger.WithFields(logrus.Fields{
 		"app_name": cfg.App.Name,
 		"env":      cfg.App.Env,
 		"version":  cfg.App.Version,
 	}).Info("Application starting")
 
+	// Хранилище в памяти заменяет только пользователей и задачи, поэтому
+	// подкоманды администрирования требуют базы, а остальные разделы API - Postgres
+	if cfg.DB.Driver == "memory" {
+		if command != "serve" {
+			exitOnError(fmt.Errorf("command %q requires db.driver postgres or sqlite", command))
+		}
+		serveMemory(cfg, mainLogger)
+		return
+	}
+
 	// Подключение к БД
-	pgDB, err := db.InitPostgres(cfg, mainLogger)
+	connect := db.InitPostgres
+	if cfg.DB.Driver == db.DriverSQLite {
+		connect = db.InitSQLite
+	}
+	database, err := connect(cfg, mainLogger)
 	if err != nil {
 		mainLogger.Fatalf("Error connecting to database: %s", err)
 	}
-	defer pgDB.Close()
+	defer database.Close()
 
-	// Настройка Gin
-	if cfg.App.Env == "production" {
-		gin.SetMode(gin.ReleaseMode)
+	// Подкоманды администрирования не запускают сервер
+	if command != "serve" {
+		err = runCommand(context.Background(), command, args, cfg, database, mainLogger)
+		database.Close()
+		exitOnError(err)
+		return
 	}
-	route := gin.New()
 
-	// Middleware
-	route.Use(gin.Recovery())
-	route.Use(requestid.New())
-	route.Use(middleware.Logger())
+	if cfg.DB.AutoMigrate {
+		if err = autoMigrate(context.Background(), database, mainLogger); err != nil {
+			mainLogger.Fatalf("Error applying migrations: %s", err)
+		}
+	}
 
-	route.GET("/ping", ping(mainLogger))
+	// В SQLite, как и в памяти, есть только пользователи и задачи
+	if cfg.DB.Driver == db.DriverSQLite {
+		serveSQLite(cfg, database, mainLogger)
+		return
+	}
+
+	// Хранилище вложений
+	fileStorage, err := storage.New(cfg.Attachments)
+	if err != nil {
+		mainLogger.Fatalf("Error initializing attachments storage: %s", err)
+	}
+
+	// Трассировка: спаны маршрутов, сервисов и SQL-запросов
+	shutdownTracing, err := tracing.Init(cfg.Tracing, cfg.App)
+	if err != nil {
+		mainLogger.Fatalf("Error initializing tracing: %s", err)
+	}
+
+	// Почта для напоминаний
+	mailSender := mailer.New(cfg.Mail, mainLogger)
+
+	// Фоновые задачи останавливаются при завершении работы; background ждёт их выхода
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	var background sync.WaitGroup
+
+	// Настройка Gin
+	route := newRouter(cfg, mainLogger)
+
+	// Метрики: запросы считаются по шаблону маршрута, запросы к базе - по методу репозитория
+	var appMetrics *metrics.Metrics
+	if cfg.Metrics.Enabled {
+		appMetrics = metrics.New(cfg.App)
+		appMetrics.RegisterDB(database.DB.DB, cfg.DB.DBName)
+		database.SetObserver(appMetrics)
+		route.Use(appMetrics.Middleware())
+	}
 
 	// Repositories
-	userRepo := user.NewRepository(pgDB, mainLogger)
-	taskRepo := task.NewRepository(pgDB, mainLogger)
+	userRepo := user.NewRepository(database, mainLogger)
+	taskRepo := task.NewRepository(database, mainLogger)
+	idempotencyRepo := idempotency.NewRepository(database, mainLogger)
+	calendarRepo := calendar.NewRepository(database, mainLogger)
+	projectRepo := project.NewRepository(database, mainLogger)
+	shareRepo := share.NewRepository(database, mainLogger)
+	workspaceRepo := workspace.NewRepository(database, mainLogger)
+	commentRepo := comment.NewRepository(database, mainLogger)
+	attachmentRepo := attachment.NewRepository(database, mainLogger)
+	notificationRepo := notification.NewRepository(database, mainLogger)
+	reminderRepo := reminder.NewRepository(database, mainLogger)
+	webhookRepo := webhook.NewRepository(database, mainLogger)
+	eventsRepo := events.NewRepository(database, mainLogger)
+	realtimeRepo := realtime.NewRepository(database, mainLogger)
+	syncRepo := tasksync.NewRepository(database, mainLogger)
 
 	// Services
+	notificationService := notification.NewService(notificationRepo, mainLogger)
 	authService := auth.NewService(userRepo, mainLogger)
-	taskService := task.NewService(taskRepo, mainLogger)
+	taskService := task.NewService(taskRepo, notificationService, mainLogger)
+	calendarService := calendar.NewService(calendarRepo, taskRepo, mainLogger)
+	projectService := project.NewService(projectRepo, mainLogger)
+	shareService := share.NewService(shareRepo, userRepo, notificationService, mainLogger)
+	workspaceService := workspace.NewService(workspaceRepo, userRepo, mainLogger)
+	commentService := comment.NewService(commentRepo, notificationService, mainLogger)
+	attachmentService := attachment.NewService(attachmentRepo, fileStorage, cfg.Attachments, mainLogger)
+	reminderService := reminder.NewService(reminderRepo, mainLogger)
+	webhookService := webhook.NewService(webhookRepo, mainLogger)
+	realtimeService := realtime.NewService(realtimeRepo, taskService, mainLogger)
+	syncService := tasksync.NewService(syncRepo, taskService, mainLogger)
+
+	// Пользователь и его проект по умолчанию создаются в одной транзакции
+	authService.OnRegister(db.NewTxManager(database), defaultProject(workspaceRepo, projectRepo))
+
+	// Background
+	cleaner := attachment.NewCleaner(attachmentRepo, fileStorage, cfg.Attachments.CleanupInterval, mainLogger)
+	idempotencyCleaner := idempotency.NewCleaner(idempotencyRepo, cfg.Idempotency.CleanupInterval, mainLogger)
+	reminderWorker := reminder.NewWorker(reminderRepo,
+		reminder.NewChannels(cfg.Reminders.Channels, notificationService, mailSender, safehttp.NewClient(10*time.Second)), cfg.Reminders, mainLogger)
+	webhookDispatcher := webhook.NewDispatcher(webhookRepo, safehttp.NewClient(0), cfg.Webhooks, mainLogger)
+	// остановка listener закрывает broker и вместе с ним открытые потоки /events и /ws
+	eventsBroker := events.NewBroker(cfg.Events.Buffer)
+	eventsListener := events.NewListener(eventsRepo, eventsBroker, db.DSN(cfg), mainLogger)
+	workers := health.NewWorkers()
+	for name, run := range map[string]func(context.Context){
+		"attachments_cleaner": cleaner.Run,
+		"idempotency_cleaner": idempotencyCleaner.Run,
+		"reminders":           reminderWorker.Run,
+		"webhooks":            webhookDispatcher.Run,
+		"events_listener":     eventsListener.Run,
+	} {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			workers.Run(bgCtx, name, run)
+		}()
+	}
+
+	// Проверки готовности /readyz
+	migrator, err := migrate.New(database.DB, migrations.FS, mainLogger)
+	if err != nil {
+		mainLogger.Fatalf("Error loading migrations: %s", err)
+	}
+	checker := health.NewChecker(cfg.Health.Timeout)
+	checker.Register("postgres", database.PingContext)
+	checker.Register("migrations", migrator.Check)
+	checker.Register("workers", workers.Check)
+
+	// Middleware
+	workspaceMiddleware := workspace.Middleware(workspaceService)
 
 	// Handlers
-	auth.NewHandler(route, &auth.HandlerDeps{
+	health.NewHandler(route, &health.HandlerDeps{
+		Checker: checker,
+	})
+	authDeps := &auth.HandlerDeps{
 		AuthService: authService,
 		Config:      cfg,
-	})
+	}
+	if appMetrics != nil {
+		authDeps.Metrics = appMetrics
+	}
+	auth.NewHandler(route, authDeps)
 	task.NewHandler(route, &task.HandlerDeps{
 		TaskService: taskService,
 		Config:      cfg,
+		Idempotency: idempotency.Middleware(idempotencyRepo, cfg.Idempotency.TTL, cfg.Idempotency.MaxBodySize),
+		Workspace:   workspaceMiddleware,
+	})
+	tasksync.NewHandler(route, &tasksync.HandlerDeps{
+		SyncService: syncService,
+		Config:      cfg,
+		Workspace:   workspaceMiddleware,
+	})
+	calendar.NewHandler(route, &calendar.HandlerDeps{
+		CalendarService:  calendarService,
+		WorkspaceService: workspaceService,
+		Config:           cfg,
+		Workspace:        workspaceMiddleware,
+	})
+	project.NewHandler(route, &project.HandlerDeps{
+		ProjectService: projectService,
+		Config:         cfg,
+		Workspace:      workspaceMiddleware,
+	})
+	share.NewHandler(route, &share.HandlerDeps{
+		ShareService: shareService,
+		Config:       cfg,
+	})
+	caldav.NewHandler(route, &caldav.HandlerDeps{
+		TaskService: taskService,
+		AuthService: authService,
+		Config:      cfg,
+		Workspace:   workspaceMiddleware,
+	})
+	comment.NewHandler(route, &comment.HandlerDeps{
+		CommentService: commentService,
+		Config:         cfg,
+		Workspace:      workspaceMiddleware,
+	})
+	attachment.NewHandler(route, &attachment.HandlerDeps{
+		AttachmentService: attachmentService,
+		Config:            cfg,
+		Workspace:         workspaceMiddleware,
+	})
+	reminder.NewHandler(route, &reminder.HandlerDeps{
+		ReminderService: reminderService,
+		Config:          cfg,
+		Workspace:       workspaceMiddleware,
+	})
+	events.NewHandler(route, &events.HandlerDeps{
+		Broker:    eventsBroker,
+		Config:    cfg,
+		Workspace: workspaceMiddleware,
+	})
+	realtime.NewHandler(route, &realtime.HandlerDeps{
+		RealtimeService: realtimeService,
+		Hub:             realtime.NewHub(),
+		Broker:          eventsBroker,
+		Config:          cfg,
+		Workspace:       workspaceMiddleware,
+	})
+	webhook.NewHandler(route, &webhook.HandlerDeps{
+		WebhookService: webhookService,
+		Config:         cfg,
+		Workspace:      workspaceMiddleware,
+	})
+	notification.NewHandler(route, &notification.HandlerDeps{
+		NotificationService: notificationService,
+		Config:              cfg,
+	})
+	workspace.NewHandler(route, &workspace.HandlerDeps{
+		WorkspaceService: workspaceService,
+		Config:           cfg,
 	})
-
-	// Настройка HTTP сервера
-	serverAddress := fmt.Sprintf("%s:%s", cfg.HTTP.Host, cfg.HTTP.Port)
-	srv := &http.Server{
-		Addr:           serverAddress,
-		Handler:        route,
-		ReadTimeout:    cfg.HTTP.ReadTimeout,
-		WriteTimeout:   cfg.HTTP.WriteTimeout,
-		IdleTimeout:    cfg.HTTP.IdleTimeout,
-		MaxHeaderBytes: 1 << 20, // 1 MB
-	}
 
 	// Запуск сервера
-	go func() {
-		mainLogger.WithField("address", serverAddress).Info("Server starting")
-		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			mainLogger.Fatalf("Server failed: %v", err)
+	srv := newServer(cfg, route)
+	listen(srv, "Server", mainLogger)
+
+	// Метрики слушают отдельный адрес, чтобы не открывать их вместе с API
+	var metricsSrv *http.Server
+	if appMetrics != nil {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle(cfg.Metrics.Path, appMetrics.Handler(cfg.Metrics.Token))
+		metricsSrv = &http.Server{
+			Addr:              fmt.Sprintf("%s:%s", cfg.Metrics.Host, cfg.Metrics.Port),
+			Handler:           metricsMux,
+			ReadHeaderTimeout: cfg.HTTP.ReadTimeout,
 		}
-	}()
+		listen(metricsSrv, "Metrics server", mainLogger)
+	}
 
 	// Graceful shutdown
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	waitForSignal()
 
 	mainLogger.Info("Shutting down server...")
+	// Сначала /readyz отвечает 503, чтобы балансировщик успел убрать экземпляр
+	checker.Shutdown()
+	time.Sleep(cfg.Health.ShutdownDelay)
+	stopBackground()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -114,17 +341,49 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		mainLogger.Fatalf("Server forced to shutdown: %v", err)
 	}
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(ctx); err != nil {
+			mainLogger.WithError(err).Warn("Metrics server forced to shutdown")
+		}
+	}
+
+	// Дожидаемся фоновых задач, чтобы не оборвать начатую отправку напоминаний
+	stopped := make(chan struct{})
+	go func() {
+		background.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		mainLogger.Warn("Background workers did not stop in time")
+	}
+
+	// Последние спаны отправляются после остановки сервера и фоновых задач
+	if err := shutdownTracing(ctx); err != nil {
+		mainLogger.WithError(err).Warn("Failed to flush traces")
+	}
 
 	mainLogger.Info("Server stopped gracefully")
 }
 
-func ping(logger *logrus.Logger) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		logger.Info("Ping endpoint called")
+// exitOnError завершает подкоманду с кодом 1, если она вернула ошибку
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
 
-		c.JSON(http.StatusOK, gin.H{
-			"message":   "pong",
-			"timestamp": time.Now(),
-		})
+// defaultProject создаёт проект Inbox в личном пространстве нового пользователя.
+// Пространство создаёт триггер на users, поэтому оно видно в той же транзакции.
+func defaultProject(workspaces *workspace.Repository, projects *project.Repository) auth.RegisterHook {
+	return func(ctx context.Context, u *user.User) error {
+		workspaceID, err := workspaces.Personal(ctx, u.ID)
+		if err != nil {
+			return err
+		}
+		_, err = projects.Create(db.WithWorkspace(ctx, workspaceID), &project.Project{UserID: u.ID, Name: "Inbox"})
+		return err
 	}
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"time"
+
+	"github.com/melnik-dev/go_todo_jwt/migrations"
+	sqlitemigrations "github.com/melnik-dev/go_todo_jwt/migrations/sqlite"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/melnik-dev/go_todo_jwt/pkg/migrate"
+	"github.com/sirupsen/logrus"
+)
+
+type migrateOutput struct {
+	Count   int   `json:"count"`
+	Version int64 `json:"version"`
+}
+
+// runMigrate выполняет migrate up|down [N]|status|goto VERSION
+func runMigrate(ctx context.Context, database *db.Db, logger *logrus.Logger, args []string) error {
+	flags, output := newFlagSet("migrate")
+	positional, err := parseFlags(flags, output, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return errUsage
+	}
+
+	migrator, err := migrate.New(database.DB, migrationsFS(database), logger)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	switch positional[0] {
+	case "up":
+		count, err = migrator.Up(ctx)
+	case "down":
+		steps := 1
+		if len(positional) > 1 {
+			if steps, err = strconv.Atoi(positional[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", positional[1])
+			}
+		}
+		count, err = migrator.Down(ctx, steps)
+	case "goto":
+		if len(positional) != 2 {
+			return errUsage
+		}
+		version, parseErr := strconv.ParseInt(positional[1], 10, 64)
+		if parseErr != nil || version < 0 {
+			return fmt.Errorf("invalid version %q", positional[1])
+		}
+		count, err = migrator.Goto(ctx, version)
+	case "status":
+		return printMigrationStatus(ctx, migrator, *output)
+	default:
+		return errUsage
+	}
+	if err != nil {
+		return err
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+	result := migrateOutput{Count: count}
+	for _, s := range statuses {
+		if s.State != migrate.StatePending {
+			result.Version = s.Version
+		}
+	}
+	return printOutput(*output, result, func(w io.Writer) {
+		fmt.Fprintf(w, "%d migration(s) run, schema version %d\n", result.Count, result.Version)
+	})
+}
+
+func printMigrationStatus(ctx context.Context, migrator *migrate.Migrator, output string) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	return printOutput(output, statuses, func(w io.Writer) {
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "-"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, s.State, appliedAt)
+		}
+	})
+}
+
+// autoMigrate применяет новые миграции при запуске сервера
+func autoMigrate(ctx context.Context, database *db.Db, logger *logrus.Logger) error {
+	migrator, err := migrate.New(database.DB, migrationsFS(database), logger)
+	if err != nil {
+		return err
+	}
+	count, err := migrator.Up(ctx)
+	if err != nil {
+		return err
+	}
+	logger.WithField("applied", count).Info("Database migrations are up to date")
+	return nil
+}
+
+// migrationsFS - набор миграций для драйвера базы: у SQLite своя схема
+func migrationsFS(database *db.Db) fs.FS {
+	if database.DriverName() == db.DriverSQLite {
+		return sqlitemigrations.FS
+	}
+	return migrations.FS
+}
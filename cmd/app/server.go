@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-contrib/requestid"
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/internal/caldav"
+	"github.com/melnik-dev/go_todo_jwt/pkg/middleware"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// longRunning - маршруты без срока запроса: потоки событий и передача больших
+// данных (экспорт, импорт, календари, CalDAV, вложения) длятся дольше любого запроса
+var longRunning = []string{
+	"/events",
+	"/ws",
+	"/task/export",
+	"/task/import",
+	"/calendar/feed/:token",
+	"/calendar/import",
+	caldav.BasePath + "/*path",
+	"/task/:id/attachments/",
+	"/attachments/:id/download",
+}
+
+// newRouter создаёт gin с общими middleware и /ping
+func newRouter(cfg *configs.Config, logger *logrus.Logger) *gin.Engine {
+	if cfg.App.Env == "production" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	route := gin.New()
+
+	route.Use(gin.Recovery())
+	route.Use(requestid.New())
+	route.Use(otelgin.Middleware(cfg.App.Name))
+	route.Use(middleware.Logger())
+	route.Use(middleware.Timeout(cfg.DB.RequestTimeout, longRunning...))
+
+	route.GET("/ping", ping(logger))
+	return route
+}
+
+// newServer настраивает HTTP-сервер API
+func newServer(cfg *configs.Config, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:           fmt.Sprintf("%s:%s", cfg.HTTP.Host, cfg.HTTP.Port),
+		Handler:        handler,
+		ReadTimeout:    cfg.HTTP.ReadTimeout,
+		WriteTimeout:   cfg.HTTP.WriteTimeout,
+		IdleTimeout:    cfg.HTTP.IdleTimeout,
+		MaxHeaderBytes: 1 << 20, // 1 MB
+	}
+}
+
+// listen запускает сервер в фоне; ошибка запуска завершает приложение
+func listen(srv *http.Server, name string, logger *logrus.Logger) {
+	go func() {
+		logger.WithField("address", srv.Addr).Info(name + " starting")
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatalf("%s failed: %v", name, err)
+		}
+	}()
+}
+
+// waitForSignal блокируется до SIGINT или SIGTERM
+func waitForSignal() {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+}
+
+func ping(logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger.Info("Ping endpoint called")
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":   "pong",
+			"timestamp": time.Now(),
+		})
+	}
+}
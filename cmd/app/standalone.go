@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/internal/auth"
+	"github.com/melnik-dev/go_todo_jwt/internal/health"
+	"github.com/melnik-dev/go_todo_jwt/internal/notification"
+	"github.com/melnik-dev/go_todo_jwt/internal/task"
+	"github.com/melnik-dev/go_todo_jwt/internal/user"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/melnik-dev/go_todo_jwt/pkg/middleware"
+	"github.com/melnik-dev/go_todo_jwt/pkg/migrate"
+	"github.com/sirupsen/logrus"
+)
+
+// serveMemory запускает сервер с хранилищем в памяти (db.driver: memory)
+func serveMemory(cfg *configs.Config, logger *logrus.Logger) {
+	userRepo := user.NewMemoryRepository()
+	taskRepo := task.NewMemoryRepository(userRepo)
+
+	logger.Warn("Using in-memory storage, data is not persisted")
+	serveStandalone(cfg, userRepo, taskRepo, health.NewChecker(cfg.Health.Timeout), logger)
+}
+
+// serveSQLite запускает сервер с базой SQLite (db.driver: sqlite)
+func serveSQLite(cfg *configs.Config, database *db.Db, logger *logrus.Logger) {
+	migrator, err := migrate.New(database.DB, migrationsFS(database), logger)
+	if err != nil {
+		logger.Fatalf("Error loading migrations: %s", err)
+	}
+	checker := health.NewChecker(cfg.Health.Timeout)
+	checker.Register("sqlite", database.PingContext)
+	checker.Register("migrations", migrator.Check)
+
+	serveStandalone(cfg, user.NewSQLiteRepository(database, logger), task.NewSQLiteRepository(database, logger), checker, logger)
+}
+
+// serveStandalone запускает сервер без Postgres: доступны регистрация, вход
+// и задачи, остальные разделы API требуют Postgres
+func serveStandalone(cfg *configs.Config, userRepo user.IRepository, taskRepo task.IRepository, checker *health.Checker, logger *logrus.Logger) {
+	route := newRouter(cfg, logger)
+
+	// Services
+	authService := auth.NewService(userRepo, logger)
+	taskService := task.NewService(taskRepo, notification.NewLogNotifier(logger), logger)
+
+	// Handlers
+	health.NewHandler(route, &health.HandlerDeps{
+		Checker: checker,
+	})
+	auth.NewHandler(route, &auth.HandlerDeps{
+		AuthService: authService,
+		Config:      cfg,
+	})
+	task.NewHandler(route, &task.HandlerDeps{
+		TaskService: taskService,
+		Config:      cfg,
+		// ключи идемпотентности хранятся в Postgres; без них запросы не дедуплицируются
+		Idempotency: func(c *gin.Context) { c.Next() },
+		Workspace:   personalWorkspace,
+	})
+
+	logger.WithField("driver", cfg.DB.Driver).Warn("Only auth and task routes are served, the rest of the API requires postgres")
+	srv := newServer(cfg, route)
+	listen(srv, "Server", logger)
+
+	waitForSignal()
+
+	logger.Info("Shutting down server...")
+	checker.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Fatalf("Server forced to shutdown: %v", err)
+	}
+	logger.Info("Server stopped gracefully")
+}
+
+// personalWorkspace заменяет workspace.Middleware: рабочих пространств в памяти
+// и в SQLite нет, и личным пространством пользователя считается его id
+func personalWorkspace(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.Abort()
+		return
+	}
+
+	c.Set("workspace_id", userID)
+	c.Request = c.Request.WithContext(db.WithWorkspace(c.Request.Context(), userID))
+	c.Next()
+}
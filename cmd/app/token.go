@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/internal/auth"
+	"github.com/melnik-dev/go_todo_jwt/pkg/jwt"
+)
+
+type tokenOutput struct {
+	Token       string    `json:"token"`
+	UserID      int       `json:"user_id"`
+	WorkspaceID int       `json:"workspace_id,omitempty"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// runToken выполняет token issue: выпускает JWT пользователя, как при входе,
+// без его пароля. Для отладки; токен отключённому пользователю не выдаётся
+func runToken(ctx context.Context, cfg *configs.Config, authService auth.IService, args []string) error {
+	fs, output := newFlagSet("token")
+	ttl := fs.Duration("ttl", cfg.JWT.TokenTTL, "token lifetime")
+	workspaceID := fs.Int("workspace", 0, "bind the token to a workspace")
+	positional, err := parseFlags(fs, output, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 || positional[0] != "issue" {
+		return errUsage
+	}
+	if *ttl <= 0 {
+		return errors.New("ttl must be positive")
+	}
+
+	u, err := authService.GetUser(ctx, positional[1])
+	if err != nil {
+		return err
+	}
+	if u.DisabledAt != nil {
+		return auth.ErrUserDisabled
+	}
+
+	result := tokenOutput{UserID: u.ID, WorkspaceID: *workspaceID, ExpiresAt: time.Now().Add(*ttl).UTC()}
+	result.Token, err = jwt.NewJWT(cfg.JWT.Secret).Create(jwt.Data{
+		UserId:      u.ID,
+		WorkspaceID: *workspaceID,
+		TokenTTL:    *ttl,
+	})
+	if err != nil {
+		return err
+	}
+
+	// в текстовом виде только токен, чтобы его было удобно подставлять: $(app token issue alice)
+	return printOutput(*output, result, func(w io.Writer) {
+		fmt.Fprintln(w, result.Token)
+	})
+}
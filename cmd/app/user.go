@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/melnik-dev/go_todo_jwt/internal/auth"
+	"github.com/melnik-dev/go_todo_jwt/internal/user"
+)
+
+// userOutput - пользователь в выводе CLI, без хеша пароля
+type userOutput struct {
+	ID         int        `json:"id"`
+	Username   string     `json:"username"`
+	Disabled   bool       `json:"disabled"`
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+}
+
+func newUserOutput(u *user.User) userOutput {
+	return userOutput{ID: u.ID, Username: u.Name, Disabled: u.DisabledAt != nil, DisabledAt: u.DisabledAt}
+}
+
+// runUser выполняет user create|list|disable|enable|reset-password
+func runUser(ctx context.Context, authService auth.IService, args []string) error {
+	fs, output := newFlagSet("user")
+	password := fs.String("password", "", "password; read from stdin when empty")
+	positional, err := parseFlags(fs, output, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return errUsage
+	}
+
+	if positional[0] == "list" {
+		users, err := authService.ListUsers(ctx)
+		if err != nil {
+			return err
+		}
+		result := make([]userOutput, 0, len(users))
+		for i := range users {
+			result = append(result, newUserOutput(&users[i]))
+		}
+		return printUsers(*output, result, result)
+	}
+
+	if len(positional) != 2 {
+		return errUsage
+	}
+	username := positional[1]
+
+	switch positional[0] {
+	case "create":
+		if *password, err = readPassword(*password); err != nil {
+			return err
+		}
+		// те же ограничения, что и при регистрации через API
+		if err = binding.Validator.ValidateStruct(auth.RegisterRequest{Name: username, Password: *password}); err != nil {
+			return err
+		}
+		_, err = authService.Register(ctx, username, *password)
+	case "disable", "enable":
+		err = authService.SetUserDisabled(ctx, username, positional[0] == "disable")
+	case "reset-password":
+		if *password, err = readPassword(*password); err != nil {
+			return err
+		}
+		if err = binding.Validator.ValidateStruct(auth.RegisterRequest{Name: username, Password: *password}); err != nil {
+			return err
+		}
+		err = authService.ResetPassword(ctx, username, *password)
+	default:
+		return errUsage
+	}
+	if err != nil {
+		return err
+	}
+
+	u, err := authService.GetUser(ctx, username)
+	if err != nil {
+		return err
+	}
+	result := newUserOutput(u)
+	return printUsers(*output, result, []userOutput{result})
+}
+
+func printUsers(output string, v any, users []userOutput) error {
+	return printOutput(output, v, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tUSERNAME\tSTATUS\tDISABLED AT")
+		for _, u := range users {
+			status, disabledAt := "active", "-"
+			if u.DisabledAt != nil {
+				status, disabledAt = "disabled", u.DisabledAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", u.ID, u.Username, status, disabledAt)
+		}
+	})
+}
@@ -16,6 +16,17 @@ type Config struct {
 	DB   ConfDB   `mapstructure:"db"`
 	JWT  ConfJWT  `mapstructure:"jwt"`
 	Log  ConfLog  `mapstructure:"log"`
+
+	Idempotency ConfIdempotency `mapstructure:"idempotency"`
+	Attachments ConfAttachments `mapstructure:"attachments"`
+	Reminders   ConfReminders   `mapstructure:"reminders"`
+	Mail        ConfMail        `mapstructure:"mail"`
+	Webhooks    ConfWebhooks    `mapstructure:"webhooks"`
+	Events      ConfEvents      `mapstructure:"events"`
+	Realtime    ConfRealtime    `mapstructure:"realtime"`
+	Health      ConfHealth      `mapstructure:"health"`
+	Metrics     ConfMetrics     `mapstructure:"metrics"`
+	Tracing     ConfTracing     `mapstructure:"tracing"`
 }
 
 type ConfApp struct {
@@ -33,12 +44,23 @@ type ConfHTTP struct {
 }
 
 type ConfDB struct {
+	// Driver - хранилище данных: postgres (по умолчанию), sqlite или memory.
+	// SQLite и память хранят только пользователей и задачи; в памяти данные
+	// теряются при остановке
+	Driver string `mapstructure:"driver"`
+	// Path - файл базы для драйвера sqlite
+	Path     string `mapstructure:"path"`
 	Host     string `mapstructure:"host"`
 	Port     string `mapstructure:"port"`
 	Username string `mapstructure:"username"`
 	DBName   string `mapstructure:"dbname"`
 	SSLMode  string `mapstructure:"sslmode"`
 	Password string `mapstructure:"password"`
+	// AutoMigrate применяет новые миграции при запуске сервера
+	AutoMigrate bool `mapstructure:"autoMigrate"`
+	// RequestTimeout ограничивает время HTTP-запроса вместе с его запросами к базе;
+	// 0 - без ограничения. На потоки /events и /ws не действует
+	RequestTimeout time.Duration `mapstructure:"requestTimeout"`
 }
 
 type ConfJWT struct {
@@ -46,6 +68,128 @@ type ConfJWT struct {
 	TokenTTL time.Duration `mapstructure:"tokenTTL"`
 }
 
+type ConfIdempotency struct {
+	TTL time.Duration `mapstructure:"ttl"`
+	// MaxBodySize - наибольшее тело запроса с Idempotency-Key, байт
+	MaxBodySize int64 `mapstructure:"maxBodySize"`
+	// Как часто удалять ключи с истёкшим ttl
+	CleanupInterval time.Duration `mapstructure:"cleanupInterval"`
+}
+
+type ConfAttachments struct {
+	// Хранилище файлов: local или s3
+	Storage string        `mapstructure:"storage"`
+	Dir     string        `mapstructure:"dir"`
+	S3      ConfS3        `mapstructure:"s3"`
+	MaxSize int64         `mapstructure:"maxSize"` // байт на файл
+	Quota   int64         `mapstructure:"quota"`   // байт на пользователя
+	Allowed []string      `mapstructure:"allowed"` // разрешённые MIME-типы, допускается image/*
+	URLTTL  time.Duration `mapstructure:"urlTTL"`
+	// Ключ подписи ссылок на скачивание; по умолчанию jwt.secret
+	SigningKey string `mapstructure:"signingKey"`
+	// Как часто удалять из хранилища файлы удалённых вложений
+	CleanupInterval time.Duration `mapstructure:"cleanupInterval"`
+}
+
+type ConfS3 struct {
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"accessKey"`
+	SecretKey string `mapstructure:"secretKey"`
+}
+
+type ConfReminders struct {
+	// Как часто проверять наступившие напоминания
+	Interval time.Duration `mapstructure:"interval"`
+	// Сколько напоминаний обрабатывается за одну транзакцию
+	Batch int `mapstructure:"batch"`
+	// Пропущенные дольше grace напоминания (например, пока сервис не работал) не отправляются
+	Grace time.Duration `mapstructure:"grace"`
+	// Каналы доставки: in_app, email, webhook
+	Channels []string `mapstructure:"channels"`
+}
+
+type ConfWebhooks struct {
+	// Как часто разбирать outbox событий и очередь доставок
+	Interval time.Duration `mapstructure:"interval"`
+	// Сколько событий или доставок обрабатывается за один проход
+	Batch int `mapstructure:"batch"`
+	// Таймаут одного запроса к получателю
+	Timeout time.Duration `mapstructure:"timeout"`
+	// После стольких неудачных попыток доставка считается проваленной
+	MaxAttempts int `mapstructure:"maxAttempts"`
+	// Пауза перед повтором удваивается с каждой попыткой, начиная с backoff, но не больше maxBackoff
+	Backoff    time.Duration `mapstructure:"backoff"`
+	MaxBackoff time.Duration `mapstructure:"maxBackoff"`
+}
+
+// ConfEvents - поток событий задач GET /events
+type ConfEvents struct {
+	// Сколько последних событий хранится для продолжения по Last-Event-ID
+	Buffer int `mapstructure:"buffer"`
+	// Как часто отправлять комментарий-пинг, чтобы прокси не закрывали соединение
+	Heartbeat time.Duration `mapstructure:"heartbeat"`
+}
+
+// ConfRealtime - WebSocket GET /ws для совместного редактирования списков
+type ConfRealtime struct {
+	// Origin страниц, которым можно подключаться; пусто - только тот же хост
+	Origins []string `mapstructure:"origins"`
+	// Как часто пинговать клиента; без pong за pongWait соединение закрывается
+	PingInterval time.Duration `mapstructure:"pingInterval"`
+	PongWait     time.Duration `mapstructure:"pongWait"`
+	// Таймаут записи одного сообщения клиенту
+	WriteTimeout time.Duration `mapstructure:"writeTimeout"`
+	// Сколько сообщений может ждать отправки; клиент, который не успевает их читать, отключается
+	SendBuffer int `mapstructure:"sendBuffer"`
+	// Максимальный размер входящего сообщения в байтах
+	MaxMessage int64 `mapstructure:"maxMessage"`
+	// Сколько сообщений в секунду принимается от одного соединения и допустимый всплеск
+	Rate  float64 `mapstructure:"rate"`
+	Burst int     `mapstructure:"burst"`
+}
+
+// ConfHealth - проверки GET /readyz
+type ConfHealth struct {
+	// Таймаут одной проверки; не уложившаяся проверка считается проваленной
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Сколько отвечать not ready перед остановкой сервера, чтобы балансировщик
+	// успел убрать экземпляр; должно быть больше периода проверки готовности
+	ShutdownDelay time.Duration `mapstructure:"shutdownDelay"`
+}
+
+// ConfMetrics - метрики Prometheus на отдельном от API адресе
+type ConfMetrics struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Host    string `mapstructure:"host"`
+	Port    string `mapstructure:"port"`
+	Path    string `mapstructure:"path"`
+	// Токен для Authorization: Bearer; пусто - без авторизации
+	Token string `mapstructure:"token"`
+}
+
+// ConfTracing - трассировка OpenTelemetry
+type ConfTracing struct {
+	// Куда отправлять спаны: none, otlp, stdout или file
+	Exporter string `mapstructure:"exporter"`
+	// URL приёмника OTLP/HTTP, например http://localhost:4318; пусто - из OTEL_EXPORTER_OTLP_ENDPOINT
+	Endpoint string `mapstructure:"endpoint"`
+	// Файл для exporter file
+	FilePath string `mapstructure:"filePath"`
+	// Доля трассируемых запросов, по умолчанию 1; для входящего traceparent решает вызывающий
+	SampleRatio float64 `mapstructure:"sampleRatio"`
+}
+
+// ConfMail - SMTP для писем; без host письма только пишутся в лог
+type ConfMail struct {
+	Host     string `mapstructure:"host"`
+	Port     string `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+}
+
 type ConfLog struct {
 	Level      string `mapstructure:"level"`
 	Format     string `mapstructure:"format"`
@@ -76,22 +220,49 @@ func LoadConfig() (*Config, error) {
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
 	envBindings := map[string]string{
-		"app.name":     "APP_NAME",
-		"app.env":      "APP_ENV",
-		"app.version":  "APP_VERSION",
-		"http.host":    "HTTP_HOST",
-		"http.port":    "HTTP_PORT",
-		"db.host":      "DB_HOST",
-		"db.port":      "DB_PORT",
-		"db.username":  "DB_USERNAME",
-		"db.password":  "DB_PASSWORD",
-		"db.dbname":    "DB_NAME",
-		"db.sslmode":   "DB_SSLMODE",
-		"jwt.secret":   "JWT_SECRET",
-		"log.level":    "LOG_LEVEL",
-		"log.format":   "LOG_FORMAT",
-		"log.output":   "LOG_OUTPUT",
-		"log.filepath": "LOG_FILE_PATH",
+		"app.name":          "APP_NAME",
+		"app.env":           "APP_ENV",
+		"app.version":       "APP_VERSION",
+		"http.host":         "HTTP_HOST",
+		"http.port":         "HTTP_PORT",
+		"db.driver":         "DB_DRIVER",
+		"db.path":           "DB_PATH",
+		"db.host":           "DB_HOST",
+		"db.port":           "DB_PORT",
+		"db.username":       "DB_USERNAME",
+		"db.password":       "DB_PASSWORD",
+		"db.dbname":         "DB_NAME",
+		"db.sslmode":        "DB_SSLMODE",
+		"db.autoMigrate":    "DB_AUTO_MIGRATE",
+		"db.requestTimeout": "DB_REQUEST_TIMEOUT",
+		"jwt.secret":        "JWT_SECRET",
+		"log.level":         "LOG_LEVEL",
+		"log.format":        "LOG_FORMAT",
+		"log.output":        "LOG_OUTPUT",
+		"log.filepath":      "LOG_FILE_PATH",
+
+		"attachments.storage":      "ATTACHMENTS_STORAGE",
+		"attachments.dir":          "ATTACHMENTS_DIR",
+		"attachments.signingKey":   "ATTACHMENTS_SIGNING_KEY",
+		"attachments.s3.endpoint":  "S3_ENDPOINT",
+		"attachments.s3.region":    "S3_REGION",
+		"attachments.s3.bucket":    "S3_BUCKET",
+		"attachments.s3.accessKey": "S3_ACCESS_KEY",
+		"attachments.s3.secretKey": "S3_SECRET_KEY",
+
+		"mail.host":     "SMTP_HOST",
+		"mail.port":     "SMTP_PORT",
+		"mail.username": "SMTP_USERNAME",
+		"mail.password": "SMTP_PASSWORD",
+		"mail.from":     "SMTP_FROM",
+
+		"metrics.enabled": "METRICS_ENABLED",
+		"metrics.host":    "METRICS_HOST",
+		"metrics.port":    "METRICS_PORT",
+		"metrics.token":   "METRICS_TOKEN",
+
+		"tracing.exporter": "TRACING_EXPORTER",
+		"tracing.endpoint": "TRACING_ENDPOINT",
 	}
 
 	for configKey, envKey := range envBindings {
@@ -124,24 +295,67 @@ func validateConfig(cfg *Config) error {
 		errors = append(errors, "HTTP_PORT environment variable not set")
 	}
 
-	if cfg.DB.Host == "" {
-		errors = append(errors, "DB_HOST environment variable not set")
+	switch cfg.DB.Driver {
+	case "", "postgres":
+		if cfg.DB.Host == "" {
+			errors = append(errors, "DB_HOST environment variable not set")
+		}
+		if cfg.DB.Port == "" {
+			errors = append(errors, "DB_PORT environment variable not set")
+		}
+		if cfg.DB.Username == "" {
+			errors = append(errors, "DB_USERNAME environment variable not set")
+		}
+		if cfg.DB.Password == "" {
+			errors = append(errors, "DB_PASSWORD environment variable not set")
+		}
+		if cfg.DB.DBName == "" {
+			errors = append(errors, "DB_NAME environment variable not set")
+		}
+	case "sqlite":
+		if cfg.DB.Path == "" {
+			errors = append(errors, "DB_PATH environment variable not set")
+		}
+	case "memory":
+	default:
+		errors = append(errors, fmt.Sprintf("unknown db driver %q", cfg.DB.Driver))
+	}
+
+	if cfg.DB.RequestTimeout < 0 {
+		errors = append(errors, "db.requestTimeout must not be negative")
 	}
-	if cfg.DB.Port == "" {
-		errors = append(errors, "DB_PORT environment variable not set")
+
+	if cfg.JWT.Secret == "" {
+		errors = append(errors, "JWT_SECRET environment variable not set")
 	}
-	if cfg.DB.Username == "" {
-		errors = append(errors, "DB_USERNAME environment variable not set")
+
+	switch cfg.Attachments.Storage {
+	case "", "local":
+	case "s3":
+		if cfg.Attachments.S3.Endpoint == "" || cfg.Attachments.S3.Bucket == "" {
+			errors = append(errors, "S3_ENDPOINT and S3_BUCKET must be set for s3 attachments storage")
+		}
+	default:
+		errors = append(errors, fmt.Sprintf("unknown attachments storage %q", cfg.Attachments.Storage))
 	}
-	if cfg.DB.Password == "" {
-		errors = append(errors, "DB_PASSWORD environment variable not set")
+
+	switch cfg.Tracing.Exporter {
+	case "", "none", "otlp", "stdout":
+	case "file":
+		if cfg.Tracing.FilePath == "" {
+			errors = append(errors, "tracing.filePath must be set for file tracing exporter")
+		}
+	default:
+		errors = append(errors, fmt.Sprintf("unknown tracing exporter %q", cfg.Tracing.Exporter))
 	}
-	if cfg.DB.DBName == "" {
-		errors = append(errors, "DB_NAME environment variable not set")
+	if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+		errors = append(errors, "tracing.sampleRatio must be between 0 and 1")
 	}
 
-	if cfg.JWT.Secret == "" {
-		errors = append(errors, "JWT_SECRET environment variable not set")
+	for _, channel := range cfg.Reminders.Channels {
+		if channel != "in_app" && channel != "email" && channel != "webhook" {
+			errors = append(errors, fmt.Sprintf("unknown reminders channel %q", channel))
+		}
 	}
 
 	if len(errors) > 0 {
@@ -159,10 +373,134 @@ func setDefaults(cfg *Config) {
 		cfg.App.Env = "development"
 	}
 
+	if cfg.DB.Driver == "" {
+		cfg.DB.Driver = "postgres"
+	}
+
 	if cfg.JWT.TokenTTL == 0 {
 		cfg.JWT.TokenTTL = time.Hour
 	}
 
+	if cfg.Idempotency.TTL == 0 {
+		cfg.Idempotency.TTL = 24 * time.Hour
+	}
+	if cfg.Idempotency.MaxBodySize == 0 {
+		cfg.Idempotency.MaxBodySize = 10 << 20
+	}
+	if cfg.Idempotency.CleanupInterval == 0 {
+		cfg.Idempotency.CleanupInterval = time.Hour
+	}
+
+	if cfg.Attachments.Storage == "" {
+		cfg.Attachments.Storage = "local"
+	}
+	if cfg.Attachments.Dir == "" {
+		cfg.Attachments.Dir = "data/attachments"
+	}
+	if cfg.Attachments.S3.Region == "" {
+		cfg.Attachments.S3.Region = "us-east-1"
+	}
+	if cfg.Attachments.MaxSize == 0 {
+		cfg.Attachments.MaxSize = 10 << 20
+	}
+	if cfg.Attachments.Quota == 0 {
+		cfg.Attachments.Quota = 100 << 20
+	}
+	if len(cfg.Attachments.Allowed) == 0 {
+		cfg.Attachments.Allowed = []string{"image/*", "application/pdf", "text/plain", "application/zip"}
+	}
+	if cfg.Attachments.URLTTL == 0 {
+		cfg.Attachments.URLTTL = 15 * time.Minute
+	}
+	if cfg.Attachments.SigningKey == "" {
+		cfg.Attachments.SigningKey = cfg.JWT.Secret
+	}
+	if cfg.Attachments.CleanupInterval == 0 {
+		cfg.Attachments.CleanupInterval = time.Minute
+	}
+
+	if cfg.Reminders.Interval == 0 {
+		cfg.Reminders.Interval = 30 * time.Second
+	}
+	if cfg.Reminders.Batch == 0 {
+		cfg.Reminders.Batch = 100
+	}
+	if cfg.Reminders.Grace == 0 {
+		cfg.Reminders.Grace = time.Hour
+	}
+	if len(cfg.Reminders.Channels) == 0 {
+		cfg.Reminders.Channels = []string{"in_app", "email", "webhook"}
+	}
+	if cfg.Mail.Port == "" {
+		cfg.Mail.Port = "587"
+	}
+
+	if cfg.Webhooks.Interval == 0 {
+		cfg.Webhooks.Interval = 5 * time.Second
+	}
+	if cfg.Webhooks.Batch == 0 {
+		cfg.Webhooks.Batch = 50
+	}
+	if cfg.Webhooks.Timeout == 0 {
+		cfg.Webhooks.Timeout = 10 * time.Second
+	}
+	if cfg.Webhooks.MaxAttempts == 0 {
+		cfg.Webhooks.MaxAttempts = 8
+	}
+	if cfg.Webhooks.Backoff == 0 {
+		cfg.Webhooks.Backoff = 30 * time.Second
+	}
+	if cfg.Webhooks.MaxBackoff == 0 {
+		cfg.Webhooks.MaxBackoff = 6 * time.Hour
+	}
+
+	if cfg.Events.Buffer == 0 {
+		cfg.Events.Buffer = 1000
+	}
+	if cfg.Events.Heartbeat == 0 {
+		cfg.Events.Heartbeat = 15 * time.Second
+	}
+
+	if cfg.Realtime.PingInterval == 0 {
+		cfg.Realtime.PingInterval = 30 * time.Second
+	}
+	if cfg.Realtime.PongWait == 0 {
+		cfg.Realtime.PongWait = 60 * time.Second
+	}
+	if cfg.Realtime.WriteTimeout == 0 {
+		cfg.Realtime.WriteTimeout = 10 * time.Second
+	}
+	if cfg.Realtime.SendBuffer == 0 {
+		cfg.Realtime.SendBuffer = 64
+	}
+	if cfg.Realtime.MaxMessage == 0 {
+		cfg.Realtime.MaxMessage = 16 << 10
+	}
+	if cfg.Realtime.Rate == 0 {
+		cfg.Realtime.Rate = 10
+	}
+	if cfg.Realtime.Burst == 0 {
+		cfg.Realtime.Burst = 20
+	}
+
+	if cfg.Health.Timeout == 0 {
+		cfg.Health.Timeout = 2 * time.Second
+	}
+
+	if cfg.Metrics.Port == "" {
+		cfg.Metrics.Port = "9090"
+	}
+	if cfg.Metrics.Path == "" {
+		cfg.Metrics.Path = "/metrics"
+	}
+
+	if cfg.Tracing.Exporter == "" {
+		cfg.Tracing.Exporter = "none"
+	}
+	if cfg.Tracing.SampleRatio == 0 {
+		cfg.Tracing.SampleRatio = 1
+	}
+
 	if cfg.Log.Level == "" {
 		cfg.Log.Level = "info"
 	}
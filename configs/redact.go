@@ -0,0 +1,67 @@
+package configs
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Redacted - значение секретной настройки при выводе
+const Redacted = "[redacted]"
+
+// secretSuffixes - окончания имён настроек, значения которых нельзя показывать:
+// password, secret, signingKey, secretKey и т.п.
+var secretSuffixes = []string{"password", "secret", "key", "token"}
+
+type Setting struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+}
+
+// Settings возвращает настройки плоским списком ключей вида db.host, как в config.yml.
+// Непустые значения секретов заменяются на Redacted
+func (c *Config) Settings() []Setting {
+	var settings []Setting
+	flatten("", reflect.ValueOf(*c), &settings)
+	sort.Slice(settings, func(i, j int) bool {
+		return settings[i].Key < settings[j].Key
+	})
+	return settings
+}
+
+func flatten(prefix string, v reflect.Value, settings *[]Setting) {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		key := field.Tag.Get("mapstructure")
+		if key == "" {
+			continue
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		value := v.Field(i)
+		if value.Kind() == reflect.Struct {
+			flatten(key, value, settings)
+			continue
+		}
+		*settings = append(*settings, Setting{Key: key, Value: settingValue(key, value)})
+	}
+}
+
+func settingValue(key string, v reflect.Value) any {
+	// пустой секрет показывается как есть, чтобы было видно, что он не задан
+	name := strings.ToLower(key[strings.LastIndex(key, ".")+1:])
+	for _, suffix := range secretSuffixes {
+		if strings.HasSuffix(name, suffix) && !v.IsZero() {
+			return Redacted
+		}
+	}
+	// длительности выводятся как в config.yml: 30s, а не в наносекундах
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	return v.Interface()
+}
@@ -0,0 +1,39 @@
+package configs_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/melnik-dev/go_todo_jwt/configs"
+)
+
+func TestConfig_Settings(t *testing.T) {
+	cfg := &configs.Config{
+		DB:  configs.ConfDB{Host: "db", Password: "qwerty"},
+		JWT: configs.ConfJWT{Secret: "jwt_secret", TokenTTL: time.Hour},
+		Attachments: configs.ConfAttachments{
+			S3: configs.ConfS3{Bucket: "files", SecretKey: "s3_secret"},
+		},
+	}
+
+	settings := make(map[string]any)
+	for _, s := range cfg.Settings() {
+		settings[s.Key] = s.Value
+	}
+
+	tests := map[string]any{
+		"db.host":                  "db",
+		"db.password":              configs.Redacted,
+		"jwt.secret":               configs.Redacted,
+		"jwt.tokenTTL":             "1h0m0s",
+		"attachments.s3.bucket":    "files",
+		"attachments.s3.secretKey": configs.Redacted,
+		"attachments.signingKey":   "",
+		"db.autoMigrate":           false,
+	}
+	for key, want := range tests {
+		if got, ok := settings[key]; !ok || got != want {
+			t.Errorf("%s: expected %v, got %v", key, want, got)
+		}
+	}
+}
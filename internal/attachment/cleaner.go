@@ -0,0 +1,66 @@
+package attachment
+
+import (
+	"context"
+	"time"
+
+	"github.com/melnik-dev/go_todo_jwt/pkg/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// cleanupBatch - сколько файлов удаляется за один проход очереди
+const cleanupBatch = 100
+
+// Cleaner стирает из хранилища файлы удалённых вложений, в том числе
+// удалённых вместе с задачей (ON DELETE CASCADE + триггер attachments_deletion)
+type Cleaner struct {
+	attachmentRepo IRepository
+	storage        storage.Storage
+	interval       time.Duration
+	logger         *logrus.Logger
+}
+
+func NewCleaner(attachmentRepo IRepository, storage storage.Storage, interval time.Duration, logger *logrus.Logger) *Cleaner {
+	return &Cleaner{
+		attachmentRepo: attachmentRepo,
+		storage:        storage,
+		interval:       interval,
+		logger:         logger,
+	}
+}
+
+// Run разбирает очередь каждые interval, пока не отменён ctx
+func (c *Cleaner) Run(ctx context.Context) {
+	logClean := c.logger.WithField("layer", "Cleaner attachment layer")
+	logClean.Info("Attachment cleaner started")
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := c.Clean(ctx); err != nil && ctx.Err() == nil {
+			logClean.WithError(err).Error("Failed to clean attachments")
+		}
+		select {
+		case <-ctx.Done():
+			logClean.Info("Attachment cleaner stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Clean проходит очередь удаления пачками, пока в ней есть ключи, срок попытки
+// которых наступил. Неудачные ключи откладываются, поэтому неполной пачка бывает
+// только в конце очереди. Возвращает число стёртых файлов.
+func (c *Cleaner) Clean(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		removed, taken, err := c.attachmentRepo.DrainDeletions(ctx, cleanupBatch, func(key string) error {
+			return c.storage.Delete(ctx, key)
+		})
+		total += removed
+		if err != nil || taken < cleanupBatch {
+			return total, err
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package attachment_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/melnik-dev/go_todo_jwt/internal/attachment"
+	"github.com/melnik-dev/go_todo_jwt/pkg/storage"
+)
+
+func TestCleaner_Clean_FailedBatch(t *testing.T) {
+	local, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// первая пачка целиком не удалилась, но очередь за ней разбирается дальше
+	batches := [][2]int{{0, 100}, {3, 3}}
+	calls := 0
+	repo := &MockAttachmentRepository{
+		DrainDeletionsMock: func(limit int, remove func(key string) error) (int, int, error) {
+			b := batches[calls]
+			calls++
+			return b[0], b[1], nil
+		},
+	}
+
+	n, err := attachment.NewCleaner(repo, local, time.Minute, mockLogger()).Clean(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3 removed, got %d", n)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 batches, got %d", calls)
+	}
+}
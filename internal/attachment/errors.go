@@ -0,0 +1,13 @@
+package attachment
+
+import "errors"
+
+var (
+	ErrTaskNotFound       = errors.New("task not found")
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrForbidden          = errors.New("not enough permissions for attachment")
+	ErrTooLarge           = errors.New("file is too large")
+	ErrUnsupportedType    = errors.New("file type is not allowed")
+	ErrQuotaExceeded      = errors.New("storage quota exceeded")
+	ErrInvalidSignature   = errors.New("invalid or expired download link")
+)
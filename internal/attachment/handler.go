@@ -0,0 +1,320 @@
+package attachment
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/pkg/logger"
+	"github.com/melnik-dev/go_todo_jwt/pkg/middleware"
+	"github.com/melnik-dev/go_todo_jwt/pkg/response"
+	"github.com/sirupsen/logrus"
+)
+
+// multipartOverhead - запас сверх attachments.maxSize на заголовки multipart
+const multipartOverhead = 1 << 20
+
+// inlineTypes открываются в браузере, остальное скачивается файлом
+var inlineTypes = map[string]bool{
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+}
+
+type HandlerDeps struct {
+	AttachmentService IService
+	*configs.Config
+	// Workspace выбирает рабочее пространство запроса
+	Workspace gin.HandlerFunc
+}
+
+type Handler struct {
+	AttachmentService IService
+	*configs.Config
+}
+
+func NewHandler(r *gin.Engine, deps *HandlerDeps) {
+	handler := &Handler{
+		AttachmentService: deps.AttachmentService,
+		Config:            deps.Config,
+	}
+	attachments := r.Group("/task/:id/attachments")
+	attachments.Use(middleware.IsAuthed(handler.Config.JWT.Secret))
+	if deps.Workspace != nil {
+		attachments.Use(deps.Workspace)
+	}
+	attachments.GET("/", handler.GetAll)
+	attachments.POST("/", handler.Upload)
+	attachments.GET("/:attachment_id", handler.Get)
+	attachments.DELETE("/:attachment_id", handler.Delete)
+
+	// Ссылка на скачивание подписана, поэтому JWT не нужен
+	r.GET("/attachments/:id/download", handler.Download)
+	r.GET("/attachments/usage", middleware.IsAuthed(handler.Config.JWT.Secret), handler.Usage)
+}
+
+func (h *Handler) GetAll(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to GetAll attachments")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri TaskURIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind task ID")
+		response.BadRequest(c, "Invalid task ID")
+		return
+	}
+
+	attachments, err := h.AttachmentService.GetAll(c.Request.Context(), userID, uri.TaskID)
+	if err != nil {
+		if !writeError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to GetAll attachments")
+			response.InternalServerError(c, "Failed to get attachments")
+		}
+		return
+	}
+	for i := range attachments {
+		absoluteURL(c, &attachments[i])
+	}
+
+	logHandle.Debug("GetAll attachments successfully")
+	response.Success(c, http.StatusOK, gin.H{"attachments": attachments})
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Get attachment")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri URIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind ID")
+		response.BadRequest(c, "Invalid attachment ID")
+		return
+	}
+
+	attachment, err := h.AttachmentService.Get(c.Request.Context(), userID, uri.TaskID, uri.ID)
+	if err != nil {
+		if !writeError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to Get attachment")
+			response.InternalServerError(c, "Failed to get attachment")
+		}
+		return
+	}
+	absoluteURL(c, attachment)
+
+	logHandle.Debug("Get attachment successfully")
+	response.Success(c, http.StatusOK, attachment)
+}
+
+// Upload принимает multipart/form-data с файлом в поле file
+func (h *Handler) Upload(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Upload attachment")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri TaskURIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind task ID")
+		response.BadRequest(c, "Invalid task ID")
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.Config.Attachments.MaxSize+multipartOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			logHandle.Warn(ErrTooLarge.Error())
+			response.Error(c, http.StatusRequestEntityTooLarge, ErrTooLarge.Error())
+			return
+		}
+		logHandle.WithError(err).Warn("Failed to read file")
+		response.BadRequest(c, "Field file is required")
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		logHandle.WithError(err).Error("Failed to open uploaded file")
+		response.InternalServerError(c, "Failed to read file")
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.AttachmentService.Upload(c.Request.Context(), userID, uri.TaskID, &Upload{
+		Filename: header.Filename,
+		Size:     header.Size,
+		Content:  file,
+	})
+	if err != nil {
+		if !writeError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to Upload attachment")
+			response.InternalServerError(c, "Failed to upload attachment")
+		}
+		return
+	}
+	absoluteURL(c, attachment)
+
+	logHandle.Debug("Upload attachment successfully")
+	response.Success(c, http.StatusCreated, attachment)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Delete attachment")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri URIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind ID")
+		response.BadRequest(c, "Invalid attachment ID")
+		return
+	}
+
+	err := h.AttachmentService.Delete(c.Request.Context(), userID, uri.TaskID, uri.ID)
+	if err != nil {
+		if !writeError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to Delete attachment")
+			response.InternalServerError(c, "Failed to delete attachment")
+		}
+		return
+	}
+
+	logHandle.Debug("Delete attachment successfully")
+	response.Success(c, http.StatusOK, gin.H{"message": "Attachment deleted successfully"})
+}
+
+func (h *Handler) Usage(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to get Usage")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	usage, err := h.AttachmentService.Usage(c.Request.Context(), userID)
+	if err != nil {
+		logHandle.WithError(err).Error("Failed to get Usage")
+		response.InternalServerError(c, "Failed to get storage usage")
+		return
+	}
+
+	logHandle.Debug("Usage successfully")
+	response.Success(c, http.StatusOK, usage)
+}
+
+// Download отдаёт файл по подписанной ссылке
+func (h *Handler) Download(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Download attachment")
+
+	var uri DownloadURIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind ID")
+		response.BadRequest(c, "Invalid attachment ID")
+		return
+	}
+	var query DownloadQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		logHandle.WithError(err).Warn(ErrInvalidSignature.Error())
+		response.Forbidden(c, ErrInvalidSignature.Error())
+		return
+	}
+
+	attachment, content, err := h.AttachmentService.Open(c.Request.Context(), uri.ID, query.Expires, query.Signature)
+	if err != nil {
+		if !writeError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to Open attachment")
+			response.InternalServerError(c, "Failed to download attachment")
+		}
+		return
+	}
+	defer content.Close()
+
+	mediaType, _, _ := mime.ParseMediaType(attachment.ContentType)
+	disposition := "attachment"
+	if inlineTypes[mediaType] {
+		disposition = "inline"
+	}
+
+	c.Header("Content-Type", attachment.ContentType)
+	c.Header("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	c.Header("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Security-Policy", "default-src 'none'; sandbox")
+	c.Header("Cache-Control", "private, no-store")
+	c.Status(http.StatusOK)
+	if _, err = io.Copy(c.Writer, content); err != nil {
+		logHandle.WithError(err).Warn("Failed to stream attachment")
+		return
+	}
+
+	logHandle.Debug("Download attachment successfully")
+}
+
+// writeError отвечает на ошибки, которые понятны клиенту; false - ошибка не из их числа
+func writeError(c *gin.Context, logHandle *logrus.Entry, err error) bool {
+	status := 0
+	switch {
+	case errors.Is(err, ErrTaskNotFound), errors.Is(err, ErrAttachmentNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrForbidden), errors.Is(err, ErrInvalidSignature):
+		status = http.StatusForbidden
+	case errors.Is(err, ErrTooLarge), errors.Is(err, ErrQuotaExceeded):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrUnsupportedType):
+		status = http.StatusUnsupportedMediaType
+	default:
+		return false
+	}
+	logHandle.Warn(err.Error())
+	response.Error(c, status, err.Error())
+	return true
+}
+
+// absoluteURL дополняет относительную ссылку сервиса адресом, по которому пришёл запрос
+func absoluteURL(c *gin.Context, attachment *Attachment) {
+	if strings.HasPrefix(attachment.URL, "/") {
+		attachment.URL = fmt.Sprintf("%s://%s%s", requestScheme(c), c.Request.Host, attachment.URL)
+	}
+}
+
+func requestScheme(c *gin.Context) string {
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		return proto
+	}
+	if c.Request.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+func handlerLogger(c *gin.Context) *logrus.Entry {
+	return logger.FromContext(c).WithField("layer", "Handler attachment layer")
+}
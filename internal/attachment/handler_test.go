@@ -0,0 +1,162 @@
+package attachment_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/internal/attachment"
+	"github.com/sirupsen/logrus"
+)
+
+// MockAttachmentService встраивает attachment.IService, чтобы переопределять только нужные методы
+type MockAttachmentService struct {
+	attachment.IService
+	UploadMock func(userID, taskID int, upload *attachment.Upload) (*attachment.Attachment, error)
+	OpenMock   func(id int, expires int64, signature string) (*attachment.Attachment, io.ReadCloser, error)
+}
+
+func (m *MockAttachmentService) Upload(ctx context.Context, userID, taskID int, upload *attachment.Upload) (*attachment.Attachment, error) {
+	return m.UploadMock(userID, taskID, upload)
+}
+
+func (m *MockAttachmentService) Open(ctx context.Context, id int, expires int64, signature string) (*attachment.Attachment, io.ReadCloser, error) {
+	return m.OpenMock(id, expires, signature)
+}
+
+func mockGin() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		c.Set("logger", logrus.NewEntry(logger))
+		c.Set("user_id", 42)
+		c.Next()
+	})
+	return r
+}
+
+func multipartBody(t *testing.T, field string, content []byte) (*bytes.Buffer, string) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile(field, "shot.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	w.Close()
+	return &body, w.FormDataContentType()
+}
+
+func TestHandler_Upload(t *testing.T) {
+	tests := []struct {
+		name     string
+		field    string
+		size     int
+		err      error
+		wantCode int
+	}{
+		{name: "success", field: "file", size: 10, wantCode: http.StatusCreated},
+		{name: "missing file", field: "other", size: 10, wantCode: http.StatusBadRequest},
+		{name: "body over limit", field: "file", size: 3 << 20, wantCode: http.StatusRequestEntityTooLarge},
+		{name: "unsupported type", field: "file", size: 10, err: attachment.ErrUnsupportedType, wantCode: http.StatusUnsupportedMediaType},
+		{name: "quota", field: "file", size: 10, err: attachment.ErrQuotaExceeded, wantCode: http.StatusRequestEntityTooLarge},
+		{name: "viewer", field: "file", size: 10, err: attachment.ErrForbidden, wantCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &attachment.Handler{
+				AttachmentService: &MockAttachmentService{
+					UploadMock: func(userID, taskID int, upload *attachment.Upload) (*attachment.Attachment, error) {
+						if tt.err != nil {
+							return nil, tt.err
+						}
+						if upload.Size != int64(tt.size) || upload.Filename != "shot.png" {
+							t.Errorf("unexpected upload %+v", upload)
+						}
+						return &attachment.Attachment{ID: 5, TaskID: taskID, URL: "/attachments/5/download?expires=1&signature=ab"}, nil
+					},
+				},
+				Config: &configs.Config{Attachments: configs.ConfAttachments{MaxSize: 1 << 20}},
+			}
+
+			r := mockGin()
+			r.POST("/task/:id/attachments/", handler.Upload)
+			body, contentType := multipartBody(t, tt.field, bytes.Repeat([]byte{1}, tt.size))
+			req := httptest.NewRequest(http.MethodPost, "/task/1/attachments/", body)
+			req.Header.Set("Content-Type", contentType)
+			req.Header.Set("X-Forwarded-Proto", "https")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+			if tt.wantCode == http.StatusCreated && !strings.Contains(w.Body.String(), `"url":"https://example.com/attachments/5/download?`) {
+				t.Errorf("expected absolute url, got %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_Download(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		err      error
+		wantCode int
+	}{
+		{name: "success", path: "/attachments/5/download?expires=1&signature=ab", wantCode: http.StatusOK},
+		{name: "no signature", path: "/attachments/5/download", wantCode: http.StatusForbidden},
+		{name: "bad signature", path: "/attachments/5/download?expires=1&signature=ab", err: attachment.ErrInvalidSignature, wantCode: http.StatusForbidden},
+		{name: "deleted", path: "/attachments/5/download?expires=1&signature=ab", err: attachment.ErrAttachmentNotFound, wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &MockAttachmentService{
+				OpenMock: func(id int, expires int64, signature string) (*attachment.Attachment, io.ReadCloser, error) {
+					if tt.err != nil {
+						return nil, nil, tt.err
+					}
+					a := &attachment.Attachment{ID: id, Filename: "отчёт \"Q1\".txt", ContentType: "text/plain; charset=utf-8", Size: 5}
+					return a, io.NopCloser(strings.NewReader("hello")), nil
+				},
+			}
+
+			r := mockGin()
+			attachment.NewHandler(r, &attachment.HandlerDeps{
+				AttachmentService: service,
+				Config:            &configs.Config{JWT: configs.ConfJWT{Secret: "secret"}},
+			})
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, w.Code)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			if w.Body.String() != "hello" {
+				t.Errorf("unexpected body %q", w.Body.String())
+			}
+			if got := w.Header().Get("Content-Disposition"); !strings.HasPrefix(got, "attachment; filename*=utf-8''") {
+				t.Errorf("unexpected disposition %q", got)
+			}
+			if w.Header().Get("X-Content-Type-Options") != "nosniff" {
+				t.Error("expected nosniff header")
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package attachment
+
+import "time"
+
+// Attachment - файл, прикреплённый к задаче. Содержимое лежит в хранилище
+// по StorageKey; URL - подписанная ссылка на скачивание с ограниченным сроком.
+type Attachment struct {
+	ID          int       `db:"id" json:"id"`
+	TaskID      int       `db:"task_id" json:"task_id"`
+	UserID      int       `db:"user_id" json:"user_id"`
+	Filename    string    `db:"filename" json:"filename"`
+	ContentType string    `db:"content_type" json:"content_type"`
+	Size        int64     `db:"size" json:"size"`
+	StorageKey  string    `db:"storage_key" json:"-"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	URL         string    `db:"-" json:"url"`
+	ExpiresAt   time.Time `db:"-" json:"url_expires_at"`
+}
+
+// Usage - занятое пользователем место в хранилище, байт
+type Usage struct {
+	Used  int64 `json:"used"`
+	Quota int64 `json:"quota"`
+}
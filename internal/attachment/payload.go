@@ -0,0 +1,20 @@
+package attachment
+
+type TaskURIParam struct {
+	TaskID int `uri:"id" binding:"required,min=1"`
+}
+
+type URIParam struct {
+	TaskID int `uri:"id" binding:"required,min=1"`
+	ID     int `uri:"attachment_id" binding:"required,min=1"`
+}
+
+type DownloadURIParam struct {
+	ID int `uri:"id" binding:"required,min=1"`
+}
+
+// DownloadQuery - параметры подписанной ссылки
+type DownloadQuery struct {
+	Expires   int64  `form:"expires" binding:"required"`
+	Signature string `form:"signature" binding:"required,hexadecimal"`
+}
@@ -0,0 +1,290 @@
+package attachment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/sirupsen/logrus"
+)
+
+const selectAttachments = `SELECT a.id, a.task_id, a.user_id, a.filename, a.content_type, a.size, a.storage_key, a.created_at
+	FROM attachments a`
+
+// Методы, кроме GetForDownload и DrainDeletions, работают в рабочем пространстве
+// из ctx через db.Tenant; права на задачу проверяет сервис через Rank.
+type IRepository interface {
+	Rank(ctx context.Context, taskID, userID int) (int, error)
+	Usage(ctx context.Context, userID int) (int64, error)
+	Create(ctx context.Context, attachment *Attachment, quota int64) error
+	Get(ctx context.Context, taskID, id int) (*Attachment, error)
+	GetByTask(ctx context.Context, taskID int) ([]Attachment, error)
+	Delete(ctx context.Context, id int) error
+	GetForDownload(ctx context.Context, id int) (*Attachment, error)
+	DrainDeletions(ctx context.Context, limit int, remove func(key string) error) (int, int, error)
+}
+
+type Repository struct {
+	db     *db.Db
+	logger *logrus.Logger
+}
+
+func NewRepository(db *db.Db, logger *logrus.Logger) *Repository {
+	return &Repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Rank возвращает лучшую роль пользователя в задаче: 0 - задача не видна, 3 - владелец
+func (r *Repository) Rank(ctx context.Context, taskID, userID int) (int, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"task_id": taskID,
+		"user_id": userID,
+	})
+	logRepo.Debug("Attempting to get Rank")
+
+	var rank int
+	query := `SELECT COALESCE(MAX(rank), 0) FROM task_access WHERE task_id = $1 AND user_id = $2`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		return tx.GetContext(ctx, &rank, query, taskID, userID)
+	})
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to get Rank database")
+		return 0, err
+	}
+
+	logRepo.WithField("rank", rank).Debug("Rank database successfully")
+	return rank, nil
+}
+
+// Usage возвращает суммарный размер вложений пользователя во всех рабочих пространствах
+func (r *Repository) Usage(ctx context.Context, userID int) (int64, error) {
+	logRepo := repositoryLogger(r.logger).WithField("user_id", userID)
+	logRepo.Debug("Attempting to get Usage")
+
+	var used int64
+	query := `SELECT COALESCE(SUM(size), 0) FROM attachments WHERE user_id = $1`
+	if err := r.db.GetContext(ctx, &used, query, userID); err != nil {
+		logRepo.WithError(err).Error("Failed to get Usage database")
+		return 0, err
+	}
+
+	logRepo.WithField("used", used).Debug("Usage database successfully")
+	return used, nil
+}
+
+// Create сохраняет вложение, если оно помещается в квоту пользователя.
+// Строка пользователя блокируется, чтобы параллельные загрузки не превысили квоту вместе.
+func (r *Repository) Create(ctx context.Context, attachment *Attachment, quota int64) error {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"task_id": attachment.TaskID,
+		"user_id": attachment.UserID,
+	})
+	logRepo.Debug("Attempting to Create attachment")
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, `SELECT id FROM users WHERE id = $1 FOR UPDATE`, attachment.UserID); err != nil {
+			return err
+		}
+
+		var used int64
+		query := `SELECT COALESCE(SUM(size), 0) FROM attachments WHERE user_id = $1`
+		if err := tx.GetContext(ctx, &used, query, attachment.UserID); err != nil {
+			return err
+		}
+		if used+attachment.Size > quota {
+			return ErrQuotaExceeded
+		}
+
+		query = `INSERT INTO attachments (task_id, user_id, filename, content_type, size, storage_key)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				RETURNING id, created_at`
+		row := tx.QueryRowContext(ctx, query, attachment.TaskID, attachment.UserID, attachment.Filename,
+			attachment.ContentType, attachment.Size, attachment.StorageKey)
+		return row.Scan(&attachment.ID, &attachment.CreatedAt)
+	})
+	if err != nil {
+		if errors.Is(err, ErrQuotaExceeded) {
+			logRepo.Warn(ErrQuotaExceeded.Error())
+			return err
+		}
+		logRepo.WithError(err).Error("Failed to Create attachment database")
+		return err
+	}
+
+	logRepo.WithField("attachment_id", attachment.ID).Debug("Create attachment database successfully")
+	return nil
+}
+
+// Get возвращает вложение задачи taskID
+func (r *Repository) Get(ctx context.Context, taskID, id int) (*Attachment, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"task_id":       taskID,
+		"attachment_id": id,
+	})
+	logRepo.Debug("Attempting to Get attachment")
+
+	var attachment Attachment
+	query := selectAttachments + ` JOIN tasks t ON t.id = a.task_id WHERE a.task_id = $1 AND a.id = $2`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		return tx.GetContext(ctx, &attachment, query, taskID, id)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logRepo.Warn(ErrAttachmentNotFound.Error())
+			return nil, ErrAttachmentNotFound
+		}
+		logRepo.WithError(err).Error("Failed to Get attachment database")
+		return nil, err
+	}
+
+	logRepo.Debug("Get attachment database successfully")
+	return &attachment, nil
+}
+
+// GetByTask возвращает вложения задачи в порядке загрузки
+func (r *Repository) GetByTask(ctx context.Context, taskID int) ([]Attachment, error) {
+	logRepo := repositoryLogger(r.logger).WithField("task_id", taskID)
+	logRepo.Debug("Attempting to GetByTask attachments")
+
+	attachments := make([]Attachment, 0)
+	query := selectAttachments + ` JOIN tasks t ON t.id = a.task_id WHERE a.task_id = $1 ORDER BY a.id`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		return tx.SelectContext(ctx, &attachments, query, taskID)
+	})
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to GetByTask attachments database")
+		return nil, err
+	}
+
+	logRepo.Debug("GetByTask attachments database successfully")
+	return attachments, nil
+}
+
+// Delete удаляет вложение; файл попадает в attachment_deletions через триггер
+func (r *Repository) Delete(ctx context.Context, id int) error {
+	logRepo := repositoryLogger(r.logger).WithField("attachment_id", id)
+	logRepo.Debug("Attempting to Delete attachment")
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, `DELETE FROM attachments WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+		row, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if row == 0 {
+			return ErrAttachmentNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrAttachmentNotFound) {
+			logRepo.Warn(ErrAttachmentNotFound.Error())
+			return err
+		}
+		logRepo.WithError(err).Error("Failed to Delete attachment database")
+		return err
+	}
+
+	logRepo.Debug("Delete attachment database successfully")
+	return nil
+}
+
+// GetForDownload читает вложение без рабочего пространства: доступ к нему
+// уже подтверждён подписью ссылки
+func (r *Repository) GetForDownload(ctx context.Context, id int) (*Attachment, error) {
+	logRepo := repositoryLogger(r.logger).WithField("attachment_id", id)
+	logRepo.Debug("Attempting to GetForDownload attachment")
+
+	var attachment Attachment
+	query := selectAttachments + ` WHERE a.id = $1`
+	if err := r.db.GetContext(ctx, &attachment, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logRepo.Warn(ErrAttachmentNotFound.Error())
+			return nil, ErrAttachmentNotFound
+		}
+		logRepo.WithError(err).Error("Failed to GetForDownload attachment database")
+		return nil, err
+	}
+
+	logRepo.Debug("GetForDownload attachment database successfully")
+	return &attachment, nil
+}
+
+// DrainDeletions передаёт remove до limit ключей, срок попытки которых наступил,
+// и убирает из очереди те, что удалось стереть. Остальные откладываются с
+// удваивающейся паузой (от минуты до суток), чтобы не занимать
+// голову очереди. Возвращает число стёртых и число выбранных ключей.
+// SKIP LOCKED позволяет нескольким экземплярам приложения разбирать очередь одновременно.
+func (r *Repository) DrainDeletions(ctx context.Context, limit int, remove func(key string) error) (int, int, error) {
+	logRepo := repositoryLogger(r.logger)
+	logRepo.Debug("Attempting to DrainDeletions")
+
+	var removed, failed []string
+	err := db.NewTxManager(r.db).WithinTx(ctx, func(ctx context.Context) error {
+		var keys []string
+		query := `SELECT storage_key FROM attachment_deletions
+				WHERE next_attempt_at <= NOW()
+				ORDER BY next_attempt_at
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED`
+		if err := r.db.SelectContext(ctx, &keys, query, limit); err != nil {
+			logRepo.WithError(err).Error("Failed to select attachment deletions")
+			return err
+		}
+
+		// при повторе транзакции ключи выбираются заново
+		removed = make([]string, 0, len(keys))
+		failed = make([]string, 0)
+		for _, key := range keys {
+			if err := remove(key); err != nil {
+				logRepo.WithError(err).WithField("storage_key", key).Warn("Failed to remove attachment file")
+				failed = append(failed, key)
+				continue
+			}
+			removed = append(removed, key)
+		}
+
+		if len(removed) > 0 {
+			query = `DELETE FROM attachment_deletions WHERE storage_key = ANY($1)`
+			if _, err := r.db.ExecContext(ctx, query, pq.Array(removed)); err != nil {
+				logRepo.WithError(err).Error("Failed to delete attachment deletions")
+				return err
+			}
+		}
+		if len(failed) > 0 {
+			query = `UPDATE attachment_deletions
+					SET attempts = attempts + 1,
+						next_attempt_at = NOW() + LEAST(INTERVAL '1 minute' * POWER(2, LEAST(attempts, 20)), INTERVAL '1 day')
+					WHERE storage_key = ANY($1)`
+			if _, err := r.db.ExecContext(ctx, query, pq.Array(failed)); err != nil {
+				logRepo.WithError(err).Error("Failed to postpone attachment deletions")
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	logRepo.WithFields(logrus.Fields{
+		"removed":   len(removed),
+		"postponed": len(failed),
+	}).Debug("DrainDeletions successfully")
+	return len(removed), len(removed) + len(failed), nil
+}
+
+func repositoryLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Repository attachment layer")
+}
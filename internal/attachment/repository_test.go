@@ -0,0 +1,153 @@
+package attachment_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/melnik-dev/go_todo_jwt/internal/attachment"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+)
+
+func mockDB() (*attachment.Repository, sqlmock.Sqlmock, error) {
+	mockDb, mock, err := sqlmock.New()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pgDB := sqlx.NewDb(mockDb, "sqlMock")
+
+	repo := attachment.NewRepository(&db.Db{
+		DB: pgDB,
+	}, mockLogger())
+
+	return repo, mock, err
+}
+
+var ctx = db.WithWorkspace(context.Background(), 1)
+
+func expectTenant(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT set_config('role', $1, true), set_config('app.workspace_id', $2, true)`)).
+		WithArgs(db.TenantRole, "1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestAttachmentRepository_Create_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectTenant(mock)
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT id FROM users WHERE id = $1 FOR UPDATE`)).
+		WithArgs(42).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(SUM(size), 0) FROM attachments WHERE user_id = $1`)).
+		WithArgs(42).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(90))
+	mock.ExpectQuery(`INSERT INTO attachments`).
+		WithArgs(1, 42, "a.png", "image/png", int64(10), "tasks/1/abc").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(5, time.Now()))
+	mock.ExpectCommit()
+
+	a := &attachment.Attachment{TaskID: 1, UserID: 42, Filename: "a.png", ContentType: "image/png", Size: 10, StorageKey: "tasks/1/abc"}
+	if err = repo.Create(ctx, a, 100); err != nil {
+		t.Fatal(err)
+	}
+	if a.ID != 5 {
+		t.Errorf("unexpected id %d", a.ID)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAttachmentRepository_Create_FailQuota(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectTenant(mock)
+	mock.ExpectExec(`FOR UPDATE`).
+		WithArgs(42).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT COALESCE\(SUM\(size\), 0\)`).
+		WithArgs(42).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(91))
+	mock.ExpectRollback()
+
+	a := &attachment.Attachment{TaskID: 1, UserID: 42, Size: 10}
+	if err = repo.Create(ctx, a, 100); !errors.Is(err, attachment.ErrQuotaExceeded) {
+		t.Fatalf("expected %v, got %v", attachment.ErrQuotaExceeded, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAttachmentRepository_Delete_FailNotFound(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectTenant(mock)
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM attachments WHERE id = $1`)).
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	if err = repo.Delete(ctx, 5); !errors.Is(err, attachment.ErrAttachmentNotFound) {
+		t.Fatalf("expected %v, got %v", attachment.ErrAttachmentNotFound, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAttachmentRepository_DrainDeletions(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT storage_key FROM attachment_deletions\s+WHERE next_attempt_at <= NOW\(\)\s+ORDER BY next_attempt_at .* FOR UPDATE SKIP LOCKED`).
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"storage_key"}).AddRow("tasks/1/a").AddRow("tasks/1/b"))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM attachment_deletions WHERE storage_key = ANY($1)`)).
+		WithArgs(pq.Array([]string{"tasks/1/a"})).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// файл b удалить не удалось - он откладывается и не держит голову очереди
+	mock.ExpectExec(`UPDATE attachment_deletions\s+SET attempts = attempts \+ 1,\s+next_attempt_at = NOW\(\) \+ .*WHERE storage_key = ANY\(\$1\)`).
+		WithArgs(pq.Array([]string{"tasks/1/b"})).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	removed, taken, err := repo.DrainDeletions(context.Background(), 10, func(key string) error {
+		if key == "tasks/1/b" {
+			return errors.New("storage unavailable")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 || taken != 2 {
+		t.Errorf("expected 1 removed of 2 taken, got %d of %d", removed, taken)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,348 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/pkg/storage"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	rankEditor = 2
+	rankOwner  = 3
+	// sniffLen - сколько первых байт файла смотрим, чтобы определить его тип
+	sniffLen = 3072
+)
+
+type IService interface {
+	GetAll(ctx context.Context, userID, taskID int) ([]Attachment, error)
+	Get(ctx context.Context, userID, taskID, id int) (*Attachment, error)
+	Upload(ctx context.Context, userID, taskID int, upload *Upload) (*Attachment, error)
+	Delete(ctx context.Context, userID, taskID, id int) error
+	Usage(ctx context.Context, userID int) (*Usage, error)
+	Open(ctx context.Context, id int, expires int64, signature string) (*Attachment, io.ReadCloser, error)
+}
+
+// Upload - загружаемый файл; Size берётся из multipart-заголовка
+type Upload struct {
+	Filename string
+	Size     int64
+	Content  io.Reader
+}
+
+type Service struct {
+	attachmentRepo IRepository
+	storage        storage.Storage
+	cfg            configs.ConfAttachments
+	logger         *logrus.Logger
+}
+
+func NewService(attachmentRepo IRepository, storage storage.Storage, cfg configs.ConfAttachments, logger *logrus.Logger) *Service {
+	return &Service{
+		attachmentRepo: attachmentRepo,
+		storage:        storage,
+		cfg:            cfg,
+		logger:         logger,
+	}
+}
+
+// GetAll возвращает вложения задачи со свежими ссылками на скачивание
+func (s *Service) GetAll(ctx context.Context, userID, taskID int) ([]Attachment, error) {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id": userID,
+		"task_id": taskID,
+	})
+	logServ.Debug("Attempting to GetAll attachments")
+
+	if _, err := s.require(ctx, userID, taskID, 1); err != nil {
+		logServ.WithError(err).Warn("Failed to GetAll attachments")
+		return nil, err
+	}
+
+	attachments, err := s.attachmentRepo.GetByTask(ctx, taskID)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to GetByTask")
+		return nil, err
+	}
+	for i := range attachments {
+		s.sign(&attachments[i])
+	}
+
+	logServ.Debug("GetAll attachments successfully")
+	return attachments, nil
+}
+
+func (s *Service) Get(ctx context.Context, userID, taskID, id int) (*Attachment, error) {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id":       userID,
+		"task_id":       taskID,
+		"attachment_id": id,
+	})
+	logServ.Debug("Attempting to Get attachment")
+
+	if _, err := s.require(ctx, userID, taskID, 1); err != nil {
+		logServ.WithError(err).Warn("Failed to Get attachment")
+		return nil, err
+	}
+
+	attachment, err := s.attachmentRepo.Get(ctx, taskID, id)
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to Get attachment")
+		return nil, err
+	}
+	s.sign(attachment)
+
+	logServ.Debug("Get attachment successfully")
+	return attachment, nil
+}
+
+// Upload прикрепляет файл к задаче. Загружать может редактор или владелец;
+// тип файла определяется по содержимому, а не по имени или заголовку клиента.
+func (s *Service) Upload(ctx context.Context, userID, taskID int, upload *Upload) (*Attachment, error) {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id": userID,
+		"task_id": taskID,
+		"size":    upload.Size,
+	})
+	logServ.Debug("Attempting to Upload attachment")
+
+	if upload.Size > s.cfg.MaxSize {
+		logServ.Warn(ErrTooLarge.Error())
+		return nil, ErrTooLarge
+	}
+	if _, err := s.require(ctx, userID, taskID, rankEditor); err != nil {
+		logServ.WithError(err).Warn("Failed to Upload attachment")
+		return nil, err
+	}
+
+	// Ранняя проверка квоты, чтобы не загружать файл зря; окончательная - в Create
+	used, err := s.attachmentRepo.Usage(ctx, userID)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to get Usage")
+		return nil, err
+	}
+	if used+upload.Size > s.cfg.Quota {
+		logServ.Warn(ErrQuotaExceeded.Error())
+		return nil, ErrQuotaExceeded
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(upload.Content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		logServ.WithError(err).Warn("Failed to read upload")
+		return nil, err
+	}
+	head = head[:n]
+	contentType := mimetype.Detect(head)
+	if !s.allowed(contentType) {
+		logServ.WithField("content_type", contentType.String()).Warn(ErrUnsupportedType.Error())
+		return nil, ErrUnsupportedType
+	}
+
+	key, err := storageKey(taskID)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to generate storage key")
+		return nil, err
+	}
+	content := io.MultiReader(bytes.NewReader(head), upload.Content)
+	if err = s.storage.Put(ctx, key, content, upload.Size, contentType.String()); err != nil {
+		logServ.WithError(err).Error("Failed to Put file")
+		return nil, err
+	}
+
+	attachment := &Attachment{
+		TaskID:      taskID,
+		UserID:      userID,
+		Filename:    cleanFilename(upload.Filename, contentType.Extension()),
+		ContentType: contentType.String(),
+		Size:        upload.Size,
+		StorageKey:  key,
+	}
+	if err = s.attachmentRepo.Create(ctx, attachment, s.cfg.Quota); err != nil {
+		if delErr := s.storage.Delete(context.WithoutCancel(ctx), key); delErr != nil {
+			logServ.WithError(delErr).WithField("storage_key", key).Error("Failed to remove orphaned file")
+		}
+		logServ.WithError(err).Warn("Failed to Create attachment")
+		return nil, err
+	}
+	s.sign(attachment)
+
+	logServ.WithField("attachment_id", attachment.ID).Debug("Upload attachment successfully")
+	return attachment, nil
+}
+
+// Delete удаляет вложение; удалить может загрузивший его или владелец задачи.
+// Файл стирается из хранилища позже, см. Cleaner.
+func (s *Service) Delete(ctx context.Context, userID, taskID, id int) error {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id":       userID,
+		"task_id":       taskID,
+		"attachment_id": id,
+	})
+	logServ.Debug("Attempting to Delete attachment")
+
+	rank, err := s.require(ctx, userID, taskID, 1)
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to Delete attachment")
+		return err
+	}
+
+	attachment, err := s.attachmentRepo.Get(ctx, taskID, id)
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to Get attachment")
+		return err
+	}
+	if attachment.UserID != userID && rank < rankOwner {
+		logServ.Warn(ErrForbidden.Error())
+		return ErrForbidden
+	}
+
+	if err = s.attachmentRepo.Delete(ctx, id); err != nil {
+		logServ.WithError(err).Warn("Failed to Delete attachment")
+		return err
+	}
+
+	logServ.Debug("Delete attachment successfully")
+	return nil
+}
+
+func (s *Service) Usage(ctx context.Context, userID int) (*Usage, error) {
+	logServ := serviceLogger(s.logger).WithField("user_id", userID)
+	logServ.Debug("Attempting to get Usage")
+
+	used, err := s.attachmentRepo.Usage(ctx, userID)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to get Usage")
+		return nil, err
+	}
+
+	logServ.Debug("Usage successfully")
+	return &Usage{Used: used, Quota: s.cfg.Quota}, nil
+}
+
+// Open проверяет подписанную ссылку и открывает файл вложения на чтение
+func (s *Service) Open(ctx context.Context, id int, expires int64, signature string) (*Attachment, io.ReadCloser, error) {
+	logServ := serviceLogger(s.logger).WithField("attachment_id", id)
+	logServ.Debug("Attempting to Open attachment")
+
+	if time.Now().Unix() > expires || !hmac.Equal([]byte(signature), []byte(s.signature(id, expires))) {
+		logServ.Warn(ErrInvalidSignature.Error())
+		return nil, nil, ErrInvalidSignature
+	}
+
+	attachment, err := s.attachmentRepo.GetForDownload(ctx, id)
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to GetForDownload")
+		return nil, nil, err
+	}
+
+	content, err := s.storage.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			logServ.Warn("Attachment file is missing in storage")
+			return nil, nil, ErrAttachmentNotFound
+		}
+		logServ.WithError(err).Error("Failed to Get file")
+		return nil, nil, err
+	}
+
+	logServ.Debug("Open attachment successfully")
+	return attachment, content, nil
+}
+
+// require возвращает ранг пользователя в задаче: ErrTaskNotFound, если задача
+// ему не видна, и ErrForbidden, если ранг ниже minRank
+func (s *Service) require(ctx context.Context, userID, taskID, minRank int) (int, error) {
+	rank, err := s.attachmentRepo.Rank(ctx, taskID, userID)
+	if err != nil {
+		return 0, err
+	}
+	if rank == 0 {
+		return 0, ErrTaskNotFound
+	}
+	if rank < minRank {
+		return 0, ErrForbidden
+	}
+	return rank, nil
+}
+
+// allowed сверяет тип с attachments.allowed; "image/*" разрешает все изображения
+func (s *Service) allowed(contentType *mimetype.MIME) bool {
+	for _, pattern := range s.cfg.Allowed {
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if strings.HasPrefix(contentType.String(), prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if contentType.Is(pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// sign выставляет ссылку на скачивание, действующую attachments.urlTTL.
+// URL относительный, полный адрес собирает обработчик.
+func (s *Service) sign(attachment *Attachment) {
+	attachment.ExpiresAt = time.Now().Add(s.cfg.URLTTL).Truncate(time.Second)
+	expires := attachment.ExpiresAt.Unix()
+	attachment.URL = fmt.Sprintf("/attachments/%d/download?expires=%d&signature=%s",
+		attachment.ID, expires, s.signature(attachment.ID, expires))
+}
+
+func (s *Service) signature(id int, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(s.cfg.SigningKey))
+	mac.Write([]byte("attachment:" + strconv.Itoa(id) + ":" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// storageKey возвращает случайный ключ, по которому нельзя угадать чужие файлы
+func storageKey(taskID int) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("tasks/%d/%s", taskID, hex.EncodeToString(b)), nil
+}
+
+// cleanFilename оставляет от имени файла последний элемент пути без управляющих
+// символов и не длиннее 255 байт
+func cleanFilename(name, ext string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		name = "file" + ext
+	}
+	for len(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+func serviceLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Service attachment layer")
+}
@@ -0,0 +1,276 @@
+package attachment_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/internal/attachment"
+	"github.com/melnik-dev/go_todo_jwt/pkg/storage"
+	"github.com/sirupsen/logrus"
+)
+
+type MockAttachmentRepository struct {
+	RankMock           func(taskID, userID int) (int, error)
+	UsageMock          func(userID int) (int64, error)
+	CreateMock         func(a *attachment.Attachment, quota int64) error
+	GetMock            func(taskID, id int) (*attachment.Attachment, error)
+	GetByTaskMock      func(taskID int) ([]attachment.Attachment, error)
+	DeleteMock         func(id int) error
+	GetForDownloadMock func(id int) (*attachment.Attachment, error)
+	DrainDeletionsMock func(limit int, remove func(key string) error) (int, int, error)
+}
+
+func (m *MockAttachmentRepository) Rank(ctx context.Context, taskID, userID int) (int, error) {
+	return m.RankMock(taskID, userID)
+}
+
+func (m *MockAttachmentRepository) Usage(ctx context.Context, userID int) (int64, error) {
+	return m.UsageMock(userID)
+}
+
+func (m *MockAttachmentRepository) Create(ctx context.Context, a *attachment.Attachment, quota int64) error {
+	return m.CreateMock(a, quota)
+}
+
+func (m *MockAttachmentRepository) Get(ctx context.Context, taskID, id int) (*attachment.Attachment, error) {
+	return m.GetMock(taskID, id)
+}
+
+func (m *MockAttachmentRepository) GetByTask(ctx context.Context, taskID int) ([]attachment.Attachment, error) {
+	return m.GetByTaskMock(taskID)
+}
+
+func (m *MockAttachmentRepository) Delete(ctx context.Context, id int) error {
+	return m.DeleteMock(id)
+}
+
+func (m *MockAttachmentRepository) GetForDownload(ctx context.Context, id int) (*attachment.Attachment, error) {
+	return m.GetForDownloadMock(id)
+}
+
+func (m *MockAttachmentRepository) DrainDeletions(ctx context.Context, limit int, remove func(key string) error) (int, int, error) {
+	return m.DrainDeletionsMock(limit, remove)
+}
+
+func mockLogger() *logrus.Logger {
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	return l
+}
+
+func testConfig() configs.ConfAttachments {
+	return configs.ConfAttachments{
+		MaxSize:    1 << 20,
+		Quota:      2 << 20,
+		Allowed:    []string{"image/*", "text/plain"},
+		URLTTL:     time.Minute,
+		SigningKey: "secret",
+	}
+}
+
+// pngHeader - сигнатура PNG, по которой mimetype узнаёт изображение
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestService_Upload(t *testing.T) {
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved *attachment.Attachment
+	repo := &MockAttachmentRepository{
+		RankMock:  func(taskID, userID int) (int, error) { return 2, nil },
+		UsageMock: func(userID int) (int64, error) { return 0, nil },
+		CreateMock: func(a *attachment.Attachment, quota int64) error {
+			a.ID = 5
+			saved = a
+			return nil
+		},
+	}
+	service := attachment.NewService(repo, store, testConfig(), mockLogger())
+
+	content := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte{1}, 5000)...)
+	a, err := service.Upload(context.Background(), 42, 1, &attachment.Upload{
+		Filename: `C:\Users\bob\shot.txt`,
+		Size:     int64(len(content)),
+		Content:  bytes.NewReader(content),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != saved || a.ContentType != "image/png" || a.Filename != "shot.txt" {
+		t.Fatalf("unexpected attachment %+v", a)
+	}
+	if !strings.HasPrefix(a.StorageKey, "tasks/1/") || !strings.HasPrefix(a.URL, "/attachments/5/download?") {
+		t.Errorf("unexpected key %q or url %q", a.StorageKey, a.URL)
+	}
+
+	r, err := store.Get(context.Background(), a.StorageKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := io.ReadAll(r)
+	r.Close()
+	if !bytes.Equal(stored, content) {
+		t.Error("stored content differs from uploaded")
+	}
+}
+
+func TestService_Upload_Fail(t *testing.T) {
+	tests := []struct {
+		name    string
+		rank    int
+		used    int64
+		content []byte
+		size    int64
+		quota   error
+		wantErr error
+	}{
+		{name: "task not visible", rank: 0, content: pngHeader, wantErr: attachment.ErrTaskNotFound},
+		{name: "viewer", rank: 1, content: pngHeader, wantErr: attachment.ErrForbidden},
+		{name: "too large", rank: 2, content: pngHeader, size: 2 << 20, wantErr: attachment.ErrTooLarge},
+		{name: "quota", rank: 2, used: 2<<20 - 4, content: pngHeader, wantErr: attachment.ErrQuotaExceeded},
+		{name: "quota race", rank: 2, content: pngHeader, quota: attachment.ErrQuotaExceeded, wantErr: attachment.ErrQuotaExceeded},
+		{name: "disallowed type", rank: 2, content: []byte("%PDF-1.4\n"), wantErr: attachment.ErrUnsupportedType},
+		{name: "html is not plain text", rank: 2, content: []byte("<html><script>alert(1)</script></html>"), wantErr: attachment.ErrUnsupportedType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			store, err := storage.NewLocal(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var key string
+			repo := &MockAttachmentRepository{
+				RankMock:  func(taskID, userID int) (int, error) { return tt.rank, nil },
+				UsageMock: func(userID int) (int64, error) { return tt.used, nil },
+				CreateMock: func(a *attachment.Attachment, quota int64) error {
+					key = a.StorageKey
+					return tt.quota
+				},
+			}
+			service := attachment.NewService(repo, store, testConfig(), mockLogger())
+
+			size := tt.size
+			if size == 0 {
+				size = int64(len(tt.content))
+			}
+			_, err = service.Upload(context.Background(), 42, 1, &attachment.Upload{
+				Filename: "f", Size: size, Content: bytes.NewReader(tt.content),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			// файл, не попавший в БД, не должен остаться в хранилище
+			if key != "" {
+				if _, err = store.Get(context.Background(), key); !errors.Is(err, storage.ErrNotFound) {
+					t.Errorf("orphaned file %q left in storage", key)
+				}
+			}
+		})
+	}
+}
+
+func TestService_Delete(t *testing.T) {
+	tests := []struct {
+		name       string
+		rank       int
+		uploaderID int
+		wantErr    error
+	}{
+		{name: "uploader", rank: 2, uploaderID: 42},
+		{name: "task owner", rank: 3, uploaderID: 7},
+		{name: "editor cannot delete others", rank: 2, uploaderID: 7, wantErr: attachment.ErrForbidden},
+		{name: "task not visible", rank: 0, uploaderID: 42, wantErr: attachment.ErrTaskNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deleted := false
+			repo := &MockAttachmentRepository{
+				RankMock: func(taskID, userID int) (int, error) { return tt.rank, nil },
+				GetMock: func(taskID, id int) (*attachment.Attachment, error) {
+					return &attachment.Attachment{ID: id, TaskID: taskID, UserID: tt.uploaderID}, nil
+				},
+				DeleteMock: func(id int) error {
+					deleted = true
+					return nil
+				},
+			}
+			service := attachment.NewService(repo, nil, testConfig(), mockLogger())
+
+			err := service.Delete(context.Background(), 42, 1, 5)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if deleted != (tt.wantErr == nil) {
+				t.Errorf("unexpected deleted=%v", deleted)
+			}
+		})
+	}
+}
+
+func TestService_Open(t *testing.T) {
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = store.Put(context.Background(), "tasks/1/abc", strings.NewReader("hello"), 5, ""); err != nil {
+		t.Fatal(err)
+	}
+	repo := &MockAttachmentRepository{
+		RankMock: func(taskID, userID int) (int, error) { return 1, nil },
+		GetMock: func(taskID, id int) (*attachment.Attachment, error) {
+			return &attachment.Attachment{ID: id, TaskID: taskID, StorageKey: "tasks/1/abc"}, nil
+		},
+		GetForDownloadMock: func(id int) (*attachment.Attachment, error) {
+			return &attachment.Attachment{ID: id, StorageKey: "tasks/1/abc"}, nil
+		},
+	}
+	service := attachment.NewService(repo, store, testConfig(), mockLogger())
+
+	a, err := service.Get(context.Background(), 42, 1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	link, _ := url.Parse(a.URL)
+	expires, _ := strconv.ParseInt(link.Query().Get("expires"), 10, 64)
+	signature := link.Query().Get("signature")
+
+	_, content, err := service.Open(context.Background(), 5, expires, signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(content)
+	content.Close()
+	if string(data) != "hello" {
+		t.Errorf("unexpected content %q", data)
+	}
+
+	tests := []struct {
+		name      string
+		id        int
+		expires   int64
+		signature string
+	}{
+		{name: "other attachment", id: 6, expires: expires, signature: signature},
+		{name: "extended expiry", id: 5, expires: expires + 3600, signature: signature},
+		{name: "expired", id: 5, expires: 1, signature: signature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := service.Open(context.Background(), tt.id, tt.expires, tt.signature); !errors.Is(err, attachment.ErrInvalidSignature) {
+				t.Fatalf("expected %v, got %v", attachment.ErrInvalidSignature, err)
+			}
+		})
+	}
+}
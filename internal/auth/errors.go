@@ -5,4 +5,12 @@ import "errors"
 var (
 	ErrUserExists   = errors.New("user exists")
 	ErrInvalidLogin = errors.New("invalid login or password")
+	ErrUserDisabled = errors.New("user is disabled")
+)
+
+// Исходы входа для метрик
+const (
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+	LoginLockout = "lockout"
 )
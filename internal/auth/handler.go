@@ -8,28 +8,45 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/internal/user"
 	"github.com/melnik-dev/go_todo_jwt/pkg/jwt"
+	"github.com/melnik-dev/go_todo_jwt/pkg/middleware"
 	"github.com/melnik-dev/go_todo_jwt/pkg/response"
 )
 
 type HandlerDeps struct {
 	AuthService IService
 	*configs.Config
+	// Metrics считает исходы входа; nil - не считать
+	Metrics Metrics
 }
 
 type Handler struct {
 	AuthService IService
 	*configs.Config
+	Metrics Metrics
+}
+
+// Metrics получает исход каждой попытки входа: LoginSuccess, LoginFailure или LoginLockout
+type Metrics interface {
+	LoginAttempt(outcome string)
 }
 
 func NewHandler(r *gin.Engine, deps *HandlerDeps) {
 	handler := &Handler{
 		AuthService: deps.AuthService,
 		Config:      deps.Config,
+		Metrics:     deps.Metrics,
 	}
 	auth := r.Group("/auth")
 	auth.POST("/register", handler.Register)
 	auth.POST("/login", handler.Login)
+
+	appPasswords := auth.Group("/app-passwords")
+	appPasswords.Use(middleware.IsAuthed(handler.Config.JWT.Secret))
+	appPasswords.POST("", handler.CreateAppPassword)
+	appPasswords.GET("", handler.ListAppPasswords)
+	appPasswords.DELETE("/:id", handler.DeleteAppPassword)
 }
 
 func (h *Handler) Register(c *gin.Context) {
@@ -44,7 +61,7 @@ func (h *Handler) Register(c *gin.Context) {
 	}
 	logHandle = logHandle.WithField("user_name", input.Name)
 
-	userId, err := h.AuthService.Register(input.Name, input.Password)
+	userId, err := h.AuthService.Register(c.Request.Context(), input.Name, input.Password)
 	if err != nil {
 		if errors.Is(err, ErrUserExists) {
 			logHandle.Warn(ErrUserExists.Error())
@@ -83,10 +100,16 @@ func (h *Handler) Login(c *gin.Context) {
 	}
 	logHandle = logHandle.WithField("user_name", input.Name)
 
-	userId, err := h.AuthService.Login(input.Name, input.Password)
+	userId, err := h.AuthService.Login(c.Request.Context(), input.Name, input.Password)
 	if err != nil {
 		if errors.Is(err, ErrInvalidLogin) {
-			logHandle.Warn(ErrInvalidLogin.Error())
+			// отключённый пользователь получает тот же ответ, различаются только метрики
+			if errors.Is(err, ErrUserDisabled) {
+				h.loginAttempt(LoginLockout)
+			} else {
+				h.loginAttempt(LoginFailure)
+			}
+			logHandle.Warn(err.Error())
 			response.Unauthorized(c, ErrInvalidLogin.Error())
 			return
 		}
@@ -105,10 +128,100 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
+	h.loginAttempt(LoginSuccess)
 	logHandle.Debug("Login successfully")
 	response.Success(c, http.StatusOK, LoginResponse{Token: token})
 }
 
+func (h *Handler) CreateAppPassword(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to CreateAppPassword")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var input CreateAppPasswordRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind JSON in CreateAppPassword")
+		response.BadRequest(c, "Invalid input data")
+		return
+	}
+
+	appPassword, password, err := h.AuthService.CreateAppPassword(c.Request.Context(), userID, input.Name)
+	if err != nil {
+		logHandle.WithError(err).Error("Failed to CreateAppPassword")
+		response.InternalServerError(c, "Failed to create app password")
+		return
+	}
+
+	logHandle.Debug("CreateAppPassword successfully")
+	response.Success(c, http.StatusOK, CreateAppPasswordResponse{
+		ID:       appPassword.ID,
+		Name:     appPassword.Name,
+		Password: password,
+	})
+}
+
+func (h *Handler) ListAppPasswords(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to ListAppPasswords")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	passwords, err := h.AuthService.ListAppPasswords(c.Request.Context(), userID)
+	if err != nil {
+		logHandle.WithError(err).Error("Failed to ListAppPasswords")
+		response.InternalServerError(c, "Failed to get app passwords")
+		return
+	}
+
+	logHandle.Debug("ListAppPasswords successfully")
+	response.Success(c, http.StatusOK, gin.H{"app_passwords": passwords})
+}
+
+func (h *Handler) DeleteAppPassword(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to DeleteAppPassword")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri AppPasswordURIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind app password ID")
+		response.BadRequest(c, "Invalid app password ID")
+		return
+	}
+
+	err := h.AuthService.DeleteAppPassword(c.Request.Context(), userID, uri.ID)
+	if err != nil {
+		if errors.Is(err, user.ErrAppPasswordNotFound) {
+			logHandle.Warn(user.ErrAppPasswordNotFound.Error())
+			response.NotFound(c, user.ErrAppPasswordNotFound.Error())
+			return
+		}
+		logHandle.WithError(err).Error("Failed to DeleteAppPassword")
+		response.InternalServerError(c, "Failed to delete app password")
+		return
+	}
+
+	logHandle.Debug("DeleteAppPassword successfully")
+	response.Success(c, http.StatusOK, gin.H{"message": "App password deleted successfully"})
+}
+
+func (h *Handler) loginAttempt(outcome string) {
+	if h.Metrics != nil {
+		h.Metrics.LoginAttempt(outcome)
+	}
+}
+
 func handlerLogger(c *gin.Context) *logrus.Entry {
 	return logger.FromContext(c).WithField("layer", "Handler auth layer")
 }
@@ -2,11 +2,13 @@ package auth_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/melnik-dev/go_todo_jwt/configs"
 	"github.com/melnik-dev/go_todo_jwt/internal/auth"
+	"github.com/melnik-dev/go_todo_jwt/internal/user"
 	"github.com/sirupsen/logrus"
 	"io"
 	"net/http"
@@ -18,16 +20,58 @@ import (
 type MockAuthService struct {
 	RegisterMock func(username, password string) (int, error)
 	LoginMock    func(username, password string) (int, error)
+
+	AuthenticateMock      func(username, password string) (int, error)
+	CreateAppPasswordMock func(userID int, name string) (*user.AppPassword, string, error)
+	ListAppPasswordsMock  func(userID int) ([]user.AppPassword, error)
+	DeleteAppPasswordMock func(userID, id int) error
+
+	GetUserMock         func(username string) (*user.User, error)
+	ListUsersMock       func() ([]user.User, error)
+	SetUserDisabledMock func(username string, disabled bool) error
+	ResetPasswordMock   func(username, password string) error
 }
 
-func (m *MockAuthService) Register(username, password string) (int, error) {
+func (m *MockAuthService) Register(_ context.Context, username, password string) (int, error) {
 	return m.RegisterMock(username, password)
 }
 
-func (m *MockAuthService) Login(username, password string) (int, error) {
+func (m *MockAuthService) Login(_ context.Context, username, password string) (int, error) {
 	return m.LoginMock(username, password)
 }
 
+func (m *MockAuthService) Authenticate(_ context.Context, username, password string) (int, error) {
+	return m.AuthenticateMock(username, password)
+}
+
+func (m *MockAuthService) CreateAppPassword(_ context.Context, userID int, name string) (*user.AppPassword, string, error) {
+	return m.CreateAppPasswordMock(userID, name)
+}
+
+func (m *MockAuthService) ListAppPasswords(_ context.Context, userID int) ([]user.AppPassword, error) {
+	return m.ListAppPasswordsMock(userID)
+}
+
+func (m *MockAuthService) DeleteAppPassword(_ context.Context, userID, id int) error {
+	return m.DeleteAppPasswordMock(userID, id)
+}
+
+func (m *MockAuthService) GetUser(_ context.Context, username string) (*user.User, error) {
+	return m.GetUserMock(username)
+}
+
+func (m *MockAuthService) ListUsers(_ context.Context) ([]user.User, error) {
+	return m.ListUsersMock()
+}
+
+func (m *MockAuthService) SetUserDisabled(_ context.Context, username string, disabled bool) error {
+	return m.SetUserDisabledMock(username, disabled)
+}
+
+func (m *MockAuthService) ResetPassword(_ context.Context, username, password string) error {
+	return m.ResetPasswordMock(username, password)
+}
+
 func mockGin() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	r := gin.Default()
@@ -274,3 +318,115 @@ func TestHandler_Login_FailInvalid(t *testing.T) {
 		t.Errorf("expected %d, got %d", http.StatusBadRequest, w.Code)
 	}
 }
+
+func TestHandler_CreateAppPassword_Success(t *testing.T) {
+	handler := &auth.Handler{
+		AuthService: &MockAuthService{
+			CreateAppPasswordMock: func(userID int, name string) (*user.AppPassword, string, error) {
+				return &user.AppPassword{ID: 1, UserID: userID, Name: name}, "secret", nil
+			},
+		},
+	}
+
+	r := mockGin()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", 42)
+	})
+	r.POST("/auth/app-passwords", handler.CreateAppPassword)
+
+	body, _ := json.Marshal(map[string]string{"name": "Thunderbird"})
+	req := httptest.NewRequest(http.MethodPost, "/auth/app-passwords", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var res struct {
+		Data auth.CreateAppPasswordResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Data.Password != "secret" || res.Data.Name != "Thunderbird" {
+		t.Errorf("unexpected response: %+v", res.Data)
+	}
+}
+
+func TestHandler_DeleteAppPassword_FailNotFound(t *testing.T) {
+	handler := &auth.Handler{
+		AuthService: &MockAuthService{
+			DeleteAppPasswordMock: func(userID, id int) error {
+				return user.ErrAppPasswordNotFound
+			},
+		},
+	}
+
+	r := mockGin()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", 42)
+	})
+	r.DELETE("/auth/app-passwords/:id", handler.DeleteAppPassword)
+
+	req := httptest.NewRequest(http.MethodDelete, "/auth/app-passwords/1", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+type MockMetrics struct {
+	outcomes []string
+}
+
+func (m *MockMetrics) LoginAttempt(outcome string) {
+	m.outcomes = append(m.outcomes, outcome)
+}
+
+func TestHandler_Login_Metrics(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    int
+		wantOutcome string
+	}{
+		{name: "success", wantCode: http.StatusOK, wantOutcome: auth.LoginSuccess},
+		{name: "wrong password", err: auth.ErrInvalidLogin, wantCode: http.StatusUnauthorized, wantOutcome: auth.LoginFailure},
+		{
+			name:        "disabled user",
+			err:         fmt.Errorf("%w: %w", auth.ErrInvalidLogin, auth.ErrUserDisabled),
+			wantCode:    http.StatusUnauthorized,
+			wantOutcome: auth.LoginLockout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := &MockMetrics{}
+			handler := &auth.Handler{
+				AuthService: &MockAuthService{
+					LoginMock: func(username, password string) (int, error) {
+						return 42, tt.err
+					},
+				},
+				Config: &configs.Config{
+					JWT: configs.ConfJWT{Secret: "secret", TokenTTL: time.Hour},
+				},
+				Metrics: metrics,
+			}
+
+			w := requestLoginHelper(t, Options{h: handler})
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, w.Code)
+			}
+			if len(metrics.outcomes) != 1 || metrics.outcomes[0] != tt.wantOutcome {
+				t.Errorf("expected outcome %s, got %v", tt.wantOutcome, metrics.outcomes)
+			}
+		})
+	}
+}
@@ -17,3 +17,17 @@ type LoginRequest struct {
 type LoginResponse struct {
 	Token string `json:"token"`
 }
+
+type AppPasswordURIParam struct {
+	ID int `uri:"id" binding:"required,min=1"`
+}
+
+type CreateAppPasswordRequest struct {
+	Name string `json:"name" binding:"required,min=1,max=100"`
+}
+
+type CreateAppPasswordResponse struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
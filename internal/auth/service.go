@@ -1,20 +1,47 @@
 package auth
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/melnik-dev/go_todo_jwt/internal/user"
 	"github.com/melnik-dev/go_todo_jwt/pkg/crypto"
+	"github.com/melnik-dev/go_todo_jwt/pkg/tracing"
 	"github.com/sirupsen/logrus"
+	"strings"
 )
 
 type IService interface {
-	Register(username, password string) (int, error)
-	Login(username, password string) (int, error)
+	Register(ctx context.Context, username, password string) (int, error)
+	Login(ctx context.Context, username, password string) (int, error)
+	Authenticate(ctx context.Context, username, password string) (int, error)
+	CreateAppPassword(ctx context.Context, userID int, name string) (*user.AppPassword, string, error)
+	ListAppPasswords(ctx context.Context, userID int) ([]user.AppPassword, error)
+	DeleteAppPassword(ctx context.Context, userID, id int) error
+	GetUser(ctx context.Context, username string) (*user.User, error)
+	ListUsers(ctx context.Context) ([]user.User, error)
+	SetUserDisabled(ctx context.Context, username string, disabled bool) error
+	ResetPassword(ctx context.Context, username, password string) error
 }
 
+// Transactor выполняет fn в транзакции, которую репозитории берут из ctx (db.TxManager)
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// RegisterHook дополняет регистрацию, например создаёт проекты по умолчанию
+type RegisterHook func(ctx context.Context, u *user.User) error
+
 type Service struct {
-	userRepo user.IRepository
-	logger   *logrus.Logger
+	userRepo   user.IRepository
+	tx         Transactor
+	onRegister RegisterHook
+	logger     *logrus.Logger
 }
 
 func NewService(repo user.IRepository, logger *logrus.Logger) *Service {
@@ -24,11 +51,21 @@ func NewService(repo user.IRepository, logger *logrus.Logger) *Service {
 	}
 }
 
-func (s *Service) Register(username, password string) (int, error) {
+// OnRegister выполняет hook после создания пользователя в одной транзакции tx:
+// ошибка hook отменяет и саму регистрацию
+func (s *Service) OnRegister(tx Transactor, hook RegisterHook) {
+	s.tx = tx
+	s.onRegister = hook
+}
+
+func (s *Service) Register(ctx context.Context, username, password string) (int, error) {
+	ctx, span := tracing.Start(ctx, "auth.Service.Register")
+	defer span.End()
+
 	logServ := serviceLogger(s.logger).WithField("user_name", username)
 	logServ.Debug("Attempting to Register new user")
 
-	existedUser, _ := s.userRepo.Get(username)
+	existedUser, _ := s.userRepo.Get(ctx, username)
 	if existedUser != nil {
 		logServ.Warn(ErrUserExists.Error())
 		return 0, ErrUserExists
@@ -44,8 +81,13 @@ func (s *Service) Register(username, password string) (int, error) {
 		Name:     username,
 		Password: hashPassword,
 	}
-	_, err = s.userRepo.Create(u)
+	err = s.register(ctx, u)
 	if err != nil {
+		// имя могли занять между проверкой и созданием
+		if errors.Is(err, user.ErrUsernameTaken) {
+			logServ.Warn(ErrUserExists.Error())
+			return 0, ErrUserExists
+		}
 		logServ.WithError(err).Error("failed to Create")
 		return 0, err
 	}
@@ -54,14 +96,33 @@ func (s *Service) Register(username, password string) (int, error) {
 	return u.ID, nil
 }
 
-func (s *Service) Login(username, password string) (int, error) {
+// register создаёт пользователя и выполняет onRegister в одной транзакции
+func (s *Service) register(ctx context.Context, u *user.User) error {
+	create := func(ctx context.Context) error {
+		if _, err := s.userRepo.Create(ctx, u); err != nil {
+			return err
+		}
+		if s.onRegister != nil {
+			return s.onRegister(ctx, u)
+		}
+		return nil
+	}
+	if s.tx == nil {
+		return create(ctx)
+	}
+	return s.tx.WithinTx(ctx, create)
+}
+
+func (s *Service) Login(ctx context.Context, username, password string) (int, error) {
+	ctx, span := tracing.Start(ctx, "auth.Service.Login")
+	defer span.End()
+
 	logServ := serviceLogger(s.logger).WithField("user_name", username)
 	logServ.Debug("Attempting to Login new user")
 
-	existedUser, err := s.userRepo.Get(username)
+	existedUser, err := s.loginUser(ctx, logServ, username)
 	if err != nil {
-		logServ.WithError(err).Warn("failed to fetch user")
-		return 0, ErrInvalidLogin
+		return 0, err
 	}
 
 	if !crypto.ComparePasswords(password, existedUser.Password) {
@@ -69,10 +130,229 @@ func (s *Service) Login(username, password string) (int, error) {
 		return 0, ErrInvalidLogin
 	}
 
+	// отключённому пользователю отвечаем как на неверный пароль, не раскрывая состояние аккаунта;
+	// ErrUserDisabled в цепочке нужен только для метрик и логов
+	if existedUser.DisabledAt != nil {
+		logServ.Warn(ErrUserDisabled.Error())
+		return 0, fmt.Errorf("%w: %w", ErrInvalidLogin, ErrUserDisabled)
+	}
+
 	logServ.Debug("User Login successfully")
 	return existedUser.ID, nil
 }
 
+// Authenticate проверяет логин для сторонних клиентов: сначала среди паролей приложений,
+// затем основной пароль аккаунта
+func (s *Service) Authenticate(ctx context.Context, username, password string) (int, error) {
+	ctx, span := tracing.Start(ctx, "auth.Service.Authenticate")
+	defer span.End()
+
+	logServ := serviceLogger(s.logger).WithField("user_name", username)
+	logServ.Debug("Attempting to Authenticate")
+
+	existedUser, err := s.loginUser(ctx, logServ, username)
+	if err != nil {
+		return 0, err
+	}
+	if existedUser.DisabledAt != nil {
+		logServ.Warn(ErrUserDisabled.Error())
+		return 0, ErrInvalidLogin
+	}
+
+	appPassword, err := s.userRepo.GetAppPasswordByHash(ctx, hashAppPassword(password))
+	if err != nil && !errors.Is(err, user.ErrAppPasswordNotFound) {
+		logServ.WithError(err).Error("failed to fetch app password")
+		return 0, err
+	}
+	if appPassword != nil && appPassword.UserID == existedUser.ID {
+		if err := s.userRepo.TouchAppPassword(ctx, appPassword.ID); err != nil {
+			logServ.WithError(err).Warn("failed to touch app password")
+		}
+		logServ.WithField("app_password_id", appPassword.ID).Debug("User Authenticate by app password successfully")
+		return existedUser.ID, nil
+	}
+
+	if !crypto.ComparePasswords(password, existedUser.Password) {
+		logServ.Warn(ErrInvalidLogin.Error())
+		return 0, ErrInvalidLogin
+	}
+
+	logServ.Debug("User Authenticate successfully")
+	return existedUser.ID, nil
+}
+
+// loginUser находит пользователя для входа. Ненайденный пользователь - это
+// ErrInvalidLogin, а остальные ошибки (в том числе db.ErrCanceled и
+// db.ErrTimeout) возвращаются как есть, чтобы ответ был 499/503, а не 401
+func (s *Service) loginUser(ctx context.Context, logServ *logrus.Entry, username string) (*user.User, error) {
+	existedUser, err := s.userRepo.Get(ctx, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logServ.Warn(ErrInvalidLogin.Error())
+			return nil, ErrInvalidLogin
+		}
+		logServ.WithError(err).Error("failed to fetch user")
+		return nil, err
+	}
+	return existedUser, nil
+}
+
+// CreateAppPassword генерирует пароль приложения. Пароль возвращается один раз,
+// в базе хранится только его хеш.
+func (s *Service) CreateAppPassword(ctx context.Context, userID int, name string) (*user.AppPassword, string, error) {
+	ctx, span := tracing.Start(ctx, "auth.Service.CreateAppPassword")
+	defer span.End()
+
+	logServ := serviceLogger(s.logger).WithField("user_id", userID)
+	logServ.Debug("Attempting to CreateAppPassword")
+
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		logServ.WithError(err).Error("failed to generate app password")
+		return nil, "", fmt.Errorf("failed to generate app password: %w", err)
+	}
+	password := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))
+
+	appPassword, err := s.userRepo.CreateAppPassword(ctx, &user.AppPassword{
+		UserID:       userID,
+		Name:         name,
+		PasswordHash: hashAppPassword(password),
+	})
+	if err != nil {
+		logServ.WithError(err).Error("failed to CreateAppPassword")
+		return nil, "", err
+	}
+
+	logServ.Debug("CreateAppPassword successfully")
+	return appPassword, password, nil
+}
+
+func (s *Service) ListAppPasswords(ctx context.Context, userID int) ([]user.AppPassword, error) {
+	ctx, span := tracing.Start(ctx, "auth.Service.ListAppPasswords")
+	defer span.End()
+
+	logServ := serviceLogger(s.logger).WithField("user_id", userID)
+	logServ.Debug("Attempting to ListAppPasswords")
+
+	passwords, err := s.userRepo.GetAppPasswords(ctx, userID)
+	if err != nil {
+		logServ.WithError(err).Error("failed to GetAppPasswords")
+		return nil, err
+	}
+
+	logServ.Debug("ListAppPasswords successfully")
+	return passwords, nil
+}
+
+func (s *Service) DeleteAppPassword(ctx context.Context, userID, id int) error {
+	ctx, span := tracing.Start(ctx, "auth.Service.DeleteAppPassword")
+	defer span.End()
+
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id":         userID,
+		"app_password_id": id,
+	})
+	logServ.Debug("Attempting to DeleteAppPassword")
+
+	if err := s.userRepo.DeleteAppPassword(ctx, userID, id); err != nil {
+		logServ.WithError(err).Warn("failed to DeleteAppPassword")
+		return err
+	}
+
+	logServ.Debug("DeleteAppPassword successfully")
+	return nil
+}
+
+// GetUser возвращает пользователя по имени
+func (s *Service) GetUser(ctx context.Context, username string) (*user.User, error) {
+	ctx, span := tracing.Start(ctx, "auth.Service.GetUser")
+	defer span.End()
+
+	logServ := serviceLogger(s.logger).WithField("user_name", username)
+	logServ.Debug("Attempting to GetUser")
+
+	existedUser, err := s.userRepo.Get(ctx, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logServ.Warn(user.ErrUserNotFound.Error())
+			return nil, user.ErrUserNotFound
+		}
+		logServ.WithError(err).Error("failed to Get")
+		return nil, err
+	}
+
+	logServ.Debug("GetUser successfully")
+	return existedUser, nil
+}
+
+func (s *Service) ListUsers(ctx context.Context) ([]user.User, error) {
+	ctx, span := tracing.Start(ctx, "auth.Service.ListUsers")
+	defer span.End()
+
+	logServ := serviceLogger(s.logger)
+	logServ.Debug("Attempting to ListUsers")
+
+	users, err := s.userRepo.List(ctx)
+	if err != nil {
+		logServ.WithError(err).Error("failed to List")
+		return nil, err
+	}
+
+	logServ.Debug("ListUsers successfully")
+	return users, nil
+}
+
+// SetUserDisabled отключает пользователя или снимает отключение. Отключённый
+// не может войти ни паролем, ни паролем приложения; уже выданные JWT
+// действуют до истечения срока
+func (s *Service) SetUserDisabled(ctx context.Context, username string, disabled bool) error {
+	ctx, span := tracing.Start(ctx, "auth.Service.SetUserDisabled")
+	defer span.End()
+
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_name": username,
+		"disabled":  disabled,
+	})
+	logServ.Debug("Attempting to SetUserDisabled")
+
+	if err := s.userRepo.SetDisabled(ctx, username, disabled); err != nil {
+		logServ.WithError(err).Warn("failed to SetDisabled")
+		return err
+	}
+
+	logServ.Debug("SetUserDisabled successfully")
+	return nil
+}
+
+// ResetPassword задаёт пользователю новый основной пароль
+func (s *Service) ResetPassword(ctx context.Context, username, password string) error {
+	ctx, span := tracing.Start(ctx, "auth.Service.ResetPassword")
+	defer span.End()
+
+	logServ := serviceLogger(s.logger).WithField("user_name", username)
+	logServ.Debug("Attempting to ResetPassword")
+
+	hashPassword, err := crypto.HashPassword(password)
+	if err != nil {
+		logServ.WithError(err).Error("failed to hash password")
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	if err = s.userRepo.UpdatePassword(ctx, username, hashPassword); err != nil {
+		logServ.WithError(err).Warn("failed to UpdatePassword")
+		return err
+	}
+
+	logServ.Debug("ResetPassword successfully")
+	return nil
+}
+
+// hashAppPassword - пароли приложений случайные и длинные, поэтому достаточно SHA-256 без bcrypt
+func hashAppPassword(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
 func serviceLogger(l *logrus.Logger) *logrus.Entry {
 	return l.WithField("layer", "Service auth layer")
 }
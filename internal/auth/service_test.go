@@ -1,29 +1,75 @@
 package auth_test
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/melnik-dev/go_todo_jwt/internal/auth"
 	"github.com/melnik-dev/go_todo_jwt/internal/user"
 	"github.com/melnik-dev/go_todo_jwt/pkg/crypto"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
 	"github.com/sirupsen/logrus"
 	"io"
 	"testing"
+	"time"
 )
 
 type MockUserRepository struct {
 	GetMock    func(username string) (*user.User, error)
 	CreateMock func(user *user.User) (*user.User, error)
+
+	CreateAppPasswordMock    func(password *user.AppPassword) (*user.AppPassword, error)
+	GetAppPasswordsMock      func(userID int) ([]user.AppPassword, error)
+	GetAppPasswordByHashMock func(hash string) (*user.AppPassword, error)
+	TouchAppPasswordMock     func(id int) error
+	DeleteAppPasswordMock    func(userID, id int) error
+
+	ListMock           func() ([]user.User, error)
+	SetDisabledMock    func(username string, disabled bool) error
+	UpdatePasswordMock func(username, password string) error
 }
 
-func (m *MockUserRepository) Get(username string) (*user.User, error) {
+func (m *MockUserRepository) Get(_ context.Context, username string) (*user.User, error) {
 	return m.GetMock(username)
 }
 
-func (m *MockUserRepository) Create(user *user.User) (*user.User, error) {
+func (m *MockUserRepository) Create(_ context.Context, user *user.User) (*user.User, error) {
 	return m.CreateMock(user)
 }
 
+func (m *MockUserRepository) CreateAppPassword(_ context.Context, password *user.AppPassword) (*user.AppPassword, error) {
+	return m.CreateAppPasswordMock(password)
+}
+
+func (m *MockUserRepository) GetAppPasswords(_ context.Context, userID int) ([]user.AppPassword, error) {
+	return m.GetAppPasswordsMock(userID)
+}
+
+func (m *MockUserRepository) GetAppPasswordByHash(_ context.Context, hash string) (*user.AppPassword, error) {
+	return m.GetAppPasswordByHashMock(hash)
+}
+
+func (m *MockUserRepository) TouchAppPassword(_ context.Context, id int) error {
+	return m.TouchAppPasswordMock(id)
+}
+
+func (m *MockUserRepository) DeleteAppPassword(_ context.Context, userID, id int) error {
+	return m.DeleteAppPasswordMock(userID, id)
+}
+
+func (m *MockUserRepository) List(_ context.Context) ([]user.User, error) {
+	return m.ListMock()
+}
+
+func (m *MockUserRepository) SetDisabled(_ context.Context, username string, disabled bool) error {
+	return m.SetDisabledMock(username, disabled)
+}
+
+func (m *MockUserRepository) UpdatePassword(_ context.Context, username, password string) error {
+	return m.UpdatePasswordMock(username, password)
+}
+
 func mockLogger() *logrus.Logger {
 	l := logrus.New()
 	l.SetOutput(io.Discard)
@@ -43,7 +89,7 @@ func TestService_Register_Success(t *testing.T) {
 
 	service := auth.NewService(mockRepo, mockLogger())
 
-	expId, err := service.Register("test_user", "test_pass")
+	expId, err := service.Register(context.Background(), "test_user", "test_pass")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -65,12 +111,66 @@ func TestService_Register_Fail(t *testing.T) {
 
 	service := auth.NewService(mockRepo, mockLogger())
 
-	_, err := service.Register("test_user", "test_pass")
+	_, err := service.Register(context.Background(), "test_user", "test_pass")
 	if err == nil {
 		t.Fatal(err)
 	}
 }
 
+// MockTransactor запоминает, что регистрация шла внутри транзакции
+type MockTransactor struct {
+	Calls int
+}
+
+func (m *MockTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	m.Calls++
+	return fn(ctx)
+}
+
+func TestService_Register_OnRegister(t *testing.T) {
+	hookErr := errors.New("failed to create default project")
+	tests := []struct {
+		name    string
+		hookErr error
+	}{
+		{name: "success"},
+		{name: "hook failed", hookErr: hookErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockUserRepository{
+				CreateMock: func(u *user.User) (*user.User, error) {
+					u.ID = 42
+					return u, nil
+				},
+				GetMock: func(username string) (*user.User, error) {
+					return nil, nil
+				},
+			}
+			tx := &MockTransactor{}
+			var hooked *user.User
+
+			service := auth.NewService(mockRepo, mockLogger())
+			service.OnRegister(tx, func(ctx context.Context, u *user.User) error {
+				hooked = u
+				return tt.hookErr
+			})
+
+			id, err := service.Register(context.Background(), "test_user", "test_pass")
+			if !errors.Is(err, tt.hookErr) {
+				t.Fatalf("expected %v, got %v", tt.hookErr, err)
+			}
+			if tx.Calls != 1 || hooked == nil || hooked.ID != 42 {
+				t.Errorf("expected hook for user 42 within one transaction, got %d calls and %+v", tx.Calls, hooked)
+			}
+			if tt.hookErr == nil && id != 42 {
+				t.Errorf("expected ID 42, got %d", id)
+			}
+		})
+	}
+}
+
 func TestService_Register_FailExist(t *testing.T) {
 	mockRepo := &MockUserRepository{
 		GetMock: func(username string) (*user.User, error) {
@@ -80,7 +180,7 @@ func TestService_Register_FailExist(t *testing.T) {
 
 	service := auth.NewService(mockRepo, mockLogger())
 
-	_, err := service.Register("test_user", "test_pass")
+	_, err := service.Register(context.Background(), "test_user", "test_pass")
 	if !errors.Is(err, auth.ErrUserExists) {
 		t.Fatalf("expected ErrUserExists, got %v", err)
 	}
@@ -99,7 +199,7 @@ func TestService_Login_Success(t *testing.T) {
 
 	service := auth.NewService(mockRepo, mockLogger())
 
-	expId, err := service.Login("test_user", "test_pass")
+	expId, err := service.Login(context.Background(), "test_user", "test_pass")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -112,14 +212,150 @@ func TestService_Login_Success(t *testing.T) {
 func TestService_Login_Fail(t *testing.T) {
 	mockRepo := &MockUserRepository{
 		GetMock: func(username string) (*user.User, error) {
-			return nil, auth.ErrInvalidLogin
+			return nil, sql.ErrNoRows
 		},
 	}
 
 	service := auth.NewService(mockRepo, mockLogger())
 
-	_, err := service.Login("test_user", "test_pass")
-	if err == nil {
+	_, err := service.Login(context.Background(), "test_user", "test_pass")
+	if !errors.Is(err, auth.ErrInvalidLogin) {
+		t.Fatalf("expected ErrInvalidLogin, got %v", err)
+	}
+}
+
+func TestService_Login_FailCanceled(t *testing.T) {
+	mockRepo := &MockUserRepository{
+		GetMock: func(username string) (*user.User, error) {
+			return nil, db.ErrCanceled
+		},
+	}
+
+	service := auth.NewService(mockRepo, mockLogger())
+
+	// отмена запроса не должна превращаться в неверный пароль (401)
+	_, err := service.Login(context.Background(), "test_user", "test_pass")
+	if !errors.Is(err, db.ErrCanceled) || errors.Is(err, auth.ErrInvalidLogin) {
+		t.Fatalf("expected db.ErrCanceled, got %v", err)
+	}
+	_, err = service.Authenticate(context.Background(), "test_user", "test_pass")
+	if !errors.Is(err, db.ErrCanceled) || errors.Is(err, auth.ErrInvalidLogin) {
+		t.Fatalf("expected db.ErrCanceled, got %v", err)
+	}
+}
+
+func TestService_Authenticate_AppPassword(t *testing.T) {
+	hashes := map[string]*user.AppPassword{}
+	touched := 0
+	mockRepo := &MockUserRepository{
+		GetMock: func(username string) (*user.User, error) {
+			return &user.User{ID: 42, Name: username, Password: "not a bcrypt hash"}, nil
+		},
+		CreateAppPasswordMock: func(password *user.AppPassword) (*user.AppPassword, error) {
+			password.ID = 1
+			hashes[password.PasswordHash] = password
+			return password, nil
+		},
+		GetAppPasswordByHashMock: func(hash string) (*user.AppPassword, error) {
+			if p, ok := hashes[hash]; ok {
+				return p, nil
+			}
+			return nil, user.ErrAppPasswordNotFound
+		},
+		TouchAppPasswordMock: func(id int) error {
+			touched++
+			return nil
+		},
+	}
+
+	service := auth.NewService(mockRepo, mockLogger())
+
+	_, password, err := service.CreateAppPassword(context.Background(), 42, "Thunderbird")
+	if err != nil {
 		t.Fatal(err)
 	}
+	if _, stored := hashes[password]; stored {
+		t.Error("app password must be stored hashed")
+	}
+
+	userID, err := service.Authenticate(context.Background(), "test_user", password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userID != 42 || touched != 1 {
+		t.Errorf("expected user 42 and one touch, got %d and %d", userID, touched)
+	}
+
+	if _, err := service.Authenticate(context.Background(), "test_user", "wrong"); !errors.Is(err, auth.ErrInvalidLogin) {
+		t.Errorf("expected %v, got %v", auth.ErrInvalidLogin, err)
+	}
+}
+
+func TestService_Authenticate_ForeignAppPassword(t *testing.T) {
+	mockRepo := &MockUserRepository{
+		GetMock: func(username string) (*user.User, error) {
+			return &user.User{ID: 42, Name: username, Password: "not a bcrypt hash"}, nil
+		},
+		GetAppPasswordByHashMock: func(hash string) (*user.AppPassword, error) {
+			return &user.AppPassword{ID: 1, UserID: 7}, nil
+		},
+	}
+
+	service := auth.NewService(mockRepo, mockLogger())
+
+	if _, err := service.Authenticate(context.Background(), "test_user", "someone_else"); !errors.Is(err, auth.ErrInvalidLogin) {
+		t.Fatalf("expected %v, got %v", auth.ErrInvalidLogin, err)
+	}
+}
+
+func TestService_Login_Disabled(t *testing.T) {
+	disabledAt := time.Now()
+	mockRepo := &MockUserRepository{
+		GetMock: func(username string) (*user.User, error) {
+			pass, _ := crypto.HashPassword("test_pass")
+			return &user.User{ID: 42, Password: pass, DisabledAt: &disabledAt}, nil
+		},
+	}
+
+	service := auth.NewService(mockRepo, mockLogger())
+
+	if _, err := service.Login(context.Background(), "test_user", "test_pass"); !errors.Is(err, auth.ErrInvalidLogin) {
+		t.Fatalf("expected %v, got %v", auth.ErrInvalidLogin, err)
+	}
+	if _, err := service.Authenticate(context.Background(), "test_user", "test_pass"); !errors.Is(err, auth.ErrInvalidLogin) {
+		t.Fatalf("expected %v, got %v", auth.ErrInvalidLogin, err)
+	}
+}
+
+func TestService_GetUser_NotFound(t *testing.T) {
+	mockRepo := &MockUserRepository{
+		GetMock: func(username string) (*user.User, error) {
+			return nil, sql.ErrNoRows
+		},
+	}
+
+	service := auth.NewService(mockRepo, mockLogger())
+
+	if _, err := service.GetUser(context.Background(), "test_user"); !errors.Is(err, user.ErrUserNotFound) {
+		t.Fatalf("expected %v, got %v", user.ErrUserNotFound, err)
+	}
+}
+
+func TestService_ResetPassword(t *testing.T) {
+	var stored string
+	mockRepo := &MockUserRepository{
+		UpdatePasswordMock: func(username, password string) error {
+			stored = password
+			return nil
+		},
+	}
+
+	service := auth.NewService(mockRepo, mockLogger())
+
+	if err := service.ResetPassword(context.Background(), "test_user", "new_pass"); err != nil {
+		t.Fatal(err)
+	}
+	if !crypto.ComparePasswords("new_pass", stored) {
+		t.Error("password must be stored hashed")
+	}
 }
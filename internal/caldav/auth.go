@@ -0,0 +1,100 @@
+package caldav
+
+import (
+	"crypto/sha256"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/internal/auth"
+	"github.com/melnik-dev/go_todo_jwt/pkg/logger"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	realm = "todo"
+	// клиенты шлют Basic-заголовок в каждом запросе, а bcrypt намеренно медленный,
+	// поэтому успешные проверки ненадолго запоминаются
+	credentialsTTL = time.Minute
+)
+
+type credentialsEntry struct {
+	userID    int
+	expiresAt time.Time
+}
+
+type credentialsCache struct {
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]credentialsEntry
+}
+
+func (c *credentialsCache) get(key [sha256.Size]byte) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return 0, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return 0, false
+	}
+	return entry.userID, true
+}
+
+func (c *credentialsCache) put(key [sha256.Size]byte, userID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for k, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = credentialsEntry{userID: userID, expiresAt: now.Add(credentialsTTL)}
+}
+
+// BasicAuth пускает по логину и паролю аккаунта или паролю приложения
+// и кладёт user_id в контекст так же, как middleware.IsAuthed
+func BasicAuth(authService auth.IService) gin.HandlerFunc {
+	cache := &credentialsCache{entries: make(map[[sha256.Size]byte]credentialsEntry)}
+
+	return func(c *gin.Context) {
+		auLogger := logger.FromContext(c)
+
+		username, password, ok := c.Request.BasicAuth()
+		if !ok || username == "" {
+			auLogger.Debug("Unauthorized: no basic credentials")
+			unauthorized(c)
+			return
+		}
+
+		key := sha256.Sum256([]byte(username + "\x00" + password))
+		userID, ok := cache.get(key)
+		if !ok {
+			var err error
+			userID, err = authService.Authenticate(c.Request.Context(), username, password)
+			if err != nil {
+				if errors.Is(err, auth.ErrInvalidLogin) {
+					auLogger.WithField("user_name", username).Warn("Unauthorized: invalid basic credentials")
+					unauthorized(c)
+					return
+				}
+				auLogger.WithError(err).Error("Failed to Authenticate")
+				c.AbortWithStatus(http.StatusInternalServerError)
+				return
+			}
+			cache.put(key, userID)
+		}
+
+		auLogger.WithField("user_id", userID).Debug("User authenticated successfully")
+		c.Set("user_id", userID)
+
+		c.Next()
+	}
+}
+
+func unauthorized(c *gin.Context) {
+	c.Header("WWW-Authenticate", `Basic realm="`+realm+`", charset="UTF-8"`)
+	c.AbortWithStatus(http.StatusUnauthorized)
+}
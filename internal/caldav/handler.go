@@ -0,0 +1,675 @@
+package caldav
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/melnik-dev/go_todo_jwt/internal/auth"
+	"github.com/melnik-dev/go_todo_jwt/internal/calendar"
+	"github.com/melnik-dev/go_todo_jwt/internal/task"
+	"github.com/melnik-dev/go_todo_jwt/pkg/ical"
+	"github.com/melnik-dev/go_todo_jwt/pkg/logger"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/pkg/middleware"
+)
+
+const (
+	BasePath       = "/dav"
+	principalPath  = BasePath + "/principal/"
+	homePath       = BasePath + "/calendars/"
+	collectionPath = homePath + "tasks/"
+
+	methodPropfind = "PROPFIND"
+	methodReport   = "REPORT"
+
+	syncTokenPrefix   = "urn:x-todo:sync:"
+	objectContentType = "text/calendar; charset=utf-8; component=vtodo"
+	maxObjectSize     = 1 << 20 // 1 MB
+)
+
+type HandlerDeps struct {
+	TaskService task.IService
+	AuthService auth.IService
+	*configs.Config
+	// Workspace выбирает рабочее пространство запроса; клиенты CalDAV
+	// заголовков не шлют, поэтому обычно это личное пространство
+	Workspace gin.HandlerFunc
+}
+
+type Handler struct {
+	TaskService task.IService
+	*configs.Config
+}
+
+// NewHandler регистрирует CalDAV (RFC 4791) с единственным календарём задач пользователя:
+//
+//	/dav/principal/          - принципал пользователя
+//	/dav/calendars/          - calendar-home-set
+//	/dav/calendars/tasks/    - календарь с VTODO
+//	/dav/calendars/tasks/<uid>.ics
+func NewHandler(r *gin.Engine, deps *HandlerDeps) {
+	handler := &Handler{
+		TaskService: deps.TaskService,
+		Config:      deps.Config,
+	}
+	for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodOptions, methodPropfind} {
+		r.Handle(method, "/.well-known/caldav", handler.WellKnown)
+	}
+
+	dav := r.Group(BasePath)
+	dav.Use(BasicAuth(deps.AuthService))
+	if deps.Workspace != nil {
+		dav.Use(deps.Workspace)
+	}
+	dav.Handle(http.MethodOptions, "/*path", handler.Options)
+	dav.Handle(methodPropfind, "/*path", handler.Propfind)
+	dav.Handle(methodReport, "/*path", handler.Report)
+	dav.Handle(http.MethodGet, "/*path", handler.Get)
+	dav.Handle(http.MethodHead, "/*path", handler.Get)
+	dav.Handle(http.MethodPut, "/*path", handler.Put)
+	dav.Handle(http.MethodDelete, "/*path", handler.Delete)
+}
+
+type resourceKind int
+
+const (
+	kindPrincipal resourceKind = iota
+	kindHome
+	kindCollection
+	kindObject
+)
+
+type resource struct {
+	kind resourceKind
+	href string
+	uid  string
+}
+
+// resolve разбирает экранированный путь запроса, чтобы UID со слэшем не ломал маршрут
+func resolve(escapedPath string) (resource, bool) {
+	switch escapedPath {
+	case BasePath + "/", principalPath:
+		return resource{kind: kindPrincipal, href: escapedPath}, true
+	case homePath:
+		return resource{kind: kindHome, href: homePath}, true
+	case collectionPath, strings.TrimSuffix(collectionPath, "/"):
+		return resource{kind: kindCollection, href: collectionPath}, true
+	}
+
+	name, ok := strings.CutPrefix(escapedPath, collectionPath)
+	if !ok || strings.Contains(name, "/") || !strings.HasSuffix(name, ".ics") {
+		return resource{}, false
+	}
+	uid, err := url.PathUnescape(strings.TrimSuffix(name, ".ics"))
+	if err != nil || uid == "" {
+		return resource{}, false
+	}
+	return resource{kind: kindObject, href: objectHref(uid), uid: uid}, true
+}
+
+func (r resource) allow() string {
+	switch r.kind {
+	case kindObject:
+		return "OPTIONS, PROPFIND, GET, HEAD, PUT, DELETE"
+	case kindCollection:
+		return "OPTIONS, PROPFIND, REPORT"
+	default:
+		return "OPTIONS, PROPFIND"
+	}
+}
+
+func objectHref(uid string) string {
+	return collectionPath + url.PathEscape(uid) + ".ics"
+}
+
+func etag(t *task.Task) string {
+	return fmt.Sprintf(`"%d"`, t.ChangeSeq)
+}
+
+func syncToken(seq int64) string {
+	return syncTokenPrefix + strconv.FormatInt(seq, 10)
+}
+
+func parseSyncToken(token string) (int64, bool) {
+	if token == "" {
+		return 0, true
+	}
+	raw, ok := strings.CutPrefix(token, syncTokenPrefix)
+	if !ok {
+		return 0, false
+	}
+	seq, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || seq < 0 {
+		return 0, false
+	}
+	return seq, true
+}
+
+func (h *Handler) WellKnown(c *gin.Context) {
+	c.Redirect(http.StatusMovedPermanently, BasePath+"/")
+}
+
+func (h *Handler) Options(c *gin.Context) {
+	res, ok := resolve(c.Request.URL.EscapedPath())
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.Header("DAV", "1, 3, calendar-access")
+	c.Header("Allow", res.allow())
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) Propfind(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Propfind")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+	res, ok := resolve(c.Request.URL.EscapedPath())
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	var input propfindRequest
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxObjectSize))
+	if err != nil {
+		logHandle.WithError(err).Warn("Failed to read Propfind body")
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := xml.Unmarshal(body, &input); err != nil {
+			logHandle.WithError(err).Warn("Failed to parse Propfind body")
+			c.Status(http.StatusBadRequest)
+			return
+		}
+	}
+	// пустое тело равносильно allprop
+	var requested []xml.Name
+	if input.Prop != nil && input.AllProp == nil && input.PropName == nil {
+		requested = input.Prop.list()
+	}
+	namesOnly := input.PropName != nil
+	// глубину infinity не поддерживаем и отвечаем как на 1
+	withChildren := c.GetHeader("Depth") != "0"
+
+	var responses []davResponse
+	add := func(href string, available map[xml.Name]string) {
+		found, notFound := selectProps(available, requested, namesOnly)
+		responses = append(responses, davResponse{href: href, found: found, notFound: notFound})
+	}
+
+	switch res.kind {
+	case kindPrincipal:
+		add(res.href, principalProps())
+	case kindHome:
+		add(res.href, homeProps())
+		if withChildren {
+			props, err := h.collectionProps(c.Request.Context(), userID)
+			if err != nil {
+				logHandle.WithError(err).Error("Failed to Propfind collection")
+				c.Status(http.StatusInternalServerError)
+				return
+			}
+			add(collectionPath, props)
+		}
+	case kindCollection:
+		props, err := h.collectionProps(c.Request.Context(), userID)
+		if err != nil {
+			logHandle.WithError(err).Error("Failed to Propfind collection")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		add(res.href, props)
+		if withChildren {
+			tasks, err := h.ownTasks(c.Request.Context(), userID)
+			if err != nil {
+				logHandle.WithError(err).Error("Failed to GetAll")
+				c.Status(http.StatusInternalServerError)
+				return
+			}
+			for i := range tasks {
+				add(objectHref(tasks[i].UID), objectProps(&tasks[i], requested))
+			}
+		}
+	case kindObject:
+		t, err := h.TaskService.GetByUID(c.Request.Context(), userID, res.uid)
+		if err != nil {
+			h.taskError(c, logHandle, err)
+			return
+		}
+		add(res.href, objectProps(t, requested))
+	}
+
+	logHandle.Debug("Propfind successfully")
+	writeMultistatus(c.Writer, responses, "")
+}
+
+func (h *Handler) Report(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Report")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+	res, ok := resolve(c.Request.URL.EscapedPath())
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	if res.kind != kindCollection {
+		c.Header("Allow", res.allow())
+		c.Status(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var input reportRequest
+	if err := xml.NewDecoder(http.MaxBytesReader(c.Writer, c.Request.Body, maxObjectSize)).Decode(&input); err != nil {
+		logHandle.WithError(err).Warn("Failed to parse Report body")
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	var requested []xml.Name
+	if input.Prop != nil {
+		requested = input.Prop.list()
+	}
+	add := func(responses []davResponse, t *task.Task) []davResponse {
+		found, notFound := selectProps(objectProps(t, requested), requested, false)
+		return append(responses, davResponse{href: objectHref(t.UID), found: found, notFound: notFound})
+	}
+	logHandle = logHandle.WithField("report", input.XMLName.Local)
+
+	switch input.XMLName {
+	case reportCalendarQuery:
+		filter := parseFilter(input.Filter)
+		responses := []davResponse{}
+		if !filter.matchesNothing {
+			tasks, err := h.ownTasks(c.Request.Context(), userID)
+			if err != nil {
+				logHandle.WithError(err).Error("Failed to GetAll")
+				c.Status(http.StatusInternalServerError)
+				return
+			}
+			for i := range tasks {
+				if filter.onlyIncomplete && tasks[i].Completed {
+					continue
+				}
+				responses = add(responses, &tasks[i])
+			}
+		}
+		logHandle.Debug("Report successfully")
+		writeMultistatus(c.Writer, responses, "")
+
+	case reportCalendarMultiget:
+		responses := []davResponse{}
+		for _, href := range input.Hrefs {
+			u, err := url.Parse(strings.TrimSpace(href))
+			if err != nil {
+				responses = append(responses, davResponse{href: href, status: http.StatusNotFound})
+				continue
+			}
+			obj, ok := resolve(u.EscapedPath())
+			if !ok || obj.kind != kindObject {
+				responses = append(responses, davResponse{href: href, status: http.StatusNotFound})
+				continue
+			}
+			t, err := h.TaskService.GetByUID(c.Request.Context(), userID, obj.uid)
+			if err != nil {
+				if errors.Is(err, task.ErrTaskNotFound) {
+					responses = append(responses, davResponse{href: obj.href, status: http.StatusNotFound})
+					continue
+				}
+				logHandle.WithError(err).Error("Failed to GetByUID")
+				c.Status(http.StatusInternalServerError)
+				return
+			}
+			responses = add(responses, t)
+		}
+		logHandle.Debug("Report successfully")
+		writeMultistatus(c.Writer, responses, "")
+
+	case reportSyncCollection:
+		since, ok := parseSyncToken(strings.TrimSpace(input.SyncToken))
+		if !ok {
+			logHandle.WithField("sync_token", input.SyncToken).Warn("Invalid sync token")
+			writeError(c.Writer, http.StatusForbidden, xml.Name{Space: nsDAV, Local: "valid-sync-token"})
+			return
+		}
+		changes, err := h.TaskService.GetChanges(c.Request.Context(), userID, since)
+		if err != nil {
+			logHandle.WithError(err).Error("Failed to GetChanges")
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		responses := []davResponse{}
+		for i := range changes.Updated {
+			responses = add(responses, &changes.Updated[i])
+		}
+		// при первой синхронизации удалённые задачи клиенту неинтересны
+		if since > 0 {
+			for _, uid := range changes.Deleted {
+				responses = append(responses, davResponse{href: objectHref(uid), status: http.StatusNotFound})
+			}
+		}
+		logHandle.Debug("Report successfully")
+		writeMultistatus(c.Writer, responses, syncToken(changes.Token))
+
+	default:
+		logHandle.Warn("Unsupported report")
+		writeError(c.Writer, http.StatusForbidden, xml.Name{Space: nsDAV, Local: "supported-report"})
+	}
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Get")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+	res, ok := resolve(c.Request.URL.EscapedPath())
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	if res.kind != kindObject {
+		c.Header("Allow", res.allow())
+		c.Status(http.StatusMethodNotAllowed)
+		return
+	}
+
+	t, err := h.TaskService.GetByUID(c.Request.Context(), userID, res.uid)
+	if err != nil {
+		h.taskError(c, logHandle, err)
+		return
+	}
+	data, err := encodeTask(t)
+	if err != nil {
+		logHandle.WithError(err).Error("Failed to encode task")
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	logHandle.Debug("Get successfully")
+	c.Header("ETag", etag(t))
+	c.Data(http.StatusOK, objectContentType, data)
+}
+
+func (h *Handler) Put(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Put")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+	res, ok := resolve(c.Request.URL.EscapedPath())
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	if res.kind != kindObject {
+		c.Header("Allow", res.allow())
+		c.Status(http.StatusMethodNotAllowed)
+		return
+	}
+	logHandle = logHandle.WithField("uid", res.uid)
+
+	todos, err := ical.Parse(http.MaxBytesReader(c.Writer, c.Request.Body, maxObjectSize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			logHandle.WithError(err).Warn("Calendar object is too large")
+			writeError(c.Writer, http.StatusRequestEntityTooLarge, xml.Name{Space: nsCalDAV, Local: "max-resource-size"})
+			return
+		}
+		logHandle.WithError(err).Warn(ical.ErrMalformed.Error())
+		writeError(c.Writer, http.StatusForbidden, xml.Name{Space: nsCalDAV, Local: "valid-calendar-data"})
+		return
+	}
+	if len(todos) == 0 {
+		logHandle.Warn("Calendar object has no VTODO")
+		writeError(c.Writer, http.StatusForbidden, xml.Name{Space: nsCalDAV, Local: "supported-calendar-component"})
+		return
+	}
+	// ресурс хранится по UID, поэтому имя файла обязано с ним совпадать
+	if len(todos) > 1 || (todos[0].UID != "" && todos[0].UID != res.uid) {
+		logHandle.Warn("Calendar object must contain one VTODO with UID matching its name")
+		writeError(c.Writer, http.StatusForbidden, xml.Name{Space: nsCalDAV, Local: "valid-calendar-object-resource"})
+		return
+	}
+	todos[0].UID = res.uid
+
+	existing, err := h.TaskService.GetByUID(c.Request.Context(), userID, res.uid)
+	if err != nil && !errors.Is(err, task.ErrTaskNotFound) {
+		logHandle.WithError(err).Error("Failed to GetByUID")
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	if !preconditionsMet(c.Request, existing) {
+		logHandle.Debug("Put precondition failed")
+		c.Status(http.StatusPreconditionFailed)
+		return
+	}
+
+	t, err := calendar.FromTodo(&todos[0], userID)
+	if err != nil {
+		logHandle.WithError(err).Warn("Invalid VTODO")
+		writeError(c.Writer, http.StatusForbidden, xml.Name{Space: nsCalDAV, Local: "valid-calendar-object-resource"})
+		return
+	}
+
+	created, err := h.TaskService.Upsert(c.Request.Context(), userID, &t)
+	if err != nil {
+		logHandle.WithError(err).Error("Failed to Upsert")
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	logHandle.WithField("created", created).Debug("Put successfully")
+	c.Header("ETag", etag(&t))
+	if created {
+		c.Status(http.StatusCreated)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Delete")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+	res, ok := resolve(c.Request.URL.EscapedPath())
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	if res.kind != kindObject {
+		c.Header("Allow", res.allow())
+		c.Status(http.StatusMethodNotAllowed)
+		return
+	}
+	logHandle = logHandle.WithField("uid", res.uid)
+
+	existing, err := h.TaskService.GetByUID(c.Request.Context(), userID, res.uid)
+	if err != nil {
+		h.taskError(c, logHandle, err)
+		return
+	}
+	if !preconditionsMet(c.Request, existing) {
+		logHandle.Debug("Delete precondition failed")
+		c.Status(http.StatusPreconditionFailed)
+		return
+	}
+
+	if err := h.TaskService.DeleteByUID(c.Request.Context(), userID, res.uid); err != nil {
+		h.taskError(c, logHandle, err)
+		return
+	}
+
+	logHandle.Debug("Delete successfully")
+	c.Status(http.StatusNoContent)
+}
+
+// ownTasks возвращает только собственные задачи: ресурсы календаря адресуются по UID,
+// а он уникален лишь у владельца, поэтому общие задачи по CalDAV не отдаются
+func (h *Handler) ownTasks(ctx context.Context, userID int) ([]task.Task, error) {
+	tasks, err := h.TaskService.GetAll(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	own := tasks[:0]
+	for _, t := range tasks {
+		if t.UserID == userID {
+			own = append(own, t)
+		}
+	}
+	return own, nil
+}
+
+// preconditionsMet проверяет If-Match и If-None-Match против текущей версии задачи
+func preconditionsMet(r *http.Request, existing *task.Task) bool {
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		if existing == nil {
+			return false
+		}
+		if ifMatch != "*" && !etagListContains(ifMatch, etag(existing)) {
+			return false
+		}
+	}
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" && existing != nil {
+		if ifNoneMatch == "*" || etagListContains(ifNoneMatch, etag(existing)) {
+			return false
+		}
+	}
+	return true
+}
+
+func etagListContains(list, tag string) bool {
+	for _, candidate := range strings.Split(list, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handler) taskError(c *gin.Context, logHandle *logrus.Entry, err error) {
+	if errors.Is(err, task.ErrTaskNotFound) {
+		logHandle.Warn(task.ErrTaskNotFound.Error())
+		c.Status(http.StatusNotFound)
+		return
+	}
+	logHandle.WithError(err).Error("Failed to access task")
+	c.Status(http.StatusInternalServerError)
+}
+
+func principalProps() map[xml.Name]string {
+	return map[xml.Name]string{
+		propResourceType:      "<d:principal/>",
+		propDisplayName:       "Tasks",
+		propCurrentPrincipal:  hrefXML(principalPath),
+		propPrincipalURL:      hrefXML(principalPath),
+		propCalendarHomeSet:   hrefXML(homePath),
+		propCalendarUserAddrs: "",
+	}
+}
+
+func homeProps() map[xml.Name]string {
+	return map[xml.Name]string{
+		propResourceType:     "<d:collection/>",
+		propCurrentPrincipal: hrefXML(principalPath),
+		propOwner:            hrefXML(principalPath),
+		propPrivilegeSet:     privileges,
+	}
+}
+
+const privileges = "<d:privilege><d:read/></d:privilege>" +
+	"<d:privilege><d:write/></d:privilege>" +
+	"<d:privilege><d:write-content/></d:privilege>" +
+	"<d:privilege><d:bind/></d:privilege>" +
+	"<d:privilege><d:unbind/></d:privilege>"
+
+func (h *Handler) collectionProps(ctx context.Context, userID int) (map[xml.Name]string, error) {
+	seq, err := h.TaskService.LatestChange(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	token := escape(syncToken(seq))
+	return map[xml.Name]string{
+		propResourceType:     "<d:collection/><c:calendar/>",
+		propDisplayName:      "Tasks",
+		propCurrentPrincipal: hrefXML(principalPath),
+		propOwner:            hrefXML(principalPath),
+		propPrivilegeSet:     privileges,
+		propSupportedCompSet: `<c:comp name="VTODO"/>`,
+		propSupportedReports: "<d:supported-report><d:report><c:calendar-query/></d:report></d:supported-report>" +
+			"<d:supported-report><d:report><c:calendar-multiget/></d:report></d:supported-report>" +
+			"<d:supported-report><d:report><d:sync-collection/></d:report></d:supported-report>",
+		propGetCTag:        token,
+		propSyncToken:      token,
+		propGetContentType: "text/calendar; charset=utf-8",
+	}, nil
+}
+
+// objectProps собирает свойства задачи. calendar-data кодируется только по запросу.
+func objectProps(t *task.Task, requested []xml.Name) map[xml.Name]string {
+	props := map[xml.Name]string{
+		propResourceType:   "",
+		propGetETag:        escape(etag(t)),
+		propGetContentType: objectContentType,
+	}
+	for _, name := range requested {
+		if name != propCalendarData {
+			continue
+		}
+		if data, err := encodeTask(t); err == nil {
+			props[propCalendarData] = escape(string(data))
+		}
+	}
+	return props
+}
+
+func encodeTask(t *task.Task) ([]byte, error) {
+	todo := calendar.ToTodo(t)
+	if !t.UpdatedAt.IsZero() {
+		todo.LastModified = &t.UpdatedAt
+	}
+
+	var buf bytes.Buffer
+	enc := ical.NewEncoder(&buf)
+	if err := enc.Begin(""); err != nil {
+		return nil, err
+	}
+	if err := enc.Encode(&todo); err != nil {
+		return nil, err
+	}
+	if err := enc.End(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func handlerLogger(c *gin.Context) *logrus.Entry {
+	return logger.FromContext(c).WithField("layer", "Handler caldav layer")
+}
@@ -0,0 +1,435 @@
+package caldav_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/internal/auth"
+	"github.com/melnik-dev/go_todo_jwt/internal/caldav"
+	"github.com/melnik-dev/go_todo_jwt/internal/task"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// MockTaskService встраивает task.IService, чтобы переопределять только нужные методы
+type MockTaskService struct {
+	task.IService
+	GetAllMock       func(userID int) ([]task.Task, error)
+	GetByUIDMock     func(userID int, uid string) (*task.Task, error)
+	UpsertMock       func(userID int, t *task.Task) (bool, error)
+	DeleteByUIDMock  func(userID int, uid string) error
+	GetChangesMock   func(userID int, since int64) (*task.ChangeSet, error)
+	LatestChangeMock func(userID int) (int64, error)
+}
+
+func (m *MockTaskService) GetAll(_ context.Context, userID int) ([]task.Task, error) {
+	return m.GetAllMock(userID)
+}
+
+func (m *MockTaskService) GetByUID(_ context.Context, userID int, uid string) (*task.Task, error) {
+	return m.GetByUIDMock(userID, uid)
+}
+
+func (m *MockTaskService) Upsert(_ context.Context, userID int, t *task.Task) (bool, error) {
+	return m.UpsertMock(userID, t)
+}
+
+func (m *MockTaskService) DeleteByUID(_ context.Context, userID int, uid string) error {
+	return m.DeleteByUIDMock(userID, uid)
+}
+
+func (m *MockTaskService) GetChanges(_ context.Context, userID int, since int64) (*task.ChangeSet, error) {
+	return m.GetChangesMock(userID, since)
+}
+
+func (m *MockTaskService) LatestChange(_ context.Context, userID int) (int64, error) {
+	return m.LatestChangeMock(userID)
+}
+
+type MockAuthService struct {
+	auth.IService
+}
+
+func (m *MockAuthService) Authenticate(_ context.Context, username, password string) (int, error) {
+	if username == "alice" && password == "app-password" {
+		return 42, nil
+	}
+	return 0, auth.ErrInvalidLogin
+}
+
+type multistatus struct {
+	Responses []struct {
+		Href      string `xml:"DAV: href"`
+		Status    string `xml:"DAV: status"`
+		Propstats []struct {
+			Prop struct {
+				Inner string `xml:",innerxml"`
+			} `xml:"DAV: prop"`
+			Status string `xml:"DAV: status"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+	SyncToken string `xml:"DAV: sync-token"`
+}
+
+func sampleTasks() []task.Task {
+	due := time.Date(2026, 5, 5, 15, 0, 0, 0, time.UTC)
+	return []task.Task{
+		{ID: 1, UserID: 42, UID: "task-1@example.com", Title: "Buy milk", DueAt: &due, ChangeSeq: 11},
+		{ID: 2, UserID: 42, UID: "task-2", Title: "Done task", Completed: true, ChangeSeq: 12},
+	}
+}
+
+func newService() *MockTaskService {
+	return &MockTaskService{
+		GetAllMock: func(userID int) ([]task.Task, error) {
+			return sampleTasks(), nil
+		},
+		GetByUIDMock: func(userID int, uid string) (*task.Task, error) {
+			for _, t := range sampleTasks() {
+				if t.UID == uid {
+					return &t, nil
+				}
+			}
+			return nil, task.ErrTaskNotFound
+		},
+		LatestChangeMock: func(userID int) (int64, error) {
+			return 12, nil
+		},
+	}
+}
+
+func mockGin(service task.IService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		c.Set("logger", logrus.NewEntry(logger))
+		c.Next()
+	})
+	caldav.NewHandler(r, &caldav.HandlerDeps{
+		TaskService: service,
+		AuthService: &MockAuthService{},
+	})
+	return r
+}
+
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func serve(t *testing.T, r *gin.Engine, method, path string, body []byte, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, "http://example.com"+path, bytes.NewReader(body))
+	req.SetBasicAuth("alice", "app-password")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func parseMultistatus(t *testing.T, w *httptest.ResponseRecorder) multistatus {
+	t.Helper()
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("expected %d, got %d: %s", http.StatusMultiStatus, w.Code, w.Body.String())
+	}
+	var ms multistatus
+	if err := xml.Unmarshal(w.Body.Bytes(), &ms); err != nil {
+		t.Fatalf("invalid multistatus: %v\n%s", err, w.Body.String())
+	}
+	return ms
+}
+
+func TestHandler_Unauthorized(t *testing.T) {
+	r := mockGin(newService())
+
+	req := httptest.NewRequest("PROPFIND", "http://example.com/dav/", nil)
+	req.SetBasicAuth("alice", "wrong")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+	if !strings.HasPrefix(w.Header().Get("WWW-Authenticate"), "Basic") {
+		t.Errorf("expected Basic challenge, got %q", w.Header().Get("WWW-Authenticate"))
+	}
+}
+
+func TestHandler_WellKnown(t *testing.T) {
+	r := mockGin(newService())
+
+	w := serve(t, r, "PROPFIND", "/.well-known/caldav", nil, nil)
+
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/dav/" {
+		t.Errorf("expected redirect to /dav/, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestHandler_Options(t *testing.T) {
+	r := mockGin(newService())
+
+	w := serve(t, r, http.MethodOptions, "/dav/calendars/tasks/", nil, nil)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, w.Code)
+	}
+	if !strings.Contains(w.Header().Get("DAV"), "calendar-access") {
+		t.Errorf("expected calendar-access in DAV header, got %q", w.Header().Get("DAV"))
+	}
+}
+
+func TestHandler_PropfindPrincipal(t *testing.T) {
+	r := mockGin(newService())
+
+	w := serve(t, r, "PROPFIND", "/dav/", fixture(t, "propfind_principal.xml"), map[string]string{"Depth": "0"})
+
+	ms := parseMultistatus(t, w)
+	if len(ms.Responses) != 1 || ms.Responses[0].Href != "/dav/" {
+		t.Fatalf("unexpected responses: %+v", ms.Responses)
+	}
+	props := ms.Responses[0].Propstats[0].Prop.Inner
+	if !strings.Contains(props, "<d:current-user-principal><d:href>/dav/principal/</d:href>") {
+		t.Errorf("expected current-user-principal, got %s", props)
+	}
+}
+
+func TestHandler_PropfindHome(t *testing.T) {
+	r := mockGin(newService())
+
+	w := serve(t, r, "PROPFIND", "/dav/calendars/", fixture(t, "propfind_home.xml"), map[string]string{"Depth": "1"})
+
+	ms := parseMultistatus(t, w)
+	if len(ms.Responses) != 2 || ms.Responses[1].Href != "/dav/calendars/tasks/" {
+		t.Fatalf("unexpected responses: %+v", ms.Responses)
+	}
+	collection := ms.Responses[1]
+	if len(collection.Propstats) != 2 {
+		t.Fatalf("expected found and not found propstats, got %+v", collection.Propstats)
+	}
+	found := collection.Propstats[0].Prop.Inner
+	for _, want := range []string{"<c:calendar/>", `<c:comp name="VTODO"/>`, "<d:sync-token>urn:x-todo:sync:12</d:sync-token>", "<cs:getctag>urn:x-todo:sync:12</cs:getctag>"} {
+		if !strings.Contains(found, want) {
+			t.Errorf("expected %s in %s", want, found)
+		}
+	}
+	if !strings.Contains(collection.Propstats[1].Prop.Inner, "calendar-color") ||
+		!strings.Contains(collection.Propstats[1].Status, "404") {
+		t.Errorf("expected calendar-color not found, got %+v", collection.Propstats[1])
+	}
+}
+
+func TestHandler_PropfindCollection(t *testing.T) {
+	r := mockGin(newService())
+
+	w := serve(t, r, "PROPFIND", "/dav/calendars/tasks/", fixture(t, "propfind_collection.xml"), map[string]string{"Depth": "0"})
+	ms := parseMultistatus(t, w)
+	if len(ms.Responses) != 1 || !strings.Contains(ms.Responses[0].Propstats[0].Prop.Inner, "calendar-multiget") {
+		t.Fatalf("unexpected responses: %+v", ms.Responses)
+	}
+
+	w = serve(t, r, "PROPFIND", "/dav/calendars/tasks/", fixture(t, "propfind_etags.xml"), map[string]string{"Depth": "1"})
+	ms = parseMultistatus(t, w)
+	if len(ms.Responses) != 3 {
+		t.Fatalf("expected collection and 2 tasks, got %+v", ms.Responses)
+	}
+	if ms.Responses[1].Href != "/dav/calendars/tasks/task-1@example.com.ics" {
+		t.Errorf("unexpected href %q", ms.Responses[1].Href)
+	}
+	if !strings.Contains(ms.Responses[1].Propstats[0].Prop.Inner, "<d:getetag>&#34;11&#34;</d:getetag>") {
+		t.Errorf("expected etag, got %s", ms.Responses[1].Propstats[0].Prop.Inner)
+	}
+}
+
+func TestHandler_ReportCalendarQuery(t *testing.T) {
+	r := mockGin(newService())
+
+	w := serve(t, r, "REPORT", "/dav/calendars/tasks/", fixture(t, "report_calendar_query.xml"), map[string]string{"Depth": "1"})
+
+	ms := parseMultistatus(t, w)
+	if len(ms.Responses) != 1 {
+		t.Fatalf("expected only incomplete task, got %+v", ms.Responses)
+	}
+	props := ms.Responses[0].Propstats[0].Prop.Inner
+	for _, want := range []string{"UID:task-1@example.com", "SUMMARY:Buy milk", "DUE:20260505T150000Z"} {
+		if !strings.Contains(props, want) {
+			t.Errorf("expected %s in %s", want, props)
+		}
+	}
+
+	w = serve(t, r, "REPORT", "/dav/calendars/tasks/", fixture(t, "report_calendar_query_events.xml"), map[string]string{"Depth": "1"})
+	if ms := parseMultistatus(t, w); len(ms.Responses) != 0 {
+		t.Errorf("expected no tasks for VEVENT query, got %+v", ms.Responses)
+	}
+}
+
+func TestHandler_ReportMultiget(t *testing.T) {
+	r := mockGin(newService())
+
+	w := serve(t, r, "REPORT", "/dav/calendars/tasks/", fixture(t, "report_multiget.xml"), nil)
+
+	ms := parseMultistatus(t, w)
+	if len(ms.Responses) != 2 {
+		t.Fatalf("expected 2 responses, got %+v", ms.Responses)
+	}
+	if !strings.Contains(ms.Responses[0].Propstats[0].Prop.Inner, "SUMMARY:Buy milk") {
+		t.Errorf("expected calendar-data, got %s", ms.Responses[0].Propstats[0].Prop.Inner)
+	}
+	if !strings.Contains(ms.Responses[1].Status, "404") {
+		t.Errorf("expected missing task to be 404, got %+v", ms.Responses[1])
+	}
+}
+
+func TestHandler_ReportSyncCollection(t *testing.T) {
+	service := newService()
+	service.GetChangesMock = func(userID int, since int64) (*task.ChangeSet, error) {
+		if since != 10 {
+			t.Errorf("expected since 10, got %d", since)
+		}
+		return &task.ChangeSet{
+			Token:   14,
+			Updated: sampleTasks()[:1],
+			Deleted: []string{"gone"},
+		}, nil
+	}
+	r := mockGin(service)
+
+	w := serve(t, r, "REPORT", "/dav/calendars/tasks/", fixture(t, "report_sync_collection.xml"), nil)
+
+	ms := parseMultistatus(t, w)
+	if ms.SyncToken != "urn:x-todo:sync:14" {
+		t.Errorf("expected new sync token, got %q", ms.SyncToken)
+	}
+	if len(ms.Responses) != 2 {
+		t.Fatalf("expected updated and deleted responses, got %+v", ms.Responses)
+	}
+	if ms.Responses[1].Href != "/dav/calendars/tasks/gone.ics" || !strings.Contains(ms.Responses[1].Status, "404") {
+		t.Errorf("expected deleted task as 404, got %+v", ms.Responses[1])
+	}
+}
+
+func TestHandler_ReportSyncCollection_InvalidToken(t *testing.T) {
+	r := mockGin(newService())
+
+	w := serve(t, r, "REPORT", "/dav/calendars/tasks/", fixture(t, "report_sync_collection_invalid.xml"), nil)
+
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "valid-sync-token") {
+		t.Errorf("expected 403 valid-sync-token, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandler_Get(t *testing.T) {
+	r := mockGin(newService())
+
+	w := serve(t, r, http.MethodGet, "/dav/calendars/tasks/task-2.ics", nil, nil)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, w.Code)
+	}
+	if w.Header().Get("ETag") != `"12"` {
+		t.Errorf("expected etag, got %q", w.Header().Get("ETag"))
+	}
+	if !strings.Contains(w.Body.String(), "STATUS:COMPLETED") {
+		t.Errorf("unexpected body %s", w.Body.String())
+	}
+
+	w = serve(t, r, http.MethodGet, "/dav/calendars/tasks/missing.ics", nil, nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestHandler_Put_Create(t *testing.T) {
+	service := newService()
+	var saved task.Task
+	service.UpsertMock = func(userID int, t *task.Task) (bool, error) {
+		t.ChangeSeq = 13
+		saved = *t
+		return true, nil
+	}
+	r := mockGin(service)
+
+	w := serve(t, r, http.MethodPut, "/dav/calendars/tasks/4f1c2a9e-7d3b-4c4e-9a51-3b8f0d2e6c11.ics",
+		fixture(t, "put_vtodo.ics"), map[string]string{"If-None-Match": "*", "Content-Type": "text/calendar"})
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if w.Header().Get("ETag") != `"13"` {
+		t.Errorf("expected etag, got %q", w.Header().Get("ETag"))
+	}
+	if saved.UserID != 42 || saved.Title != "Отправить отчёт" || saved.Description != "Квартальный отчёт, черновик в папке" {
+		t.Errorf("unexpected saved task %+v", saved)
+	}
+	if want := time.Date(2026, 5, 5, 15, 0, 0, 0, time.UTC); saved.DueAt == nil || !saved.DueAt.Equal(want) {
+		t.Errorf("expected due %v, got %v", want, saved.DueAt)
+	}
+}
+
+func TestHandler_Put_Preconditions(t *testing.T) {
+	service := newService()
+	service.UpsertMock = func(userID int, t *task.Task) (bool, error) {
+		return false, nil
+	}
+	r := mockGin(service)
+	body := bytes.ReplaceAll(fixture(t, "put_vtodo.ics"), []byte("4f1c2a9e-7d3b-4c4e-9a51-3b8f0d2e6c11"), []byte("task-2"))
+
+	tests := []struct {
+		name    string
+		path    string
+		headers map[string]string
+		status  int
+	}{
+		{"create over existing", "/dav/calendars/tasks/task-2.ics", map[string]string{"If-None-Match": "*"}, http.StatusPreconditionFailed},
+		{"stale etag", "/dav/calendars/tasks/task-2.ics", map[string]string{"If-Match": `"1"`}, http.StatusPreconditionFailed},
+		{"uid mismatch", "/dav/calendars/tasks/other.ics", nil, http.StatusForbidden},
+		{"update", "/dav/calendars/tasks/task-2.ics", map[string]string{"If-Match": `"12"`}, http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, r, http.MethodPut, tt.path, body, tt.headers)
+			if w.Code != tt.status {
+				t.Errorf("expected %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_Delete(t *testing.T) {
+	service := newService()
+	deleted := ""
+	service.DeleteByUIDMock = func(userID int, uid string) error {
+		deleted = uid
+		return nil
+	}
+	r := mockGin(service)
+
+	w := serve(t, r, http.MethodDelete, "/dav/calendars/tasks/task-1%40example.com.ics", nil, map[string]string{"If-Match": `"10"`})
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected %d, got %d", http.StatusPreconditionFailed, w.Code)
+	}
+
+	w = serve(t, r, http.MethodDelete, "/dav/calendars/tasks/task-1%40example.com.ics", nil, map[string]string{"If-Match": `"11"`})
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected %d, got %d", http.StatusNoContent, w.Code)
+	}
+	if deleted != "task-1@example.com" {
+		t.Errorf("expected task-1@example.com to be deleted, got %q", deleted)
+	}
+}
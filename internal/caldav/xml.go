@@ -0,0 +1,243 @@
+package caldav
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	nsDAV    = "DAV:"
+	nsCalDAV = "urn:ietf:params:xml:ns:caldav"
+	nsCS     = "http://calendarserver.org/ns/"
+)
+
+var prefixes = map[string]string{
+	nsDAV:    "d",
+	nsCalDAV: "c",
+	nsCS:     "cs",
+}
+
+var (
+	propResourceType      = xml.Name{Space: nsDAV, Local: "resourcetype"}
+	propDisplayName       = xml.Name{Space: nsDAV, Local: "displayname"}
+	propGetETag           = xml.Name{Space: nsDAV, Local: "getetag"}
+	propGetContentType    = xml.Name{Space: nsDAV, Local: "getcontenttype"}
+	propCurrentPrincipal  = xml.Name{Space: nsDAV, Local: "current-user-principal"}
+	propPrincipalURL      = xml.Name{Space: nsDAV, Local: "principal-URL"}
+	propOwner             = xml.Name{Space: nsDAV, Local: "owner"}
+	propSyncToken         = xml.Name{Space: nsDAV, Local: "sync-token"}
+	propPrivilegeSet      = xml.Name{Space: nsDAV, Local: "current-user-privilege-set"}
+	propSupportedReports  = xml.Name{Space: nsDAV, Local: "supported-report-set"}
+	propCalendarHomeSet   = xml.Name{Space: nsCalDAV, Local: "calendar-home-set"}
+	propCalendarData      = xml.Name{Space: nsCalDAV, Local: "calendar-data"}
+	propSupportedCompSet  = xml.Name{Space: nsCalDAV, Local: "supported-calendar-component-set"}
+	propCalendarUserAddrs = xml.Name{Space: nsCalDAV, Local: "calendar-user-address-set"}
+	propGetCTag           = xml.Name{Space: nsCS, Local: "getctag"}
+)
+
+// propNames - список запрошенных свойств из <d:prop>
+type propNames struct {
+	Names []struct {
+		XMLName xml.Name
+	} `xml:",any"`
+}
+
+func (p *propNames) list() []xml.Name {
+	names := make([]xml.Name, 0, len(p.Names))
+	for _, n := range p.Names {
+		names = append(names, n.XMLName)
+	}
+	return names
+}
+
+type propfindRequest struct {
+	XMLName  xml.Name   `xml:"DAV: propfind"`
+	AllProp  *struct{}  `xml:"DAV: allprop"`
+	PropName *struct{}  `xml:"DAV: propname"`
+	Prop     *propNames `xml:"DAV: prop"`
+}
+
+type compFilter struct {
+	Name        string       `xml:"name,attr"`
+	Comps       []compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+	PropFilters []struct {
+		Name         string    `xml:"name,attr"`
+		IsNotDefined *struct{} `xml:"urn:ietf:params:xml:ns:caldav is-not-defined"`
+	} `xml:"urn:ietf:params:xml:ns:caldav prop-filter"`
+}
+
+// reportRequest объединяет calendar-query, calendar-multiget и sync-collection,
+// тип отчёта определяется по XMLName
+type reportRequest struct {
+	XMLName   xml.Name
+	Prop      *propNames  `xml:"DAV: prop"`
+	Hrefs     []string    `xml:"DAV: href"`
+	SyncToken string      `xml:"DAV: sync-token"`
+	Filter    *compFilter `xml:"urn:ietf:params:xml:ns:caldav filter>comp-filter"`
+}
+
+var (
+	reportCalendarQuery    = xml.Name{Space: nsCalDAV, Local: "calendar-query"}
+	reportCalendarMultiget = xml.Name{Space: nsCalDAV, Local: "calendar-multiget"}
+	reportSyncCollection   = xml.Name{Space: nsDAV, Local: "sync-collection"}
+)
+
+// todoFilter - то, что из calendar-query реально влияет на выборку задач
+type todoFilter struct {
+	matchesNothing bool
+	onlyIncomplete bool
+}
+
+func parseFilter(f *compFilter) todoFilter {
+	if f == nil || len(f.Comps) == 0 {
+		return todoFilter{}
+	}
+	for _, comp := range f.Comps {
+		if !strings.EqualFold(comp.Name, "VTODO") {
+			continue
+		}
+		var filter todoFilter
+		for _, pf := range comp.PropFilters {
+			if strings.EqualFold(pf.Name, "COMPLETED") && pf.IsNotDefined != nil {
+				filter.onlyIncomplete = true
+			}
+		}
+		return filter
+	}
+	// запрошены только VEVENT и т.п., задач в ответе нет
+	return todoFilter{matchesNothing: true}
+}
+
+// propValue - свойство ресурса в виде готового XML содержимого
+type propValue struct {
+	name  xml.Name
+	inner string
+}
+
+type davResponse struct {
+	href     string
+	found    []propValue
+	notFound []xml.Name
+	status   int // для ответов без свойств, например удалённых ресурсов
+}
+
+// selectProps отбирает запрошенные свойства из доступных. Без списка (allprop)
+// возвращаются все, кроме дорогих вроде calendar-data.
+func selectProps(available map[xml.Name]string, requested []xml.Name, namesOnly bool) ([]propValue, []xml.Name) {
+	var found []propValue
+	var notFound []xml.Name
+
+	if requested == nil {
+		for name, inner := range available {
+			if name == propCalendarData {
+				continue
+			}
+			if namesOnly {
+				inner = ""
+			}
+			found = append(found, propValue{name: name, inner: inner})
+		}
+		sort.Slice(found, func(i, j int) bool {
+			return found[i].name.Space+found[i].name.Local < found[j].name.Space+found[j].name.Local
+		})
+		return found, nil
+	}
+
+	for _, name := range requested {
+		if inner, ok := available[name]; ok {
+			found = append(found, propValue{name: name, inner: inner})
+		} else {
+			notFound = append(notFound, name)
+		}
+	}
+	return found, notFound
+}
+
+func writeMultistatus(w http.ResponseWriter, responses []davResponse, syncToken string) {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<d:multistatus xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav" xmlns:cs="http://calendarserver.org/ns/">`)
+	for _, r := range responses {
+		b.WriteString("<d:response><d:href>")
+		b.WriteString(escape(r.href))
+		b.WriteString("</d:href>")
+		if r.status != 0 {
+			writeStatus(&b, r.status)
+		}
+		if len(r.found) > 0 {
+			b.WriteString("<d:propstat><d:prop>")
+			for _, p := range r.found {
+				writeElement(&b, p.name, p.inner)
+			}
+			b.WriteString("</d:prop>")
+			writeStatus(&b, http.StatusOK)
+			b.WriteString("</d:propstat>")
+		}
+		if len(r.notFound) > 0 {
+			b.WriteString("<d:propstat><d:prop>")
+			for _, name := range r.notFound {
+				writeElement(&b, name, "")
+			}
+			b.WriteString("</d:prop>")
+			writeStatus(&b, http.StatusNotFound)
+			b.WriteString("</d:propstat>")
+		}
+		b.WriteString("</d:response>")
+	}
+	if syncToken != "" {
+		b.WriteString("<d:sync-token>")
+		b.WriteString(escape(syncToken))
+		b.WriteString("</d:sync-token>")
+	}
+	b.WriteString("</d:multistatus>")
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	_, _ = w.Write([]byte(b.String()))
+}
+
+// writeError пишет тело ошибки с нарушенным предусловием (RFC 4918, раздел 16)
+func writeError(w http.ResponseWriter, status int, condition xml.Name) {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<d:error xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">`)
+	writeElement(&b, condition, "")
+	b.WriteString("</d:error>")
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(b.String()))
+}
+
+func writeStatus(b *strings.Builder, status int) {
+	fmt.Fprintf(b, "<d:status>HTTP/1.1 %d %s</d:status>", status, http.StatusText(status))
+}
+
+func writeElement(b *strings.Builder, name xml.Name, inner string) {
+	tag := name.Local
+	attr := ""
+	if prefix, ok := prefixes[name.Space]; ok {
+		tag = prefix + ":" + name.Local
+	} else if name.Space != "" {
+		tag = "x:" + name.Local
+		attr = ` xmlns:x="` + escape(name.Space) + `"`
+	}
+	if inner == "" {
+		b.WriteString("<" + tag + attr + "/>")
+		return
+	}
+	b.WriteString("<" + tag + attr + ">" + inner + "</" + tag + ">")
+}
+
+func hrefXML(href string) string {
+	return "<d:href>" + escape(href) + "</d:href>"
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
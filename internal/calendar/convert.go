@@ -0,0 +1,54 @@
+package calendar
+
+import (
+	"fmt"
+	"github.com/melnik-dev/go_todo_jwt/internal/task"
+	"github.com/melnik-dev/go_todo_jwt/pkg/ical"
+	"strings"
+	"unicode/utf8"
+)
+
+// ToTodo переводит задачу в VTODO. Повторений у задач нет, поэтому RRULE не заполняется.
+func ToTodo(t *task.Task) ical.Todo {
+	todo := ical.Todo{
+		UID:         t.UID,
+		Summary:     t.Title,
+		Description: t.Description,
+		Status:      ical.StatusNeedsAction,
+		Due:         t.DueAt,
+	}
+	if t.Completed {
+		todo.Status = ical.StatusCompleted
+		todo.Completed = t.CompletedAt
+	}
+	return todo
+}
+
+// FromTodo переводит VTODO в задачу пользователя с теми же ограничениями, что и у API задач
+func FromTodo(todo *ical.Todo, userID int) (task.Task, error) {
+	t := task.Task{
+		UserID:      userID,
+		UID:         strings.TrimSpace(todo.UID),
+		Title:       strings.TrimSpace(todo.Summary),
+		Description: todo.Description,
+		Completed:   todo.Status == ical.StatusCompleted || todo.Completed != nil,
+		DueAt:       todo.Due,
+	}
+	if t.Completed {
+		t.CompletedAt = todo.Completed
+	}
+
+	switch {
+	case t.UID == "":
+		return t, fmt.Errorf("UID is required")
+	case utf8.RuneCountInString(t.UID) > 255:
+		return t, fmt.Errorf("UID must be at most 255 characters")
+	case t.Title == "":
+		return t, fmt.Errorf("SUMMARY is required")
+	case utf8.RuneCountInString(t.Title) > 100:
+		return t, fmt.Errorf("SUMMARY must be at most 100 characters")
+	case utf8.RuneCountInString(t.Description) > 500:
+		return t, fmt.Errorf("DESCRIPTION must be at most 500 characters")
+	}
+	return t, nil
+}
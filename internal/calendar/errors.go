@@ -0,0 +1,8 @@
+package calendar
+
+import "errors"
+
+var (
+	ErrFeedTokenNotFound = errors.New("feed token not found")
+	ErrImportInvalid     = errors.New("calendar contains invalid tasks")
+)
@@ -0,0 +1,210 @@
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"github.com/melnik-dev/go_todo_jwt/internal/workspace"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/melnik-dev/go_todo_jwt/pkg/ical"
+	"github.com/melnik-dev/go_todo_jwt/pkg/logger"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/pkg/middleware"
+	"github.com/melnik-dev/go_todo_jwt/pkg/response"
+)
+
+const maxImportSize = 10 << 20 // 10 MB
+
+type HandlerDeps struct {
+	CalendarService IService
+	// WorkspaceService определяет личное пространство владельца фида
+	WorkspaceService workspace.IService
+	*configs.Config
+	Workspace gin.HandlerFunc
+}
+
+type Handler struct {
+	CalendarService  IService
+	WorkspaceService workspace.IService
+	*configs.Config
+}
+
+func NewHandler(r *gin.Engine, deps *HandlerDeps) {
+	handler := &Handler{
+		CalendarService:  deps.CalendarService,
+		WorkspaceService: deps.WorkspaceService,
+		Config:           deps.Config,
+	}
+	// фид открывается календарными приложениями без JWT, доступ по токену в ссылке
+	r.GET("/calendar/feed/:token", handler.Feed)
+
+	calendar := r.Group("/calendar")
+	calendar.Use(middleware.IsAuthed(handler.Config.JWT.Secret))
+	if deps.Workspace != nil {
+		calendar.Use(deps.Workspace)
+	}
+	calendar.POST("/feed-token", handler.IssueFeedToken)
+	calendar.DELETE("/feed-token", handler.RevokeFeedToken)
+	calendar.POST("/import", handler.Import)
+}
+
+func (h *Handler) IssueFeedToken(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to IssueFeedToken")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	token, err := h.CalendarService.IssueFeedToken(c.Request.Context(), userID)
+	if err != nil {
+		logHandle.WithError(err).Error("Failed to IssueFeedToken")
+		response.InternalServerError(c, "Failed to issue feed token")
+		return
+	}
+
+	logHandle.Debug("IssueFeedToken successfully")
+	response.Success(c, http.StatusOK, FeedTokenResponse{
+		Token: token,
+		URL:   fmt.Sprintf("%s://%s/calendar/feed/%s.ics", requestScheme(c), c.Request.Host, token),
+	})
+}
+
+func (h *Handler) RevokeFeedToken(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to RevokeFeedToken")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	err := h.CalendarService.RevokeFeedToken(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, ErrFeedTokenNotFound) {
+			logHandle.Warn(ErrFeedTokenNotFound.Error())
+			response.NotFound(c, ErrFeedTokenNotFound.Error())
+			return
+		}
+		logHandle.WithError(err).Error("Failed to RevokeFeedToken")
+		response.InternalServerError(c, "Failed to revoke feed token")
+		return
+	}
+
+	logHandle.Debug("RevokeFeedToken successfully")
+	response.Success(c, http.StatusOK, gin.H{"message": "Feed token revoked successfully"})
+}
+
+func (h *Handler) Feed(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Feed")
+
+	var uri FeedURIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind feed token")
+		response.NotFound(c, ErrFeedTokenNotFound.Error())
+		return
+	}
+
+	userID, err := h.CalendarService.UserIDByFeedToken(c.Request.Context(), strings.TrimSuffix(uri.Token, ".ics"))
+	if err != nil {
+		if errors.Is(err, ErrFeedTokenNotFound) {
+			logHandle.Warn(ErrFeedTokenNotFound.Error())
+			response.NotFound(c, ErrFeedTokenNotFound.Error())
+			return
+		}
+		logHandle.WithError(err).Error("Failed to UserIDByFeedToken")
+		response.InternalServerError(c, "Failed to get feed")
+		return
+	}
+	logHandle = logHandle.WithField("user_id", userID)
+
+	// фид всегда отдаёт личное пространство владельца токена
+	ctx := c.Request.Context()
+	if h.WorkspaceService != nil {
+		workspaceID, err := h.WorkspaceService.Resolve(c.Request.Context(), userID, 0)
+		if err != nil {
+			logHandle.WithError(err).Error("Failed to Resolve workspace")
+			response.InternalServerError(c, "Failed to get feed")
+			return
+		}
+		ctx = db.WithWorkspace(ctx, workspaceID)
+	}
+
+	c.Header("Content-Type", ical.ContentType)
+	c.Header("Cache-Control", "private, max-age=300")
+
+	err = h.CalendarService.Export(ctx, userID, c.Writer)
+	if err != nil {
+		if !c.Writer.Written() {
+			logHandle.WithError(err).Error("Failed to Export")
+			response.InternalServerError(c, "Failed to get feed")
+			return
+		}
+		logHandle.WithError(err).Error("Feed interrupted")
+		c.Abort()
+		return
+	}
+
+	logHandle.Debug("Feed successfully")
+}
+
+func (h *Handler) Import(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Import")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	report, err := h.CalendarService.Import(c.Request.Context(), userID, body)
+	if err != nil {
+		if errors.Is(err, ErrImportInvalid) {
+			logHandle.Warn(ErrImportInvalid.Error())
+			c.JSON(http.StatusUnprocessableEntity, response.Response{
+				Status: http.StatusUnprocessableEntity,
+				Error:  ErrImportInvalid.Error(),
+				Data:   report,
+			})
+			return
+		}
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			logHandle.WithError(err).Warn("Calendar file is too large")
+			response.Error(c, http.StatusRequestEntityTooLarge, "Calendar file is too large")
+			return
+		}
+		if errors.Is(err, ical.ErrMalformed) {
+			logHandle.WithError(err).Warn(ical.ErrMalformed.Error())
+			response.BadRequest(c, err.Error())
+			return
+		}
+		logHandle.WithError(err).Error("Failed to Import")
+		response.InternalServerError(c, "Failed to import calendar")
+		return
+	}
+
+	logHandle.Debug("Import successfully")
+	response.Success(c, http.StatusOK, report)
+}
+
+func requestScheme(c *gin.Context) string {
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		return proto
+	}
+	if c.Request.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+func handlerLogger(c *gin.Context) *logrus.Entry {
+	return logger.FromContext(c).WithField("layer", "Handler calendar layer")
+}
@@ -0,0 +1,176 @@
+package calendar_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/internal/calendar"
+	"github.com/melnik-dev/go_todo_jwt/pkg/ical"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type MockCalendarService struct {
+	IssueFeedTokenMock    func(userID int) (string, error)
+	RevokeFeedTokenMock   func(userID int) error
+	UserIDByFeedTokenMock func(token string) (int, error)
+	ExportMock            func(userID int, w io.Writer) error
+	ImportMock            func(userID int, r io.Reader) (*calendar.ImportReport, error)
+}
+
+func (m *MockCalendarService) IssueFeedToken(_ context.Context, userID int) (string, error) {
+	return m.IssueFeedTokenMock(userID)
+}
+
+func (m *MockCalendarService) RevokeFeedToken(_ context.Context, userID int) error {
+	return m.RevokeFeedTokenMock(userID)
+}
+
+func (m *MockCalendarService) UserIDByFeedToken(_ context.Context, token string) (int, error) {
+	return m.UserIDByFeedTokenMock(token)
+}
+
+func (m *MockCalendarService) Export(_ context.Context, userID int, w io.Writer) error {
+	return m.ExportMock(userID, w)
+}
+
+func (m *MockCalendarService) Import(_ context.Context, userID int, r io.Reader) (*calendar.ImportReport, error) {
+	return m.ImportMock(userID, r)
+}
+
+func mockGin() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		c.Set("logger", logrus.NewEntry(logger))
+		c.Set("user_id", 42)
+		c.Next()
+	})
+	return r
+}
+
+func TestHandler_IssueFeedToken_Success(t *testing.T) {
+	handler := &calendar.Handler{
+		CalendarService: &MockCalendarService{
+			IssueFeedTokenMock: func(userID int) (string, error) {
+				return "token", nil
+			},
+		},
+	}
+
+	r := mockGin()
+	r.POST("/calendar/feed-token", handler.IssueFeedToken)
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/calendar/feed-token", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var res struct {
+		Data calendar.FeedTokenResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Data.URL != "http://example.com/calendar/feed/token.ics" {
+		t.Errorf("unexpected feed url %s", res.Data.URL)
+	}
+}
+
+func TestHandler_RevokeFeedToken_FailNotFound(t *testing.T) {
+	handler := &calendar.Handler{
+		CalendarService: &MockCalendarService{
+			RevokeFeedTokenMock: func(userID int) error {
+				return calendar.ErrFeedTokenNotFound
+			},
+		},
+	}
+
+	r := mockGin()
+	r.DELETE("/calendar/feed-token", handler.RevokeFeedToken)
+	req := httptest.NewRequest(http.MethodDelete, "/calendar/feed-token", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestHandler_Feed_Success(t *testing.T) {
+	handler := &calendar.Handler{
+		CalendarService: &MockCalendarService{
+			UserIDByFeedTokenMock: func(token string) (int, error) {
+				if token != "token" {
+					t.Errorf("expected token without .ics suffix, got %s", token)
+				}
+				return 42, nil
+			},
+			ExportMock: func(userID int, w io.Writer) error {
+				_, err := io.WriteString(w, "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n")
+				return err
+			},
+		},
+	}
+
+	r := mockGin()
+	r.GET("/calendar/feed/:token", handler.Feed)
+	req := httptest.NewRequest(http.MethodGet, "/calendar/feed/token.ics", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected %d, got %d", http.StatusOK, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != ical.ContentType {
+		t.Errorf("expected content type %s, got %s", ical.ContentType, ct)
+	}
+}
+
+func TestHandler_Feed_FailNotFound(t *testing.T) {
+	handler := &calendar.Handler{
+		CalendarService: &MockCalendarService{
+			UserIDByFeedTokenMock: func(token string) (int, error) {
+				return 0, calendar.ErrFeedTokenNotFound
+			},
+		},
+	}
+
+	r := mockGin()
+	r.GET("/calendar/feed/:token", handler.Feed)
+	req := httptest.NewRequest(http.MethodGet, "/calendar/feed/revoked.ics", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestHandler_Import_FailMalformed(t *testing.T) {
+	handler := &calendar.Handler{
+		CalendarService: &MockCalendarService{
+			ImportMock: func(userID int, r io.Reader) (*calendar.ImportReport, error) {
+				return nil, ical.ErrMalformed
+			},
+		},
+	}
+
+	r := mockGin()
+	r.POST("/calendar/import", handler.Import)
+	req := httptest.NewRequest(http.MethodPost, "/calendar/import", bytes.NewBufferString("garbage"))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
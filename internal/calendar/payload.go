@@ -0,0 +1,22 @@
+package calendar
+
+type FeedURIParam struct {
+	Token string `uri:"token" binding:"required"`
+}
+
+type FeedTokenResponse struct {
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+type ImportItemError struct {
+	UID   string `json:"uid"`
+	Error string `json:"error"`
+}
+
+type ImportReport struct {
+	Total   int               `json:"total"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Errors  []ImportItemError `json:"errors"`
+}
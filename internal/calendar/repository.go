@@ -0,0 +1,98 @@
+package calendar
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/sirupsen/logrus"
+)
+
+type IRepository interface {
+	SaveFeedToken(ctx context.Context, userID int, tokenHash string) error
+	DeleteFeedToken(ctx context.Context, userID int) error
+	GetUserIDByFeedToken(ctx context.Context, tokenHash string) (int, error)
+}
+
+type Repository struct {
+	db     *db.Db
+	logger *logrus.Logger
+}
+
+func NewRepository(db *db.Db, logger *logrus.Logger) *Repository {
+	return &Repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// SaveFeedToken заменяет токен фида пользователя, старая ссылка перестаёт работать
+func (r *Repository) SaveFeedToken(ctx context.Context, userID int, tokenHash string) error {
+	logRepo := repositoryLogger(r.logger).WithField("user_id", userID)
+	logRepo.Debug("Attempting to SaveFeedToken")
+
+	query := `INSERT INTO feed_tokens (user_id, token_hash)
+				VALUES ($1, $2)
+				ON CONFLICT (user_id) DO UPDATE
+				SET token_hash = EXCLUDED.token_hash, created_at = NOW()`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, tokenHash); err != nil {
+		logRepo.WithError(err).Error("Failed to SaveFeedToken database")
+		return err
+	}
+
+	logRepo.Debug("SaveFeedToken database successfully")
+	return nil
+}
+
+func (r *Repository) DeleteFeedToken(ctx context.Context, userID int) error {
+	logRepo := repositoryLogger(r.logger).WithField("user_id", userID)
+	logRepo.Debug("Attempting to DeleteFeedToken")
+
+	query := `DELETE FROM feed_tokens WHERE user_id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to DeleteFeedToken database")
+		return err
+	}
+
+	row, err := result.RowsAffected()
+	if err != nil {
+		logRepo.WithError(err).Error("Failed rows affected by DeleteFeedToken database")
+		return err
+	}
+
+	if row == 0 {
+		logRepo.Warn(ErrFeedTokenNotFound.Error())
+		return ErrFeedTokenNotFound
+	}
+
+	logRepo.Debug("DeleteFeedToken database successfully")
+	return nil
+}
+
+func (r *Repository) GetUserIDByFeedToken(ctx context.Context, tokenHash string) (int, error) {
+	logRepo := repositoryLogger(r.logger)
+	logRepo.Debug("Attempting to GetUserIDByFeedToken")
+
+	var userID int
+	query := `SELECT user_id FROM feed_tokens WHERE token_hash = $1`
+
+	err := r.db.GetContext(ctx, &userID, query, tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logRepo.Warn(ErrFeedTokenNotFound.Error())
+			return 0, ErrFeedTokenNotFound
+		}
+		logRepo.WithError(err).Error("Failed to GetUserIDByFeedToken database")
+		return 0, err
+	}
+
+	logRepo.WithField("user_id", userID).Debug("GetUserIDByFeedToken database successfully")
+	return userID, nil
+}
+
+func repositoryLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Repository calendar layer")
+}
@@ -0,0 +1,88 @@
+package calendar_test
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/melnik-dev/go_todo_jwt/internal/calendar"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"regexp"
+	"testing"
+)
+
+func mockDB() (*calendar.Repository, sqlmock.Sqlmock, error) {
+	mockDb, mock, err := sqlmock.New()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pgDB := sqlx.NewDb(mockDb, "sqlMock")
+
+	repo := calendar.NewRepository(&db.Db{
+		DB: pgDB,
+	}, mockLogger())
+
+	return repo, mock, err
+}
+
+func TestCalendarRepository_SaveFeedToken_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectExec(`INSERT INTO feed_tokens`).
+		WithArgs(42, "hash").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err = repo.SaveFeedToken(context.Background(), 42, "hash"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCalendarRepository_DeleteFeedToken_FailNotFound(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM feed_tokens WHERE user_id = $1`)).
+		WithArgs(42).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err = repo.DeleteFeedToken(context.Background(), 42); err != calendar.ErrFeedTokenNotFound {
+		t.Fatalf("expected %v, got %v", calendar.ErrFeedTokenNotFound, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCalendarRepository_GetUserIDByFeedToken_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT user_id FROM feed_tokens WHERE token_hash = $1`)).
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(42))
+
+	userID, err := repo.GetUserIDByFeedToken(context.Background(), "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if userID != 42 {
+		t.Errorf("Expected user 42, got %d", userID)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,182 @@
+package calendar
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/melnik-dev/go_todo_jwt/internal/task"
+	"github.com/melnik-dev/go_todo_jwt/internal/user"
+	"github.com/melnik-dev/go_todo_jwt/pkg/ical"
+	"github.com/sirupsen/logrus"
+	"io"
+)
+
+const calendarName = "Tasks"
+
+type IService interface {
+	IssueFeedToken(ctx context.Context, userID int) (string, error)
+	RevokeFeedToken(ctx context.Context, userID int) error
+	UserIDByFeedToken(ctx context.Context, token string) (int, error)
+	Export(ctx context.Context, userID int, w io.Writer) error
+	Import(ctx context.Context, userID int, r io.Reader) (*ImportReport, error)
+}
+
+type Service struct {
+	calendarRepo IRepository
+	taskRepo     task.IRepository
+	logger       *logrus.Logger
+}
+
+func NewService(calendarRepo IRepository, taskRepo task.IRepository, logger *logrus.Logger) *Service {
+	return &Service{
+		calendarRepo: calendarRepo,
+		taskRepo:     taskRepo,
+		logger:       logger,
+	}
+}
+
+// IssueFeedToken выпускает новый токен фида, предыдущий при этом отзывается.
+// В базе хранится только хеш токена.
+func (s *Service) IssueFeedToken(ctx context.Context, userID int) (string, error) {
+	logServ := serviceLogger(s.logger).WithField("user_id", userID)
+	logServ.Debug("Attempting to IssueFeedToken")
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		logServ.WithError(err).Error("Failed to generate feed token")
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+
+	if err := s.calendarRepo.SaveFeedToken(ctx, userID, hashToken(token)); err != nil {
+		logServ.WithError(err).Error("Failed to SaveFeedToken")
+		return "", err
+	}
+
+	logServ.Debug("IssueFeedToken successfully")
+	return token, nil
+}
+
+func (s *Service) RevokeFeedToken(ctx context.Context, userID int) error {
+	logServ := serviceLogger(s.logger).WithField("user_id", userID)
+	logServ.Debug("Attempting to RevokeFeedToken")
+
+	if err := s.calendarRepo.DeleteFeedToken(ctx, userID); err != nil {
+		logServ.WithError(err).Warn("Failed to DeleteFeedToken")
+		return err
+	}
+
+	logServ.Debug("RevokeFeedToken successfully")
+	return nil
+}
+
+func (s *Service) UserIDByFeedToken(ctx context.Context, token string) (int, error) {
+	logServ := serviceLogger(s.logger)
+	logServ.Debug("Attempting to UserIDByFeedToken")
+
+	userID, err := s.calendarRepo.GetUserIDByFeedToken(ctx, hashToken(token))
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to GetUserIDByFeedToken")
+		return 0, err
+	}
+
+	logServ.WithField("user_id", userID).Debug("UserIDByFeedToken successfully")
+	return userID, nil
+}
+
+// Export пишет все задачи пользователя в w как VCALENDAR, читая их из базы построчно
+func (s *Service) Export(ctx context.Context, userID int, w io.Writer) error {
+	logServ := serviceLogger(s.logger).WithField("user_id", userID)
+	logServ.Debug("Attempting to Export")
+
+	enc := ical.NewEncoder(w)
+	begun := false
+	err := s.taskRepo.Each(ctx, &user.User{ID: userID}, func(t *task.Task) error {
+		if !begun {
+			if err := enc.Begin(calendarName); err != nil {
+				return err
+			}
+			begun = true
+		}
+		todo := ToTodo(t)
+		return enc.Encode(&todo)
+	})
+	if err == nil && !begun {
+		err = enc.Begin(calendarName)
+	}
+	if err == nil {
+		err = enc.End()
+	}
+	if err != nil {
+		logServ.WithError(err).Error("Failed to Export")
+		return err
+	}
+
+	logServ.Debug("Export successfully")
+	return nil
+}
+
+// Import создаёт или обновляет задачи по UID одной транзакцией.
+// Если хотя бы один VTODO невалиден, ничего не сохраняется.
+func (s *Service) Import(ctx context.Context, userID int, r io.Reader) (*ImportReport, error) {
+	logServ := serviceLogger(s.logger).WithField("user_id", userID)
+	logServ.Debug("Attempting to Import")
+
+	todos, err := ical.Parse(r)
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to parse calendar")
+		return nil, err
+	}
+
+	report := &ImportReport{
+		Total:  len(todos),
+		Errors: []ImportItemError{},
+	}
+	tasks := make([]task.Task, 0, len(todos))
+	byUID := make(map[string]int, len(todos))
+	for i := range todos {
+		t, err := FromTodo(&todos[i], userID)
+		if err != nil {
+			report.Errors = append(report.Errors, ImportItemError{UID: todos[i].UID, Error: err.Error()})
+			continue
+		}
+		// повторный UID в файле заменяет предыдущий
+		if idx, ok := byUID[t.UID]; ok {
+			tasks[idx] = t
+			continue
+		}
+		byUID[t.UID] = len(tasks)
+		tasks = append(tasks, t)
+	}
+
+	if len(report.Errors) > 0 {
+		logServ.WithField("errors", len(report.Errors)).Warn(ErrImportInvalid.Error())
+		return report, ErrImportInvalid
+	}
+
+	if len(tasks) > 0 {
+		created, err := s.taskRepo.UpsertBatch(ctx, tasks)
+		if err != nil {
+			logServ.WithError(err).Error("Failed to UpsertBatch")
+			return nil, err
+		}
+		report.Created = created
+		report.Updated = len(tasks) - created
+	}
+
+	logServ.WithFields(logrus.Fields{
+		"created": report.Created,
+		"updated": report.Updated,
+	}).Debug("Import successfully")
+	return report, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func serviceLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Service calendar layer")
+}
@@ -0,0 +1,180 @@
+package calendar_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/melnik-dev/go_todo_jwt/internal/calendar"
+	"github.com/melnik-dev/go_todo_jwt/internal/task"
+	"github.com/melnik-dev/go_todo_jwt/internal/user"
+	"github.com/melnik-dev/go_todo_jwt/pkg/ical"
+	"github.com/sirupsen/logrus"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+type MockCalendarRepository struct {
+	SaveFeedTokenMock        func(userID int, tokenHash string) error
+	DeleteFeedTokenMock      func(userID int) error
+	GetUserIDByFeedTokenMock func(tokenHash string) (int, error)
+}
+
+func (m *MockCalendarRepository) SaveFeedToken(_ context.Context, userID int, tokenHash string) error {
+	return m.SaveFeedTokenMock(userID, tokenHash)
+}
+
+func (m *MockCalendarRepository) DeleteFeedToken(_ context.Context, userID int) error {
+	return m.DeleteFeedTokenMock(userID)
+}
+
+func (m *MockCalendarRepository) GetUserIDByFeedToken(_ context.Context, tokenHash string) (int, error) {
+	return m.GetUserIDByFeedTokenMock(tokenHash)
+}
+
+// MockTaskRepository встраивает task.IRepository, чтобы переопределять только нужные методы
+type MockTaskRepository struct {
+	task.IRepository
+	EachMock        func(user *user.User, fn func(task *task.Task) error) error
+	UpsertBatchMock func(tasks []task.Task) (int, error)
+}
+
+func (m *MockTaskRepository) Each(_ context.Context, user *user.User, fn func(task *task.Task) error) error {
+	return m.EachMock(user, fn)
+}
+
+func (m *MockTaskRepository) UpsertBatch(_ context.Context, tasks []task.Task) (int, error) {
+	return m.UpsertBatchMock(tasks)
+}
+
+func mockLogger() *logrus.Logger {
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	return l
+}
+
+func TestService_FeedToken(t *testing.T) {
+	tokens := map[string]int{}
+	repo := &MockCalendarRepository{
+		SaveFeedTokenMock: func(userID int, tokenHash string) error {
+			tokens[tokenHash] = userID
+			return nil
+		},
+		GetUserIDByFeedTokenMock: func(tokenHash string) (int, error) {
+			userID, ok := tokens[tokenHash]
+			if !ok {
+				return 0, calendar.ErrFeedTokenNotFound
+			}
+			return userID, nil
+		},
+	}
+	service := calendar.NewService(repo, &MockTaskRepository{}, mockLogger())
+
+	token, err := service.IssueFeedToken(context.Background(), 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, stored := tokens[token]; stored {
+		t.Error("token must be stored hashed")
+	}
+
+	userID, err := service.UserIDByFeedToken(context.Background(), token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userID != 42 {
+		t.Errorf("expected user 42, got %d", userID)
+	}
+
+	if _, err := service.UserIDByFeedToken(context.Background(), "unknown"); !errors.Is(err, calendar.ErrFeedTokenNotFound) {
+		t.Errorf("expected %v, got %v", calendar.ErrFeedTokenNotFound, err)
+	}
+}
+
+func TestService_Export(t *testing.T) {
+	completedAt := time.Date(2026, 4, 30, 8, 0, 0, 0, time.UTC)
+	tasks := []task.Task{
+		{ID: 1, UserID: 42, UID: "uid-1", Title: "test_title_1", Completed: true, CompletedAt: &completedAt},
+		{ID: 2, UserID: 42, UID: "uid-2", Title: "test_title_2", Description: "test_desc_2"},
+	}
+	service := calendar.NewService(&MockCalendarRepository{}, &MockTaskRepository{
+		EachMock: func(user *user.User, fn func(task *task.Task) error) error {
+			for i := range tasks {
+				if err := fn(&tasks[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}, mockLogger())
+
+	var buf bytes.Buffer
+	if err := service.Export(context.Background(), 42, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	todos, err := ical.Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(todos) != 2 {
+		t.Fatalf("expected 2 todos, got %d", len(todos))
+	}
+	if todos[0].UID != "uid-1" || todos[0].Status != ical.StatusCompleted || !todos[0].Completed.Equal(completedAt) {
+		t.Errorf("unexpected first todo: %+v", todos[0])
+	}
+	if todos[1].UID != "uid-2" || todos[1].Status != ical.StatusNeedsAction || todos[1].Description != "test_desc_2" {
+		t.Errorf("unexpected second todo: %+v", todos[1])
+	}
+}
+
+func TestService_Import_Success(t *testing.T) {
+	var upserted []task.Task
+	service := calendar.NewService(&MockCalendarRepository{}, &MockTaskRepository{
+		UpsertBatchMock: func(tasks []task.Task) (int, error) {
+			upserted = tasks
+			return 1, nil
+		},
+	}, mockLogger())
+
+	data := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VTODO\r\nUID:uid-1\r\nSUMMARY:First\r\nEND:VTODO\r\n" +
+		"BEGIN:VTODO\r\nUID:uid-2\r\nSUMMARY:Second\r\nSTATUS:COMPLETED\r\nEND:VTODO\r\n" +
+		"BEGIN:VTODO\r\nUID:uid-1\r\nSUMMARY:First again\r\nEND:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+
+	report, err := service.Import(context.Background(), 42, strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Total != 3 || report.Created != 1 || report.Updated != 1 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if len(upserted) != 2 || upserted[0].Title != "First again" || !upserted[1].Completed || upserted[1].UserID != 42 {
+		t.Errorf("unexpected upserted tasks: %+v", upserted)
+	}
+}
+
+func TestService_Import_FailInvalid(t *testing.T) {
+	service := calendar.NewService(&MockCalendarRepository{}, &MockTaskRepository{
+		UpsertBatchMock: func(tasks []task.Task) (int, error) {
+			t.Fatal("UpsertBatch must not be called when todos are invalid")
+			return 0, nil
+		},
+	}, mockLogger())
+
+	data := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VTODO\r\nUID:uid-1\r\nSUMMARY:First\r\nEND:VTODO\r\n" +
+		"BEGIN:VTODO\r\nSUMMARY:No UID\r\nEND:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+
+	report, err := service.Import(context.Background(), 42, strings.NewReader(data))
+	if !errors.Is(err, calendar.ErrImportInvalid) {
+		t.Fatalf("expected %v, got %v", calendar.ErrImportInvalid, err)
+	}
+	if len(report.Errors) != 1 {
+		t.Errorf("expected 1 error, got %+v", report.Errors)
+	}
+}
@@ -0,0 +1,10 @@
+package comment
+
+import "errors"
+
+var (
+	ErrTaskNotFound     = errors.New("task not found")
+	ErrCommentNotFound  = errors.New("comment not found")
+	ErrCommentForbidden = errors.New("not enough permissions for comment")
+	ErrParentNotFound   = errors.New("parent comment not found")
+)
@@ -0,0 +1,225 @@
+package comment
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/pkg/logger"
+	"github.com/melnik-dev/go_todo_jwt/pkg/middleware"
+	"github.com/melnik-dev/go_todo_jwt/pkg/response"
+	"github.com/sirupsen/logrus"
+)
+
+type HandlerDeps struct {
+	CommentService IService
+	*configs.Config
+	// Workspace выбирает рабочее пространство запроса
+	Workspace gin.HandlerFunc
+}
+
+type Handler struct {
+	CommentService IService
+	*configs.Config
+}
+
+func NewHandler(r *gin.Engine, deps *HandlerDeps) {
+	handler := &Handler{
+		CommentService: deps.CommentService,
+		Config:         deps.Config,
+	}
+	comments := r.Group("/task/:id/comments")
+	comments.Use(middleware.IsAuthed(handler.Config.JWT.Secret))
+	if deps.Workspace != nil {
+		comments.Use(deps.Workspace)
+	}
+	comments.GET("/", handler.GetAll)
+	comments.POST("/", handler.Create)
+	comments.PUT("/:comment_id", handler.Update)
+	comments.DELETE("/:comment_id", handler.Delete)
+	comments.GET("/:comment_id/history", handler.GetHistory)
+}
+
+func (h *Handler) GetAll(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to GetAll comments")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri TaskURIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind task ID")
+		response.BadRequest(c, "Invalid task ID")
+		return
+	}
+
+	comments, err := h.CommentService.GetAll(c.Request.Context(), userID, uri.TaskID)
+	if err != nil {
+		if !writeError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to GetAll comments")
+			response.InternalServerError(c, "Failed to get comments")
+		}
+		return
+	}
+
+	logHandle.Debug("GetAll comments successfully")
+	response.Success(c, http.StatusOK, gin.H{"comments": comments})
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Create comment")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri TaskURIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind task ID")
+		response.BadRequest(c, "Invalid task ID")
+		return
+	}
+
+	var req CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind request")
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	comment, err := h.CommentService.Create(c.Request.Context(), userID, uri.TaskID, &req)
+	if err != nil {
+		if errors.Is(err, ErrParentNotFound) {
+			logHandle.Warn(ErrParentNotFound.Error())
+			response.BadRequest(c, ErrParentNotFound.Error())
+			return
+		}
+		if !writeError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to Create comment")
+			response.InternalServerError(c, "Failed to create comment")
+		}
+		return
+	}
+
+	logHandle.Debug("Create comment successfully")
+	response.Success(c, http.StatusCreated, comment)
+}
+
+func (h *Handler) Update(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Update comment")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri URIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind ID")
+		response.BadRequest(c, "Invalid comment ID")
+		return
+	}
+
+	var req UpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind request")
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	comment, err := h.CommentService.Update(c.Request.Context(), userID, uri.TaskID, uri.ID, req.Body)
+	if err != nil {
+		if !writeError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to Update comment")
+			response.InternalServerError(c, "Failed to update comment")
+		}
+		return
+	}
+
+	logHandle.Debug("Update comment successfully")
+	response.Success(c, http.StatusOK, comment)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Delete comment")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri URIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind ID")
+		response.BadRequest(c, "Invalid comment ID")
+		return
+	}
+
+	err := h.CommentService.Delete(c.Request.Context(), userID, uri.TaskID, uri.ID)
+	if err != nil {
+		if !writeError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to Delete comment")
+			response.InternalServerError(c, "Failed to delete comment")
+		}
+		return
+	}
+
+	logHandle.Debug("Delete comment successfully")
+	response.Success(c, http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
+
+func (h *Handler) GetHistory(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to GetHistory")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri URIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind ID")
+		response.BadRequest(c, "Invalid comment ID")
+		return
+	}
+
+	revisions, err := h.CommentService.GetHistory(c.Request.Context(), userID, uri.TaskID, uri.ID)
+	if err != nil {
+		if !writeError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to GetHistory")
+			response.InternalServerError(c, "Failed to get comment history")
+		}
+		return
+	}
+
+	logHandle.Debug("GetHistory successfully")
+	response.Success(c, http.StatusOK, gin.H{"revisions": revisions})
+}
+
+// writeError отвечает на ошибки доступа и отсутствия; false - ошибка не из их числа
+func writeError(c *gin.Context, logHandle *logrus.Entry, err error) bool {
+	switch {
+	case errors.Is(err, ErrTaskNotFound), errors.Is(err, ErrCommentNotFound):
+		logHandle.Warn(err.Error())
+		response.NotFound(c, err.Error())
+	case errors.Is(err, ErrCommentForbidden):
+		logHandle.Warn(err.Error())
+		response.Forbidden(c, err.Error())
+	default:
+		return false
+	}
+	return true
+}
+
+func handlerLogger(c *gin.Context) *logrus.Entry {
+	return logger.FromContext(c).WithField("layer", "Handler comment layer")
+}
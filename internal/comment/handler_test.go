@@ -0,0 +1,160 @@
+package comment_test
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/internal/comment"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// MockCommentService встраивает comment.IService, чтобы переопределять только нужные методы
+type MockCommentService struct {
+	comment.IService
+	CreateMock func(userID, taskID int, req *comment.CreateRequest) (*comment.Comment, error)
+	UpdateMock func(userID, taskID, commentID int, body string) (*comment.Comment, error)
+	DeleteMock func(userID, taskID, commentID int) error
+}
+
+func (m *MockCommentService) Create(ctx context.Context, userID, taskID int, req *comment.CreateRequest) (*comment.Comment, error) {
+	return m.CreateMock(userID, taskID, req)
+}
+
+func (m *MockCommentService) Update(ctx context.Context, userID, taskID, commentID int, body string) (*comment.Comment, error) {
+	return m.UpdateMock(userID, taskID, commentID, body)
+}
+
+func (m *MockCommentService) Delete(ctx context.Context, userID, taskID, commentID int) error {
+	return m.DeleteMock(userID, taskID, commentID)
+}
+
+func mockGin() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		c.Set("logger", logrus.NewEntry(logger))
+		c.Set("user_id", 42)
+		c.Next()
+	})
+	return r
+}
+
+func TestHandler_Create(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		err      error
+		wantCode int
+	}{
+		{name: "success", body: `{"body":"hello"}`, wantCode: http.StatusCreated},
+		{name: "reply", body: `{"body":"hello","parent_id":3}`, wantCode: http.StatusCreated},
+		{name: "empty body", body: `{"body":""}`, wantCode: http.StatusBadRequest},
+		{name: "unknown parent", body: `{"body":"hello","parent_id":3}`, err: comment.ErrParentNotFound, wantCode: http.StatusBadRequest},
+		{name: "task not visible", body: `{"body":"hello"}`, err: comment.ErrTaskNotFound, wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &comment.Handler{
+				CommentService: &MockCommentService{
+					CreateMock: func(userID, taskID int, req *comment.CreateRequest) (*comment.Comment, error) {
+						if tt.err != nil {
+							return nil, tt.err
+						}
+						return &comment.Comment{ID: 1, TaskID: taskID, Body: req.Body, ParentID: req.ParentID}, nil
+					},
+				},
+			}
+
+			r := mockGin()
+			r.POST("/task/:id/comments/", handler.Create)
+			req := httptest.NewRequest(http.MethodPost, "/task/1/comments/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestHandler_Update(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{name: "success", wantCode: http.StatusOK},
+		{name: "not author", err: comment.ErrCommentForbidden, wantCode: http.StatusForbidden},
+		{name: "not found", err: comment.ErrCommentNotFound, wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &comment.Handler{
+				CommentService: &MockCommentService{
+					UpdateMock: func(userID, taskID, commentID int, body string) (*comment.Comment, error) {
+						if tt.err != nil {
+							return nil, tt.err
+						}
+						return &comment.Comment{ID: commentID, TaskID: taskID, Body: body}, nil
+					},
+				},
+			}
+
+			r := mockGin()
+			r.PUT("/task/:id/comments/:comment_id", handler.Update)
+			req := httptest.NewRequest(http.MethodPut, "/task/1/comments/10", strings.NewReader(`{"body":"edited"}`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestHandler_Delete(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		err      error
+		wantCode int
+	}{
+		{name: "success", path: "/task/1/comments/10", wantCode: http.StatusOK},
+		{name: "forbidden", path: "/task/1/comments/10", err: comment.ErrCommentForbidden, wantCode: http.StatusForbidden},
+		{name: "invalid id", path: "/task/1/comments/abc", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &comment.Handler{
+				CommentService: &MockCommentService{
+					DeleteMock: func(userID, taskID, commentID int) error {
+						return tt.err
+					},
+				},
+			}
+
+			r := mockGin()
+			r.DELETE("/task/:id/comments/:comment_id", handler.Delete)
+			req := httptest.NewRequest(http.MethodDelete, tt.path, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, w.Code)
+			}
+		})
+	}
+}
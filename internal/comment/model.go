@@ -0,0 +1,38 @@
+package comment
+
+import "time"
+
+// Comment - комментарий к задаче. В ответах ответы вложены в Replies.
+type Comment struct {
+	ID        int        `db:"id" json:"id"`
+	TaskID    int        `db:"task_id" json:"task_id"`
+	ParentID  *int       `db:"parent_id" json:"parent_id"`
+	UserID    *int       `db:"user_id" json:"user_id"`
+	Username  *string    `db:"username" json:"username"`
+	Body      string     `db:"body" json:"body"`
+	HTML      string     `db:"body_html" json:"html"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	EditedAt  *time.Time `db:"edited_at" json:"edited_at"`
+	DeletedAt *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+	Mentions  []Mention  `db:"-" json:"mentions"`
+	Replies   []*Comment `db:"-" json:"replies"`
+}
+
+func (c *Comment) Deleted() bool {
+	return c.DeletedAt != nil
+}
+
+// Mention - пользователь, упомянутый в комментарии через @username
+type Mention struct {
+	CommentID int    `db:"comment_id" json:"-"`
+	UserID    int    `db:"user_id" json:"user_id"`
+	Username  string `db:"username" json:"username"`
+}
+
+// Revision - прежняя версия текста комментария
+type Revision struct {
+	ID        int       `db:"id" json:"id"`
+	CommentID int       `db:"comment_id" json:"comment_id"`
+	Body      string    `db:"body" json:"body"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
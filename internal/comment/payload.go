@@ -0,0 +1,19 @@
+package comment
+
+type TaskURIParam struct {
+	TaskID int `uri:"id" binding:"required,min=1"`
+}
+
+type URIParam struct {
+	TaskID int `uri:"id" binding:"required,min=1"`
+	ID     int `uri:"comment_id" binding:"required,min=1"`
+}
+
+type CreateRequest struct {
+	Body     string `json:"body" binding:"required,min=1,max=10000"`
+	ParentID *int   `json:"parent_id" binding:"omitempty,min=1"`
+}
+
+type UpdateRequest struct {
+	Body string `json:"body" binding:"required,min=1,max=10000"`
+}
@@ -0,0 +1,328 @@
+package comment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/sirupsen/logrus"
+)
+
+const selectComments = `SELECT c.id, c.task_id, c.parent_id, c.user_id, u.username,
+		c.body, c.body_html, c.created_at, c.edited_at, c.deleted_at
+	FROM comments c
+	LEFT JOIN users u ON u.id = c.user_id`
+
+// Все методы работают в рабочем пространстве из ctx через db.Tenant;
+// права на задачу проверяет сервис через Rank.
+type IRepository interface {
+	Rank(ctx context.Context, taskID, userID int) (int, error)
+	Create(ctx context.Context, comment *Comment) error
+	Get(ctx context.Context, taskID, id int) (*Comment, error)
+	GetByTask(ctx context.Context, taskID int) ([]Comment, error)
+	Update(ctx context.Context, comment *Comment) error
+	Delete(ctx context.Context, id int) error
+	GetRevisions(ctx context.Context, id int) ([]Revision, error)
+	ResolveMentions(ctx context.Context, taskID int, usernames []string) ([]Mention, error)
+}
+
+type Repository struct {
+	db     *db.Db
+	logger *logrus.Logger
+}
+
+func NewRepository(db *db.Db, logger *logrus.Logger) *Repository {
+	return &Repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Rank возвращает лучшую роль пользователя в задаче: 0 - задача не видна, 3 - владелец
+func (r *Repository) Rank(ctx context.Context, taskID, userID int) (int, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"task_id": taskID,
+		"user_id": userID,
+	})
+	logRepo.Debug("Attempting to get Rank")
+
+	var rank int
+	query := `SELECT COALESCE(MAX(rank), 0) FROM task_access WHERE task_id = $1 AND user_id = $2`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		return tx.GetContext(ctx, &rank, query, taskID, userID)
+	})
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to get Rank database")
+		return 0, err
+	}
+
+	logRepo.WithField("rank", rank).Debug("Rank database successfully")
+	return rank, nil
+}
+
+// Create сохраняет комментарий вместе с упоминаниями
+func (r *Repository) Create(ctx context.Context, comment *Comment) error {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"task_id": comment.TaskID,
+		"user_id": comment.UserID,
+	})
+	logRepo.Debug("Attempting to Create comment")
+
+	query := `INSERT INTO comments (task_id, parent_id, user_id, body, body_html)
+				VALUES ($1, $2, $3, $4, $5)
+				RETURNING id, created_at, (SELECT username FROM users WHERE id = $3)`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		row := tx.QueryRowContext(ctx, query, comment.TaskID, comment.ParentID, comment.UserID, comment.Body, comment.HTML)
+		if err := row.Scan(&comment.ID, &comment.CreatedAt, &comment.Username); err != nil {
+			return err
+		}
+		return saveMentions(ctx, tx, comment)
+	})
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to Create comment database")
+		return err
+	}
+
+	logRepo.WithField("comment_id", comment.ID).Debug("Create comment database successfully")
+	return nil
+}
+
+// Get возвращает комментарий задачи taskID вместе с упоминаниями
+func (r *Repository) Get(ctx context.Context, taskID, id int) (*Comment, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"task_id":    taskID,
+		"comment_id": id,
+	})
+	logRepo.Debug("Attempting to Get comment")
+
+	var comment Comment
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		query := selectComments + ` WHERE c.task_id = $1 AND c.id = $2`
+		if err := tx.GetContext(ctx, &comment, query, taskID, id); err != nil {
+			return err
+		}
+
+		query = `SELECT m.comment_id, m.user_id, u.username
+				FROM comment_mentions m
+				JOIN users u ON u.id = m.user_id
+				WHERE m.comment_id = $1
+				ORDER BY u.username`
+		comment.Mentions = make([]Mention, 0)
+		return tx.SelectContext(ctx, &comment.Mentions, query, id)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logRepo.Warn(ErrCommentNotFound.Error())
+			return nil, ErrCommentNotFound
+		}
+		logRepo.WithError(err).Error("Failed to Get comment database")
+		return nil, err
+	}
+
+	logRepo.Debug("Get comment database successfully")
+	return &comment, nil
+}
+
+// GetByTask возвращает все комментарии задачи в порядке создания
+func (r *Repository) GetByTask(ctx context.Context, taskID int) ([]Comment, error) {
+	logRepo := repositoryLogger(r.logger).WithField("task_id", taskID)
+	logRepo.Debug("Attempting to GetByTask comments")
+
+	comments := make([]Comment, 0)
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		query := selectComments + ` WHERE c.task_id = $1 ORDER BY c.id`
+		if err := tx.SelectContext(ctx, &comments, query, taskID); err != nil {
+			return err
+		}
+
+		var mentions []Mention
+		query = `SELECT m.comment_id, m.user_id, u.username
+				FROM comment_mentions m
+				JOIN comments c ON c.id = m.comment_id
+				JOIN users u ON u.id = m.user_id
+				WHERE c.task_id = $1
+				ORDER BY u.username`
+		if err := tx.SelectContext(ctx, &mentions, query, taskID); err != nil {
+			return err
+		}
+
+		byID := make(map[int]*Comment, len(comments))
+		for i := range comments {
+			comments[i].Mentions = make([]Mention, 0)
+			byID[comments[i].ID] = &comments[i]
+		}
+		for _, mention := range mentions {
+			if c, ok := byID[mention.CommentID]; ok {
+				c.Mentions = append(c.Mentions, mention)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to GetByTask comments database")
+		return nil, err
+	}
+
+	logRepo.Debug("GetByTask comments database successfully")
+	return comments, nil
+}
+
+// Update сохраняет прежний текст в истории, меняет текст и упоминания
+func (r *Repository) Update(ctx context.Context, comment *Comment) error {
+	logRepo := repositoryLogger(r.logger).WithField("comment_id", comment.ID)
+	logRepo.Debug("Attempting to Update comment")
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		query := `INSERT INTO comment_revisions (comment_id, body, created_at)
+				SELECT id, body, COALESCE(edited_at, created_at) FROM comments
+				WHERE id = $1 AND deleted_at IS NULL`
+		result, err := tx.ExecContext(ctx, query, comment.ID)
+		if err != nil {
+			return err
+		}
+		row, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if row == 0 {
+			return ErrCommentNotFound
+		}
+
+		query = `UPDATE comments SET body = $1, body_html = $2, edited_at = NOW()
+				WHERE id = $3
+				RETURNING edited_at`
+		if err = tx.GetContext(ctx, &comment.EditedAt, query, comment.Body, comment.HTML, comment.ID); err != nil {
+			return err
+		}
+
+		query = `DELETE FROM comment_mentions WHERE comment_id = $1`
+		if _, err = tx.ExecContext(ctx, query, comment.ID); err != nil {
+			return err
+		}
+		return saveMentions(ctx, tx, comment)
+	})
+	if err != nil {
+		if errors.Is(err, ErrCommentNotFound) {
+			logRepo.Warn(ErrCommentNotFound.Error())
+			return err
+		}
+		logRepo.WithError(err).Error("Failed to Update comment database")
+		return err
+	}
+
+	logRepo.Debug("Update comment database successfully")
+	return nil
+}
+
+// Delete стирает текст, историю и упоминания, но оставляет комментарий в ветке,
+// чтобы не потерять ответы на него
+func (r *Repository) Delete(ctx context.Context, id int) error {
+	logRepo := repositoryLogger(r.logger).WithField("comment_id", id)
+	logRepo.Debug("Attempting to Delete comment")
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		query := `UPDATE comments SET body = '', body_html = '', deleted_at = NOW()
+				WHERE id = $1 AND deleted_at IS NULL`
+		result, err := tx.ExecContext(ctx, query, id)
+		if err != nil {
+			return err
+		}
+		row, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if row == 0 {
+			return ErrCommentNotFound
+		}
+
+		if _, err = tx.ExecContext(ctx, `DELETE FROM comment_revisions WHERE comment_id = $1`, id); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `DELETE FROM comment_mentions WHERE comment_id = $1`, id)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, ErrCommentNotFound) {
+			logRepo.Warn(ErrCommentNotFound.Error())
+			return err
+		}
+		logRepo.WithError(err).Error("Failed to Delete comment database")
+		return err
+	}
+
+	logRepo.Debug("Delete comment database successfully")
+	return nil
+}
+
+func (r *Repository) GetRevisions(ctx context.Context, id int) ([]Revision, error) {
+	logRepo := repositoryLogger(r.logger).WithField("comment_id", id)
+	logRepo.Debug("Attempting to GetRevisions")
+
+	revisions := make([]Revision, 0)
+	query := `SELECT id, comment_id, body, created_at FROM comment_revisions WHERE comment_id = $1 ORDER BY id`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		return tx.SelectContext(ctx, &revisions, query, id)
+	})
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to GetRevisions database")
+		return nil, err
+	}
+
+	logRepo.Debug("GetRevisions database successfully")
+	return revisions, nil
+}
+
+// ResolveMentions находит среди usernames (без учёта регистра) пользователей,
+// у которых есть доступ к задаче. Остальные имена молча пропускаются.
+func (r *Repository) ResolveMentions(ctx context.Context, taskID int, usernames []string) ([]Mention, error) {
+	logRepo := repositoryLogger(r.logger).WithField("task_id", taskID)
+	logRepo.Debug("Attempting to ResolveMentions")
+
+	mentions := make([]Mention, 0)
+	if len(usernames) == 0 {
+		return mentions, nil
+	}
+	lowered := make([]string, len(usernames))
+	for i, name := range usernames {
+		lowered[i] = strings.ToLower(name)
+	}
+
+	query := `SELECT DISTINCT u.id AS user_id, u.username
+				FROM users u
+				JOIN task_access a ON a.user_id = u.id
+				WHERE a.task_id = $1 AND LOWER(u.username) = ANY($2)
+				ORDER BY u.username`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		return tx.SelectContext(ctx, &mentions, query, taskID, pq.Array(lowered))
+	})
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to ResolveMentions database")
+		return nil, err
+	}
+
+	logRepo.WithField("mentions", len(mentions)).Debug("ResolveMentions database successfully")
+	return mentions, nil
+}
+
+func saveMentions(ctx context.Context, tx *sqlx.Tx, comment *Comment) error {
+	query := `INSERT INTO comment_mentions (comment_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	for i := range comment.Mentions {
+		comment.Mentions[i].CommentID = comment.ID
+		if _, err := tx.ExecContext(ctx, query, comment.ID, comment.Mentions[i].UserID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func repositoryLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Repository comment layer")
+}
@@ -0,0 +1,149 @@
+package comment_test
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/melnik-dev/go_todo_jwt/internal/comment"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func mockDB() (*comment.Repository, sqlmock.Sqlmock, error) {
+	mockDb, mock, err := sqlmock.New()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pgDB := sqlx.NewDb(mockDb, "sqlMock")
+
+	repo := comment.NewRepository(&db.Db{
+		DB: pgDB,
+	}, mockLogger())
+
+	return repo, mock, err
+}
+
+var ctx = db.WithWorkspace(context.Background(), 1)
+
+func expectTenant(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT set_config('role', $1, true), set_config('app.workspace_id', $2, true)`)).
+		WithArgs(db.TenantRole, "1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestCommentRepository_Create_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userID := 42
+	expectTenant(mock)
+	mock.ExpectQuery(`INSERT INTO comments \(task_id, parent_id, user_id, body, body_html\)`).
+		WithArgs(1, nil, &userID, "hi @bob", "<p>hi @bob</p>").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "username"}).AddRow(10, time.Now(), "alice"))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO comment_mentions (comment_id, user_id) VALUES ($1, $2)`)).
+		WithArgs(10, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	c := &comment.Comment{
+		TaskID:   1,
+		UserID:   &userID,
+		Body:     "hi @bob",
+		HTML:     "<p>hi @bob</p>",
+		Mentions: []comment.Mention{{UserID: 7, Username: "bob"}},
+	}
+	if err = repo.Create(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	if c.ID != 10 || c.Username == nil || *c.Username != "alice" || c.Mentions[0].CommentID != 10 {
+		t.Errorf("unexpected comment %+v", c)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCommentRepository_Update_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectTenant(mock)
+	mock.ExpectExec(`INSERT INTO comment_revisions`).
+		WithArgs(10).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`UPDATE comments SET body = \$1, body_html = \$2, edited_at = NOW\(\)`).
+		WithArgs("new", "<p>new</p>", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"edited_at"}).AddRow(time.Now()))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM comment_mentions WHERE comment_id = $1`)).
+		WithArgs(10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	c := &comment.Comment{ID: 10, Body: "new", HTML: "<p>new</p>"}
+	if err = repo.Update(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	if c.EditedAt == nil {
+		t.Error("expected edited_at to be set")
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCommentRepository_Delete_FailNotFound(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectTenant(mock)
+	mock.ExpectExec(`UPDATE comments SET body = '', body_html = '', deleted_at = NOW\(\)`).
+		WithArgs(10).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	if err = repo.Delete(ctx, 10); err != comment.ErrCommentNotFound {
+		t.Fatalf("expected %v, got %v", comment.ErrCommentNotFound, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCommentRepository_ResolveMentions_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectTenant(mock)
+	mock.ExpectQuery(`JOIN task_access a ON a.user_id = u.id`).
+		WithArgs(1, pq.Array([]string{"bob", "carol"})).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "username"}).AddRow(7, "Bob"))
+	mock.ExpectCommit()
+
+	mentions, err := repo.ResolveMentions(ctx, 1, []string{"Bob", "carol"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mentions) != 1 || mentions[0].UserID != 7 {
+		t.Errorf("unexpected mentions %+v", mentions)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,292 @@
+package comment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/melnik-dev/go_todo_jwt/internal/notification"
+	"github.com/melnik-dev/go_todo_jwt/pkg/markdown"
+	"github.com/sirupsen/logrus"
+)
+
+// rankOwner - ранг владельца задачи в task_access
+const rankOwner = 3
+
+type IService interface {
+	GetAll(ctx context.Context, userID, taskID int) ([]*Comment, error)
+	Create(ctx context.Context, userID, taskID int, req *CreateRequest) (*Comment, error)
+	Update(ctx context.Context, userID, taskID, commentID int, body string) (*Comment, error)
+	Delete(ctx context.Context, userID, taskID, commentID int) error
+	GetHistory(ctx context.Context, userID, taskID, commentID int) ([]Revision, error)
+}
+
+type Service struct {
+	commentRepo IRepository
+	notifier    notification.Notifier
+	logger      *logrus.Logger
+}
+
+func NewService(commentRepo IRepository, notifier notification.Notifier, logger *logrus.Logger) *Service {
+	return &Service{
+		commentRepo: commentRepo,
+		notifier:    notifier,
+		logger:      logger,
+	}
+}
+
+// GetAll возвращает комментарии задачи деревом: корневые по порядку создания,
+// ответы вложены в Replies
+func (s *Service) GetAll(ctx context.Context, userID, taskID int) ([]*Comment, error) {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id": userID,
+		"task_id": taskID,
+	})
+	logServ.Debug("Attempting to GetAll comments")
+
+	if _, err := s.require(ctx, userID, taskID); err != nil {
+		logServ.WithError(err).Warn("Failed to GetAll comments")
+		return nil, err
+	}
+
+	comments, err := s.commentRepo.GetByTask(ctx, taskID)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to GetByTask")
+		return nil, err
+	}
+
+	logServ.Debug("GetAll comments successfully")
+	return buildTree(comments), nil
+}
+
+// Create добавляет комментарий или ответ. Комментировать может любой, кто видит задачу.
+func (s *Service) Create(ctx context.Context, userID, taskID int, req *CreateRequest) (*Comment, error) {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id": userID,
+		"task_id": taskID,
+	})
+	logServ.Debug("Attempting to Create comment")
+
+	if _, err := s.require(ctx, userID, taskID); err != nil {
+		logServ.WithError(err).Warn("Failed to Create comment")
+		return nil, err
+	}
+
+	if req.ParentID != nil {
+		parent, err := s.commentRepo.Get(ctx, taskID, *req.ParentID)
+		if err != nil {
+			if errors.Is(err, ErrCommentNotFound) {
+				logServ.Warn(ErrParentNotFound.Error())
+				return nil, ErrParentNotFound
+			}
+			logServ.WithError(err).Error("Failed to Get parent comment")
+			return nil, err
+		}
+		if parent.Deleted() {
+			logServ.Warn(ErrParentNotFound.Error())
+			return nil, ErrParentNotFound
+		}
+	}
+
+	mentions, err := s.commentRepo.ResolveMentions(ctx, taskID, markdown.Mentions(req.Body))
+	if err != nil {
+		logServ.WithError(err).Error("Failed to ResolveMentions")
+		return nil, err
+	}
+
+	comment := &Comment{
+		TaskID:   taskID,
+		ParentID: req.ParentID,
+		UserID:   &userID,
+		Body:     req.Body,
+		HTML:     markdown.Render(req.Body),
+		Mentions: mentions,
+		Replies:  []*Comment{},
+	}
+	if err = s.commentRepo.Create(ctx, comment); err != nil {
+		logServ.WithError(err).Error("Failed to Create comment")
+		return nil, err
+	}
+	s.notifyMentions(ctx, logServ, comment, nil)
+
+	logServ.WithField("comment_id", comment.ID).Debug("Create comment successfully")
+	return comment, nil
+}
+
+// Update меняет текст комментария; править можно только свои комментарии.
+// Уведомления получают только впервые упомянутые пользователи.
+func (s *Service) Update(ctx context.Context, userID, taskID, commentID int, body string) (*Comment, error) {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id":    userID,
+		"task_id":    taskID,
+		"comment_id": commentID,
+	})
+	logServ.Debug("Attempting to Update comment")
+
+	if _, err := s.require(ctx, userID, taskID); err != nil {
+		logServ.WithError(err).Warn("Failed to Update comment")
+		return nil, err
+	}
+
+	comment, err := s.commentRepo.Get(ctx, taskID, commentID)
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to Get comment")
+		return nil, err
+	}
+	if comment.Deleted() {
+		logServ.Warn(ErrCommentNotFound.Error())
+		return nil, ErrCommentNotFound
+	}
+	if comment.UserID == nil || *comment.UserID != userID {
+		logServ.Warn(ErrCommentForbidden.Error())
+		return nil, ErrCommentForbidden
+	}
+
+	previous := comment.Mentions
+	comment.Mentions, err = s.commentRepo.ResolveMentions(ctx, taskID, markdown.Mentions(body))
+	if err != nil {
+		logServ.WithError(err).Error("Failed to ResolveMentions")
+		return nil, err
+	}
+	comment.Body = body
+	comment.HTML = markdown.Render(body)
+	comment.Replies = []*Comment{}
+
+	if err = s.commentRepo.Update(ctx, comment); err != nil {
+		logServ.WithError(err).Warn("Failed to Update comment")
+		return nil, err
+	}
+	s.notifyMentions(ctx, logServ, comment, previous)
+
+	logServ.Debug("Update comment successfully")
+	return comment, nil
+}
+
+// Delete удаляет комментарий; удалить может автор или владелец задачи
+func (s *Service) Delete(ctx context.Context, userID, taskID, commentID int) error {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id":    userID,
+		"task_id":    taskID,
+		"comment_id": commentID,
+	})
+	logServ.Debug("Attempting to Delete comment")
+
+	rank, err := s.require(ctx, userID, taskID)
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to Delete comment")
+		return err
+	}
+
+	comment, err := s.commentRepo.Get(ctx, taskID, commentID)
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to Get comment")
+		return err
+	}
+	isAuthor := comment.UserID != nil && *comment.UserID == userID
+	if !isAuthor && rank < rankOwner {
+		logServ.Warn(ErrCommentForbidden.Error())
+		return ErrCommentForbidden
+	}
+
+	if err = s.commentRepo.Delete(ctx, commentID); err != nil {
+		logServ.WithError(err).Warn("Failed to Delete comment")
+		return err
+	}
+
+	logServ.Debug("Delete comment successfully")
+	return nil
+}
+
+// GetHistory возвращает прежние версии текста комментария, от старых к новым
+func (s *Service) GetHistory(ctx context.Context, userID, taskID, commentID int) ([]Revision, error) {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id":    userID,
+		"task_id":    taskID,
+		"comment_id": commentID,
+	})
+	logServ.Debug("Attempting to GetHistory")
+
+	if _, err := s.require(ctx, userID, taskID); err != nil {
+		logServ.WithError(err).Warn("Failed to GetHistory")
+		return nil, err
+	}
+	if _, err := s.commentRepo.Get(ctx, taskID, commentID); err != nil {
+		logServ.WithError(err).Warn("Failed to Get comment")
+		return nil, err
+	}
+
+	revisions, err := s.commentRepo.GetRevisions(ctx, commentID)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to GetRevisions")
+		return nil, err
+	}
+
+	logServ.Debug("GetHistory successfully")
+	return revisions, nil
+}
+
+// require возвращает ранг пользователя в задаче или ErrTaskNotFound, если задача ему не видна
+func (s *Service) require(ctx context.Context, userID, taskID int) (int, error) {
+	rank, err := s.commentRepo.Rank(ctx, taskID, userID)
+	if err != nil {
+		return 0, err
+	}
+	if rank == 0 {
+		return 0, ErrTaskNotFound
+	}
+	return rank, nil
+}
+
+// notifyMentions уведомляет упомянутых пользователей, кроме автора и уже упомянутых раньше
+func (s *Service) notifyMentions(ctx context.Context, logServ *logrus.Entry, comment *Comment, previous []Mention) {
+	skip := map[int]bool{*comment.UserID: true}
+	for _, mention := range previous {
+		skip[mention.UserID] = true
+	}
+
+	author := ""
+	if comment.Username != nil {
+		author = *comment.Username
+	}
+	for _, mention := range comment.Mentions {
+		if skip[mention.UserID] {
+			continue
+		}
+		err := s.notifier.Notify(ctx, &notification.Notification{
+			UserID:  mention.UserID,
+			ActorID: *comment.UserID,
+			Type:    notification.TypeMentioned,
+			TaskID:  comment.TaskID,
+			Text:    fmt.Sprintf("@%s mentioned you in a comment", author),
+		})
+		if err != nil {
+			logServ.WithError(err).WithField("mentioned_id", mention.UserID).Warn("Failed to notify mentioned user")
+		}
+	}
+}
+
+// buildTree раскладывает комментарии по веткам, сохраняя порядок создания
+func buildTree(comments []Comment) []*Comment {
+	byID := make(map[int]*Comment, len(comments))
+	for i := range comments {
+		comments[i].Replies = []*Comment{}
+		byID[comments[i].ID] = &comments[i]
+	}
+
+	roots := make([]*Comment, 0)
+	for i := range comments {
+		c := &comments[i]
+		if c.ParentID != nil {
+			if parent, ok := byID[*c.ParentID]; ok {
+				parent.Replies = append(parent.Replies, c)
+				continue
+			}
+		}
+		roots = append(roots, c)
+	}
+	return roots
+}
+
+func serviceLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Service comment layer")
+}
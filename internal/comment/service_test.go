@@ -0,0 +1,291 @@
+package comment_test
+
+import (
+	"context"
+	"errors"
+	"github.com/melnik-dev/go_todo_jwt/internal/comment"
+	"github.com/melnik-dev/go_todo_jwt/internal/notification"
+	"github.com/sirupsen/logrus"
+	"io"
+	"strings"
+	"testing"
+)
+
+type MockCommentRepository struct {
+	RankMock            func(taskID, userID int) (int, error)
+	CreateMock          func(c *comment.Comment) error
+	GetMock             func(taskID, id int) (*comment.Comment, error)
+	GetByTaskMock       func(taskID int) ([]comment.Comment, error)
+	UpdateMock          func(c *comment.Comment) error
+	DeleteMock          func(id int) error
+	GetRevisionsMock    func(id int) ([]comment.Revision, error)
+	ResolveMentionsMock func(taskID int, usernames []string) ([]comment.Mention, error)
+}
+
+func (m *MockCommentRepository) Rank(ctx context.Context, taskID, userID int) (int, error) {
+	return m.RankMock(taskID, userID)
+}
+
+func (m *MockCommentRepository) Create(ctx context.Context, c *comment.Comment) error {
+	return m.CreateMock(c)
+}
+
+func (m *MockCommentRepository) Get(ctx context.Context, taskID, id int) (*comment.Comment, error) {
+	return m.GetMock(taskID, id)
+}
+
+func (m *MockCommentRepository) GetByTask(ctx context.Context, taskID int) ([]comment.Comment, error) {
+	return m.GetByTaskMock(taskID)
+}
+
+func (m *MockCommentRepository) Update(ctx context.Context, c *comment.Comment) error {
+	return m.UpdateMock(c)
+}
+
+func (m *MockCommentRepository) Delete(ctx context.Context, id int) error {
+	return m.DeleteMock(id)
+}
+
+func (m *MockCommentRepository) GetRevisions(ctx context.Context, id int) ([]comment.Revision, error) {
+	return m.GetRevisionsMock(id)
+}
+
+func (m *MockCommentRepository) ResolveMentions(ctx context.Context, taskID int, usernames []string) ([]comment.Mention, error) {
+	return m.ResolveMentionsMock(taskID, usernames)
+}
+
+type MockNotifier struct {
+	Sent []notification.Notification
+}
+
+func (m *MockNotifier) Notify(ctx context.Context, n *notification.Notification) error {
+	m.Sent = append(m.Sent, *n)
+	return nil
+}
+
+func mockLogger() *logrus.Logger {
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	return l
+}
+
+// knownUsers имитирует поиск упомянутых: alice (42) и bob (7) видят задачу
+func knownUsers(taskID int, usernames []string) ([]comment.Mention, error) {
+	ids := map[string]int{"alice": 42, "bob": 7}
+	mentions := make([]comment.Mention, 0)
+	for _, name := range usernames {
+		if id, ok := ids[strings.ToLower(name)]; ok {
+			mentions = append(mentions, comment.Mention{UserID: id, Username: strings.ToLower(name)})
+		}
+	}
+	return mentions, nil
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestService_Create(t *testing.T) {
+	notifier := &MockNotifier{}
+	var saved *comment.Comment
+	repo := &MockCommentRepository{
+		RankMock: func(taskID, userID int) (int, error) {
+			return 1, nil
+		},
+		ResolveMentionsMock: knownUsers,
+		CreateMock: func(c *comment.Comment) error {
+			c.ID = 10
+			name := "alice"
+			c.Username = &name
+			saved = c
+			return nil
+		},
+	}
+	service := comment.NewService(repo, notifier, mockLogger())
+
+	c, err := service.Create(context.Background(), 42, 1, &comment.CreateRequest{
+		Body: "**LGTM** @bob, @alice and @carol <script>",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != saved || c.ID != 10 {
+		t.Fatalf("unexpected comment %+v", c)
+	}
+	if c.HTML != "<p><strong>LGTM</strong> @bob, @alice and @carol &lt;script&gt;</p>" {
+		t.Errorf("unexpected html %q", c.HTML)
+	}
+	if len(c.Mentions) != 2 {
+		t.Errorf("expected 2 mentions, got %+v", c.Mentions)
+	}
+	// автор не получает уведомление о своём упоминании
+	if len(notifier.Sent) != 1 || notifier.Sent[0].UserID != 7 || notifier.Sent[0].Type != notification.TypeMentioned {
+		t.Errorf("unexpected notifications %+v", notifier.Sent)
+	}
+}
+
+func TestService_Create_Fail(t *testing.T) {
+	tests := []struct {
+		name     string
+		rank     int
+		parentID *int
+		wantErr  error
+	}{
+		{name: "task not visible", rank: 0, wantErr: comment.ErrTaskNotFound},
+		{name: "unknown parent", rank: 1, parentID: intPtr(99), wantErr: comment.ErrParentNotFound},
+		{name: "deleted parent", rank: 1, parentID: intPtr(5), wantErr: comment.ErrParentNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockCommentRepository{
+				RankMock: func(taskID, userID int) (int, error) {
+					return tt.rank, nil
+				},
+				GetMock: func(taskID, id int) (*comment.Comment, error) {
+					if id != 5 {
+						return nil, comment.ErrCommentNotFound
+					}
+					c := &comment.Comment{ID: id, TaskID: taskID}
+					c.DeletedAt = &c.CreatedAt
+					return c, nil
+				},
+				CreateMock: func(c *comment.Comment) error {
+					t.Fatal("Create must not be called")
+					return nil
+				},
+			}
+			service := comment.NewService(repo, &MockNotifier{}, mockLogger())
+
+			_, err := service.Create(context.Background(), 42, 1, &comment.CreateRequest{Body: "hi", ParentID: tt.parentID})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestService_Update(t *testing.T) {
+	tests := []struct {
+		name     string
+		authorID int
+		wantErr  error
+		wantSent int
+	}{
+		{name: "author edits", authorID: 42, wantSent: 1},
+		{name: "not author", authorID: 7, wantErr: comment.ErrCommentForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifier := &MockNotifier{}
+			repo := &MockCommentRepository{
+				RankMock: func(taskID, userID int) (int, error) {
+					return 3, nil
+				},
+				GetMock: func(taskID, id int) (*comment.Comment, error) {
+					return &comment.Comment{
+						ID:       id,
+						TaskID:   taskID,
+						UserID:   intPtr(tt.authorID),
+						Body:     "ping @bob",
+						Mentions: []comment.Mention{{UserID: 7, Username: "bob"}},
+					}, nil
+				},
+				ResolveMentionsMock: knownUsers,
+				UpdateMock: func(c *comment.Comment) error {
+					return nil
+				},
+			}
+			service := comment.NewService(repo, notifier, mockLogger())
+
+			c, err := service.Update(context.Background(), 42, 1, 10, "ping @bob and @alice, cc @Bob")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && c.Body != "ping @bob and @alice, cc @Bob" {
+				t.Errorf("unexpected body %q", c.Body)
+			}
+			// bob уже был упомянут, alice - сама автор
+			if len(notifier.Sent) != 0 {
+				t.Errorf("expected no notifications, got %+v", notifier.Sent)
+			}
+		})
+	}
+}
+
+func TestService_Delete(t *testing.T) {
+	tests := []struct {
+		name     string
+		rank     int
+		authorID int
+		wantErr  error
+	}{
+		{name: "author", rank: 1, authorID: 42},
+		{name: "task owner", rank: 3, authorID: 7},
+		{name: "editor cannot delete others", rank: 2, authorID: 7, wantErr: comment.ErrCommentForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deleted := false
+			repo := &MockCommentRepository{
+				RankMock: func(taskID, userID int) (int, error) {
+					return tt.rank, nil
+				},
+				GetMock: func(taskID, id int) (*comment.Comment, error) {
+					return &comment.Comment{ID: id, TaskID: taskID, UserID: intPtr(tt.authorID)}, nil
+				},
+				DeleteMock: func(id int) error {
+					deleted = true
+					return nil
+				},
+			}
+			service := comment.NewService(repo, &MockNotifier{}, mockLogger())
+
+			err := service.Delete(context.Background(), 42, 1, 10)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if deleted != (tt.wantErr == nil) {
+				t.Errorf("unexpected deleted=%v", deleted)
+			}
+		})
+	}
+}
+
+func TestService_GetAll_Tree(t *testing.T) {
+	repo := &MockCommentRepository{
+		RankMock: func(taskID, userID int) (int, error) {
+			return 1, nil
+		},
+		GetByTaskMock: func(taskID int) ([]comment.Comment, error) {
+			return []comment.Comment{
+				{ID: 1},
+				{ID: 2, ParentID: intPtr(1)},
+				{ID: 3},
+				{ID: 4, ParentID: intPtr(2)},
+				{ID: 5, ParentID: intPtr(1)},
+			}, nil
+		},
+	}
+	service := comment.NewService(repo, &MockNotifier{}, mockLogger())
+
+	roots, err := service.GetAll(context.Background(), 42, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != 2 || roots[0].ID != 1 || roots[1].ID != 3 {
+		t.Fatalf("unexpected roots %+v", roots)
+	}
+	replies := roots[0].Replies
+	if len(replies) != 2 || replies[0].ID != 2 || replies[1].ID != 5 {
+		t.Fatalf("unexpected replies %+v", replies)
+	}
+	if len(replies[0].Replies) != 1 || replies[0].Replies[0].ID != 4 {
+		t.Errorf("unexpected nested replies %+v", replies[0].Replies)
+	}
+	if roots[1].Replies == nil {
+		t.Error("replies must be an empty list, not nil")
+	}
+}
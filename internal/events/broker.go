@@ -0,0 +1,148 @@
+package events
+
+import (
+	"errors"
+	"sync"
+)
+
+// subscriberBuffer - сколько событий может ждать отправки одному клиенту.
+// Клиент, который не успевает их читать, отключается и продолжает по Last-Event-ID.
+const subscriberBuffer = 64
+
+// ErrReplayUnavailable - событий после Last-Event-ID уже нет в буфере,
+// клиенту нужно перечитать задачи целиком
+var ErrReplayUnavailable = errors.New("events after last event id are no longer buffered")
+
+// Subscription - подписка клиента потока; Events закрывается при отключении
+type Subscription struct {
+	UserID      int
+	WorkspaceID int
+	events      chan Event
+}
+
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Broker раздаёт события задач подписчикам этого экземпляра и хранит
+// последние size событий для продолжения потока по Last-Event-ID
+type Broker struct {
+	mu     sync.Mutex
+	size   int
+	buffer []Event
+	// since - id, после которого в буфере есть все события
+	since  int64
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+func NewBroker(size int) *Broker {
+	return &Broker{
+		size: size,
+		subs: make(map[*Subscription]struct{}),
+	}
+}
+
+// Start сообщает, что события с id не больше lastID в буфер не попадут
+func (b *Broker) Start(lastID int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.since = max(b.since, lastID)
+}
+
+// Publish кладёт событие в буфер и отправляет его подписчикам, которым оно видно
+func (b *Broker) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+
+	if len(b.buffer) == b.size {
+		b.since = max(b.since, b.buffer[0].ID)
+		b.buffer = append(b.buffer[:0], b.buffer[1:]...)
+	}
+	b.buffer = append(b.buffer, e)
+
+	for sub := range b.subs {
+		if !e.Visible(sub.UserID, sub.WorkspaceID) {
+			continue
+		}
+		select {
+		case sub.events <- e:
+		default:
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribe подписывает клиента и возвращает видимые ему события после lastID.
+// Событие lastID ищется в буфере, и отдаются пришедшие после него: id растут
+// в порядке вставки, а не фиксации транзакций. lastID = 0 - без повтора.
+// ErrReplayUnavailable возвращается вместе с действующей подпиской.
+func (b *Broker) Subscribe(userID, workspaceID int, lastID int64) (*Subscription, []Event, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &Subscription{
+		UserID:      userID,
+		WorkspaceID: workspaceID,
+		events:      make(chan Event, subscriberBuffer),
+	}
+	if b.closed {
+		close(sub.events)
+		return sub, nil, nil
+	}
+	b.subs[sub] = struct{}{}
+
+	if lastID == 0 {
+		return sub, nil, nil
+	}
+
+	start := -1
+	for i := range b.buffer {
+		if b.buffer[i].ID == lastID {
+			start = i + 1
+			break
+		}
+	}
+	var err error
+	if start == -1 && lastID < b.since {
+		err = ErrReplayUnavailable
+	}
+
+	replay := make([]Event, 0)
+	for i, e := range b.buffer {
+		if (start >= 0 && i < start) || (start == -1 && e.ID <= lastID) {
+			continue
+		}
+		if e.Visible(userID, workspaceID) {
+			replay = append(replay, e)
+		}
+	}
+	return sub, replay, err
+}
+
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(sub)
+}
+
+// Close отключает всех подписчиков; вызывается при остановке сервиса,
+// чтобы открытые потоки не задерживали завершение HTTP-сервера
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subs {
+		b.remove(sub)
+	}
+}
+
+func (b *Broker) remove(sub *Subscription) {
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.events)
+	}
+}
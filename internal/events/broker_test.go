@@ -0,0 +1,145 @@
+package events_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/melnik-dev/go_todo_jwt/internal/events"
+)
+
+func event(id int64, users ...int64) events.Event {
+	return events.Event{ID: id, WorkspaceID: 1, Type: "task.updated", TaskID: int(id), Payload: []byte(`{}`), Audience: pq.Int64Array(users)}
+}
+
+func ids(list []events.Event) []int64 {
+	result := make([]int64, 0, len(list))
+	for _, e := range list {
+		result = append(result, e.ID)
+	}
+	return result
+}
+
+func TestBroker_Publish(t *testing.T) {
+	broker := events.NewBroker(10)
+	alice, _, _ := broker.Subscribe(42, 1, 0)
+	bob, _, _ := broker.Subscribe(7, 1, 0)
+	otherWorkspace, _, _ := broker.Subscribe(42, 2, 0)
+
+	broker.Publish(event(1, 42, 7))
+	broker.Publish(event(2, 42))
+
+	if got := len(alice.Events()); got != 2 {
+		t.Errorf("expected 2 events for alice, got %d", got)
+	}
+	if got := len(bob.Events()); got != 1 {
+		t.Errorf("expected 1 event for bob, got %d", got)
+	}
+	if got := len(otherWorkspace.Events()); got != 0 {
+		t.Errorf("events of other workspace must not be delivered, got %d", got)
+	}
+}
+
+func TestBroker_SharedTask(t *testing.T) {
+	broker := events.NewBroker(10)
+	broker.Start(0)
+	// задача 2 пространства 1 поделена с пользователем 7, его личное пространство - 9
+	shared := event(2, 42, 7)
+	shared.SharedInto = pq.Int64Array{9}
+
+	owner, _, _ := broker.Subscribe(42, 1, 0)
+	grantee, _, _ := broker.Subscribe(7, 9, 0)
+	stranger, _, _ := broker.Subscribe(8, 9, 0)
+	broker.Publish(event(1, 42))
+	broker.Publish(shared)
+
+	if got := len(owner.Events()); got != 2 {
+		t.Errorf("expected 2 events for owner, got %d", got)
+	}
+	if got := len(grantee.Events()); got != 1 {
+		t.Errorf("expected shared task event in grantee's personal workspace, got %d", got)
+	}
+	if got := len(stranger.Events()); got != 0 {
+		t.Errorf("events outside audience must not be delivered, got %d", got)
+	}
+
+	_, replay, err := broker.Subscribe(7, 9, 1)
+	if err != nil || !slices.Equal(ids(replay), []int64{2}) {
+		t.Errorf("expected shared task event in replay, got %v %v", ids(replay), err)
+	}
+}
+
+func TestBroker_Subscribe_Replay(t *testing.T) {
+	broker := events.NewBroker(3)
+	broker.Start(10)
+	// 13 вставлено раньше 12, но зафиксировано позже
+	for _, e := range []events.Event{event(11, 42), event(13, 42), event(12, 42), event(14, 7)} {
+		broker.Publish(e)
+	}
+
+	tests := []struct {
+		name    string
+		lastID  int64
+		want    []int64
+		wantErr error
+	}{
+		{name: "no replay", lastID: 0, want: []int64{}},
+		{name: "after buffered event", lastID: 13, want: []int64{12}},
+		{name: "latest", lastID: 12, want: []int64{}},
+		{name: "evicted", lastID: 10, want: []int64{13, 12}, wantErr: events.ErrReplayUnavailable},
+		{name: "not buffered but not lost", lastID: 11, want: []int64{13, 12}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, replay, err := broker.Subscribe(42, 1, tt.lastID)
+			defer broker.Unsubscribe(sub)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			got := ids(replay)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestBroker_SlowSubscriber(t *testing.T) {
+	broker := events.NewBroker(1000)
+	sub, _, _ := broker.Subscribe(42, 1, 0)
+
+	for i := int64(1); i <= 100; i++ {
+		broker.Publish(event(i, 42))
+	}
+
+	n := 0
+	for range sub.Events() {
+		n++
+	}
+	if n == 0 || n >= 100 {
+		t.Errorf("slow subscriber must be disconnected after its buffer fills, got %d events", n)
+	}
+}
+
+func TestBroker_Close(t *testing.T) {
+	broker := events.NewBroker(10)
+	sub, _, _ := broker.Subscribe(42, 1, 0)
+
+	broker.Close()
+	if _, ok := <-sub.Events(); ok {
+		t.Error("subscription must be closed")
+	}
+
+	late, _, _ := broker.Subscribe(42, 1, 0)
+	if _, ok := <-late.Events(); ok {
+		t.Error("subscription after Close must be closed")
+	}
+	broker.Unsubscribe(sub)
+}
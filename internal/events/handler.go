@@ -0,0 +1,147 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/melnik-dev/go_todo_jwt/pkg/logger"
+	"github.com/melnik-dev/go_todo_jwt/pkg/middleware"
+	"github.com/melnik-dev/go_todo_jwt/pkg/response"
+	"github.com/sirupsen/logrus"
+)
+
+// EventReset сообщает клиенту, что часть событий потеряна и задачи нужно перечитать
+const EventReset = "reset"
+
+type HandlerDeps struct {
+	Broker *Broker
+	*configs.Config
+	// Workspace выбирает рабочее пространство запроса
+	Workspace gin.HandlerFunc
+}
+
+type Handler struct {
+	Broker *Broker
+	*configs.Config
+}
+
+func NewHandler(r *gin.Engine, deps *HandlerDeps) {
+	handler := &Handler{
+		Broker: deps.Broker,
+		Config: deps.Config,
+	}
+	events := r.Group("/events")
+	events.Use(middleware.IsAuthed(handler.Config.JWT.Secret))
+	if deps.Workspace != nil {
+		events.Use(deps.Workspace)
+	}
+	events.GET("", handler.Stream)
+}
+
+// Stream отдаёт события задач пользователя в формате Server-Sent Events.
+// Продолжение после обрыва - по заголовку Last-Event-ID (или параметру
+// last_event_id для первого подключения EventSource).
+func (h *Handler) Stream(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Stream events")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+	workspaceID, _ := db.WorkspaceFromContext(c.Request.Context())
+
+	lastID, err := lastEventID(c)
+	if err != nil {
+		logHandle.WithError(err).Warn("Invalid Last-Event-ID")
+		response.BadRequest(c, "Invalid Last-Event-ID")
+		return
+	}
+
+	sub, replay, err := h.Broker.Subscribe(userID, workspaceID, lastID)
+	defer h.Broker.Unsubscribe(sub)
+
+	// поток живёт дольше WriteTimeout сервера
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if errors.Is(err, ErrReplayUnavailable) {
+		logHandle.WithField("last_event_id", lastID).Info(err.Error())
+		fmt.Fprintf(c.Writer, "event: %s\ndata: {}\n\n", EventReset)
+	}
+	for i := range replay {
+		if err = writeEvent(c, &replay[i]); err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+	logHandle.WithField("replayed", len(replay)).Debug("Stream events started")
+
+	heartbeat := time.NewTicker(h.Config.Events.Heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			logHandle.Debug("Client closed events stream")
+			return
+		case e, ok := <-sub.Events():
+			if !ok {
+				// медленный клиент или остановка сервиса: клиент переподключится
+				logHandle.Debug("Events stream closed by server")
+				return
+			}
+			if err = writeEvent(c, &e); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err = fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+func writeEvent(c *gin.Context, e *Event) error {
+	data, err := json.Marshal(Data{
+		Type:      e.Type,
+		TaskID:    e.TaskID,
+		Task:      e.Payload,
+		CreatedAt: e.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
+
+func lastEventID(c *gin.Context) (int64, error) {
+	value := c.GetHeader("Last-Event-ID")
+	if value == "" {
+		value = c.Query("last_event_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("invalid event id %q", value)
+	}
+	return id, nil
+}
+
+func handlerLogger(c *gin.Context) *logrus.Entry {
+	return logger.FromContext(c).WithField("layer", "Handler events layer")
+}
@@ -0,0 +1,138 @@
+package events_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/internal/events"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/sirupsen/logrus"
+)
+
+func mockGin() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		c.Set("logger", logrus.NewEntry(logger))
+		c.Set("user_id", 42)
+		c.Request = c.Request.WithContext(db.WithWorkspace(c.Request.Context(), 1))
+		c.Next()
+	})
+	return r
+}
+
+// readEvents читает из потока n событий (блоков, разделённых пустой строкой)
+func readEvents(t *testing.T, scanner *bufio.Scanner, n int) []string {
+	t.Helper()
+	var blocks []string
+	var block []string
+	for len(blocks) < n && scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			blocks = append(blocks, strings.Join(block, "\n"))
+			block = nil
+			continue
+		}
+		block = append(block, line)
+	}
+	if len(blocks) < n {
+		t.Fatalf("expected %d events, got %v", n, blocks)
+	}
+	return blocks
+}
+
+func TestHandler_Stream(t *testing.T) {
+	broker := events.NewBroker(10)
+	broker.Start(1)
+	broker.Publish(event(2, 42))
+	broker.Publish(event(3, 7))
+	broker.Publish(event(4, 42))
+
+	handler := &events.Handler{
+		Broker: broker,
+		Config: &configs.Config{Events: configs.ConfEvents{Heartbeat: time.Hour}},
+	}
+	r := mockGin()
+	r.GET("/events", handler.Stream)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events", nil)
+	req.Header.Set("Last-Event-ID", "2")
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", resp.Header.Get("Content-Type"))
+	}
+	scanner := bufio.NewScanner(resp.Body)
+
+	// повтор после Last-Event-ID: событие 3 пользователю не видно
+	replay := readEvents(t, scanner, 1)
+	if !strings.HasPrefix(replay[0], "id: 4\nevent: task.updated\ndata: {") {
+		t.Errorf("unexpected replayed event %q", replay[0])
+	}
+
+	broker.Publish(event(5, 7))
+	broker.Publish(event(6, 42))
+	live := readEvents(t, scanner, 1)
+	if !strings.HasPrefix(live[0], "id: 6\n") || !strings.Contains(live[0], `"task_id":6`) {
+		t.Errorf("unexpected live event %q", live[0])
+	}
+}
+
+func TestHandler_Stream_Reset(t *testing.T) {
+	broker := events.NewBroker(10)
+	broker.Start(100)
+
+	handler := &events.Handler{
+		Broker: broker,
+		Config: &configs.Config{Events: configs.ConfEvents{Heartbeat: time.Hour}},
+	}
+	r := mockGin()
+	r.GET("/events", handler.Stream)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL + "/events?last_event_id=50")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	blocks := readEvents(t, bufio.NewScanner(resp.Body), 1)
+	if blocks[0] != "event: reset\ndata: {}" {
+		t.Errorf("expected reset event, got %q", blocks[0])
+	}
+	// закрытие broker при остановке сервиса завершает поток
+	broker.Close()
+}
+
+func TestHandler_Stream_InvalidLastEventID(t *testing.T) {
+	handler := &events.Handler{Broker: events.NewBroker(10), Config: &configs.Config{}}
+	r := mockGin()
+	r.GET("/events", handler.Stream)
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Last-Event-ID", "abc")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,147 @@
+package events
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// Channel - канал NOTIFY, в который триггер webhook_events_notify пишет id новых событий
+const Channel = "task_events"
+
+// fetchBatch - сколько событий читается за один запрос после переподключения
+const fetchBatch = 500
+
+// Listener получает события всех экземпляров приложения через LISTEN/NOTIFY
+// и публикует их в Broker
+type Listener struct {
+	eventsRepo IRepository
+	broker     *Broker
+	dsn        string
+	logger     *logrus.Logger
+	// last - наибольший опубликованный id
+	last int64
+}
+
+func NewListener(eventsRepo IRepository, broker *Broker, dsn string, logger *logrus.Logger) *Listener {
+	return &Listener{
+		eventsRepo: eventsRepo,
+		broker:     broker,
+		dsn:        dsn,
+		logger:     logger,
+	}
+}
+
+// Run слушает канал, пока не отменён ctx, и затем закрывает Broker
+func (l *Listener) Run(ctx context.Context) {
+	logListen := listenerLogger(l.logger)
+	defer l.broker.Close()
+
+	last, err := l.eventsRepo.Last(ctx)
+	if err != nil {
+		logListen.WithError(err).Error("Failed to get last event")
+	}
+	l.last = last
+	l.broker.Start(last)
+
+	listener := pq.NewListener(l.dsn, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			logListen.WithError(err).Warn("Events listener connection problem")
+		}
+	})
+	defer listener.Close()
+	if err = listener.Listen(Channel); err != nil {
+		logListen.WithError(err).Error("Failed to LISTEN events channel")
+		return
+	}
+	logListen.Info("Events listener started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			logListen.Info("Events listener stopped")
+			return
+		case n := <-listener.Notify:
+			// nil приходит после переподключения: уведомления за это время потеряны
+			if n == nil {
+				err = l.CatchUp(ctx)
+			} else {
+				payloads, reconnected := drain(listener.Notify)
+				err = l.Receive(ctx, append([]string{n.Extra}, payloads...))
+				if err == nil && reconnected {
+					err = l.CatchUp(ctx)
+				}
+			}
+			if err != nil && ctx.Err() == nil {
+				logListen.WithError(err).Error("Failed to publish events")
+			}
+		case <-time.After(90 * time.Second):
+			// проверка соединения; pq переподключится сам, если оно оборвалось
+			go listener.Ping()
+		}
+	}
+}
+
+// Receive публикует события по id из уведомлений
+func (l *Listener) Receive(ctx context.Context, payloads []string) error {
+	ids := make([]int64, 0, len(payloads))
+	for _, payload := range payloads {
+		if id, err := strconv.ParseInt(payload, 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	events, err := l.eventsRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	l.publish(events)
+	return nil
+}
+
+// CatchUp публикует события, записанные после последнего опубликованного
+func (l *Listener) CatchUp(ctx context.Context) error {
+	for {
+		events, err := l.eventsRepo.After(ctx, l.last, fetchBatch)
+		if err != nil {
+			return err
+		}
+		l.publish(events)
+		if len(events) < fetchBatch {
+			return nil
+		}
+	}
+}
+
+func (l *Listener) publish(events []Event) {
+	for _, e := range events {
+		l.broker.Publish(e)
+		l.last = max(l.last, e.ID)
+	}
+}
+
+// drain забирает уже пришедшие уведомления, чтобы прочитать их одним запросом;
+// reconnected - среди них был признак переподключения
+func drain(notify <-chan *pq.Notification) (payloads []string, reconnected bool) {
+	for {
+		select {
+		case n := <-notify:
+			if n == nil {
+				return payloads, true
+			}
+			payloads = append(payloads, n.Extra)
+		default:
+			return payloads, false
+		}
+	}
+}
+
+func listenerLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Listener events layer")
+}
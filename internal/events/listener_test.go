@@ -0,0 +1,69 @@
+package events_test
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/melnik-dev/go_todo_jwt/internal/events"
+	"github.com/sirupsen/logrus"
+)
+
+type MockEventsRepository struct {
+	LastMock     func() (int64, error)
+	GetByIDsMock func(ids []int64) ([]events.Event, error)
+	AfterMock    func(id int64, limit int) ([]events.Event, error)
+}
+
+func (m *MockEventsRepository) Last(ctx context.Context) (int64, error) {
+	return m.LastMock()
+}
+
+func (m *MockEventsRepository) GetByIDs(ctx context.Context, ids []int64) ([]events.Event, error) {
+	return m.GetByIDsMock(ids)
+}
+
+func (m *MockEventsRepository) After(ctx context.Context, id int64, limit int) ([]events.Event, error) {
+	return m.AfterMock(id, limit)
+}
+
+func mockLogger() *logrus.Logger {
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	return l
+}
+
+func TestListener_ReceiveAndCatchUp(t *testing.T) {
+	var requested []int64
+	var afterID int64
+	repo := &MockEventsRepository{
+		GetByIDsMock: func(ids []int64) ([]events.Event, error) {
+			requested = ids
+			return []events.Event{event(5, 42), event(6, 42)}, nil
+		},
+		AfterMock: func(id int64, limit int) ([]events.Event, error) {
+			afterID = id
+			return []events.Event{event(7, 42)}, nil
+		},
+	}
+	broker := events.NewBroker(10)
+	sub, _, _ := broker.Subscribe(42, 1, 0)
+	listener := events.NewListener(repo, broker, "", mockLogger())
+
+	if err := listener.Receive(context.Background(), []string{"5", "bad", "6"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(requested) != 2 || requested[0] != 5 || requested[1] != 6 {
+		t.Errorf("unexpected ids %v", requested)
+	}
+
+	if err := listener.CatchUp(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if afterID != 6 {
+		t.Errorf("expected catch up after 6, got %d", afterID)
+	}
+	if got := len(sub.Events()); got != 3 {
+		t.Errorf("expected 3 published events, got %d", got)
+	}
+}
@@ -0,0 +1,41 @@
+package events
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Event - событие задачи из outbox webhook_events. Audience - пользователи
+// с доступом к задаче на момент события. SharedInto - личные пространства
+// тех из них, кто не участник пространства задачи: поделённая задача видна
+// им там (см. миграцию 0003_shared_access).
+type Event struct {
+	ID          int64         `db:"id"`
+	WorkspaceID int           `db:"workspace_id"`
+	Type        string        `db:"type"`
+	TaskID      int           `db:"task_id"`
+	Payload     []byte        `db:"payload"`
+	Audience    pq.Int64Array `db:"audience"`
+	SharedInto  pq.Int64Array `db:"shared_into"`
+	CreatedAt   time.Time     `db:"created_at"`
+}
+
+// Visible сообщает, должен ли пользователь в рабочем пространстве получить событие:
+// участник - в пространстве задачи, остальные из Audience - в своём личном
+func (e *Event) Visible(userID, workspaceID int) bool {
+	if !slices.Contains(e.Audience, int64(userID)) {
+		return false
+	}
+	return e.WorkspaceID == workspaceID || slices.Contains(e.SharedInto, int64(workspaceID))
+}
+
+// Data - поле data события в потоке
+type Data struct {
+	Type      string          `json:"type"`
+	TaskID    int             `json:"task_id"`
+	Task      json.RawMessage `json:"task"`
+	CreatedAt time.Time       `json:"created_at"`
+}
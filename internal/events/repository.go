@@ -0,0 +1,86 @@
+package events
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/sirupsen/logrus"
+)
+
+// Репозиторий читает outbox всех рабочих пространств; кому показывать
+// событие, решает Broker по Event.Visible
+type IRepository interface {
+	Last(ctx context.Context) (int64, error)
+	GetByIDs(ctx context.Context, ids []int64) ([]Event, error)
+	After(ctx context.Context, id int64, limit int) ([]Event, error)
+}
+
+type Repository struct {
+	db     *db.Db
+	logger *logrus.Logger
+}
+
+func NewRepository(db *db.Db, logger *logrus.Logger) *Repository {
+	return &Repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// eventColumns читает событие из webhook_events e вместе с SharedInto
+const eventColumns = `e.id, e.workspace_id, e.type, e.task_id, e.payload, e.audience, e.created_at,
+		ARRAY(SELECT w.id FROM workspaces w
+			WHERE w.personal_user_id = ANY(e.audience) AND NOT EXISTS (
+				SELECT 1 FROM workspace_members m
+				WHERE m.workspace_id = e.workspace_id AND m.user_id = w.personal_user_id)) AS shared_into`
+
+// Last возвращает id последнего записанного события, 0 - событий нет
+func (r *Repository) Last(ctx context.Context) (int64, error) {
+	logRepo := repositoryLogger(r.logger)
+	logRepo.Debug("Attempting to get Last event")
+
+	var id int64
+	if err := r.db.GetContext(ctx, &id, `SELECT COALESCE(MAX(id), 0) FROM webhook_events`); err != nil {
+		logRepo.WithError(err).Error("Failed to get Last event database")
+		return 0, err
+	}
+
+	logRepo.WithField("event_id", id).Debug("Last event database successfully")
+	return id, nil
+}
+
+func (r *Repository) GetByIDs(ctx context.Context, ids []int64) ([]Event, error) {
+	logRepo := repositoryLogger(r.logger).WithField("count", len(ids))
+	logRepo.Debug("Attempting to GetByIDs events")
+
+	events := make([]Event, 0, len(ids))
+	query := `SELECT ` + eventColumns + ` FROM webhook_events e WHERE e.id = ANY($1) ORDER BY e.id`
+	if err := r.db.SelectContext(ctx, &events, query, pq.Array(ids)); err != nil {
+		logRepo.WithError(err).Error("Failed to GetByIDs events database")
+		return nil, err
+	}
+
+	logRepo.Debug("GetByIDs events database successfully")
+	return events, nil
+}
+
+// After возвращает до limit событий с id больше заданного, по возрастанию id
+func (r *Repository) After(ctx context.Context, id int64, limit int) ([]Event, error) {
+	logRepo := repositoryLogger(r.logger).WithField("event_id", id)
+	logRepo.Debug("Attempting to get events After")
+
+	events := make([]Event, 0)
+	query := `SELECT ` + eventColumns + ` FROM webhook_events e WHERE e.id > $1 ORDER BY e.id LIMIT $2`
+	if err := r.db.SelectContext(ctx, &events, query, id, limit); err != nil {
+		logRepo.WithError(err).Error("Failed to get events After database")
+		return nil, err
+	}
+
+	logRepo.Debug("Events After database successfully")
+	return events, nil
+}
+
+func repositoryLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Repository events layer")
+}
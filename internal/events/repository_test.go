@@ -0,0 +1,53 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/melnik-dev/go_todo_jwt/internal/events"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+)
+
+func mockDB() (*events.Repository, sqlmock.Sqlmock, error) {
+	mockDb, mock, err := sqlmock.New()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pgDB := sqlx.NewDb(mockDb, "sqlMock")
+
+	repo := events.NewRepository(&db.Db{
+		DB: pgDB,
+	}, mockLogger())
+
+	return repo, mock, err
+}
+
+func TestEventsRepository_GetByIDs_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	columns := []string{"id", "workspace_id", "type", "task_id", "payload", "audience", "created_at", "shared_into"}
+	mock.ExpectQuery(`SELECT (.+) FROM webhook_events e WHERE e.id = ANY\(\$1\) ORDER BY e.id`).
+		WithArgs(pq.Array([]int64{5, 6})).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(5, 1, "task.created", 10, []byte(`{"id":10}`), "{42,7}", time.Now(), "{}"))
+
+	list, err := repo.GetByIDs(context.Background(), []int64{5, 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || !list[0].Visible(7, 1) || list[0].Visible(8, 1) || list[0].Visible(42, 2) {
+		t.Errorf("unexpected events %+v", list)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,102 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Check проверяет одну зависимость; ошибка означает, что экземпляр не готов
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker выполняет зарегистрированные проверки готовности
+type Checker struct {
+	timeout      time.Duration
+	mu           sync.RWMutex
+	checks       []namedCheck
+	shuttingDown atomic.Bool
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Register добавляет проверку; результаты выводятся в порядке регистрации
+func (c *Checker) Register(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Shutdown переводит экземпляр в not ready до конца работы процесса,
+// чтобы балансировщик перестал присылать запросы до остановки сервера
+func (c *Checker) Shutdown() {
+	c.shuttingDown.Store(true)
+}
+
+// Ready выполняет все проверки параллельно, каждую не дольше timeout.
+// Во время остановки проверки не выполняются
+func (c *Checker) Ready(ctx context.Context) *Report {
+	if c.shuttingDown.Load() {
+		return &Report{Status: StatusShuttingDown, Checks: []Result{}}
+	}
+
+	c.mu.RLock()
+	checks := c.checks
+	c.mu.RUnlock()
+
+	report := &Report{Status: StatusOK, Checks: make([]Result, len(checks))}
+	var wg sync.WaitGroup
+	for i, nc := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = c.run(ctx, nc)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// run выполняет проверку с таймаутом; проверка, не следящая за ctx,
+// считается проваленной по таймауту, а её результат отбрасывается
+func (c *Checker) run(ctx context.Context, nc namedCheck) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- nc.check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := Result{
+		Name:      nc.name,
+		Status:    StatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,83 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/melnik-dev/go_todo_jwt/internal/health"
+)
+
+func TestChecker_Ready(t *testing.T) {
+	checker := health.NewChecker(50 * time.Millisecond)
+	checker.Register("ok", func(ctx context.Context) error { return nil })
+	checker.Register("fail", func(ctx context.Context) error { return errors.New("connection refused") })
+	// проверка, не следящая за ctx, не должна задерживать ответ дольше таймаута
+	checker.Register("hang", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	start := time.Now()
+	report := checker.Ready(context.Background())
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("checks must be limited by timeout, took %v", time.Since(start))
+	}
+
+	if report.Status != health.StatusFail {
+		t.Errorf("expected %s, got %s", health.StatusFail, report.Status)
+	}
+	want := []struct {
+		name   string
+		status string
+	}{
+		{name: "ok", status: health.StatusOK},
+		{name: "fail", status: health.StatusFail},
+		{name: "hang", status: health.StatusFail},
+	}
+	if len(report.Checks) != len(want) {
+		t.Fatalf("expected %d checks, got %+v", len(want), report.Checks)
+	}
+	for i, w := range want {
+		if report.Checks[i].Name != w.name || report.Checks[i].Status != w.status {
+			t.Errorf("check %d: expected %+v, got %+v", i, w, report.Checks[i])
+		}
+	}
+	if report.Checks[2].Error != context.DeadlineExceeded.Error() {
+		t.Errorf("expected timeout error, got %q", report.Checks[2].Error)
+	}
+}
+
+func TestChecker_Shutdown(t *testing.T) {
+	checker := health.NewChecker(time.Second)
+	checker.Register("ok", func(ctx context.Context) error { return nil })
+
+	if report := checker.Ready(context.Background()); report.Status != health.StatusOK {
+		t.Fatalf("expected %s, got %+v", health.StatusOK, report)
+	}
+
+	checker.Shutdown()
+	if report := checker.Ready(context.Background()); report.Status != health.StatusShuttingDown {
+		t.Fatalf("expected %s, got %+v", health.StatusShuttingDown, report)
+	}
+}
+
+func TestWorkers_Check(t *testing.T) {
+	workers := health.NewWorkers()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started := make(chan struct{})
+	go workers.Run(ctx, "long", func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+	})
+	<-started
+	workers.Run(ctx, "crashed", func(ctx context.Context) {})
+
+	err := workers.Check(context.Background())
+	if err == nil || err.Error() != "workers stopped: crashed" {
+		t.Fatalf("expected crashed worker, got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/pkg/logger"
+	"github.com/melnik-dev/go_todo_jwt/pkg/response"
+	"github.com/sirupsen/logrus"
+)
+
+type HandlerDeps struct {
+	Checker *Checker
+}
+
+type Handler struct {
+	Checker *Checker
+}
+
+// NewHandler регистрирует /healthz и /readyz; они без авторизации, их вызывает оркестратор
+func NewHandler(r *gin.Engine, deps *HandlerDeps) {
+	handler := &Handler{
+		Checker: deps.Checker,
+	}
+	r.GET("/healthz", handler.Live)
+	r.GET("/readyz", handler.Ready)
+}
+
+// Live отвечает, пока процесс способен обрабатывать запросы; зависимости не проверяются,
+// чтобы сбой базы не приводил к перезапуску всех экземпляров
+func (h *Handler) Live(c *gin.Context) {
+	response.Success(c, http.StatusOK, gin.H{"status": StatusOK})
+}
+
+// Ready выполняет проверки готовности: 200, если все прошли, иначе 503
+func (h *Handler) Ready(c *gin.Context) {
+	report := h.Checker.Ready(c.Request.Context())
+
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+		handlerLogger(c).WithField("checks", report.Checks).Warn("Not ready")
+	}
+	response.Success(c, status, report)
+}
+
+func handlerLogger(c *gin.Context) *logrus.Entry {
+	return logger.FromContext(c).WithField("layer", "Handler health layer")
+}
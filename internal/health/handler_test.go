@@ -0,0 +1,80 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/internal/health"
+	"github.com/sirupsen/logrus"
+)
+
+func mockGin(checker *health.Checker) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		c.Set("logger", logrus.NewEntry(logger))
+		c.Next()
+	})
+	health.NewHandler(r, &health.HandlerDeps{Checker: checker})
+	return r
+}
+
+func TestHandler_Ready(t *testing.T) {
+	var dbErr error
+	checker := health.NewChecker(time.Second)
+	checker.Register("postgres", func(ctx context.Context) error { return dbErr })
+	r := mockGin(checker)
+
+	tests := []struct {
+		name       string
+		dbErr      error
+		shutdown   bool
+		wantCode   int
+		wantStatus string
+	}{
+		{name: "ready", wantCode: http.StatusOK, wantStatus: health.StatusOK},
+		{name: "database down", dbErr: errors.New("connection refused"), wantCode: http.StatusServiceUnavailable, wantStatus: health.StatusFail},
+		{name: "shutting down", shutdown: true, wantCode: http.StatusServiceUnavailable, wantStatus: health.StatusShuttingDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbErr = tt.dbErr
+			if tt.shutdown {
+				checker.Shutdown()
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, w.Code)
+			}
+			var body struct {
+				Data health.Report `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Data.Status != tt.wantStatus {
+				t.Errorf("expected %s, got %+v", tt.wantStatus, body.Data)
+			}
+		})
+	}
+
+	// живость не зависит от проверок и остановки
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected %d from /healthz, got %d", http.StatusOK, w.Code)
+	}
+}
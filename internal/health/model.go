@@ -0,0 +1,21 @@
+package health
+
+const (
+	StatusOK           = "ok"
+	StatusFail         = "fail"
+	StatusShuttingDown = "shutting_down"
+)
+
+// Result - итог одной проверки
+type Result struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report - итог всех проверок; Status ok, только если прошли все
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
@@ -0,0 +1,51 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Workers отслеживает фоновые задачи: задача, вышедшая раньше остановки
+// приложения, делает экземпляр не готовым
+type Workers struct {
+	mu      sync.Mutex
+	running map[string]bool
+}
+
+func NewWorkers() *Workers {
+	return &Workers{running: make(map[string]bool)}
+}
+
+// Run выполняет фоновую задачу name, отмечая её работающей до выхода из run
+func (w *Workers) Run(ctx context.Context, name string, run func(ctx context.Context)) {
+	w.set(name, true)
+	defer w.set(name, false)
+	run(ctx)
+}
+
+// Check - проверка готовности: все запущенные задачи ещё работают
+func (w *Workers) Check(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var stopped []string
+	for name, running := range w.running {
+		if !running {
+			stopped = append(stopped, name)
+		}
+	}
+	if len(stopped) > 0 {
+		slices.Sort(stopped)
+		return fmt.Errorf("workers stopped: %s", strings.Join(stopped, ", "))
+	}
+	return nil
+}
+
+func (w *Workers) set(name string, running bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.running[name] = running
+}
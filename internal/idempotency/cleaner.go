@@ -0,0 +1,45 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Cleaner удаляет просроченные ключи идемпотентности
+type Cleaner struct {
+	repo     IRepository
+	interval time.Duration
+	logger   *logrus.Logger
+}
+
+func NewCleaner(repo IRepository, interval time.Duration, logger *logrus.Logger) *Cleaner {
+	return &Cleaner{
+		repo:     repo,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Run удаляет просроченные ключи каждые interval, пока не отменён ctx
+func (c *Cleaner) Run(ctx context.Context) {
+	logClean := c.logger.WithField("layer", "Cleaner idempotency layer")
+	logClean.Info("Idempotency cleaner started")
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		if n, err := c.repo.PurgeExpired(ctx); err != nil && ctx.Err() == nil {
+			logClean.WithError(err).Error("Failed to purge idempotency keys")
+		} else if n > 0 {
+			logClean.WithField("rows", n).Info("Expired idempotency keys purged")
+		}
+		select {
+		case <-ctx.Done():
+			logClean.Info("Idempotency cleaner stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
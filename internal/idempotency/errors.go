@@ -0,0 +1,9 @@
+package idempotency
+
+import "errors"
+
+var (
+	ErrKeyNotFound      = errors.New("idempotency key not found")
+	ErrKeyReused        = errors.New("idempotency key reused with a different request")
+	ErrRequestInProcess = errors.New("request with this idempotency key is still in process")
+)
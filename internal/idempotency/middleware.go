@@ -0,0 +1,184 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/melnik-dev/go_todo_jwt/pkg/logger"
+	"github.com/melnik-dev/go_todo_jwt/pkg/middleware"
+	"github.com/melnik-dev/go_todo_jwt/pkg/response"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+// Middleware повторяет сохранённый ответ на POST-запрос с тем же Idempotency-Key
+// вместо повторного выполнения. Должен стоять после middleware.IsAuthed.
+// Тело запроса читается в память для хеша, поэтому оно ограничено maxBodySize
+// байт: больший запрос получает 413 до обработчика.
+func Middleware(repo IRepository, ttl time.Duration, maxBodySize int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderKey)
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+			return
+		}
+
+		logMiddle := middlewareLogger(c).WithField("idempotency_key", key)
+
+		if len(key) > maxKeyLength {
+			logMiddle.Warn("Idempotency key is too long")
+			response.AbortWithStatus(c, http.StatusBadRequest, "Idempotency-Key is too long")
+			return
+		}
+
+		userID, ok := middleware.GetUserID(c)
+		if !ok {
+			c.Abort()
+			return
+		}
+		logMiddle = logMiddle.WithField("user_id", userID)
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBodySize))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				logMiddle.WithError(err).Warn("Request body is too large")
+				response.AbortWithStatus(c, http.StatusRequestEntityTooLarge, "Request body is too large")
+				return
+			}
+			logMiddle.WithError(err).Warn("Failed to read request body")
+			response.AbortWithStatus(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		record := &Record{
+			UserID:      userID,
+			Key:         key,
+			RequestHash: requestHash(c.Request, body),
+			ExpiresAt:   time.Now().Add(ttl),
+		}
+
+		reserved, err := repo.Reserve(c.Request.Context(), record)
+		if err != nil {
+			logMiddle.WithError(err).Error("Failed to reserve idempotency key")
+			response.AbortWithStatus(c, http.StatusInternalServerError, "Failed to process idempotency key")
+			return
+		}
+
+		if !reserved {
+			replay(c, repo, record, logMiddle)
+			return
+		}
+
+		writer := &bodyWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+
+		// ключ освобождается и после отмены запроса, поэтому без отмены его контекста
+		ctx := context.WithoutCancel(c.Request.Context())
+
+		// паника обработчика уходит мимо кода ниже в gin.Recovery: без освобождения
+		// ключ до конца ttl отвечал бы 409 на каждый повтор
+		defer func() {
+			if p := recover(); p != nil {
+				release(ctx, repo, record, logMiddle)
+				panic(p)
+			}
+		}()
+
+		c.Next()
+
+		// 5xx и оборванные клиентом запросы не сохраняем, чтобы клиент мог повторить запрос
+		if writer.Status() >= http.StatusInternalServerError || writer.Status() == response.StatusClientClosedRequest {
+			release(ctx, repo, record, logMiddle)
+			return
+		}
+
+		record.StatusCode = writer.Status()
+		record.ContentType = writer.Header().Get("Content-Type")
+		record.ResponseBody = writer.body.Bytes()
+		if err := repo.Complete(ctx, record); err != nil {
+			logMiddle.WithError(err).Error("Failed to save idempotent response")
+		}
+	}
+}
+
+func release(ctx context.Context, repo IRepository, record *Record, logMiddle *logrus.Entry) {
+	if err := repo.Delete(ctx, record.UserID, record.Key); err != nil {
+		logMiddle.WithError(err).Error("Failed to release idempotency key")
+	}
+}
+
+func replay(c *gin.Context, repo IRepository, record *Record, logMiddle *logrus.Entry) {
+	existing, err := repo.Get(c.Request.Context(), record.UserID, record.Key)
+	if err != nil {
+		if errors.Is(err, ErrKeyNotFound) {
+			logMiddle.Warn(ErrRequestInProcess.Error())
+			response.AbortWithStatus(c, http.StatusConflict, ErrRequestInProcess.Error())
+			return
+		}
+		logMiddle.WithError(err).Error("Failed to get idempotency key")
+		response.AbortWithStatus(c, http.StatusInternalServerError, "Failed to process idempotency key")
+		return
+	}
+
+	if existing.RequestHash != record.RequestHash {
+		logMiddle.Warn(ErrKeyReused.Error())
+		response.AbortWithStatus(c, http.StatusUnprocessableEntity, ErrKeyReused.Error())
+		return
+	}
+
+	if !existing.IsCompleted() {
+		logMiddle.Warn(ErrRequestInProcess.Error())
+		response.AbortWithStatus(c, http.StatusConflict, ErrRequestInProcess.Error())
+		return
+	}
+
+	logMiddle.Debug("Replaying stored response")
+	c.Header(HeaderReplayed, "true")
+	c.Data(existing.StatusCode, existing.ContentType, existing.ResponseBody)
+	c.Abort()
+}
+
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.Path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// bodyWriter копирует тело ответа, чтобы его можно было сохранить
+type bodyWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *bodyWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+func middlewareLogger(c *gin.Context) *logrus.Entry {
+	return logger.FromContext(c).WithField("layer", "Middleware idempotency layer")
+}
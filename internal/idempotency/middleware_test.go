@@ -0,0 +1,231 @@
+package idempotency_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/internal/idempotency"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type MemoryRepository struct {
+	mu      sync.Mutex
+	records map[string]*idempotency.Record
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{records: make(map[string]*idempotency.Record)}
+}
+
+func (m *MemoryRepository) Reserve(_ context.Context, record *idempotency.Record) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.records[record.Key]; ok && existing.ExpiresAt.After(time.Now()) {
+		return false, nil
+	}
+	stored := *record
+	m.records[record.Key] = &stored
+	return true, nil
+}
+
+func (m *MemoryRepository) Get(_ context.Context, userID int, key string) (*idempotency.Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record, ok := m.records[key]
+	if !ok {
+		return nil, idempotency.ErrKeyNotFound
+	}
+	stored := *record
+	return &stored, nil
+}
+
+func (m *MemoryRepository) Complete(_ context.Context, record *idempotency.Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored := *record
+	m.records[record.Key] = &stored
+	return nil
+}
+
+func (m *MemoryRepository) Delete(_ context.Context, userID int, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records, key)
+	return nil
+}
+
+func (m *MemoryRepository) PurgeExpired(_ context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for key, record := range m.records {
+		if record.ExpiresAt.Before(time.Now()) {
+			delete(m.records, key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// maxBodySize - ограничение тела запроса в тестах
+const maxBodySize = 64
+
+func mockGin(repo idempotency.IRepository, calls *int, status int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(gin.RecoveryWithWriter(io.Discard))
+	r.Use(func(c *gin.Context) {
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		c.Set("logger", logrus.NewEntry(logger))
+		c.Set("user_id", 42)
+		c.Next()
+	})
+	r.Use(idempotency.Middleware(repo, time.Hour, maxBodySize))
+	r.POST("/task/create", func(c *gin.Context) {
+		*calls++
+		c.JSON(status, gin.H{"id": *calls})
+	})
+	return r
+}
+
+func doRequest(r *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/task/create", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(idempotency.HeaderKey, key)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware_Replay(t *testing.T) {
+	calls := 0
+	r := mockGin(NewMemoryRepository(), &calls, http.StatusOK)
+
+	first := doRequest(r, "key", `{"title":"a"}`)
+	second := doRequest(r, "key", `{"title":"a"}`)
+
+	if calls != 1 {
+		t.Fatalf("expected handler to be called once, got %d", calls)
+	}
+	if second.Code != first.Code || second.Body.String() != first.Body.String() {
+		t.Errorf("expected replayed response %d %s, got %d %s", first.Code, first.Body, second.Code, second.Body)
+	}
+	if second.Header().Get(idempotency.HeaderReplayed) != "true" {
+		t.Error("expected replayed header")
+	}
+}
+
+func TestMiddleware_FailBodyTooLarge(t *testing.T) {
+	calls := 0
+	repo := NewMemoryRepository()
+	r := mockGin(repo, &calls, http.StatusOK)
+
+	w := doRequest(r, "key", `{"title":"`+strings.Repeat("a", maxBodySize)+`"}`)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected %d, got %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+	if calls != 0 {
+		t.Errorf("expected handler not to be called, got %d", calls)
+	}
+	if _, err := repo.Get(context.Background(), 42, "key"); err == nil {
+		t.Error("expected key not to be reserved")
+	}
+}
+
+func TestMiddleware_FailKeyReused(t *testing.T) {
+	calls := 0
+	r := mockGin(NewMemoryRepository(), &calls, http.StatusOK)
+
+	doRequest(r, "key", `{"title":"a"}`)
+	w := doRequest(r, "key", `{"title":"b"}`)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+	if calls != 1 {
+		t.Errorf("expected handler to be called once, got %d", calls)
+	}
+}
+
+func TestMiddleware_FailInProcess(t *testing.T) {
+	calls := 0
+	repo := NewMemoryRepository()
+	r := mockGin(repo, &calls, http.StatusOK)
+
+	first := doRequest(r, "key", `{"title":"a"}`)
+	if first.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, first.Code)
+	}
+	record, _ := repo.Get(context.Background(), 42, "key")
+	record.StatusCode = 0
+	_ = repo.Complete(context.Background(), record)
+
+	w := doRequest(r, "key", `{"title":"a"}`)
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected %d, got %d", http.StatusConflict, w.Code)
+	}
+}
+
+func TestMiddleware_ServerErrorNotStored(t *testing.T) {
+	calls := 0
+	r := mockGin(NewMemoryRepository(), &calls, http.StatusInternalServerError)
+
+	doRequest(r, "key", `{"title":"a"}`)
+	doRequest(r, "key", `{"title":"a"}`)
+
+	if calls != 2 {
+		t.Errorf("expected handler to be called twice, got %d", calls)
+	}
+}
+
+func TestMiddleware_PanicReleasesKey(t *testing.T) {
+	calls := 0
+	repo := NewMemoryRepository()
+	r := mockGin(repo, &calls, http.StatusOK)
+	r.POST("/task/panic", func(c *gin.Context) {
+		calls++
+		panic("handler failed")
+	})
+
+	for range 2 {
+		req := httptest.NewRequest(http.MethodPost, "/task/panic", bytes.NewBufferString(`{"title":"a"}`))
+		req.Header.Set(idempotency.HeaderKey, "key")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("expected %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("expected handler to be called twice, got %d", calls)
+	}
+	if _, err := repo.Get(context.Background(), 42, "key"); err == nil {
+		t.Error("expected key to be released")
+	}
+}
+
+func TestMiddleware_WithoutKey(t *testing.T) {
+	calls := 0
+	r := mockGin(NewMemoryRepository(), &calls, http.StatusOK)
+
+	doRequest(r, "", `{"title":"a"}`)
+	doRequest(r, "", `{"title":"a"}`)
+
+	if calls != 2 {
+		t.Errorf("expected handler to be called twice, got %d", calls)
+	}
+}
@@ -0,0 +1,20 @@
+package idempotency
+
+import "time"
+
+// Record - сохранённый результат запроса с заголовком Idempotency-Key.
+// StatusCode равен 0, пока первый запрос ещё обрабатывается.
+type Record struct {
+	UserID       int       `db:"user_id"`
+	Key          string    `db:"key"`
+	RequestHash  string    `db:"request_hash"`
+	StatusCode   int       `db:"status_code"`
+	ContentType  string    `db:"content_type"`
+	ResponseBody []byte    `db:"response_body"`
+	CreatedAt    time.Time `db:"created_at"`
+	ExpiresAt    time.Time `db:"expires_at"`
+}
+
+func (r *Record) IsCompleted() bool {
+	return r.StatusCode != 0
+}
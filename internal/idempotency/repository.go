@@ -0,0 +1,162 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/sirupsen/logrus"
+)
+
+type IRepository interface {
+	Reserve(ctx context.Context, record *Record) (bool, error)
+	Get(ctx context.Context, userID int, key string) (*Record, error)
+	Complete(ctx context.Context, record *Record) error
+	Delete(ctx context.Context, userID int, key string) error
+	PurgeExpired(ctx context.Context) (int64, error)
+}
+
+type Repository struct {
+	db     *db.Db
+	logger *logrus.Logger
+}
+
+func NewRepository(db *db.Db, logger *logrus.Logger) *Repository {
+	return &Repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Reserve занимает ключ за первым запросом. Возвращает false, если ключ уже занят
+// и его срок ещё не истёк; просроченный ключ перезаписывается.
+func (r *Repository) Reserve(ctx context.Context, record *Record) (bool, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": record.UserID,
+		"key":     record.Key,
+	})
+	logRepo.Debug("Attempting to Reserve")
+
+	query := `INSERT INTO idempotency_keys (user_id, key, request_hash, expires_at)
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT (user_id, key) DO UPDATE
+				SET request_hash = EXCLUDED.request_hash, status_code = 0, content_type = '',
+					response_body = NULL, created_at = NOW(), expires_at = EXCLUDED.expires_at
+				WHERE idempotency_keys.expires_at < NOW()
+				RETURNING created_at`
+
+	row := r.db.QueryRowContext(ctx, query, record.UserID, record.Key, record.RequestHash, record.ExpiresAt)
+	if err := row.Scan(&record.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logRepo.Debug("Key already reserved")
+			return false, nil
+		}
+		logRepo.WithError(err).Error("Failed to Reserve database")
+		return false, err
+	}
+
+	logRepo.Debug("Reserve database successfully")
+	return true, nil
+}
+
+func (r *Repository) Get(ctx context.Context, userID int, key string) (*Record, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": userID,
+		"key":     key,
+	})
+	logRepo.Debug("Attempting to Get")
+
+	var record Record
+	query := `SELECT * FROM idempotency_keys WHERE user_id = $1 AND key = $2`
+
+	err := r.db.GetContext(ctx, &record, query, userID, key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logRepo.WithError(err).Warn(ErrKeyNotFound.Error())
+			return nil, ErrKeyNotFound
+		}
+		logRepo.WithError(err).Error("Failed to Get database")
+		return nil, err
+	}
+
+	logRepo.Debug("Get database successfully")
+	return &record, nil
+}
+
+func (r *Repository) Complete(ctx context.Context, record *Record) error {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": record.UserID,
+		"key":     record.Key,
+	})
+	logRepo.Debug("Attempting to Complete")
+
+	query := `UPDATE idempotency_keys
+				SET status_code = $1, content_type = $2, response_body = $3
+				WHERE user_id = $4 AND key = $5`
+
+	result, err := r.db.ExecContext(ctx, query, record.StatusCode, record.ContentType, record.ResponseBody, record.UserID, record.Key)
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to Complete database")
+		return err
+	}
+
+	row, err := result.RowsAffected()
+	if err != nil {
+		logRepo.WithError(err).Error("Failed rows affected by Complete database")
+		return err
+	}
+
+	if row == 0 {
+		logRepo.Warn(ErrKeyNotFound.Error())
+		return ErrKeyNotFound
+	}
+
+	logRepo.Debug("Complete database successfully")
+	return nil
+}
+
+func (r *Repository) Delete(ctx context.Context, userID int, key string) error {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": userID,
+		"key":     key,
+	})
+	logRepo.Debug("Attempting to Delete")
+
+	query := `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, key); err != nil {
+		logRepo.WithError(err).Error("Failed to Delete database")
+		return err
+	}
+
+	logRepo.Debug("Delete database successfully")
+	return nil
+}
+
+// PurgeExpired удаляет ключи с истёкшим сроком: Reserve их перезаписывает,
+// но ключи, которые больше не приходят, иначе остались бы навсегда
+func (r *Repository) PurgeExpired(ctx context.Context) (int64, error) {
+	logRepo := repositoryLogger(r.logger)
+	logRepo.Debug("Attempting to PurgeExpired")
+
+	query := `DELETE FROM idempotency_keys WHERE expires_at < NOW()`
+
+	result, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to PurgeExpired database")
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		logRepo.WithError(err).Error("Failed rows affected by PurgeExpired database")
+		return 0, err
+	}
+
+	logRepo.WithField("rows", rows).Debug("PurgeExpired database successfully")
+	return rows, nil
+}
+
+func repositoryLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Repository idempotency layer")
+}
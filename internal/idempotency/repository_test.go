@@ -0,0 +1,152 @@
+package idempotency_test
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/melnik-dev/go_todo_jwt/internal/idempotency"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/sirupsen/logrus"
+	"io"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func mockDB() (*idempotency.Repository, sqlmock.Sqlmock, error) {
+	mockDb, mock, err := sqlmock.New()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pgDB := sqlx.NewDb(mockDb, "sqlMock")
+
+	testLogger := logrus.New()
+	testLogger.SetOutput(io.Discard)
+	repo := idempotency.NewRepository(&db.Db{
+		DB: pgDB,
+	}, testLogger)
+
+	return repo, mock, err
+}
+
+func TestIdempotencyRepository_Reserve_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expiresAt := time.Now().Add(time.Hour)
+	mock.ExpectQuery(`INSERT INTO idempotency_keys`).
+		WithArgs(42, "key", "hash", expiresAt).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+
+	reserved, err := repo.Reserve(context.Background(), &idempotency.Record{
+		UserID:      42,
+		Key:         "key",
+		RequestHash: "hash",
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reserved {
+		t.Error("expected key to be reserved")
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIdempotencyRepository_Reserve_Taken(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery(`INSERT INTO idempotency_keys`).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}))
+
+	reserved, err := repo.Reserve(context.Background(), &idempotency.Record{UserID: 42, Key: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reserved {
+		t.Error("expected key to be already taken")
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIdempotencyRepository_Get_NotFound(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM idempotency_keys WHERE user_id = $1 AND key = $2`)).
+		WithArgs(42, "key").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "key"}))
+
+	_, err = repo.Get(context.Background(), 42, "key")
+	if err != idempotency.ErrKeyNotFound {
+		t.Fatalf("expected %v, got %v", idempotency.ErrKeyNotFound, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIdempotencyRepository_Complete_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectExec(`UPDATE idempotency_keys`).
+		WithArgs(200, "application/json", []byte(`{}`), 42, "key").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.Complete(context.Background(), &idempotency.Record{
+		UserID:       42,
+		Key:          "key",
+		StatusCode:   200,
+		ContentType:  "application/json",
+		ResponseBody: []byte(`{}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIdempotencyRepository_PurgeExpired_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM idempotency_keys WHERE expires_at < NOW()`)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	n, err := repo.PurgeExpired(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3 purged keys, got %d", n)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,8 @@
+package notification
+
+import "errors"
+
+var (
+	ErrNotificationNotFound = errors.New("notification not found")
+	ErrUnknownType          = errors.New("unknown notification type")
+)
@@ -0,0 +1,195 @@
+package notification
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/pkg/logger"
+	"github.com/melnik-dev/go_todo_jwt/pkg/middleware"
+	"github.com/melnik-dev/go_todo_jwt/pkg/response"
+	"github.com/sirupsen/logrus"
+)
+
+type HandlerDeps struct {
+	NotificationService IService
+	*configs.Config
+}
+
+type Handler struct {
+	NotificationService IService
+	*configs.Config
+}
+
+func NewHandler(r *gin.Engine, deps *HandlerDeps) {
+	handler := &Handler{
+		NotificationService: deps.NotificationService,
+		Config:              deps.Config,
+	}
+	notifications := r.Group("/notifications")
+	notifications.Use(middleware.IsAuthed(handler.Config.JWT.Secret))
+	notifications.GET("/", handler.GetAll)
+	notifications.GET("/unread-count", handler.UnreadCount)
+	notifications.POST("/:id/read", handler.MarkRead)
+	notifications.POST("/read-all", handler.MarkAllRead)
+	notifications.GET("/preferences", handler.GetPreferences)
+	notifications.PUT("/preferences", handler.SetPreferences)
+}
+
+func (h *Handler) GetAll(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to GetAll notifications")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var query ListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind query")
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	notifications, unread, err := h.NotificationService.GetAll(c.Request.Context(), userID, &query)
+	if err != nil {
+		logHandle.WithError(err).Error("Failed to GetAll notifications")
+		response.InternalServerError(c, "Failed to get notifications")
+		return
+	}
+
+	logHandle.Debug("GetAll notifications successfully")
+	response.Success(c, http.StatusOK, gin.H{
+		"notifications": notifications,
+		"unread_count":  unread,
+	})
+}
+
+func (h *Handler) UnreadCount(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to get UnreadCount")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	unread, err := h.NotificationService.UnreadCount(c.Request.Context(), userID)
+	if err != nil {
+		logHandle.WithError(err).Error("Failed to get UnreadCount")
+		response.InternalServerError(c, "Failed to get unread count")
+		return
+	}
+
+	logHandle.Debug("UnreadCount successfully")
+	response.Success(c, http.StatusOK, gin.H{"unread_count": unread})
+}
+
+func (h *Handler) MarkRead(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to MarkRead")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri URIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind ID")
+		response.BadRequest(c, "Invalid notification ID")
+		return
+	}
+
+	if err := h.NotificationService.MarkRead(c.Request.Context(), userID, uri.ID); err != nil {
+		if errors.Is(err, ErrNotificationNotFound) {
+			logHandle.Warn(ErrNotificationNotFound.Error())
+			response.NotFound(c, ErrNotificationNotFound.Error())
+			return
+		}
+		logHandle.WithError(err).Error("Failed to MarkRead")
+		response.InternalServerError(c, "Failed to mark notification as read")
+		return
+	}
+
+	logHandle.Debug("MarkRead successfully")
+	response.Success(c, http.StatusOK, gin.H{"message": "Notification marked as read"})
+}
+
+func (h *Handler) MarkAllRead(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to MarkAllRead")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	updated, err := h.NotificationService.MarkAllRead(c.Request.Context(), userID)
+	if err != nil {
+		logHandle.WithError(err).Error("Failed to MarkAllRead")
+		response.InternalServerError(c, "Failed to mark notifications as read")
+		return
+	}
+
+	logHandle.Debug("MarkAllRead successfully")
+	response.Success(c, http.StatusOK, gin.H{"updated": updated})
+}
+
+func (h *Handler) GetPreferences(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to GetPreferences")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	prefs, err := h.NotificationService.GetPreferences(c.Request.Context(), userID)
+	if err != nil {
+		logHandle.WithError(err).Error("Failed to GetPreferences")
+		response.InternalServerError(c, "Failed to get notification preferences")
+		return
+	}
+
+	logHandle.Debug("GetPreferences successfully")
+	response.Success(c, http.StatusOK, gin.H{"preferences": prefs})
+}
+
+func (h *Handler) SetPreferences(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to SetPreferences")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req SetPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind request")
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	prefs, err := h.NotificationService.SetPreferences(c.Request.Context(), userID, req.Preferences)
+	if err != nil {
+		if errors.Is(err, ErrUnknownType) {
+			logHandle.Warn(ErrUnknownType.Error())
+			response.BadRequest(c, ErrUnknownType.Error())
+			return
+		}
+		logHandle.WithError(err).Error("Failed to SetPreferences")
+		response.InternalServerError(c, "Failed to set notification preferences")
+		return
+	}
+
+	logHandle.Debug("SetPreferences successfully")
+	response.Success(c, http.StatusOK, gin.H{"preferences": prefs})
+}
+
+func handlerLogger(c *gin.Context) *logrus.Entry {
+	return logger.FromContext(c).WithField("layer", "Handler notification layer")
+}
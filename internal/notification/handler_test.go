@@ -0,0 +1,113 @@
+package notification_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/internal/notification"
+	"github.com/sirupsen/logrus"
+)
+
+// MockNotificationService встраивает notification.IService, чтобы переопределять только нужные методы
+type MockNotificationService struct {
+	notification.IService
+	MarkReadMock       func(userID, id int) error
+	SetPreferencesMock func(userID int, prefs map[string]bool) ([]notification.Preference, error)
+}
+
+func (m *MockNotificationService) MarkRead(ctx context.Context, userID, id int) error {
+	return m.MarkReadMock(userID, id)
+}
+
+func (m *MockNotificationService) SetPreferences(ctx context.Context, userID int, prefs map[string]bool) ([]notification.Preference, error) {
+	return m.SetPreferencesMock(userID, prefs)
+}
+
+func mockGin() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("logger", logrus.NewEntry(mockLogger()))
+		c.Set("user_id", 42)
+		c.Next()
+	})
+	return r
+}
+
+func TestHandler_MarkRead(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		err      error
+		wantCode int
+	}{
+		{name: "success", path: "/notifications/3/read", wantCode: http.StatusOK},
+		{name: "not own", path: "/notifications/3/read", err: notification.ErrNotificationNotFound, wantCode: http.StatusNotFound},
+		{name: "invalid id", path: "/notifications/abc/read", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &notification.Handler{
+				NotificationService: &MockNotificationService{
+					MarkReadMock: func(userID, id int) error {
+						return tt.err
+					},
+				},
+			}
+
+			r := mockGin()
+			r.POST("/notifications/:id/read", handler.MarkRead)
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestHandler_SetPreferences(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		err      error
+		wantCode int
+	}{
+		{name: "success", body: `{"preferences":{"mentioned":false}}`, wantCode: http.StatusOK},
+		{name: "unknown type", body: `{"preferences":{"weather":true}}`, err: notification.ErrUnknownType, wantCode: http.StatusBadRequest},
+		{name: "missing preferences", body: `{}`, wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &notification.Handler{
+				NotificationService: &MockNotificationService{
+					SetPreferencesMock: func(userID int, prefs map[string]bool) ([]notification.Preference, error) {
+						if tt.err != nil {
+							return nil, tt.err
+						}
+						return []notification.Preference{{Type: notification.TypeMentioned, Enabled: prefs[notification.TypeMentioned]}}, nil
+					},
+				},
+			}
+
+			r := mockGin()
+			r.PUT("/notifications/preferences", handler.SetPreferences)
+			req := httptest.NewRequest(http.MethodPut, "/notifications/preferences", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package notification
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Типы уведомлений
+const (
+	TypeTaskAssigned  = "task_assigned"
+	TypeMentioned     = "mentioned"
+	TypeDueSoon       = "due_soon"
+	TypeShared        = "shared"
+	TypeTaskCompleted = "task_completed"
+	TypeTaskDeleted   = "task_deleted"
+)
+
+// Types - все типы уведомлений, на которые можно подписаться в настройках
+var Types = []string{
+	TypeTaskAssigned,
+	TypeMentioned,
+	TypeDueSoon,
+	TypeShared,
+	TypeTaskCompleted,
+	TypeTaskDeleted,
+}
+
+// Notification - событие для пользователя UserID, вызванное действием ActorID.
+// ActorID, TaskID и ProjectID равны 0, если не заданы.
+type Notification struct {
+	ID        int        `db:"id" json:"id"`
+	UserID    int        `db:"user_id" json:"-"`
+	ActorID   int        `db:"actor_id" json:"actor_id,omitempty"`
+	Actor     string     `db:"actor" json:"actor,omitempty"`
+	Type      string     `db:"type" json:"type"`
+	TaskID    int        `db:"task_id" json:"task_id,omitempty"`
+	ProjectID int        `db:"project_id" json:"project_id,omitempty"`
+	Text      string     `db:"text" json:"text"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	ReadAt    *time.Time `db:"read_at" json:"read_at"`
+}
+
+// Notifier доставляет уведомления пользователям. Ошибка доставки не должна
+// отменять действие, которое вызвало уведомление.
+type Notifier interface {
+	Notify(ctx context.Context, n *Notification) error
+}
+
+// LogNotifier только пишет уведомления в лог
+type LogNotifier struct {
+	logger *logrus.Logger
+}
+
+func NewLogNotifier(logger *logrus.Logger) *LogNotifier {
+	return &LogNotifier{
+		logger: logger,
+	}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, notification *Notification) error {
+	n.logger.WithFields(logrus.Fields{
+		"layer":    "Notifier notification layer",
+		"user_id":  notification.UserID,
+		"actor_id": notification.ActorID,
+		"type":     notification.Type,
+		"task_id":  notification.TaskID,
+	}).Info(notification.Text)
+	return nil
+}
@@ -0,0 +1,23 @@
+package notification
+
+type URIParam struct {
+	ID int `uri:"id" binding:"required,min=1"`
+}
+
+// ListQuery - страница уведомлений от новых к старым; BeforeID - id последнего
+// уведомления предыдущей страницы
+type ListQuery struct {
+	Unread   bool `form:"unread"`
+	Limit    int  `form:"limit" binding:"omitempty,min=1,max=100"`
+	BeforeID int  `form:"before_id" binding:"omitempty,min=1"`
+}
+
+// Preference - включён ли для пользователя тип уведомлений
+type Preference struct {
+	Type    string `json:"type"`
+	Enabled bool   `json:"enabled"`
+}
+
+type SetPreferencesRequest struct {
+	Preferences map[string]bool `json:"preferences" binding:"required"`
+}
@@ -0,0 +1,217 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/sirupsen/logrus"
+)
+
+// Уведомления принадлежат пользователю, а не рабочему пространству,
+// поэтому запросы выполняются без db.Tenant.
+type IRepository interface {
+	Create(ctx context.Context, n *Notification) (bool, error)
+	GetByUser(ctx context.Context, userID int, query *ListQuery) ([]Notification, error)
+	UnreadCount(ctx context.Context, userID int) (int, error)
+	MarkRead(ctx context.Context, userID, id int) error
+	MarkAllRead(ctx context.Context, userID int) (int64, error)
+	GetPreferences(ctx context.Context, userID int) (map[string]bool, error)
+	SetPreferences(ctx context.Context, userID int, prefs map[string]bool) error
+}
+
+type Repository struct {
+	db     *db.Db
+	logger *logrus.Logger
+}
+
+func NewRepository(db *db.Db, logger *logrus.Logger) *Repository {
+	return &Repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Create сохраняет уведомление, если пользователь не отключил этот тип.
+// Возвращает false, если уведомление отброшено настройками.
+func (r *Repository) Create(ctx context.Context, n *Notification) (bool, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": n.UserID,
+		"type":    n.Type,
+	})
+	logRepo.Debug("Attempting to Create notification")
+
+	query := `INSERT INTO notifications (user_id, actor_id, type, task_id, project_id, text)
+				SELECT $1, NULLIF($2, 0), $3, NULLIF($4, 0), NULLIF($5, 0), $6
+				WHERE NOT EXISTS (
+					SELECT 1 FROM notification_preferences p
+					WHERE p.user_id = $1 AND p.type = $3 AND NOT p.enabled)
+				RETURNING id, created_at`
+
+	row := r.db.QueryRowContext(ctx, query, n.UserID, n.ActorID, n.Type, n.TaskID, n.ProjectID, n.Text)
+	if err := row.Scan(&n.ID, &n.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logRepo.Debug("Notification type is disabled by user")
+			return false, nil
+		}
+		logRepo.WithError(err).Error("Failed to Create notification database")
+		return false, err
+	}
+
+	logRepo.WithField("notification_id", n.ID).Debug("Create notification database successfully")
+	return true, nil
+}
+
+// GetByUser возвращает уведомления пользователя от новых к старым
+func (r *Repository) GetByUser(ctx context.Context, userID int, query *ListQuery) ([]Notification, error) {
+	logRepo := repositoryLogger(r.logger).WithField("user_id", userID)
+	logRepo.Debug("Attempting to GetByUser notifications")
+
+	notifications := make([]Notification, 0)
+	sqlQuery := `SELECT n.id, n.user_id, COALESCE(n.actor_id, 0) AS actor_id, COALESCE(u.username, '') AS actor,
+					n.type, COALESCE(n.task_id, 0) AS task_id, COALESCE(n.project_id, 0) AS project_id,
+					n.text, n.created_at, n.read_at
+				FROM notifications n
+				LEFT JOIN users u ON u.id = n.actor_id
+				WHERE n.user_id = $1
+					AND ($2 = 0 OR n.id < $2)
+					AND (NOT $3 OR n.read_at IS NULL)
+				ORDER BY n.id DESC
+				LIMIT $4`
+
+	err := r.db.SelectContext(ctx, &notifications, sqlQuery, userID, query.BeforeID, query.Unread, query.Limit)
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to GetByUser notifications database")
+		return nil, err
+	}
+
+	logRepo.Debug("GetByUser notifications database successfully")
+	return notifications, nil
+}
+
+func (r *Repository) UnreadCount(ctx context.Context, userID int) (int, error) {
+	logRepo := repositoryLogger(r.logger).WithField("user_id", userID)
+	logRepo.Debug("Attempting to get UnreadCount")
+
+	var count int
+	query := `SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`
+	if err := r.db.GetContext(ctx, &count, query, userID); err != nil {
+		logRepo.WithError(err).Error("Failed to get UnreadCount database")
+		return 0, err
+	}
+
+	logRepo.Debug("UnreadCount database successfully")
+	return count, nil
+}
+
+// MarkRead отмечает уведомление прочитанным; повторная отметка не меняет время прочтения
+func (r *Repository) MarkRead(ctx context.Context, userID, id int) error {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id":         userID,
+		"notification_id": id,
+	})
+	logRepo.Debug("Attempting to MarkRead")
+
+	query := `UPDATE notifications SET read_at = COALESCE(read_at, NOW()) WHERE id = $1 AND user_id = $2`
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to MarkRead database")
+		return err
+	}
+	row, err := result.RowsAffected()
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to get rows affected")
+		return err
+	}
+	if row == 0 {
+		logRepo.Warn(ErrNotificationNotFound.Error())
+		return ErrNotificationNotFound
+	}
+
+	logRepo.Debug("MarkRead database successfully")
+	return nil
+}
+
+// MarkAllRead отмечает прочитанными все уведомления пользователя и возвращает их число
+func (r *Repository) MarkAllRead(ctx context.Context, userID int) (int64, error) {
+	logRepo := repositoryLogger(r.logger).WithField("user_id", userID)
+	logRepo.Debug("Attempting to MarkAllRead")
+
+	query := `UPDATE notifications SET read_at = NOW() WHERE user_id = $1 AND read_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to MarkAllRead database")
+		return 0, err
+	}
+	row, err := result.RowsAffected()
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to get rows affected")
+		return 0, err
+	}
+
+	logRepo.WithField("updated", row).Debug("MarkAllRead database successfully")
+	return row, nil
+}
+
+// GetPreferences возвращает только явно заданные настройки
+func (r *Repository) GetPreferences(ctx context.Context, userID int) (map[string]bool, error) {
+	logRepo := repositoryLogger(r.logger).WithField("user_id", userID)
+	logRepo.Debug("Attempting to GetPreferences")
+
+	rows, err := r.db.QueryContext(ctx, `SELECT type, enabled FROM notification_preferences WHERE user_id = $1`, userID)
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to GetPreferences database")
+		return nil, err
+	}
+	defer rows.Close()
+
+	prefs := make(map[string]bool)
+	for rows.Next() {
+		var notificationType string
+		var enabled bool
+		if err = rows.Scan(&notificationType, &enabled); err != nil {
+			logRepo.WithError(err).Error("Failed to scan preference")
+			return nil, err
+		}
+		prefs[notificationType] = enabled
+	}
+	if err = rows.Err(); err != nil {
+		logRepo.WithError(err).Error("Failed to GetPreferences database")
+		return nil, err
+	}
+
+	logRepo.Debug("GetPreferences database successfully")
+	return prefs, nil
+}
+
+func (r *Repository) SetPreferences(ctx context.Context, userID int, prefs map[string]bool) error {
+	logRepo := repositoryLogger(r.logger).WithField("user_id", userID)
+	logRepo.Debug("Attempting to SetPreferences")
+
+	err := db.NewTxManager(r.db).WithinTx(ctx, func(ctx context.Context) error {
+		query := `INSERT INTO notification_preferences (user_id, type, enabled) VALUES ($1, $2, $3)
+				ON CONFLICT (user_id, type) DO UPDATE SET enabled = EXCLUDED.enabled`
+		for _, notificationType := range Types {
+			enabled, ok := prefs[notificationType]
+			if !ok {
+				continue
+			}
+			if _, err := r.db.ExecContext(ctx, query, userID, notificationType, enabled); err != nil {
+				logRepo.WithError(err).Error("Failed to SetPreferences database")
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logRepo.Debug("SetPreferences database successfully")
+	return nil
+}
+
+func repositoryLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Repository notification layer")
+}
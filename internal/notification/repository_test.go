@@ -0,0 +1,125 @@
+package notification_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/melnik-dev/go_todo_jwt/internal/notification"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/sirupsen/logrus"
+)
+
+func mockLogger() *logrus.Logger {
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	return l
+}
+
+func mockDB() (*notification.Repository, sqlmock.Sqlmock, error) {
+	mockDb, mock, err := sqlmock.New()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pgDB := sqlx.NewDb(mockDb, "sqlMock")
+
+	repo := notification.NewRepository(&db.Db{
+		DB: pgDB,
+	}, mockLogger())
+
+	return repo, mock, err
+}
+
+func TestNotificationRepository_Create(t *testing.T) {
+	tests := []struct {
+		name        string
+		rows        *sqlmock.Rows
+		wantCreated bool
+	}{
+		{name: "created", rows: sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, time.Now()), wantCreated: true},
+		{name: "disabled by preferences", rows: sqlmock.NewRows([]string{"id", "created_at"})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock, err := mockDB()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mock.ExpectQuery(`INSERT INTO notifications .* WHERE NOT EXISTS`).
+				WithArgs(7, 42, notification.TypeMentioned, 1, 0, "hi").
+				WillReturnRows(tt.rows)
+
+			n := &notification.Notification{UserID: 7, ActorID: 42, Type: notification.TypeMentioned, TaskID: 1, Text: "hi"}
+			created, err := repo.Create(context.Background(), n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if created != tt.wantCreated {
+				t.Errorf("expected created=%v, got %v", tt.wantCreated, created)
+			}
+			if created && n.ID != 3 {
+				t.Errorf("unexpected id %d", n.ID)
+			}
+
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestNotificationRepository_MarkRead_FailNotFound(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE notifications SET read_at = COALESCE(read_at, NOW()) WHERE id = $1 AND user_id = $2`)).
+		WithArgs(3, 42).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err = repo.MarkRead(context.Background(), 42, 3); !errors.Is(err, notification.ErrNotificationNotFound) {
+		t.Fatalf("expected %v, got %v", notification.ErrNotificationNotFound, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNotificationRepository_SetPreferences(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// порядок вставки задаётся notification.Types, а не обходом map
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO notification_preferences`).
+		WithArgs(42, notification.TypeMentioned, false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO notification_preferences`).
+		WithArgs(42, notification.TypeDueSoon, true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = repo.SetPreferences(context.Background(), 42, map[string]bool{
+		notification.TypeDueSoon:   true,
+		notification.TypeMentioned: false,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,182 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultLimit - размер страницы GET /notifications по умолчанию
+const defaultLimit = 50
+
+type IService interface {
+	GetAll(ctx context.Context, userID int, query *ListQuery) ([]Notification, int, error)
+	UnreadCount(ctx context.Context, userID int) (int, error)
+	MarkRead(ctx context.Context, userID, id int) error
+	MarkAllRead(ctx context.Context, userID int) (int64, error)
+	GetPreferences(ctx context.Context, userID int) ([]Preference, error)
+	SetPreferences(ctx context.Context, userID int, prefs map[string]bool) ([]Preference, error)
+}
+
+// Service хранит уведомления во входящих пользователей и реализует Notifier
+type Service struct {
+	notificationRepo IRepository
+	logger           *logrus.Logger
+}
+
+func NewService(notificationRepo IRepository, logger *logrus.Logger) *Service {
+	return &Service{
+		notificationRepo: notificationRepo,
+		logger:           logger,
+	}
+}
+
+// Notify кладёт уведомление во входящие получателя. Уведомления о собственных
+// действиях и отключённых в настройках типах молча отбрасываются.
+func (s *Service) Notify(ctx context.Context, n *Notification) error {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id":  n.UserID,
+		"actor_id": n.ActorID,
+		"type":     n.Type,
+	})
+	logServ.Debug("Attempting to Notify")
+
+	if n.UserID == n.ActorID {
+		logServ.Debug("Skip notification about own action")
+		return nil
+	}
+
+	created, err := s.notificationRepo.Create(ctx, n)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to Create notification")
+		return err
+	}
+
+	logServ.WithField("created", created).Debug("Notify successfully")
+	return nil
+}
+
+// GetAll возвращает страницу уведомлений и общее число непрочитанных
+func (s *Service) GetAll(ctx context.Context, userID int, query *ListQuery) ([]Notification, int, error) {
+	logServ := serviceLogger(s.logger).WithField("user_id", userID)
+	logServ.Debug("Attempting to GetAll notifications")
+
+	if query.Limit == 0 {
+		query.Limit = defaultLimit
+	}
+	notifications, err := s.notificationRepo.GetByUser(ctx, userID, query)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to GetByUser")
+		return nil, 0, err
+	}
+	unread, err := s.notificationRepo.UnreadCount(ctx, userID)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to get UnreadCount")
+		return nil, 0, err
+	}
+
+	logServ.Debug("GetAll notifications successfully")
+	return notifications, unread, nil
+}
+
+func (s *Service) UnreadCount(ctx context.Context, userID int) (int, error) {
+	logServ := serviceLogger(s.logger).WithField("user_id", userID)
+	logServ.Debug("Attempting to get UnreadCount")
+
+	unread, err := s.notificationRepo.UnreadCount(ctx, userID)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to get UnreadCount")
+		return 0, err
+	}
+
+	logServ.Debug("UnreadCount successfully")
+	return unread, nil
+}
+
+func (s *Service) MarkRead(ctx context.Context, userID, id int) error {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id":         userID,
+		"notification_id": id,
+	})
+	logServ.Debug("Attempting to MarkRead")
+
+	if err := s.notificationRepo.MarkRead(ctx, userID, id); err != nil {
+		logServ.WithError(err).Warn("Failed to MarkRead")
+		return err
+	}
+
+	logServ.Debug("MarkRead successfully")
+	return nil
+}
+
+func (s *Service) MarkAllRead(ctx context.Context, userID int) (int64, error) {
+	logServ := serviceLogger(s.logger).WithField("user_id", userID)
+	logServ.Debug("Attempting to MarkAllRead")
+
+	updated, err := s.notificationRepo.MarkAllRead(ctx, userID)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to MarkAllRead")
+		return 0, err
+	}
+
+	logServ.WithField("updated", updated).Debug("MarkAllRead successfully")
+	return updated, nil
+}
+
+// GetPreferences возвращает настройки по всем типам; не заданные явно типы включены
+func (s *Service) GetPreferences(ctx context.Context, userID int) ([]Preference, error) {
+	logServ := serviceLogger(s.logger).WithField("user_id", userID)
+	logServ.Debug("Attempting to GetPreferences")
+
+	prefs, err := s.notificationRepo.GetPreferences(ctx, userID)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to GetPreferences")
+		return nil, err
+	}
+
+	logServ.Debug("GetPreferences successfully")
+	return preferences(prefs), nil
+}
+
+// SetPreferences меняет только переданные типы, остальные настройки сохраняются
+func (s *Service) SetPreferences(ctx context.Context, userID int, prefs map[string]bool) ([]Preference, error) {
+	logServ := serviceLogger(s.logger).WithField("user_id", userID)
+	logServ.Debug("Attempting to SetPreferences")
+
+	for notificationType := range prefs {
+		if !knownType(notificationType) {
+			logServ.WithField("type", notificationType).Warn(ErrUnknownType.Error())
+			return nil, ErrUnknownType
+		}
+	}
+
+	if err := s.notificationRepo.SetPreferences(ctx, userID, prefs); err != nil {
+		logServ.WithError(err).Error("Failed to SetPreferences")
+		return nil, err
+	}
+
+	logServ.Debug("SetPreferences successfully")
+	return s.GetPreferences(ctx, userID)
+}
+
+func preferences(prefs map[string]bool) []Preference {
+	result := make([]Preference, 0, len(Types))
+	for _, notificationType := range Types {
+		enabled, ok := prefs[notificationType]
+		result = append(result, Preference{Type: notificationType, Enabled: enabled || !ok})
+	}
+	return result
+}
+
+func knownType(notificationType string) bool {
+	for _, t := range Types {
+		if t == notificationType {
+			return true
+		}
+	}
+	return false
+}
+
+func serviceLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Service notification layer")
+}
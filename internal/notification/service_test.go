@@ -0,0 +1,121 @@
+package notification_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/melnik-dev/go_todo_jwt/internal/notification"
+)
+
+type MockNotificationRepository struct {
+	CreateMock         func(n *notification.Notification) (bool, error)
+	GetByUserMock      func(userID int, query *notification.ListQuery) ([]notification.Notification, error)
+	UnreadCountMock    func(userID int) (int, error)
+	MarkReadMock       func(userID, id int) error
+	MarkAllReadMock    func(userID int) (int64, error)
+	GetPreferencesMock func(userID int) (map[string]bool, error)
+	SetPreferencesMock func(userID int, prefs map[string]bool) error
+}
+
+func (m *MockNotificationRepository) Create(ctx context.Context, n *notification.Notification) (bool, error) {
+	return m.CreateMock(n)
+}
+
+func (m *MockNotificationRepository) GetByUser(ctx context.Context, userID int, query *notification.ListQuery) ([]notification.Notification, error) {
+	return m.GetByUserMock(userID, query)
+}
+
+func (m *MockNotificationRepository) UnreadCount(ctx context.Context, userID int) (int, error) {
+	return m.UnreadCountMock(userID)
+}
+
+func (m *MockNotificationRepository) MarkRead(ctx context.Context, userID, id int) error {
+	return m.MarkReadMock(userID, id)
+}
+
+func (m *MockNotificationRepository) MarkAllRead(ctx context.Context, userID int) (int64, error) {
+	return m.MarkAllReadMock(userID)
+}
+
+func (m *MockNotificationRepository) GetPreferences(ctx context.Context, userID int) (map[string]bool, error) {
+	return m.GetPreferencesMock(userID)
+}
+
+func (m *MockNotificationRepository) SetPreferences(ctx context.Context, userID int, prefs map[string]bool) error {
+	return m.SetPreferencesMock(userID, prefs)
+}
+
+func TestService_Notify(t *testing.T) {
+	var created []notification.Notification
+	service := notification.NewService(&MockNotificationRepository{
+		CreateMock: func(n *notification.Notification) (bool, error) {
+			created = append(created, *n)
+			return true, nil
+		},
+	}, mockLogger())
+
+	if err := service.Notify(context.Background(), &notification.Notification{UserID: 7, ActorID: 42, Type: notification.TypeShared}); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Notify(context.Background(), &notification.Notification{UserID: 42, ActorID: 42, Type: notification.TypeShared}); err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 1 || created[0].UserID != 7 {
+		t.Errorf("unexpected notifications %+v", created)
+	}
+}
+
+func TestService_GetAll_DefaultLimit(t *testing.T) {
+	service := notification.NewService(&MockNotificationRepository{
+		GetByUserMock: func(userID int, query *notification.ListQuery) ([]notification.Notification, error) {
+			if query.Limit != 50 || !query.Unread {
+				t.Errorf("unexpected query %+v", query)
+			}
+			return []notification.Notification{{ID: 1}}, nil
+		},
+		UnreadCountMock: func(userID int) (int, error) {
+			return 4, nil
+		},
+	}, mockLogger())
+
+	notifications, unread, err := service.GetAll(context.Background(), 42, &notification.ListQuery{Unread: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notifications) != 1 || unread != 4 {
+		t.Errorf("unexpected result %+v, %d", notifications, unread)
+	}
+}
+
+func TestService_Preferences(t *testing.T) {
+	stored := map[string]bool{}
+	service := notification.NewService(&MockNotificationRepository{
+		GetPreferencesMock: func(userID int) (map[string]bool, error) {
+			return stored, nil
+		},
+		SetPreferencesMock: func(userID int, prefs map[string]bool) error {
+			for k, v := range prefs {
+				stored[k] = v
+			}
+			return nil
+		},
+	}, mockLogger())
+
+	if _, err := service.SetPreferences(context.Background(), 42, map[string]bool{"weather": true}); !errors.Is(err, notification.ErrUnknownType) {
+		t.Fatalf("expected %v, got %v", notification.ErrUnknownType, err)
+	}
+
+	prefs, err := service.SetPreferences(context.Background(), 42, map[string]bool{notification.TypeMentioned: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prefs) != len(notification.Types) {
+		t.Fatalf("expected all types, got %+v", prefs)
+	}
+	for _, p := range prefs {
+		if p.Enabled != (p.Type != notification.TypeMentioned) {
+			t.Errorf("unexpected preference %+v", p)
+		}
+	}
+}
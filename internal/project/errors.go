@@ -0,0 +1,8 @@
+package project
+
+import "errors"
+
+var (
+	ErrProjectNotFound  = errors.New("project not found")
+	ErrProjectForbidden = errors.New("not enough permissions for project")
+)
@@ -0,0 +1,127 @@
+package project
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/pkg/logger"
+	"github.com/melnik-dev/go_todo_jwt/pkg/middleware"
+	"github.com/melnik-dev/go_todo_jwt/pkg/response"
+	"github.com/sirupsen/logrus"
+)
+
+type HandlerDeps struct {
+	ProjectService IService
+	*configs.Config
+	// Workspace выбирает рабочее пространство запроса
+	Workspace gin.HandlerFunc
+}
+
+type Handler struct {
+	ProjectService IService
+	*configs.Config
+}
+
+func NewHandler(r *gin.Engine, deps *HandlerDeps) {
+	handler := &Handler{
+		ProjectService: deps.ProjectService,
+		Config:         deps.Config,
+	}
+	project := r.Group("/project")
+	project.Use(middleware.IsAuthed(handler.Config.JWT.Secret))
+	if deps.Workspace != nil {
+		project.Use(deps.Workspace)
+	}
+	project.POST("/create", handler.Create)
+	project.GET("/", handler.GetAll)
+	project.DELETE("/:id", handler.Delete)
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Create project")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind request")
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	project, err := h.ProjectService.Create(c.Request.Context(), userID, req.Name)
+	if err != nil {
+		logHandle.WithError(err).Error("Failed to Create project")
+		response.InternalServerError(c, "Failed to create project")
+		return
+	}
+
+	logHandle.Debug("Create project successfully")
+	response.Success(c, http.StatusCreated, project)
+}
+
+func (h *Handler) GetAll(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to GetAll projects")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	projects, err := h.ProjectService.GetAll(c.Request.Context(), userID)
+	if err != nil {
+		logHandle.WithError(err).Error("Failed to GetAll projects")
+		response.InternalServerError(c, "Failed to get projects")
+		return
+	}
+
+	logHandle.Debug("GetAll projects successfully")
+	response.Success(c, http.StatusOK, projects)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Delete project")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri URIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind project id")
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	err := h.ProjectService.Delete(c.Request.Context(), userID, uri.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrProjectNotFound):
+			logHandle.Warn(ErrProjectNotFound.Error())
+			response.NotFound(c, ErrProjectNotFound.Error())
+		case errors.Is(err, ErrProjectForbidden):
+			logHandle.Warn(ErrProjectForbidden.Error())
+			response.Forbidden(c, ErrProjectForbidden.Error())
+		default:
+			logHandle.WithError(err).Error("Failed to Delete project")
+			response.InternalServerError(c, "Failed to delete project")
+		}
+		return
+	}
+
+	logHandle.Debug("Delete project successfully")
+	response.Success(c, http.StatusOK, gin.H{"message": "Project deleted successfully"})
+}
+
+func handlerLogger(c *gin.Context) *logrus.Entry {
+	return logger.FromContext(c).WithField("layer", "Handler project layer")
+}
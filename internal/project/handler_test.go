@@ -0,0 +1,112 @@
+package project_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/internal/project"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type MockProjectService struct {
+	CreateMock func(userID int, name string) (*project.Project, error)
+	GetAllMock func(userID int) ([]project.Project, error)
+	DeleteMock func(userID, projectID int) error
+}
+
+func (m *MockProjectService) Create(ctx context.Context, userID int, name string) (*project.Project, error) {
+	return m.CreateMock(userID, name)
+}
+
+func (m *MockProjectService) GetAll(ctx context.Context, userID int) ([]project.Project, error) {
+	return m.GetAllMock(userID)
+}
+
+func (m *MockProjectService) Delete(ctx context.Context, userID, projectID int) error {
+	return m.DeleteMock(userID, projectID)
+}
+
+func mockGin() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		c.Set("logger", logrus.NewEntry(logger))
+		c.Set("user_id", 42)
+		c.Next()
+	})
+	return r
+}
+
+func TestHandler_Create(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{name: "success", body: `{"name":"Home"}`, wantCode: http.StatusCreated},
+		{name: "empty name", body: `{"name":""}`, wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &project.Handler{
+				ProjectService: &MockProjectService{
+					CreateMock: func(userID int, name string) (*project.Project, error) {
+						return &project.Project{ID: 1, UserID: userID, Name: name}, nil
+					},
+				},
+			}
+
+			r := mockGin()
+			r.POST("/project/create", handler.Create)
+			req := httptest.NewRequest(http.MethodPost, "/project/create", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestHandler_Delete(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{name: "success", wantCode: http.StatusOK},
+		{name: "not found", err: project.ErrProjectNotFound, wantCode: http.StatusNotFound},
+		{name: "forbidden", err: project.ErrProjectForbidden, wantCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &project.Handler{
+				ProjectService: &MockProjectService{
+					DeleteMock: func(userID, projectID int) error {
+						return tt.err
+					},
+				},
+			}
+
+			r := mockGin()
+			r.DELETE("/project/:id", handler.Delete)
+			req := httptest.NewRequest(http.MethodDelete, "/project/1", nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package project
+
+import "time"
+
+type Project struct {
+	ID        int       `db:"id" json:"id"`
+	UserID    int       `db:"user_id" json:"user_id"`
+	Name      string    `db:"name" json:"name"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	// Role и SharedBy заполняются при выборке доступных проектов
+	Role     string  `db:"role" json:"role,omitempty"`
+	SharedBy *string `db:"shared_by" json:"shared_by,omitempty"`
+}
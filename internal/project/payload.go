@@ -0,0 +1,9 @@
+package project
+
+type URIParam struct {
+	ID int `uri:"id" binding:"required,min=1"`
+}
+
+type CreateRequest struct {
+	Name string `json:"name" binding:"required,min=1,max=100"`
+}
@@ -0,0 +1,140 @@
+package project
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/sirupsen/logrus"
+)
+
+const rankOwner = 3
+
+// Проекты изолированы по рабочему пространству из ctx так же, как задачи (см. db.Tenant)
+type IRepository interface {
+	Create(ctx context.Context, project *Project) (*Project, error)
+	GetAll(ctx context.Context, userID int) ([]Project, error)
+	Delete(ctx context.Context, project *Project) error
+}
+
+type Repository struct {
+	db     *db.Db
+	logger *logrus.Logger
+}
+
+func NewRepository(db *db.Db, logger *logrus.Logger) *Repository {
+	return &Repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *Repository) Create(ctx context.Context, project *Project) (*Project, error) {
+	logRepo := repositoryLogger(r.logger).WithField("user_id", project.UserID)
+	logRepo.Debug("Attempting to Create project")
+
+	query := `INSERT INTO projects (user_id, name) VALUES ($1, $2) RETURNING id, created_at`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		return tx.QueryRowContext(ctx, query, project.UserID, project.Name).Scan(&project.ID, &project.CreatedAt)
+	})
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to Create database")
+		return nil, err
+	}
+	project.Role = "owner"
+
+	logRepo.WithField("project_id", project.ID).Debug("Create database successfully")
+	return project, nil
+}
+
+// GetAll возвращает собственные и расшаренные проекты пользователя с его лучшей ролью
+func (r *Repository) GetAll(ctx context.Context, userID int) ([]Project, error) {
+	logRepo := repositoryLogger(r.logger).WithField("user_id", userID)
+	logRepo.Debug("Attempting to GetAll projects")
+
+	var projects []Project
+	query := `WITH access AS (
+			SELECT DISTINCT ON (project_id) project_id, rank, shared_by_id
+			FROM project_access WHERE user_id = $1
+			ORDER BY project_id, rank DESC, shared_by_id NULLS FIRST)
+		SELECT p.*,
+			CASE a.rank WHEN 3 THEN 'owner' WHEN 2 THEN 'editor' ELSE 'viewer' END AS role,
+			u.username AS shared_by
+		FROM access a
+		JOIN projects p ON p.id = a.project_id
+		LEFT JOIN users u ON u.id = a.shared_by_id
+		ORDER BY p.id`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		return tx.SelectContext(ctx, &projects, query, userID)
+	})
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to GetAll database")
+		return nil, err
+	}
+
+	logRepo.Debug("GetAll database successfully")
+	return projects, nil
+}
+
+// Delete удаляет проект, если у пользователя есть роль owner. Задачи проекта
+// остаются у своих владельцев без проекта.
+func (r *Repository) Delete(ctx context.Context, project *Project) error {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id":    project.UserID,
+		"project_id": project.ID,
+	})
+	logRepo.Debug("Attempting to Delete project")
+
+	query := `DELETE FROM projects p WHERE p.id = $1 AND EXISTS (
+			SELECT 1 FROM project_access pa
+			WHERE pa.project_id = p.id AND pa.user_id = $2 AND pa.rank >= $3)`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, query, project.ID, project.UserID, rankOwner)
+		if err != nil {
+			return err
+		}
+		return checkAffected(ctx, tx, result, project)
+	})
+	if err != nil {
+		if errors.Is(err, ErrProjectNotFound) || errors.Is(err, ErrProjectForbidden) {
+			logRepo.WithError(err).Warn("Project was not deleted")
+			return err
+		}
+		logRepo.WithError(err).Error("Failed to Delete database")
+		return err
+	}
+
+	logRepo.Debug("Delete database successfully")
+	return nil
+}
+
+// checkAffected отличает проект, которого пользователь не видит, от проекта,
+// где ему не хватает прав
+func checkAffected(ctx context.Context, tx *sqlx.Tx, result sql.Result, project *Project) error {
+	row, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if row > 0 {
+		return nil
+	}
+
+	var rank int
+	query := `SELECT COALESCE(MAX(rank), 0) FROM project_access WHERE project_id = $1 AND user_id = $2`
+
+	if err := tx.GetContext(ctx, &rank, query, project.ID, project.UserID); err != nil {
+		return err
+	}
+	if rank == 0 {
+		return ErrProjectNotFound
+	}
+	return ErrProjectForbidden
+}
+
+func repositoryLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Repository project layer")
+}
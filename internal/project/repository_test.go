@@ -0,0 +1,129 @@
+package project_test
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/melnik-dev/go_todo_jwt/internal/project"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func mockDB() (*project.Repository, sqlmock.Sqlmock, error) {
+	mockDb, mock, err := sqlmock.New()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pgDB := sqlx.NewDb(mockDb, "sqlMock")
+
+	repo := project.NewRepository(&db.Db{
+		DB: pgDB,
+	}, mockLogger())
+
+	return repo, mock, err
+}
+
+var ctx = db.WithWorkspace(context.Background(), 1)
+
+func expectTenant(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT set_config('role', $1, true), set_config('app.workspace_id', $2, true)`)).
+		WithArgs(db.TenantRole, "1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestProjectRepository_Create_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectTenant(mock)
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO projects (user_id, name) VALUES ($1, $2) RETURNING id, created_at`)).
+		WithArgs(42, "Home").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+	mock.ExpectCommit()
+
+	p, err := repo.Create(ctx, &project.Project{UserID: 42, Name: "Home"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != 1 || p.Role != "owner" {
+		t.Errorf("unexpected project %+v", p)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProjectRepository_GetAll_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "created_at", "role", "shared_by"}).
+		AddRow(1, 42, "Home", time.Now(), "owner", nil).
+		AddRow(2, 7, "Team", time.Now(), "editor", "bob")
+	expectTenant(mock)
+	mock.ExpectQuery(`FROM project_access WHERE user_id = \$1`).
+		WithArgs(42).
+		WillReturnRows(rows)
+	mock.ExpectCommit()
+
+	projects, err := repo.GetAll(ctx, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 2 {
+		t.Fatalf("expected 2 projects, got %d", len(projects))
+	}
+	if projects[0].SharedBy != nil || projects[1].SharedBy == nil || *projects[1].SharedBy != "bob" {
+		t.Errorf("unexpected shared_by: %+v", projects)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProjectRepository_Delete(t *testing.T) {
+	tests := []struct {
+		name    string
+		rank    int
+		wantErr error
+	}{
+		{name: "not visible", rank: 0, wantErr: project.ErrProjectNotFound},
+		{name: "editor", rank: 2, wantErr: project.ErrProjectForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock, err := mockDB()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectTenant(mock)
+			mock.ExpectExec(`DELETE FROM projects p WHERE p.id = \$1`).
+				WithArgs(1, 42, 3).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(MAX(rank), 0) FROM project_access WHERE project_id = $1 AND user_id = $2`)).
+				WithArgs(1, 42).
+				WillReturnRows(sqlmock.NewRows([]string{"rank"}).AddRow(tt.rank))
+			mock.ExpectRollback()
+
+			if err = repo.Delete(ctx, &project.Project{ID: 1, UserID: 42}); err != tt.wantErr {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package project
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+)
+
+type IService interface {
+	Create(ctx context.Context, userID int, name string) (*Project, error)
+	GetAll(ctx context.Context, userID int) ([]Project, error)
+	Delete(ctx context.Context, userID, projectID int) error
+}
+
+type Service struct {
+	projectRepo IRepository
+	logger      *logrus.Logger
+}
+
+func NewService(projectRepo IRepository, logger *logrus.Logger) *Service {
+	return &Service{
+		projectRepo: projectRepo,
+		logger:      logger,
+	}
+}
+
+func (s *Service) Create(ctx context.Context, userID int, name string) (*Project, error) {
+	logServ := serviceLogger(s.logger).WithField("user_id", userID)
+	logServ.Debug("Attempting to Create project")
+
+	project, err := s.projectRepo.Create(ctx, &Project{UserID: userID, Name: name})
+	if err != nil {
+		logServ.WithError(err).Error("Failed to Create project")
+		return nil, err
+	}
+
+	logServ.WithField("project_id", project.ID).Debug("Create project successfully")
+	return project, nil
+}
+
+func (s *Service) GetAll(ctx context.Context, userID int) ([]Project, error) {
+	logServ := serviceLogger(s.logger).WithField("user_id", userID)
+	logServ.Debug("Attempting to GetAll projects")
+
+	projects, err := s.projectRepo.GetAll(ctx, userID)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to GetAll projects")
+		return nil, err
+	}
+
+	logServ.Debug("GetAll projects successfully")
+	return projects, nil
+}
+
+func (s *Service) Delete(ctx context.Context, userID, projectID int) error {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id":    userID,
+		"project_id": projectID,
+	})
+	logServ.Debug("Attempting to Delete project")
+
+	if err := s.projectRepo.Delete(ctx, &Project{ID: projectID, UserID: userID}); err != nil {
+		logServ.WithError(err).Warn("Failed to Delete project")
+		return err
+	}
+
+	logServ.Debug("Delete project successfully")
+	return nil
+}
+
+func serviceLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Service project layer")
+}
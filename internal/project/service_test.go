@@ -0,0 +1,69 @@
+package project_test
+
+import (
+	"context"
+	"errors"
+	"github.com/melnik-dev/go_todo_jwt/internal/project"
+	"github.com/sirupsen/logrus"
+	"io"
+	"testing"
+)
+
+type MockProjectRepository struct {
+	CreateMock func(project *project.Project) (*project.Project, error)
+	GetAllMock func(userID int) ([]project.Project, error)
+	DeleteMock func(project *project.Project) error
+}
+
+func (m *MockProjectRepository) Create(ctx context.Context, project *project.Project) (*project.Project, error) {
+	return m.CreateMock(project)
+}
+
+func (m *MockProjectRepository) GetAll(ctx context.Context, userID int) ([]project.Project, error) {
+	return m.GetAllMock(userID)
+}
+
+func (m *MockProjectRepository) Delete(ctx context.Context, project *project.Project) error {
+	return m.DeleteMock(project)
+}
+
+func mockLogger() *logrus.Logger {
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	return l
+}
+
+func TestService_Create(t *testing.T) {
+	service := project.NewService(&MockProjectRepository{
+		CreateMock: func(p *project.Project) (*project.Project, error) {
+			if p.UserID != 42 || p.Name != "Home" {
+				t.Errorf("unexpected project %+v", p)
+			}
+			p.ID = 1
+			return p, nil
+		},
+	}, mockLogger())
+
+	p, err := service.Create(context.Background(), 42, "Home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != 1 {
+		t.Errorf("expected id 1, got %d", p.ID)
+	}
+}
+
+func TestService_Delete_PassesError(t *testing.T) {
+	service := project.NewService(&MockProjectRepository{
+		DeleteMock: func(p *project.Project) error {
+			if p.ID != 1 || p.UserID != 42 {
+				t.Errorf("unexpected project %+v", p)
+			}
+			return project.ErrProjectForbidden
+		},
+	}, mockLogger())
+
+	if err := service.Delete(context.Background(), 42, 1); !errors.Is(err, project.ErrProjectForbidden) {
+		t.Fatalf("expected %v, got %v", project.ErrProjectForbidden, err)
+	}
+}
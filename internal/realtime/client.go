@@ -0,0 +1,73 @@
+package realtime
+
+import (
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/time/rate"
+)
+
+// Client - одно WebSocket-подключение пользователя
+type Client struct {
+	UserID      int
+	WorkspaceID int
+
+	// send - очередь исходящих сообщений; запись в полную очередь отключает клиента
+	send chan []byte
+	// done закрывается один раз, когда подключение нужно завершить
+	done      chan struct{}
+	closeOnce sync.Once
+	closeCode int
+	closeText string
+
+	limiter *rate.Limiter
+	// projects - комнаты, в которых состоит клиент; меняется только под Hub.mu
+	projects map[int]struct{}
+}
+
+func NewClient(userID, workspaceID, sendBuffer int, limiter *rate.Limiter) *Client {
+	return &Client{
+		UserID:      userID,
+		WorkspaceID: workspaceID,
+		send:        make(chan []byte, sendBuffer),
+		done:        make(chan struct{}),
+		limiter:     limiter,
+		projects:    make(map[int]struct{}),
+	}
+}
+
+// Done закрывается, когда подключение завершается
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Queue - очередь исходящих сообщений; её читает только writer подключения
+func (c *Client) Queue() <-chan []byte {
+	return c.send
+}
+
+// Close завершает подключение с кодом и причиной для кадра закрытия; повторные вызовы ничего не меняют
+func (c *Client) Close(code int, text string) {
+	c.closeOnce.Do(func() {
+		c.closeCode = code
+		c.closeText = text
+		close(c.done)
+	})
+}
+
+// enqueue ставит сообщение в очередь, не блокируясь. Клиент, который не успевает
+// читать, отключается: догонять ему дешевле переподключением и перечитыванием задач.
+func (c *Client) enqueue(msg []byte) bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+	select {
+	case c.send <- msg:
+		return true
+	default:
+		c.Close(websocket.CloseTryAgainLater, "slow consumer")
+		return false
+	}
+}
@@ -0,0 +1,11 @@
+package realtime
+
+import "errors"
+
+var (
+	ErrProjectNotFound = errors.New("project not found")
+	ErrNotSubscribed   = errors.New("not subscribed to project")
+	ErrUnknownMessage  = errors.New("unknown message type")
+	ErrInvalidMessage  = errors.New("invalid message")
+	ErrRateLimited     = errors.New("too many messages")
+)
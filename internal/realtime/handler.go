@@ -0,0 +1,360 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gorilla/websocket"
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/internal/events"
+	"github.com/melnik-dev/go_todo_jwt/internal/task"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/melnik-dev/go_todo_jwt/pkg/logger"
+	"github.com/melnik-dev/go_todo_jwt/pkg/middleware"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+// ProtocolBearer - подпротокол, которым браузер передаёт JWT: WebSocket API
+// браузера не умеет ставить заголовки, поэтому токен идёт вторым элементом
+// Sec-WebSocket-Protocol: "bearer, <token>"
+const ProtocolBearer = "bearer"
+
+type HandlerDeps struct {
+	RealtimeService IService
+	Hub             *Hub
+	Broker          *events.Broker
+	*configs.Config
+	// Workspace выбирает рабочее пространство запроса
+	Workspace gin.HandlerFunc
+}
+
+type Handler struct {
+	RealtimeService IService
+	Hub             *Hub
+	Broker          *events.Broker
+	*configs.Config
+}
+
+func NewHandler(r *gin.Engine, deps *HandlerDeps) {
+	handler := &Handler{
+		RealtimeService: deps.RealtimeService,
+		Hub:             deps.Hub,
+		Broker:          deps.Broker,
+		Config:          deps.Config,
+	}
+	ws := r.Group("/ws")
+	ws.Use(BearerProtocol(), middleware.IsAuthed(handler.Config.JWT.Secret))
+	if deps.Workspace != nil {
+		ws.Use(deps.Workspace)
+	}
+	ws.GET("", handler.Connect)
+}
+
+// BearerProtocol переносит JWT из подпротокола bearer в заголовок Authorization,
+// чтобы браузерные клиенты проходили ту же проверку middleware.IsAuthed
+func BearerProtocol() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			protocols := websocket.Subprotocols(c.Request)
+			if i := slices.Index(protocols, ProtocolBearer); i >= 0 && i+1 < len(protocols) {
+				c.Request.Header.Set("Authorization", "Bearer "+protocols[i+1])
+			}
+		}
+		c.Next()
+	}
+}
+
+// Connect переводит запрос на WebSocket и обслуживает подключение до его закрытия:
+// подписки на проекты, присутствие, набор текста, изменения задач и события
+// задач подписанных проектов
+func (h *Handler) Connect(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Connect")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+	workspaceID, _ := db.WorkspaceFromContext(c.Request.Context())
+
+	upgrader := websocket.Upgrader{Subprotocols: []string{ProtocolBearer}}
+	if len(h.Config.Realtime.Origins) > 0 {
+		upgrader.CheckOrigin = h.checkOrigin
+	}
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade уже ответил клиенту ошибкой
+		logHandle.WithError(err).Warn("Failed to upgrade connection")
+		return
+	}
+	logHandle = logHandle.WithFields(logrus.Fields{
+		"user_id":      userID,
+		"workspace_id": workspaceID,
+	})
+
+	cfg := h.Config.Realtime
+	client := NewClient(userID, workspaceID, cfg.SendBuffer, rate.NewLimiter(rate.Limit(cfg.Rate), cfg.Burst))
+	sub, _, _ := h.Broker.Subscribe(userID, workspaceID, 0)
+	defer h.Broker.Unsubscribe(sub)
+
+	conn.SetReadLimit(cfg.MaxMessage)
+	_ = conn.SetReadDeadline(time.Now().Add(cfg.PongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(cfg.PongWait))
+	})
+
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		h.write(logHandle, conn, client, sub)
+	}()
+	logHandle.Debug("Connection opened")
+
+	h.read(c.Request.Context(), logHandle, conn, client)
+
+	client.Close(websocket.CloseNormalClosure, "")
+	h.Hub.LeaveAll(client)
+	<-written
+	logHandle.Debug("Connection closed")
+}
+
+// read разбирает сообщения клиента, пока соединение не закроется
+func (h *Handler) read(ctx context.Context, logHandle *logrus.Entry, conn *websocket.Conn, client *Client) {
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				logHandle.WithError(err).Debug("Connection read failed")
+			}
+			return
+		}
+
+		var msg Message
+		if err = json.Unmarshal(data, &msg); err != nil {
+			h.reply(logHandle, client, "", ErrInvalidMessage)
+			continue
+		}
+		if !client.limiter.Allow() {
+			logHandle.Warn(ErrRateLimited.Error())
+			h.reply(logHandle, client, msg.Ref, ErrRateLimited)
+			continue
+		}
+
+		result, err := h.handle(ctx, client, &msg)
+		if msg.Type == TypeTyping && err == nil {
+			continue
+		}
+		if err != nil {
+			h.reply(logHandle.WithField("type", msg.Type), client, msg.Ref, err)
+			continue
+		}
+		if reply, err := encode(TypeAck, msg.Ref, result); err == nil {
+			client.enqueue(reply)
+		}
+	}
+}
+
+// handle выполняет сообщение клиента и возвращает данные для ack.
+// На typing ack не отправляется: такие сообщения идут часто и не требуют ответа.
+func (h *Handler) handle(ctx context.Context, client *Client, msg *Message) (any, error) {
+	switch msg.Type {
+	case TypeSubscribe:
+		var req ProjectRequest
+		if err := decode(msg.Data, &req); err != nil {
+			return nil, err
+		}
+		if err := h.RealtimeService.Subscribe(ctx, client.UserID, req.ProjectID); err != nil {
+			return nil, err
+		}
+		h.Hub.Join(client, req.ProjectID)
+		return req, nil
+
+	case TypeUnsubscribe:
+		var req ProjectRequest
+		if err := decode(msg.Data, &req); err != nil {
+			return nil, err
+		}
+		h.Hub.Leave(client, req.ProjectID)
+		return req, nil
+
+	case TypeTyping:
+		var req TypingRequest
+		if err := decode(msg.Data, &req); err != nil {
+			return nil, err
+		}
+		if !h.Hub.Subscribed(client, req.ProjectID) {
+			return nil, ErrNotSubscribed
+		}
+		typing, err := encode(TypeTyping, "", Typing{
+			ProjectID: req.ProjectID,
+			TaskID:    req.TaskID,
+			UserID:    client.UserID,
+			Typing:    req.Typing,
+		})
+		if err != nil {
+			return nil, err
+		}
+		h.Hub.Broadcast(client.WorkspaceID, req.ProjectID, typing, client)
+		return nil, nil
+
+	case TypeTaskCreate:
+		var req CreateTaskRequest
+		if err := decode(msg.Data, &req); err != nil {
+			return nil, err
+		}
+		taskID, err := h.RealtimeService.CreateTask(ctx, client.UserID, &req)
+		if err != nil {
+			return nil, err
+		}
+		return TaskResponse{TaskID: taskID}, nil
+
+	case TypeTaskUpdate:
+		var req UpdateTaskRequest
+		if err := decode(msg.Data, &req); err != nil {
+			return nil, err
+		}
+		if err := h.RealtimeService.UpdateTask(ctx, client.UserID, &req); err != nil {
+			return nil, err
+		}
+		return TaskResponse{TaskID: req.TaskID}, nil
+
+	case TypeTaskDelete:
+		var req DeleteTaskRequest
+		if err := decode(msg.Data, &req); err != nil {
+			return nil, err
+		}
+		if err := h.RealtimeService.DeleteTask(ctx, client.UserID, &req); err != nil {
+			return nil, err
+		}
+		return TaskResponse{TaskID: req.TaskID}, nil
+	}
+	return nil, ErrUnknownMessage
+}
+
+// write отправляет клиенту очередь сообщений и события задач подписанных
+// проектов и пингует его; при выходе закрывает соединение, что завершает read
+func (h *Handler) write(logHandle *logrus.Entry, conn *websocket.Conn, client *Client, sub *events.Subscription) {
+	defer conn.Close()
+
+	cfg := h.Config.Realtime
+	ping := time.NewTicker(cfg.PingInterval)
+	defer ping.Stop()
+
+	stream := sub.Events()
+	for {
+		select {
+		case <-client.Done():
+			msg := websocket.FormatCloseMessage(client.closeCode, client.closeText)
+			_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(cfg.WriteTimeout))
+			return
+		case msg := <-client.Queue():
+			if err := h.writeMessage(conn, msg); err != nil {
+				logHandle.WithError(err).Debug("Connection write failed")
+				client.Close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case e, ok := <-stream:
+			if !ok {
+				// Broker отключил медленного клиента или сервис останавливается
+				stream = nil
+				client.Close(websocket.CloseTryAgainLater, "events stream closed")
+				continue
+			}
+			msg, send := h.taskEvent(client, &e)
+			if !send {
+				continue
+			}
+			if err := h.writeMessage(conn, msg); err != nil {
+				logHandle.WithError(err).Debug("Connection write failed")
+				client.Close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(cfg.WriteTimeout)); err != nil {
+				logHandle.WithError(err).Debug("Connection ping failed")
+				client.Close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		}
+	}
+}
+
+func (h *Handler) writeMessage(conn *websocket.Conn, msg []byte) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(h.Config.Realtime.WriteTimeout)); err != nil {
+		return err
+	}
+	return conn.WriteMessage(websocket.TextMessage, msg)
+}
+
+// taskEvent готовит событие задачи к отправке, если задача лежит в проекте,
+// на который подписан клиент
+func (h *Handler) taskEvent(client *Client, e *events.Event) ([]byte, bool) {
+	var payload struct {
+		ProjectID *int `json:"project_id"`
+	}
+	if err := json.Unmarshal(e.Payload, &payload); err != nil || payload.ProjectID == nil {
+		return nil, false
+	}
+	if !h.Hub.Subscribed(client, *payload.ProjectID) {
+		return nil, false
+	}
+	msg, err := encode(e.Type, "", events.Data{
+		Type:      e.Type,
+		TaskID:    e.TaskID,
+		Task:      e.Payload,
+		CreatedAt: e.CreatedAt,
+	})
+	return msg, err == nil
+}
+
+// reply отправляет клиенту error; внутренние ошибки не раскрываются
+func (h *Handler) reply(logHandle *logrus.Entry, client *Client, ref string, err error) {
+	text := err.Error()
+	switch {
+	case errors.Is(err, ErrInvalidMessage), errors.Is(err, ErrUnknownMessage),
+		errors.Is(err, ErrRateLimited), errors.Is(err, ErrNotSubscribed),
+		errors.Is(err, ErrProjectNotFound), errors.Is(err, task.ErrTaskNotFound),
+		errors.Is(err, task.ErrTaskForbidden), errors.Is(err, task.ErrProjectNotFound):
+		logHandle.Debug(text)
+	default:
+		logHandle.WithError(err).Error("Failed to handle message")
+		text = "internal error"
+	}
+	if msg, err := encode(TypeError, ref, ErrorData{Error: text}); err == nil {
+		client.enqueue(msg)
+	}
+}
+
+// checkOrigin пускает страницы из realtime.origins; без списка работает проверка
+// gorilla/websocket по умолчанию - Origin должен совпадать с Host
+func (h *Handler) checkOrigin(r *http.Request) bool {
+	origins := h.Config.Realtime.Origins
+	origin := r.Header.Get("Origin")
+	return origin == "" || slices.Contains(origins, "*") || slices.Contains(origins, origin)
+}
+
+// decode разбирает data сообщения и проверяет его по тегам binding, как ShouldBindJSON
+func decode(data json.RawMessage, dst any) error {
+	if len(data) == 0 {
+		return ErrInvalidMessage
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	if err := binding.Validator.ValidateStruct(dst); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	return nil
+}
+
+func handlerLogger(c *gin.Context) *logrus.Entry {
+	return logger.FromContext(c).WithField("layer", "Handler realtime layer")
+}
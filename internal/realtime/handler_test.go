@@ -0,0 +1,284 @@
+package realtime_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/lib/pq"
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/internal/events"
+	"github.com/melnik-dev/go_todo_jwt/internal/realtime"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/melnik-dev/go_todo_jwt/pkg/jwt"
+	"github.com/sirupsen/logrus"
+)
+
+const secret = "secret"
+
+// MockRealtimeService встраивает realtime.IService, чтобы переопределять только нужные методы
+type MockRealtimeService struct {
+	realtime.IService
+	SubscribeMock  func(userID, projectID int) error
+	CreateTaskMock func(userID int, req *realtime.CreateTaskRequest) (int, error)
+}
+
+func (m *MockRealtimeService) Subscribe(ctx context.Context, userID, projectID int) error {
+	return m.SubscribeMock(userID, projectID)
+}
+
+func (m *MockRealtimeService) CreateTask(ctx context.Context, userID int, req *realtime.CreateTaskRequest) (int, error) {
+	return m.CreateTaskMock(userID, req)
+}
+
+// visibleProject разрешает подписку только на проект 5
+func visibleProject(userID, projectID int) error {
+	if projectID != 5 {
+		return realtime.ErrProjectNotFound
+	}
+	return nil
+}
+
+func mockConfig() *configs.Config {
+	return &configs.Config{
+		JWT: configs.ConfJWT{Secret: secret},
+		Realtime: configs.ConfRealtime{
+			PingInterval: time.Hour,
+			PongWait:     time.Hour,
+			WriteTimeout: time.Second,
+			SendBuffer:   16,
+			MaxMessage:   4096,
+			Rate:         100,
+			Burst:        100,
+		},
+	}
+}
+
+// mockServer поднимает /ws с настоящей проверкой JWT; рабочее пространство всегда 1
+func mockServer(t *testing.T, service realtime.IService, broker *events.Broker, cfg *configs.Config) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		c.Set("logger", logrus.NewEntry(logger))
+		c.Next()
+	})
+	realtime.NewHandler(r, &realtime.HandlerDeps{
+		RealtimeService: service,
+		Hub:             realtime.NewHub(),
+		Broker:          broker,
+		Config:          cfg,
+		Workspace: func(c *gin.Context) {
+			c.Request = c.Request.WithContext(db.WithWorkspace(c.Request.Context(), 1))
+			c.Next()
+		},
+	})
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// dial подключается как браузер: токен передаётся подпротоколом bearer
+func dial(t *testing.T, srv *httptest.Server, userID int) *websocket.Conn {
+	t.Helper()
+	token, err := jwt.NewJWT(secret).Create(jwt.Data{UserId: userID, TokenTTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dialer := websocket.Dialer{Subprotocols: []string{realtime.ProtocolBearer, token}}
+	conn, resp, err := dialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Header.Get("Sec-WebSocket-Protocol") != realtime.ProtocolBearer {
+		t.Errorf("unexpected protocol %q", resp.Header.Get("Sec-WebSocket-Protocol"))
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func send(t *testing.T, conn *websocket.Conn, typ, ref, data string) {
+	t.Helper()
+	msg := realtime.Message{Type: typ, Ref: ref}
+	if data != "" {
+		msg.Data = json.RawMessage(data)
+	}
+	if err := conn.WriteJSON(msg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// receive читает следующее сообщение типа typ, пропуская остальные
+func receive(t *testing.T, conn *websocket.Conn, typ string) realtime.Message {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var msg realtime.Message
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("waiting for %s: %v", typ, err)
+		}
+		if msg.Type == typ {
+			return msg
+		}
+	}
+}
+
+func TestHandler_Connect_Unauthorized(t *testing.T) {
+	srv := mockServer(t, &MockRealtimeService{}, events.NewBroker(10), mockConfig())
+
+	dialer := websocket.Dialer{Subprotocols: []string{realtime.ProtocolBearer, "invalid"}}
+	_, resp, err := dialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+	if err == nil {
+		t.Fatal("expected handshake to fail")
+	}
+	if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %v", resp)
+	}
+}
+
+func TestHandler_PresenceAndTyping(t *testing.T) {
+	srv := mockServer(t, &MockRealtimeService{SubscribeMock: visibleProject}, events.NewBroker(10), mockConfig())
+	alice := dial(t, srv, 42)
+	bob := dial(t, srv, 7)
+
+	send(t, alice, realtime.TypeSubscribe, "1", `{"project_id":5}`)
+	if ack := receive(t, alice, realtime.TypeAck); ack.Ref != "1" {
+		t.Fatalf("unexpected ack %+v", ack)
+	}
+	send(t, bob, realtime.TypeSubscribe, "2", `{"project_id":5}`)
+	receive(t, bob, realtime.TypeAck)
+
+	// присутствие с одной alice пришло до её ack, следующее - уже с bob
+	var presence realtime.Presence
+	if err := json.Unmarshal(receive(t, alice, realtime.TypePresence).Data, &presence); err != nil {
+		t.Fatal(err)
+	}
+	if len(presence.Users) != 2 || presence.Users[0] != 7 || presence.Users[1] != 42 {
+		t.Fatalf("unexpected presence %+v", presence)
+	}
+
+	send(t, bob, realtime.TypeTyping, "", `{"project_id":5,"task_id":10,"typing":true}`)
+	var typing realtime.Typing
+	if err := json.Unmarshal(receive(t, alice, realtime.TypeTyping).Data, &typing); err != nil {
+		t.Fatal(err)
+	}
+	if typing.UserID != 7 || typing.TaskID != 10 || !typing.Typing {
+		t.Errorf("unexpected typing %+v", typing)
+	}
+
+	bob.Close()
+	if err := json.Unmarshal(receive(t, alice, realtime.TypePresence).Data, &presence); err != nil {
+		t.Fatal(err)
+	}
+	if len(presence.Users) != 1 || presence.Users[0] != 42 {
+		t.Errorf("expected bob to leave, got %+v", presence)
+	}
+}
+
+func TestHandler_Messages(t *testing.T) {
+	tests := []struct {
+		name      string
+		typ       string
+		data      string
+		wantType  string
+		wantError string
+	}{
+		{name: "create task", typ: realtime.TypeTaskCreate, data: `{"project_id":5,"title":"Buy milk"}`, wantType: realtime.TypeAck},
+		{name: "empty title", typ: realtime.TypeTaskCreate, data: `{"title":""}`, wantType: realtime.TypeError},
+		{name: "hidden project", typ: realtime.TypeSubscribe, data: `{"project_id":6}`, wantType: realtime.TypeError, wantError: realtime.ErrProjectNotFound.Error()},
+		{name: "typing without subscription", typ: realtime.TypeTyping, data: `{"project_id":5,"task_id":1}`, wantType: realtime.TypeError, wantError: realtime.ErrNotSubscribed.Error()},
+		{name: "unknown type", typ: "task.archive", data: `{}`, wantType: realtime.TypeError, wantError: realtime.ErrUnknownMessage.Error()},
+	}
+
+	service := &MockRealtimeService{
+		SubscribeMock: visibleProject,
+		CreateTaskMock: func(userID int, req *realtime.CreateTaskRequest) (int, error) {
+			return 10, nil
+		},
+	}
+	srv := mockServer(t, service, events.NewBroker(10), mockConfig())
+	conn := dial(t, srv, 42)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			send(t, conn, tt.typ, tt.name, tt.data)
+			_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			var msg realtime.Message
+			if err := conn.ReadJSON(&msg); err != nil {
+				t.Fatal(err)
+			}
+			if msg.Type != tt.wantType || msg.Ref != tt.name {
+				t.Fatalf("unexpected reply %+v (%s)", msg, msg.Data)
+			}
+			if tt.wantError != "" && !strings.Contains(string(msg.Data), tt.wantError) {
+				t.Errorf("expected error %q, got %s", tt.wantError, msg.Data)
+			}
+		})
+	}
+}
+
+func TestHandler_RateLimit(t *testing.T) {
+	cfg := mockConfig()
+	cfg.Realtime.Rate = 0.001
+	cfg.Realtime.Burst = 1
+	srv := mockServer(t, &MockRealtimeService{SubscribeMock: visibleProject}, events.NewBroker(10), cfg)
+	conn := dial(t, srv, 42)
+
+	send(t, conn, realtime.TypeUnsubscribe, "1", `{"project_id":5}`)
+	send(t, conn, realtime.TypeUnsubscribe, "2", `{"project_id":5}`)
+
+	receive(t, conn, realtime.TypeAck)
+	msg := receive(t, conn, realtime.TypeError)
+	if msg.Ref != "2" || !strings.Contains(string(msg.Data), realtime.ErrRateLimited.Error()) {
+		t.Errorf("unexpected reply %+v (%s)", msg, msg.Data)
+	}
+}
+
+func TestHandler_TaskEvents(t *testing.T) {
+	broker := events.NewBroker(10)
+	srv := mockServer(t, &MockRealtimeService{SubscribeMock: visibleProject}, broker, mockConfig())
+	conn := dial(t, srv, 42)
+
+	send(t, conn, realtime.TypeSubscribe, "1", `{"project_id":5}`)
+	receive(t, conn, realtime.TypeAck)
+
+	for i, payload := range []string{`{"id":1}`, `{"id":2,"project_id":6}`, `{"id":3,"project_id":5}`} {
+		broker.Publish(events.Event{
+			ID:          int64(i + 1),
+			WorkspaceID: 1,
+			Type:        "task.created",
+			TaskID:      i + 1,
+			Payload:     []byte(payload),
+			Audience:    pq.Int64Array{42},
+		})
+	}
+
+	var data events.Data
+	if err := json.Unmarshal(receive(t, conn, "task.created").Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if data.TaskID != 3 {
+		t.Errorf("expected only task of subscribed project, got %+v", data)
+	}
+
+	// остановка broker закрывает подключение
+	broker.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseTryAgainLater) {
+				t.Errorf("expected close %d, got %v", websocket.CloseTryAgainLater, err)
+			}
+			break
+		}
+	}
+}
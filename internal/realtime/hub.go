@@ -0,0 +1,122 @@
+package realtime
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+)
+
+// room - проект в рабочем пространстве; id проектов уникальны глобально,
+// но пространство в ключе не даёт подключению из чужого пространства попасть в комнату
+type room struct {
+	workspaceID int
+	projectID   int
+}
+
+// Hub хранит комнаты проектов этого экземпляра: кто их смотрит и кому
+// рассылать присутствие и набор текста
+type Hub struct {
+	mu    sync.Mutex
+	rooms map[room]map[*Client]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		rooms: make(map[room]map[*Client]struct{}),
+	}
+}
+
+// Join добавляет клиента в комнату проекта и рассылает её участникам новый список присутствующих
+func (h *Hub) Join(c *Client, projectID int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := room{workspaceID: c.WorkspaceID, projectID: projectID}
+	clients, ok := h.rooms[key]
+	if !ok {
+		clients = make(map[*Client]struct{})
+		h.rooms[key] = clients
+	}
+	clients[c] = struct{}{}
+	c.projects[projectID] = struct{}{}
+	h.presence(key)
+}
+
+// Leave убирает клиента из комнаты; оставшиеся получают новый список присутствующих
+func (h *Hub) Leave(c *Client, projectID int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leave(c, projectID)
+}
+
+// LeaveAll убирает клиента из всех комнат при отключении
+func (h *Hub) LeaveAll(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for projectID := range c.projects {
+		h.leave(c, projectID)
+	}
+}
+
+// Subscribed сообщает, подписан ли клиент на проект
+func (h *Hub) Subscribed(c *Client, projectID int) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, ok := c.projects[projectID]
+	return ok
+}
+
+// Broadcast отправляет сообщение всем участникам комнаты проекта, кроме except
+func (h *Hub) Broadcast(workspaceID, projectID int, msg []byte, except *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.rooms[room{workspaceID: workspaceID, projectID: projectID}] {
+		if c != except {
+			c.enqueue(msg)
+		}
+	}
+}
+
+func (h *Hub) leave(c *Client, projectID int) {
+	key := room{workspaceID: c.WorkspaceID, projectID: projectID}
+	delete(c.projects, projectID)
+	clients, ok := h.rooms[key]
+	if !ok {
+		return
+	}
+	delete(clients, c)
+	if len(clients) == 0 {
+		delete(h.rooms, key)
+		return
+	}
+	h.presence(key)
+}
+
+// presence рассылает участникам комнаты пользователей, у которых она открыта;
+// пользователь с несколькими вкладками учитывается один раз
+func (h *Hub) presence(key room) {
+	clients := h.rooms[key]
+	users := make([]int, 0, len(clients))
+	for c := range clients {
+		if !slices.Contains(users, c.UserID) {
+			users = append(users, c.UserID)
+		}
+	}
+	slices.Sort(users)
+
+	msg, err := encode(TypePresence, "", Presence{ProjectID: key.projectID, Users: users})
+	if err != nil {
+		return
+	}
+	for c := range clients {
+		c.enqueue(msg)
+	}
+}
+
+func encode(typ, ref string, data any) ([]byte, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(Message{Type: typ, Ref: ref, Data: raw})
+}
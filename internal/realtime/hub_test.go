@@ -0,0 +1,104 @@
+package realtime_test
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/melnik-dev/go_todo_jwt/internal/realtime"
+	"golang.org/x/time/rate"
+)
+
+func newClient(userID, sendBuffer int) *realtime.Client {
+	return realtime.NewClient(userID, 1, sendBuffer, rate.NewLimiter(rate.Inf, 1))
+}
+
+func closed(c *realtime.Client) bool {
+	select {
+	case <-c.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+func TestHub_Presence(t *testing.T) {
+	hub := realtime.NewHub()
+	alice := newClient(42, 10)
+	bob := newClient(7, 10)
+	bobTab := newClient(7, 10)
+
+	hub.Join(alice, 5)
+	hub.Join(bob, 5)
+	hub.Join(bobTab, 5)
+	hub.Leave(bob, 5)
+
+	if !hub.Subscribed(bobTab, 5) || hub.Subscribed(bob, 5) {
+		t.Fatal("unexpected subscriptions")
+	}
+	if closed(alice) || closed(bob) || closed(bobTab) {
+		t.Fatal("clients must stay connected")
+	}
+
+	hub.Broadcast(1, 5, []byte(`{"type":"typing"}`), alice)
+	hub.Broadcast(2, 5, []byte(`{"type":"typing"}`), nil)
+
+	tests := []struct {
+		name   string
+		client *realtime.Client
+		want   [][]int
+		typing int
+	}{
+		// alice видит всех вошедших, затем уход одной вкладки bob ничего не меняет
+		{name: "alice", client: alice, want: [][]int{{42}, {7, 42}, {7, 42}, {7, 42}}},
+		{name: "bob tab", client: bobTab, want: [][]int{{7, 42}, {7, 42}}, typing: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][]int
+			typing := 0
+			for len(got)+typing < len(tt.want)+tt.typing {
+				var msg realtime.Message
+				if err := json.Unmarshal(<-tt.client.Queue(), &msg); err != nil {
+					t.Fatal(err)
+				}
+				if msg.Type == realtime.TypeTyping {
+					typing++
+					continue
+				}
+				var presence realtime.Presence
+				if err := json.Unmarshal(msg.Data, &presence); err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, presence.Users)
+			}
+			if !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("expected presence %v, got %v", tt.want, got)
+			}
+			if typing != tt.typing {
+				t.Errorf("expected %d typing messages, got %d", tt.typing, typing)
+			}
+		})
+	}
+}
+
+func TestHub_SlowConsumer(t *testing.T) {
+	hub := realtime.NewHub()
+	slow := newClient(42, 1)
+	fast := newClient(7, 10)
+
+	hub.Join(slow, 5)
+	hub.Join(fast, 5)
+
+	if !closed(slow) {
+		t.Fatal("slow client must be disconnected when its queue is full")
+	}
+	if closed(fast) {
+		t.Fatal("fast client must stay connected")
+	}
+
+	hub.LeaveAll(slow)
+	if hub.Subscribed(slow, 5) {
+		t.Error("disconnected client must leave its rooms")
+	}
+}
@@ -0,0 +1,49 @@
+package realtime
+
+import (
+	"encoding/json"
+)
+
+// Типы сообщений клиента
+const (
+	TypeSubscribe   = "subscribe"
+	TypeUnsubscribe = "unsubscribe"
+	TypeTyping      = "typing"
+	TypeTaskCreate  = "task.create"
+	TypeTaskUpdate  = "task.update"
+	TypeTaskDelete  = "task.delete"
+)
+
+// Типы сообщений сервера; события задач приходят с типами outbox (task.created и т.д.)
+const (
+	TypeAck      = "ack"
+	TypeError    = "error"
+	TypePresence = "presence"
+)
+
+// Message - конверт сообщения в обе стороны. Ref клиент выбирает сам,
+// сервер повторяет его в ack или error на это сообщение.
+type Message struct {
+	Type string          `json:"type"`
+	Ref  string          `json:"ref,omitempty"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// Presence - кто сейчас смотрит проект
+type Presence struct {
+	ProjectID int   `json:"project_id"`
+	Users     []int `json:"users"`
+}
+
+// Typing - пользователь начал или закончил редактировать задачу проекта
+type Typing struct {
+	ProjectID int  `json:"project_id"`
+	TaskID    int  `json:"task_id"`
+	UserID    int  `json:"user_id"`
+	Typing    bool `json:"typing"`
+}
+
+// ErrorData - причина отказа в сообщении error
+type ErrorData struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,33 @@
+package realtime
+
+type ProjectRequest struct {
+	ProjectID int `json:"project_id" binding:"required,min=1"`
+}
+
+type TypingRequest struct {
+	ProjectID int  `json:"project_id" binding:"required,min=1"`
+	TaskID    int  `json:"task_id" binding:"required,min=1"`
+	Typing    bool `json:"typing"`
+}
+
+// CreateTaskRequest создаёт задачу; с project_id она сразу кладётся в проект
+type CreateTaskRequest struct {
+	ProjectID   *int   `json:"project_id" binding:"omitempty,min=1"`
+	Title       string `json:"title" binding:"required,min=1,max=100"`
+	Description string `json:"description" binding:"max=500"`
+}
+
+type UpdateTaskRequest struct {
+	TaskID      int    `json:"task_id" binding:"required,min=1"`
+	Title       string `json:"title" binding:"required,min=1,max=100"`
+	Description string `json:"description" binding:"max=500"`
+	Completed   bool   `json:"completed"`
+}
+
+type DeleteTaskRequest struct {
+	TaskID int `json:"task_id" binding:"required,min=1"`
+}
+
+type TaskResponse struct {
+	TaskID int `json:"task_id"`
+}
@@ -0,0 +1,52 @@
+package realtime
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/sirupsen/logrus"
+)
+
+type IRepository interface {
+	ProjectRank(ctx context.Context, projectID, userID int) (int, error)
+}
+
+type Repository struct {
+	db     *db.Db
+	logger *logrus.Logger
+}
+
+func NewRepository(db *db.Db, logger *logrus.Logger) *Repository {
+	return &Repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// ProjectRank возвращает лучший ранг пользователя в проекте, 0 - проект ему не виден
+func (r *Repository) ProjectRank(ctx context.Context, projectID, userID int) (int, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"project_id": projectID,
+		"user_id":    userID,
+	})
+	logRepo.Debug("Attempting to get ProjectRank")
+
+	var rank int
+	query := `SELECT COALESCE(MAX(rank), 0) FROM project_access WHERE project_id = $1 AND user_id = $2`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		return tx.GetContext(ctx, &rank, query, projectID, userID)
+	})
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to get ProjectRank database")
+		return 0, err
+	}
+
+	logRepo.WithField("rank", rank).Debug("ProjectRank database successfully")
+	return rank, nil
+}
+
+func repositoryLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Repository realtime layer")
+}
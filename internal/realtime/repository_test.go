@@ -0,0 +1,55 @@
+package realtime_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/melnik-dev/go_todo_jwt/internal/realtime"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+)
+
+func mockDB() (*realtime.Repository, sqlmock.Sqlmock, error) {
+	mockDb, mock, err := sqlmock.New()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pgDB := sqlx.NewDb(mockDb, "sqlMock")
+
+	repo := realtime.NewRepository(&db.Db{
+		DB: pgDB,
+	}, mockLogger())
+
+	return repo, mock, err
+}
+
+func TestRealtimeRepository_ProjectRank(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT set_config('role', $1, true), set_config('app.workspace_id', $2, true)`)).
+		WithArgs(db.TenantRole, "1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(MAX(rank), 0) FROM project_access WHERE project_id = $1 AND user_id = $2`)).
+		WithArgs(5, 42).
+		WillReturnRows(sqlmock.NewRows([]string{"rank"}).AddRow(2))
+	mock.ExpectCommit()
+
+	rank, err := repo.ProjectRank(db.WithWorkspace(context.Background(), 1), 5, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rank != 2 {
+		t.Errorf("expected rank 2, got %d", rank)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,116 @@
+package realtime
+
+import (
+	"context"
+
+	"github.com/melnik-dev/go_todo_jwt/internal/task"
+	"github.com/sirupsen/logrus"
+)
+
+type IService interface {
+	Subscribe(ctx context.Context, userID, projectID int) error
+	CreateTask(ctx context.Context, userID int, req *CreateTaskRequest) (int, error)
+	UpdateTask(ctx context.Context, userID int, req *UpdateTaskRequest) error
+	DeleteTask(ctx context.Context, userID int, req *DeleteTaskRequest) error
+}
+
+// Service проверяет доступ к проектам комнат; изменения задач проходят
+// через task.IService с теми же правами и уведомлениями, что и в REST API
+type Service struct {
+	realtimeRepo IRepository
+	taskService  task.IService
+	logger       *logrus.Logger
+}
+
+func NewService(realtimeRepo IRepository, taskService task.IService, logger *logrus.Logger) *Service {
+	return &Service{
+		realtimeRepo: realtimeRepo,
+		taskService:  taskService,
+		logger:       logger,
+	}
+}
+
+// Subscribe разрешает подписку на проект любому, кто его видит
+func (s *Service) Subscribe(ctx context.Context, userID, projectID int) error {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id":    userID,
+		"project_id": projectID,
+	})
+	logServ.Debug("Attempting to Subscribe")
+
+	rank, err := s.realtimeRepo.ProjectRank(ctx, projectID, userID)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to get ProjectRank")
+		return err
+	}
+	if rank == 0 {
+		logServ.Warn(ErrProjectNotFound.Error())
+		return ErrProjectNotFound
+	}
+
+	logServ.Debug("Subscribe successfully")
+	return nil
+}
+
+// CreateTask создаёт задачу и кладёт её в проект. Если положить не удалось,
+// задача удаляется, чтобы клиент не получил её вне проекта.
+func (s *Service) CreateTask(ctx context.Context, userID int, req *CreateTaskRequest) (int, error) {
+	logServ := serviceLogger(s.logger).WithField("user_id", userID)
+	logServ.Debug("Attempting to CreateTask")
+
+	taskID, err := s.taskService.Create(ctx, userID, req.Title, req.Description)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to Create task")
+		return 0, err
+	}
+	logServ = logServ.WithField("task_id", taskID)
+
+	if req.ProjectID != nil {
+		if err = s.taskService.SetProject(ctx, userID, taskID, req.ProjectID); err != nil {
+			logServ.WithError(err).Warn("Failed to SetProject")
+			if delErr := s.taskService.Delete(ctx, userID, taskID); delErr != nil {
+				logServ.WithError(delErr).Error("Failed to Delete task without project")
+			}
+			return 0, err
+		}
+	}
+
+	logServ.Debug("CreateTask successfully")
+	return taskID, nil
+}
+
+func (s *Service) UpdateTask(ctx context.Context, userID int, req *UpdateTaskRequest) error {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id": userID,
+		"task_id": req.TaskID,
+	})
+	logServ.Debug("Attempting to UpdateTask")
+
+	if err := s.taskService.Update(ctx, userID, req.TaskID, req.Title, req.Description, req.Completed); err != nil {
+		logServ.WithError(err).Warn("Failed to Update task")
+		return err
+	}
+
+	logServ.Debug("UpdateTask successfully")
+	return nil
+}
+
+func (s *Service) DeleteTask(ctx context.Context, userID int, req *DeleteTaskRequest) error {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id": userID,
+		"task_id": req.TaskID,
+	})
+	logServ.Debug("Attempting to DeleteTask")
+
+	if err := s.taskService.Delete(ctx, userID, req.TaskID); err != nil {
+		logServ.WithError(err).Warn("Failed to Delete task")
+		return err
+	}
+
+	logServ.Debug("DeleteTask successfully")
+	return nil
+}
+
+func serviceLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Service realtime layer")
+}
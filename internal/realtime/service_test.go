@@ -0,0 +1,133 @@
+package realtime_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/melnik-dev/go_todo_jwt/internal/realtime"
+	"github.com/melnik-dev/go_todo_jwt/internal/task"
+	"github.com/sirupsen/logrus"
+)
+
+type MockRealtimeRepository struct {
+	ProjectRankMock func(projectID, userID int) (int, error)
+}
+
+func (m *MockRealtimeRepository) ProjectRank(ctx context.Context, projectID, userID int) (int, error) {
+	return m.ProjectRankMock(projectID, userID)
+}
+
+// MockTaskService встраивает task.IService, чтобы переопределять только нужные методы
+type MockTaskService struct {
+	task.IService
+	CreateMock     func(userID int, title, desc string) (int, error)
+	UpdateMock     func(userID, taskID int, title, desc string, completed bool) error
+	DeleteMock     func(userID, taskID int) error
+	SetProjectMock func(userID, taskID int, projectID *int) error
+}
+
+func (m *MockTaskService) Create(ctx context.Context, userID int, title, desc string) (int, error) {
+	return m.CreateMock(userID, title, desc)
+}
+
+func (m *MockTaskService) Update(ctx context.Context, userID, taskID int, title, desc string, completed bool) error {
+	return m.UpdateMock(userID, taskID, title, desc, completed)
+}
+
+func (m *MockTaskService) Delete(ctx context.Context, userID, taskID int) error {
+	return m.DeleteMock(userID, taskID)
+}
+
+func (m *MockTaskService) SetProject(ctx context.Context, userID, taskID int, projectID *int) error {
+	return m.SetProjectMock(userID, taskID, projectID)
+}
+
+func mockLogger() *logrus.Logger {
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	return l
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestService_Subscribe(t *testing.T) {
+	tests := []struct {
+		name    string
+		rank    int
+		wantErr error
+	}{
+		{name: "viewer", rank: 1},
+		{name: "owner", rank: 3},
+		{name: "not visible", rank: 0, wantErr: realtime.ErrProjectNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockRealtimeRepository{
+				ProjectRankMock: func(projectID, userID int) (int, error) {
+					return tt.rank, nil
+				},
+			}
+			service := realtime.NewService(repo, &MockTaskService{}, mockLogger())
+
+			err := service.Subscribe(context.Background(), 42, 5)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestService_CreateTask(t *testing.T) {
+	tests := []struct {
+		name        string
+		projectID   *int
+		projectErr  error
+		wantErr     error
+		wantDeleted bool
+	}{
+		{name: "without project"},
+		{name: "into project", projectID: intPtr(5)},
+		{name: "project forbidden", projectID: intPtr(5), projectErr: task.ErrProjectNotFound, wantErr: task.ErrProjectNotFound, wantDeleted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deleted := false
+			tasks := &MockTaskService{
+				CreateMock: func(userID int, title, desc string) (int, error) {
+					return 10, nil
+				},
+				SetProjectMock: func(userID, taskID int, projectID *int) error {
+					if taskID != 10 || *projectID != 5 {
+						t.Errorf("unexpected SetProject(%d, %v)", taskID, *projectID)
+					}
+					return tt.projectErr
+				},
+				DeleteMock: func(userID, taskID int) error {
+					deleted = true
+					return nil
+				},
+			}
+			service := realtime.NewService(&MockRealtimeRepository{}, tasks, mockLogger())
+
+			taskID, err := service.CreateTask(context.Background(), 42, &realtime.CreateTaskRequest{
+				ProjectID: tt.projectID,
+				Title:     "Buy milk",
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && taskID != 10 {
+				t.Errorf("expected task 10, got %d", taskID)
+			}
+			if deleted != tt.wantDeleted {
+				t.Errorf("unexpected deleted=%v", deleted)
+			}
+		})
+	}
+}
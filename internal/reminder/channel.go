@@ -0,0 +1,153 @@
+package reminder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/melnik-dev/go_todo_jwt/internal/notification"
+	"github.com/melnik-dev/go_todo_jwt/pkg/mailer"
+)
+
+const (
+	ChannelInApp   = "in_app"
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+)
+
+// Channel доставляет напоминание одному получателю. ErrSkipped означает,
+// что канал у получателя не настроен и доставка не считается неудачной.
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, due *Due, userID int, settings *Settings) error
+}
+
+// NewChannels собирает каналы по именам из конфигурации; неизвестные имена пропускаются.
+// client отправляет запросы webhook-канала на адреса пользователей, см. safehttp.
+func NewChannels(names []string, notifier notification.Notifier, m mailer.Mailer, client *http.Client) []Channel {
+	channels := make([]Channel, 0, len(names))
+	for _, name := range names {
+		switch name {
+		case ChannelInApp:
+			channels = append(channels, NewInApp(notifier))
+		case ChannelEmail:
+			channels = append(channels, NewEmail(m))
+		case ChannelWebhook:
+			channels = append(channels, NewWebhook(client))
+		}
+	}
+	return channels
+}
+
+// InApp кладёт напоминание во входящие уведомления
+type InApp struct {
+	notifier notification.Notifier
+}
+
+func NewInApp(notifier notification.Notifier) *InApp {
+	return &InApp{notifier: notifier}
+}
+
+func (ch *InApp) Name() string {
+	return ChannelInApp
+}
+
+func (ch *InApp) Send(ctx context.Context, due *Due, userID int, settings *Settings) error {
+	return ch.notifier.Notify(ctx, &notification.Notification{
+		UserID: userID,
+		Type:   notification.TypeDueSoon,
+		TaskID: due.TaskID,
+		Text:   Text(due),
+	})
+}
+
+// Email отправляет напоминание на адрес из настроек пользователя
+type Email struct {
+	mailer mailer.Mailer
+}
+
+func NewEmail(m mailer.Mailer) *Email {
+	return &Email{mailer: m}
+}
+
+func (ch *Email) Name() string {
+	return ChannelEmail
+}
+
+func (ch *Email) Send(ctx context.Context, due *Due, userID int, settings *Settings) error {
+	if settings == nil || settings.Email == nil {
+		return ErrSkipped
+	}
+	body := fmt.Sprintf("%s.\r\nDue at: %s\r\n", Text(due), due.DueAt.UTC().Format(time.RFC1123))
+	return ch.mailer.Send(ctx, *settings.Email, "Reminder: "+due.Title, body)
+}
+
+// Webhook отправляет напоминание POST-запросом с JSON на URL из настроек пользователя
+type Webhook struct {
+	client *http.Client
+}
+
+func NewWebhook(client *http.Client) *Webhook {
+	return &Webhook{client: client}
+}
+
+func (ch *Webhook) Name() string {
+	return ChannelWebhook
+}
+
+// WebhookPayload - тело запроса webhook-канала
+type WebhookPayload struct {
+	Event   string    `json:"event"`
+	UserID  int       `json:"user_id"`
+	TaskID  int       `json:"task_id"`
+	Title   string    `json:"title"`
+	DueAt   time.Time `json:"due_at"`
+	Offset  string    `json:"offset"`
+	Message string    `json:"message"`
+}
+
+func (ch *Webhook) Send(ctx context.Context, due *Due, userID int, settings *Settings) error {
+	if settings == nil || settings.WebhookURL == nil {
+		return ErrSkipped
+	}
+
+	body, err := json.Marshal(WebhookPayload{
+		Event:   "task.reminder",
+		UserID:  userID,
+		TaskID:  due.TaskID,
+		Title:   due.Title,
+		DueAt:   due.DueAt,
+		Offset:  formatOffset(due.OffsetSeconds),
+		Message: Text(due),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *settings.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := ch.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Text - текст напоминания: срок уже наступил или наступит через смещение
+func Text(due *Due) string {
+	if due.OffsetSeconds == 0 {
+		return fmt.Sprintf("Task %q is due now", due.Title)
+	}
+	return fmt.Sprintf("Task %q is due in %s", due.Title, formatOffset(due.OffsetSeconds))
+}
@@ -0,0 +1,13 @@
+package reminder
+
+import "errors"
+
+var (
+	ErrTaskNotFound      = errors.New("task not found")
+	ErrForbidden         = errors.New("not enough permissions for task reminders")
+	ErrInvalidOffset     = errors.New("reminder offset must be a duration between 0s and 720h, e.g. 24h or 30m")
+	ErrInvalidEmail      = errors.New("invalid email address")
+	ErrInvalidWebhookURL = errors.New("webhook url must be an absolute http or https url")
+	// ErrSkipped - канал не настроен для получателя, доставка не требуется
+	ErrSkipped = errors.New("channel is not configured for recipient")
+)
@@ -0,0 +1,180 @@
+package reminder
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/pkg/logger"
+	"github.com/melnik-dev/go_todo_jwt/pkg/middleware"
+	"github.com/melnik-dev/go_todo_jwt/pkg/response"
+	"github.com/sirupsen/logrus"
+)
+
+type HandlerDeps struct {
+	ReminderService IService
+	*configs.Config
+	// Workspace выбирает рабочее пространство запроса
+	Workspace gin.HandlerFunc
+}
+
+type Handler struct {
+	ReminderService IService
+	*configs.Config
+}
+
+func NewHandler(r *gin.Engine, deps *HandlerDeps) {
+	handler := &Handler{
+		ReminderService: deps.ReminderService,
+		Config:          deps.Config,
+	}
+	reminders := r.Group("/task/:id/reminders")
+	reminders.Use(middleware.IsAuthed(handler.Config.JWT.Secret))
+	if deps.Workspace != nil {
+		reminders.Use(deps.Workspace)
+	}
+	reminders.GET("/", handler.Get)
+	reminders.PUT("/", handler.Set)
+
+	settings := r.Group("/reminders/settings")
+	settings.Use(middleware.IsAuthed(handler.Config.JWT.Secret))
+	settings.GET("/", handler.GetSettings)
+	settings.PUT("/", handler.SaveSettings)
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Get reminders")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri URIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind task ID")
+		response.BadRequest(c, "Invalid task ID")
+		return
+	}
+
+	reminders, err := h.ReminderService.Get(c.Request.Context(), userID, uri.TaskID)
+	if err != nil {
+		if !writeError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to Get reminders")
+			response.InternalServerError(c, "Failed to get reminders")
+		}
+		return
+	}
+
+	logHandle.Debug("Get reminders successfully")
+	response.Success(c, http.StatusOK, gin.H{"reminders": reminders})
+}
+
+func (h *Handler) Set(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Set reminders")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri URIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind task ID")
+		response.BadRequest(c, "Invalid task ID")
+		return
+	}
+
+	var req SetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind request")
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	reminders, err := h.ReminderService.Set(c.Request.Context(), userID, uri.TaskID, req.Offsets)
+	if err != nil {
+		if !writeError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to Set reminders")
+			response.InternalServerError(c, "Failed to set reminders")
+		}
+		return
+	}
+
+	logHandle.Debug("Set reminders successfully")
+	response.Success(c, http.StatusOK, gin.H{"reminders": reminders})
+}
+
+func (h *Handler) GetSettings(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to GetSettings")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	settings, err := h.ReminderService.GetSettings(c.Request.Context(), userID)
+	if err != nil {
+		logHandle.WithError(err).Error("Failed to GetSettings")
+		response.InternalServerError(c, "Failed to get reminder settings")
+		return
+	}
+
+	logHandle.Debug("GetSettings successfully")
+	response.Success(c, http.StatusOK, settings)
+}
+
+func (h *Handler) SaveSettings(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to SaveSettings")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req SettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind request")
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	settings, err := h.ReminderService.SaveSettings(c.Request.Context(), userID, &req)
+	if err != nil {
+		if !writeError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to SaveSettings")
+			response.InternalServerError(c, "Failed to save reminder settings")
+		}
+		return
+	}
+
+	logHandle.Debug("SaveSettings successfully")
+	response.Success(c, http.StatusOK, settings)
+}
+
+// writeError отвечает на ошибки проверки, доступа и отсутствия; false - ошибка не из их числа
+func writeError(c *gin.Context, logHandle *logrus.Entry, err error) bool {
+	switch {
+	case errors.Is(err, ErrInvalidOffset), errors.Is(err, ErrInvalidEmail), errors.Is(err, ErrInvalidWebhookURL):
+		logHandle.Warn(err.Error())
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, ErrTaskNotFound):
+		logHandle.Warn(err.Error())
+		response.NotFound(c, err.Error())
+	case errors.Is(err, ErrForbidden):
+		logHandle.Warn(err.Error())
+		response.Forbidden(c, err.Error())
+	default:
+		return false
+	}
+	return true
+}
+
+func handlerLogger(c *gin.Context) *logrus.Entry {
+	return logger.FromContext(c).WithField("layer", "Handler reminder layer")
+}
@@ -0,0 +1,122 @@
+package reminder_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/internal/reminder"
+	"github.com/sirupsen/logrus"
+)
+
+// MockReminderService встраивает reminder.IService, чтобы переопределять только нужные методы
+type MockReminderService struct {
+	reminder.IService
+	SetMock          func(userID, taskID int, offsets []string) ([]reminder.Reminder, error)
+	SaveSettingsMock func(userID int, req *reminder.SettingsRequest) (*reminder.Settings, error)
+}
+
+func (m *MockReminderService) Set(ctx context.Context, userID, taskID int, offsets []string) ([]reminder.Reminder, error) {
+	return m.SetMock(userID, taskID, offsets)
+}
+
+func (m *MockReminderService) SaveSettings(ctx context.Context, userID int, req *reminder.SettingsRequest) (*reminder.Settings, error) {
+	return m.SaveSettingsMock(userID, req)
+}
+
+func mockGin() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		c.Set("logger", logrus.NewEntry(logger))
+		c.Set("user_id", 42)
+		c.Next()
+	})
+	return r
+}
+
+func TestHandler_Set(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		body     string
+		err      error
+		wantCode int
+	}{
+		{name: "success", path: "/task/1/reminders/", body: `{"offsets":["24h","0s"]}`, wantCode: http.StatusOK},
+		{name: "too many", path: "/task/1/reminders/", body: `{"offsets":["1h","2h","3h","4h","5h","6h"]}`, wantCode: http.StatusBadRequest},
+		{name: "invalid offset", path: "/task/1/reminders/", body: `{"offsets":["soon"]}`, err: reminder.ErrInvalidOffset, wantCode: http.StatusBadRequest},
+		{name: "viewer", path: "/task/1/reminders/", body: `{"offsets":["1h"]}`, err: reminder.ErrForbidden, wantCode: http.StatusForbidden},
+		{name: "not found", path: "/task/1/reminders/", body: `{"offsets":["1h"]}`, err: reminder.ErrTaskNotFound, wantCode: http.StatusNotFound},
+		{name: "invalid id", path: "/task/abc/reminders/", body: `{"offsets":["1h"]}`, wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &reminder.Handler{
+				ReminderService: &MockReminderService{
+					SetMock: func(userID, taskID int, offsets []string) ([]reminder.Reminder, error) {
+						if tt.err != nil {
+							return nil, tt.err
+						}
+						return []reminder.Reminder{{TaskID: taskID, OffsetSeconds: 86400, Offset: "24h"}}, nil
+					},
+				},
+			}
+
+			r := mockGin()
+			r.PUT("/task/:id/reminders/", handler.Set)
+			req := httptest.NewRequest(http.MethodPut, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestHandler_SaveSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{name: "success", wantCode: http.StatusOK},
+		{name: "invalid webhook", err: reminder.ErrInvalidWebhookURL, wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &reminder.Handler{
+				ReminderService: &MockReminderService{
+					SaveSettingsMock: func(userID int, req *reminder.SettingsRequest) (*reminder.Settings, error) {
+						if tt.err != nil {
+							return nil, tt.err
+						}
+						return &reminder.Settings{UserID: userID, WebhookURL: req.WebhookURL}, nil
+					},
+				},
+			}
+
+			r := mockGin()
+			r.PUT("/reminders/settings/", handler.SaveSettings)
+			req := httptest.NewRequest(http.MethodPut, "/reminders/settings/", strings.NewReader(`{"webhook_url":"https://example.com/hook"}`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, w.Code)
+			}
+		})
+	}
+}
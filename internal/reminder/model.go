@@ -0,0 +1,41 @@
+package reminder
+
+import "time"
+
+// Reminder - напоминание о сроке задачи за OffsetSeconds до due_at.
+// RemindAt и Sent вычисляются по текущему сроку задачи; без срока RemindAt пуст.
+type Reminder struct {
+	ID            int        `db:"id" json:"id"`
+	TaskID        int        `db:"task_id" json:"task_id"`
+	OffsetSeconds int        `db:"offset_seconds" json:"offset_seconds"`
+	Offset        string     `db:"-" json:"offset"`
+	RemindAt      *time.Time `db:"remind_at" json:"remind_at"`
+	Sent          bool       `db:"sent" json:"sent"`
+}
+
+// Settings - куда, кроме входящих, доставлять напоминания пользователю
+type Settings struct {
+	UserID     int     `db:"user_id" json:"-"`
+	Email      *string `db:"email" json:"email"`
+	WebhookURL *string `db:"webhook_url" json:"webhook_url"`
+}
+
+// Due - наступившее напоминание, которое обрабатывает Worker
+type Due struct {
+	ID            int       `db:"id"`
+	TaskID        int       `db:"task_id"`
+	OffsetSeconds int       `db:"offset_seconds"`
+	Title         string    `db:"title"`
+	DueAt         time.Time `db:"due_at"`
+	OwnerID       int       `db:"user_id"`
+	AssigneeID    *int      `db:"assignee_id"`
+}
+
+// Recipients - владелец и исполнитель задачи без повторов
+func (d *Due) Recipients() []int {
+	recipients := []int{d.OwnerID}
+	if d.AssigneeID != nil && *d.AssigneeID != d.OwnerID {
+		recipients = append(recipients, *d.AssigneeID)
+	}
+	return recipients
+}
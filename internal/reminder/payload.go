@@ -0,0 +1,16 @@
+package reminder
+
+type URIParam struct {
+	TaskID int `uri:"id" binding:"required,min=1"`
+}
+
+// SetRequest заменяет напоминания задачи; смещения в формате Go duration: "24h", "30m", "0s"
+type SetRequest struct {
+	Offsets []string `json:"offsets" binding:"max=5,dive,required"`
+}
+
+// SettingsRequest - пустая строка или null отключают канал
+type SettingsRequest struct {
+	Email      *string `json:"email" binding:"omitempty,max=255"`
+	WebhookURL *string `json:"webhook_url" binding:"omitempty,max=2000"`
+}
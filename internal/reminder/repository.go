@@ -0,0 +1,207 @@
+package reminder
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/sirupsen/logrus"
+)
+
+// Rank, GetByTask и Replace работают в рабочем пространстве из ctx через db.Tenant.
+// Настройки принадлежат пользователю, а ProcessDue обходит все рабочие пространства.
+type IRepository interface {
+	Rank(ctx context.Context, taskID, userID int) (int, error)
+	GetByTask(ctx context.Context, taskID int) ([]Reminder, error)
+	Replace(ctx context.Context, taskID int, offsets []int) error
+	GetSettings(ctx context.Context, userID int) (*Settings, error)
+	SaveSettings(ctx context.Context, settings *Settings) error
+	ProcessDue(ctx context.Context, limit int, grace time.Duration, deliver func(due *Due) bool) (int, error)
+}
+
+type Repository struct {
+	db     *db.Db
+	logger *logrus.Logger
+}
+
+func NewRepository(db *db.Db, logger *logrus.Logger) *Repository {
+	return &Repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Rank возвращает лучшую роль пользователя в задаче: 0 - задача не видна, 3 - владелец
+func (r *Repository) Rank(ctx context.Context, taskID, userID int) (int, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"task_id": taskID,
+		"user_id": userID,
+	})
+	logRepo.Debug("Attempting to get Rank")
+
+	var rank int
+	query := `SELECT COALESCE(MAX(rank), 0) FROM task_access WHERE task_id = $1 AND user_id = $2`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		return tx.GetContext(ctx, &rank, query, taskID, userID)
+	})
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to get Rank database")
+		return 0, err
+	}
+
+	logRepo.WithField("rank", rank).Debug("Rank database successfully")
+	return rank, nil
+}
+
+// GetByTask возвращает напоминания задачи от дальних к ближним
+func (r *Repository) GetByTask(ctx context.Context, taskID int) ([]Reminder, error) {
+	logRepo := repositoryLogger(r.logger).WithField("task_id", taskID)
+	logRepo.Debug("Attempting to GetByTask reminders")
+
+	reminders := make([]Reminder, 0)
+	query := `SELECT r.id, r.task_id, r.offset_seconds,
+					t.due_at - r.offset_seconds * INTERVAL '1 second' AS remind_at,
+					r.sent_for IS NOT NULL AND r.sent_for = t.due_at AS sent
+				FROM task_reminders r
+				JOIN tasks t ON t.id = r.task_id
+				WHERE r.task_id = $1
+				ORDER BY r.offset_seconds DESC`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		return tx.SelectContext(ctx, &reminders, query, taskID)
+	})
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to GetByTask reminders database")
+		return nil, err
+	}
+
+	logRepo.Debug("GetByTask reminders database successfully")
+	return reminders, nil
+}
+
+// Replace оставляет у задачи только напоминания с offsets; у сохранившихся
+// смещений отметка об отправке не сбрасывается
+func (r *Repository) Replace(ctx context.Context, taskID int, offsets []int) error {
+	logRepo := repositoryLogger(r.logger).WithField("task_id", taskID)
+	logRepo.Debug("Attempting to Replace reminders")
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		query := `DELETE FROM task_reminders WHERE task_id = $1 AND NOT offset_seconds = ANY($2)`
+		if _, err := tx.ExecContext(ctx, query, taskID, pq.Array(offsets)); err != nil {
+			return err
+		}
+
+		query = `INSERT INTO task_reminders (task_id, offset_seconds)
+				SELECT $1, UNNEST($2::INTEGER[])
+				ON CONFLICT (task_id, offset_seconds) DO NOTHING`
+		_, err := tx.ExecContext(ctx, query, taskID, pq.Array(offsets))
+		return err
+	})
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to Replace reminders database")
+		return err
+	}
+
+	logRepo.Debug("Replace reminders database successfully")
+	return nil
+}
+
+// GetSettings возвращает пустые настройки, если пользователь их не задавал
+func (r *Repository) GetSettings(ctx context.Context, userID int) (*Settings, error) {
+	logRepo := repositoryLogger(r.logger).WithField("user_id", userID)
+	logRepo.Debug("Attempting to GetSettings")
+
+	var settings Settings
+	query := `SELECT user_id, email, webhook_url FROM reminder_settings WHERE user_id = $1`
+	if err := r.db.GetContext(ctx, &settings, query, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logRepo.Debug("Reminder settings are not set")
+			return &Settings{UserID: userID}, nil
+		}
+		logRepo.WithError(err).Error("Failed to GetSettings database")
+		return nil, err
+	}
+
+	logRepo.Debug("GetSettings database successfully")
+	return &settings, nil
+}
+
+func (r *Repository) SaveSettings(ctx context.Context, settings *Settings) error {
+	logRepo := repositoryLogger(r.logger).WithField("user_id", settings.UserID)
+	logRepo.Debug("Attempting to SaveSettings")
+
+	query := `INSERT INTO reminder_settings (user_id, email, webhook_url) VALUES ($1, $2, $3)
+				ON CONFLICT (user_id) DO UPDATE SET email = EXCLUDED.email, webhook_url = EXCLUDED.webhook_url`
+	if _, err := r.db.ExecContext(ctx, query, settings.UserID, settings.Email, settings.WebhookURL); err != nil {
+		logRepo.WithError(err).Error("Failed to SaveSettings database")
+		return err
+	}
+
+	logRepo.Debug("SaveSettings database successfully")
+	return nil
+}
+
+// ProcessDue блокирует до limit наступивших напоминаний незавершённых задач и передаёт
+// их deliver. Напоминание отмечается отправленным, если deliver вернул true;
+// возвращается число отмеченных.
+// SKIP LOCKED не даёт нескольким экземплярам приложения отправить одно напоминание дважды.
+// Отмена ctx останавливает обход, но отметки уже отправленных фиксируются:
+// транзакция не зависит от ctx, иначе её откат отправил бы их повторно.
+func (r *Repository) ProcessDue(ctx context.Context, limit int, grace time.Duration, deliver func(due *Due) bool) (int, error) {
+	logRepo := repositoryLogger(r.logger)
+	logRepo.Debug("Attempting to ProcessDue reminders")
+
+	// повтор транзакции отправил бы уже доставленные напоминания ещё раз
+	txManager := db.NewTxManager(r.db)
+	txManager.MaxRetries = 0
+
+	var due []Due
+	sent := 0
+	err := txManager.WithinTx(context.WithoutCancel(ctx), func(txCtx context.Context) error {
+		query := `SELECT r.id, r.task_id, r.offset_seconds, t.title, t.due_at, t.user_id, t.assignee_id
+				FROM task_reminders r
+				JOIN tasks t ON t.id = r.task_id
+				WHERE t.due_at IS NOT NULL AND NOT t.completed
+					AND r.sent_for IS DISTINCT FROM t.due_at
+					AND t.due_at - r.offset_seconds * INTERVAL '1 second' <= NOW()
+					AND t.due_at - r.offset_seconds * INTERVAL '1 second' > NOW() - $2 * INTERVAL '1 second'
+				ORDER BY t.due_at - r.offset_seconds * INTERVAL '1 second'
+				LIMIT $1
+				FOR UPDATE OF r SKIP LOCKED`
+		if err := r.db.SelectContext(txCtx, &due, query, limit, int64(grace/time.Second)); err != nil {
+			logRepo.WithError(err).Error("Failed to select due reminders")
+			return err
+		}
+
+		for i := range due {
+			if ctx.Err() != nil {
+				break
+			}
+			if !deliver(&due[i]) {
+				continue
+			}
+			query = `UPDATE task_reminders SET sent_for = $1 WHERE id = $2`
+			if _, err := r.db.ExecContext(txCtx, query, due[i].DueAt, due[i].ID); err != nil {
+				logRepo.WithError(err).Error("Failed to mark reminder as sent")
+				return err
+			}
+			sent++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	logRepo.WithFields(logrus.Fields{"due": len(due), "sent": sent}).Debug("ProcessDue reminders successfully")
+	return sent, nil
+}
+
+func repositoryLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Repository reminder layer")
+}
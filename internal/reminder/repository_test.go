@@ -0,0 +1,194 @@
+package reminder_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/melnik-dev/go_todo_jwt/internal/reminder"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+)
+
+func mockDB() (*reminder.Repository, sqlmock.Sqlmock, error) {
+	mockDb, mock, err := sqlmock.New()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pgDB := sqlx.NewDb(mockDb, "sqlMock")
+
+	repo := reminder.NewRepository(&db.Db{
+		DB: pgDB,
+	}, mockLogger())
+
+	return repo, mock, err
+}
+
+var ctx = db.WithWorkspace(context.Background(), 1)
+
+func expectTenant(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT set_config('role', $1, true), set_config('app.workspace_id', $2, true)`)).
+		WithArgs(db.TenantRole, "1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestReminderRepository_Replace_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offsets := []int{86400, 0}
+	expectTenant(mock)
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM task_reminders WHERE task_id = $1 AND NOT offset_seconds = ANY($2)`)).
+		WithArgs(1, pq.Array(offsets)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO task_reminders \(task_id, offset_seconds\)`).
+		WithArgs(1, pq.Array(offsets)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err = repo.Replace(ctx, 1, offsets); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReminderRepository_GetSettings_Empty(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT user_id, email, webhook_url FROM reminder_settings WHERE user_id = $1`)).
+		WithArgs(42).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "email", "webhook_url"}))
+
+	settings, err := repo.GetSettings(context.Background(), 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.UserID != 42 || settings.Email != nil || settings.WebhookURL != nil {
+		t.Errorf("unexpected settings %+v", settings)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReminderRepository_ProcessDue_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dueAt := time.Now().Add(time.Hour)
+	columns := []string{"id", "task_id", "offset_seconds", "title", "due_at", "user_id", "assignee_id"}
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FOR UPDATE OF r SKIP LOCKED`).
+		WithArgs(100, int64(3600)).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, 10, 3600, "Report", dueAt, 42, 7).
+			AddRow(2, 11, 0, "Call", dueAt, 42, nil))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE task_reminders SET sent_for = $1 WHERE id = $2`)).
+		WithArgs(dueAt, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	var seen []int
+	sent, err := repo.ProcessDue(context.Background(), 100, time.Hour, func(due *reminder.Due) bool {
+		seen = append(seen, due.ID)
+		// вторая доставка не удалась - напоминание остаётся неотправленным
+		return due.ID == 1
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 1 || len(seen) != 2 {
+		t.Errorf("unexpected sent=%d seen=%v", sent, seen)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReminderRepository_ProcessDue_Canceled(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dueAt := time.Now().Add(time.Hour)
+	columns := []string{"id", "task_id", "offset_seconds", "title", "due_at", "user_id", "assignee_id"}
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FOR UPDATE OF r SKIP LOCKED`).
+		WithArgs(100, int64(3600)).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, 10, 3600, "Report", dueAt, 42, 7).
+			AddRow(2, 11, 0, "Call", dueAt, 42, nil))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE task_reminders SET sent_for = $1 WHERE id = $2`)).
+		WithArgs(dueAt, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// остановка во время первой доставки: она отмечается, вторая не начинается
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var seen []int
+	sent, err := repo.ProcessDue(ctx, 100, time.Hour, func(due *reminder.Due) bool {
+		seen = append(seen, due.ID)
+		cancel()
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 1 || len(seen) != 1 {
+		t.Errorf("unexpected sent=%d seen=%v", sent, seen)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReminderRepository_ProcessDue_WithinTx(t *testing.T) {
+	mockDb, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := &db.Db{DB: sqlx.NewDb(mockDb, "sqlMock")}
+	repo := reminder.NewRepository(database, mockLogger())
+
+	columns := []string{"id", "task_id", "offset_seconds", "title", "due_at", "user_id", "assignee_id"}
+	mock.ExpectBegin()
+	mock.ExpectExec(`SAVEPOINT sp_1`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`FOR UPDATE OF r SKIP LOCKED`).
+		WithArgs(100, int64(3600)).
+		WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectExec(`RELEASE SAVEPOINT sp_1`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	// внешняя транзакция не открывает вторую, а ставит точку сохранения
+	err = db.NewTxManager(database).WithinTx(context.Background(), func(ctx context.Context) error {
+		_, err := repo.ProcessDue(ctx, 100, time.Hour, func(due *reminder.Due) bool { return true })
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,210 @@
+package reminder
+
+import (
+	"context"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// rankEditor - минимальный ранг в task_access для изменения напоминаний
+	rankEditor = 2
+	// maxOffset - напоминание нельзя поставить раньше, чем за 30 дней до срока
+	maxOffset = 720 * time.Hour
+)
+
+type IService interface {
+	Get(ctx context.Context, userID, taskID int) ([]Reminder, error)
+	Set(ctx context.Context, userID, taskID int, offsets []string) ([]Reminder, error)
+	GetSettings(ctx context.Context, userID int) (*Settings, error)
+	SaveSettings(ctx context.Context, userID int, req *SettingsRequest) (*Settings, error)
+}
+
+type Service struct {
+	reminderRepo IRepository
+	logger       *logrus.Logger
+}
+
+func NewService(reminderRepo IRepository, logger *logrus.Logger) *Service {
+	return &Service{
+		reminderRepo: reminderRepo,
+		logger:       logger,
+	}
+}
+
+// Get возвращает напоминания задачи; смотреть может любой, кто видит задачу
+func (s *Service) Get(ctx context.Context, userID, taskID int) ([]Reminder, error) {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id": userID,
+		"task_id": taskID,
+	})
+	logServ.Debug("Attempting to Get reminders")
+
+	if err := s.require(ctx, userID, taskID, 1); err != nil {
+		logServ.WithError(err).Warn("Failed to Get reminders")
+		return nil, err
+	}
+
+	reminders, err := s.reminderRepo.GetByTask(ctx, taskID)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to GetByTask reminders")
+		return nil, err
+	}
+
+	logServ.Debug("Get reminders successfully")
+	return withOffsets(reminders), nil
+}
+
+// Set заменяет напоминания задачи; менять их могут редактор и владелец
+func (s *Service) Set(ctx context.Context, userID, taskID int, offsets []string) ([]Reminder, error) {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id": userID,
+		"task_id": taskID,
+	})
+	logServ.Debug("Attempting to Set reminders")
+
+	seconds, err := parseOffsets(offsets)
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to parse offsets")
+		return nil, err
+	}
+	if err = s.require(ctx, userID, taskID, rankEditor); err != nil {
+		logServ.WithError(err).Warn("Failed to Set reminders")
+		return nil, err
+	}
+
+	if err = s.reminderRepo.Replace(ctx, taskID, seconds); err != nil {
+		logServ.WithError(err).Error("Failed to Replace reminders")
+		return nil, err
+	}
+	reminders, err := s.reminderRepo.GetByTask(ctx, taskID)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to GetByTask reminders")
+		return nil, err
+	}
+
+	logServ.Debug("Set reminders successfully")
+	return withOffsets(reminders), nil
+}
+
+func (s *Service) GetSettings(ctx context.Context, userID int) (*Settings, error) {
+	logServ := serviceLogger(s.logger).WithField("user_id", userID)
+	logServ.Debug("Attempting to GetSettings")
+
+	settings, err := s.reminderRepo.GetSettings(ctx, userID)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to GetSettings")
+		return nil, err
+	}
+
+	logServ.Debug("GetSettings successfully")
+	return settings, nil
+}
+
+// SaveSettings сохраняет адреса доставки; пустое значение отключает канал
+func (s *Service) SaveSettings(ctx context.Context, userID int, req *SettingsRequest) (*Settings, error) {
+	logServ := serviceLogger(s.logger).WithField("user_id", userID)
+	logServ.Debug("Attempting to SaveSettings")
+
+	settings := &Settings{
+		UserID:     userID,
+		Email:      normalize(req.Email),
+		WebhookURL: normalize(req.WebhookURL),
+	}
+	if settings.Email != nil {
+		addr, err := mail.ParseAddress(*settings.Email)
+		if err != nil || addr.Name != "" {
+			logServ.WithError(err).Warn(ErrInvalidEmail.Error())
+			return nil, ErrInvalidEmail
+		}
+	}
+	if settings.WebhookURL != nil {
+		u, err := url.Parse(*settings.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			logServ.WithError(err).Warn(ErrInvalidWebhookURL.Error())
+			return nil, ErrInvalidWebhookURL
+		}
+	}
+
+	if err := s.reminderRepo.SaveSettings(ctx, settings); err != nil {
+		logServ.WithError(err).Error("Failed to SaveSettings")
+		return nil, err
+	}
+
+	logServ.Debug("SaveSettings successfully")
+	return settings, nil
+}
+
+// require проверяет, что ранг пользователя в задаче не ниже min.
+// Невидимая задача - ErrTaskNotFound, недостаточный ранг - ErrForbidden.
+func (s *Service) require(ctx context.Context, userID, taskID, min int) error {
+	rank, err := s.reminderRepo.Rank(ctx, taskID, userID)
+	if err != nil {
+		return err
+	}
+	if rank == 0 {
+		return ErrTaskNotFound
+	}
+	if rank < min {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// parseOffsets переводит смещения в секунды без повторов, от дальних к ближним
+func parseOffsets(offsets []string) ([]int, error) {
+	seen := make(map[int]bool, len(offsets))
+	seconds := make([]int, 0, len(offsets))
+	for _, offset := range offsets {
+		d, err := time.ParseDuration(strings.TrimSpace(offset))
+		if err != nil || d < 0 || d > maxOffset || d%time.Second != 0 {
+			return nil, ErrInvalidOffset
+		}
+		sec := int(d / time.Second)
+		if !seen[sec] {
+			seen[sec] = true
+			seconds = append(seconds, sec)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(seconds)))
+	return seconds, nil
+}
+
+func withOffsets(reminders []Reminder) []Reminder {
+	for i := range reminders {
+		reminders[i].Offset = formatOffset(reminders[i].OffsetSeconds)
+	}
+	return reminders
+}
+
+// formatOffset печатает смещение без нулевых хвостов: 24h, 1h30m, 0s
+func formatOffset(seconds int) string {
+	s := (time.Duration(seconds) * time.Second).String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+func normalize(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	v := strings.TrimSpace(*value)
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+func serviceLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Service reminder layer")
+}
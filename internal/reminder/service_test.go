@@ -0,0 +1,158 @@
+package reminder_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/melnik-dev/go_todo_jwt/internal/reminder"
+	"github.com/sirupsen/logrus"
+)
+
+type MockReminderRepository struct {
+	RankMock         func(taskID, userID int) (int, error)
+	GetByTaskMock    func(taskID int) ([]reminder.Reminder, error)
+	ReplaceMock      func(taskID int, offsets []int) error
+	GetSettingsMock  func(userID int) (*reminder.Settings, error)
+	SaveSettingsMock func(settings *reminder.Settings) error
+	ProcessDueMock   func(limit int, grace time.Duration, deliver func(due *reminder.Due) bool) (int, error)
+}
+
+func (m *MockReminderRepository) Rank(ctx context.Context, taskID, userID int) (int, error) {
+	return m.RankMock(taskID, userID)
+}
+
+func (m *MockReminderRepository) GetByTask(ctx context.Context, taskID int) ([]reminder.Reminder, error) {
+	return m.GetByTaskMock(taskID)
+}
+
+func (m *MockReminderRepository) Replace(ctx context.Context, taskID int, offsets []int) error {
+	return m.ReplaceMock(taskID, offsets)
+}
+
+func (m *MockReminderRepository) GetSettings(ctx context.Context, userID int) (*reminder.Settings, error) {
+	return m.GetSettingsMock(userID)
+}
+
+func (m *MockReminderRepository) SaveSettings(ctx context.Context, settings *reminder.Settings) error {
+	return m.SaveSettingsMock(settings)
+}
+
+func (m *MockReminderRepository) ProcessDue(ctx context.Context, limit int, grace time.Duration, deliver func(due *reminder.Due) bool) (int, error) {
+	return m.ProcessDueMock(limit, grace, deliver)
+}
+
+func mockLogger() *logrus.Logger {
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	return l
+}
+
+func strPtr(v string) *string {
+	return &v
+}
+
+func TestService_Set(t *testing.T) {
+	tests := []struct {
+		name        string
+		rank        int
+		offsets     []string
+		wantErr     error
+		wantSeconds []int
+	}{
+		{name: "sorted without duplicates", rank: 2, offsets: []string{"0s", "24h", "1h30m", "1440m"}, wantSeconds: []int{86400, 5400, 0}},
+		{name: "clear", rank: 3, offsets: []string{}, wantSeconds: []int{}},
+		{name: "invalid format", rank: 2, offsets: []string{"1 day"}, wantErr: reminder.ErrInvalidOffset},
+		{name: "negative", rank: 2, offsets: []string{"-1h"}, wantErr: reminder.ErrInvalidOffset},
+		{name: "too far", rank: 2, offsets: []string{"721h"}, wantErr: reminder.ErrInvalidOffset},
+		{name: "viewer", rank: 1, offsets: []string{"1h"}, wantErr: reminder.ErrForbidden},
+		{name: "task not visible", rank: 0, offsets: []string{"1h"}, wantErr: reminder.ErrTaskNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var replaced []int
+			repo := &MockReminderRepository{
+				RankMock: func(taskID, userID int) (int, error) {
+					return tt.rank, nil
+				},
+				ReplaceMock: func(taskID int, offsets []int) error {
+					replaced = offsets
+					return nil
+				},
+				GetByTaskMock: func(taskID int) ([]reminder.Reminder, error) {
+					reminders := make([]reminder.Reminder, 0, len(replaced))
+					for _, sec := range replaced {
+						reminders = append(reminders, reminder.Reminder{TaskID: taskID, OffsetSeconds: sec})
+					}
+					return reminders, nil
+				},
+			}
+			service := reminder.NewService(repo, mockLogger())
+
+			reminders, err := service.Set(context.Background(), 42, 1, tt.offsets)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				if replaced != nil {
+					t.Error("Replace must not be called")
+				}
+				return
+			}
+			if !reflect.DeepEqual(replaced, tt.wantSeconds) {
+				t.Errorf("expected offsets %v, got %v", tt.wantSeconds, replaced)
+			}
+			if len(tt.wantSeconds) == 3 {
+				got := []string{reminders[0].Offset, reminders[1].Offset, reminders[2].Offset}
+				if !reflect.DeepEqual(got, []string{"24h", "1h30m", "0s"}) {
+					t.Errorf("unexpected formatted offsets %v", got)
+				}
+			}
+		})
+	}
+}
+
+func TestService_SaveSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     reminder.SettingsRequest
+		wantErr error
+		want    reminder.Settings
+	}{
+		{
+			name: "success",
+			req:  reminder.SettingsRequest{Email: strPtr(" alice@example.com "), WebhookURL: strPtr("https://hooks.example.com/r")},
+			want: reminder.Settings{UserID: 42, Email: strPtr("alice@example.com"), WebhookURL: strPtr("https://hooks.example.com/r")},
+		},
+		{name: "empty disables", req: reminder.SettingsRequest{Email: strPtr("")}, want: reminder.Settings{UserID: 42}},
+		{name: "invalid email", req: reminder.SettingsRequest{Email: strPtr("alice")}, wantErr: reminder.ErrInvalidEmail},
+		{name: "email with name", req: reminder.SettingsRequest{Email: strPtr("Alice <alice@example.com>")}, wantErr: reminder.ErrInvalidEmail},
+		{name: "relative url", req: reminder.SettingsRequest{WebhookURL: strPtr("/hook")}, wantErr: reminder.ErrInvalidWebhookURL},
+		{name: "not http", req: reminder.SettingsRequest{WebhookURL: strPtr("ftp://example.com")}, wantErr: reminder.ErrInvalidWebhookURL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var saved *reminder.Settings
+			repo := &MockReminderRepository{
+				SaveSettingsMock: func(settings *reminder.Settings) error {
+					saved = settings
+					return nil
+				},
+			}
+			service := reminder.NewService(repo, mockLogger())
+
+			_, err := service.SaveSettings(context.Background(), 42, &tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && !reflect.DeepEqual(*saved, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, *saved)
+			}
+		})
+	}
+}
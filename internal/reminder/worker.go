@@ -0,0 +1,104 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/sirupsen/logrus"
+)
+
+// Worker периодически отправляет наступившие напоминания владельцу и исполнителю задачи
+type Worker struct {
+	reminderRepo IRepository
+	channels     []Channel
+	cfg          configs.ConfReminders
+	logger       *logrus.Logger
+}
+
+func NewWorker(reminderRepo IRepository, channels []Channel, cfg configs.ConfReminders, logger *logrus.Logger) *Worker {
+	return &Worker{
+		reminderRepo: reminderRepo,
+		channels:     channels,
+		cfg:          cfg,
+		logger:       logger,
+	}
+}
+
+// Run проверяет напоминания каждые Interval, пока не отменён ctx
+func (w *Worker) Run(ctx context.Context) {
+	logWork := workerLogger(w.logger)
+	logWork.Info("Reminder worker started")
+
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := w.Tick(ctx); err != nil && ctx.Err() == nil {
+			logWork.WithError(err).Error("Failed to process reminders")
+		}
+		select {
+		case <-ctx.Done():
+			logWork.Info("Reminder worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick обрабатывает наступившие напоминания пачками по Batch; возвращает число отправленных
+func (w *Worker) Tick(ctx context.Context) (int, error) {
+	total := 0
+	for ctx.Err() == nil {
+		n, err := w.reminderRepo.ProcessDue(ctx, w.cfg.Batch, w.cfg.Grace, func(due *Due) bool {
+			return w.deliver(ctx, due)
+		})
+		total += n
+		if err != nil || n < w.cfg.Batch {
+			return total, err
+		}
+	}
+	return total, ctx.Err()
+}
+
+// deliver отправляет напоминание всем получателям по всем каналам. Начатая
+// доставка не прерывается остановкой сервиса, а ProcessDue после отмены
+// ctx фиксирует отметки уже доставленных, чтобы они не ушли повторно. false - все попытки доставки не удались,
+// напоминание останется в очереди до следующего прохода.
+func (w *Worker) deliver(ctx context.Context, due *Due) bool {
+	ctx = context.WithoutCancel(ctx)
+	logWork := workerLogger(w.logger).WithFields(logrus.Fields{
+		"reminder_id": due.ID,
+		"task_id":     due.TaskID,
+	})
+
+	attempted, failed := 0, 0
+	for _, userID := range due.Recipients() {
+		settings, err := w.reminderRepo.GetSettings(ctx, userID)
+		if err != nil {
+			logWork.WithError(err).WithField("user_id", userID).Warn("Failed to GetSettings")
+			settings = &Settings{UserID: userID}
+		}
+		for _, channel := range w.channels {
+			err = channel.Send(ctx, due, userID, settings)
+			if errors.Is(err, ErrSkipped) {
+				continue
+			}
+			attempted++
+			if err != nil {
+				failed++
+				logWork.WithError(err).WithFields(logrus.Fields{
+					"user_id": userID,
+					"channel": channel.Name(),
+				}).Warn("Failed to deliver reminder")
+			}
+		}
+	}
+
+	logWork.WithFields(logrus.Fields{"attempted": attempted, "failed": failed}).Debug("Reminder delivered")
+	return attempted == 0 || failed < attempted
+}
+
+func workerLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Worker reminder layer")
+}
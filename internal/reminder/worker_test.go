@@ -0,0 +1,132 @@
+package reminder_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/internal/notification"
+	"github.com/melnik-dev/go_todo_jwt/internal/reminder"
+)
+
+type MockNotifier struct {
+	Sent []notification.Notification
+}
+
+func (m *MockNotifier) Notify(ctx context.Context, n *notification.Notification) error {
+	m.Sent = append(m.Sent, *n)
+	return nil
+}
+
+type MockMailer struct {
+	To  []string
+	Err error
+}
+
+func (m *MockMailer) Send(ctx context.Context, to, subject, body string) error {
+	m.To = append(m.To, to)
+	return m.Err
+}
+
+func TestWorker_Tick(t *testing.T) {
+	var hooks []reminder.WebhookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload reminder.WebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Error(err)
+		}
+		hooks = append(hooks, payload)
+	}))
+	defer srv.Close()
+
+	assignee := 7
+	due := []reminder.Due{
+		{ID: 1, TaskID: 10, OffsetSeconds: 3600, Title: "Report", DueAt: time.Now().Add(time.Hour), OwnerID: 42, AssigneeID: &assignee},
+		{ID: 2, TaskID: 11, Title: "Call", DueAt: time.Now(), OwnerID: 42, AssigneeID: intPtr(42)},
+	}
+	var delivered []bool
+	repo := &MockReminderRepository{
+		ProcessDueMock: func(limit int, grace time.Duration, deliver func(due *reminder.Due) bool) (int, error) {
+			sent := 0
+			for i := range due {
+				ok := deliver(&due[i])
+				delivered = append(delivered, ok)
+				if ok {
+					sent++
+				}
+			}
+			return sent, nil
+		},
+		GetSettingsMock: func(userID int) (*reminder.Settings, error) {
+			if userID == 42 {
+				return &reminder.Settings{UserID: userID, Email: strPtr("owner@example.com"), WebhookURL: strPtr(srv.URL)}, nil
+			}
+			return &reminder.Settings{UserID: userID}, nil
+		},
+	}
+	notifier := &MockNotifier{}
+	mailer := &MockMailer{}
+	channels := reminder.NewChannels([]string{"in_app", "email", "webhook"}, notifier, mailer, srv.Client())
+	worker := reminder.NewWorker(repo, channels, configs.ConfReminders{Batch: 100, Grace: time.Hour}, mockLogger())
+
+	sent, err := worker.Tick(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 2 {
+		t.Errorf("expected 2 sent, got %d", sent)
+	}
+	// владелец и исполнитель первой задачи, только владелец второй
+	if len(notifier.Sent) != 3 || notifier.Sent[0].Text != `Task "Report" is due in 1h` || notifier.Sent[2].Text != `Task "Call" is due now` {
+		t.Errorf("unexpected notifications %+v", notifier.Sent)
+	}
+	if len(mailer.To) != 2 || len(hooks) != 2 || hooks[0].Event != "task.reminder" || hooks[0].Offset != "1h" {
+		t.Errorf("unexpected deliveries: mail %v, hooks %+v", mailer.To, hooks)
+	}
+}
+
+func TestWorker_Deliver_Failures(t *testing.T) {
+	tests := []struct {
+		name     string
+		channels []string
+		mailErr  error
+		want     bool
+	}{
+		{name: "all attempts failed", channels: []string{"email"}, mailErr: errors.New("smtp down"), want: false},
+		{name: "one channel succeeded", channels: []string{"in_app", "email"}, mailErr: errors.New("smtp down"), want: true},
+		{name: "nothing configured", channels: []string{"webhook"}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got bool
+			repo := &MockReminderRepository{
+				ProcessDueMock: func(limit int, grace time.Duration, deliver func(due *reminder.Due) bool) (int, error) {
+					got = deliver(&reminder.Due{ID: 1, TaskID: 10, Title: "Report", OwnerID: 42})
+					return 0, nil
+				},
+				GetSettingsMock: func(userID int) (*reminder.Settings, error) {
+					return &reminder.Settings{UserID: userID, Email: strPtr("owner@example.com")}, nil
+				},
+			}
+			channels := reminder.NewChannels(tt.channels, &MockNotifier{}, &MockMailer{Err: tt.mailErr}, http.DefaultClient)
+			worker := reminder.NewWorker(repo, channels, configs.ConfReminders{Batch: 100}, mockLogger())
+
+			if _, err := worker.Tick(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("expected delivered=%v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
@@ -0,0 +1,166 @@
+package share_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/melnik-dev/go_todo_jwt/internal/notification"
+	"github.com/melnik-dev/go_todo_jwt/internal/share"
+	"github.com/melnik-dev/go_todo_jwt/internal/task"
+	"github.com/melnik-dev/go_todo_jwt/internal/user"
+	"github.com/melnik-dev/go_todo_jwt/internal/user/usertest"
+	"github.com/melnik-dev/go_todo_jwt/internal/workspace"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db/dbtest"
+)
+
+// Принятое приглашение должно пройти политики RLS: задача владельца лежит
+// в его пространстве, а приглашённый читает её из своего личного
+func TestSharedTask_Postgres(t *testing.T) {
+	database := dbtest.Postgres(t)
+	logger := dbtest.Logger()
+
+	users := user.NewRepository(database, logger)
+	tasks := task.NewRepository(database, logger)
+	workspaces := workspace.NewRepository(database, logger)
+	shares := share.NewService(share.NewRepository(database, logger), users, notification.NewLogNotifier(logger), logger)
+
+	personal := func(u *user.User) context.Context {
+		t.Helper()
+		workspaceID, err := workspaces.Personal(context.Background(), u.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return db.WithWorkspace(context.Background(), workspaceID)
+	}
+
+	owner := usertest.CreateUser(t, users)
+	grantee := usertest.CreateUser(t, users)
+	ownerCtx, granteeCtx := personal(owner), personal(grantee)
+
+	created, err := tasks.Create(ownerCtx, &task.Task{UserID: owner.ID, Title: "Shared report"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = tasks.GetById(granteeCtx, &task.Task{ID: created.ID, UserID: grantee.ID})
+	if !errors.Is(err, task.ErrTaskNotFound) {
+		t.Fatalf("task must be hidden before the invitation is accepted, got %v", err)
+	}
+
+	invitation, err := shares.Share(context.Background(), owner.ID, &share.CreateRequest{
+		ResourceType: share.ResourceTask,
+		ResourceID:   created.ID,
+		Username:     grantee.Name,
+		Role:         share.RoleEditor,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = shares.Accept(context.Background(), grantee.ID, invitation.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := tasks.GetById(granteeCtx, &task.Task{ID: created.ID, UserID: grantee.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Role != task.RoleEditor || got.SharedBy == nil || *got.SharedBy != owner.Name {
+		t.Errorf("unexpected role %q and shared_by %v", got.Role, got.SharedBy)
+	}
+
+	all, err := tasks.GetAll(granteeCtx, grantee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 || all[0].ID != created.ID {
+		t.Errorf("expected only the shared task in the list, got %+v", all)
+	}
+
+	err = tasks.Update(granteeCtx, &task.Task{ID: created.ID, UserID: grantee.ID, Title: "Edited by grantee"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = tasks.GetById(ownerCtx, &task.Task{ID: created.ID, UserID: owner.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Edited by grantee" {
+		t.Errorf("owner should see the edit, got title %q", got.Title)
+	}
+}
+
+// Исполнитель только отмечает выполнение: менять поля задачи и переназначать
+// её может владелец или редактор
+func TestAssignedTask_Postgres(t *testing.T) {
+	database := dbtest.Postgres(t)
+	logger := dbtest.Logger()
+
+	users := user.NewRepository(database, logger)
+	tasks := task.NewRepository(database, logger)
+	workspaces := workspace.NewRepository(database, logger)
+	shares := share.NewService(share.NewRepository(database, logger), users, notification.NewLogNotifier(logger), logger)
+
+	personal := func(u *user.User) context.Context {
+		t.Helper()
+		workspaceID, err := workspaces.Personal(context.Background(), u.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return db.WithWorkspace(context.Background(), workspaceID)
+	}
+
+	owner := usertest.CreateUser(t, users)
+	assignee := usertest.CreateUser(t, users)
+	ownerCtx, assigneeCtx := personal(owner), personal(assignee)
+
+	created, err := tasks.Create(ownerCtx, &task.Task{UserID: owner.ID, Title: "Report", Description: "draft"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	invitation, err := shares.Share(context.Background(), owner.ID, &share.CreateRequest{
+		ResourceType: share.ResourceTask,
+		ResourceID:   created.ID,
+		Username:     assignee.Name,
+		Role:         share.RoleViewer,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = shares.Accept(context.Background(), assignee.ID, invitation.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tasks.SetAssignee(ownerCtx, &task.Task{ID: created.ID, UserID: owner.ID, AssigneeID: &assignee.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := tasks.GetById(assigneeCtx, &task.Task{ID: created.ID, UserID: assignee.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Role != task.RoleAssignee || got.CanEdit() {
+		t.Errorf("expected role %q, got %q", task.RoleAssignee, got.Role)
+	}
+
+	err = tasks.Update(assigneeCtx, &task.Task{ID: created.ID, UserID: assignee.ID, Title: "Renamed", Description: "draft"})
+	if !errors.Is(err, task.ErrTaskForbidden) {
+		t.Errorf("expected %v on title change, got %v", task.ErrTaskForbidden, err)
+	}
+	_, err = tasks.SetAssignee(assigneeCtx, &task.Task{ID: created.ID, UserID: assignee.ID, AssigneeID: &owner.ID})
+	if !errors.Is(err, task.ErrTaskForbidden) {
+		t.Errorf("expected %v on reassignment, got %v", task.ErrTaskForbidden, err)
+	}
+
+	err = tasks.Update(assigneeCtx, &task.Task{ID: created.ID, UserID: assignee.ID, Title: "Report", Description: "draft", Completed: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = tasks.GetById(ownerCtx, &task.Task{ID: created.ID, UserID: owner.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Completed || got.AssigneeID == nil || *got.AssigneeID != assignee.ID {
+		t.Errorf("expected completed task still assigned to %d, got %+v", assignee.ID, got)
+	}
+}
@@ -0,0 +1,11 @@
+package share
+
+import "errors"
+
+var (
+	ErrShareNotFound    = errors.New("share not found")
+	ErrResourceNotFound = errors.New("shared resource not found")
+	ErrShareForbidden   = errors.New("only owners can share this resource")
+	ErrUserNotFound     = errors.New("user not found")
+	ErrShareWithSelf    = errors.New("cannot share with yourself")
+)
@@ -0,0 +1,221 @@
+package share
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/pkg/logger"
+	"github.com/melnik-dev/go_todo_jwt/pkg/middleware"
+	"github.com/melnik-dev/go_todo_jwt/pkg/response"
+	"github.com/sirupsen/logrus"
+)
+
+type HandlerDeps struct {
+	ShareService IService
+	*configs.Config
+}
+
+type Handler struct {
+	ShareService IService
+	*configs.Config
+}
+
+func NewHandler(r *gin.Engine, deps *HandlerDeps) {
+	handler := &Handler{
+		ShareService: deps.ShareService,
+		Config:       deps.Config,
+	}
+	share := r.Group("/share")
+	share.Use(middleware.IsAuthed(handler.Config.JWT.Secret))
+	share.POST("/", handler.Create)
+	share.GET("/", handler.GetByResource)
+	share.DELETE("/:id", handler.Revoke)
+	share.GET("/invitations", handler.GetInvitations)
+	share.POST("/invitations/:id/accept", handler.Accept)
+	share.POST("/invitations/:id/decline", handler.Decline)
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Create share")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind request")
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	share, err := h.ShareService.Share(c.Request.Context(), userID, &req)
+	if err != nil {
+		if errors.Is(err, ErrShareWithSelf) {
+			logHandle.Warn(ErrShareWithSelf.Error())
+			response.BadRequest(c, ErrShareWithSelf.Error())
+			return
+		}
+		if errors.Is(err, ErrUserNotFound) {
+			logHandle.Warn(ErrUserNotFound.Error())
+			response.NotFound(c, ErrUserNotFound.Error())
+			return
+		}
+		if !writeAccessError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to Share")
+			response.InternalServerError(c, "Failed to share resource")
+		}
+		return
+	}
+
+	logHandle.Debug("Create share successfully")
+	response.Success(c, http.StatusCreated, share)
+}
+
+func (h *Handler) GetByResource(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to GetByResource")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var query ResourceQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind query")
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	shares, err := h.ShareService.GetByResource(c.Request.Context(), userID, query.ResourceType, query.ResourceID)
+	if err != nil {
+		if !writeAccessError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to GetByResource")
+			response.InternalServerError(c, "Failed to get shares")
+		}
+		return
+	}
+
+	logHandle.Debug("GetByResource successfully")
+	response.Success(c, http.StatusOK, shares)
+}
+
+func (h *Handler) GetInvitations(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to GetInvitations")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	shares, err := h.ShareService.GetInvitations(c.Request.Context(), userID)
+	if err != nil {
+		logHandle.WithError(err).Error("Failed to GetInvitations")
+		response.InternalServerError(c, "Failed to get invitations")
+		return
+	}
+
+	logHandle.Debug("GetInvitations successfully")
+	response.Success(c, http.StatusOK, shares)
+}
+
+func (h *Handler) Accept(c *gin.Context) {
+	h.respond(c, h.ShareService.Accept, "Invitation accepted successfully")
+}
+
+func (h *Handler) Decline(c *gin.Context) {
+	h.respond(c, h.ShareService.Decline, "Invitation declined successfully")
+}
+
+func (h *Handler) Revoke(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Revoke share")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri URIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind share id")
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	err := h.ShareService.Revoke(c.Request.Context(), userID, uri.ID)
+	if err != nil {
+		if errors.Is(err, ErrShareNotFound) {
+			logHandle.Warn(ErrShareNotFound.Error())
+			response.NotFound(c, ErrShareNotFound.Error())
+			return
+		}
+		if !writeAccessError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to Revoke share")
+			response.InternalServerError(c, "Failed to revoke share")
+		}
+		return
+	}
+
+	logHandle.Debug("Revoke share successfully")
+	response.Success(c, http.StatusOK, gin.H{"message": "Share revoked successfully"})
+}
+
+func (h *Handler) respond(c *gin.Context, fn func(ctx context.Context, userID, shareID int) error, message string) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to respond to invitation")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri URIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind invitation id")
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	err := fn(c.Request.Context(), userID, uri.ID)
+	if err != nil {
+		if errors.Is(err, ErrShareNotFound) {
+			logHandle.Warn(ErrShareNotFound.Error())
+			response.NotFound(c, ErrShareNotFound.Error())
+			return
+		}
+		logHandle.WithError(err).Error("Failed to respond to invitation")
+		response.InternalServerError(c, "Failed to respond to invitation")
+		return
+	}
+
+	logHandle.Debug("Respond to invitation successfully")
+	response.Success(c, http.StatusOK, gin.H{"message": message})
+}
+
+// writeAccessError отвечает 404/403 на ошибки доступа к ресурсу и сообщает,
+// была ли ошибка обработана
+func writeAccessError(c *gin.Context, logHandle *logrus.Entry, err error) bool {
+	switch {
+	case errors.Is(err, ErrResourceNotFound):
+		logHandle.Warn(ErrResourceNotFound.Error())
+		response.NotFound(c, ErrResourceNotFound.Error())
+	case errors.Is(err, ErrShareForbidden):
+		logHandle.Warn(ErrShareForbidden.Error())
+		response.Forbidden(c, ErrShareForbidden.Error())
+	default:
+		return false
+	}
+	return true
+}
+
+func handlerLogger(c *gin.Context) *logrus.Entry {
+	return logger.FromContext(c).WithField("layer", "Handler share layer")
+}
@@ -0,0 +1,137 @@
+package share_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/internal/share"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type MockShareService struct {
+	ShareMock          func(ownerID int, req *share.CreateRequest) (*share.Share, error)
+	GetByResourceMock  func(userID int, resourceType string, resourceID int) ([]share.Share, error)
+	GetInvitationsMock func(userID int) ([]share.Share, error)
+	AcceptMock         func(userID, shareID int) error
+	DeclineMock        func(userID, shareID int) error
+	RevokeMock         func(userID, shareID int) error
+}
+
+func (m *MockShareService) Share(_ context.Context, ownerID int, req *share.CreateRequest) (*share.Share, error) {
+	return m.ShareMock(ownerID, req)
+}
+
+func (m *MockShareService) GetByResource(_ context.Context, userID int, resourceType string, resourceID int) ([]share.Share, error) {
+	return m.GetByResourceMock(userID, resourceType, resourceID)
+}
+
+func (m *MockShareService) GetInvitations(_ context.Context, userID int) ([]share.Share, error) {
+	return m.GetInvitationsMock(userID)
+}
+
+func (m *MockShareService) Accept(_ context.Context, userID, shareID int) error {
+	return m.AcceptMock(userID, shareID)
+}
+
+func (m *MockShareService) Decline(_ context.Context, userID, shareID int) error {
+	return m.DeclineMock(userID, shareID)
+}
+
+func (m *MockShareService) Revoke(_ context.Context, userID, shareID int) error {
+	return m.RevokeMock(userID, shareID)
+}
+
+func mockGin() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		c.Set("logger", logrus.NewEntry(logger))
+		c.Set("user_id", 42)
+		c.Next()
+	})
+	return r
+}
+
+func TestHandler_Create(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		err      error
+		wantCode int
+	}{
+		{name: "success", body: `{"resource_type":"task","resource_id":5,"username":"bob","role":"viewer"}`, wantCode: http.StatusCreated},
+		{name: "bad role", body: `{"resource_type":"task","resource_id":5,"username":"bob","role":"admin"}`, wantCode: http.StatusBadRequest},
+		{name: "forbidden", body: `{"resource_type":"task","resource_id":5,"username":"bob","role":"viewer"}`, err: share.ErrShareForbidden, wantCode: http.StatusForbidden},
+		{name: "no resource", body: `{"resource_type":"project","resource_id":5,"username":"bob","role":"viewer"}`, err: share.ErrResourceNotFound, wantCode: http.StatusNotFound},
+		{name: "no user", body: `{"resource_type":"task","resource_id":5,"username":"carol","role":"viewer"}`, err: share.ErrUserNotFound, wantCode: http.StatusNotFound},
+		{name: "self", body: `{"resource_type":"task","resource_id":5,"username":"alice","role":"viewer"}`, err: share.ErrShareWithSelf, wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &share.Handler{
+				ShareService: &MockShareService{
+					ShareMock: func(ownerID int, req *share.CreateRequest) (*share.Share, error) {
+						if tt.err != nil {
+							return nil, tt.err
+						}
+						return &share.Share{ID: 1, OwnerID: ownerID, Role: req.Role}, nil
+					},
+				},
+			}
+
+			r := mockGin()
+			r.POST("/share/", handler.Create)
+			req := httptest.NewRequest(http.MethodPost, "/share/", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestHandler_Accept(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{name: "success", wantCode: http.StatusOK},
+		{name: "not found", err: share.ErrShareNotFound, wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &share.Handler{
+				ShareService: &MockShareService{
+					AcceptMock: func(userID, shareID int) error {
+						if userID != 42 || shareID != 3 {
+							t.Errorf("unexpected accept(%d, %d)", userID, shareID)
+						}
+						return tt.err
+					},
+				},
+			}
+
+			r := mockGin()
+			r.POST("/share/invitations/:id/accept", handler.Accept)
+			req := httptest.NewRequest(http.MethodPost, "/share/invitations/3/accept", nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, w.Code)
+			}
+		})
+	}
+}
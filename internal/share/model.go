@@ -0,0 +1,35 @@
+package share
+
+import "time"
+
+const (
+	ResourceTask    = "task"
+	ResourceProject = "project"
+
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleOwner  = "owner"
+
+	StatusPending  = "pending"
+	StatusAccepted = "accepted"
+	StatusDeclined = "declined"
+)
+
+// rankOwner - ранг роли owner в представлениях task_access и project_access
+const rankOwner = 3
+
+type Share struct {
+	ID           int        `db:"id" json:"id"`
+	ResourceType string     `db:"resource_type" json:"resource_type"`
+	ResourceID   int        `db:"resource_id" json:"resource_id"`
+	OwnerID      int        `db:"owner_id" json:"owner_id"`
+	UserID       int        `db:"user_id" json:"user_id"`
+	Role         string     `db:"role" json:"role"`
+	Status       string     `db:"status" json:"status"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	RespondedAt  *time.Time `db:"responded_at" json:"responded_at,omitempty"`
+	// заполняются при выборке приглашений и списка доступа
+	SharedBy     string `db:"shared_by" json:"shared_by,omitempty"`
+	Username     string `db:"username" json:"username,omitempty"`
+	ResourceName string `db:"resource_name" json:"resource_name,omitempty"`
+}
@@ -0,0 +1,17 @@
+package share
+
+type URIParam struct {
+	ID int `uri:"id" binding:"required,min=1"`
+}
+
+type CreateRequest struct {
+	ResourceType string `json:"resource_type" binding:"required,oneof=task project"`
+	ResourceID   int    `json:"resource_id" binding:"required,min=1"`
+	Username     string `json:"username" binding:"required"`
+	Role         string `json:"role" binding:"required,oneof=viewer editor owner"`
+}
+
+type ResourceQuery struct {
+	ResourceType string `form:"resource_type" binding:"required,oneof=task project"`
+	ResourceID   int    `form:"resource_id" binding:"required,min=1"`
+}
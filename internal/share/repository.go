@@ -0,0 +1,226 @@
+package share
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/sirupsen/logrus"
+)
+
+// rankQueries - запрос лучшей роли пользователя для каждого типа ресурса
+var rankQueries = map[string]string{
+	ResourceTask:    `SELECT COALESCE(MAX(rank), 0) FROM task_access WHERE task_id = $1 AND user_id = $2`,
+	ResourceProject: `SELECT COALESCE(MAX(rank), 0) FROM project_access WHERE project_id = $1 AND user_id = $2`,
+}
+
+type IRepository interface {
+	Rank(ctx context.Context, resourceType string, resourceID, userID int) (int, error)
+	Save(ctx context.Context, share *Share) (*Share, error)
+	Get(ctx context.Context, id int) (*Share, error)
+	GetInvitations(ctx context.Context, userID int) ([]Share, error)
+	GetByResource(ctx context.Context, resourceType string, resourceID int) ([]Share, error)
+	Respond(ctx context.Context, id, userID int, status string) error
+	Delete(ctx context.Context, id int) error
+}
+
+type Repository struct {
+	db     *db.Db
+	logger *logrus.Logger
+}
+
+func NewRepository(db *db.Db, logger *logrus.Logger) *Repository {
+	return &Repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Rank возвращает ранг лучшей роли пользователя в ресурсе, 0 - доступа нет
+func (r *Repository) Rank(ctx context.Context, resourceType string, resourceID, userID int) (int, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id":       userID,
+		"resource_type": resourceType,
+		"resource_id":   resourceID,
+	})
+	logRepo.Debug("Attempting to Rank")
+
+	query, ok := rankQueries[resourceType]
+	if !ok {
+		return 0, ErrResourceNotFound
+	}
+
+	var rank int
+	if err := r.db.GetContext(ctx, &rank, query, resourceID, userID); err != nil {
+		logRepo.WithError(err).Error("Failed to Rank database")
+		return 0, err
+	}
+
+	logRepo.Debug("Rank database successfully")
+	return rank, nil
+}
+
+// Save создаёт приглашение. Повторное приглашение меняет роль; отклонённое
+// снова становится ожидающим, принятое остаётся принятым.
+func (r *Repository) Save(ctx context.Context, share *Share) (*Share, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"owner_id":      share.OwnerID,
+		"user_id":       share.UserID,
+		"resource_type": share.ResourceType,
+		"resource_id":   share.ResourceID,
+	})
+	logRepo.Debug("Attempting to Save share")
+
+	query := `INSERT INTO shares (resource_type, resource_id, owner_id, user_id, role)
+				VALUES ($1, $2, $3, $4, $5)
+				ON CONFLICT (resource_type, resource_id, user_id) DO UPDATE
+				SET role = EXCLUDED.role, owner_id = EXCLUDED.owner_id,
+					status = CASE WHEN shares.status = 'accepted' THEN 'accepted' ELSE 'pending' END,
+					responded_at = CASE WHEN shares.status = 'accepted' THEN shares.responded_at END
+				RETURNING id, status, created_at, responded_at`
+
+	row := r.db.QueryRowContext(ctx, query, share.ResourceType, share.ResourceID, share.OwnerID, share.UserID, share.Role)
+	if err := row.Scan(&share.ID, &share.Status, &share.CreatedAt, &share.RespondedAt); err != nil {
+		logRepo.WithError(err).Error("Failed to Save database")
+		return nil, err
+	}
+
+	logRepo.WithField("share_id", share.ID).Debug("Save database successfully")
+	return share, nil
+}
+
+func (r *Repository) Get(ctx context.Context, id int) (*Share, error) {
+	logRepo := repositoryLogger(r.logger).WithField("share_id", id)
+	logRepo.Debug("Attempting to Get share")
+
+	var share Share
+	query := `SELECT * FROM shares WHERE id = $1`
+
+	err := r.db.GetContext(ctx, &share, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logRepo.Warn(ErrShareNotFound.Error())
+			return nil, ErrShareNotFound
+		}
+		logRepo.WithError(err).Error("Failed to Get database")
+		return nil, err
+	}
+
+	logRepo.Debug("Get database successfully")
+	return &share, nil
+}
+
+// GetInvitations возвращает ожидающие ответа приглашения пользователя
+func (r *Repository) GetInvitations(ctx context.Context, userID int) ([]Share, error) {
+	logRepo := repositoryLogger(r.logger).WithField("user_id", userID)
+	logRepo.Debug("Attempting to GetInvitations")
+
+	var shares []Share
+	query := `SELECT s.*, u.username AS shared_by,
+				COALESCE(t.title, p.name, '') AS resource_name
+			FROM shares s
+			JOIN users u ON u.id = s.owner_id
+			LEFT JOIN tasks t ON s.resource_type = 'task' AND t.id = s.resource_id
+			LEFT JOIN projects p ON s.resource_type = 'project' AND p.id = s.resource_id
+			WHERE s.user_id = $1 AND s.status = 'pending'
+			ORDER BY s.id`
+
+	err := r.db.SelectContext(ctx, &shares, query, userID)
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to GetInvitations database")
+		return nil, err
+	}
+
+	logRepo.Debug("GetInvitations database successfully")
+	return shares, nil
+}
+
+// GetByResource возвращает все приглашения ресурса вместе с именами пользователей
+func (r *Repository) GetByResource(ctx context.Context, resourceType string, resourceID int) ([]Share, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"resource_type": resourceType,
+		"resource_id":   resourceID,
+	})
+	logRepo.Debug("Attempting to GetByResource")
+
+	var shares []Share
+	query := `SELECT s.*, o.username AS shared_by, u.username
+			FROM shares s
+			JOIN users o ON o.id = s.owner_id
+			JOIN users u ON u.id = s.user_id
+			WHERE s.resource_type = $1 AND s.resource_id = $2
+			ORDER BY s.id`
+
+	err := r.db.SelectContext(ctx, &shares, query, resourceType, resourceID)
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to GetByResource database")
+		return nil, err
+	}
+
+	logRepo.Debug("GetByResource database successfully")
+	return shares, nil
+}
+
+// Respond принимает или отклоняет ожидающее приглашение пользователя
+func (r *Repository) Respond(ctx context.Context, id, userID int, status string) error {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"share_id": id,
+		"user_id":  userID,
+		"status":   status,
+	})
+	logRepo.Debug("Attempting to Respond")
+
+	query := `UPDATE shares SET status = $1, responded_at = NOW()
+				WHERE id = $2 AND user_id = $3 AND status = 'pending'`
+
+	result, err := r.db.ExecContext(ctx, query, status, id, userID)
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to Respond database")
+		return err
+	}
+
+	row, err := result.RowsAffected()
+	if err != nil {
+		logRepo.WithError(err).Error("Failed rows affected by Respond database")
+		return err
+	}
+
+	if row == 0 {
+		logRepo.Warn(ErrShareNotFound.Error())
+		return ErrShareNotFound
+	}
+
+	logRepo.Debug("Respond database successfully")
+	return nil
+}
+
+func (r *Repository) Delete(ctx context.Context, id int) error {
+	logRepo := repositoryLogger(r.logger).WithField("share_id", id)
+	logRepo.Debug("Attempting to Delete share")
+
+	query := `DELETE FROM shares WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to Delete database")
+		return err
+	}
+
+	row, err := result.RowsAffected()
+	if err != nil {
+		logRepo.WithError(err).Error("Failed rows affected by Delete database")
+		return err
+	}
+
+	if row == 0 {
+		logRepo.Warn(ErrShareNotFound.Error())
+		return ErrShareNotFound
+	}
+
+	logRepo.Debug("Delete database successfully")
+	return nil
+}
+
+func repositoryLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Repository share layer")
+}
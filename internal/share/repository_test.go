@@ -0,0 +1,119 @@
+package share_test
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/melnik-dev/go_todo_jwt/internal/share"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func mockDB() (*share.Repository, sqlmock.Sqlmock, error) {
+	mockDb, mock, err := sqlmock.New()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pgDB := sqlx.NewDb(mockDb, "sqlMock")
+
+	repo := share.NewRepository(&db.Db{
+		DB: pgDB,
+	}, mockLogger())
+
+	return repo, mock, err
+}
+
+func TestShareRepository_Rank_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(MAX(rank), 0) FROM task_access WHERE task_id = $1 AND user_id = $2`)).
+		WithArgs(5, 42).
+		WillReturnRows(sqlmock.NewRows([]string{"rank"}).AddRow(3))
+
+	rank, err := repo.Rank(context.Background(), share.ResourceTask, 5, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rank != 3 {
+		t.Errorf("expected rank 3, got %d", rank)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShareRepository_Save_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery(`INSERT INTO shares .* ON CONFLICT \(resource_type, resource_id, user_id\) DO UPDATE`).
+		WithArgs("project", 1, 42, 7, "editor").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "created_at", "responded_at"}).
+			AddRow(3, "pending", time.Now(), nil))
+
+	s, err := repo.Save(context.Background(), &share.Share{ResourceType: "project", ResourceID: 1, OwnerID: 42, UserID: 7, Role: "editor"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID != 3 || s.Status != share.StatusPending {
+		t.Errorf("unexpected share %+v", s)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShareRepository_GetInvitations_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "resource_type", "resource_id", "owner_id", "user_id", "role", "status",
+		"created_at", "responded_at", "shared_by", "resource_name"}).
+		AddRow(3, "task", 5, 42, 7, "viewer", "pending", time.Now(), nil, "alice", "Buy milk")
+	mock.ExpectQuery(`WHERE s.user_id = \$1 AND s.status = 'pending'`).
+		WithArgs(7).
+		WillReturnRows(rows)
+
+	shares, err := repo.GetInvitations(context.Background(), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shares) != 1 || shares[0].SharedBy != "alice" || shares[0].ResourceName != "Buy milk" {
+		t.Errorf("unexpected invitations %+v", shares)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShareRepository_Respond_FailNotFound(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectExec(`UPDATE shares SET status = \$1, responded_at = NOW\(\)`).
+		WithArgs("accepted", 3, 7).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err = repo.Respond(context.Background(), 3, 7, share.StatusAccepted); err != share.ErrShareNotFound {
+		t.Fatalf("expected %v, got %v", share.ErrShareNotFound, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,217 @@
+package share
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/melnik-dev/go_todo_jwt/internal/notification"
+	"github.com/melnik-dev/go_todo_jwt/internal/user"
+	"github.com/sirupsen/logrus"
+)
+
+type IService interface {
+	Share(ctx context.Context, ownerID int, req *CreateRequest) (*Share, error)
+	GetByResource(ctx context.Context, userID int, resourceType string, resourceID int) ([]Share, error)
+	GetInvitations(ctx context.Context, userID int) ([]Share, error)
+	Accept(ctx context.Context, userID, shareID int) error
+	Decline(ctx context.Context, userID, shareID int) error
+	Revoke(ctx context.Context, userID, shareID int) error
+}
+
+type Service struct {
+	shareRepo IRepository
+	userRepo  user.IRepository
+	notifier  notification.Notifier
+	logger    *logrus.Logger
+}
+
+func NewService(shareRepo IRepository, userRepo user.IRepository, notifier notification.Notifier, logger *logrus.Logger) *Service {
+	return &Service{
+		shareRepo: shareRepo,
+		userRepo:  userRepo,
+		notifier:  notifier,
+		logger:    logger,
+	}
+}
+
+// Share приглашает пользователя к задаче или проекту. Делиться может только
+// пользователь с ролью owner, в том числе полученной через приглашение.
+func (s *Service) Share(ctx context.Context, ownerID int, req *CreateRequest) (*Share, error) {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id":       ownerID,
+		"resource_type": req.ResourceType,
+		"resource_id":   req.ResourceID,
+	})
+	logServ.Debug("Attempting to Share")
+
+	if err := s.requireOwner(ctx, ownerID, req.ResourceType, req.ResourceID); err != nil {
+		logServ.WithError(err).Warn("Failed to Share")
+		return nil, err
+	}
+
+	grantee, err := s.userRepo.Get(ctx, req.Username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logServ.Warn(ErrUserNotFound.Error())
+			return nil, ErrUserNotFound
+		}
+		logServ.WithError(err).Error("Failed to Get user")
+		return nil, err
+	}
+	if grantee.ID == ownerID {
+		logServ.Warn(ErrShareWithSelf.Error())
+		return nil, ErrShareWithSelf
+	}
+
+	share, err := s.shareRepo.Save(ctx, &Share{
+		ResourceType: req.ResourceType,
+		ResourceID:   req.ResourceID,
+		OwnerID:      ownerID,
+		UserID:       grantee.ID,
+		Role:         req.Role,
+	})
+	if err != nil {
+		logServ.WithError(err).Error("Failed to Save share")
+		return nil, err
+	}
+	share.Username = grantee.Name
+	s.notifyShared(ctx, logServ, share)
+
+	logServ.WithField("share_id", share.ID).Debug("Share successfully")
+	return share, nil
+}
+
+// GetByResource показывает владельцам ресурса, с кем он расшарен
+func (s *Service) GetByResource(ctx context.Context, userID int, resourceType string, resourceID int) ([]Share, error) {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id":       userID,
+		"resource_type": resourceType,
+		"resource_id":   resourceID,
+	})
+	logServ.Debug("Attempting to GetByResource")
+
+	if err := s.requireOwner(ctx, userID, resourceType, resourceID); err != nil {
+		logServ.WithError(err).Warn("Failed to GetByResource")
+		return nil, err
+	}
+
+	shares, err := s.shareRepo.GetByResource(ctx, resourceType, resourceID)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to GetByResource")
+		return nil, err
+	}
+
+	logServ.Debug("GetByResource successfully")
+	return shares, nil
+}
+
+func (s *Service) GetInvitations(ctx context.Context, userID int) ([]Share, error) {
+	logServ := serviceLogger(s.logger).WithField("user_id", userID)
+	logServ.Debug("Attempting to GetInvitations")
+
+	shares, err := s.shareRepo.GetInvitations(ctx, userID)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to GetInvitations")
+		return nil, err
+	}
+
+	logServ.Debug("GetInvitations successfully")
+	return shares, nil
+}
+
+func (s *Service) Accept(ctx context.Context, userID, shareID int) error {
+	return s.respond(ctx, userID, shareID, StatusAccepted)
+}
+
+func (s *Service) Decline(ctx context.Context, userID, shareID int) error {
+	return s.respond(ctx, userID, shareID, StatusDeclined)
+}
+
+// Revoke удаляет приглашение. Получатель может отказаться от доступа сам,
+// остальным нужна роль owner в ресурсе.
+func (s *Service) Revoke(ctx context.Context, userID, shareID int) error {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id":  userID,
+		"share_id": shareID,
+	})
+	logServ.Debug("Attempting to Revoke")
+
+	share, err := s.shareRepo.Get(ctx, shareID)
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to Get share")
+		return err
+	}
+
+	if share.UserID != userID {
+		if err = s.requireOwner(ctx, userID, share.ResourceType, share.ResourceID); err != nil {
+			// чужие приглашения к недоступным ресурсам не раскрываем
+			if errors.Is(err, ErrResourceNotFound) {
+				err = ErrShareNotFound
+			}
+			logServ.WithError(err).Warn("Failed to Revoke")
+			return err
+		}
+	}
+
+	if err = s.shareRepo.Delete(ctx, shareID); err != nil {
+		logServ.WithError(err).Warn("Failed to Delete share")
+		return err
+	}
+
+	logServ.Debug("Revoke successfully")
+	return nil
+}
+
+func (s *Service) respond(ctx context.Context, userID, shareID int, status string) error {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id":  userID,
+		"share_id": shareID,
+		"status":   status,
+	})
+	logServ.Debug("Attempting to respond to invitation")
+
+	if err := s.shareRepo.Respond(ctx, shareID, userID, status); err != nil {
+		logServ.WithError(err).Warn("Failed to Respond")
+		return err
+	}
+
+	logServ.Debug("Respond successfully")
+	return nil
+}
+
+func (s *Service) requireOwner(ctx context.Context, userID int, resourceType string, resourceID int) error {
+	rank, err := s.shareRepo.Rank(ctx, resourceType, resourceID, userID)
+	if err != nil {
+		return err
+	}
+	if rank == 0 {
+		return ErrResourceNotFound
+	}
+	if rank < rankOwner {
+		return ErrShareForbidden
+	}
+	return nil
+}
+
+// notifyShared сообщает приглашённому о новом приглашении; ошибка только пишется в лог
+func (s *Service) notifyShared(ctx context.Context, logServ *logrus.Entry, share *Share) {
+	n := &notification.Notification{
+		UserID:  share.UserID,
+		ActorID: share.OwnerID,
+		Type:    notification.TypeShared,
+		Text:    fmt.Sprintf("You were invited to a %s as %s", share.ResourceType, share.Role),
+	}
+	if share.ResourceType == ResourceTask {
+		n.TaskID = share.ResourceID
+	} else {
+		n.ProjectID = share.ResourceID
+	}
+	if err := s.notifier.Notify(ctx, n); err != nil {
+		logServ.WithError(err).Warn("Failed to notify grantee")
+	}
+}
+
+func serviceLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Service share layer")
+}
@@ -0,0 +1,203 @@
+package share_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/melnik-dev/go_todo_jwt/internal/notification"
+	"github.com/melnik-dev/go_todo_jwt/internal/share"
+	"github.com/melnik-dev/go_todo_jwt/internal/user"
+	"github.com/sirupsen/logrus"
+	"io"
+	"testing"
+)
+
+type MockShareRepository struct {
+	RankMock           func(resourceType string, resourceID, userID int) (int, error)
+	SaveMock           func(share *share.Share) (*share.Share, error)
+	GetMock            func(id int) (*share.Share, error)
+	GetInvitationsMock func(userID int) ([]share.Share, error)
+	GetByResourceMock  func(resourceType string, resourceID int) ([]share.Share, error)
+	RespondMock        func(id, userID int, status string) error
+	DeleteMock         func(id int) error
+}
+
+func (m *MockShareRepository) Rank(_ context.Context, resourceType string, resourceID, userID int) (int, error) {
+	return m.RankMock(resourceType, resourceID, userID)
+}
+
+func (m *MockShareRepository) Save(_ context.Context, share *share.Share) (*share.Share, error) {
+	return m.SaveMock(share)
+}
+
+func (m *MockShareRepository) Get(_ context.Context, id int) (*share.Share, error) {
+	return m.GetMock(id)
+}
+
+func (m *MockShareRepository) GetInvitations(_ context.Context, userID int) ([]share.Share, error) {
+	return m.GetInvitationsMock(userID)
+}
+
+func (m *MockShareRepository) GetByResource(_ context.Context, resourceType string, resourceID int) ([]share.Share, error) {
+	return m.GetByResourceMock(resourceType, resourceID)
+}
+
+func (m *MockShareRepository) Respond(_ context.Context, id, userID int, status string) error {
+	return m.RespondMock(id, userID, status)
+}
+
+func (m *MockShareRepository) Delete(_ context.Context, id int) error {
+	return m.DeleteMock(id)
+}
+
+// MockUserRepository встраивает user.IRepository, чтобы переопределять только нужные методы
+type MockUserRepository struct {
+	user.IRepository
+	GetMock func(username string) (*user.User, error)
+}
+
+func (m *MockUserRepository) Get(_ context.Context, username string) (*user.User, error) {
+	return m.GetMock(username)
+}
+
+type MockNotifier struct {
+	Sent []notification.Notification
+}
+
+func (m *MockNotifier) Notify(ctx context.Context, n *notification.Notification) error {
+	m.Sent = append(m.Sent, *n)
+	return nil
+}
+
+func mockLogger() *logrus.Logger {
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	return l
+}
+
+func mockUsers() *MockUserRepository {
+	return &MockUserRepository{
+		GetMock: func(username string) (*user.User, error) {
+			switch username {
+			case "alice":
+				return &user.User{ID: 42, Name: "alice"}, nil
+			case "bob":
+				return &user.User{ID: 7, Name: "bob"}, nil
+			}
+			return nil, sql.ErrNoRows
+		},
+	}
+}
+
+func TestService_Share(t *testing.T) {
+	tests := []struct {
+		name     string
+		rank     int
+		username string
+		wantErr  error
+	}{
+		{name: "success", rank: 3, username: "bob"},
+		{name: "not visible", rank: 0, username: "bob", wantErr: share.ErrResourceNotFound},
+		{name: "editor cannot share", rank: 2, username: "bob", wantErr: share.ErrShareForbidden},
+		{name: "unknown user", rank: 3, username: "carol", wantErr: share.ErrUserNotFound},
+		{name: "self", rank: 3, username: "alice", wantErr: share.ErrShareWithSelf},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := false
+			repo := &MockShareRepository{
+				RankMock: func(resourceType string, resourceID, userID int) (int, error) {
+					return tt.rank, nil
+				},
+				SaveMock: func(s *share.Share) (*share.Share, error) {
+					saved = true
+					if s.OwnerID != 42 || s.UserID != 7 || s.Role != share.RoleEditor {
+						t.Errorf("unexpected share %+v", s)
+					}
+					s.ID = 1
+					s.Status = share.StatusPending
+					return s, nil
+				},
+			}
+			notifier := &MockNotifier{}
+			service := share.NewService(repo, mockUsers(), notifier, mockLogger())
+
+			s, err := service.Share(context.Background(), 42, &share.CreateRequest{
+				ResourceType: share.ResourceTask,
+				ResourceID:   5,
+				Username:     tt.username,
+				Role:         share.RoleEditor,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr == nil && (s.Username != "bob" || !saved) {
+				t.Errorf("unexpected share %+v", s)
+			}
+			if tt.wantErr != nil && saved {
+				t.Error("share must not be saved on error")
+			}
+			if tt.wantErr == nil && (len(notifier.Sent) != 1 || notifier.Sent[0].UserID != 7 || notifier.Sent[0].TaskID != 5) {
+				t.Errorf("unexpected notifications %+v", notifier.Sent)
+			}
+		})
+	}
+}
+
+func TestService_Revoke(t *testing.T) {
+	tests := []struct {
+		name    string
+		userID  int
+		rank    int
+		wantErr error
+	}{
+		{name: "grantee leaves", userID: 7},
+		{name: "owner revokes", userID: 42, rank: 3},
+		{name: "editor cannot revoke", userID: 9, rank: 2, wantErr: share.ErrShareForbidden},
+		{name: "stranger", userID: 9, rank: 0, wantErr: share.ErrShareNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deleted := false
+			repo := &MockShareRepository{
+				GetMock: func(id int) (*share.Share, error) {
+					return &share.Share{ID: id, ResourceType: share.ResourceProject, ResourceID: 1, OwnerID: 42, UserID: 7}, nil
+				},
+				RankMock: func(resourceType string, resourceID, userID int) (int, error) {
+					return tt.rank, nil
+				},
+				DeleteMock: func(id int) error {
+					deleted = true
+					return nil
+				},
+			}
+			service := share.NewService(repo, mockUsers(), &MockNotifier{}, mockLogger())
+
+			err := service.Revoke(context.Background(), tt.userID, 3)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if deleted != (tt.wantErr == nil) {
+				t.Errorf("unexpected deleted = %v", deleted)
+			}
+		})
+	}
+}
+
+func TestService_Accept(t *testing.T) {
+	repo := &MockShareRepository{
+		RespondMock: func(id, userID int, status string) error {
+			if id != 3 || userID != 7 || status != share.StatusAccepted {
+				t.Errorf("unexpected respond(%d, %d, %s)", id, userID, status)
+			}
+			return nil
+		},
+	}
+	service := share.NewService(repo, mockUsers(), &MockNotifier{}, mockLogger())
+
+	if err := service.Accept(context.Background(), 7, 3); err != nil {
+		t.Fatal(err)
+	}
+}
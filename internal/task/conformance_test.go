@@ -0,0 +1,47 @@
+package task_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/melnik-dev/go_todo_jwt/internal/task"
+	"github.com/melnik-dev/go_todo_jwt/internal/task/tasktest"
+	"github.com/melnik-dev/go_todo_jwt/internal/user"
+	"github.com/melnik-dev/go_todo_jwt/internal/workspace"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db/dbtest"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	tasktest.Run(t, func(*testing.T) tasktest.Env {
+		users := user.NewMemoryRepository()
+		return tasktest.Env{
+			Tasks: task.NewMemoryRepository(users),
+			Users: users,
+			// в памяти личное пространство пользователя совпадает с его id
+			Workspace: func(_ context.Context, userID int) (int, error) { return userID, nil },
+		}
+	})
+}
+
+func TestRepository_Postgres(t *testing.T) {
+	database := dbtest.Postgres(t)
+	tasktest.Run(t, func(*testing.T) tasktest.Env {
+		return tasktest.Env{
+			Tasks:     task.NewRepository(database, dbtest.Logger()),
+			Users:     user.NewRepository(database, dbtest.Logger()),
+			Workspace: workspace.NewRepository(database, dbtest.Logger()).Personal,
+		}
+	})
+}
+
+func TestSQLiteRepository(t *testing.T) {
+	database := dbtest.SQLite(t)
+	tasktest.Run(t, func(*testing.T) tasktest.Env {
+		return tasktest.Env{
+			Tasks: task.NewSQLiteRepository(database, dbtest.Logger()),
+			Users: user.NewSQLiteRepository(database, dbtest.Logger()),
+			// в SQLite личное пространство пользователя совпадает с его id
+			Workspace: func(_ context.Context, userID int) (int, error) { return userID, nil },
+		}
+	})
+}
@@ -3,5 +3,12 @@ package task
 import "errors"
 
 var (
-	ErrTaskNotFound = errors.New("task not found")
+	ErrTaskNotFound     = errors.New("task not found")
+	ErrTaskForbidden    = errors.New("not enough permissions for task")
+	ErrProjectNotFound  = errors.New("project not found")
+	ErrAssigneeNoAccess = errors.New("assignee has no access to task")
+	ErrUnknownFormat    = errors.New("unknown format")
+	ErrImportMalformed  = errors.New("malformed import file")
+	ErrImportInvalid    = errors.New("import file contains invalid rows")
+	ErrInvalidTodoTxt   = errors.New("invalid todo.txt line")
 )
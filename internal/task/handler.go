@@ -2,9 +2,12 @@ package task
 
 import (
 	"errors"
+	"fmt"
 	"github.com/melnik-dev/go_todo_jwt/pkg/logger"
 	"github.com/sirupsen/logrus"
+	"io"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/melnik-dev/go_todo_jwt/configs"
@@ -12,9 +15,17 @@ import (
 	"github.com/melnik-dev/go_todo_jwt/pkg/response"
 )
 
+const (
+	maxImportSize  = 10 << 20 // 10 MB
+	maxTodoTxtLine = 64 << 10 // 64 KB
+)
+
 type HandlerDeps struct {
 	TaskService IService
 	*configs.Config
+	// Workspace выбирает рабочее пространство запроса
+	Workspace   gin.HandlerFunc
+	Idempotency gin.HandlerFunc
 }
 
 type Handler struct {
@@ -29,7 +40,20 @@ func NewHandler(r *gin.Engine, deps *HandlerDeps) {
 	}
 	task := r.Group("/task")
 	task.Use(middleware.IsAuthed(handler.Config.JWT.Secret))
+	if deps.Workspace != nil {
+		task.Use(deps.Workspace)
+	}
+	if deps.Idempotency != nil {
+		task.Use(deps.Idempotency)
+	}
 	task.POST("/create", handler.Create)
+	task.GET("/export", handler.Export)
+	task.POST("/import", handler.Import)
+	task.GET("/:id/todotxt", handler.GetTodoTxt)
+	task.PUT("/:id/todotxt", handler.UpdateTodoTxt)
+	task.PUT("/:id/project", handler.SetProject)
+	task.PUT("/:id/assignee", handler.SetAssignee)
+	task.GET("/:id/assignments", handler.GetAssignments)
 	task.PUT("/:id", handler.Update)
 	task.DELETE("/:id", handler.Delete)
 	task.GET("/:id", handler.Get)
@@ -52,7 +76,7 @@ func (h *Handler) Create(c *gin.Context) {
 		return
 	}
 
-	taskId, err := h.TaskService.Create(userID, input.Title, input.Description)
+	taskId, err := h.TaskService.Create(c.Request.Context(), userID, input.Title, input.Description)
 	if err != nil {
 		logHandle.WithError(err).Error("Failed to Create")
 		response.InternalServerError(c, "Failed to create task")
@@ -87,13 +111,18 @@ func (h *Handler) Update(c *gin.Context) {
 		return
 	}
 
-	err := h.TaskService.Update(userID, uri.ID, input.Title, input.Description, input.Completed)
+	err := h.TaskService.Update(c.Request.Context(), userID, uri.ID, input.Title, input.Description, input.Completed)
 	if err != nil {
 		if errors.Is(err, ErrTaskNotFound) {
 			logHandle.Warn(ErrTaskNotFound.Error())
 			response.NotFound(c, ErrTaskNotFound.Error())
 			return
 		}
+		if errors.Is(err, ErrTaskForbidden) {
+			logHandle.WithError(err).Warn(ErrTaskForbidden.Error())
+			response.Forbidden(c, ErrTaskForbidden.Error())
+			return
+		}
 		logHandle.WithError(err).Error("Failed to Update")
 		response.InternalServerError(c, "Failed to update task")
 		return
@@ -103,6 +132,134 @@ func (h *Handler) Update(c *gin.Context) {
 	response.Success(c, http.StatusOK, gin.H{"message": "Task updated successfully"})
 }
 
+func (h *Handler) SetProject(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to SetProject")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri URIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind ID")
+		response.BadRequest(c, "Invalid task ID")
+		return
+	}
+	logHandle = logHandle.WithField("task_id", uri.ID)
+
+	var input SetProjectRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind JSON in SetProject")
+		response.BadRequest(c, "Invalid input data")
+		return
+	}
+
+	err := h.TaskService.SetProject(c.Request.Context(), userID, uri.ID, input.ProjectID)
+	if err != nil {
+		if errors.Is(err, ErrTaskNotFound) || errors.Is(err, ErrProjectNotFound) {
+			logHandle.WithError(err).Warn("Task or project not found")
+			response.NotFound(c, err.Error())
+			return
+		}
+		if errors.Is(err, ErrTaskForbidden) {
+			logHandle.WithError(err).Warn(ErrTaskForbidden.Error())
+			response.Forbidden(c, ErrTaskForbidden.Error())
+			return
+		}
+		logHandle.WithError(err).Error("Failed to SetProject")
+		response.InternalServerError(c, "Failed to move task")
+		return
+	}
+
+	logHandle.Debug("SetProject successfully")
+	response.Success(c, http.StatusOK, gin.H{"message": "Task moved successfully"})
+}
+
+func (h *Handler) SetAssignee(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to SetAssignee")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri URIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind ID")
+		response.BadRequest(c, "Invalid task ID")
+		return
+	}
+	logHandle = logHandle.WithField("task_id", uri.ID)
+
+	var input SetAssigneeRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind JSON in SetAssignee")
+		response.BadRequest(c, "Invalid input data")
+		return
+	}
+
+	err := h.TaskService.SetAssignee(c.Request.Context(), userID, uri.ID, input.AssigneeID)
+	if err != nil {
+		if errors.Is(err, ErrTaskNotFound) {
+			logHandle.Warn(ErrTaskNotFound.Error())
+			response.NotFound(c, ErrTaskNotFound.Error())
+			return
+		}
+		if errors.Is(err, ErrTaskForbidden) {
+			logHandle.Warn(ErrTaskForbidden.Error())
+			response.Forbidden(c, ErrTaskForbidden.Error())
+			return
+		}
+		if errors.Is(err, ErrAssigneeNoAccess) {
+			logHandle.Warn(ErrAssigneeNoAccess.Error())
+			response.Error(c, http.StatusUnprocessableEntity, ErrAssigneeNoAccess.Error())
+			return
+		}
+		logHandle.WithError(err).Error("Failed to SetAssignee")
+		response.InternalServerError(c, "Failed to assign task")
+		return
+	}
+
+	logHandle.Debug("SetAssignee successfully")
+	response.Success(c, http.StatusOK, gin.H{"message": "Task assigned successfully"})
+}
+
+func (h *Handler) GetAssignments(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to GetAssignments")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri URIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind ID")
+		response.BadRequest(c, "Invalid task ID")
+		return
+	}
+	logHandle = logHandle.WithField("task_id", uri.ID)
+
+	assignments, err := h.TaskService.GetAssignments(c.Request.Context(), userID, uri.ID)
+	if err != nil {
+		if errors.Is(err, ErrTaskNotFound) {
+			logHandle.Warn(ErrTaskNotFound.Error())
+			response.NotFound(c, ErrTaskNotFound.Error())
+			return
+		}
+		logHandle.WithError(err).Error("Failed to GetAssignments")
+		response.InternalServerError(c, "Failed to get assignments")
+		return
+	}
+
+	logHandle.Debug("GetAssignments successfully")
+	response.Success(c, http.StatusOK, gin.H{"assignments": assignments})
+}
+
 func (h *Handler) Delete(c *gin.Context) {
 	logHandle := handlerLogger(c)
 	logHandle.Debug("Received request to Delete")
@@ -120,13 +277,18 @@ func (h *Handler) Delete(c *gin.Context) {
 	}
 	logHandle = logHandle.WithField("task_id", uri.ID)
 
-	err := h.TaskService.Delete(userID, uri.ID)
+	err := h.TaskService.Delete(c.Request.Context(), userID, uri.ID)
 	if err != nil {
 		if errors.Is(err, ErrTaskNotFound) {
 			logHandle.WithError(err).Warn(ErrTaskNotFound.Error())
 			response.NotFound(c, ErrTaskNotFound.Error())
 			return
 		}
+		if errors.Is(err, ErrTaskForbidden) {
+			logHandle.WithError(err).Warn(ErrTaskForbidden.Error())
+			response.Forbidden(c, ErrTaskForbidden.Error())
+			return
+		}
 		logHandle.WithError(err).Error("Failed to delete")
 		response.InternalServerError(c, "Failed to delete task")
 		return
@@ -153,7 +315,7 @@ func (h *Handler) Get(c *gin.Context) {
 	}
 	logHandle = logHandle.WithField("task_id", uri.ID)
 
-	task, err := h.TaskService.GetById(userID, uri.ID)
+	task, err := h.TaskService.GetById(c.Request.Context(), userID, uri.ID)
 	if err != nil {
 		if errors.Is(err, ErrTaskNotFound) {
 			logHandle.WithError(err).Warn(ErrTaskNotFound.Error())
@@ -169,6 +331,97 @@ func (h *Handler) Get(c *gin.Context) {
 	response.Success(c, http.StatusOK, gin.H{"task": task})
 }
 
+// GetTodoTxt отдаёт задачу одной строкой todo.txt
+func (h *Handler) GetTodoTxt(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to GetTodoTxt")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri URIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind task ID")
+		response.BadRequest(c, "Invalid task ID")
+		return
+	}
+	logHandle = logHandle.WithField("task_id", uri.ID)
+
+	task, err := h.TaskService.GetById(c.Request.Context(), userID, uri.ID)
+	if err != nil {
+		if errors.Is(err, ErrTaskNotFound) {
+			logHandle.WithError(err).Warn(ErrTaskNotFound.Error())
+			response.NotFound(c, ErrTaskNotFound.Error())
+			return
+		}
+		logHandle.WithError(err).Error("Failed to GetById")
+		response.InternalServerError(c, "Failed to get task")
+		return
+	}
+
+	logHandle.Debug("GetTodoTxt successfully")
+	c.String(http.StatusOK, "%s\n", ToTodoTxt(task).String())
+}
+
+// UpdateTodoTxt заменяет задачу строкой todo.txt из тела запроса и отвечает итоговой строкой
+func (h *Handler) UpdateTodoTxt(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to UpdateTodoTxt")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri URIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind task ID")
+		response.BadRequest(c, "Invalid task ID")
+		return
+	}
+	logHandle = logHandle.WithField("task_id", uri.ID)
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxTodoTxtLine))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			logHandle.WithError(err).Warn("todo.txt line is too large")
+			response.Error(c, http.StatusRequestEntityTooLarge, "todo.txt line is too large")
+			return
+		}
+		logHandle.WithError(err).Warn("Failed to read todo.txt line")
+		response.BadRequest(c, "Invalid input")
+		return
+	}
+
+	task, err := h.TaskService.UpdateTodoTxt(c.Request.Context(), userID, uri.ID, strings.TrimRight(string(body), "\r\n"))
+	if err != nil {
+		if errors.Is(err, ErrTaskNotFound) {
+			logHandle.WithError(err).Warn(ErrTaskNotFound.Error())
+			response.NotFound(c, ErrTaskNotFound.Error())
+			return
+		}
+		if errors.Is(err, ErrTaskForbidden) {
+			logHandle.WithError(err).Warn(ErrTaskForbidden.Error())
+			response.Forbidden(c, ErrTaskForbidden.Error())
+			return
+		}
+		if errors.Is(err, ErrInvalidTodoTxt) {
+			logHandle.WithError(err).Warn(ErrInvalidTodoTxt.Error())
+			response.BadRequest(c, err.Error())
+			return
+		}
+		logHandle.WithError(err).Error("Failed to UpdateTodoTxt")
+		response.InternalServerError(c, "Failed to update task")
+		return
+	}
+
+	logHandle.Debug("UpdateTodoTxt successfully")
+	c.String(http.StatusOK, "%s\n", ToTodoTxt(task).String())
+}
+
 func (h *Handler) GetAll(c *gin.Context) {
 	logHandle := handlerLogger(c)
 	logHandle.Debug("Received request to GetAll")
@@ -178,7 +431,20 @@ func (h *Handler) GetAll(c *gin.Context) {
 		return
 	}
 
-	tasks, err := h.TaskService.GetAll(userID)
+	var query ListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind query in GetAll")
+		response.BadRequest(c, "Invalid assignee filter")
+		return
+	}
+
+	var tasks []Task
+	var err error
+	if query.Assignee == "me" {
+		tasks, err = h.TaskService.GetAssigned(c.Request.Context(), userID)
+	} else {
+		tasks, err = h.TaskService.GetAll(c.Request.Context(), userID)
+	}
 	if err != nil {
 		logHandle.WithError(err).Error("Failed to get all tasks")
 		response.InternalServerError(c, "Failed to get tasks")
@@ -189,6 +455,109 @@ func (h *Handler) GetAll(c *gin.Context) {
 	response.Success(c, http.StatusOK, gin.H{"tasks": tasks})
 }
 
+func (h *Handler) Export(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Export")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var query ExportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind query in Export")
+		response.BadRequest(c, "Invalid query")
+		return
+	}
+
+	format, err := ParseFormat(query.Format)
+	if err != nil {
+		logHandle.WithError(err).Warn(ErrUnknownFormat.Error())
+		response.BadRequest(c, ErrUnknownFormat.Error())
+		return
+	}
+	logHandle = logHandle.WithField("format", format)
+
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="tasks.%s"`, format))
+
+	err = h.TaskService.Export(c.Request.Context(), userID, format, c.Writer)
+	if err != nil {
+		if !c.Writer.Written() {
+			c.Header("Content-Disposition", "")
+			logHandle.WithError(err).Error("Failed to Export")
+			response.InternalServerError(c, "Failed to export tasks")
+			return
+		}
+		// заголовки уже отправлены, остаётся только оборвать поток
+		logHandle.WithError(err).Error("Export interrupted")
+		c.Abort()
+		return
+	}
+
+	logHandle.Debug("Export successfully")
+}
+
+func (h *Handler) Import(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Import")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var query ImportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind query in Import")
+		response.BadRequest(c, "Invalid query")
+		return
+	}
+
+	format, err := ParseFormat(query.Format)
+	if err != nil {
+		logHandle.WithError(err).Warn(ErrUnknownFormat.Error())
+		response.BadRequest(c, ErrUnknownFormat.Error())
+		return
+	}
+	logHandle = logHandle.WithFields(logrus.Fields{
+		"format":  format,
+		"dry_run": query.DryRun,
+	})
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	report, err := h.TaskService.Import(c.Request.Context(), userID, format, body, query.DryRun)
+	if err != nil {
+		if errors.Is(err, ErrImportInvalid) {
+			logHandle.Warn(ErrImportInvalid.Error())
+			c.JSON(http.StatusUnprocessableEntity, response.Response{
+				Status: http.StatusUnprocessableEntity,
+				Error:  ErrImportInvalid.Error(),
+				Data:   report,
+			})
+			return
+		}
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			logHandle.WithError(err).Warn("Import file is too large")
+			response.Error(c, http.StatusRequestEntityTooLarge, "Import file is too large")
+			return
+		}
+		if errors.Is(err, ErrImportMalformed) {
+			logHandle.WithError(err).Warn(ErrImportMalformed.Error())
+			response.BadRequest(c, err.Error())
+			return
+		}
+		logHandle.WithError(err).Error("Failed to Import")
+		response.InternalServerError(c, "Failed to import tasks")
+		return
+	}
+
+	logHandle.Debug("Import successfully")
+	response.Success(c, http.StatusOK, report)
+}
+
 func handlerLogger(c *gin.Context) *logrus.Entry {
 	return logger.FromContext(c).WithField("layer", "Handler task layer")
 }
@@ -2,15 +2,20 @@ package task_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/melnik-dev/go_todo_jwt/internal/task"
+	"github.com/melnik-dev/go_todo_jwt/pkg/middleware"
+	"github.com/melnik-dev/go_todo_jwt/pkg/response"
 	"github.com/sirupsen/logrus"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 type MockTaskService struct {
@@ -19,28 +24,90 @@ type MockTaskService struct {
 	DeleteMock  func(userID, taskID int) error
 	GetByIdMock func(userID, taskID int) (*task.Task, error)
 	GetAllMock  func(userID int) ([]task.Task, error)
+	ExportMock  func(userID int, format task.Format, w io.Writer) error
+	ImportMock  func(userID int, format task.Format, r io.Reader, dryRun bool) (*task.ImportReport, error)
+
+	GetByUIDMock      func(userID int, uid string) (*task.Task, error)
+	UpsertMock        func(userID int, t *task.Task) (bool, error)
+	DeleteByUIDMock   func(userID int, uid string) error
+	GetChangesMock    func(userID int, since int64) (*task.ChangeSet, error)
+	LatestChangeMock  func(userID int) (int64, error)
+	UpdateTodoTxtMock func(userID, taskID int, line string) (*task.Task, error)
+	SetProjectMock    func(userID, taskID int, projectID *int) error
+
+	GetAssignedMock    func(userID int) ([]task.Task, error)
+	SetAssigneeMock    func(userID, taskID int, assigneeID *int) error
+	GetAssignmentsMock func(userID, taskID int) ([]task.Assignment, error)
 }
 
-func (m *MockTaskService) Create(userID int, title, desc string) (int, error) {
+func (m *MockTaskService) Create(ctx context.Context, userID int, title, desc string) (int, error) {
 	return m.CreateMock(userID, title, desc)
 }
 
-func (m *MockTaskService) Update(userID, taskID int, title, desc string, completed bool) error {
+func (m *MockTaskService) Update(ctx context.Context, userID, taskID int, title, desc string, completed bool) error {
 	return m.UpdateMock(userID, taskID, title, desc, completed)
 }
 
-func (m *MockTaskService) Delete(userID, taskID int) error {
+func (m *MockTaskService) Delete(ctx context.Context, userID, taskID int) error {
 	return m.DeleteMock(userID, taskID)
 }
 
-func (m *MockTaskService) GetById(userID, taskID int) (*task.Task, error) {
+func (m *MockTaskService) GetById(ctx context.Context, userID, taskID int) (*task.Task, error) {
 	return m.GetByIdMock(userID, taskID)
 }
 
-func (m *MockTaskService) GetAll(userID int) ([]task.Task, error) {
+func (m *MockTaskService) GetAll(ctx context.Context, userID int) ([]task.Task, error) {
 	return m.GetAllMock(userID)
 }
 
+func (m *MockTaskService) Export(ctx context.Context, userID int, format task.Format, w io.Writer) error {
+	return m.ExportMock(userID, format, w)
+}
+
+func (m *MockTaskService) Import(ctx context.Context, userID int, format task.Format, r io.Reader, dryRun bool) (*task.ImportReport, error) {
+	return m.ImportMock(userID, format, r, dryRun)
+}
+
+func (m *MockTaskService) GetByUID(ctx context.Context, userID int, uid string) (*task.Task, error) {
+	return m.GetByUIDMock(userID, uid)
+}
+
+func (m *MockTaskService) Upsert(ctx context.Context, userID int, t *task.Task) (bool, error) {
+	return m.UpsertMock(userID, t)
+}
+
+func (m *MockTaskService) DeleteByUID(ctx context.Context, userID int, uid string) error {
+	return m.DeleteByUIDMock(userID, uid)
+}
+
+func (m *MockTaskService) GetChanges(ctx context.Context, userID int, since int64) (*task.ChangeSet, error) {
+	return m.GetChangesMock(userID, since)
+}
+
+func (m *MockTaskService) LatestChange(ctx context.Context, userID int) (int64, error) {
+	return m.LatestChangeMock(userID)
+}
+
+func (m *MockTaskService) GetAssigned(ctx context.Context, userID int) ([]task.Task, error) {
+	return m.GetAssignedMock(userID)
+}
+
+func (m *MockTaskService) SetAssignee(ctx context.Context, userID, taskID int, assigneeID *int) error {
+	return m.SetAssigneeMock(userID, taskID, assigneeID)
+}
+
+func (m *MockTaskService) GetAssignments(ctx context.Context, userID, taskID int) ([]task.Assignment, error) {
+	return m.GetAssignmentsMock(userID, taskID)
+}
+
+func (m *MockTaskService) SetProject(ctx context.Context, userID, taskID int, projectID *int) error {
+	return m.SetProjectMock(userID, taskID, projectID)
+}
+
+func (m *MockTaskService) UpdateTodoTxt(ctx context.Context, userID, taskID int, line string) (*task.Task, error) {
+	return m.UpdateTodoTxtMock(userID, taskID, line)
+}
+
 func mockGin() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	r := gin.Default()
@@ -470,3 +537,362 @@ func TestHandler_GetAll_Fail(t *testing.T) {
 		t.Errorf("expected %d, got %d", http.StatusInternalServerError, w.Code)
 	}
 }
+
+func TestHandler_GetAll_Canceled(t *testing.T) {
+	handler := &task.Handler{
+		TaskService: &MockTaskService{
+			GetAllMock: func(userID int) ([]task.Task, error) {
+				// драйвер отвечает на отмену своей ошибкой; код ответа выбирается по контексту запроса
+				time.Sleep(20 * time.Millisecond)
+				return nil, fmt.Errorf("pq: canceling statement due to user request")
+			},
+		},
+	}
+
+	t.Run("client closed request", func(t *testing.T) {
+		r := mockGin()
+		r.GET("/task", handler.GetAll)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/task", nil).WithContext(ctx))
+
+		if w.Code != response.StatusClientClosedRequest {
+			t.Errorf("expected %d, got %d", response.StatusClientClosedRequest, w.Code)
+		}
+	})
+
+	t.Run("request timeout", func(t *testing.T) {
+		r := mockGin()
+		r.GET("/task", middleware.Timeout(5*time.Millisecond), handler.GetAll)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/task", nil))
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected %d, got %d", http.StatusServiceUnavailable, w.Code)
+		}
+	})
+}
+
+func TestHandler_Export_Success(t *testing.T) {
+	handler := &task.Handler{
+		TaskService: &MockTaskService{
+			ExportMock: func(userID int, format task.Format, w io.Writer) error {
+				if format != task.FormatCSV {
+					t.Errorf("expected format %s, got %s", task.FormatCSV, format)
+				}
+				_, err := io.WriteString(w, "id,title,description,completed\n")
+				return err
+			},
+		},
+	}
+
+	r := mockGin()
+	r.GET("/task/export", handler.Export)
+	req := httptest.NewRequest(http.MethodGet, "/task/export?format=csv", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected %d, got %d", http.StatusOK, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != task.FormatCSV.ContentType() {
+		t.Errorf("expected content type %s, got %s", task.FormatCSV.ContentType(), ct)
+	}
+}
+
+func TestHandler_Export_FailUnknownFormat(t *testing.T) {
+	handler := &task.Handler{TaskService: &MockTaskService{}}
+
+	r := mockGin()
+	r.GET("/task/export", handler.Export)
+	req := httptest.NewRequest(http.MethodGet, "/task/export?format=xml", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestHandler_Export_Fail(t *testing.T) {
+	handler := &task.Handler{
+		TaskService: &MockTaskService{
+			ExportMock: func(userID int, format task.Format, w io.Writer) error {
+				return fmt.Errorf("test error")
+			},
+		},
+	}
+
+	r := mockGin()
+	r.GET("/task/export", handler.Export)
+	req := httptest.NewRequest(http.MethodGet, "/task/export", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
+
+func requestImportHelper(t *testing.T, h *task.Handler, url string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := mockGin()
+	r.POST("/task/import", h.Import)
+
+	req := httptest.NewRequest(http.MethodPost, url, bytes.NewBufferString("- [ ] test_title\n"))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestHandler_Import_Success(t *testing.T) {
+	handler := &task.Handler{
+		TaskService: &MockTaskService{
+			ImportMock: func(userID int, format task.Format, r io.Reader, dryRun bool) (*task.ImportReport, error) {
+				if format != task.FormatMarkdown || !dryRun {
+					t.Errorf("unexpected format %s or dry run %v", format, dryRun)
+				}
+				return &task.ImportReport{DryRun: dryRun, Total: 1, Created: 1}, nil
+			},
+		},
+	}
+
+	w := requestImportHelper(t, handler, "/task/import?format=md&dry_run=true")
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestHandler_Import_FailInvalidRows(t *testing.T) {
+	handler := &task.Handler{
+		TaskService: &MockTaskService{
+			ImportMock: func(userID int, format task.Format, r io.Reader, dryRun bool) (*task.ImportReport, error) {
+				return &task.ImportReport{
+					Total:  1,
+					Errors: []task.ImportRowError{{Row: 1, Error: "title is required"}},
+				}, task.ErrImportInvalid
+			},
+		},
+	}
+
+	w := requestImportHelper(t, handler, "/task/import?format=md")
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+
+	type ResponseWrapper struct {
+		Data task.ImportReport `json:"data"`
+	}
+
+	var res ResponseWrapper
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Data.Errors) != 1 || res.Data.Errors[0].Row != 1 {
+		t.Errorf("unexpected report: %+v", res.Data)
+	}
+}
+
+func TestHandler_Import_FailMalformed(t *testing.T) {
+	handler := &task.Handler{
+		TaskService: &MockTaskService{
+			ImportMock: func(userID int, format task.Format, r io.Reader, dryRun bool) (*task.ImportReport, error) {
+				return nil, task.ErrImportMalformed
+			},
+		},
+	}
+
+	w := requestImportHelper(t, handler, "/task/import?format=json")
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestHandler_UpdateTodoTxt_Success(t *testing.T) {
+	handler := &task.Handler{
+		TaskService: &MockTaskService{
+			UpdateTodoTxtMock: func(userID, taskID int, line string) (*task.Task, error) {
+				if taskID != 1 || line != "(A) Call Mom +family" {
+					t.Errorf("unexpected task %d or line %q", taskID, line)
+				}
+				return &task.Task{ID: 1, Title: "Call Mom", Extras: task.Extras{
+					"todotxt.priority": "A",
+					"todotxt.projects": "family",
+				}}, nil
+			},
+		},
+	}
+
+	r := mockGin()
+	r.PUT("/task/:id/todotxt", handler.UpdateTodoTxt)
+	req := httptest.NewRequest(http.MethodPut, "http://example.com/task/1/todotxt", strings.NewReader("(A) Call Mom +family\n"))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, w.Code)
+	}
+	if w.Body.String() != "(A) Call Mom +family\n" {
+		t.Errorf("unexpected body %q", w.Body.String())
+	}
+}
+
+func TestHandler_UpdateTodoTxt_FailInvalid(t *testing.T) {
+	handler := &task.Handler{
+		TaskService: &MockTaskService{
+			UpdateTodoTxtMock: func(userID, taskID int, line string) (*task.Task, error) {
+				return nil, fmt.Errorf("%w: title is required", task.ErrInvalidTodoTxt)
+			},
+		},
+	}
+
+	r := mockGin()
+	r.PUT("/task/:id/todotxt", handler.UpdateTodoTxt)
+	req := httptest.NewRequest(http.MethodPut, "http://example.com/task/1/todotxt", strings.NewReader("+family"))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestHandler_Update_FailForbidden(t *testing.T) {
+	handler := &task.Handler{
+		TaskService: &MockTaskService{
+			UpdateMock: func(userID, taskID int, title, desc string, completed bool) error {
+				return task.ErrTaskForbidden
+			},
+		},
+	}
+
+	r := mockGin()
+	r.PUT("/task/:id", handler.Update)
+	body := `{"title":"test_title","description":"","completed":true}`
+	req := httptest.NewRequest(http.MethodPut, "http://example.com/task/1", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestHandler_SetProject(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		err    error
+		status int
+	}{
+		{"move", `{"project_id":5}`, nil, http.StatusOK},
+		{"remove from project", `{"project_id":null}`, nil, http.StatusOK},
+		{"project not accessible", `{"project_id":5}`, task.ErrProjectNotFound, http.StatusNotFound},
+		{"viewer", `{"project_id":5}`, task.ErrTaskForbidden, http.StatusForbidden},
+		{"invalid project", `{"project_id":0}`, nil, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &task.Handler{
+				TaskService: &MockTaskService{
+					SetProjectMock: func(userID, taskID int, projectID *int) error {
+						return tt.err
+					},
+				},
+			}
+
+			r := mockGin()
+			r.PUT("/task/:id/project", handler.SetProject)
+			req := httptest.NewRequest(http.MethodPut, "http://example.com/task/1/project", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("expected %d, got %d", tt.status, w.Code)
+			}
+		})
+	}
+}
+
+func TestHandler_SetAssignee(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		err    error
+		status int
+	}{
+		{"assign", `{"assignee_id":7}`, nil, http.StatusOK},
+		{"unassign", `{"assignee_id":null}`, nil, http.StatusOK},
+		{"not found", `{"assignee_id":7}`, task.ErrTaskNotFound, http.StatusNotFound},
+		{"viewer", `{"assignee_id":7}`, task.ErrTaskForbidden, http.StatusForbidden},
+		{"assignee without access", `{"assignee_id":7}`, task.ErrAssigneeNoAccess, http.StatusUnprocessableEntity},
+		{"invalid assignee", `{"assignee_id":0}`, nil, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &task.Handler{
+				TaskService: &MockTaskService{
+					SetAssigneeMock: func(userID, taskID int, assigneeID *int) error {
+						return tt.err
+					},
+				},
+			}
+
+			r := mockGin()
+			r.PUT("/task/:id/assignee", handler.SetAssignee)
+			req := httptest.NewRequest(http.MethodPut, "http://example.com/task/1/assignee", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("expected %d, got %d", tt.status, w.Code)
+			}
+		})
+	}
+}
+
+func TestHandler_GetAll_AssignedToMe(t *testing.T) {
+	handler := &task.Handler{
+		TaskService: &MockTaskService{
+			GetAllMock: func(userID int) ([]task.Task, error) {
+				t.Fatal("GetAll must not be called with assignee=me")
+				return nil, nil
+			},
+			GetAssignedMock: func(userID int) ([]task.Task, error) {
+				return []task.Task{{ID: 1, Title: "Assigned", AssigneeID: &userID}}, nil
+			},
+		},
+	}
+
+	r := mockGin()
+	r.GET("/task/", handler.GetAll)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/task/?assignee=me", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"Assigned"`) {
+		t.Errorf("unexpected body %s", w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "http://example.com/task/?assignee=bob", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,568 @@
+package task
+
+import (
+	"context"
+	"maps"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/melnik-dev/go_todo_jwt/internal/user"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+)
+
+// MemoryRepository хранит задачи в памяти процесса (db.driver: memory) и
+// повторяет поведение Repository: рабочее пространство берётся из ctx,
+// UID уникален у владельца, номер изменения общий для всех задач, а удаления
+// оставляют надгробия для журнала изменений. Общего доступа и проектов нет:
+// задачу видят владелец и исполнитель (с правами редактора).
+type MemoryRepository struct {
+	mu    sync.RWMutex
+	users *user.MemoryRepository
+
+	tasks      map[int]*Task
+	lastID     int
+	changeSeq  int64
+	tombstones map[tombstoneKey]tombstone
+
+	assignments      []Assignment
+	lastAssignmentID int
+}
+
+type tombstoneKey struct {
+	userID int
+	uid    string
+}
+
+type tombstone struct {
+	workspaceID int
+	changeSeq   int64
+}
+
+func NewMemoryRepository(users *user.MemoryRepository) *MemoryRepository {
+	return &MemoryRepository{
+		users:      users,
+		tasks:      make(map[int]*Task),
+		tombstones: make(map[tombstoneKey]tombstone),
+	}
+}
+
+func (r *MemoryRepository) Create(ctx context.Context, task *Task) (*Task, error) {
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.insert(workspaceID, &Task{
+		UserID:      task.UserID,
+		Title:       task.Title,
+		Description: task.Description,
+		UID:         uuid.NewString(),
+	})
+	task.ID = r.lastID
+	return task, nil
+}
+
+func (r *MemoryRepository) Update(ctx context.Context, task *Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := r.editable(ctx, task, rankViewer)
+	if err != nil {
+		return err
+	}
+	// исполнитель меняет только отметку о выполнении, как в Repository.Update
+	if memoryRank(stored, task.UserID) < rankEditor &&
+		(stored.Title != task.Title || stored.Description != task.Description) {
+		return ErrTaskForbidden
+	}
+
+	stored.Title = task.Title
+	stored.Description = task.Description
+	stored.CompletedAt = completedAt(task.Completed, stored.CompletedAt)
+	stored.Completed = task.Completed
+	r.touch(stored)
+	return nil
+}
+
+func (r *MemoryRepository) DeleteById(ctx context.Context, task *Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := r.editable(ctx, task, rankOwner)
+	if err != nil {
+		return err
+	}
+	r.delete(stored)
+	return nil
+}
+
+func (r *MemoryRepository) GetById(ctx context.Context, task *Task) (*Task, error) {
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.tasks[task.ID]
+	if !ok || stored.WorkspaceID != workspaceID || memoryRank(stored, task.UserID) == 0 {
+		return nil, ErrTaskNotFound
+	}
+	*task = r.accessible(stored, task.UserID)
+	return task, nil
+}
+
+func (r *MemoryRepository) GetAll(ctx context.Context, user *user.User) ([]Task, error) {
+	return r.list(ctx, user.ID, func(*Task) bool { return true })
+}
+
+// Each отдаёт задачи снимком, поэтому fn может обращаться к хранилищу
+func (r *MemoryRepository) Each(ctx context.Context, user *user.User, fn func(task *Task) error) error {
+	tasks, err := r.GetAll(ctx, user)
+	if err != nil {
+		return err
+	}
+	for i := range tasks {
+		if err = fn(&tasks[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) CreateBatch(ctx context.Context, tasks []Task) error {
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range tasks {
+		task := &tasks[i]
+		r.insert(workspaceID, &Task{
+			UserID:      task.UserID,
+			Title:       task.Title,
+			Description: task.Description,
+			Completed:   task.Completed,
+			UID:         uuid.NewString(),
+			DueAt:       copyOf(task.DueAt),
+			CompletedAt: completedAt(task.Completed, copyOf(task.CompletedAt)),
+			Extras:      task.Extras,
+		})
+		task.ID = r.lastID
+	}
+	return nil
+}
+
+func (r *MemoryRepository) UpsertBatch(ctx context.Context, tasks []Task) (int, error) {
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// задача с тем же UID в другом пространстве не видна, и Postgres отклоняет
+	// её обновление политикой RLS; проверка до записи сохраняет "всё или ничего"
+	for _, task := range tasks {
+		if stored := r.byUID(task.UserID, task.UID); stored != nil && stored.WorkspaceID != workspaceID {
+			return 0, ErrTaskForbidden
+		}
+	}
+
+	created := 0
+	for i := range tasks {
+		task := &tasks[i]
+		stored := r.byUID(task.UserID, task.UID)
+		if stored == nil {
+			stored = r.insert(workspaceID, &Task{
+				UserID: task.UserID,
+				UID:    task.UID,
+			})
+			created++
+		} else {
+			r.touch(stored)
+		}
+
+		stored.Title = task.Title
+		stored.Description = task.Description
+		stored.Completed = task.Completed
+		stored.DueAt = copyOf(task.DueAt)
+		stored.CompletedAt = completedAt(task.Completed, copyOf(task.CompletedAt))
+		if task.Extras != nil {
+			stored.Extras = maps.Clone(task.Extras)
+		}
+
+		task.ID = stored.ID
+		task.ChangeSeq = stored.ChangeSeq
+	}
+	return created, nil
+}
+
+func (r *MemoryRepository) GetByUID(ctx context.Context, task *Task) (*Task, error) {
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored := r.byUID(task.UserID, task.UID)
+	if stored == nil || stored.WorkspaceID != workspaceID {
+		return nil, ErrTaskNotFound
+	}
+	*task = cloneTask(stored)
+	return task, nil
+}
+
+func (r *MemoryRepository) DeleteByUID(ctx context.Context, task *Task) error {
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := r.byUID(task.UserID, task.UID)
+	if stored == nil || stored.WorkspaceID != workspaceID {
+		return ErrTaskNotFound
+	}
+	r.delete(stored)
+	return nil
+}
+
+func (r *MemoryRepository) GetChanges(ctx context.Context, user *user.User, since int64) (*ChangeSet, error) {
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	changes := &ChangeSet{
+		Token:   since,
+		Updated: make([]Task, 0),
+		Deleted: make([]string, 0),
+	}
+
+	for _, stored := range r.tasks {
+		if stored.UserID == user.ID && stored.WorkspaceID == workspaceID && stored.ChangeSeq > since {
+			changes.Updated = append(changes.Updated, cloneTask(stored))
+		}
+	}
+	sort.Slice(changes.Updated, func(i, j int) bool {
+		return changes.Updated[i].ChangeSeq < changes.Updated[j].ChangeSeq
+	})
+
+	var deleted []tombstoneKey
+	for key, tomb := range r.tombstones {
+		if key.userID == user.ID && tomb.workspaceID == workspaceID && tomb.changeSeq > since &&
+			r.byUID(key.userID, key.uid) == nil {
+			deleted = append(deleted, key)
+		}
+	}
+	sort.Slice(deleted, func(i, j int) bool {
+		return r.tombstones[deleted[i]].changeSeq < r.tombstones[deleted[j]].changeSeq
+	})
+
+	for _, task := range changes.Updated {
+		changes.Token = max(changes.Token, task.ChangeSeq)
+	}
+	for _, key := range deleted {
+		changes.Deleted = append(changes.Deleted, key.uid)
+		changes.Token = max(changes.Token, r.tombstones[key].changeSeq)
+	}
+	return changes, nil
+}
+
+func (r *MemoryRepository) LatestChange(ctx context.Context, user *user.User) (int64, error) {
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var token int64
+	for _, stored := range r.tasks {
+		if stored.UserID == user.ID && stored.WorkspaceID == workspaceID {
+			token = max(token, stored.ChangeSeq)
+		}
+	}
+	for key, tomb := range r.tombstones {
+		if key.userID == user.ID && tomb.workspaceID == workspaceID {
+			token = max(token, tomb.changeSeq)
+		}
+	}
+	return token, nil
+}
+
+// SetProject только убирает задачу из проекта: проектов в памяти нет
+func (r *MemoryRepository) SetProject(ctx context.Context, task *Task) error {
+	if _, err := workspaceFromContext(ctx); err != nil {
+		return err
+	}
+	if task.ProjectID != nil {
+		return ErrProjectNotFound
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := r.editable(ctx, task, rankEditor)
+	if err != nil {
+		return err
+	}
+	stored.ProjectID = nil
+	r.touch(stored)
+	return nil
+}
+
+func (r *MemoryRepository) GetAssigned(ctx context.Context, user *user.User) ([]Task, error) {
+	return r.list(ctx, user.ID, func(task *Task) bool {
+		return task.AssigneeID != nil && *task.AssigneeID == user.ID
+	})
+}
+
+// SetAssignee назначает исполнителем владельца или нынешнего исполнителя:
+// без общего доступа задачу больше никто не видит
+func (r *MemoryRepository) SetAssignee(ctx context.Context, task *Task) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := r.editable(ctx, task, rankEditor)
+	if err != nil {
+		return false, err
+	}
+	if task.AssigneeID != nil && memoryRank(stored, *task.AssigneeID) == 0 {
+		return false, ErrAssigneeNoAccess
+	}
+	if sameAssignee(stored.AssigneeID, task.AssigneeID) {
+		return false, nil
+	}
+
+	stored.AssigneeID = copyOf(task.AssigneeID)
+	r.touch(stored)
+	task.Title = stored.Title
+
+	r.lastAssignmentID++
+	r.assignments = append(r.assignments, Assignment{
+		ID:           r.lastAssignmentID,
+		TaskID:       stored.ID,
+		AssigneeID:   copyOf(task.AssigneeID),
+		AssignedByID: copyOf(&task.UserID),
+		CreatedAt:    time.Now(),
+	})
+	return true, nil
+}
+
+func (r *MemoryRepository) GetAssignments(ctx context.Context, task *Task) ([]Assignment, error) {
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.tasks[task.ID]
+	if !ok || stored.WorkspaceID != workspaceID || memoryRank(stored, task.UserID) == 0 {
+		return nil, ErrTaskNotFound
+	}
+
+	assignments := make([]Assignment, 0)
+	for _, assignment := range r.assignments {
+		if assignment.TaskID != task.ID {
+			continue
+		}
+		assignment.Assignee = r.username(ctx, assignment.AssigneeID)
+		assignment.AssignedBy = r.username(ctx, assignment.AssignedByID)
+		assignments = append(assignments, assignment)
+	}
+	return assignments, nil
+}
+
+// workspaceFromContext - рабочее пространство запроса для хранилищ без db.Tenant
+func workspaceFromContext(ctx context.Context) (int, error) {
+	workspaceID, ok := db.WorkspaceFromContext(ctx)
+	if !ok {
+		return 0, db.ErrNoWorkspace
+	}
+	return workspaceID, nil
+}
+
+// insert сохраняет новую задачу со значениями по умолчанию из схемы tasks
+func (r *MemoryRepository) insert(workspaceID int, task *Task) *Task {
+	r.lastID++
+	task.ID = r.lastID
+	task.WorkspaceID = workspaceID
+	if task.Extras == nil {
+		task.Extras = Extras{}
+	} else {
+		task.Extras = maps.Clone(task.Extras)
+	}
+	r.touch(task)
+	r.tasks[task.ID] = task
+	return task
+}
+
+// touch повторяет триггер tasks_touch: новый номер изменения и время
+func (r *MemoryRepository) touch(task *Task) {
+	r.changeSeq++
+	task.ChangeSeq = r.changeSeq
+	task.UpdatedAt = time.Now()
+}
+
+// delete удаляет задачу и оставляет надгробие, как триггер task_tombstone
+func (r *MemoryRepository) delete(task *Task) {
+	delete(r.tasks, task.ID)
+	r.changeSeq++
+	r.tombstones[tombstoneKey{userID: task.UserID, uid: task.UID}] = tombstone{
+		workspaceID: task.WorkspaceID,
+		changeSeq:   r.changeSeq,
+	}
+
+	assignments := r.assignments[:0]
+	for _, assignment := range r.assignments {
+		if assignment.TaskID != task.ID {
+			assignments = append(assignments, assignment)
+		}
+	}
+	r.assignments = assignments
+}
+
+// editable находит задачу task.ID, которую пользователь task.UserID может менять
+// с рангом не ниже need; иначе ErrTaskNotFound или ErrTaskForbidden, как checkAffected
+func (r *MemoryRepository) editable(ctx context.Context, task *Task, need int) (*Task, error) {
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, ok := r.tasks[task.ID]
+	if !ok || stored.WorkspaceID != workspaceID {
+		return nil, ErrTaskNotFound
+	}
+	switch got := memoryRank(stored, task.UserID); {
+	case got == 0:
+		return nil, ErrTaskNotFound
+	case got < need:
+		return nil, ErrTaskForbidden
+	}
+	return stored, nil
+}
+
+func (r *MemoryRepository) byUID(userID int, uid string) *Task {
+	for _, stored := range r.tasks {
+		if stored.UserID == userID && stored.UID == uid {
+			return stored
+		}
+	}
+	return nil
+}
+
+// list возвращает видимые пользователю задачи пространства из ctx по возрастанию id
+func (r *MemoryRepository) list(ctx context.Context, userID int, match func(task *Task) bool) ([]Task, error) {
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tasks := make([]Task, 0)
+	for _, stored := range r.tasks {
+		if stored.WorkspaceID == workspaceID && memoryRank(stored, userID) > 0 && match(stored) {
+			tasks = append(tasks, r.accessible(stored, userID))
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks, nil
+}
+
+// accessible заполняет поля, которые Repository читает через accessibleTasks
+func (r *MemoryRepository) accessible(stored *Task, userID int) Task {
+	task := cloneTask(stored)
+	task.Role = RoleAssignee
+	if memoryRank(stored, userID) == rankOwner {
+		task.Role = RoleOwner
+	} else {
+		task.SharedBy = r.username(context.Background(), &stored.UserID)
+	}
+	comments := 0
+	task.CommentCount = &comments
+	return task
+}
+
+func (r *MemoryRepository) username(ctx context.Context, id *int) *string {
+	if id == nil {
+		return nil
+	}
+	u, err := r.users.GetByID(ctx, *id)
+	if err != nil {
+		return nil
+	}
+	return &u.Name
+}
+
+// memoryRank - ранг пользователя в задаче, как в task_access: владелец или исполнитель
+func memoryRank(task *Task, userID int) int {
+	switch {
+	case task.UserID == userID:
+		return rankOwner
+	case task.AssigneeID != nil && *task.AssigneeID == userID:
+		return rankViewer
+	}
+	return 0
+}
+
+// completedAt - время выполнения по правилу схемы: первое сохранённое или текущее
+func completedAt(completed bool, at *time.Time) *time.Time {
+	if !completed {
+		return nil
+	}
+	if at != nil {
+		return at
+	}
+	now := time.Now()
+	return &now
+}
+
+func cloneTask(stored *Task) Task {
+	task := *stored
+	task.Extras = maps.Clone(stored.Extras)
+	task.DueAt = copyOf(stored.DueAt)
+	task.CompletedAt = copyOf(stored.CompletedAt)
+	task.ProjectID = copyOf(stored.ProjectID)
+	task.AssigneeID = copyOf(stored.AssigneeID)
+	return task
+}
+
+func sameAssignee(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func copyOf[T any](v *T) *T {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	return &c
+}
@@ -1,9 +1,94 @@
 package task
 
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
 type Task struct {
-	ID          int    `db:"id" json:"id"`
-	UserID      int    `db:"user_id" json:"user_id"`
-	Title       string `db:"title" json:"title"`
-	Description string `db:"description" json:"description"`
-	Completed   bool   `db:"completed" json:"completed"`
+	ID          int        `db:"id" json:"id"`
+	UserID      int        `db:"user_id" json:"user_id"`
+	Title       string     `db:"title" json:"title"`
+	Description string     `db:"description" json:"description"`
+	Completed   bool       `db:"completed" json:"completed"`
+	UID         string     `db:"uid" json:"uid"`
+	DueAt       *time.Time `db:"due_at" json:"due_at"`
+	CompletedAt *time.Time `db:"completed_at" json:"completed_at"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
+	Extras      Extras     `db:"extras" json:"extras,omitempty"`
+	ProjectID   *int       `db:"project_id" json:"project_id,omitempty"`
+	AssigneeID  *int       `db:"assignee_id" json:"assignee_id,omitempty"`
+	WorkspaceID int        `db:"workspace_id" json:"-"`
+	// Role и SharedBy заполняются при чтении через task_access: роль текущего
+	// пользователя и имя того, кто поделился задачей (пусто для своих задач)
+	Role     string  `db:"role" json:"role,omitempty"`
+	SharedBy *string `db:"shared_by" json:"shared_by,omitempty"`
+	// CommentCount - число неудалённых комментариев, заполняется там же
+	CommentCount *int `db:"comment_count" json:"comment_count,omitempty"`
+	// ChangeSeq растёт при каждом изменении задачи, см. task_change_seq
+	ChangeSeq int64 `db:"change_seq" json:"-"`
+}
+
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleOwner  = "owner"
+	// RoleAssignee - исполнитель без общего доступа: может только отметить выполнение
+	RoleAssignee = "assignee"
+)
+
+// CanEdit сообщает, может ли текущий пользователь менять задачу. Задачи,
+// прочитанные без task_access (Role пуст), принадлежат ему самому.
+func (t *Task) CanEdit() bool {
+	return t.Role != RoleViewer && t.Role != RoleAssignee
+}
+
+// Assignment - запись истории назначений задачи; AssigneeID пуст, если исполнителя сняли
+type Assignment struct {
+	ID           int       `db:"id" json:"id"`
+	TaskID       int       `db:"task_id" json:"task_id"`
+	AssigneeID   *int      `db:"assignee_id" json:"assignee_id"`
+	Assignee     *string   `db:"assignee" json:"assignee"`
+	AssignedByID *int      `db:"assigned_by" json:"assigned_by_id"`
+	AssignedBy   *string   `db:"assigned_by_name" json:"assigned_by"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+}
+
+// ChangeSet - изменения задач пользователя после заданного номера изменения
+type ChangeSet struct {
+	Token   int64
+	Updated []Task
+	Deleted []string // UID удалённых задач
+}
+
+// Extras - поля внешних форматов, которых нет в модели задачи (например, приоритет
+// и метки todo.txt). Хранятся как есть, чтобы при обратном экспорте ничего не потерялось.
+type Extras map[string]string
+
+// Value отдаёт JSON строкой: []byte lib/pq передал бы как bytea
+func (e Extras) Value() (driver.Value, error) {
+	if e == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (e *Extras) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*e = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, e)
+	case string:
+		return json.Unmarshal([]byte(v), e)
+	default:
+		return fmt.Errorf("unsupported extras type %T", src)
+	}
 }
@@ -18,3 +18,38 @@ type UpdateRequest struct {
 	Description string `json:"description" binding:"max=500"`
 	Completed   bool   `json:"completed" binding:"required"`
 }
+
+type SetProjectRequest struct {
+	ProjectID *int `json:"project_id" binding:"omitempty,min=1"`
+}
+
+// SetAssigneeRequest назначает исполнителя; null снимает назначение
+type SetAssigneeRequest struct {
+	AssigneeID *int `json:"assignee_id" binding:"omitempty,min=1"`
+}
+
+type ListQuery struct {
+	Assignee string `form:"assignee" binding:"omitempty,oneof=me"`
+}
+
+type ExportQuery struct {
+	Format string `form:"format"`
+}
+
+type ImportQuery struct {
+	Format string `form:"format"`
+	DryRun bool   `form:"dry_run"`
+}
+
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+type ImportReport struct {
+	DryRun     bool             `json:"dry_run"`
+	Total      int              `json:"total"`
+	Created    int              `json:"created"`
+	Duplicates []int            `json:"duplicates"`
+	Errors     []ImportRowError `json:"errors"`
+}
@@ -1,19 +1,59 @@
 package task
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"github.com/jmoiron/sqlx"
 	"github.com/melnik-dev/go_todo_jwt/internal/user"
 	"github.com/melnik-dev/go_todo_jwt/pkg/db"
 	"github.com/sirupsen/logrus"
 )
 
+// Ранги ролей в task_access и project_access. Исполнитель получает rankViewer:
+// отметить выполнение ему разрешает отдельное условие в Update.
+const (
+	rankViewer = 1
+	rankEditor = 2
+	rankOwner  = 3
+)
+
+// accessibleTasks выбирает задачи, доступные пользователю $1, вместе с его лучшей
+// ролью, именем того, кто поделился задачей, и числом комментариев
+const accessibleTasks = `WITH access AS (
+		SELECT DISTINCT ON (task_id) task_id, rank, shared_by_id
+		FROM task_access WHERE user_id = $1
+		ORDER BY task_id, rank DESC, shared_by_id NULLS FIRST)
+	SELECT t.*,
+		CASE a.rank WHEN 3 THEN 'owner' WHEN 2 THEN 'editor'
+			ELSE CASE WHEN t.assignee_id = $1 THEN 'assignee' ELSE 'viewer' END END AS role,
+		u.username AS shared_by,
+		(SELECT COUNT(*) FROM comments c WHERE c.task_id = t.id AND c.deleted_at IS NULL) AS comment_count
+	FROM access a
+	JOIN tasks t ON t.id = a.task_id
+	LEFT JOIN users u ON u.id = a.shared_by_id`
+
+// Все методы работают в рабочем пространстве из ctx (db.WithWorkspace):
+// запросы выполняются через db.Tenant, и задачи других пространств
+// отсекают политики RLS, а не условия в самих запросах. Задачи и проекты,
+// которыми поделились с пользователем, видны в его личном пространстве.
 type IRepository interface {
-	Create(task *Task) (*Task, error)
-	Update(task *Task) error
-	DeleteById(task *Task) error
-	GetById(task *Task) (*Task, error)
-	GetAll(user *user.User) ([]Task, error)
+	Create(ctx context.Context, task *Task) (*Task, error)
+	Update(ctx context.Context, task *Task) error
+	DeleteById(ctx context.Context, task *Task) error
+	GetById(ctx context.Context, task *Task) (*Task, error)
+	GetAll(ctx context.Context, user *user.User) ([]Task, error)
+	Each(ctx context.Context, user *user.User, fn func(task *Task) error) error
+	CreateBatch(ctx context.Context, tasks []Task) error
+	UpsertBatch(ctx context.Context, tasks []Task) (int, error)
+	GetByUID(ctx context.Context, task *Task) (*Task, error)
+	DeleteByUID(ctx context.Context, task *Task) error
+	GetChanges(ctx context.Context, user *user.User, since int64) (*ChangeSet, error)
+	LatestChange(ctx context.Context, user *user.User) (int64, error)
+	SetProject(ctx context.Context, task *Task) error
+	GetAssigned(ctx context.Context, user *user.User) ([]Task, error)
+	SetAssignee(ctx context.Context, task *Task) (bool, error)
+	GetAssignments(ctx context.Context, task *Task) ([]Assignment, error)
 }
 
 type Repository struct {
@@ -28,7 +68,7 @@ func NewRepository(db *db.Db, logger *logrus.Logger) *Repository {
 	}
 }
 
-func (r *Repository) Create(task *Task) (*Task, error) {
+func (r *Repository) Create(ctx context.Context, task *Task) (*Task, error) {
 	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
 		"user_id": task.UserID,
 		"title":   task.Title,
@@ -40,8 +80,10 @@ func (r *Repository) Create(task *Task) (*Task, error) {
 				VALUES ($1, $2, $3) 
 				RETURNING id`
 
-	row := r.db.QueryRow(query, task.UserID, task.Title, task.Description)
-	if err := row.Scan(&id); err != nil {
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		return tx.QueryRowContext(ctx, query, task.UserID, task.Title, task.Description).Scan(&id)
+	})
+	if err != nil {
 		logRepo.WithError(err).Error("Failed to insert database")
 		return nil, err
 	}
@@ -51,7 +93,7 @@ func (r *Repository) Create(task *Task) (*Task, error) {
 	return task, nil
 }
 
-func (r *Repository) Update(task *Task) error {
+func (r *Repository) Update(ctx context.Context, task *Task) error {
 	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
 		"user_id": task.UserID,
 		"task_id": task.ID,
@@ -59,70 +101,75 @@ func (r *Repository) Update(task *Task) error {
 	logRepo.Debug("Attempting to Update")
 
 	query := `UPDATE tasks 
-				SET title = $1, description = $2, completed = $3 
-				WHERE id = $4 AND user_id = $5`
+				SET title = $1, description = $2, completed = $3,
+					completed_at = CASE WHEN $3 THEN COALESCE(completed_at, NOW()) END
+				WHERE id = $4 AND (EXISTS (
+					SELECT 1 FROM task_access a WHERE a.task_id = tasks.id AND a.user_id = $5 AND a.rank >= $6)
+					OR assignee_id = $5 AND title = $1 AND description = $2)`
 
-	result, err := r.db.Exec(query, task.Title, task.Description, task.Completed, task.ID, task.UserID)
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, query, task.Title, task.Description, task.Completed, task.ID, task.UserID, rankEditor)
+		if err != nil {
+			return err
+		}
+		return r.checkAffected(ctx, tx, result, task)
+	})
 	if err != nil {
+		if errors.Is(err, ErrTaskNotFound) || errors.Is(err, ErrTaskForbidden) {
+			logRepo.WithError(err).Warn("Task was not updated")
+			return err
+		}
 		logRepo.WithError(err).Error("Failed to Update database")
 		return err
 	}
 
-	row, err := result.RowsAffected()
-	if err != nil {
-		logRepo.WithError(err).Error("Failed rows affected by Update database")
-		return err
-	}
-
-	if row == 0 {
-		logRepo.WithError(err).Warn(ErrTaskNotFound.Error())
-		return ErrTaskNotFound
-	}
-
 	logRepo.Debug("Update database successfully")
 	return nil
 }
 
-func (r *Repository) DeleteById(task *Task) error {
+func (r *Repository) DeleteById(ctx context.Context, task *Task) error {
 	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
 		"user_id": task.UserID,
 		"task_id": task.ID,
 	})
 	logRepo.Debug("Attempting to Delete")
 
-	query := `DELETE FROM tasks WHERE id = $1 AND user_id = $2`
+	query := `DELETE FROM tasks
+				WHERE id = $1 AND EXISTS (
+					SELECT 1 FROM task_access a WHERE a.task_id = tasks.id AND a.user_id = $2 AND a.rank >= $3)`
 
-	result, err := r.db.Exec(query, task.ID, task.UserID)
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, query, task.ID, task.UserID, rankOwner)
+		if err != nil {
+			return err
+		}
+		return r.checkAffected(ctx, tx, result, task)
+	})
 	if err != nil {
+		if errors.Is(err, ErrTaskNotFound) || errors.Is(err, ErrTaskForbidden) {
+			logRepo.WithError(err).Warn("Task was not deleted")
+			return err
+		}
 		logRepo.WithError(err).Error("Failed to Delete database")
 		return err
 	}
 
-	row, err := result.RowsAffected()
-	if err != nil {
-		logRepo.WithError(err).Error("Failed rows affected by Delete database")
-		return err
-	}
-
-	if row == 0 {
-		logRepo.WithError(err).Warn(ErrTaskNotFound.Error())
-		return ErrTaskNotFound
-	}
-
 	logRepo.Debug("Delete database successfully")
 	return nil
 }
 
-func (r *Repository) GetById(task *Task) (*Task, error) {
+func (r *Repository) GetById(ctx context.Context, task *Task) (*Task, error) {
 	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
 		"user_id": task.UserID,
 		"task_id": task.ID,
 	})
 	logRepo.Debug("Attempting to GetById")
 
-	query := `SELECT * FROM tasks WHERE id = $1 AND user_id = $2`
+	query := accessibleTasks + ` WHERE t.id = $2`
 
-	err := r.db.Get(task, query, task.ID, task.UserID)
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		return tx.GetContext(ctx, task, query, task.UserID, task.ID)
+	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			logRepo.WithError(err).Warn(ErrTaskNotFound.Error())
@@ -136,16 +183,18 @@ func (r *Repository) GetById(task *Task) (*Task, error) {
 	return task, nil
 }
 
-func (r *Repository) GetAll(user *user.User) ([]Task, error) {
+func (r *Repository) GetAll(ctx context.Context, user *user.User) ([]Task, error) {
 	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
 		"user_id": user.ID,
 	})
 	logRepo.Debug("Attempting to GetAll")
 
 	tasks := make([]Task, 0)
-	query := `SELECT * FROM tasks WHERE user_id = $1`
+	query := accessibleTasks + ` ORDER BY t.id`
 
-	err := r.db.Select(&tasks, query, user.ID)
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		return tx.SelectContext(ctx, &tasks, query, user.ID)
+	})
 	if err != nil {
 		logRepo.WithError(err).Error("Failed to GetAll database")
 		return nil, err
@@ -155,6 +204,476 @@ func (r *Repository) GetAll(user *user.User) ([]Task, error) {
 	return tasks, err
 }
 
+// Each построчно читает доступные пользователю задачи и передаёт их в fn, не загружая весь список в память
+func (r *Repository) Each(ctx context.Context, user *user.User, fn func(task *Task) error) error {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": user.ID,
+	})
+	logRepo.Debug("Attempting to Each")
+
+	query := accessibleTasks + ` ORDER BY t.id`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		rows, err := tx.QueryxContext(ctx, query, user.ID)
+		if err != nil {
+			logRepo.WithError(err).Error("Failed to Each database")
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var task Task
+			if err := rows.StructScan(&task); err != nil {
+				logRepo.WithError(err).Error("Failed to scan task")
+				return err
+			}
+			if err := fn(&task); err != nil {
+				return err
+			}
+		}
+		if err := rows.Err(); err != nil {
+			logRepo.WithError(err).Error("Failed to iterate tasks")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logRepo.Debug("Each database successfully")
+	return nil
+}
+
+// CreateBatch создаёт задачи в одной транзакции: либо все, либо ни одной
+func (r *Repository) CreateBatch(ctx context.Context, tasks []Task) error {
+	logRepo := repositoryLogger(r.logger).WithField("count", len(tasks))
+	logRepo.Debug("Attempting to CreateBatch")
+
+	query := `INSERT INTO tasks (user_id, title, description, completed, due_at, completed_at, extras)
+				VALUES ($1, $2, $3, $4, $5, CASE WHEN $4 THEN COALESCE($6, NOW()) END, COALESCE($7, '{}'::jsonb))
+				RETURNING id`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		stmt, err := tx.PreparexContext(ctx, query)
+		if err != nil {
+			logRepo.WithError(err).Error("Failed to prepare insert")
+			return err
+		}
+		defer stmt.Close()
+
+		for i := range tasks {
+			task := &tasks[i]
+			row := stmt.QueryRowContext(ctx, task.UserID, task.Title, task.Description, task.Completed, task.DueAt, task.CompletedAt, task.Extras)
+			if err := row.Scan(&task.ID); err != nil {
+				logRepo.WithError(err).Error("Failed to insert database")
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to CreateBatch database")
+		return err
+	}
+
+	logRepo.Debug("CreateBatch database successfully")
+	return nil
+}
+
+// UpsertBatch создаёт или обновляет задачи по UID в одной транзакции.
+// UID уникален только у владельца, поэтому методы по UID и журнал изменений
+// работают с собственными задачами пользователя, без учёта общего доступа.
+// Возвращает количество созданных задач.
+func (r *Repository) UpsertBatch(ctx context.Context, tasks []Task) (int, error) {
+	logRepo := repositoryLogger(r.logger).WithField("count", len(tasks))
+	logRepo.Debug("Attempting to UpsertBatch")
+
+	// extras без значения (NULL) у существующей задачи не трогаются: форматы,
+	// которые их не знают, не должны стирать чужие поля
+	query := `INSERT INTO tasks (user_id, uid, title, description, completed, due_at, completed_at, extras)
+				VALUES ($1, $2, $3, $4, $5, $6, CASE WHEN $5 THEN COALESCE($7, NOW()) END, COALESCE($8, '{}'::jsonb))
+				ON CONFLICT (user_id, uid) DO UPDATE
+				SET title = EXCLUDED.title, description = EXCLUDED.description, completed = EXCLUDED.completed,
+					due_at = EXCLUDED.due_at, completed_at = EXCLUDED.completed_at,
+					extras = COALESCE($8, tasks.extras)
+				RETURNING id, change_seq, (xmax = 0) AS inserted`
+
+	created := 0
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		stmt, err := tx.PreparexContext(ctx, query)
+		if err != nil {
+			logRepo.WithError(err).Error("Failed to prepare upsert")
+			return err
+		}
+		defer stmt.Close()
+
+		for i := range tasks {
+			task := &tasks[i]
+			var inserted bool
+			row := stmt.QueryRowContext(ctx, task.UserID, task.UID, task.Title, task.Description, task.Completed, task.DueAt, task.CompletedAt, task.Extras)
+			if err := row.Scan(&task.ID, &task.ChangeSeq, &inserted); err != nil {
+				logRepo.WithError(err).Error("Failed to upsert database")
+				return err
+			}
+			if inserted {
+				created++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to UpsertBatch database")
+		return 0, err
+	}
+
+	logRepo.WithField("created", created).Debug("UpsertBatch database successfully")
+	return created, nil
+}
+
+func (r *Repository) GetByUID(ctx context.Context, task *Task) (*Task, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": task.UserID,
+		"uid":     task.UID,
+	})
+	logRepo.Debug("Attempting to GetByUID")
+
+	query := `SELECT * FROM tasks WHERE uid = $1 AND user_id = $2`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		return tx.GetContext(ctx, task, query, task.UID, task.UserID)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logRepo.WithError(err).Warn(ErrTaskNotFound.Error())
+			return nil, ErrTaskNotFound
+		}
+		logRepo.WithError(err).Error("Failed to GetByUID database")
+		return nil, err
+	}
+
+	logRepo.Debug("GetByUID database successfully")
+	return task, nil
+}
+
+func (r *Repository) DeleteByUID(ctx context.Context, task *Task) error {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": task.UserID,
+		"uid":     task.UID,
+	})
+	logRepo.Debug("Attempting to DeleteByUID")
+
+	query := `DELETE FROM tasks WHERE uid = $1 AND user_id = $2`
+
+	var row int64
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, query, task.UID, task.UserID)
+		if err != nil {
+			return err
+		}
+		row, err = result.RowsAffected()
+		return err
+	})
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to DeleteByUID database")
+		return err
+	}
+
+	if row == 0 {
+		logRepo.Warn(ErrTaskNotFound.Error())
+		return ErrTaskNotFound
+	}
+
+	logRepo.Debug("DeleteByUID database successfully")
+	return nil
+}
+
+// GetChanges возвращает задачи, изменённые после since, и UID удалённых после since задач.
+// Token - номер последнего изменения, его клиент передаёт в следующий раз.
+func (r *Repository) GetChanges(ctx context.Context, user *user.User, since int64) (*ChangeSet, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": user.ID,
+		"since":   since,
+	})
+	logRepo.Debug("Attempting to GetChanges")
+
+	changes := &ChangeSet{
+		Token:   since,
+		Updated: make([]Task, 0),
+		Deleted: make([]string, 0),
+	}
+
+	var tombstones []struct {
+		UID       string `db:"uid"`
+		ChangeSeq int64  `db:"change_seq"`
+	}
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		query := `SELECT * FROM tasks WHERE user_id = $1 AND change_seq > $2 ORDER BY change_seq`
+
+		if err := tx.SelectContext(ctx, &changes.Updated, query, user.ID, since); err != nil {
+			logRepo.WithError(err).Error("Failed to GetChanges tasks database")
+			return err
+		}
+
+		query = `SELECT t.uid, t.change_seq FROM task_tombstones t
+				WHERE t.user_id = $1 AND t.change_seq > $2
+				AND NOT EXISTS (SELECT 1 FROM tasks WHERE tasks.user_id = t.user_id AND tasks.uid = t.uid)
+				ORDER BY t.change_seq`
+
+		if err := tx.SelectContext(ctx, &tombstones, query, user.ID, since); err != nil {
+			logRepo.WithError(err).Error("Failed to GetChanges tombstones database")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, task := range changes.Updated {
+		changes.Token = max(changes.Token, task.ChangeSeq)
+	}
+	for _, tombstone := range tombstones {
+		changes.Deleted = append(changes.Deleted, tombstone.UID)
+		changes.Token = max(changes.Token, tombstone.ChangeSeq)
+	}
+
+	logRepo.Debug("GetChanges database successfully")
+	return changes, nil
+}
+
+// LatestChange возвращает номер последнего изменения задач пользователя, включая удаления
+func (r *Repository) LatestChange(ctx context.Context, user *user.User) (int64, error) {
+	logRepo := repositoryLogger(r.logger).WithField("user_id", user.ID)
+	logRepo.Debug("Attempting to LatestChange")
+
+	var token int64
+	query := `SELECT GREATEST(
+				(SELECT COALESCE(MAX(change_seq), 0) FROM tasks WHERE user_id = $1),
+				(SELECT COALESCE(MAX(change_seq), 0) FROM task_tombstones WHERE user_id = $1))`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		return tx.GetContext(ctx, &token, query, user.ID)
+	})
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to LatestChange database")
+		return 0, err
+	}
+
+	logRepo.Debug("LatestChange database successfully")
+	return token, nil
+}
+
 func repositoryLogger(l *logrus.Logger) *logrus.Entry {
 	return l.WithField("layer", "Repository task layer")
 }
+
+// SetProject переносит задачу в проект (или убирает из проекта, если ProjectID пуст).
+// Нужны права редактора и на задачу, и на проект.
+func (r *Repository) SetProject(ctx context.Context, task *Task) error {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": task.UserID,
+		"task_id": task.ID,
+	})
+	logRepo.Debug("Attempting to SetProject")
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		if task.ProjectID != nil {
+			var rank int
+			query := `SELECT COALESCE(MAX(rank), 0) FROM project_access WHERE project_id = $1 AND user_id = $2`
+			if err := tx.GetContext(ctx, &rank, query, *task.ProjectID, task.UserID); err != nil {
+				return err
+			}
+			if rank < rankEditor {
+				return ErrProjectNotFound
+			}
+		}
+
+		query := `UPDATE tasks SET project_id = $1
+				WHERE id = $2 AND EXISTS (
+					SELECT 1 FROM task_access a WHERE a.task_id = tasks.id AND a.user_id = $3 AND a.rank >= $4)`
+
+		result, err := tx.ExecContext(ctx, query, task.ProjectID, task.ID, task.UserID, rankEditor)
+		if err != nil {
+			return err
+		}
+		return r.checkAffected(ctx, tx, result, task)
+	})
+	if err != nil {
+		if errors.Is(err, ErrProjectNotFound) || errors.Is(err, ErrTaskNotFound) || errors.Is(err, ErrTaskForbidden) {
+			logRepo.WithError(err).Warn("Task was not moved")
+			return err
+		}
+		logRepo.WithError(err).Error("Failed to SetProject database")
+		return err
+	}
+
+	logRepo.Debug("SetProject database successfully")
+	return nil
+}
+
+// GetAssigned возвращает доступные пользователю задачи, где он исполнитель
+func (r *Repository) GetAssigned(ctx context.Context, user *user.User) ([]Task, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": user.ID,
+	})
+	logRepo.Debug("Attempting to GetAssigned")
+
+	tasks := make([]Task, 0)
+	query := accessibleTasks + ` WHERE t.assignee_id = $1 ORDER BY t.id`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		return tx.SelectContext(ctx, &tasks, query, user.ID)
+	})
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to GetAssigned database")
+		return nil, err
+	}
+
+	logRepo.Debug("GetAssigned database successfully")
+	return tasks, nil
+}
+
+// SetAssignee назначает задаче исполнителя task.AssigneeID (или снимает, если он пуст)
+// и пишет запись в историю. Назначать могут редакторы и владельцы, а исполнитель
+// должен видеть задачу или состоять в её общем рабочем пространстве.
+// Возвращает false, если исполнитель не изменился; тогда в task.Title пусто.
+func (r *Repository) SetAssignee(ctx context.Context, task *Task) (bool, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": task.UserID,
+		"task_id": task.ID,
+	})
+	logRepo.Debug("Attempting to SetAssignee")
+
+	changed := false
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		rank, err := r.rank(ctx, tx, task.ID, task.UserID)
+		if err != nil {
+			return err
+		}
+		if rank == 0 {
+			return ErrTaskNotFound
+		}
+		if rank < rankEditor {
+			return ErrTaskForbidden
+		}
+
+		if task.AssigneeID != nil {
+			var allowed bool
+			query := `SELECT EXISTS (SELECT 1 FROM task_access WHERE task_id = $1 AND user_id = $2)
+					OR EXISTS (
+						SELECT 1 FROM tasks t
+						JOIN workspaces w ON w.id = t.workspace_id
+						JOIN workspace_members m ON m.workspace_id = w.id
+						WHERE t.id = $1 AND m.user_id = $2 AND w.personal_user_id IS NULL)`
+			if err = tx.GetContext(ctx, &allowed, query, task.ID, *task.AssigneeID); err != nil {
+				return err
+			}
+			if !allowed {
+				return ErrAssigneeNoAccess
+			}
+		}
+
+		query := `UPDATE tasks SET assignee_id = $1
+				WHERE id = $2 AND assignee_id IS DISTINCT FROM $1
+				RETURNING title`
+		err = tx.GetContext(ctx, &task.Title, query, task.AssigneeID, task.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		query = `INSERT INTO task_assignments (task_id, assignee_id, assigned_by) VALUES ($1, $2, $3)`
+		if _, err = tx.ExecContext(ctx, query, task.ID, task.AssigneeID, task.UserID); err != nil {
+			return err
+		}
+		changed = true
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrTaskNotFound) || errors.Is(err, ErrTaskForbidden) || errors.Is(err, ErrAssigneeNoAccess) {
+			logRepo.WithError(err).Warn("Task was not assigned")
+			return false, err
+		}
+		logRepo.WithError(err).Error("Failed to SetAssignee database")
+		return false, err
+	}
+
+	logRepo.WithField("changed", changed).Debug("SetAssignee database successfully")
+	return changed, nil
+}
+
+// GetAssignments возвращает историю назначений задачи, видимой пользователю
+func (r *Repository) GetAssignments(ctx context.Context, task *Task) ([]Assignment, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": task.UserID,
+		"task_id": task.ID,
+	})
+	logRepo.Debug("Attempting to GetAssignments")
+
+	assignments := make([]Assignment, 0)
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		rank, err := r.rank(ctx, tx, task.ID, task.UserID)
+		if err != nil {
+			return err
+		}
+		if rank == 0 {
+			return ErrTaskNotFound
+		}
+
+		query := `SELECT a.id, a.task_id, a.assignee_id, u.username AS assignee,
+					a.assigned_by, b.username AS assigned_by_name, a.created_at
+				FROM task_assignments a
+				LEFT JOIN users u ON u.id = a.assignee_id
+				LEFT JOIN users b ON b.id = a.assigned_by
+				WHERE a.task_id = $1
+				ORDER BY a.id`
+		return tx.SelectContext(ctx, &assignments, query, task.ID)
+	})
+	if err != nil {
+		if errors.Is(err, ErrTaskNotFound) {
+			logRepo.Warn(ErrTaskNotFound.Error())
+			return nil, err
+		}
+		logRepo.WithError(err).Error("Failed to GetAssignments database")
+		return nil, err
+	}
+
+	logRepo.Debug("GetAssignments database successfully")
+	return assignments, nil
+}
+
+// checkAffected объясняет, почему запрос не затронул задачу: она не видна пользователю
+// (ErrTaskNotFound) или видна, но прав не хватает (ErrTaskForbidden)
+func (r *Repository) checkAffected(ctx context.Context, tx *sqlx.Tx, result sql.Result, task *Task) error {
+	row, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if row > 0 {
+		return nil
+	}
+
+	rank, err := r.rank(ctx, tx, task.ID, task.UserID)
+	if err != nil {
+		return err
+	}
+	if rank > 0 {
+		return ErrTaskForbidden
+	}
+	return ErrTaskNotFound
+}
+
+// rank возвращает лучшую роль пользователя в задаче (0 - задача не видна)
+func (r *Repository) rank(ctx context.Context, tx *sqlx.Tx, taskID, userID int) (int, error) {
+	var rank int
+	query := `SELECT COALESCE(MAX(rank), 0) FROM task_access WHERE task_id = $1 AND user_id = $2`
+
+	if err := tx.GetContext(ctx, &rank, query, taskID, userID); err != nil {
+		return 0, err
+	}
+	return rank, nil
+}
@@ -1,6 +1,8 @@
 package task_test
 
 import (
+	"context"
+	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
 	"github.com/melnik-dev/go_todo_jwt/internal/task"
@@ -30,19 +32,33 @@ func mockDB() (*task.Repository, sqlmock.Sqlmock, error) {
 	return repo, mock, err
 }
 
+// ctx - запрос в рабочем пространстве 1
+var ctx = db.WithWorkspace(context.Background(), 1)
+
+// expectTenant ожидает начало транзакции db.Tenant с переключением роли и пространства
+func expectTenant(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT set_config('role', $1, true), set_config('app.workspace_id', $2, true)`)).
+		WithArgs(db.TenantRole, "1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
 func TestTaskRepository_Create_Success(t *testing.T) {
 	repo, mock, err := mockDB()
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	expectTenant(mock)
 	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO tasks (user_id, title, description)
 				VALUES ($1, $2, $3) 
 				RETURNING id`)).
 		WithArgs(42, "test_title", "test_desc").
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
-	exp, err := repo.Create(&task.Task{
+	mock.ExpectCommit()
+
+	exp, err := repo.Create(ctx, &task.Task{
 		UserID:      42,
 		Title:       "test_title",
 		Description: "test_desc",
@@ -66,11 +82,14 @@ func TestTaskRepository_Create_Fail(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	expectTenant(mock)
 	mock.ExpectQuery(`INSERT INTO tasks`).
 		WithArgs(42, "test_title", "test_desc").
 		WillReturnError(sqlmock.ErrCancelled)
 
-	_, err = repo.Create(&task.Task{
+	mock.ExpectRollback()
+
+	_, err = repo.Create(ctx, &task.Task{
 		UserID:      42,
 		Title:       "test_title",
 		Description: "test_desc",
@@ -90,13 +109,16 @@ func TestTaskRepository_Update_Success(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	mock.ExpectExec(regexp.QuoteMeta(`UPDATE tasks 
-				SET title = $1, description = $2, completed = $3 
-				WHERE id = $4 AND user_id = $5`)).
-		WithArgs("test_title", "test_desc", true, 1, 42).
+	expectTenant(mock)
+	mock.ExpectExec(`UPDATE tasks\s+SET title = \$1, description = \$2, completed = \$3,.+`+
+		`WHERE id = \$4 AND \(EXISTS \(\s+SELECT 1 FROM task_access a WHERE a.task_id = tasks.id AND a.user_id = \$5 AND a.rank >= \$6\)\s+`+
+		`OR assignee_id = \$5 AND title = \$1 AND description = \$2\)`).
+		WithArgs("test_title", "test_desc", true, 1, 42, 2).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	err = repo.Update(&task.Task{
+	mock.ExpectCommit()
+
+	err = repo.Update(ctx, &task.Task{
 		ID:          1,
 		UserID:      42,
 		Title:       "test_title",
@@ -118,11 +140,14 @@ func TestTaskRepository_Get_Fail(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	expectTenant(mock)
 	mock.ExpectExec(`UPDATE tasks`).
-		WithArgs("test_title", "test_desc", true, 1, 42).
+		WithArgs("test_title", "test_desc", true, 1, 42, 2).
 		WillReturnError(sqlmock.ErrCancelled)
 
-	err = repo.Update(&task.Task{
+	mock.ExpectRollback()
+
+	err = repo.Update(ctx, &task.Task{
 		ID:          1,
 		UserID:      42,
 		Title:       "test_title",
@@ -144,11 +169,14 @@ func TestTaskRepository_DeleteById_Success(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM tasks WHERE id = $1 AND user_id = $2`)).
-		WithArgs(1, 42).
+	expectTenant(mock)
+	mock.ExpectExec(`DELETE FROM tasks\s+WHERE id = \$1 AND EXISTS \(\s+SELECT 1 FROM task_access a WHERE a.task_id = tasks.id AND a.user_id = \$2 AND a.rank >= \$3\)`).
+		WithArgs(1, 42, 3).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	err = repo.DeleteById(&task.Task{
+	mock.ExpectCommit()
+
+	err = repo.DeleteById(ctx, &task.Task{
 		ID:     1,
 		UserID: 42,
 	})
@@ -167,11 +195,14 @@ func TestTaskRepository_DeleteById_Fail(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	expectTenant(mock)
 	mock.ExpectExec(`DELETE FROM tasks`).
-		WithArgs(1, 42).
+		WithArgs(1, 42, 3).
 		WillReturnError(sqlmock.ErrCancelled)
 
-	err = repo.DeleteById(&task.Task{
+	mock.ExpectRollback()
+
+	err = repo.DeleteById(ctx, &task.Task{
 		ID:     1,
 		UserID: 42,
 	})
@@ -190,12 +221,15 @@ func TestTaskRepository_GetById_Success(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM tasks WHERE id = $1 AND user_id = $2`)).
-		WithArgs(1, 42).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title", "description", "completed"}).
-			AddRow(1, 42, "test_title", "test_desc", true))
+	expectTenant(mock)
+	mock.ExpectQuery(`FROM task_access WHERE user_id = \$1.+WHERE t.id = \$2`).
+		WithArgs(42, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title", "description", "completed", "role", "shared_by"}).
+			AddRow(1, 42, "test_title", "test_desc", true, "owner", nil))
 
-	exp, err := repo.GetById(&task.Task{
+	mock.ExpectCommit()
+
+	exp, err := repo.GetById(ctx, &task.Task{
 		ID:          1,
 		UserID:      42,
 		Title:       "test_title",
@@ -225,11 +259,14 @@ func TestTaskRepository_GetById_Fail(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM tasks`)).
-		WithArgs(1, 42).
+	expectTenant(mock)
+	mock.ExpectQuery(`FROM task_access`).
+		WithArgs(42, 1).
 		WillReturnError(sqlmock.ErrCancelled)
 
-	_, err = repo.GetById(&task.Task{
+	mock.ExpectRollback()
+
+	_, err = repo.GetById(ctx, &task.Task{
 		ID:     1,
 		UserID: 42,
 	})
@@ -248,22 +285,26 @@ func TestTaskRepository_GetAll_Success(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM tasks WHERE user_id = $1`)).
+	expectTenant(mock)
+	mock.ExpectQuery(`FROM task_access WHERE user_id = \$1.+ORDER BY t.id`).
 		WithArgs(42).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title", "description", "completed"}).
-			AddRow(1, 42, "test_title_1", "test_desc_1", true).
-			AddRow(2, 42, "test_title_2", "test_desc_2", false))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title", "description", "completed", "role", "shared_by"}).
+			AddRow(1, 42, "test_title_1", "test_desc_1", true, "owner", nil).
+			AddRow(2, 7, "test_title_2", "test_desc_2", false, "viewer", "bob"))
+
+	mock.ExpectCommit()
 
-	exp, err := repo.GetAll(&user.User{
+	exp, err := repo.GetAll(ctx, &user.User{
 		ID: 42,
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	sharedBy := "bob"
 	expect := []task.Task{
-		{ID: 1, UserID: 42, Title: "test_title_1", Description: "test_desc_1", Completed: true},
-		{ID: 2, UserID: 42, Title: "test_title_2", Description: "test_desc_2", Completed: false},
+		{ID: 1, UserID: 42, Title: "test_title_1", Description: "test_desc_1", Completed: true, Role: task.RoleOwner},
+		{ID: 2, UserID: 7, Title: "test_title_2", Description: "test_desc_2", Completed: false, Role: task.RoleViewer, SharedBy: &sharedBy},
 	}
 
 	if !reflect.DeepEqual(exp, expect) {
@@ -281,11 +322,14 @@ func TestTaskRepository_GetAll_Fail(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM tasks WHERE user_id = $1`)).
+	expectTenant(mock)
+	mock.ExpectQuery(`FROM task_access`).
 		WithArgs(42).
 		WillReturnError(sqlmock.ErrCancelled)
 
-	_, err = repo.GetAll(&user.User{
+	mock.ExpectRollback()
+
+	_, err = repo.GetAll(ctx, &user.User{
 		ID: 42,
 	})
 	if err == nil {
@@ -296,3 +340,443 @@ func TestTaskRepository_GetAll_Fail(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestTaskRepository_Each_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "title", "description", "completed"}).
+		AddRow(1, 42, "test_title_1", "test_desc_1", true).
+		AddRow(2, 42, "test_title_2", "test_desc_2", false)
+
+	expectTenant(mock)
+	mock.ExpectQuery(`FROM task_access WHERE user_id = \$1.+ORDER BY t.id`).
+		WithArgs(42).
+		WillReturnRows(rows)
+
+	var ids []int
+	mock.ExpectCommit()
+
+	err = repo.Each(ctx, &user.User{ID: 42}, func(task *task.Task) error {
+		ids = append(ids, task.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(ids, []int{1, 2}) {
+		t.Errorf("Expected ids [1 2], got %v", ids)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTaskRepository_CreateBatch_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectTenant(mock)
+	prep := mock.ExpectPrepare(`INSERT INTO tasks`)
+	prep.ExpectQuery().
+		WithArgs(42, "test_title_1", "test_desc_1", false, nil, nil, `{"todotxt.priority":"A"}`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	prep.ExpectQuery().
+		WithArgs(42, "test_title_2", "test_desc_2", true, nil, nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectCommit()
+
+	tasks := []task.Task{
+		{UserID: 42, Title: "test_title_1", Description: "test_desc_1", Extras: task.Extras{"todotxt.priority": "A"}},
+		{UserID: 42, Title: "test_title_2", Description: "test_desc_2", Completed: true},
+	}
+	if err = repo.CreateBatch(ctx, tasks); err != nil {
+		t.Fatal(err)
+	}
+
+	if tasks[0].ID != 1 || tasks[1].ID != 2 {
+		t.Errorf("Expected ids 1 and 2, got %d and %d", tasks[0].ID, tasks[1].ID)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTaskRepository_CreateBatch_Rollback(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectTenant(mock)
+	prep := mock.ExpectPrepare(`INSERT INTO tasks`)
+	prep.ExpectQuery().
+		WithArgs(42, "test_title_1", "", false, nil, nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	prep.ExpectQuery().
+		WithArgs(42, "test_title_2", "", false, nil, nil, nil).
+		WillReturnError(sqlmock.ErrCancelled)
+	mock.ExpectRollback()
+
+	err = repo.CreateBatch(ctx, []task.Task{
+		{UserID: 42, Title: "test_title_1"},
+		{UserID: 42, Title: "test_title_2"},
+	})
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTaskRepository_UpsertBatch_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectTenant(mock)
+	prep := mock.ExpectPrepare(`INSERT INTO tasks .* ON CONFLICT \(user_id, uid\) DO UPDATE`)
+	prep.ExpectQuery().
+		WithArgs(42, "uid-1", "test_title_1", "", false, nil, nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "change_seq", "inserted"}).AddRow(1, 10, true))
+	prep.ExpectQuery().
+		WithArgs(42, "uid-2", "test_title_2", "", true, nil, nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "change_seq", "inserted"}).AddRow(2, 11, false))
+	mock.ExpectCommit()
+
+	created, err := repo.UpsertBatch(ctx, []task.Task{
+		{UserID: 42, UID: "uid-1", Title: "test_title_1"},
+		{UserID: 42, UID: "uid-2", Title: "test_title_2", Completed: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if created != 1 {
+		t.Errorf("Expected 1 created task, got %d", created)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTaskRepository_GetByUID_FailNotFound(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectTenant(mock)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM tasks WHERE uid = $1 AND user_id = $2`)).
+		WithArgs("uid-1", 42).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	mock.ExpectRollback()
+
+	_, err = repo.GetByUID(ctx, &task.Task{UID: "uid-1", UserID: 42})
+	if err != task.ErrTaskNotFound {
+		t.Fatalf("Expected %v, got %v", task.ErrTaskNotFound, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTaskRepository_DeleteByUID_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectTenant(mock)
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM tasks WHERE uid = $1 AND user_id = $2`)).
+		WithArgs("uid-1", 42).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectCommit()
+
+	if err = repo.DeleteByUID(ctx, &task.Task{UID: "uid-1", UserID: 42}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTaskRepository_GetChanges_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectTenant(mock)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM tasks WHERE user_id = $1 AND change_seq > $2 ORDER BY change_seq`)).
+		WithArgs(42, int64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title", "uid", "change_seq"}).
+			AddRow(1, 42, "test_title", "uid-1", 7))
+	mock.ExpectQuery(`SELECT t.uid, t.change_seq FROM task_tombstones t`).
+		WithArgs(42, int64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"uid", "change_seq"}).AddRow("uid-2", 9))
+
+	mock.ExpectCommit()
+
+	changes, err := repo.GetChanges(ctx, &user.User{ID: 42}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if changes.Token != 9 || len(changes.Updated) != 1 || !reflect.DeepEqual(changes.Deleted, []string{"uid-2"}) {
+		t.Errorf("Unexpected changes: %+v", changes)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTaskRepository_Update_FailForbidden(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectTenant(mock)
+	mock.ExpectExec(`UPDATE tasks`).
+		WithArgs("test_title", "", false, 1, 42, 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(MAX(rank), 0) FROM task_access WHERE task_id = $1 AND user_id = $2`)).
+		WithArgs(1, 42).
+		WillReturnRows(sqlmock.NewRows([]string{"rank"}).AddRow(1))
+
+	mock.ExpectRollback()
+
+	err = repo.Update(ctx, &task.Task{ID: 1, UserID: 42, Title: "test_title"})
+	if !errors.Is(err, task.ErrTaskForbidden) {
+		t.Fatalf("Expected %v, got %v", task.ErrTaskForbidden, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTaskRepository_DeleteById_FailNotFound(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectTenant(mock)
+	mock.ExpectExec(`DELETE FROM tasks`).
+		WithArgs(1, 42, 3).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`FROM task_access WHERE task_id = \$1 AND user_id = \$2`).
+		WithArgs(1, 42).
+		WillReturnRows(sqlmock.NewRows([]string{"rank"}).AddRow(0))
+
+	mock.ExpectRollback()
+
+	err = repo.DeleteById(ctx, &task.Task{ID: 1, UserID: 42})
+	if !errors.Is(err, task.ErrTaskNotFound) {
+		t.Fatalf("Expected %v, got %v", task.ErrTaskNotFound, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTaskRepository_SetProject_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	projectID := 5
+	expectTenant(mock)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(MAX(rank), 0) FROM project_access WHERE project_id = $1 AND user_id = $2`)).
+		WithArgs(5, 42).
+		WillReturnRows(sqlmock.NewRows([]string{"rank"}).AddRow(2))
+	mock.ExpectExec(`UPDATE tasks SET project_id = \$1`).
+		WithArgs(5, 1, 42, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectCommit()
+
+	if err = repo.SetProject(ctx, &task.Task{ID: 1, UserID: 42, ProjectID: &projectID}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTaskRepository_SetProject_FailProjectViewer(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	projectID := 5
+	expectTenant(mock)
+	mock.ExpectQuery(`FROM project_access`).
+		WithArgs(5, 42).
+		WillReturnRows(sqlmock.NewRows([]string{"rank"}).AddRow(1))
+
+	mock.ExpectRollback()
+
+	err = repo.SetProject(ctx, &task.Task{ID: 1, UserID: 42, ProjectID: &projectID})
+	if !errors.Is(err, task.ErrProjectNotFound) {
+		t.Fatalf("Expected %v, got %v", task.ErrProjectNotFound, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTaskRepository_SetAssignee_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assigneeID := 7
+	expectTenant(mock)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(MAX(rank), 0) FROM task_access WHERE task_id = $1 AND user_id = $2`)).
+		WithArgs(1, 42).
+		WillReturnRows(sqlmock.NewRows([]string{"rank"}).AddRow(2))
+	mock.ExpectQuery(`JOIN workspace_members m ON m.workspace_id = w.id`).
+		WithArgs(1, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(`UPDATE tasks SET assignee_id = \$1`).
+		WithArgs(&assigneeID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"title"}).AddRow("Write report"))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO task_assignments (task_id, assignee_id, assigned_by) VALUES ($1, $2, $3)`)).
+		WithArgs(1, &assigneeID, 42).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	tk := &task.Task{ID: 1, UserID: 42, AssigneeID: &assigneeID}
+	changed, err := repo.SetAssignee(ctx, tk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed || tk.Title != "Write report" {
+		t.Errorf("unexpected result changed=%v task=%+v", changed, tk)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTaskRepository_SetAssignee_Unchanged(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectTenant(mock)
+	mock.ExpectQuery(`FROM task_access`).
+		WithArgs(1, 42).
+		WillReturnRows(sqlmock.NewRows([]string{"rank"}).AddRow(3))
+	mock.ExpectQuery(`UPDATE tasks SET assignee_id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"title"}))
+	mock.ExpectCommit()
+
+	changed, err := repo.SetAssignee(ctx, &task.Task{ID: 1, UserID: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed {
+		t.Error("expected unchanged assignee")
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTaskRepository_SetAssignee_Fail(t *testing.T) {
+	tests := []struct {
+		name    string
+		rank    int
+		access  bool
+		wantErr error
+	}{
+		{name: "not visible", rank: 0, wantErr: task.ErrTaskNotFound},
+		{name: "viewer", rank: 1, wantErr: task.ErrTaskForbidden},
+		{name: "assignee without access", rank: 2, access: false, wantErr: task.ErrAssigneeNoAccess},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock, err := mockDB()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assigneeID := 7
+			expectTenant(mock)
+			mock.ExpectQuery(`FROM task_access`).
+				WithArgs(1, 42).
+				WillReturnRows(sqlmock.NewRows([]string{"rank"}).AddRow(tt.rank))
+			if tt.rank >= 2 {
+				mock.ExpectQuery(`JOIN workspace_members`).
+					WithArgs(1, 7).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(tt.access))
+			}
+			mock.ExpectRollback()
+
+			_, err = repo.SetAssignee(ctx, &task.Task{ID: 1, UserID: 42, AssigneeID: &assigneeID})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestTaskRepository_GetAssigned_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectTenant(mock)
+	mock.ExpectQuery(`WHERE t.assignee_id = \$1 ORDER BY t.id`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title", "assignee_id", "role"}).
+			AddRow(1, 42, "Write report", 7, "editor"))
+	mock.ExpectCommit()
+
+	tasks, err := repo.GetAssigned(ctx, &user.User{ID: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || tasks[0].AssigneeID == nil || *tasks[0].AssigneeID != 7 {
+		t.Errorf("unexpected tasks %+v", tasks)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
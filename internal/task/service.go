@@ -1,31 +1,56 @@
 package task
 
 import (
+	"context"
+	"fmt"
+	"github.com/melnik-dev/go_todo_jwt/internal/notification"
 	"github.com/melnik-dev/go_todo_jwt/internal/user"
+	"github.com/melnik-dev/go_todo_jwt/pkg/todotxt"
+	"github.com/melnik-dev/go_todo_jwt/pkg/tracing"
 	"github.com/sirupsen/logrus"
+	"io"
+	"strings"
+	"unicode/utf8"
 )
 
 type IService interface {
-	Create(userID int, title, desc string) (int, error)
-	Update(userID, taskID int, title, desc string, completed bool) error
-	Delete(userID, taskID int) error
-	GetById(userID, taskID int) (*Task, error)
-	GetAll(userID int) ([]Task, error)
+	Create(ctx context.Context, userID int, title, desc string) (int, error)
+	Update(ctx context.Context, userID, taskID int, title, desc string, completed bool) error
+	Delete(ctx context.Context, userID, taskID int) error
+	GetById(ctx context.Context, userID, taskID int) (*Task, error)
+	GetAll(ctx context.Context, userID int) ([]Task, error)
+	Export(ctx context.Context, userID int, format Format, w io.Writer) error
+	Import(ctx context.Context, userID int, format Format, r io.Reader, dryRun bool) (*ImportReport, error)
+	GetByUID(ctx context.Context, userID int, uid string) (*Task, error)
+	Upsert(ctx context.Context, userID int, task *Task) (bool, error)
+	DeleteByUID(ctx context.Context, userID int, uid string) error
+	GetChanges(ctx context.Context, userID int, since int64) (*ChangeSet, error)
+	LatestChange(ctx context.Context, userID int) (int64, error)
+	UpdateTodoTxt(ctx context.Context, userID, taskID int, line string) (*Task, error)
+	SetProject(ctx context.Context, userID, taskID int, projectID *int) error
+	GetAssigned(ctx context.Context, userID int) ([]Task, error)
+	SetAssignee(ctx context.Context, userID, taskID int, assigneeID *int) error
+	GetAssignments(ctx context.Context, userID, taskID int) ([]Assignment, error)
 }
 
 type Service struct {
 	taskRepo IRepository
+	notifier notification.Notifier
 	logger   *logrus.Logger
 }
 
-func NewService(repo IRepository, logger *logrus.Logger) *Service {
+func NewService(repo IRepository, notifier notification.Notifier, logger *logrus.Logger) *Service {
 	return &Service{
 		taskRepo: repo,
+		notifier: notifier,
 		logger:   logger,
 	}
 }
 
-func (s *Service) Create(userID int, title, desc string) (int, error) {
+func (s *Service) Create(ctx context.Context, userID int, title, desc string) (int, error) {
+	ctx, span := tracing.Start(ctx, "task.Service.Create")
+	defer span.End()
+
 	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
 		"user_id": userID,
 	})
@@ -36,7 +61,7 @@ func (s *Service) Create(userID int, title, desc string) (int, error) {
 		Title:       title,
 		Description: desc,
 	}
-	_, err := s.taskRepo.Create(task)
+	_, err := s.taskRepo.Create(ctx, task)
 	if err != nil {
 		logServ.WithError(err).Error("Failed to Create")
 		return 0, err
@@ -46,13 +71,26 @@ func (s *Service) Create(userID int, title, desc string) (int, error) {
 	return task.ID, nil
 }
 
-func (s *Service) Update(userID, taskID int, title, desc string, completed bool) error {
+func (s *Service) Update(ctx context.Context, userID, taskID int, title, desc string, completed bool) error {
+	ctx, span := tracing.Start(ctx, "task.Service.Update")
+	defer span.End()
+
 	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
 		"user_id": userID,
 		"task_id": taskID,
 	})
 	logServ.Debug("Attempting to Update")
 
+	// прежнее состояние нужно, чтобы понять, кого уведомить о завершении
+	existing, err := s.taskRepo.GetById(ctx, &Task{
+		ID:     taskID,
+		UserID: userID,
+	})
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to GetById")
+		return err
+	}
+
 	task := &Task{
 		ID:          taskID,
 		UserID:      userID,
@@ -60,17 +98,25 @@ func (s *Service) Update(userID, taskID int, title, desc string, completed bool)
 		Description: desc,
 		Completed:   completed,
 	}
-	err := s.taskRepo.Update(task)
+	err = s.taskRepo.Update(ctx, task)
 	if err != nil {
 		logServ.WithError(err).Error("Failed to Update")
 		return err
 	}
 
+	if completed && !existing.Completed {
+		existing.Title = title
+		s.notifyCompleted(ctx, logServ, userID, existing)
+	}
+
 	logServ.Debug("Update successfully")
 	return nil
 }
 
-func (s *Service) Delete(userID, taskID int) error {
+func (s *Service) Delete(ctx context.Context, userID, taskID int) error {
+	ctx, span := tracing.Start(ctx, "task.Service.Delete")
+	defer span.End()
+
 	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
 		"user_id": userID,
 		"task_id": taskID,
@@ -82,24 +128,48 @@ func (s *Service) Delete(userID, taskID int) error {
 		UserID: userID,
 	}
 
-	err := s.taskRepo.DeleteById(task)
+	// исполнителя нужно знать до удаления, чтобы сообщить ему. GetById читает
+	// задачу в свою структуру: в task.UserID должен остаться тот, кто удаляет
+	existing, err := s.taskRepo.GetById(ctx, &Task{
+		ID:     taskID,
+		UserID: userID,
+	})
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to GetById")
+		return err
+	}
+
+	err = s.taskRepo.DeleteById(ctx, task)
 	if err != nil {
 		logServ.WithError(err).Error("Failed to Delete")
 		return err
 	}
 
+	if existing.AssigneeID != nil {
+		s.notify(ctx, logServ, &notification.Notification{
+			UserID:  *existing.AssigneeID,
+			ActorID: userID,
+			Type:    notification.TypeTaskDeleted,
+			TaskID:  taskID,
+			Text:    fmt.Sprintf("Task %q assigned to you was deleted", existing.Title),
+		})
+	}
+
 	logServ.Debug("Delete successfully")
 	return nil
 }
 
-func (s *Service) GetById(userID, taskID int) (*Task, error) {
+func (s *Service) GetById(ctx context.Context, userID, taskID int) (*Task, error) {
+	ctx, span := tracing.Start(ctx, "task.Service.GetById")
+	defer span.End()
+
 	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
 		"user_id": userID,
 		"task_id": taskID,
 	})
 	logServ.Debug("Attempting to Delete")
 
-	task, err := s.taskRepo.GetById(&Task{
+	task, err := s.taskRepo.GetById(ctx, &Task{
 		ID:     taskID,
 		UserID: userID,
 	})
@@ -112,7 +182,10 @@ func (s *Service) GetById(userID, taskID int) (*Task, error) {
 	return task, nil
 }
 
-func (s *Service) GetAll(userID int) ([]Task, error) {
+func (s *Service) GetAll(ctx context.Context, userID int) ([]Task, error) {
+	ctx, span := tracing.Start(ctx, "task.Service.GetAll")
+	defer span.End()
+
 	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
 		"user_id": userID,
 	})
@@ -120,7 +193,7 @@ func (s *Service) GetAll(userID int) ([]Task, error) {
 
 	us := &user.User{ID: userID}
 
-	tasks, err := s.taskRepo.GetAll(us)
+	tasks, err := s.taskRepo.GetAll(ctx, us)
 	if err != nil {
 		logServ.WithError(err).Error("Failed to GetAll")
 		return nil, err
@@ -130,6 +203,443 @@ func (s *Service) GetAll(userID int) ([]Task, error) {
 	return tasks, nil
 }
 
+// Export пишет задачи пользователя в w построчно. Пока из базы не прочитана первая
+// строка, в w ничего не пишется, чтобы обработчик мог ответить ошибкой.
+func (s *Service) Export(ctx context.Context, userID int, format Format, w io.Writer) error {
+	ctx, span := tracing.Start(ctx, "task.Service.Export")
+	defer span.End()
+
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id": userID,
+		"format":  format,
+	})
+	logServ.Debug("Attempting to Export")
+
+	enc := newEncoder(format, w)
+	begun := false
+	err := s.taskRepo.Each(ctx, &user.User{ID: userID}, func(task *Task) error {
+		if !begun {
+			if err := enc.Begin(); err != nil {
+				return err
+			}
+			begun = true
+		}
+		return enc.Encode(task)
+	})
+	if err == nil && !begun {
+		err = enc.Begin()
+	}
+	if err == nil {
+		err = enc.End()
+	}
+	if err != nil {
+		logServ.WithError(err).Error("Failed to Export")
+		return err
+	}
+
+	logServ.Debug("Export successfully")
+	return nil
+}
+
+// Import создаёт задачи из файла одной транзакцией. Если хотя бы одна строка невалидна,
+// не создаётся ничего и возвращается ErrImportInvalid вместе с отчётом.
+// Задачи, совпадающие с уже существующими по названию и описанию, пропускаются.
+func (s *Service) Import(ctx context.Context, userID int, format Format, r io.Reader, dryRun bool) (*ImportReport, error) {
+	ctx, span := tracing.Start(ctx, "task.Service.Import")
+	defer span.End()
+
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id": userID,
+		"format":  format,
+		"dry_run": dryRun,
+	})
+	logServ.Debug("Attempting to Import")
+
+	rows, err := decodeTasks(format, r)
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to decode import file")
+		return nil, err
+	}
+
+	existing, err := s.taskRepo.GetAll(ctx, &user.User{ID: userID})
+	if err != nil {
+		logServ.WithError(err).Error("Failed to GetAll")
+		return nil, err
+	}
+	seen := make(map[string]struct{}, len(existing)+len(rows))
+	for _, task := range existing {
+		seen[duplicateKey(&task)] = struct{}{}
+	}
+
+	report := &ImportReport{
+		DryRun:     dryRun,
+		Total:      len(rows),
+		Duplicates: []int{},
+		Errors:     []ImportRowError{},
+	}
+	tasks := make([]Task, 0, len(rows))
+	for _, row := range rows {
+		if err := validateImportRow(&row); err != nil {
+			report.Errors = append(report.Errors, ImportRowError{Row: row.Row, Error: err.Error()})
+			continue
+		}
+
+		key := duplicateKey(&row.Task)
+		if _, ok := seen[key]; ok {
+			report.Duplicates = append(report.Duplicates, row.Row)
+			continue
+		}
+		seen[key] = struct{}{}
+
+		row.Task.UserID = userID
+		tasks = append(tasks, row.Task)
+	}
+
+	if len(report.Errors) > 0 {
+		logServ.WithField("errors", len(report.Errors)).Warn(ErrImportInvalid.Error())
+		return report, ErrImportInvalid
+	}
+
+	if !dryRun && len(tasks) > 0 {
+		if err := s.taskRepo.CreateBatch(ctx, tasks); err != nil {
+			logServ.WithError(err).Error("Failed to CreateBatch")
+			return nil, err
+		}
+	}
+	report.Created = len(tasks)
+
+	logServ.WithField("created", report.Created).Debug("Import successfully")
+	return report, nil
+}
+
+func (s *Service) GetByUID(ctx context.Context, userID int, uid string) (*Task, error) {
+	ctx, span := tracing.Start(ctx, "task.Service.GetByUID")
+	defer span.End()
+
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id": userID,
+		"uid":     uid,
+	})
+	logServ.Debug("Attempting to GetByUID")
+
+	task, err := s.taskRepo.GetByUID(ctx, &Task{
+		UID:    uid,
+		UserID: userID,
+	})
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to GetByUID")
+		return nil, err
+	}
+
+	logServ.Debug("GetByUID successfully")
+	return task, nil
+}
+
+// Upsert создаёт задачу или заменяет существующую с тем же UID.
+// Возвращает true, если задача создана.
+func (s *Service) Upsert(ctx context.Context, userID int, task *Task) (bool, error) {
+	ctx, span := tracing.Start(ctx, "task.Service.Upsert")
+	defer span.End()
+
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id": userID,
+		"uid":     task.UID,
+	})
+	logServ.Debug("Attempting to Upsert")
+
+	task.UserID = userID
+	tasks := []Task{*task}
+	created, err := s.taskRepo.UpsertBatch(ctx, tasks)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to Upsert")
+		return false, err
+	}
+	*task = tasks[0]
+
+	logServ.Debug("Upsert successfully")
+	return created == 1, nil
+}
+
+func (s *Service) DeleteByUID(ctx context.Context, userID int, uid string) error {
+	ctx, span := tracing.Start(ctx, "task.Service.DeleteByUID")
+	defer span.End()
+
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id": userID,
+		"uid":     uid,
+	})
+	logServ.Debug("Attempting to DeleteByUID")
+
+	err := s.taskRepo.DeleteByUID(ctx, &Task{
+		UID:    uid,
+		UserID: userID,
+	})
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to DeleteByUID")
+		return err
+	}
+
+	logServ.Debug("DeleteByUID successfully")
+	return nil
+}
+
+func (s *Service) GetChanges(ctx context.Context, userID int, since int64) (*ChangeSet, error) {
+	ctx, span := tracing.Start(ctx, "task.Service.GetChanges")
+	defer span.End()
+
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id": userID,
+		"since":   since,
+	})
+	logServ.Debug("Attempting to GetChanges")
+
+	changes, err := s.taskRepo.GetChanges(ctx, &user.User{ID: userID}, since)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to GetChanges")
+		return nil, err
+	}
+
+	logServ.Debug("GetChanges successfully")
+	return changes, nil
+}
+
+func (s *Service) LatestChange(ctx context.Context, userID int) (int64, error) {
+	ctx, span := tracing.Start(ctx, "task.Service.LatestChange")
+	defer span.End()
+
+	logServ := serviceLogger(s.logger).WithField("user_id", userID)
+	logServ.Debug("Attempting to LatestChange")
+
+	token, err := s.taskRepo.LatestChange(ctx, &user.User{ID: userID})
+	if err != nil {
+		logServ.WithError(err).Error("Failed to LatestChange")
+		return 0, err
+	}
+
+	logServ.Debug("LatestChange successfully")
+	return token, nil
+}
+
+// UpdateTodoTxt заменяет задачу строкой todo.txt. UID и описание остаются прежними,
+// а поля todo.txt без аналога в модели полностью берутся из новой строки.
+func (s *Service) UpdateTodoTxt(ctx context.Context, userID, taskID int, line string) (*Task, error) {
+	ctx, span := tracing.Start(ctx, "task.Service.UpdateTodoTxt")
+	defer span.End()
+
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id": userID,
+		"task_id": taskID,
+	})
+	logServ.Debug("Attempting to UpdateTodoTxt")
+
+	item, err := todotxt.Parse(line)
+	if err != nil {
+		logServ.WithError(err).Warn(ErrInvalidTodoTxt.Error())
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTodoTxt, err)
+	}
+	row := importRow{Task: FromTodoTxt(&item)}
+	if err := validateImportRow(&row); err != nil {
+		logServ.WithError(err).Warn(ErrInvalidTodoTxt.Error())
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTodoTxt, err)
+	}
+
+	existing, err := s.taskRepo.GetById(ctx, &Task{
+		ID:     taskID,
+		UserID: userID,
+	})
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to GetById")
+		return nil, err
+	}
+
+	if !existing.CanEdit() {
+		logServ.Warn(ErrTaskForbidden.Error())
+		return nil, ErrTaskForbidden
+	}
+
+	// общая задача остаётся у владельца, UID уникален в его пространстве
+	task := row.Task
+	task.ID = existing.ID
+	task.UserID = existing.UserID
+	task.UID = existing.UID
+	task.Description = existing.Description
+	if task.Extras == nil {
+		// пустые Extras, а не nil: строка без меток должна стереть прежние
+		task.Extras = Extras{}
+	}
+	if task.Completed && task.CompletedAt == nil {
+		task.CompletedAt = existing.CompletedAt
+	}
+
+	tasks := []Task{task}
+	if _, err := s.taskRepo.UpsertBatch(ctx, tasks); err != nil {
+		logServ.WithError(err).Error("Failed to UpsertBatch")
+		return nil, err
+	}
+
+	if task.Completed && !existing.Completed {
+		existing.Title = task.Title
+		s.notifyCompleted(ctx, logServ, userID, existing)
+	}
+
+	logServ.Debug("UpdateTodoTxt successfully")
+	return &tasks[0], nil
+}
+
+// SetProject переносит задачу в проект или убирает из него, если projectID пуст
+func (s *Service) SetProject(ctx context.Context, userID, taskID int, projectID *int) error {
+	ctx, span := tracing.Start(ctx, "task.Service.SetProject")
+	defer span.End()
+
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id": userID,
+		"task_id": taskID,
+	})
+	logServ.Debug("Attempting to SetProject")
+
+	err := s.taskRepo.SetProject(ctx, &Task{
+		ID:        taskID,
+		UserID:    userID,
+		ProjectID: projectID,
+	})
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to SetProject")
+		return err
+	}
+
+	logServ.Debug("SetProject successfully")
+	return nil
+}
+
+// GetAssigned возвращает задачи, назначенные пользователю
+func (s *Service) GetAssigned(ctx context.Context, userID int) ([]Task, error) {
+	ctx, span := tracing.Start(ctx, "task.Service.GetAssigned")
+	defer span.End()
+
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id": userID,
+	})
+	logServ.Debug("Attempting to GetAssigned")
+
+	tasks, err := s.taskRepo.GetAssigned(ctx, &user.User{ID: userID})
+	if err != nil {
+		logServ.WithError(err).Error("Failed to GetAssigned")
+		return nil, err
+	}
+
+	logServ.Debug("GetAssigned successfully")
+	return tasks, nil
+}
+
+// SetAssignee назначает исполнителя задачи или снимает его, если assigneeID пуст.
+// Новый исполнитель получает уведомление, если назначил его кто-то другой.
+func (s *Service) SetAssignee(ctx context.Context, userID, taskID int, assigneeID *int) error {
+	ctx, span := tracing.Start(ctx, "task.Service.SetAssignee")
+	defer span.End()
+
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id": userID,
+		"task_id": taskID,
+	})
+	logServ.Debug("Attempting to SetAssignee")
+
+	task := &Task{
+		ID:         taskID,
+		UserID:     userID,
+		AssigneeID: assigneeID,
+	}
+	changed, err := s.taskRepo.SetAssignee(ctx, task)
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to SetAssignee")
+		return err
+	}
+
+	if changed && assigneeID != nil && *assigneeID != userID {
+		s.notify(ctx, logServ, &notification.Notification{
+			UserID:  *assigneeID,
+			ActorID: userID,
+			Type:    notification.TypeTaskAssigned,
+			TaskID:  taskID,
+			Text:    fmt.Sprintf("You were assigned to task %q", task.Title),
+		})
+	}
+
+	logServ.WithField("changed", changed).Debug("SetAssignee successfully")
+	return nil
+}
+
+// GetAssignments возвращает историю назначений задачи
+func (s *Service) GetAssignments(ctx context.Context, userID, taskID int) ([]Assignment, error) {
+	ctx, span := tracing.Start(ctx, "task.Service.GetAssignments")
+	defer span.End()
+
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id": userID,
+		"task_id": taskID,
+	})
+	logServ.Debug("Attempting to GetAssignments")
+
+	assignments, err := s.taskRepo.GetAssignments(ctx, &Task{ID: taskID, UserID: userID})
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to GetAssignments")
+		return nil, err
+	}
+
+	logServ.Debug("GetAssignments successfully")
+	return assignments, nil
+}
+
+// notifyCompleted сообщает владельцу и исполнителю, что задачу завершил кто-то другой
+func (s *Service) notifyCompleted(ctx context.Context, logServ *logrus.Entry, actorID int, task *Task) {
+	recipients := []int{task.UserID}
+	if task.AssigneeID != nil && *task.AssigneeID != task.UserID {
+		recipients = append(recipients, *task.AssigneeID)
+	}
+	for _, recipient := range recipients {
+		if recipient == actorID {
+			continue
+		}
+		s.notify(ctx, logServ, &notification.Notification{
+			UserID:  recipient,
+			ActorID: actorID,
+			Type:    notification.TypeTaskCompleted,
+			TaskID:  task.ID,
+			Text:    fmt.Sprintf("Task %q was completed", task.Title),
+		})
+	}
+}
+
+// notify отправляет уведомление; ошибка только пишется в лог и не отменяет действие
+func (s *Service) notify(ctx context.Context, logServ *logrus.Entry, n *notification.Notification) {
+	if err := s.notifier.Notify(ctx, n); err != nil {
+		logServ.WithError(err).WithFields(logrus.Fields{
+			"recipient_id": n.UserID,
+			"type":         n.Type,
+		}).Warn("Failed to send notification")
+	}
+}
+
+func validateImportRow(row *importRow) error {
+	if row.Err != nil {
+		return row.Err
+	}
+	row.Task.Title = strings.TrimSpace(row.Task.Title)
+	if row.Task.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+	if utf8.RuneCountInString(row.Task.Title) > 100 {
+		return fmt.Errorf("title must be at most 100 characters")
+	}
+	if utf8.RuneCountInString(row.Task.Description) > 500 {
+		return fmt.Errorf("description must be at most 500 characters")
+	}
+	return nil
+}
+
+func duplicateKey(task *Task) string {
+	return strings.ToLower(strings.TrimSpace(task.Title)) + "\x00" + strings.ToLower(strings.TrimSpace(task.Description))
+}
+
 func serviceLogger(l *logrus.Logger) *logrus.Entry {
 	return l.WithField("layer", "Service task layer")
 }
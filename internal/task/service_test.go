@@ -1,42 +1,118 @@
 package task_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"github.com/melnik-dev/go_todo_jwt/internal/notification"
 	"github.com/melnik-dev/go_todo_jwt/internal/task"
 	"github.com/melnik-dev/go_todo_jwt/internal/user"
 	"github.com/sirupsen/logrus"
 	"io"
+	"reflect"
+	"strings"
 	"testing"
 )
 
 type MockTaskRepository struct {
-	CreateMock     func(task *task.Task) (*task.Task, error)
-	UpdateMock     func(task *task.Task) error
-	DeleteByIdMock func(task *task.Task) error
-	GetByIdMock    func(task *task.Task) (*task.Task, error)
-	GetAllMock     func(user *user.User) ([]task.Task, error)
+	CreateMock       func(task *task.Task) (*task.Task, error)
+	UpdateMock       func(task *task.Task) error
+	DeleteByIdMock   func(task *task.Task) error
+	GetByIdMock      func(task *task.Task) (*task.Task, error)
+	GetAllMock       func(user *user.User) ([]task.Task, error)
+	EachMock         func(user *user.User, fn func(task *task.Task) error) error
+	CreateBatchMock  func(tasks []task.Task) error
+	UpsertBatchMock  func(tasks []task.Task) (int, error)
+	GetByUIDMock     func(task *task.Task) (*task.Task, error)
+	DeleteByUIDMock  func(task *task.Task) error
+	GetChangesMock   func(user *user.User, since int64) (*task.ChangeSet, error)
+	LatestChangeMock func(user *user.User) (int64, error)
+	SetProjectMock   func(task *task.Task) error
+
+	GetAssignedMock    func(user *user.User) ([]task.Task, error)
+	SetAssigneeMock    func(task *task.Task) (bool, error)
+	GetAssignmentsMock func(task *task.Task) ([]task.Assignment, error)
 }
 
-func (m *MockTaskRepository) Create(task *task.Task) (*task.Task, error) {
+func (m *MockTaskRepository) Create(ctx context.Context, task *task.Task) (*task.Task, error) {
 	return m.CreateMock(task)
 }
 
-func (m *MockTaskRepository) Update(task *task.Task) error {
+func (m *MockTaskRepository) Update(ctx context.Context, task *task.Task) error {
 	return m.UpdateMock(task)
 }
 
-func (m *MockTaskRepository) DeleteById(task *task.Task) error {
+func (m *MockTaskRepository) DeleteById(ctx context.Context, task *task.Task) error {
 	return m.DeleteByIdMock(task)
 }
 
-func (m *MockTaskRepository) GetById(task *task.Task) (*task.Task, error) {
+func (m *MockTaskRepository) GetById(ctx context.Context, task *task.Task) (*task.Task, error) {
 	return m.GetByIdMock(task)
 }
 
-func (m *MockTaskRepository) GetAll(user *user.User) ([]task.Task, error) {
+func (m *MockTaskRepository) GetAll(ctx context.Context, user *user.User) ([]task.Task, error) {
 	return m.GetAllMock(user)
 }
 
+func (m *MockTaskRepository) Each(ctx context.Context, user *user.User, fn func(task *task.Task) error) error {
+	return m.EachMock(user, fn)
+}
+
+func (m *MockTaskRepository) CreateBatch(ctx context.Context, tasks []task.Task) error {
+	return m.CreateBatchMock(tasks)
+}
+
+func (m *MockTaskRepository) UpsertBatch(ctx context.Context, tasks []task.Task) (int, error) {
+	return m.UpsertBatchMock(tasks)
+}
+
+func (m *MockTaskRepository) GetByUID(ctx context.Context, task *task.Task) (*task.Task, error) {
+	return m.GetByUIDMock(task)
+}
+
+func (m *MockTaskRepository) DeleteByUID(ctx context.Context, task *task.Task) error {
+	return m.DeleteByUIDMock(task)
+}
+
+func (m *MockTaskRepository) GetChanges(ctx context.Context, user *user.User, since int64) (*task.ChangeSet, error) {
+	return m.GetChangesMock(user, since)
+}
+
+func (m *MockTaskRepository) SetProject(ctx context.Context, task *task.Task) error {
+	return m.SetProjectMock(task)
+}
+
+func (m *MockTaskRepository) LatestChange(ctx context.Context, user *user.User) (int64, error) {
+	return m.LatestChangeMock(user)
+}
+
+func (m *MockTaskRepository) GetAssigned(ctx context.Context, user *user.User) ([]task.Task, error) {
+	return m.GetAssignedMock(user)
+}
+
+func (m *MockTaskRepository) SetAssignee(ctx context.Context, task *task.Task) (bool, error) {
+	return m.SetAssigneeMock(task)
+}
+
+func (m *MockTaskRepository) GetAssignments(ctx context.Context, task *task.Task) ([]task.Assignment, error) {
+	return m.GetAssignmentsMock(task)
+}
+
+type MockNotifier struct {
+	Sent []notification.Notification
+	Err  error
+}
+
+func (m *MockNotifier) Notify(ctx context.Context, n *notification.Notification) error {
+	m.Sent = append(m.Sent, *n)
+	return m.Err
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
 func mockLogger() *logrus.Logger {
 	l := logrus.New()
 	l.SetOutput(io.Discard)
@@ -51,9 +127,9 @@ func TestService_Create_Success(t *testing.T) {
 		},
 	}
 
-	service := task.NewService(mockRepo, mockLogger())
+	service := task.NewService(mockRepo, &MockNotifier{}, mockLogger())
 
-	expId, err := service.Create(42, "test_title", "test_desc")
+	expId, err := service.Create(context.Background(), 42, "test_title", "test_desc")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -71,9 +147,9 @@ func TestService_Create_Fail(t *testing.T) {
 		},
 	}
 
-	service := task.NewService(mockRepo, mockLogger())
+	service := task.NewService(mockRepo, &MockNotifier{}, mockLogger())
 
-	_, err := service.Create(42, "test_title", "test_desc")
+	_, err := service.Create(context.Background(), 42, "test_title", "test_desc")
 	if err == nil {
 		t.Fatal(err)
 	}
@@ -81,14 +157,17 @@ func TestService_Create_Fail(t *testing.T) {
 
 func TestService_Update_Success(t *testing.T) {
 	mockRepo := &MockTaskRepository{
+		GetByIdMock: func(tk *task.Task) (*task.Task, error) {
+			return &task.Task{ID: tk.ID, UserID: tk.UserID, Role: "owner"}, nil
+		},
 		UpdateMock: func(task *task.Task) error {
 			return nil
 		},
 	}
 
-	service := task.NewService(mockRepo, mockLogger())
+	service := task.NewService(mockRepo, &MockNotifier{}, mockLogger())
 
-	err := service.Update(42, 1, "test_title", "test_desc", true)
+	err := service.Update(context.Background(), 42, 1, "test_title", "test_desc", true)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -96,14 +175,17 @@ func TestService_Update_Success(t *testing.T) {
 
 func TestService_Update_Fail(t *testing.T) {
 	mockRepo := &MockTaskRepository{
+		GetByIdMock: func(tk *task.Task) (*task.Task, error) {
+			return &task.Task{ID: tk.ID, UserID: tk.UserID, Role: "owner"}, nil
+		},
 		UpdateMock: func(task *task.Task) error {
 			return fmt.Errorf("test error")
 		},
 	}
 
-	service := task.NewService(mockRepo, mockLogger())
+	service := task.NewService(mockRepo, &MockNotifier{}, mockLogger())
 
-	err := service.Update(42, 1, "test_title", "test_desc", true)
+	err := service.Update(context.Background(), 42, 1, "test_title", "test_desc", true)
 	if err == nil {
 		t.Fatal(err)
 	}
@@ -111,14 +193,17 @@ func TestService_Update_Fail(t *testing.T) {
 
 func TestService_Delete_Success(t *testing.T) {
 	mockRepo := &MockTaskRepository{
+		GetByIdMock: func(tk *task.Task) (*task.Task, error) {
+			return &task.Task{ID: tk.ID, UserID: tk.UserID, Role: "owner"}, nil
+		},
 		DeleteByIdMock: func(task *task.Task) error {
 			return nil
 		},
 	}
 
-	service := task.NewService(mockRepo, mockLogger())
+	service := task.NewService(mockRepo, &MockNotifier{}, mockLogger())
 
-	err := service.Delete(42, 1)
+	err := service.Delete(context.Background(), 42, 1)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -126,19 +211,110 @@ func TestService_Delete_Success(t *testing.T) {
 
 func TestService_Delete_Fail(t *testing.T) {
 	mockRepo := &MockTaskRepository{
+		GetByIdMock: func(tk *task.Task) (*task.Task, error) {
+			return &task.Task{ID: tk.ID, UserID: tk.UserID, Role: "owner"}, nil
+		},
 		DeleteByIdMock: func(task *task.Task) error {
 			return fmt.Errorf("test error")
 		},
 	}
 
-	service := task.NewService(mockRepo, mockLogger())
+	service := task.NewService(mockRepo, &MockNotifier{}, mockLogger())
 
-	err := service.Delete(42, 1)
+	err := service.Delete(context.Background(), 42, 1)
 	if err == nil {
 		t.Fatal(err)
 	}
 }
 
+func TestService_Delete_FailViewer(t *testing.T) {
+	const owner, viewer = 42, 7
+	mockRepo := &MockTaskRepository{
+		// как Repository.GetById: t.* читается в переданную задачу, и UserID становится владельцем
+		GetByIdMock: func(tk *task.Task) (*task.Task, error) {
+			tk.UserID = owner
+			tk.Role = task.RoleViewer
+			return tk, nil
+		},
+		DeleteByIdMock: func(tk *task.Task) error {
+			if tk.UserID != owner {
+				return task.ErrTaskForbidden
+			}
+			return nil
+		},
+	}
+
+	service := task.NewService(mockRepo, &MockNotifier{}, mockLogger())
+
+	err := service.Delete(context.Background(), viewer, 1)
+	if !errors.Is(err, task.ErrTaskForbidden) {
+		t.Fatalf("expected ErrTaskForbidden, got %v", err)
+	}
+}
+
+func TestService_Update_NotifiesCompletion(t *testing.T) {
+	tests := []struct {
+		name       string
+		actorID    int
+		completed  bool
+		wasDone    bool
+		wantNotify []int
+	}{
+		{name: "editor completes", actorID: 9, completed: true, wantNotify: []int{42, 7}},
+		{name: "assignee completes", actorID: 7, completed: true, wantNotify: []int{42}},
+		{name: "owner completes", actorID: 42, completed: true, wantNotify: []int{7}},
+		{name: "already completed", actorID: 9, completed: true, wasDone: true},
+		{name: "not completed", actorID: 9, completed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifier := &MockNotifier{}
+			service := task.NewService(&MockTaskRepository{
+				GetByIdMock: func(tk *task.Task) (*task.Task, error) {
+					return &task.Task{ID: tk.ID, UserID: 42, AssigneeID: intPtr(7), Completed: tt.wasDone}, nil
+				},
+				UpdateMock: func(tk *task.Task) error {
+					return nil
+				},
+			}, notifier, mockLogger())
+
+			if err := service.Update(context.Background(), tt.actorID, 1, "title", "", tt.completed); err != nil {
+				t.Fatal(err)
+			}
+			if len(notifier.Sent) != len(tt.wantNotify) {
+				t.Fatalf("expected %d notifications, got %+v", len(tt.wantNotify), notifier.Sent)
+			}
+			for i, userID := range tt.wantNotify {
+				n := notifier.Sent[i]
+				if n.UserID != userID || n.ActorID != tt.actorID || n.Type != notification.TypeTaskCompleted {
+					t.Errorf("unexpected notification %+v", n)
+				}
+			}
+		})
+	}
+}
+
+func TestService_Delete_NotifiesAssignee(t *testing.T) {
+	notifier := &MockNotifier{Err: fmt.Errorf("inbox unavailable")}
+	service := task.NewService(&MockTaskRepository{
+		GetByIdMock: func(tk *task.Task) (*task.Task, error) {
+			return &task.Task{ID: tk.ID, UserID: 42, Title: "Report", AssigneeID: intPtr(7)}, nil
+		},
+		DeleteByIdMock: func(tk *task.Task) error {
+			return nil
+		},
+	}, notifier, mockLogger())
+
+	// ошибка доставки уведомления не отменяет удаление
+	if err := service.Delete(context.Background(), 42, 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(notifier.Sent) != 1 || notifier.Sent[0].UserID != 7 || notifier.Sent[0].Type != notification.TypeTaskDeleted {
+		t.Errorf("unexpected notifications %+v", notifier.Sent)
+	}
+}
+
 func TestService_GetById_Success(t *testing.T) {
 	mockRepo := &MockTaskRepository{
 		GetByIdMock: func(task *task.Task) (*task.Task, error) {
@@ -151,9 +327,9 @@ func TestService_GetById_Success(t *testing.T) {
 		},
 	}
 
-	service := task.NewService(mockRepo, mockLogger())
+	service := task.NewService(mockRepo, &MockNotifier{}, mockLogger())
 
-	exp, err := service.GetById(42, 1)
+	exp, err := service.GetById(context.Background(), 42, 1)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -174,9 +350,9 @@ func TestService_GetById_Fail(t *testing.T) {
 		},
 	}
 
-	service := task.NewService(mockRepo, mockLogger())
+	service := task.NewService(mockRepo, &MockNotifier{}, mockLogger())
 
-	_, err := service.GetById(42, 1)
+	_, err := service.GetById(context.Background(), 42, 1)
 	if err == nil {
 		t.Fatal(err)
 	}
@@ -204,9 +380,9 @@ func TestService_GetAll_Success(t *testing.T) {
 		},
 	}
 
-	service := task.NewService(mockRepo, mockLogger())
+	service := task.NewService(mockRepo, &MockNotifier{}, mockLogger())
 
-	exp, err := service.GetAll(42)
+	exp, err := service.GetAll(context.Background(), 42)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -239,10 +415,397 @@ func TestService_GetAll_Fail(t *testing.T) {
 		},
 	}
 
-	service := task.NewService(mockRepo, mockLogger())
+	service := task.NewService(mockRepo, &MockNotifier{}, mockLogger())
 
-	_, err := service.GetAll(42)
+	_, err := service.GetAll(context.Background(), 42)
 	if err == nil {
 		t.Fatal(err)
 	}
 }
+
+func eachTasks(tasks []task.Task) func(user *user.User, fn func(task *task.Task) error) error {
+	return func(user *user.User, fn func(task *task.Task) error) error {
+		for i := range tasks {
+			if err := fn(&tasks[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestService_Export_Formats(t *testing.T) {
+	tasks := []task.Task{
+		{ID: 1, UserID: 42, Title: "test_title_1", Description: "line 1\nline 2", Completed: true},
+		{ID: 2, UserID: 42, Title: "test, title 2", Description: "", Completed: false},
+	}
+
+	tests := []struct {
+		format task.Format
+		want   string
+	}{
+		{
+			format: task.FormatJSON,
+			want: `[{"id":1,"user_id":42,"title":"test_title_1","description":"line 1\nline 2","completed":true,"uid":"","due_at":null,"completed_at":null,"updated_at":"0001-01-01T00:00:00Z"},` +
+				`{"id":2,"user_id":42,"title":"test, title 2","description":"","completed":false,"uid":"","due_at":null,"completed_at":null,"updated_at":"0001-01-01T00:00:00Z"}]` + "\n",
+		},
+		{
+			format: task.FormatCSV,
+			want:   "id,title,description,completed\n1,test_title_1,\"line 1\nline 2\",true\n2,\"test, title 2\",,false\n",
+		},
+		{
+			format: task.FormatMarkdown,
+			want:   "- [x] test_title_1\n  line 1\n  line 2\n- [ ] test, title 2\n",
+		},
+		{
+			format: task.FormatTodoTxt,
+			want:   "x test_title_1\ntest, title 2\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			service := task.NewService(&MockTaskRepository{EachMock: eachTasks(tasks)}, &MockNotifier{}, mockLogger())
+
+			var buf bytes.Buffer
+			if err := service.Export(context.Background(), 42, tt.format, &buf); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("expected\n%q\ngot\n%q", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestService_Export_FailNothingWritten(t *testing.T) {
+	service := task.NewService(&MockTaskRepository{
+		EachMock: func(user *user.User, fn func(task *task.Task) error) error {
+			return fmt.Errorf("test error")
+		},
+	}, &MockNotifier{}, mockLogger())
+
+	var buf bytes.Buffer
+	if err := service.Export(context.Background(), 42, task.FormatJSON, &buf); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing written, got %q", buf.String())
+	}
+}
+
+func TestService_Import_RoundTrip(t *testing.T) {
+	source := []task.Task{
+		{ID: 1, UserID: 42, Title: "test_title_1", Description: "line 1\nline 2", Completed: true},
+		{ID: 2, UserID: 42, Title: "test_title_2", Description: "test_desc_2", Completed: false},
+	}
+
+	for _, format := range []task.Format{task.FormatJSON, task.FormatCSV, task.FormatMarkdown} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			exporter := task.NewService(&MockTaskRepository{EachMock: eachTasks(source)}, &MockNotifier{}, mockLogger())
+			if err := exporter.Export(context.Background(), 42, format, &buf); err != nil {
+				t.Fatal(err)
+			}
+
+			var created []task.Task
+			importer := task.NewService(&MockTaskRepository{
+				GetAllMock: func(user *user.User) ([]task.Task, error) {
+					return nil, nil
+				},
+				CreateBatchMock: func(tasks []task.Task) error {
+					created = tasks
+					return nil
+				},
+			}, &MockNotifier{}, mockLogger())
+
+			report, err := importer.Import(context.Background(), 7, format, &buf, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Created != 2 || len(created) != 2 {
+				t.Fatalf("expected 2 created tasks, got report %+v", report)
+			}
+			for i, got := range created {
+				want := source[i]
+				if got.UserID != 7 || got.Title != want.Title ||
+					got.Description != want.Description || got.Completed != want.Completed {
+					t.Errorf("unexpected task %d: %+v", i, got)
+				}
+			}
+		})
+	}
+}
+
+func TestService_Import_DryRunAndDuplicates(t *testing.T) {
+	service := task.NewService(&MockTaskRepository{
+		GetAllMock: func(user *user.User) ([]task.Task, error) {
+			return []task.Task{{ID: 1, Title: "Existing", Description: ""}}, nil
+		},
+		CreateBatchMock: func(tasks []task.Task) error {
+			t.Fatal("CreateBatch must not be called in dry run")
+			return nil
+		},
+	}, &MockNotifier{}, mockLogger())
+
+	input := "- [ ] existing\n- [ ] new\n- [x] new\n"
+	report, err := service.Import(context.Background(), 42, task.FormatMarkdown, strings.NewReader(input), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !report.DryRun || report.Total != 3 || report.Created != 1 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if len(report.Duplicates) != 2 || report.Duplicates[0] != 1 || report.Duplicates[1] != 3 {
+		t.Errorf("expected duplicates on rows 1 and 3, got %v", report.Duplicates)
+	}
+}
+
+func TestService_Import_FailInvalidRows(t *testing.T) {
+	service := task.NewService(&MockTaskRepository{
+		GetAllMock: func(user *user.User) ([]task.Task, error) {
+			return nil, nil
+		},
+		CreateBatchMock: func(tasks []task.Task) error {
+			t.Fatal("CreateBatch must not be called when rows are invalid")
+			return nil
+		},
+	}, &MockNotifier{}, mockLogger())
+
+	input := "title,completed\nok,false\n,false\nbad,maybe\n"
+	report, err := service.Import(context.Background(), 42, task.FormatCSV, strings.NewReader(input), false)
+	if !errors.Is(err, task.ErrImportInvalid) {
+		t.Fatalf("expected %v, got %v", task.ErrImportInvalid, err)
+	}
+
+	if len(report.Errors) != 2 || report.Errors[0].Row != 3 || report.Errors[1].Row != 4 {
+		t.Errorf("expected errors on rows 3 and 4, got %+v", report.Errors)
+	}
+}
+
+func TestService_Import_FailMalformed(t *testing.T) {
+	service := task.NewService(&MockTaskRepository{}, &MockNotifier{}, mockLogger())
+
+	_, err := service.Import(context.Background(), 42, task.FormatJSON, strings.NewReader("{"), false)
+	if !errors.Is(err, task.ErrImportMalformed) {
+		t.Fatalf("expected %v, got %v", task.ErrImportMalformed, err)
+	}
+}
+
+func TestService_Upsert_Success(t *testing.T) {
+	service := task.NewService(&MockTaskRepository{
+		UpsertBatchMock: func(tasks []task.Task) (int, error) {
+			if len(tasks) != 1 || tasks[0].UserID != 42 {
+				t.Errorf("unexpected tasks: %+v", tasks)
+			}
+			tasks[0].ID = 1
+			tasks[0].ChangeSeq = 10
+			return 1, nil
+		},
+	}, &MockNotifier{}, mockLogger())
+
+	in := &task.Task{UID: "uid-1", Title: "test_title"}
+	created, err := service.Upsert(context.Background(), 42, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !created || in.ID != 1 || in.ChangeSeq != 10 {
+		t.Errorf("unexpected result: created %v, task %+v", created, in)
+	}
+}
+
+func TestService_DeleteByUID_Fail(t *testing.T) {
+	service := task.NewService(&MockTaskRepository{
+		DeleteByUIDMock: func(in *task.Task) error {
+			return task.ErrTaskNotFound
+		},
+	}, &MockNotifier{}, mockLogger())
+
+	if err := service.DeleteByUID(context.Background(), 42, "uid-1"); !errors.Is(err, task.ErrTaskNotFound) {
+		t.Fatalf("expected %v, got %v", task.ErrTaskNotFound, err)
+	}
+}
+
+func TestService_Import_TodoTxtPreservesExtras(t *testing.T) {
+	input := "(A) 2026-04-01 Call Mom +family @phone due:2026-05-05 rec:1w\n" +
+		"x 2026-05-02 Done task pri:B\n"
+
+	var created []task.Task
+	service := task.NewService(&MockTaskRepository{
+		GetAllMock: func(user *user.User) ([]task.Task, error) {
+			return nil, nil
+		},
+		CreateBatchMock: func(tasks []task.Task) error {
+			created = tasks
+			return nil
+		},
+	}, &MockNotifier{}, mockLogger())
+
+	if _, err := service.Import(context.Background(), 42, task.FormatTodoTxt, strings.NewReader(input), false); err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 2 {
+		t.Fatalf("expected 2 created tasks, got %d", len(created))
+	}
+
+	first := created[0]
+	if first.Title != "Call Mom" || first.DueAt == nil || first.DueAt.Format("2006-01-02") != "2026-05-05" {
+		t.Errorf("unexpected task %+v", first)
+	}
+	wantExtras := task.Extras{
+		"todotxt.priority": "A",
+		"todotxt.created":  "2026-04-01",
+		"todotxt.projects": "family",
+		"todotxt.contexts": "phone",
+		"todotxt.tags":     "rec:1w",
+	}
+	if !reflect.DeepEqual(first.Extras, wantExtras) {
+		t.Errorf("expected extras %v, got %v", wantExtras, first.Extras)
+	}
+	if !created[1].Completed || created[1].CompletedAt == nil {
+		t.Errorf("expected completed task with date, got %+v", created[1])
+	}
+
+	exporter := task.NewService(&MockTaskRepository{EachMock: eachTasks(created)}, &MockNotifier{}, mockLogger())
+	var buf bytes.Buffer
+	if err := exporter.Export(context.Background(), 42, task.FormatTodoTxt, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != input {
+		t.Errorf("expected round trip\n%q\ngot\n%q", input, buf.String())
+	}
+}
+
+func TestService_Import_TodoTxtKeepsTokenOrder(t *testing.T) {
+	input := "(A) 2026-04-01 Call +family Mom @phone about due:2026-05-05 the rec:1w trip\n"
+
+	var created []task.Task
+	service := task.NewService(&MockTaskRepository{
+		GetAllMock: func(user *user.User) ([]task.Task, error) {
+			return nil, nil
+		},
+		CreateBatchMock: func(tasks []task.Task) error {
+			created = tasks
+			return nil
+		},
+	}, &MockNotifier{}, mockLogger())
+
+	if _, err := service.Import(context.Background(), 42, task.FormatTodoTxt, strings.NewReader(input), false); err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 1 || created[0].Title != "Call Mom about the trip" {
+		t.Fatalf("unexpected tasks %+v", created)
+	}
+
+	exporter := task.NewService(&MockTaskRepository{EachMock: eachTasks(created)}, &MockNotifier{}, mockLogger())
+	var buf bytes.Buffer
+	if err := exporter.Export(context.Background(), 42, task.FormatTodoTxt, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != input {
+		t.Errorf("expected round trip\n%q\ngot\n%q", input, buf.String())
+	}
+}
+
+func TestService_UpdateTodoTxt_Success(t *testing.T) {
+	var saved task.Task
+	service := task.NewService(&MockTaskRepository{
+		GetByIdMock: func(tk *task.Task) (*task.Task, error) {
+			return &task.Task{ID: tk.ID, UserID: tk.UserID, UID: "uid-1", Title: "Old", Description: "keep me",
+				Extras: task.Extras{"todotxt.priority": "C"}}, nil
+		},
+		UpsertBatchMock: func(tasks []task.Task) (int, error) {
+			saved = tasks[0]
+			return 0, nil
+		},
+	}, &MockNotifier{}, mockLogger())
+
+	_, err := service.UpdateTodoTxt(context.Background(), 42, 1, "New title @home")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if saved.ID != 1 || saved.UID != "uid-1" || saved.Title != "New title" || saved.Description != "keep me" {
+		t.Errorf("unexpected saved task %+v", saved)
+	}
+	if want := (task.Extras{"todotxt.contexts": "home"}); !reflect.DeepEqual(saved.Extras, want) {
+		t.Errorf("expected extras %v, got %v", want, saved.Extras)
+	}
+}
+
+func TestService_UpdateTodoTxt_FailInvalid(t *testing.T) {
+	service := task.NewService(&MockTaskRepository{}, &MockNotifier{}, mockLogger())
+
+	for _, line := range []string{"", "+project @context"} {
+		if _, err := service.UpdateTodoTxt(context.Background(), 42, 1, line); !errors.Is(err, task.ErrInvalidTodoTxt) {
+			t.Errorf("expected %v for %q, got %v", task.ErrInvalidTodoTxt, line, err)
+		}
+	}
+}
+
+func TestService_UpdateTodoTxt_FailViewer(t *testing.T) {
+	service := task.NewService(&MockTaskRepository{
+		GetByIdMock: func(tk *task.Task) (*task.Task, error) {
+			return &task.Task{ID: tk.ID, UserID: 7, Title: "Shared", Role: task.RoleViewer}, nil
+		},
+	}, &MockNotifier{}, mockLogger())
+
+	if _, err := service.UpdateTodoTxt(context.Background(), 42, 1, "Changed"); !errors.Is(err, task.ErrTaskForbidden) {
+		t.Errorf("expected %v, got %v", task.ErrTaskForbidden, err)
+	}
+}
+
+func TestService_SetAssignee(t *testing.T) {
+	alice, bob := 42, 7
+	tests := []struct {
+		name       string
+		assigneeID *int
+		changed    bool
+		wantSent   int
+	}{
+		{name: "assign other", assigneeID: &bob, changed: true, wantSent: 1},
+		{name: "assign self", assigneeID: &alice, changed: true},
+		{name: "unchanged", assigneeID: &bob},
+		{name: "unassign", changed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifier := &MockNotifier{}
+			service := task.NewService(&MockTaskRepository{
+				SetAssigneeMock: func(tk *task.Task) (bool, error) {
+					tk.Title = "Write report"
+					return tt.changed, nil
+				},
+			}, notifier, mockLogger())
+
+			if err := service.SetAssignee(context.Background(), alice, 1, tt.assigneeID); err != nil {
+				t.Fatal(err)
+			}
+			if len(notifier.Sent) != tt.wantSent {
+				t.Fatalf("expected %d notifications, got %d", tt.wantSent, len(notifier.Sent))
+			}
+			if tt.wantSent > 0 {
+				n := notifier.Sent[0]
+				if n.UserID != bob || n.ActorID != alice || n.Type != notification.TypeTaskAssigned || n.TaskID != 1 {
+					t.Errorf("unexpected notification %+v", n)
+				}
+			}
+		})
+	}
+}
+
+func TestService_SetAssignee_NotifyErrorIgnored(t *testing.T) {
+	bob := 7
+	service := task.NewService(&MockTaskRepository{
+		SetAssigneeMock: func(tk *task.Task) (bool, error) {
+			return true, nil
+		},
+	}, &MockNotifier{Err: errors.New("inbox unavailable")}, mockLogger())
+
+	if err := service.SetAssignee(context.Background(), 42, 1, &bob); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+}
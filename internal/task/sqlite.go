@@ -0,0 +1,768 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/melnik-dev/go_todo_jwt/internal/user"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/sirupsen/logrus"
+)
+
+// sqliteAccessibleTasks выбирает задачи пространства ?2, которые видит пользователь ?1:
+// свои (владелец) и те, где он исполнитель. Комментариев в SQLite нет.
+const sqliteAccessibleTasks = `SELECT t.*,
+		CASE WHEN t.user_id = ?1 THEN 'owner' ELSE 'assignee' END AS role,
+		CASE WHEN t.user_id = ?1 THEN NULL ELSE u.username END AS shared_by,
+		0 AS comment_count
+	FROM tasks t
+	JOIN users u ON u.id = t.user_id
+	WHERE t.workspace_id = ?2 AND (t.user_id = ?1 OR t.assignee_id = ?1)`
+
+// SQLiteRepository - IRepository для SQLite (db.driver: sqlite). Права и роли
+// такие же, как у MemoryRepository: общего доступа и проектов нет. RLS и
+// триггеров в SQLite нет, поэтому рабочее пространство из ctx проверяется
+// в условиях запросов, а номер изменения, время изменения и надгробия
+// пишутся здесь же, в транзакции с самим изменением.
+type SQLiteRepository struct {
+	db     *db.Db
+	logger *logrus.Logger
+}
+
+func NewSQLiteRepository(db *db.Db, logger *logrus.Logger) *SQLiteRepository {
+	return &SQLiteRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *SQLiteRepository) Create(ctx context.Context, task *Task) (*Task, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": task.UserID,
+		"title":   task.Title,
+	})
+	logRepo.Debug("Attempting to Create")
+
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO tasks (user_id, workspace_id, uid, title, description, updated_at, change_seq)
+				VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7)
+				RETURNING id`
+
+	err = r.withinTx(ctx, func(ctx context.Context) error {
+		seq, err := r.nextChange(ctx)
+		if err != nil {
+			return err
+		}
+		row := r.db.QueryRowContext(ctx, query, task.UserID, workspaceID, uuid.NewString(), task.Title, task.Description, time.Now(), seq)
+		return row.Scan(&task.ID)
+	})
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to insert database")
+		return nil, err
+	}
+
+	logRepo.Debug("Insert database successfully")
+	return task, nil
+}
+
+func (r *SQLiteRepository) Update(ctx context.Context, task *Task) error {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": task.UserID,
+		"task_id": task.ID,
+	})
+	logRepo.Debug("Attempting to Update")
+
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE tasks
+				SET title = ?1, description = ?2, completed = ?3,
+					completed_at = CASE WHEN ?3 THEN COALESCE(completed_at, ?4) END,
+					updated_at = ?4, change_seq = ?5
+				WHERE id = ?6 AND workspace_id = ?7
+					AND (user_id = ?8 OR assignee_id = ?8 AND title = ?1 AND description = ?2)`
+
+	err = r.withinTx(ctx, func(ctx context.Context) error {
+		seq, err := r.nextChange(ctx)
+		if err != nil {
+			return err
+		}
+		result, err := r.db.ExecContext(ctx, query, task.Title, task.Description, task.Completed, time.Now(), seq, task.ID, workspaceID, task.UserID)
+		if err != nil {
+			return err
+		}
+		return r.checkAffected(ctx, result, task, workspaceID)
+	})
+	if err != nil {
+		if errors.Is(err, ErrTaskNotFound) || errors.Is(err, ErrTaskForbidden) {
+			logRepo.WithError(err).Warn("Task was not updated")
+			return err
+		}
+		logRepo.WithError(err).Error("Failed to Update database")
+		return err
+	}
+
+	logRepo.Debug("Update database successfully")
+	return nil
+}
+
+func (r *SQLiteRepository) DeleteById(ctx context.Context, task *Task) error {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": task.UserID,
+		"task_id": task.ID,
+	})
+	logRepo.Debug("Attempting to Delete")
+
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = r.withinTx(ctx, func(ctx context.Context) error {
+		rank, err := r.rank(ctx, task.ID, task.UserID, workspaceID)
+		if err != nil {
+			return err
+		}
+		if rank == 0 {
+			return ErrTaskNotFound
+		}
+		if rank < rankOwner {
+			return ErrTaskForbidden
+		}
+		return r.delete(ctx, task.ID)
+	})
+	if err != nil {
+		if errors.Is(err, ErrTaskNotFound) || errors.Is(err, ErrTaskForbidden) {
+			logRepo.WithError(err).Warn("Task was not deleted")
+			return err
+		}
+		logRepo.WithError(err).Error("Failed to Delete database")
+		return err
+	}
+
+	logRepo.Debug("Delete database successfully")
+	return nil
+}
+
+func (r *SQLiteRepository) GetById(ctx context.Context, task *Task) (*Task, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": task.UserID,
+		"task_id": task.ID,
+	})
+	logRepo.Debug("Attempting to GetById")
+
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := sqliteAccessibleTasks + ` AND t.id = ?3`
+
+	if err = r.db.GetContext(ctx, task, query, task.UserID, workspaceID, task.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logRepo.WithError(err).Warn(ErrTaskNotFound.Error())
+			return nil, ErrTaskNotFound
+		}
+		logRepo.WithError(err).Error("Failed to GetById database")
+		return nil, err
+	}
+
+	logRepo.Debug("GetById database successfully")
+	return task, nil
+}
+
+func (r *SQLiteRepository) GetAll(ctx context.Context, user *user.User) ([]Task, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": user.ID,
+	})
+	logRepo.Debug("Attempting to GetAll")
+
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]Task, 0)
+	query := sqliteAccessibleTasks + ` ORDER BY t.id`
+
+	if err = r.db.SelectContext(ctx, &tasks, query, user.ID, workspaceID); err != nil {
+		logRepo.WithError(err).Error("Failed to GetAll database")
+		return nil, err
+	}
+
+	logRepo.Debug("GetAll database successfully")
+	return tasks, nil
+}
+
+// Each построчно читает доступные пользователю задачи и передаёт их в fn.
+// В режиме WAL чтение не мешает fn писать в базу через другое соединение.
+func (r *SQLiteRepository) Each(ctx context.Context, user *user.User, fn func(task *Task) error) error {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": user.ID,
+	})
+	logRepo.Debug("Attempting to Each")
+
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := sqliteAccessibleTasks + ` ORDER BY t.id`
+
+	rows, err := r.db.QueryxContext(ctx, query, user.ID, workspaceID)
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to Each database")
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var task Task
+		if err := rows.StructScan(&task); err != nil {
+			logRepo.WithError(err).Error("Failed to scan task")
+			return err
+		}
+		if err := fn(&task); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		logRepo.WithError(err).Error("Failed to iterate tasks")
+		return err
+	}
+
+	logRepo.Debug("Each database successfully")
+	return nil
+}
+
+// CreateBatch создаёт задачи в одной транзакции: либо все, либо ни одной
+func (r *SQLiteRepository) CreateBatch(ctx context.Context, tasks []Task) error {
+	logRepo := repositoryLogger(r.logger).WithField("count", len(tasks))
+	logRepo.Debug("Attempting to CreateBatch")
+
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO tasks (user_id, workspace_id, uid, title, description, completed, due_at, completed_at, extras, updated_at, change_seq)
+				VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, CASE WHEN ?6 THEN COALESCE(?8, ?10) END, COALESCE(?9, '{}'), ?10, ?11)
+				RETURNING id`
+
+	err = r.withinTx(ctx, func(ctx context.Context) error {
+		for i := range tasks {
+			task := &tasks[i]
+			seq, err := r.nextChange(ctx)
+			if err != nil {
+				return err
+			}
+			row := r.db.QueryRowContext(ctx, query, task.UserID, workspaceID, uuid.NewString(), task.Title, task.Description,
+				task.Completed, task.DueAt, task.CompletedAt, task.Extras, time.Now(), seq)
+			if err := row.Scan(&task.ID); err != nil {
+				logRepo.WithError(err).Error("Failed to insert database")
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to CreateBatch database")
+		return err
+	}
+
+	logRepo.Debug("CreateBatch database successfully")
+	return nil
+}
+
+// UpsertBatch создаёт или обновляет задачи по UID в одной транзакции и
+// возвращает количество созданных. Задачу с тем же UID из другого
+// пространства обновить нельзя (ErrTaskForbidden), как и под RLS в Postgres.
+func (r *SQLiteRepository) UpsertBatch(ctx context.Context, tasks []Task) (int, error) {
+	logRepo := repositoryLogger(r.logger).WithField("count", len(tasks))
+	logRepo.Debug("Attempting to UpsertBatch")
+
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	// extras без значения (NULL) у существующей задачи не трогаются: форматы,
+	// которые их не знают, не должны стирать чужие поля
+	query := `INSERT INTO tasks (user_id, workspace_id, uid, title, description, completed, due_at, completed_at, extras, updated_at, change_seq)
+				VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, CASE WHEN ?6 THEN COALESCE(?8, ?10) END, COALESCE(?9, '{}'), ?10, ?11)
+				ON CONFLICT (user_id, uid) DO UPDATE
+				SET title = excluded.title, description = excluded.description, completed = excluded.completed,
+					due_at = excluded.due_at, completed_at = excluded.completed_at,
+					extras = COALESCE(?9, tasks.extras), updated_at = excluded.updated_at, change_seq = excluded.change_seq
+				WHERE tasks.workspace_id = excluded.workspace_id
+				RETURNING id, change_seq`
+
+	created := 0
+	err = r.withinTx(ctx, func(ctx context.Context) error {
+		created = 0
+		for i := range tasks {
+			task := &tasks[i]
+
+			var exists bool
+			err := r.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM tasks WHERE user_id = ?1 AND uid = ?2)`, task.UserID, task.UID)
+			if err != nil {
+				return err
+			}
+
+			seq, err := r.nextChange(ctx)
+			if err != nil {
+				return err
+			}
+
+			row := r.db.QueryRowContext(ctx, query, task.UserID, workspaceID, task.UID, task.Title, task.Description,
+				task.Completed, task.DueAt, task.CompletedAt, task.Extras, time.Now(), seq)
+			if err := row.Scan(&task.ID, &task.ChangeSeq); err != nil {
+				// условие WHERE отсекло задачу из другого пространства
+				if errors.Is(err, sql.ErrNoRows) {
+					return ErrTaskForbidden
+				}
+				logRepo.WithError(err).Error("Failed to upsert database")
+				return err
+			}
+			if !exists {
+				created++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrTaskForbidden) {
+			logRepo.WithError(err).Warn("Tasks were not upserted")
+			return 0, err
+		}
+		logRepo.WithError(err).Error("Failed to UpsertBatch database")
+		return 0, err
+	}
+
+	logRepo.WithField("created", created).Debug("UpsertBatch database successfully")
+	return created, nil
+}
+
+func (r *SQLiteRepository) GetByUID(ctx context.Context, task *Task) (*Task, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": task.UserID,
+		"uid":     task.UID,
+	})
+	logRepo.Debug("Attempting to GetByUID")
+
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT * FROM tasks WHERE uid = ?1 AND user_id = ?2 AND workspace_id = ?3`
+
+	if err = r.db.GetContext(ctx, task, query, task.UID, task.UserID, workspaceID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logRepo.WithError(err).Warn(ErrTaskNotFound.Error())
+			return nil, ErrTaskNotFound
+		}
+		logRepo.WithError(err).Error("Failed to GetByUID database")
+		return nil, err
+	}
+
+	logRepo.Debug("GetByUID database successfully")
+	return task, nil
+}
+
+func (r *SQLiteRepository) DeleteByUID(ctx context.Context, task *Task) error {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": task.UserID,
+		"uid":     task.UID,
+	})
+	logRepo.Debug("Attempting to DeleteByUID")
+
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `SELECT id FROM tasks WHERE uid = ?1 AND user_id = ?2 AND workspace_id = ?3`
+
+	err = r.withinTx(ctx, func(ctx context.Context) error {
+		var id int
+		if err := r.db.GetContext(ctx, &id, query, task.UID, task.UserID, workspaceID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrTaskNotFound
+			}
+			return err
+		}
+		return r.delete(ctx, id)
+	})
+	if err != nil {
+		if errors.Is(err, ErrTaskNotFound) {
+			logRepo.Warn(ErrTaskNotFound.Error())
+			return err
+		}
+		logRepo.WithError(err).Error("Failed to DeleteByUID database")
+		return err
+	}
+
+	logRepo.Debug("DeleteByUID database successfully")
+	return nil
+}
+
+// GetChanges возвращает задачи, изменённые после since, и UID удалённых после since задач.
+// Оба запроса идут в одной транзакции, чтобы Token не пропустил изменений между ними.
+func (r *SQLiteRepository) GetChanges(ctx context.Context, user *user.User, since int64) (*ChangeSet, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": user.ID,
+		"since":   since,
+	})
+	logRepo.Debug("Attempting to GetChanges")
+
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := &ChangeSet{
+		Token:   since,
+		Updated: make([]Task, 0),
+		Deleted: make([]string, 0),
+	}
+
+	var tombstones []struct {
+		UID       string `db:"uid"`
+		ChangeSeq int64  `db:"change_seq"`
+	}
+
+	err = r.withinTx(ctx, func(ctx context.Context) error {
+		changes.Updated = changes.Updated[:0]
+		tombstones = tombstones[:0]
+
+		query := `SELECT * FROM tasks WHERE user_id = ?1 AND workspace_id = ?2 AND change_seq > ?3 ORDER BY change_seq`
+
+		if err := r.db.SelectContext(ctx, &changes.Updated, query, user.ID, workspaceID, since); err != nil {
+			logRepo.WithError(err).Error("Failed to GetChanges tasks database")
+			return err
+		}
+
+		query = `SELECT t.uid, t.change_seq FROM task_tombstones t
+				WHERE t.user_id = ?1 AND t.workspace_id = ?2 AND t.change_seq > ?3
+				AND NOT EXISTS (SELECT 1 FROM tasks WHERE tasks.user_id = t.user_id AND tasks.uid = t.uid)
+				ORDER BY t.change_seq`
+
+		if err := r.db.SelectContext(ctx, &tombstones, query, user.ID, workspaceID, since); err != nil {
+			logRepo.WithError(err).Error("Failed to GetChanges tombstones database")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, task := range changes.Updated {
+		changes.Token = max(changes.Token, task.ChangeSeq)
+	}
+	for _, tombstone := range tombstones {
+		changes.Deleted = append(changes.Deleted, tombstone.UID)
+		changes.Token = max(changes.Token, tombstone.ChangeSeq)
+	}
+
+	logRepo.Debug("GetChanges database successfully")
+	return changes, nil
+}
+
+// LatestChange возвращает номер последнего изменения задач пользователя, включая удаления
+func (r *SQLiteRepository) LatestChange(ctx context.Context, user *user.User) (int64, error) {
+	logRepo := repositoryLogger(r.logger).WithField("user_id", user.ID)
+	logRepo.Debug("Attempting to LatestChange")
+
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var token int64
+	query := `SELECT MAX(
+				(SELECT COALESCE(MAX(change_seq), 0) FROM tasks WHERE user_id = ?1 AND workspace_id = ?2),
+				(SELECT COALESCE(MAX(change_seq), 0) FROM task_tombstones WHERE user_id = ?1 AND workspace_id = ?2))`
+
+	if err = r.db.GetContext(ctx, &token, query, user.ID, workspaceID); err != nil {
+		logRepo.WithError(err).Error("Failed to LatestChange database")
+		return 0, err
+	}
+
+	logRepo.Debug("LatestChange database successfully")
+	return token, nil
+}
+
+// SetProject только убирает задачу из проекта: проектов в SQLite нет
+func (r *SQLiteRepository) SetProject(ctx context.Context, task *Task) error {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": task.UserID,
+		"task_id": task.ID,
+	})
+	logRepo.Debug("Attempting to SetProject")
+
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if task.ProjectID != nil {
+		logRepo.Warn(ErrProjectNotFound.Error())
+		return ErrProjectNotFound
+	}
+
+	query := `UPDATE tasks SET project_id = NULL, updated_at = ?1, change_seq = ?2
+				WHERE id = ?3 AND workspace_id = ?4 AND user_id = ?5`
+
+	err = r.withinTx(ctx, func(ctx context.Context) error {
+		seq, err := r.nextChange(ctx)
+		if err != nil {
+			return err
+		}
+		result, err := r.db.ExecContext(ctx, query, time.Now(), seq, task.ID, workspaceID, task.UserID)
+		if err != nil {
+			return err
+		}
+		return r.checkAffected(ctx, result, task, workspaceID)
+	})
+	if err != nil {
+		if errors.Is(err, ErrTaskNotFound) || errors.Is(err, ErrTaskForbidden) {
+			logRepo.WithError(err).Warn("Task was not moved")
+			return err
+		}
+		logRepo.WithError(err).Error("Failed to SetProject database")
+		return err
+	}
+
+	logRepo.Debug("SetProject database successfully")
+	return nil
+}
+
+// GetAssigned возвращает доступные пользователю задачи, где он исполнитель
+func (r *SQLiteRepository) GetAssigned(ctx context.Context, user *user.User) ([]Task, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": user.ID,
+	})
+	logRepo.Debug("Attempting to GetAssigned")
+
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]Task, 0)
+	query := sqliteAccessibleTasks + ` AND t.assignee_id = ?1 ORDER BY t.id`
+
+	if err = r.db.SelectContext(ctx, &tasks, query, user.ID, workspaceID); err != nil {
+		logRepo.WithError(err).Error("Failed to GetAssigned database")
+		return nil, err
+	}
+
+	logRepo.Debug("GetAssigned database successfully")
+	return tasks, nil
+}
+
+// SetAssignee назначает исполнителем владельца или нынешнего исполнителя:
+// без общего доступа задачу больше никто не видит. Возвращает false, если
+// исполнитель не изменился; тогда в task.Title пусто.
+func (r *SQLiteRepository) SetAssignee(ctx context.Context, task *Task) (bool, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": task.UserID,
+		"task_id": task.ID,
+	})
+	logRepo.Debug("Attempting to SetAssignee")
+
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	err = r.withinTx(ctx, func(ctx context.Context) error {
+		changed = false
+		rank, err := r.rank(ctx, task.ID, task.UserID, workspaceID)
+		if err != nil {
+			return err
+		}
+		if rank == 0 {
+			return ErrTaskNotFound
+		}
+		if rank < rankEditor {
+			return ErrTaskForbidden
+		}
+
+		if task.AssigneeID != nil {
+			rank, err = r.rank(ctx, task.ID, *task.AssigneeID, workspaceID)
+			if err != nil {
+				return err
+			}
+			if rank == 0 {
+				return ErrAssigneeNoAccess
+			}
+		}
+
+		seq, err := r.nextChange(ctx)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		query := `UPDATE tasks SET assignee_id = ?1, updated_at = ?2, change_seq = ?3
+				WHERE id = ?4 AND assignee_id IS NOT ?1
+				RETURNING title`
+		err = r.db.GetContext(ctx, &task.Title, query, task.AssigneeID, now, seq, task.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		query = `INSERT INTO task_assignments (task_id, assignee_id, assigned_by, created_at) VALUES (?1, ?2, ?3, ?4)`
+		if _, err = r.db.ExecContext(ctx, query, task.ID, task.AssigneeID, task.UserID, now); err != nil {
+			return err
+		}
+		changed = true
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrTaskNotFound) || errors.Is(err, ErrTaskForbidden) || errors.Is(err, ErrAssigneeNoAccess) {
+			logRepo.WithError(err).Warn("Task was not assigned")
+			return false, err
+		}
+		logRepo.WithError(err).Error("Failed to SetAssignee database")
+		return false, err
+	}
+
+	logRepo.WithField("changed", changed).Debug("SetAssignee database successfully")
+	return changed, nil
+}
+
+// GetAssignments возвращает историю назначений задачи, видимой пользователю
+func (r *SQLiteRepository) GetAssignments(ctx context.Context, task *Task) ([]Assignment, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id": task.UserID,
+		"task_id": task.ID,
+	})
+	logRepo.Debug("Attempting to GetAssignments")
+
+	workspaceID, err := workspaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rank, err := r.rank(ctx, task.ID, task.UserID, workspaceID)
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to GetAssignments database")
+		return nil, err
+	}
+	if rank == 0 {
+		logRepo.Warn(ErrTaskNotFound.Error())
+		return nil, ErrTaskNotFound
+	}
+
+	assignments := make([]Assignment, 0)
+	query := `SELECT a.id, a.task_id, a.assignee_id, u.username AS assignee,
+				a.assigned_by, b.username AS assigned_by_name, a.created_at
+			FROM task_assignments a
+			LEFT JOIN users u ON u.id = a.assignee_id
+			LEFT JOIN users b ON b.id = a.assigned_by
+			WHERE a.task_id = ?1
+			ORDER BY a.id`
+	if err = r.db.SelectContext(ctx, &assignments, query, task.ID); err != nil {
+		logRepo.WithError(err).Error("Failed to GetAssignments database")
+		return nil, err
+	}
+
+	logRepo.Debug("GetAssignments database successfully")
+	return assignments, nil
+}
+
+// withinTx выполняет fn в транзакции; _txlock=immediate сразу берёт блокировку
+// записи, поэтому конкурентные изменения выстраиваются в очередь
+func (r *SQLiteRepository) withinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return db.NewTxManager(r.db).WithinTx(ctx, fn)
+}
+
+// nextChange берёт следующий номер изменения, как nextval('task_change_seq').
+// Вызывается в транзакции изменения: при откате номер тоже откатывается.
+func (r *SQLiteRepository) nextChange(ctx context.Context) (int64, error) {
+	var seq int64
+	query := `UPDATE task_change_seq SET value = value + 1 WHERE id = 1 RETURNING value`
+	if err := r.db.GetContext(ctx, &seq, query); err != nil {
+		return 0, err
+	}
+	return seq, nil
+}
+
+// delete удаляет задачу и оставляет надгробие, как триггер task_tombstone
+func (r *SQLiteRepository) delete(ctx context.Context, id int) error {
+	var deleted struct {
+		UserID      int    `db:"user_id"`
+		UID         string `db:"uid"`
+		WorkspaceID int    `db:"workspace_id"`
+	}
+	query := `DELETE FROM tasks WHERE id = ?1 RETURNING user_id, uid, workspace_id`
+	if err := r.db.GetContext(ctx, &deleted, query, id); err != nil {
+		return err
+	}
+
+	seq, err := r.nextChange(ctx)
+	if err != nil {
+		return err
+	}
+
+	query = `INSERT INTO task_tombstones (user_id, uid, workspace_id, change_seq, deleted_at)
+				VALUES (?1, ?2, ?3, ?4, ?5)
+				ON CONFLICT (user_id, uid) DO UPDATE
+				SET workspace_id = excluded.workspace_id, change_seq = excluded.change_seq, deleted_at = excluded.deleted_at`
+	_, err = r.db.ExecContext(ctx, query, deleted.UserID, deleted.UID, deleted.WorkspaceID, seq, time.Now())
+	return err
+}
+
+// checkAffected объясняет, почему запрос не затронул задачу: она не видна пользователю
+// (ErrTaskNotFound) или видна, но прав не хватает (ErrTaskForbidden)
+func (r *SQLiteRepository) checkAffected(ctx context.Context, result sql.Result, task *Task, workspaceID int) error {
+	row, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if row > 0 {
+		return nil
+	}
+
+	rank, err := r.rank(ctx, task.ID, task.UserID, workspaceID)
+	if err != nil {
+		return err
+	}
+	if rank > 0 {
+		return ErrTaskForbidden
+	}
+	return ErrTaskNotFound
+}
+
+// rank возвращает роль пользователя в задаче пространства (0 - задача не видна):
+// владелец или исполнитель, как memoryRank
+func (r *SQLiteRepository) rank(ctx context.Context, taskID, userID, workspaceID int) (int, error) {
+	var rank int
+	query := `SELECT CASE WHEN user_id = ?2 THEN 3 WHEN assignee_id = ?2 THEN 1 ELSE 0 END
+				FROM tasks WHERE id = ?1 AND workspace_id = ?3`
+
+	err := r.db.GetContext(ctx, &rank, query, taskID, userID, workspaceID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return rank, nil
+}
@@ -0,0 +1,514 @@
+// Package tasktest - общий набор проверок task.IRepository. Его проходят все
+// реализации хранилища, чтобы их поведение не расходилось.
+package tasktest
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/melnik-dev/go_todo_jwt/internal/task"
+	"github.com/melnik-dev/go_todo_jwt/internal/user"
+	"github.com/melnik-dev/go_todo_jwt/internal/user/usertest"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+)
+
+// Env - хранилища одной реализации
+type Env struct {
+	Tasks task.IRepository
+	Users user.IRepository
+	// Workspace возвращает личное рабочее пространство пользователя
+	Workspace func(ctx context.Context, userID int) (int, error)
+}
+
+// Run проверяет хранилища, которые newEnv создаёт для каждого теста
+func Run(t *testing.T, newEnv func(t *testing.T) Env) {
+	tests := map[string]func(t *testing.T, env Env){
+		"NoWorkspace":     testNoWorkspace,
+		"Create":          testCreate,
+		"UserScope":       testUserScope,
+		"Update":          testUpdate,
+		"GetAll":          testGetAll,
+		"CreateBatch":     testCreateBatch,
+		"UpsertBatch":     testUpsertBatch,
+		"Changes":         testChanges,
+		"DeleteById":      testDeleteById,
+		"SetProject":      testSetProject,
+		"SetAssignee":     testSetAssignee,
+		"ForeignAssignee": testForeignAssignee,
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test(t, newEnv(t))
+		})
+	}
+}
+
+// newUser создаёт пользователя и контекст его личного рабочего пространства
+func newUser(t *testing.T, env Env) (*user.User, context.Context) {
+	t.Helper()
+
+	u := usertest.CreateUser(t, env.Users)
+	workspaceID, err := env.Workspace(context.Background(), u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u, db.WithWorkspace(context.Background(), workspaceID)
+}
+
+func create(t *testing.T, env Env, ctx context.Context, userID int, title string) *task.Task {
+	t.Helper()
+
+	created, err := env.Tasks.Create(ctx, &task.Task{UserID: userID, Title: title, Description: title + " description"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ID == 0 {
+		t.Fatal("expected ID of created task")
+	}
+	return created
+}
+
+func get(t *testing.T, env Env, ctx context.Context, userID, id int) *task.Task {
+	t.Helper()
+
+	got, err := env.Tasks.GetById(ctx, &task.Task{ID: id, UserID: userID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func ids(tasks []task.Task) []int {
+	result := make([]int, 0, len(tasks))
+	for _, t := range tasks {
+		result = append(result, t.ID)
+	}
+	return result
+}
+
+func testNoWorkspace(t *testing.T, env Env) {
+	u := usertest.CreateUser(t, env.Users)
+
+	if _, err := env.Tasks.Create(context.Background(), &task.Task{UserID: u.ID, Title: "title"}); !errors.Is(err, db.ErrNoWorkspace) {
+		t.Errorf("expected %v, got %v", db.ErrNoWorkspace, err)
+	}
+	if _, err := env.Tasks.GetAll(context.Background(), u); !errors.Is(err, db.ErrNoWorkspace) {
+		t.Errorf("expected %v, got %v", db.ErrNoWorkspace, err)
+	}
+}
+
+func testCreate(t *testing.T, env Env) {
+	u, ctx := newUser(t, env)
+	created := create(t, env, ctx, u.ID, "first")
+
+	got := get(t, env, ctx, u.ID, created.ID)
+	if got.Title != "first" || got.Description != "first description" || got.Completed || got.UserID != u.ID {
+		t.Errorf("unexpected task %+v", got)
+	}
+	if got.Role != task.RoleOwner || got.SharedBy != nil {
+		t.Errorf("expected own task, got role %q shared by %v", got.Role, got.SharedBy)
+	}
+	if got.CommentCount == nil || *got.CommentCount != 0 {
+		t.Errorf("expected 0 comments, got %v", got.CommentCount)
+	}
+	if got.UID == "" || got.ChangeSeq == 0 || got.UpdatedAt.IsZero() {
+		t.Errorf("expected uid, change_seq and updated_at defaults, got %+v", got)
+	}
+	if got.Extras == nil || len(got.Extras) != 0 {
+		t.Errorf("expected empty extras, got %v", got.Extras)
+	}
+	if got.CompletedAt != nil || got.ProjectID != nil || got.AssigneeID != nil {
+		t.Errorf("expected no completed_at, project and assignee, got %+v", got)
+	}
+}
+
+func testUserScope(t *testing.T, env Env) {
+	owner, ownerCtx := newUser(t, env)
+	other, otherCtx := newUser(t, env)
+	created := create(t, env, ownerCtx, owner.ID, "private")
+	stored := get(t, env, ownerCtx, owner.ID, created.ID)
+
+	foreign := &task.Task{ID: created.ID, UserID: other.ID, Title: "stolen"}
+	if _, err := env.Tasks.GetById(otherCtx, foreign); !errors.Is(err, task.ErrTaskNotFound) {
+		t.Errorf("GetById: expected %v, got %v", task.ErrTaskNotFound, err)
+	}
+	if err := env.Tasks.Update(otherCtx, foreign); !errors.Is(err, task.ErrTaskNotFound) {
+		t.Errorf("Update: expected %v, got %v", task.ErrTaskNotFound, err)
+	}
+	if err := env.Tasks.DeleteById(otherCtx, foreign); !errors.Is(err, task.ErrTaskNotFound) {
+		t.Errorf("DeleteById: expected %v, got %v", task.ErrTaskNotFound, err)
+	}
+	if _, err := env.Tasks.GetByUID(otherCtx, &task.Task{UserID: other.ID, UID: stored.UID}); !errors.Is(err, task.ErrTaskNotFound) {
+		t.Errorf("GetByUID: expected %v, got %v", task.ErrTaskNotFound, err)
+	}
+	if err := env.Tasks.DeleteByUID(otherCtx, &task.Task{UserID: other.ID, UID: stored.UID}); !errors.Is(err, task.ErrTaskNotFound) {
+		t.Errorf("DeleteByUID: expected %v, got %v", task.ErrTaskNotFound, err)
+	}
+
+	tasks, err := env.Tasks.GetAll(otherCtx, other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 0 {
+		t.Errorf("expected no tasks of other user, got %v", ids(tasks))
+	}
+
+	if got := get(t, env, ownerCtx, owner.ID, created.ID); got.Title != "private" {
+		t.Errorf("expected task to stay unchanged, got %+v", got)
+	}
+}
+
+func testUpdate(t *testing.T, env Env) {
+	u, ctx := newUser(t, env)
+	created := create(t, env, ctx, u.ID, "title")
+	before := get(t, env, ctx, u.ID, created.ID)
+
+	update := &task.Task{ID: created.ID, UserID: u.ID, Title: "done", Description: "new", Completed: true}
+	if err := env.Tasks.Update(ctx, update); err != nil {
+		t.Fatal(err)
+	}
+	completed := get(t, env, ctx, u.ID, created.ID)
+	if completed.Title != "done" || completed.Description != "new" || !completed.Completed || completed.CompletedAt == nil {
+		t.Fatalf("unexpected task after update %+v", completed)
+	}
+	if completed.ChangeSeq <= before.ChangeSeq {
+		t.Errorf("expected change_seq after %d, got %d", before.ChangeSeq, completed.ChangeSeq)
+	}
+
+	// повторное выполнение не сдвигает время выполнения
+	if err := env.Tasks.Update(ctx, update); err != nil {
+		t.Fatal(err)
+	}
+	if again := get(t, env, ctx, u.ID, created.ID); again.CompletedAt == nil || !again.CompletedAt.Equal(*completed.CompletedAt) {
+		t.Errorf("expected completed_at %v, got %v", completed.CompletedAt, again.CompletedAt)
+	}
+
+	update.Completed = false
+	if err := env.Tasks.Update(ctx, update); err != nil {
+		t.Fatal(err)
+	}
+	if reopened := get(t, env, ctx, u.ID, created.ID); reopened.Completed || reopened.CompletedAt != nil {
+		t.Errorf("expected reopened task, got %+v", reopened)
+	}
+
+	missing := &task.Task{ID: created.ID + 1_000_000, UserID: u.ID, Title: "missing"}
+	if err := env.Tasks.Update(ctx, missing); !errors.Is(err, task.ErrTaskNotFound) {
+		t.Errorf("expected %v, got %v", task.ErrTaskNotFound, err)
+	}
+}
+
+func testGetAll(t *testing.T, env Env) {
+	u, ctx := newUser(t, env)
+	var want []int
+	for _, title := range []string{"a", "b", "c"} {
+		want = append(want, create(t, env, ctx, u.ID, title).ID)
+	}
+
+	tasks, err := env.Tasks.GetAll(ctx, u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(ids(tasks), want) {
+		t.Errorf("expected tasks %v, got %v", want, ids(tasks))
+	}
+	for _, got := range tasks {
+		if got.Role != task.RoleOwner {
+			t.Errorf("expected role %q, got %q", task.RoleOwner, got.Role)
+		}
+	}
+
+	var each []int
+	err = env.Tasks.Each(ctx, u, func(task *task.Task) error {
+		each = append(each, task.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(each, want) {
+		t.Errorf("expected Each to yield %v, got %v", want, each)
+	}
+
+	errStop := errors.New("stop")
+	calls := 0
+	err = env.Tasks.Each(ctx, u, func(*task.Task) error {
+		calls++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || calls != 1 {
+		t.Errorf("expected Each to stop with %v after 1 call, got %v after %d", errStop, err, calls)
+	}
+}
+
+func testCreateBatch(t *testing.T, env Env) {
+	u, ctx := newUser(t, env)
+	due := time.Date(2030, 1, 2, 10, 0, 0, 0, time.UTC)
+	done := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	tasks := []task.Task{
+		{UserID: u.ID, Title: "imported", DueAt: &due, Extras: task.Extras{"priority": "A"}},
+		{UserID: u.ID, Title: "done", Completed: true, CompletedAt: &done},
+		{UserID: u.ID, Title: "done now", Completed: true},
+		{UserID: u.ID, Title: "open", CompletedAt: &done},
+	}
+	if err := env.Tasks.CreateBatch(ctx, tasks); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make([]*task.Task, len(tasks))
+	for i := range tasks {
+		if tasks[i].ID == 0 {
+			t.Fatalf("expected ID of task %d", i)
+		}
+		got[i] = get(t, env, ctx, u.ID, tasks[i].ID)
+	}
+
+	if got[0].DueAt == nil || !got[0].DueAt.Equal(due) || !maps.Equal(got[0].Extras, task.Extras{"priority": "A"}) {
+		t.Errorf("expected due_at and extras to be kept, got %+v", got[0])
+	}
+	if got[1].CompletedAt == nil || !got[1].CompletedAt.Equal(done) {
+		t.Errorf("expected completed_at %v, got %v", done, got[1].CompletedAt)
+	}
+	if got[2].CompletedAt == nil {
+		t.Error("expected completed_at for completed task")
+	}
+	if got[3].CompletedAt != nil {
+		t.Errorf("expected no completed_at for open task, got %v", got[3].CompletedAt)
+	}
+	if got[1].Extras == nil || len(got[1].Extras) != 0 {
+		t.Errorf("expected empty extras, got %v", got[1].Extras)
+	}
+}
+
+func testUpsertBatch(t *testing.T, env Env) {
+	u, ctx := newUser(t, env)
+
+	tasks := []task.Task{
+		{UserID: u.ID, UID: "a", Title: "A", Extras: task.Extras{"priority": "B"}},
+		{UserID: u.ID, UID: "b", Title: "B"},
+	}
+	created, err := env.Tasks.UpsertBatch(ctx, tasks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created != 2 || tasks[0].ID == 0 || tasks[0].ChangeSeq == 0 {
+		t.Fatalf("expected 2 created tasks with id and change_seq, got %d: %+v", created, tasks)
+	}
+
+	// без extras обновление не стирает сохранённые поля
+	update := []task.Task{{UserID: u.ID, UID: "a", Title: "A2", Completed: true}}
+	created, err = env.Tasks.UpsertBatch(ctx, update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created != 0 || update[0].ID != tasks[0].ID || update[0].ChangeSeq <= tasks[1].ChangeSeq {
+		t.Errorf("expected update of task %d with new change_seq, got %d created: %+v", tasks[0].ID, created, update[0])
+	}
+
+	got, err := env.Tasks.GetByUID(ctx, &task.Task{UserID: u.ID, UID: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != tasks[0].ID || got.Title != "A2" || !got.Completed || got.CompletedAt == nil {
+		t.Errorf("unexpected task after upsert %+v", got)
+	}
+	if !maps.Equal(got.Extras, task.Extras{"priority": "B"}) {
+		t.Errorf("expected extras to be kept, got %v", got.Extras)
+	}
+
+	// UID уникален только у владельца
+	other, otherCtx := newUser(t, env)
+	created, err = env.Tasks.UpsertBatch(otherCtx, []task.Task{{UserID: other.ID, UID: "a", Title: "other"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created != 1 {
+		t.Errorf("expected task of other user to be created, got %d", created)
+	}
+}
+
+func testChanges(t *testing.T, env Env) {
+	u, ctx := newUser(t, env)
+
+	token, err := env.Tasks.LatestChange(ctx, u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != 0 {
+		t.Errorf("expected no changes of new user, got %d", token)
+	}
+
+	if _, err = env.Tasks.UpsertBatch(ctx, []task.Task{{UserID: u.ID, UID: "a", Title: "A"}, {UserID: u.ID, UID: "b", Title: "B"}}); err != nil {
+		t.Fatal(err)
+	}
+	changes, err := env.Tasks.GetChanges(ctx, u, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes.Updated) != 2 || changes.Updated[0].UID != "a" || changes.Updated[1].UID != "b" || len(changes.Deleted) != 0 {
+		t.Fatalf("expected updated a and b, got %+v", changes)
+	}
+	if changes.Token != changes.Updated[1].ChangeSeq {
+		t.Errorf("expected token %d, got %d", changes.Updated[1].ChangeSeq, changes.Token)
+	}
+	token = changes.Token
+
+	if err = env.Tasks.DeleteByUID(ctx, &task.Task{UserID: u.ID, UID: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = env.Tasks.DeleteByUID(ctx, &task.Task{UserID: u.ID, UID: "a"}); !errors.Is(err, task.ErrTaskNotFound) {
+		t.Errorf("expected %v, got %v", task.ErrTaskNotFound, err)
+	}
+	if _, err = env.Tasks.GetByUID(ctx, &task.Task{UserID: u.ID, UID: "a"}); !errors.Is(err, task.ErrTaskNotFound) {
+		t.Errorf("expected %v, got %v", task.ErrTaskNotFound, err)
+	}
+
+	changes, err = env.Tasks.GetChanges(ctx, u, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes.Updated) != 0 || !slices.Equal(changes.Deleted, []string{"a"}) || changes.Token <= token {
+		t.Fatalf("expected deleted a after %d, got %+v", token, changes)
+	}
+	latest, err := env.Tasks.LatestChange(ctx, u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest != changes.Token {
+		t.Errorf("expected latest change %d, got %d", changes.Token, latest)
+	}
+
+	// задача, созданная заново с тем же UID, больше не считается удалённой
+	if _, err = env.Tasks.UpsertBatch(ctx, []task.Task{{UserID: u.ID, UID: "a", Title: "A again"}}); err != nil {
+		t.Fatal(err)
+	}
+	changes, err = env.Tasks.GetChanges(ctx, u, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes.Updated) != 1 || changes.Updated[0].UID != "a" || len(changes.Deleted) != 0 {
+		t.Errorf("expected recreated a, got %+v", changes)
+	}
+}
+
+func testDeleteById(t *testing.T, env Env) {
+	u, ctx := newUser(t, env)
+	created := get(t, env, ctx, u.ID, create(t, env, ctx, u.ID, "title").ID)
+
+	if err := env.Tasks.DeleteById(ctx, &task.Task{ID: created.ID, UserID: u.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.Tasks.GetById(ctx, &task.Task{ID: created.ID, UserID: u.ID}); !errors.Is(err, task.ErrTaskNotFound) {
+		t.Errorf("expected %v, got %v", task.ErrTaskNotFound, err)
+	}
+	if err := env.Tasks.DeleteById(ctx, &task.Task{ID: created.ID, UserID: u.ID}); !errors.Is(err, task.ErrTaskNotFound) {
+		t.Errorf("expected %v, got %v", task.ErrTaskNotFound, err)
+	}
+
+	changes, err := env.Tasks.GetChanges(ctx, u, created.ChangeSeq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(changes.Deleted, []string{created.UID}) {
+		t.Errorf("expected deleted %s, got %v", created.UID, changes.Deleted)
+	}
+}
+
+func testSetProject(t *testing.T, env Env) {
+	u, ctx := newUser(t, env)
+	created := create(t, env, ctx, u.ID, "title")
+
+	if err := env.Tasks.SetProject(ctx, &task.Task{ID: created.ID, UserID: u.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if got := get(t, env, ctx, u.ID, created.ID); got.ProjectID != nil {
+		t.Errorf("expected no project, got %v", *got.ProjectID)
+	}
+
+	missing := 1_000_000_000
+	err := env.Tasks.SetProject(ctx, &task.Task{ID: created.ID, UserID: u.ID, ProjectID: &missing})
+	if !errors.Is(err, task.ErrProjectNotFound) {
+		t.Errorf("expected %v, got %v", task.ErrProjectNotFound, err)
+	}
+
+	err = env.Tasks.SetProject(ctx, &task.Task{ID: created.ID + 1_000_000, UserID: u.ID})
+	if !errors.Is(err, task.ErrTaskNotFound) {
+		t.Errorf("expected %v, got %v", task.ErrTaskNotFound, err)
+	}
+}
+
+func testSetAssignee(t *testing.T, env Env) {
+	u, ctx := newUser(t, env)
+	created := create(t, env, ctx, u.ID, "assigned")
+
+	assign := &task.Task{ID: created.ID, UserID: u.ID, AssigneeID: &u.ID}
+	changed, err := env.Tasks.SetAssignee(ctx, assign)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed || assign.Title != "assigned" {
+		t.Fatalf("expected assignee to change with title, got %v %q", changed, assign.Title)
+	}
+
+	again := &task.Task{ID: created.ID, UserID: u.ID, AssigneeID: &u.ID}
+	if changed, err = env.Tasks.SetAssignee(ctx, again); err != nil || changed || again.Title != "" {
+		t.Errorf("expected no change, got %v %q %v", changed, again.Title, err)
+	}
+
+	assigned, err := env.Tasks.GetAssigned(ctx, u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(ids(assigned), []int{created.ID}) {
+		t.Errorf("expected assigned task %d, got %v", created.ID, ids(assigned))
+	}
+
+	if changed, err = env.Tasks.SetAssignee(ctx, &task.Task{ID: created.ID, UserID: u.ID}); err != nil || !changed {
+		t.Fatalf("expected assignee to be removed, got %v %v", changed, err)
+	}
+	if assigned, err = env.Tasks.GetAssigned(ctx, u); err != nil || len(assigned) != 0 {
+		t.Errorf("expected no assigned tasks, got %v %v", ids(assigned), err)
+	}
+
+	history, err := env.Tasks.GetAssignments(ctx, &task.Task{ID: created.ID, UserID: u.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected 2 assignments, got %+v", history)
+	}
+	first, second := history[0], history[1]
+	if first.AssigneeID == nil || *first.AssigneeID != u.ID || first.Assignee == nil || *first.Assignee != u.Name {
+		t.Errorf("expected assignment to %s, got %+v", u.Name, first)
+	}
+	if first.AssignedBy == nil || *first.AssignedBy != u.Name || first.CreatedAt.IsZero() {
+		t.Errorf("expected assignment by %s, got %+v", u.Name, first)
+	}
+	if second.AssigneeID != nil || second.Assignee != nil || second.ID <= first.ID {
+		t.Errorf("expected unassignment after %d, got %+v", first.ID, second)
+	}
+}
+
+func testForeignAssignee(t *testing.T, env Env) {
+	owner, ownerCtx := newUser(t, env)
+	other, otherCtx := newUser(t, env)
+	created := create(t, env, ownerCtx, owner.ID, "private")
+
+	// исполнителем может быть только тот, кто видит задачу
+	_, err := env.Tasks.SetAssignee(ownerCtx, &task.Task{ID: created.ID, UserID: owner.ID, AssigneeID: &other.ID})
+	if !errors.Is(err, task.ErrAssigneeNoAccess) {
+		t.Errorf("expected %v, got %v", task.ErrAssigneeNoAccess, err)
+	}
+
+	_, err = env.Tasks.SetAssignee(otherCtx, &task.Task{ID: created.ID, UserID: other.ID, AssigneeID: &other.ID})
+	if !errors.Is(err, task.ErrTaskNotFound) {
+		t.Errorf("expected %v, got %v", task.ErrTaskNotFound, err)
+	}
+	if _, err = env.Tasks.GetAssignments(otherCtx, &task.Task{ID: created.ID, UserID: other.ID}); !errors.Is(err, task.ErrTaskNotFound) {
+		t.Errorf("expected %v, got %v", task.ErrTaskNotFound, err)
+	}
+}
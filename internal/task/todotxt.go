@@ -0,0 +1,103 @@
+package task
+
+import (
+	"github.com/melnik-dev/go_todo_jwt/pkg/todotxt"
+	"strings"
+	"time"
+)
+
+// ключи Extras для полей todo.txt, которых нет в модели задачи
+const (
+	extraTodoTxtPriority = "todotxt.priority"
+	extraTodoTxtCreated  = "todotxt.created"
+	extraTodoTxtProjects = "todotxt.projects"
+	extraTodoTxtContexts = "todotxt.contexts"
+	extraTodoTxtTags     = "todotxt.tags"
+	// исходный порядок слов, если метки стояли не в конце строки
+	extraTodoTxtBody = "todotxt.body"
+
+	todoTxtDate   = "2006-01-02"
+	todoTxtDueTag = "due"
+)
+
+// ToTodoTxt переводит задачу в строку todo.txt, возвращая на место сохранённые в Extras поля.
+// Описание в todo.txt не попадает.
+func ToTodoTxt(t *Task) todotxt.Task {
+	item := todotxt.Task{
+		Completed: t.Completed,
+		Priority:  t.Extras[extraTodoTxtPriority],
+		Text:      t.Title,
+		Projects:  strings.Fields(t.Extras[extraTodoTxtProjects]),
+		Contexts:  strings.Fields(t.Extras[extraTodoTxtContexts]),
+		Body:      t.Extras[extraTodoTxtBody],
+	}
+	if t.Completed && t.CompletedAt != nil {
+		date := truncateDate(*t.CompletedAt)
+		item.CompletionDate = &date
+	}
+	if created, err := time.Parse(todoTxtDate, t.Extras[extraTodoTxtCreated]); err == nil {
+		item.CreationDate = &created
+	}
+	if t.DueAt != nil {
+		item.Tags = append(item.Tags, todotxt.Tag{Key: todoTxtDueTag, Value: t.DueAt.UTC().Format(todoTxtDate)})
+	}
+	for _, raw := range strings.Fields(t.Extras[extraTodoTxtTags]) {
+		if key, value, ok := strings.Cut(raw, ":"); ok {
+			item.Tags = append(item.Tags, todotxt.Tag{Key: key, Value: value})
+		}
+	}
+	return item
+}
+
+// FromTodoTxt переводит строку todo.txt в задачу. Поля без аналога в модели
+// (приоритет, дата создания, +project, @context, прочие key:value) уходят в Extras.
+func FromTodoTxt(item *todotxt.Task) Task {
+	t := Task{
+		Title:     item.Text,
+		Completed: item.Completed,
+	}
+	if item.Completed {
+		t.CompletedAt = item.CompletionDate
+	}
+
+	extras := Extras{}
+	if item.Priority != "" {
+		extras[extraTodoTxtPriority] = item.Priority
+	}
+	if item.CreationDate != nil {
+		extras[extraTodoTxtCreated] = item.CreationDate.Format(todoTxtDate)
+	}
+	if len(item.Projects) > 0 {
+		extras[extraTodoTxtProjects] = strings.Join(item.Projects, " ")
+	}
+	if len(item.Contexts) > 0 {
+		extras[extraTodoTxtContexts] = strings.Join(item.Contexts, " ")
+	}
+	if item.Body != "" {
+		extras[extraTodoTxtBody] = item.Body
+	}
+
+	var tags []string
+	for _, tag := range item.Tags {
+		if tag.Key == todoTxtDueTag && t.DueAt == nil {
+			if due, err := time.Parse(todoTxtDate, tag.Value); err == nil {
+				t.DueAt = &due
+				continue
+			}
+		}
+		tags = append(tags, tag.Key+":"+tag.Value)
+	}
+	if len(tags) > 0 {
+		extras[extraTodoTxtTags] = strings.Join(tags, " ")
+	}
+
+	if len(extras) > 0 {
+		t.Extras = extras
+	}
+	return t
+}
+
+func truncateDate(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
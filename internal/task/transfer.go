@@ -0,0 +1,327 @@
+package task
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/melnik-dev/go_todo_jwt/pkg/todotxt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type Format string
+
+const (
+	FormatJSON     Format = "json"
+	FormatCSV      Format = "csv"
+	FormatMarkdown Format = "md"
+	FormatTodoTxt  Format = "txt"
+)
+
+var csvHeader = []string{"id", "title", "description", "completed"}
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatJSON, FormatCSV, FormatMarkdown, FormatTodoTxt:
+		return f, nil
+	case "":
+		return FormatJSON, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	case FormatTodoTxt:
+		return todotxt.ContentType
+	default:
+		return "application/json; charset=utf-8"
+	}
+}
+
+// encoder пишет задачи по одной, не держа весь список в памяти
+type encoder interface {
+	Begin() error
+	Encode(task *Task) error
+	End() error
+}
+
+func newEncoder(format Format, w io.Writer) encoder {
+	switch format {
+	case FormatCSV:
+		return &csvEncoder{w: csv.NewWriter(w)}
+	case FormatMarkdown:
+		return &markdownEncoder{w: w}
+	case FormatTodoTxt:
+		return &todoTxtEncoder{w: w}
+	default:
+		return &jsonEncoder{w: w}
+	}
+}
+
+type jsonEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonEncoder) Begin() error {
+	_, err := io.WriteString(e.w, "[")
+	return err
+}
+
+func (e *jsonEncoder) Encode(task *Task) error {
+	if e.count > 0 {
+		if _, err := io.WriteString(e.w, ","); err != nil {
+			return err
+		}
+	}
+	e.count++
+	b, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(b)
+	return err
+}
+
+func (e *jsonEncoder) End() error {
+	_, err := io.WriteString(e.w, "]\n")
+	return err
+}
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvEncoder) Begin() error {
+	return e.w.Write(csvHeader)
+}
+
+func (e *csvEncoder) Encode(task *Task) error {
+	return e.w.Write([]string{
+		strconv.Itoa(task.ID),
+		task.Title,
+		task.Description,
+		strconv.FormatBool(task.Completed),
+	})
+}
+
+func (e *csvEncoder) End() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type markdownEncoder struct {
+	w io.Writer
+}
+
+func (e *markdownEncoder) Begin() error {
+	return nil
+}
+
+func (e *markdownEncoder) Encode(task *Task) error {
+	mark := " "
+	if task.Completed {
+		mark = "x"
+	}
+	if _, err := fmt.Fprintf(e.w, "- [%s] %s\n", mark, oneLine(task.Title)); err != nil {
+		return err
+	}
+	if task.Description == "" {
+		return nil
+	}
+	for _, line := range strings.Split(task.Description, "\n") {
+		if _, err := fmt.Fprintf(e.w, "  %s\n", line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *markdownEncoder) End() error {
+	return nil
+}
+
+type todoTxtEncoder struct {
+	w io.Writer
+}
+
+func (e *todoTxtEncoder) Begin() error {
+	return nil
+}
+
+func (e *todoTxtEncoder) Encode(task *Task) error {
+	_, err := fmt.Fprintln(e.w, ToTodoTxt(task).String())
+	return err
+}
+
+func (e *todoTxtEncoder) End() error {
+	return nil
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// importRow - задача из импортируемого файла вместе с номером строки для отчёта об ошибках
+type importRow struct {
+	Row  int
+	Task Task
+	Err  error
+}
+
+func decodeTasks(format Format, r io.Reader) ([]importRow, error) {
+	switch format {
+	case FormatCSV:
+		return decodeCSV(r)
+	case FormatMarkdown:
+		return decodeMarkdown(r)
+	case FormatTodoTxt:
+		return decodeTodoTxt(r)
+	default:
+		return decodeJSON(r)
+	}
+}
+
+func decodeJSON(r io.Reader) ([]importRow, error) {
+	var items []struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Completed   bool   `json:"completed"`
+	}
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrImportMalformed, err)
+	}
+
+	rows := make([]importRow, 0, len(items))
+	for i, item := range items {
+		rows = append(rows, importRow{
+			Row: i + 1,
+			Task: Task{
+				Title:       item.Title,
+				Description: item.Description,
+				Completed:   item.Completed,
+			},
+		})
+	}
+	return rows, nil
+}
+
+func decodeCSV(r io.Reader) ([]importRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrImportMalformed, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, fmt.Errorf("%w: missing title column", ErrImportMalformed)
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+
+	var rows []importRow
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrImportMalformed, err)
+		}
+
+		row := importRow{
+			Row: line,
+			Task: Task{
+				Title:       field(record, "title"),
+				Description: field(record, "description"),
+			},
+		}
+		if completed := strings.TrimSpace(field(record, "completed")); completed != "" {
+			row.Task.Completed, row.Err = strconv.ParseBool(completed)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func decodeMarkdown(r io.Reader) ([]importRow, error) {
+	var rows []importRow
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		trimmed := strings.TrimSpace(text)
+
+		if title, completed, ok := parseCheckbox(trimmed); ok {
+			rows = append(rows, importRow{
+				Row:  line,
+				Task: Task{Title: title, Completed: completed},
+			})
+			continue
+		}
+
+		// строки с отступом под пунктом считаются описанием задачи
+		if len(rows) > 0 && trimmed != "" && text != trimmed {
+			last := &rows[len(rows)-1].Task
+			if last.Description != "" {
+				last.Description += "\n"
+			}
+			last.Description += trimmed
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrImportMalformed, err)
+	}
+	return rows, nil
+}
+
+func decodeTodoTxt(r io.Reader) ([]importRow, error) {
+	var rows []importRow
+	scanner := todotxt.NewScanner(r)
+	for scanner.Scan() {
+		item := scanner.Task()
+		rows = append(rows, importRow{
+			Row:  scanner.Line(),
+			Task: FromTodoTxt(&item),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrImportMalformed, err)
+	}
+	return rows, nil
+}
+
+func parseCheckbox(line string) (string, bool, bool) {
+	if !strings.HasPrefix(line, "- [") && !strings.HasPrefix(line, "* [") {
+		return "", false, false
+	}
+	if len(line) < 5 || line[4] != ']' {
+		return "", false, false
+	}
+	switch line[3] {
+	case ' ':
+		return strings.TrimSpace(line[5:]), false, true
+	case 'x', 'X':
+		return strings.TrimSpace(line[5:]), true, true
+	default:
+		return "", false, false
+	}
+}
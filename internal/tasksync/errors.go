@@ -0,0 +1,8 @@
+package tasksync
+
+import "errors"
+
+var (
+	ErrInvalidToken  = errors.New("invalid sync token")
+	ErrTitleRequired = errors.New("title is required to create a task")
+)
@@ -0,0 +1,104 @@
+package tasksync
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/pkg/logger"
+	"github.com/melnik-dev/go_todo_jwt/pkg/middleware"
+	"github.com/melnik-dev/go_todo_jwt/pkg/response"
+	"github.com/sirupsen/logrus"
+)
+
+type HandlerDeps struct {
+	SyncService IService
+	*configs.Config
+	// Workspace выбирает рабочее пространство запроса
+	Workspace gin.HandlerFunc
+}
+
+type Handler struct {
+	SyncService IService
+	*configs.Config
+}
+
+func NewHandler(r *gin.Engine, deps *HandlerDeps) {
+	handler := &Handler{
+		SyncService: deps.SyncService,
+		Config:      deps.Config,
+	}
+	sync := r.Group("/sync")
+	sync.Use(middleware.IsAuthed(handler.Config.JWT.Secret))
+	if deps.Workspace != nil {
+		sync.Use(deps.Workspace)
+	}
+	sync.GET("", handler.Pull)
+	sync.POST("", handler.Push)
+}
+
+// Pull отдаёт изменения задач после токена since; без since - все задачи
+func (h *Handler) Pull(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Pull")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var query PullQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind query")
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	changes, err := h.SyncService.Pull(c.Request.Context(), userID, query.Since)
+	if err != nil {
+		if errors.Is(err, ErrInvalidToken) {
+			logHandle.Warn(err.Error())
+			response.BadRequest(c, err.Error())
+			return
+		}
+		logHandle.WithError(err).Error("Failed to Pull")
+		response.InternalServerError(c, "Failed to get changes")
+		return
+	}
+
+	logHandle.Debug("Pull successfully")
+	response.Success(c, http.StatusOK, changes)
+}
+
+// Push применяет пакет изменений клиента и возвращает итог по каждому
+func (h *Handler) Push(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Push")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req PushRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind request")
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	results, err := h.SyncService.Push(c.Request.Context(), userID, req.Mutations)
+	if err != nil {
+		logHandle.WithError(err).Error("Failed to Push")
+		response.InternalServerError(c, "Failed to apply changes")
+		return
+	}
+
+	logHandle.Debug("Push successfully")
+	response.Success(c, http.StatusOK, PushResponse{Results: results})
+}
+
+func handlerLogger(c *gin.Context) *logrus.Entry {
+	return logger.FromContext(c).WithField("layer", "Handler tasksync layer")
+}
@@ -0,0 +1,137 @@
+package tasksync_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/internal/tasksync"
+	"github.com/sirupsen/logrus"
+)
+
+// MockSyncService встраивает tasksync.IService, чтобы переопределять только нужные методы
+type MockSyncService struct {
+	tasksync.IService
+	PullMock func(userID int, since string) (*tasksync.Changes, error)
+	PushMock func(userID int, mutations []tasksync.Mutation) ([]tasksync.Result, error)
+}
+
+func (m *MockSyncService) Pull(ctx context.Context, userID int, since string) (*tasksync.Changes, error) {
+	return m.PullMock(userID, since)
+}
+
+func (m *MockSyncService) Push(ctx context.Context, userID int, mutations []tasksync.Mutation) ([]tasksync.Result, error) {
+	return m.PushMock(userID, mutations)
+}
+
+func mockGin() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		c.Set("logger", logrus.NewEntry(logger))
+		c.Set("user_id", 42)
+		c.Next()
+	})
+	return r
+}
+
+func TestHandler_Pull(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{name: "success", wantCode: http.StatusOK},
+		{name: "invalid token", err: tasksync.ErrInvalidToken, wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &tasksync.Handler{
+				SyncService: &MockSyncService{
+					PullMock: func(userID int, since string) (*tasksync.Changes, error) {
+						if since != "abc" {
+							t.Errorf("unexpected since %q", since)
+						}
+						if tt.err != nil {
+							return nil, tt.err
+						}
+						return &tasksync.Changes{Token: "def"}, nil
+					},
+				},
+			}
+
+			r := mockGin()
+			r.GET("/sync", handler.Pull)
+			req := httptest.NewRequest(http.MethodGet, "/sync?since=abc", nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestHandler_Push(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{
+			name:     "success",
+			body:     `{"mutations":[{"client_id":"a","op":"upsert","changed_at":"2026-10-01T12:00:00Z","fields":{"title":"Buy milk","due_at":null}}]}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "unknown op",
+			body:     `{"mutations":[{"client_id":"a","op":"archive","changed_at":"2026-10-01T12:00:00Z"}]}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "empty title",
+			body:     `{"mutations":[{"client_id":"a","op":"upsert","changed_at":"2026-10-01T12:00:00Z","fields":{"title":""}}]}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "without client time",
+			body:     `{"mutations":[{"client_id":"a","op":"delete"}]}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &tasksync.Handler{
+				SyncService: &MockSyncService{
+					PushMock: func(userID int, mutations []tasksync.Mutation) ([]tasksync.Result, error) {
+						m := mutations[0]
+						if m.Values.Title == nil || !m.Values.DueAt.Set || m.Values.DueAt.Time != nil {
+							t.Errorf("unexpected fields %+v", m.Values)
+						}
+						return []tasksync.Result{{ClientID: m.ClientID, Status: tasksync.StatusApplied}}, nil
+					},
+				},
+			}
+
+			r := mockGin()
+			r.POST("/sync", handler.Push)
+			req := httptest.NewRequest(http.MethodPost, "/sync", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d: %s", tt.wantCode, w.Code, w.Body)
+			}
+		})
+	}
+}
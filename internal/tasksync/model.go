@@ -0,0 +1,171 @@
+package tasksync
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/melnik-dev/go_todo_jwt/internal/task"
+)
+
+// Поля задачи, которые синхронизируются и сравниваются по отдельности
+const (
+	FieldTitle       = "title"
+	FieldDescription = "description"
+	FieldCompleted   = "completed"
+	FieldDueAt       = "due_at"
+	// FieldDeleted - конфликт изменения с удалением задачи
+	FieldDeleted = "deleted"
+)
+
+var Fields = []string{FieldTitle, FieldDescription, FieldCompleted, FieldDueAt}
+
+const (
+	OpUpsert = "upsert"
+	OpDelete = "delete"
+)
+
+// Статусы результата изменения
+const (
+	// StatusApplied - все присланные изменения действуют
+	StatusApplied = "applied"
+	// StatusConflict - хотя бы одно изменение отброшено: более позднее изменение сервера победило
+	StatusConflict = "conflict"
+	// StatusRejected - изменение не принято из-за ошибки в нём
+	StatusRejected = "rejected"
+)
+
+// Кто победил в конфликте
+const (
+	ResolutionClient = "client"
+	ResolutionServer = "server"
+)
+
+// Changes - изменения задач после токена: изменённые задачи и удалённые задачи
+type Changes struct {
+	Token      string      `json:"token"`
+	Upserts    []task.Task `json:"upserts"`
+	Tombstones []Tombstone `json:"tombstones"`
+}
+
+// Tombstone - удалённая задача; ClientID совпадает с uid задачи
+type Tombstone struct {
+	ClientID string `json:"client_id"`
+}
+
+// Clock - когда и каким изменением последний раз менялось поле или была удалена задача
+type Clock struct {
+	ChangeSeq int64     `db:"change_seq"`
+	ChangedAt time.Time `db:"changed_at"`
+}
+
+// State - текущее состояние задачи клиента на сервере. Task пуст, если задачи нет;
+// тогда Tombstone - её удаление, если она была.
+type State struct {
+	Task      *task.Task
+	Clocks    map[string]Clock
+	Tombstone *Clock
+}
+
+// Plan - что записать по итогам разбора изменения; ChangedAt уходит в часы полей
+type Plan struct {
+	Create    bool
+	Delete    bool
+	Values    Values
+	ChangedAt time.Time
+}
+
+// Values - значения синхронизируемых полей; пустые указатели не меняются
+type Values struct {
+	Title       *string      `json:"title" binding:"omitempty,min=1,max=100"`
+	Description *string      `json:"description" binding:"omitempty,max=500"`
+	Completed   *bool        `json:"completed"`
+	DueAt       OptionalTime `json:"due_at"`
+}
+
+// Has сообщает, прислано ли значение поля
+func (v *Values) Has(field string) bool {
+	switch field {
+	case FieldTitle:
+		return v.Title != nil
+	case FieldDescription:
+		return v.Description != nil
+	case FieldCompleted:
+		return v.Completed != nil
+	case FieldDueAt:
+		return v.DueAt.Set
+	}
+	return false
+}
+
+// Copy переносит значение поля из src
+func (v *Values) Copy(field string, src *Values) {
+	switch field {
+	case FieldTitle:
+		v.Title = src.Title
+	case FieldDescription:
+		v.Description = src.Description
+	case FieldCompleted:
+		v.Completed = src.Completed
+	case FieldDueAt:
+		v.DueAt = src.DueAt
+	}
+}
+
+// Equal сообщает, совпадает ли присланное значение поля со значением задачи
+func (v *Values) Equal(field string, t *task.Task) bool {
+	switch field {
+	case FieldTitle:
+		return *v.Title == t.Title
+	case FieldDescription:
+		return *v.Description == t.Description
+	case FieldCompleted:
+		return *v.Completed == t.Completed
+	case FieldDueAt:
+		if v.DueAt.Time == nil || t.DueAt == nil {
+			return v.DueAt.Time == nil && t.DueAt == nil
+		}
+		return v.DueAt.Time.Equal(*t.DueAt)
+	}
+	return false
+}
+
+// OptionalTime отличает отсутствующее поле (Set = false) от явного null
+type OptionalTime struct {
+	Set  bool
+	Time *time.Time
+}
+
+func (o *OptionalTime) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Time = nil
+		return nil
+	}
+	var t time.Time
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	o.Time = &t
+	return nil
+}
+
+func (o OptionalTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(o.Time)
+}
+
+// Result - итог одного изменения клиента. Task - состояние задачи на сервере
+// после изменения; пуст, если задача удалена (Deleted).
+type Result struct {
+	ClientID  string     `json:"client_id"`
+	Status    string     `json:"status"`
+	Task      *task.Task `json:"task,omitempty"`
+	Deleted   bool       `json:"deleted,omitempty"`
+	Conflicts []Conflict `json:"conflicts,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// Conflict - поле, которое изменили и клиент, и сервер после base клиента
+type Conflict struct {
+	Field      string `json:"field"`
+	Resolution string `json:"resolution"`
+}
@@ -0,0 +1,26 @@
+package tasksync
+
+import "time"
+
+type PullQuery struct {
+	Since string `form:"since"`
+}
+
+type PushRequest struct {
+	Mutations []Mutation `json:"mutations" binding:"required,max=500,dive"`
+}
+
+// Mutation - изменение, сделанное клиентом офлайн. ClientID - uid задачи,
+// для новых задач его генерирует клиент. Base - токен, на котором клиент видел
+// задачу в последний раз; ChangedAt - время изменения по часам клиента.
+type Mutation struct {
+	ClientID  string    `json:"client_id" binding:"required,max=255"`
+	Op        string    `json:"op" binding:"required,oneof=upsert delete"`
+	Base      string    `json:"base"`
+	ChangedAt time.Time `json:"changed_at" binding:"required"`
+	Values    Values    `json:"fields"`
+}
+
+type PushResponse struct {
+	Results []Result `json:"results"`
+}
@@ -0,0 +1,191 @@
+package tasksync
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/melnik-dev/go_todo_jwt/internal/task"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/sirupsen/logrus"
+)
+
+// Как и методы задач по UID, синхронизация работает с собственными задачами
+// пользователя в рабочем пространстве из ctx
+type IRepository interface {
+	Apply(ctx context.Context, userID int, clientID string, decide func(state *State) (*Plan, error)) (*task.Task, error)
+}
+
+type Repository struct {
+	db     *db.Db
+	logger *logrus.Logger
+}
+
+func NewRepository(db *db.Db, logger *logrus.Logger) *Repository {
+	return &Repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// columns - колонки tasks для синхронизируемых полей
+var columns = map[string]string{
+	FieldTitle:       "title",
+	FieldDescription: "description",
+	FieldCompleted:   "completed",
+	FieldDueAt:       "due_at",
+}
+
+// Apply читает состояние задачи клиента, передаёт его в decide и записывает
+// получившийся план в той же транзакции. Изменения одной задачи выполняются
+// по очереди: параллельная синхронизация того же uid ждёт advisory-блокировку.
+// Возвращает задачу после записи; nil - задачи нет.
+func (r *Repository) Apply(ctx context.Context, userID int, clientID string, decide func(state *State) (*Plan, error)) (*task.Task, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id":   userID,
+		"client_id": clientID,
+	})
+	logRepo.Debug("Attempting to Apply")
+
+	var result *task.Task
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1, hashtext($2))`, userID, clientID); err != nil {
+			return err
+		}
+
+		state, err := r.state(ctx, tx, userID, clientID)
+		if err != nil {
+			return err
+		}
+		result = state.Task
+
+		plan, err := decide(state)
+		if err != nil || plan == nil {
+			return err
+		}
+
+		query := `SELECT set_config('app.changed_at', $1, true)`
+		if _, err = tx.ExecContext(ctx, query, plan.ChangedAt.UTC().Format(time.RFC3339Nano)); err != nil {
+			return err
+		}
+
+		switch {
+		case plan.Delete:
+			if _, err = tx.ExecContext(ctx, `DELETE FROM tasks WHERE id = $1`, state.Task.ID); err != nil {
+				return err
+			}
+			result = nil
+		case plan.Create:
+			result, err = r.create(ctx, tx, userID, clientID, &plan.Values)
+		default:
+			result, err = r.update(ctx, tx, state.Task.ID, &plan.Values)
+		}
+		return err
+	})
+	if err != nil {
+		logRepo.WithError(err).Warn("Failed to Apply database")
+		return nil, err
+	}
+
+	logRepo.Debug("Apply database successfully")
+	return result, nil
+}
+
+func (r *Repository) state(ctx context.Context, tx *sqlx.Tx, userID int, clientID string) (*State, error) {
+	state := &State{Clocks: make(map[string]Clock)}
+
+	var current task.Task
+	query := `SELECT * FROM tasks WHERE user_id = $1 AND uid = $2 FOR UPDATE`
+	err := tx.GetContext(ctx, &current, query, userID, clientID)
+	if errors.Is(err, sql.ErrNoRows) {
+		var tombstone Clock
+		query = `SELECT change_seq, deleted_at AS changed_at FROM task_tombstones WHERE user_id = $1 AND uid = $2`
+		err = tx.GetContext(ctx, &tombstone, query, userID, clientID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return state, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		state.Tombstone = &tombstone
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state.Task = &current
+
+	var clocks []struct {
+		Field string `db:"field"`
+		Clock
+	}
+	query = `SELECT field, change_seq, changed_at FROM task_field_changes WHERE task_id = $1`
+	if err = tx.SelectContext(ctx, &clocks, query, current.ID); err != nil {
+		return nil, err
+	}
+	for _, clock := range clocks {
+		state.Clocks[clock.Field] = clock.Clock
+	}
+	return state, nil
+}
+
+func (r *Repository) create(ctx context.Context, tx *sqlx.Tx, userID int, clientID string, values *Values) (*task.Task, error) {
+	description := ""
+	if values.Description != nil {
+		description = *values.Description
+	}
+	completed := values.Completed != nil && *values.Completed
+
+	var created task.Task
+	query := `INSERT INTO tasks (user_id, uid, title, description, completed, due_at, completed_at)
+				VALUES ($1, $2, $3, $4, $5, $6, CASE WHEN $5 THEN NOW() END)
+				RETURNING *`
+	err := tx.GetContext(ctx, &created, query, userID, clientID, *values.Title, description, completed, values.DueAt.Time)
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// update меняет только присланные поля, чтобы их часы не сдвигались у остальных
+func (r *Repository) update(ctx context.Context, tx *sqlx.Tx, taskID int, values *Values) (*task.Task, error) {
+	var sets []string
+	var args []any
+	for _, field := range Fields {
+		if !values.Has(field) {
+			continue
+		}
+		var value any
+		switch field {
+		case FieldTitle:
+			value = *values.Title
+		case FieldDescription:
+			value = *values.Description
+		case FieldCompleted:
+			value = *values.Completed
+		case FieldDueAt:
+			value = values.DueAt.Time
+		}
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", columns[field], len(args)))
+		if field == FieldCompleted {
+			sets = append(sets, fmt.Sprintf("completed_at = CASE WHEN $%d THEN COALESCE(completed_at, NOW()) END", len(args)))
+		}
+	}
+
+	var updated task.Task
+	args = append(args, taskID)
+	query := `UPDATE tasks SET ` + strings.Join(sets, ", ") + fmt.Sprintf(` WHERE id = $%d RETURNING *`, len(args))
+	if err := tx.GetContext(ctx, &updated, query, args...); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func repositoryLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Repository tasksync layer")
+}
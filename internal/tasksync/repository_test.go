@@ -0,0 +1,115 @@
+package tasksync_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/melnik-dev/go_todo_jwt/internal/tasksync"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+)
+
+func mockDB() (*tasksync.Repository, sqlmock.Sqlmock, error) {
+	mockDb, mock, err := sqlmock.New()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pgDB := sqlx.NewDb(mockDb, "sqlMock")
+
+	repo := tasksync.NewRepository(&db.Db{
+		DB: pgDB,
+	}, mockLogger())
+
+	return repo, mock, err
+}
+
+var ctx = db.WithWorkspace(context.Background(), 1)
+
+func expectLocked(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT set_config('role', $1, true), set_config('app.workspace_id', $2, true)`)).
+		WithArgs(db.TenantRole, "1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock($1, hashtext($2))`)).
+		WithArgs(42, "a").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestSyncRepository_Apply_Update(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectLocked(mock)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM tasks WHERE user_id = $1 AND uid = $2 FOR UPDATE`)).
+		WithArgs(42, "a").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uid", "title", "completed"}).AddRow(1, "a", "server", false))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT field, change_seq, changed_at FROM task_field_changes WHERE task_id = $1`)).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"field", "change_seq", "changed_at"}).AddRow("title", 15, base))
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT set_config('app.changed_at', $1, true)`)).
+		WithArgs("2026-10-01T13:00:00Z").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE tasks SET title = $1, completed = $2, completed_at = CASE WHEN $2 THEN COALESCE(completed_at, NOW()) END WHERE id = $3 RETURNING *`)).
+		WithArgs("client", true, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uid", "title", "completed"}).AddRow(1, "a", "client", true))
+	mock.ExpectCommit()
+
+	done := true
+	updated, err := repo.Apply(ctx, 42, "a", func(state *tasksync.State) (*tasksync.Plan, error) {
+		if state.Task == nil || state.Clocks[tasksync.FieldTitle].ChangeSeq != 15 {
+			t.Errorf("unexpected state %+v", state)
+		}
+		return &tasksync.Plan{
+			Values:    tasksync.Values{Title: strPtr("client"), Completed: &done},
+			ChangedAt: after,
+		}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Title != "client" || !updated.Completed {
+		t.Errorf("unexpected task %+v", updated)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSyncRepository_Apply_Tombstone(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectLocked(mock)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM tasks WHERE user_id = $1 AND uid = $2 FOR UPDATE`)).
+		WithArgs(42, "a").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT change_seq, deleted_at AS changed_at FROM task_tombstones WHERE user_id = $1 AND uid = $2`)).
+		WithArgs(42, "a").
+		WillReturnRows(sqlmock.NewRows([]string{"change_seq", "changed_at"}).AddRow(15, base))
+	mock.ExpectCommit()
+
+	result, err := repo.Apply(ctx, 42, "a", func(state *tasksync.State) (*tasksync.Plan, error) {
+		if state.Task != nil || state.Tombstone == nil || state.Tombstone.ChangeSeq != 15 {
+			t.Errorf("unexpected state %+v", state)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != nil {
+		t.Errorf("expected no task, got %+v", result)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
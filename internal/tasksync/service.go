@@ -0,0 +1,190 @@
+package tasksync
+
+import (
+	"context"
+	"errors"
+
+	"github.com/melnik-dev/go_todo_jwt/internal/task"
+	"github.com/sirupsen/logrus"
+)
+
+type IService interface {
+	Pull(ctx context.Context, userID int, since string) (*Changes, error)
+	Push(ctx context.Context, userID int, mutations []Mutation) ([]Result, error)
+}
+
+type Service struct {
+	syncRepo    IRepository
+	taskService task.IService
+	logger      *logrus.Logger
+}
+
+func NewService(syncRepo IRepository, taskService task.IService, logger *logrus.Logger) *Service {
+	return &Service{
+		syncRepo:    syncRepo,
+		taskService: taskService,
+		logger:      logger,
+	}
+}
+
+// Pull возвращает задачи, изменённые после токена since, и удалённые после него задачи
+func (s *Service) Pull(ctx context.Context, userID int, since string) (*Changes, error) {
+	logServ := serviceLogger(s.logger).WithField("user_id", userID)
+	logServ.Debug("Attempting to Pull")
+
+	seq, err := DecodeToken(since)
+	if err != nil {
+		logServ.WithField("since", since).Warn(err.Error())
+		return nil, err
+	}
+
+	changeSet, err := s.taskService.GetChanges(ctx, userID, seq)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to GetChanges")
+		return nil, err
+	}
+
+	changes := &Changes{
+		Token:      EncodeToken(changeSet.Token),
+		Upserts:    changeSet.Updated,
+		Tombstones: make([]Tombstone, 0, len(changeSet.Deleted)),
+	}
+	for _, uid := range changeSet.Deleted {
+		changes.Tombstones = append(changes.Tombstones, Tombstone{ClientID: uid})
+	}
+
+	logServ.WithFields(logrus.Fields{
+		"upserts":    len(changes.Upserts),
+		"tombstones": len(changes.Tombstones),
+	}).Debug("Pull successfully")
+	return changes, nil
+}
+
+// Push применяет изменения клиента по порядку, каждое в своей транзакции.
+// Ошибка в изменении отклоняет только его; ошибка базы прерывает пакет,
+// и клиент повторяет его целиком - повтор уже применённых изменений ничего не меняет.
+func (s *Service) Push(ctx context.Context, userID int, mutations []Mutation) ([]Result, error) {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id":   userID,
+		"mutations": len(mutations),
+	})
+	logServ.Debug("Attempting to Push")
+
+	results := make([]Result, 0, len(mutations))
+	for i := range mutations {
+		m := &mutations[i]
+		result := Result{ClientID: m.ClientID}
+
+		base, err := DecodeToken(m.Base)
+		if err == nil {
+			var current *task.Task
+			current, err = s.syncRepo.Apply(ctx, userID, m.ClientID, func(state *State) (*Plan, error) {
+				plan, conflicts, err := Resolve(m, base, state)
+				result.Conflicts = conflicts
+				return plan, err
+			})
+			result.Task = current
+		}
+
+		switch {
+		case errors.Is(err, ErrInvalidToken), errors.Is(err, ErrTitleRequired):
+			result = Result{ClientID: m.ClientID, Status: StatusRejected, Error: err.Error()}
+		case err != nil:
+			logServ.WithError(err).WithField("client_id", m.ClientID).Error("Failed to Apply")
+			return nil, err
+		default:
+			result.Status = StatusApplied
+			for _, conflict := range result.Conflicts {
+				if conflict.Resolution == ResolutionServer {
+					result.Status = StatusConflict
+				}
+			}
+			result.Deleted = result.Task == nil
+		}
+		results = append(results, result)
+	}
+
+	logServ.Debug("Push successfully")
+	return results, nil
+}
+
+// Resolve решает, что записать по изменению клиента. Политика - последний
+// пишущий побеждает, отдельно по каждому полю:
+//   - поле, которое сервер не менял после base клиента, принимается от клиента;
+//   - поле, значение которого уже совпадает с присланным, не меняется и конфликтом не считается;
+//   - поле, которое поменяли обе стороны, получает значение того, чьё изменение
+//     позже по времени changed_at; при равенстве побеждает сервер;
+//   - удаление задачи сравнивается со всеми её полями, изменёнными после base:
+//     задача не удаляется, если хоть одно из них изменено позже удаления;
+//   - изменение удалённой после base задачи создаёт её заново, если оно позже удаления.
+//
+// Время клиента берётся по его часам, поэтому при сильном расхождении часов
+// побеждает не последнее изменение, а изменение с более поздней отметкой.
+func Resolve(m *Mutation, base int64, state *State) (*Plan, []Conflict, error) {
+	var conflicts []Conflict
+	// clientWins добавляет конфликт, если сервер менял поле после base, и сообщает, победил ли клиент
+	clientWins := func(field string, clock *Clock) bool {
+		if clock == nil || clock.ChangeSeq <= base {
+			return true
+		}
+		if m.ChangedAt.After(clock.ChangedAt) {
+			conflicts = append(conflicts, Conflict{Field: field, Resolution: ResolutionClient})
+			return true
+		}
+		conflicts = append(conflicts, Conflict{Field: field, Resolution: ResolutionServer})
+		return false
+	}
+	clock := func(field string) *Clock {
+		if c, ok := state.Clocks[field]; ok {
+			return &c
+		}
+		return nil
+	}
+
+	if m.Op == OpDelete {
+		if state.Task == nil {
+			return nil, nil, nil
+		}
+		wins := true
+		for _, field := range Fields {
+			if !clientWins(field, clock(field)) {
+				wins = false
+			}
+		}
+		if !wins {
+			return nil, conflicts, nil
+		}
+		return &Plan{Delete: true, ChangedAt: m.ChangedAt}, conflicts, nil
+	}
+
+	if state.Task == nil {
+		if state.Tombstone != nil && !clientWins(FieldDeleted, state.Tombstone) {
+			return nil, conflicts, nil
+		}
+		if m.Values.Title == nil {
+			return nil, nil, ErrTitleRequired
+		}
+		return &Plan{Create: true, Values: m.Values, ChangedAt: m.ChangedAt}, conflicts, nil
+	}
+
+	plan := &Plan{ChangedAt: m.ChangedAt}
+	changed := false
+	for _, field := range Fields {
+		// совпадающее значение - не конфликт: так повтор пакета ничего не меняет
+		if !m.Values.Has(field) || m.Values.Equal(field, state.Task) {
+			continue
+		}
+		if clientWins(field, clock(field)) {
+			plan.Values.Copy(field, &m.Values)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil, conflicts, nil
+	}
+	return plan, conflicts, nil
+}
+
+func serviceLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Service tasksync layer")
+}
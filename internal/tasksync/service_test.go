@@ -0,0 +1,255 @@
+package tasksync_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/melnik-dev/go_todo_jwt/internal/task"
+	"github.com/melnik-dev/go_todo_jwt/internal/tasksync"
+	"github.com/sirupsen/logrus"
+)
+
+type MockSyncRepository struct {
+	ApplyMock func(userID int, clientID string, decide func(state *tasksync.State) (*tasksync.Plan, error)) (*task.Task, error)
+}
+
+func (m *MockSyncRepository) Apply(ctx context.Context, userID int, clientID string, decide func(state *tasksync.State) (*tasksync.Plan, error)) (*task.Task, error) {
+	return m.ApplyMock(userID, clientID, decide)
+}
+
+// MockTaskService встраивает task.IService, чтобы переопределять только нужные методы
+type MockTaskService struct {
+	task.IService
+	GetChangesMock func(userID int, since int64) (*task.ChangeSet, error)
+}
+
+func (m *MockTaskService) GetChanges(ctx context.Context, userID int, since int64) (*task.ChangeSet, error) {
+	return m.GetChangesMock(userID, since)
+}
+
+func mockLogger() *logrus.Logger {
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	return l
+}
+
+func strPtr(v string) *string {
+	return &v
+}
+
+var (
+	base   = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	before = base.Add(-time.Hour)
+	after  = base.Add(time.Hour)
+)
+
+// existing - задача, у которой после токена 10 на сервере менялся только title (в момент base)
+func existing() *tasksync.State {
+	return &tasksync.State{
+		Task: &task.Task{ID: 1, UID: "a", Title: "server", Description: "old"},
+		Clocks: map[string]tasksync.Clock{
+			tasksync.FieldTitle:       {ChangeSeq: 15, ChangedAt: base},
+			tasksync.FieldDescription: {ChangeSeq: 5, ChangedAt: before},
+			tasksync.FieldCompleted:   {ChangeSeq: 5, ChangedAt: before},
+			tasksync.FieldDueAt:       {ChangeSeq: 5, ChangedAt: before},
+		},
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name          string
+		mutation      tasksync.Mutation
+		state         *tasksync.State
+		wantPlan      bool
+		wantFields    []string
+		wantConflicts []tasksync.Conflict
+		wantErr       error
+	}{
+		{
+			name:       "field not changed on server",
+			mutation:   tasksync.Mutation{Op: tasksync.OpUpsert, ChangedAt: before, Values: tasksync.Values{Description: strPtr("client")}},
+			state:      existing(),
+			wantPlan:   true,
+			wantFields: []string{tasksync.FieldDescription},
+		},
+		{
+			name:          "client edit is later",
+			mutation:      tasksync.Mutation{Op: tasksync.OpUpsert, ChangedAt: after, Values: tasksync.Values{Title: strPtr("client")}},
+			state:         existing(),
+			wantPlan:      true,
+			wantFields:    []string{tasksync.FieldTitle},
+			wantConflicts: []tasksync.Conflict{{Field: tasksync.FieldTitle, Resolution: tasksync.ResolutionClient}},
+		},
+		{
+			name:     "server edit is later, other field merges",
+			mutation: tasksync.Mutation{Op: tasksync.OpUpsert, ChangedAt: before, Values: tasksync.Values{Title: strPtr("client"), Description: strPtr("client")}},
+			state:    existing(),
+			wantPlan: true,
+			// title остаётся серверным, description принимается
+			wantFields:    []string{tasksync.FieldDescription},
+			wantConflicts: []tasksync.Conflict{{Field: tasksync.FieldTitle, Resolution: tasksync.ResolutionServer}},
+		},
+		{
+			name:     "same value is not a conflict",
+			mutation: tasksync.Mutation{Op: tasksync.OpUpsert, ChangedAt: before, Values: tasksync.Values{Title: strPtr("server")}},
+			state:    existing(),
+		},
+		{
+			name:       "create",
+			mutation:   tasksync.Mutation{Op: tasksync.OpUpsert, ChangedAt: before, Values: tasksync.Values{Title: strPtr("new")}},
+			state:      &tasksync.State{},
+			wantPlan:   true,
+			wantFields: []string{tasksync.FieldTitle},
+		},
+		{
+			name:     "create without title",
+			mutation: tasksync.Mutation{Op: tasksync.OpUpsert, ChangedAt: before, Values: tasksync.Values{Description: strPtr("new")}},
+			state:    &tasksync.State{},
+			wantErr:  tasksync.ErrTitleRequired,
+		},
+		{
+			name:          "edit of task deleted later",
+			mutation:      tasksync.Mutation{Op: tasksync.OpUpsert, ChangedAt: before, Values: tasksync.Values{Title: strPtr("client")}},
+			state:         &tasksync.State{Tombstone: &tasksync.Clock{ChangeSeq: 15, ChangedAt: base}},
+			wantConflicts: []tasksync.Conflict{{Field: tasksync.FieldDeleted, Resolution: tasksync.ResolutionServer}},
+		},
+		{
+			name:          "edit later than delete recreates task",
+			mutation:      tasksync.Mutation{Op: tasksync.OpUpsert, ChangedAt: after, Values: tasksync.Values{Title: strPtr("client")}},
+			state:         &tasksync.State{Tombstone: &tasksync.Clock{ChangeSeq: 15, ChangedAt: base}},
+			wantPlan:      true,
+			wantFields:    []string{tasksync.FieldTitle},
+			wantConflicts: []tasksync.Conflict{{Field: tasksync.FieldDeleted, Resolution: tasksync.ResolutionClient}},
+		},
+		{
+			name:          "delete after server edit",
+			mutation:      tasksync.Mutation{Op: tasksync.OpDelete, ChangedAt: after},
+			state:         existing(),
+			wantPlan:      true,
+			wantConflicts: []tasksync.Conflict{{Field: tasksync.FieldTitle, Resolution: tasksync.ResolutionClient}},
+		},
+		{
+			name:          "delete before server edit",
+			mutation:      tasksync.Mutation{Op: tasksync.OpDelete, ChangedAt: before},
+			state:         existing(),
+			wantConflicts: []tasksync.Conflict{{Field: tasksync.FieldTitle, Resolution: tasksync.ResolutionServer}},
+		},
+		{
+			name:     "delete of missing task",
+			mutation: tasksync.Mutation{Op: tasksync.OpDelete, ChangedAt: before},
+			state:    &tasksync.State{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, conflicts, err := tasksync.Resolve(&tt.mutation, 10, tt.state)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if (plan != nil) != tt.wantPlan {
+				t.Fatalf("expected plan=%v, got %+v", tt.wantPlan, plan)
+			}
+			if plan != nil {
+				var fields []string
+				for _, field := range tasksync.Fields {
+					if plan.Values.Has(field) {
+						fields = append(fields, field)
+					}
+				}
+				if !slices.Equal(fields, tt.wantFields) {
+					t.Errorf("expected fields %v, got %v", tt.wantFields, fields)
+				}
+				if !plan.ChangedAt.Equal(tt.mutation.ChangedAt) {
+					t.Errorf("plan must carry client time, got %v", plan.ChangedAt)
+				}
+			}
+			if !slices.Equal(conflicts, tt.wantConflicts) {
+				t.Errorf("expected conflicts %v, got %v", tt.wantConflicts, conflicts)
+			}
+		})
+	}
+}
+
+func TestService_Push(t *testing.T) {
+	repo := &MockSyncRepository{
+		ApplyMock: func(userID int, clientID string, decide func(state *tasksync.State) (*tasksync.Plan, error)) (*task.Task, error) {
+			state := existing()
+			if clientID == "missing" {
+				state = &tasksync.State{}
+			}
+			plan, err := decide(state)
+			if err != nil {
+				return nil, err
+			}
+			if plan != nil && plan.Delete {
+				return nil, nil
+			}
+			return state.Task, nil
+		},
+	}
+	service := tasksync.NewService(repo, &MockTaskService{}, mockLogger())
+
+	results, err := service.Push(context.Background(), 42, []tasksync.Mutation{
+		{ClientID: "a", Op: tasksync.OpUpsert, Base: tasksync.EncodeToken(10), ChangedAt: before, Values: tasksync.Values{Title: strPtr("client")}},
+		{ClientID: "a", Op: tasksync.OpUpsert, Base: "broken", ChangedAt: before},
+		{ClientID: "missing", Op: tasksync.OpUpsert, ChangedAt: before},
+		{ClientID: "a", Op: tasksync.OpDelete, Base: tasksync.EncodeToken(20), ChangedAt: before},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		status  string
+		deleted bool
+	}{
+		{status: tasksync.StatusConflict},
+		{status: tasksync.StatusRejected},
+		{status: tasksync.StatusRejected},
+		{status: tasksync.StatusApplied, deleted: true},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("expected %d results, got %+v", len(want), results)
+	}
+	for i, w := range want {
+		if results[i].Status != w.status || results[i].Deleted != w.deleted {
+			t.Errorf("result %d: expected %+v, got %+v", i, w, results[i])
+		}
+	}
+	if results[0].Task == nil || results[0].Task.Title != "server" {
+		t.Errorf("conflict must return server state, got %+v", results[0].Task)
+	}
+}
+
+func TestService_Pull(t *testing.T) {
+	tasks := &MockTaskService{
+		GetChangesMock: func(userID int, since int64) (*task.ChangeSet, error) {
+			if since != 7 {
+				t.Errorf("expected since 7, got %d", since)
+			}
+			return &task.ChangeSet{Token: 12, Updated: []task.Task{{ID: 1, UID: "a"}}, Deleted: []string{"b"}}, nil
+		},
+	}
+	service := tasksync.NewService(&MockSyncRepository{}, tasks, mockLogger())
+
+	changes, err := service.Pull(context.Background(), 42, tasksync.EncodeToken(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seq, _ := tasksync.DecodeToken(changes.Token); seq != 12 {
+		t.Errorf("expected token for 12, got %q", changes.Token)
+	}
+	if len(changes.Upserts) != 1 || len(changes.Tombstones) != 1 || changes.Tombstones[0].ClientID != "b" {
+		t.Errorf("unexpected changes %+v", changes)
+	}
+
+	if _, err = service.Pull(context.Background(), 42, "v1:7"); !errors.Is(err, tasksync.ErrInvalidToken) {
+		t.Errorf("expected %v for raw token, got %v", tasksync.ErrInvalidToken, err)
+	}
+}
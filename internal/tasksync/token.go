@@ -0,0 +1,35 @@
+package tasksync
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+)
+
+// tokenPrefix - версия формата токена; клиенты хранят токен как непрозрачную строку
+const tokenPrefix = "v1:"
+
+// EncodeToken упаковывает номер изменения задач (task_change_seq) в токен синхронизации
+func EncodeToken(seq int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(tokenPrefix + strconv.FormatInt(seq, 10)))
+}
+
+// DecodeToken возвращает номер изменения из токена; пустой токен - с самого начала
+func DecodeToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, ErrInvalidToken
+	}
+	value, ok := strings.CutPrefix(string(raw), tokenPrefix)
+	if !ok {
+		return 0, ErrInvalidToken
+	}
+	seq, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seq < 0 {
+		return 0, ErrInvalidToken
+	}
+	return seq, nil
+}
@@ -0,0 +1,29 @@
+package user_test
+
+import (
+	"testing"
+
+	"github.com/melnik-dev/go_todo_jwt/internal/user"
+	"github.com/melnik-dev/go_todo_jwt/internal/user/usertest"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db/dbtest"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	usertest.Run(t, func(*testing.T) user.IRepository {
+		return user.NewMemoryRepository()
+	})
+}
+
+func TestRepository_Postgres(t *testing.T) {
+	database := dbtest.Postgres(t)
+	usertest.Run(t, func(*testing.T) user.IRepository {
+		return user.NewRepository(database, dbtest.Logger())
+	})
+}
+
+func TestSQLiteRepository(t *testing.T) {
+	database := dbtest.SQLite(t)
+	usertest.Run(t, func(*testing.T) user.IRepository {
+		return user.NewSQLiteRepository(database, dbtest.Logger())
+	})
+}
@@ -0,0 +1,9 @@
+package user
+
+import "errors"
+
+var (
+	ErrAppPasswordNotFound = errors.New("app password not found")
+	ErrUserNotFound        = errors.New("user not found")
+	ErrUsernameTaken       = errors.New("username is taken")
+)
@@ -0,0 +1,176 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// MemoryRepository хранит пользователей в памяти процесса (db.driver: memory).
+// Ошибки совпадают с Repository: ненайденный пользователь - sql.ErrNoRows
+// в Get и ErrUserNotFound в изменяющих методах, занятое имя - ErrUsernameTaken.
+type MemoryRepository struct {
+	mu             sync.RWMutex
+	users          map[int]*User
+	byName         map[string]int
+	lastUserID     int
+	passwords      map[int]*AppPassword
+	lastPasswordID int
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		users:     make(map[int]*User),
+		byName:    make(map[string]int),
+		passwords: make(map[int]*AppPassword),
+	}
+}
+
+func (r *MemoryRepository) Create(_ context.Context, user *User) (*User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.byName[user.Name]; ok {
+		return user, ErrUsernameTaken
+	}
+
+	r.lastUserID++
+	user.ID = r.lastUserID
+	stored := *user
+	r.users[user.ID] = &stored
+	r.byName[user.Name] = user.ID
+	return user, nil
+}
+
+func (r *MemoryRepository) Get(_ context.Context, username string) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.byName[username]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	user := *r.users[id]
+	return &user, nil
+}
+
+// GetByID нужен хранилищу задач в памяти для имён в ролях и истории назначений
+func (r *MemoryRepository) GetByID(_ context.Context, id int) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.users[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	user := *stored
+	return &user, nil
+}
+
+func (r *MemoryRepository) List(_ context.Context) ([]User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// идентификаторы выдаются подряд, поэтому обход по ним даёт порядок ORDER BY id
+	users := make([]User, 0, len(r.users))
+	for id := 1; id <= r.lastUserID; id++ {
+		if user, ok := r.users[id]; ok {
+			users = append(users, *user)
+		}
+	}
+	return users, nil
+}
+
+func (r *MemoryRepository) SetDisabled(_ context.Context, username string, disabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id, ok := r.byName[username]
+	if !ok {
+		return ErrUserNotFound
+	}
+
+	user := r.users[id]
+	switch {
+	case !disabled:
+		user.DisabledAt = nil
+	case user.DisabledAt == nil:
+		now := time.Now()
+		user.DisabledAt = &now
+	}
+	return nil
+}
+
+func (r *MemoryRepository) UpdatePassword(_ context.Context, username, password string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id, ok := r.byName[username]
+	if !ok {
+		return ErrUserNotFound
+	}
+	r.users[id].Password = password
+	return nil
+}
+
+func (r *MemoryRepository) CreateAppPassword(_ context.Context, password *AppPassword) (*AppPassword, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastPasswordID++
+	password.ID = r.lastPasswordID
+	password.CreatedAt = time.Now()
+	stored := *password
+	r.passwords[password.ID] = &stored
+	return password, nil
+}
+
+func (r *MemoryRepository) GetAppPasswords(_ context.Context, userID int) ([]AppPassword, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	passwords := make([]AppPassword, 0)
+	for id := 1; id <= r.lastPasswordID; id++ {
+		if password, ok := r.passwords[id]; ok && password.UserID == userID {
+			passwords = append(passwords, *password)
+		}
+	}
+	return passwords, nil
+}
+
+func (r *MemoryRepository) GetAppPasswordByHash(_ context.Context, hash string) (*AppPassword, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, stored := range r.passwords {
+		if stored.PasswordHash == hash {
+			password := *stored
+			return &password, nil
+		}
+	}
+	return nil, ErrAppPasswordNotFound
+}
+
+func (r *MemoryRepository) TouchAppPassword(_ context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if password, ok := r.passwords[id]; ok {
+		now := time.Now()
+		password.LastUsedAt = &now
+	}
+	return nil
+}
+
+func (r *MemoryRepository) DeleteAppPassword(_ context.Context, userID, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	password, ok := r.passwords[id]
+	if !ok || password.UserID != userID {
+		return ErrAppPasswordNotFound
+	}
+	delete(r.passwords, id)
+	return nil
+}
@@ -1,7 +1,21 @@
 package user
 
+import "time"
+
 type User struct {
 	ID       int    `db:"id" json:"id"`
 	Name     string `db:"username" json:"username" binding:"required,min=3"`
 	Password string `db:"password" json:"password" binding:"required,min=3"`
+	// DisabledAt - когда пользователь отключён администратором; nil - активен
+	DisabledAt *time.Time `db:"disabled_at" json:"disabled_at,omitempty"`
+}
+
+// AppPassword - отдельный пароль для сторонних клиентов (CalDAV), который можно отозвать
+type AppPassword struct {
+	ID           int        `db:"id" json:"id"`
+	UserID       int        `db:"user_id" json:"user_id"`
+	Name         string     `db:"name" json:"name"`
+	PasswordHash string     `db:"password_hash" json:"-"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	LastUsedAt   *time.Time `db:"last_used_at" json:"last_used_at"`
 }
@@ -1,13 +1,24 @@
 package user
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"github.com/melnik-dev/go_todo_jwt/pkg/db"
 	"github.com/sirupsen/logrus"
 )
 
 type IRepository interface {
-	Create(user *User) (*User, error)
-	Get(username string) (*User, error)
+	Create(ctx context.Context, user *User) (*User, error)
+	Get(ctx context.Context, username string) (*User, error)
+	List(ctx context.Context) ([]User, error)
+	SetDisabled(ctx context.Context, username string, disabled bool) error
+	UpdatePassword(ctx context.Context, username, password string) error
+	CreateAppPassword(ctx context.Context, password *AppPassword) (*AppPassword, error)
+	GetAppPasswords(ctx context.Context, userID int) ([]AppPassword, error)
+	GetAppPasswordByHash(ctx context.Context, hash string) (*AppPassword, error)
+	TouchAppPassword(ctx context.Context, id int) error
+	DeleteAppPassword(ctx context.Context, userID, id int) error
 }
 
 type Repository struct {
@@ -22,15 +33,19 @@ func NewRepository(db *db.Db, logger *logrus.Logger) *Repository {
 	}
 }
 
-func (r *Repository) Create(user *User) (*User, error) {
+func (r *Repository) Create(ctx context.Context, user *User) (*User, error) {
 	userLogger := repositoryLogger(r.logger)
 	userLogger.Debug("Attempting to Create user")
 
 	var id int
 	query := `INSERT INTO users (username, password) VALUES ($1, $2) RETURNING id`
 
-	row := r.db.QueryRow(query, user.Name, user.Password)
+	row := r.db.QueryRowContext(ctx, query, user.Name, user.Password)
 	if err := row.Scan(&id); err != nil {
+		if db.IsUniqueViolation(err) {
+			userLogger.Warn(ErrUsernameTaken.Error())
+			return user, ErrUsernameTaken
+		}
 		userLogger.WithError(err).Error("Failed to create user in database")
 		return user, err
 	}
@@ -40,14 +55,14 @@ func (r *Repository) Create(user *User) (*User, error) {
 	return user, nil
 }
 
-func (r *Repository) Get(username string) (*User, error) {
+func (r *Repository) Get(ctx context.Context, username string) (*User, error) {
 	userLogger := repositoryLogger(r.logger)
 	userLogger.Debug("Attempting to Get user")
 
 	var user User
 	query := `SELECT * FROM users WHERE username = $1`
 
-	err := r.db.Get(&user, query, username)
+	err := r.db.GetContext(ctx, &user, query, username)
 	if err != nil {
 		userLogger.WithError(err).Error("Failed to get user in database")
 		return nil, err
@@ -57,6 +72,168 @@ func (r *Repository) Get(username string) (*User, error) {
 	return &user, nil
 }
 
+func (r *Repository) List(ctx context.Context) ([]User, error) {
+	userLogger := repositoryLogger(r.logger)
+	userLogger.Debug("Attempting to List users")
+
+	users := make([]User, 0)
+	query := `SELECT * FROM users ORDER BY id`
+	if err := r.db.SelectContext(ctx, &users, query); err != nil {
+		userLogger.WithError(err).Error("Failed to list users in database")
+		return nil, err
+	}
+
+	userLogger.Debug("User List successfully")
+	return users, nil
+}
+
+// SetDisabled отключает или снова включает пользователя; время первого отключения сохраняется
+func (r *Repository) SetDisabled(ctx context.Context, username string, disabled bool) error {
+	userLogger := repositoryLogger(r.logger).WithField("disabled", disabled)
+	userLogger.Debug("Attempting to SetDisabled")
+
+	query := `UPDATE users SET disabled_at = CASE WHEN $2 THEN COALESCE(disabled_at, NOW()) END WHERE username = $1`
+	result, err := r.db.ExecContext(ctx, query, username, disabled)
+	if err != nil {
+		userLogger.WithError(err).Error("Failed to set user disabled in database")
+		return err
+	}
+
+	row, err := result.RowsAffected()
+	if err != nil {
+		userLogger.WithError(err).Error("Failed rows affected by set user disabled")
+		return err
+	}
+
+	if row == 0 {
+		userLogger.Warn(ErrUserNotFound.Error())
+		return ErrUserNotFound
+	}
+
+	userLogger.Debug("SetDisabled successfully")
+	return nil
+}
+
+// UpdatePassword сохраняет новый хеш пароля пользователя
+func (r *Repository) UpdatePassword(ctx context.Context, username, password string) error {
+	userLogger := repositoryLogger(r.logger)
+	userLogger.Debug("Attempting to UpdatePassword")
+
+	query := `UPDATE users SET password = $2 WHERE username = $1`
+	result, err := r.db.ExecContext(ctx, query, username, password)
+	if err != nil {
+		userLogger.WithError(err).Error("Failed to update password in database")
+		return err
+	}
+
+	row, err := result.RowsAffected()
+	if err != nil {
+		userLogger.WithError(err).Error("Failed rows affected by update password")
+		return err
+	}
+
+	if row == 0 {
+		userLogger.Warn(ErrUserNotFound.Error())
+		return ErrUserNotFound
+	}
+
+	userLogger.Debug("UpdatePassword successfully")
+	return nil
+}
+
+func (r *Repository) CreateAppPassword(ctx context.Context, password *AppPassword) (*AppPassword, error) {
+	userLogger := repositoryLogger(r.logger).WithField("user_id", password.UserID)
+	userLogger.Debug("Attempting to CreateAppPassword")
+
+	query := `INSERT INTO app_passwords (user_id, name, password_hash) VALUES ($1, $2, $3) RETURNING id, created_at`
+	row := r.db.QueryRowContext(ctx, query, password.UserID, password.Name, password.PasswordHash)
+	if err := row.Scan(&password.ID, &password.CreatedAt); err != nil {
+		userLogger.WithError(err).Error("Failed to create app password in database")
+		return nil, err
+	}
+
+	userLogger.WithField("app_password_id", password.ID).Debug("CreateAppPassword successfully")
+	return password, nil
+}
+
+func (r *Repository) GetAppPasswords(ctx context.Context, userID int) ([]AppPassword, error) {
+	userLogger := repositoryLogger(r.logger).WithField("user_id", userID)
+	userLogger.Debug("Attempting to GetAppPasswords")
+
+	passwords := make([]AppPassword, 0)
+	query := `SELECT * FROM app_passwords WHERE user_id = $1 ORDER BY id`
+	if err := r.db.SelectContext(ctx, &passwords, query, userID); err != nil {
+		userLogger.WithError(err).Error("Failed to get app passwords in database")
+		return nil, err
+	}
+
+	userLogger.Debug("GetAppPasswords successfully")
+	return passwords, nil
+}
+
+func (r *Repository) GetAppPasswordByHash(ctx context.Context, hash string) (*AppPassword, error) {
+	userLogger := repositoryLogger(r.logger)
+	userLogger.Debug("Attempting to GetAppPasswordByHash")
+
+	var password AppPassword
+	query := `SELECT * FROM app_passwords WHERE password_hash = $1`
+	err := r.db.GetContext(ctx, &password, query, hash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			userLogger.Debug(ErrAppPasswordNotFound.Error())
+			return nil, ErrAppPasswordNotFound
+		}
+		userLogger.WithError(err).Error("Failed to get app password in database")
+		return nil, err
+	}
+
+	userLogger.WithField("user_id", password.UserID).Debug("GetAppPasswordByHash successfully")
+	return &password, nil
+}
+
+func (r *Repository) TouchAppPassword(ctx context.Context, id int) error {
+	userLogger := repositoryLogger(r.logger).WithField("app_password_id", id)
+	userLogger.Debug("Attempting to TouchAppPassword")
+
+	query := `UPDATE app_passwords SET last_used_at = NOW() WHERE id = $1`
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		userLogger.WithError(err).Error("Failed to touch app password in database")
+		return err
+	}
+
+	userLogger.Debug("TouchAppPassword successfully")
+	return nil
+}
+
+func (r *Repository) DeleteAppPassword(ctx context.Context, userID, id int) error {
+	userLogger := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id":         userID,
+		"app_password_id": id,
+	})
+	userLogger.Debug("Attempting to DeleteAppPassword")
+
+	query := `DELETE FROM app_passwords WHERE id = $1 AND user_id = $2`
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		userLogger.WithError(err).Error("Failed to delete app password in database")
+		return err
+	}
+
+	row, err := result.RowsAffected()
+	if err != nil {
+		userLogger.WithError(err).Error("Failed rows affected by delete app password")
+		return err
+	}
+
+	if row == 0 {
+		userLogger.Warn(ErrAppPasswordNotFound.Error())
+		return ErrAppPasswordNotFound
+	}
+
+	userLogger.Debug("DeleteAppPassword successfully")
+	return nil
+}
+
 func repositoryLogger(l *logrus.Logger) *logrus.Entry {
 	return l.WithField("layer", "Repository user layer")
 }
@@ -1,8 +1,11 @@
 package user_test
 
 import (
+	"context"
+	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/melnik-dev/go_todo_jwt/internal/user"
 	"github.com/melnik-dev/go_todo_jwt/pkg/db"
 	"github.com/sirupsen/logrus"
@@ -38,7 +41,7 @@ func TestUserRepository_Create_Success(t *testing.T) {
 		WithArgs("test_user", "test_pass").
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
-	result, err := repo.Create(&user.User{
+	result, err := repo.Create(context.Background(), &user.User{
 		Name:     "test_user",
 		Password: "test_pass",
 	})
@@ -65,7 +68,7 @@ func TestUserRepository_Create_Fail(t *testing.T) {
 		WithArgs("test_user", "test_pass").
 		WillReturnError(sqlmock.ErrCancelled)
 
-	_, err = repo.Create(&user.User{
+	_, err = repo.Create(context.Background(), &user.User{
 		Name:     "test_user",
 		Password: "test_pass",
 	})
@@ -78,6 +81,29 @@ func TestUserRepository_Create_Fail(t *testing.T) {
 	}
 }
 
+func TestUserRepository_Create_Duplicate(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery(`INSERT INTO users`).
+		WithArgs("test_user", "test_pass").
+		WillReturnError(&pq.Error{Code: "23505", Message: "duplicate key value violates unique constraint"})
+
+	_, err = repo.Create(context.Background(), &user.User{
+		Name:     "test_user",
+		Password: "test_pass",
+	})
+	if !errors.Is(err, user.ErrUsernameTaken) {
+		t.Fatalf("expected %v, got %v", user.ErrUsernameTaken, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestUserRepository_Get_Success(t *testing.T) {
 	repo, mock, err := mockDB()
 	if err != nil {
@@ -89,7 +115,7 @@ func TestUserRepository_Get_Success(t *testing.T) {
 		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password"}).
 			AddRow(1, "test_user", "test_pass"))
 
-	result, err := repo.Get("test_user")
+	result, err := repo.Get(context.Background(), "test_user")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -113,7 +139,7 @@ func TestUserRepository_Get_Fail(t *testing.T) {
 		WithArgs("test_user").
 		WillReturnError(sqlmock.ErrCancelled)
 
-	_, err = repo.Get("test_user")
+	_, err = repo.Get(context.Background(), "test_user")
 	if err == nil {
 		t.Fatal("Expected error, got nil")
 	}
@@ -122,3 +148,62 @@ func TestUserRepository_Get_Fail(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestUserRepository_GetAppPasswordByHash_NotFound(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM app_passwords WHERE password_hash = $1`)).
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err = repo.GetAppPasswordByHash(context.Background(), "hash")
+	if err != user.ErrAppPasswordNotFound {
+		t.Fatalf("Expected %v, got %v", user.ErrAppPasswordNotFound, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUserRepository_DeleteAppPassword_NotFound(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM app_passwords WHERE id = $1 AND user_id = $2`)).
+		WithArgs(1, 42).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = repo.DeleteAppPassword(context.Background(), 42, 1)
+	if err != user.ErrAppPasswordNotFound {
+		t.Fatalf("Expected %v, got %v", user.ErrAppPasswordNotFound, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUserRepository_SetDisabled_NotFound(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE users SET disabled_at = CASE WHEN $2 THEN COALESCE(disabled_at, NOW()) END WHERE username = $1`)).
+		WithArgs("test_user", true).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err = repo.SetDisabled(context.Background(), "test_user", true); !errors.Is(err, user.ErrUserNotFound) {
+		t.Fatalf("expected %v, got %v", user.ErrUserNotFound, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
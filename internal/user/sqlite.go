@@ -0,0 +1,200 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/sirupsen/logrus"
+)
+
+// SQLiteRepository - Repository для SQLite (db.driver: sqlite): те же ошибки,
+// но параметры ?N, а время берётся из приложения вместо NOW()
+type SQLiteRepository struct {
+	db     *db.Db
+	logger *logrus.Logger
+}
+
+func NewSQLiteRepository(db *db.Db, logger *logrus.Logger) *SQLiteRepository {
+	return &SQLiteRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *SQLiteRepository) Create(ctx context.Context, user *User) (*User, error) {
+	userLogger := repositoryLogger(r.logger)
+	userLogger.Debug("Attempting to Create user")
+
+	var id int
+	query := `INSERT INTO users (username, password) VALUES (?1, ?2) RETURNING id`
+	if err := r.db.QueryRowContext(ctx, query, user.Name, user.Password).Scan(&id); err != nil {
+		if db.IsUniqueViolation(err) {
+			userLogger.Warn(ErrUsernameTaken.Error())
+			return user, ErrUsernameTaken
+		}
+		userLogger.WithError(err).Error("Failed to create user in database")
+		return user, err
+	}
+	user.ID = id
+
+	userLogger.WithField("user_id", user.ID).Debug("User Create successfully")
+	return user, nil
+}
+
+func (r *SQLiteRepository) Get(ctx context.Context, username string) (*User, error) {
+	userLogger := repositoryLogger(r.logger)
+	userLogger.Debug("Attempting to Get user")
+
+	var user User
+	if err := r.db.GetContext(ctx, &user, `SELECT * FROM users WHERE username = ?1`, username); err != nil {
+		userLogger.WithError(err).Error("Failed to get user in database")
+		return nil, err
+	}
+
+	userLogger.WithField("user_id", user.ID).Debug("User Get successfully")
+	return &user, nil
+}
+
+func (r *SQLiteRepository) List(ctx context.Context) ([]User, error) {
+	userLogger := repositoryLogger(r.logger)
+	userLogger.Debug("Attempting to List users")
+
+	users := make([]User, 0)
+	if err := r.db.SelectContext(ctx, &users, `SELECT * FROM users ORDER BY id`); err != nil {
+		userLogger.WithError(err).Error("Failed to list users in database")
+		return nil, err
+	}
+
+	userLogger.Debug("User List successfully")
+	return users, nil
+}
+
+func (r *SQLiteRepository) SetDisabled(ctx context.Context, username string, disabled bool) error {
+	userLogger := repositoryLogger(r.logger).WithField("disabled", disabled)
+	userLogger.Debug("Attempting to SetDisabled")
+
+	query := `UPDATE users SET disabled_at = CASE WHEN ?2 THEN COALESCE(disabled_at, ?3) END WHERE username = ?1`
+	if err := r.exec(ctx, userLogger, ErrUserNotFound, query, username, disabled, time.Now()); err != nil {
+		return err
+	}
+
+	userLogger.Debug("SetDisabled successfully")
+	return nil
+}
+
+func (r *SQLiteRepository) UpdatePassword(ctx context.Context, username, password string) error {
+	userLogger := repositoryLogger(r.logger)
+	userLogger.Debug("Attempting to UpdatePassword")
+
+	query := `UPDATE users SET password = ?2 WHERE username = ?1`
+	if err := r.exec(ctx, userLogger, ErrUserNotFound, query, username, password); err != nil {
+		return err
+	}
+
+	userLogger.Debug("UpdatePassword successfully")
+	return nil
+}
+
+func (r *SQLiteRepository) CreateAppPassword(ctx context.Context, password *AppPassword) (*AppPassword, error) {
+	userLogger := repositoryLogger(r.logger).WithField("user_id", password.UserID)
+	userLogger.Debug("Attempting to CreateAppPassword")
+
+	password.CreatedAt = time.Now()
+	query := `INSERT INTO app_passwords (user_id, name, password_hash, created_at) VALUES (?1, ?2, ?3, ?4) RETURNING id`
+	row := r.db.QueryRowContext(ctx, query, password.UserID, password.Name, password.PasswordHash, password.CreatedAt)
+	if err := row.Scan(&password.ID); err != nil {
+		userLogger.WithError(err).Error("Failed to create app password in database")
+		return nil, err
+	}
+
+	userLogger.WithField("app_password_id", password.ID).Debug("CreateAppPassword successfully")
+	return password, nil
+}
+
+func (r *SQLiteRepository) GetAppPasswords(ctx context.Context, userID int) ([]AppPassword, error) {
+	userLogger := repositoryLogger(r.logger).WithField("user_id", userID)
+	userLogger.Debug("Attempting to GetAppPasswords")
+
+	passwords := make([]AppPassword, 0)
+	query := `SELECT * FROM app_passwords WHERE user_id = ?1 ORDER BY id`
+	if err := r.db.SelectContext(ctx, &passwords, query, userID); err != nil {
+		userLogger.WithError(err).Error("Failed to get app passwords in database")
+		return nil, err
+	}
+
+	userLogger.Debug("GetAppPasswords successfully")
+	return passwords, nil
+}
+
+func (r *SQLiteRepository) GetAppPasswordByHash(ctx context.Context, hash string) (*AppPassword, error) {
+	userLogger := repositoryLogger(r.logger)
+	userLogger.Debug("Attempting to GetAppPasswordByHash")
+
+	var password AppPassword
+	err := r.db.GetContext(ctx, &password, `SELECT * FROM app_passwords WHERE password_hash = ?1`, hash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			userLogger.Debug(ErrAppPasswordNotFound.Error())
+			return nil, ErrAppPasswordNotFound
+		}
+		userLogger.WithError(err).Error("Failed to get app password in database")
+		return nil, err
+	}
+
+	userLogger.WithField("user_id", password.UserID).Debug("GetAppPasswordByHash successfully")
+	return &password, nil
+}
+
+func (r *SQLiteRepository) TouchAppPassword(ctx context.Context, id int) error {
+	userLogger := repositoryLogger(r.logger).WithField("app_password_id", id)
+	userLogger.Debug("Attempting to TouchAppPassword")
+
+	query := `UPDATE app_passwords SET last_used_at = ?2 WHERE id = ?1`
+	if _, err := r.db.ExecContext(ctx, query, id, time.Now()); err != nil {
+		userLogger.WithError(err).Error("Failed to touch app password in database")
+		return err
+	}
+
+	userLogger.Debug("TouchAppPassword successfully")
+	return nil
+}
+
+func (r *SQLiteRepository) DeleteAppPassword(ctx context.Context, userID, id int) error {
+	userLogger := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id":         userID,
+		"app_password_id": id,
+	})
+	userLogger.Debug("Attempting to DeleteAppPassword")
+
+	query := `DELETE FROM app_passwords WHERE id = ?1 AND user_id = ?2`
+	if err := r.exec(ctx, userLogger, ErrAppPasswordNotFound, query, id, userID); err != nil {
+		return err
+	}
+
+	userLogger.Debug("DeleteAppPassword successfully")
+	return nil
+}
+
+// exec выполняет изменяющий запрос и возвращает notFound, если он не затронул строк
+func (r *SQLiteRepository) exec(ctx context.Context, userLogger *logrus.Entry, notFound error, query string, args ...any) error {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		userLogger.WithError(err).Error("Failed to execute query in database")
+		return err
+	}
+
+	row, err := result.RowsAffected()
+	if err != nil {
+		userLogger.WithError(err).Error("Failed rows affected")
+		return err
+	}
+
+	if row == 0 {
+		userLogger.Warn(notFound.Error())
+		return notFound
+	}
+	return nil
+}
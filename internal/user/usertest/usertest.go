@@ -0,0 +1,239 @@
+// Package usertest - общий набор проверок user.IRepository. Его проходят все
+// реализации хранилища, чтобы их поведение не расходилось.
+package usertest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/melnik-dev/go_todo_jwt/internal/user"
+)
+
+var names atomic.Int64
+
+// UniqueName возвращает имя, не занятое прежними запусками тестов на той же базе
+func UniqueName(prefix string) string {
+	return fmt.Sprintf("%s_%x_%d", prefix, time.Now().UnixNano(), names.Add(1))
+}
+
+// CreateUser создаёт пользователя с уникальным именем
+func CreateUser(t *testing.T, repo user.IRepository) *user.User {
+	t.Helper()
+
+	u, err := repo.Create(context.Background(), &user.User{
+		Name:     UniqueName("user"),
+		Password: "hash",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+// Run проверяет хранилище, которое newRepo создаёт для каждого теста
+func Run(t *testing.T, newRepo func(t *testing.T) user.IRepository) {
+	tests := map[string]func(t *testing.T, repo user.IRepository){
+		"Get":            testGet,
+		"UniqueUsername": testUniqueUsername,
+		"List":           testList,
+		"SetDisabled":    testSetDisabled,
+		"UpdatePassword": testUpdatePassword,
+		"AppPasswords":   testAppPasswords,
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test(t, newRepo(t))
+		})
+	}
+}
+
+func testGet(t *testing.T, repo user.IRepository) {
+	ctx := context.Background()
+	created := CreateUser(t, repo)
+	if created.ID == 0 {
+		t.Fatal("expected ID of created user")
+	}
+
+	got, err := repo.Get(ctx, created.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != created.ID || got.Name != created.Name || got.Password != created.Password || got.DisabledAt != nil {
+		t.Errorf("expected %+v, got %+v", created, got)
+	}
+
+	if _, err = repo.Get(ctx, UniqueName("missing")); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected %v, got %v", sql.ErrNoRows, err)
+	}
+}
+
+func testUniqueUsername(t *testing.T, repo user.IRepository) {
+	created := CreateUser(t, repo)
+
+	_, err := repo.Create(context.Background(), &user.User{Name: created.Name, Password: "other"})
+	if !errors.Is(err, user.ErrUsernameTaken) {
+		t.Fatalf("expected %v, got %v", user.ErrUsernameTaken, err)
+	}
+
+	got, err := repo.Get(context.Background(), created.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Password != created.Password {
+		t.Errorf("expected password %q to stay, got %q", created.Password, got.Password)
+	}
+}
+
+func testList(t *testing.T, repo user.IRepository) {
+	first := CreateUser(t, repo)
+	second := CreateUser(t, repo)
+
+	users, err := repo.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// в общей базе есть и чужие пользователи: проверяется только порядок своих
+	var ids []int
+	for i, u := range users {
+		if i > 0 && users[i-1].ID >= u.ID {
+			t.Fatalf("users are not ordered by id: %d before %d", users[i-1].ID, u.ID)
+		}
+		if u.ID == first.ID || u.ID == second.ID {
+			ids = append(ids, u.ID)
+		}
+	}
+	if len(ids) != 2 || ids[0] != first.ID || ids[1] != second.ID {
+		t.Errorf("expected users %d and %d, got %v", first.ID, second.ID, ids)
+	}
+}
+
+func testSetDisabled(t *testing.T, repo user.IRepository) {
+	ctx := context.Background()
+	created := CreateUser(t, repo)
+
+	if err := repo.SetDisabled(ctx, created.Name, true); err != nil {
+		t.Fatal(err)
+	}
+	disabled, err := repo.Get(ctx, created.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if disabled.DisabledAt == nil {
+		t.Fatal("expected disabled_at")
+	}
+
+	// повторное отключение сохраняет время первого
+	if err = repo.SetDisabled(ctx, created.Name, true); err != nil {
+		t.Fatal(err)
+	}
+	again, err := repo.Get(ctx, created.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.DisabledAt == nil || !again.DisabledAt.Equal(*disabled.DisabledAt) {
+		t.Errorf("expected disabled_at %v, got %v", disabled.DisabledAt, again.DisabledAt)
+	}
+
+	if err = repo.SetDisabled(ctx, created.Name, false); err != nil {
+		t.Fatal(err)
+	}
+	enabled, err := repo.Get(ctx, created.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enabled.DisabledAt != nil {
+		t.Errorf("expected no disabled_at, got %v", enabled.DisabledAt)
+	}
+
+	if err = repo.SetDisabled(ctx, UniqueName("missing"), true); !errors.Is(err, user.ErrUserNotFound) {
+		t.Errorf("expected %v, got %v", user.ErrUserNotFound, err)
+	}
+}
+
+func testUpdatePassword(t *testing.T, repo user.IRepository) {
+	ctx := context.Background()
+	created := CreateUser(t, repo)
+
+	if err := repo.UpdatePassword(ctx, created.Name, "new_hash"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.Get(ctx, created.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Password != "new_hash" {
+		t.Errorf("expected password %q, got %q", "new_hash", got.Password)
+	}
+
+	if err = repo.UpdatePassword(ctx, UniqueName("missing"), "hash"); !errors.Is(err, user.ErrUserNotFound) {
+		t.Errorf("expected %v, got %v", user.ErrUserNotFound, err)
+	}
+}
+
+func testAppPasswords(t *testing.T, repo user.IRepository) {
+	ctx := context.Background()
+	owner := CreateUser(t, repo)
+	other := CreateUser(t, repo)
+
+	var created []*user.AppPassword
+	for _, p := range []user.AppPassword{
+		{UserID: owner.ID, Name: "phone", PasswordHash: UniqueName("hash")},
+		{UserID: other.ID, Name: "laptop", PasswordHash: UniqueName("hash")},
+		{UserID: owner.ID, Name: "tablet", PasswordHash: UniqueName("hash")},
+	} {
+		password, err := repo.CreateAppPassword(ctx, &p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if password.ID == 0 || password.CreatedAt.IsZero() {
+			t.Fatalf("expected id and created_at, got %+v", password)
+		}
+		created = append(created, password)
+	}
+
+	passwords, err := repo.GetAppPasswords(ctx, owner.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(passwords) != 2 || passwords[0].ID != created[0].ID || passwords[1].ID != created[2].ID {
+		t.Fatalf("expected passwords %d and %d of owner, got %+v", created[0].ID, created[2].ID, passwords)
+	}
+
+	found, err := repo.GetAppPasswordByHash(ctx, created[0].PasswordHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ID != created[0].ID || found.UserID != owner.ID || found.LastUsedAt != nil {
+		t.Errorf("expected unused password %d of user %d, got %+v", created[0].ID, owner.ID, found)
+	}
+	if _, err = repo.GetAppPasswordByHash(ctx, UniqueName("hash")); !errors.Is(err, user.ErrAppPasswordNotFound) {
+		t.Errorf("expected %v, got %v", user.ErrAppPasswordNotFound, err)
+	}
+
+	if err = repo.TouchAppPassword(ctx, created[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if found, err = repo.GetAppPasswordByHash(ctx, created[0].PasswordHash); err != nil {
+		t.Fatal(err)
+	}
+	if found.LastUsedAt == nil {
+		t.Error("expected last_used_at after touch")
+	}
+
+	// чужой пароль удалить нельзя
+	if err = repo.DeleteAppPassword(ctx, other.ID, created[0].ID); !errors.Is(err, user.ErrAppPasswordNotFound) {
+		t.Errorf("expected %v, got %v", user.ErrAppPasswordNotFound, err)
+	}
+	if err = repo.DeleteAppPassword(ctx, owner.ID, created[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = repo.GetAppPasswordByHash(ctx, created[0].PasswordHash); !errors.Is(err, user.ErrAppPasswordNotFound) {
+		t.Errorf("expected %v, got %v", user.ErrAppPasswordNotFound, err)
+	}
+}
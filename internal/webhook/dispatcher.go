@@ -0,0 +1,203 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/sirupsen/logrus"
+)
+
+// maxResponseBody - сколько байт ответа получателя сохраняется в журнал
+const maxResponseBody = 1024
+
+// Body - тело запроса доставки; Task - строка задачи на момент события
+type Body struct {
+	ID          int64           `json:"id"`
+	Event       string          `json:"event"`
+	WorkspaceID int             `json:"workspace_id"`
+	CreatedAt   time.Time       `json:"created_at"`
+	Task        json.RawMessage `json:"task"`
+}
+
+// Dispatcher разносит события из outbox по вебхукам и выполняет доставки
+// с повторами по экспоненциальной паузе
+type Dispatcher struct {
+	webhookRepo IRepository
+	client      *http.Client
+	cfg         configs.ConfWebhooks
+	logger      *logrus.Logger
+	now         func() time.Time
+}
+
+// NewDispatcher ждёт client, который не ходит во внутреннюю сеть (safehttp.NewClient):
+// адреса вебхуков задают пользователи, а ответы попадают в журнал доставок
+func NewDispatcher(webhookRepo IRepository, client *http.Client, cfg configs.ConfWebhooks, logger *logrus.Logger) *Dispatcher {
+	return &Dispatcher{
+		webhookRepo: webhookRepo,
+		client:      client,
+		cfg:         cfg,
+		logger:      logger,
+		now:         time.Now,
+	}
+}
+
+// Run обрабатывает outbox и очередь доставок каждые Interval, пока не отменён ctx
+func (d *Dispatcher) Run(ctx context.Context) {
+	logDisp := dispatcherLogger(d.logger)
+	logDisp.Info("Webhook dispatcher started")
+
+	ticker := time.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := d.Tick(ctx); err != nil && ctx.Err() == nil {
+			logDisp.WithError(err).Error("Failed to process webhooks")
+		}
+		select {
+		case <-ctx.Done():
+			logDisp.Info("Webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick разносит все накопившиеся события и выполняет доставки, которым пора;
+// возвращает число выполненных попыток
+func (d *Dispatcher) Tick(ctx context.Context) (int, error) {
+	for {
+		n, err := d.webhookRepo.Dispatch(ctx, d.cfg.Batch)
+		if err != nil {
+			return 0, err
+		}
+		if n < d.cfg.Batch {
+			break
+		}
+	}
+
+	total := 0
+	for ctx.Err() == nil {
+		jobs, err := d.webhookRepo.Claim(ctx, d.cfg.Batch, d.cfg.Timeout+time.Minute)
+		if err != nil {
+			return total, err
+		}
+
+		var wg sync.WaitGroup
+		for i := range jobs {
+			wg.Add(1)
+			go func(job *Job) {
+				defer wg.Done()
+				d.deliver(ctx, job)
+			}(&jobs[i])
+		}
+		wg.Wait()
+
+		total += len(jobs)
+		if len(jobs) < d.cfg.Batch {
+			return total, nil
+		}
+	}
+	return total, ctx.Err()
+}
+
+// deliver выполняет одну попытку и записывает результат. Если остановка сервиса
+// прервала запрос, результат не записывается: доставка повторится после lease.
+func (d *Dispatcher) deliver(ctx context.Context, job *Job) {
+	logDisp := dispatcherLogger(d.logger).WithFields(logrus.Fields{
+		"delivery_id": job.DeliveryID,
+		"event":       job.Event,
+	})
+
+	start := d.now()
+	status, body, err := d.send(ctx, job)
+	if ctx.Err() != nil {
+		logDisp.Debug("Delivery interrupted by shutdown")
+		return
+	}
+
+	attempt := &Attempt{
+		DeliveryID: job.DeliveryID,
+		Status:     StatusSucceeded,
+		Duration:   d.now().Sub(start),
+	}
+	if status != 0 {
+		attempt.ResponseStatus = &status
+		attempt.ResponseBody = &body
+	}
+	if err == nil && (status < 200 || status >= 300) {
+		err = fmt.Errorf("unexpected response status %d", status)
+	}
+	if err != nil {
+		msg := err.Error()
+		attempt.Error = &msg
+		attempt.Status = StatusPending
+		attempt.NextAttemptAt = d.now().Add(Backoff(d.cfg, job.Attempts+1))
+		if job.Attempts+1 >= d.cfg.MaxAttempts {
+			attempt.Status = StatusFailed
+		}
+		logDisp.WithError(err).WithField("attempt", job.Attempts+1).Warn("Webhook delivery failed")
+	}
+	if attempt.NextAttemptAt.IsZero() {
+		attempt.NextAttemptAt = d.now()
+	}
+
+	if err = d.webhookRepo.Record(context.WithoutCancel(ctx), attempt); err != nil {
+		logDisp.WithError(err).Error("Failed to Record attempt")
+	}
+}
+
+// send подписывает и отправляет тело события; возвращает статус и начало ответа
+func (d *Dispatcher) send(ctx context.Context, job *Job) (int, string, error) {
+	payload, err := json.Marshal(Body{
+		ID:          job.EventID,
+		Event:       job.Event,
+		WorkspaceID: job.WorkspaceID,
+		CreatedAt:   job.CreatedAt,
+		Task:        job.Payload,
+	})
+	if err != nil {
+		return 0, "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, "", err
+	}
+	timestamp := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "todo-jwt-webhooks")
+	req.Header.Set(HeaderEvent, job.Event)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(job.DeliveryID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(job.Secret, timestamp, payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	return resp.StatusCode, string(body), nil
+}
+
+// Backoff - пауза перед попыткой attempt+1: Backoff, 2*Backoff, 4*Backoff, ... не больше MaxBackoff
+func Backoff(cfg configs.ConfWebhooks, attempt int) time.Duration {
+	delay := cfg.Backoff
+	for i := 1; i < attempt && delay < cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, cfg.MaxBackoff)
+}
+
+func dispatcherLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Dispatcher webhook layer")
+}
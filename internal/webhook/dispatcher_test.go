@@ -0,0 +1,166 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/internal/webhook"
+)
+
+var dispatcherCfg = configs.ConfWebhooks{
+	Batch:       10,
+	Timeout:     time.Second,
+	MaxAttempts: 3,
+	Backoff:     30 * time.Second,
+	MaxBackoff:  time.Hour,
+}
+
+func TestBackoff(t *testing.T) {
+	cfg := configs.ConfWebhooks{Backoff: 30 * time.Second, MaxBackoff: 5 * time.Minute}
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	for i, w := range want {
+		if got := webhook.Backoff(cfg, i+1); got != w {
+			t.Errorf("attempt %d: expected %v, got %v", i+1, w, got)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{"id":1}' | openssl dgst -sha256 -hmac secret
+	got := webhook.Sign("secret", 1700000000, []byte(`{"id":1}`))
+	if got != "sha256=3dd1b9aef568d75f6790a84bd2e5dfa1f44409eef3cbdbd3f10b837376100c11" {
+		t.Fatalf("unexpected signature %s", got)
+	}
+	if !webhook.Verify("secret", 1700000000, []byte(`{"id":1}`), got) {
+		t.Error("signature must verify")
+	}
+	if webhook.Verify("other", 1700000000, []byte(`{"id":1}`), got) {
+		t.Error("signature must not verify with another secret")
+	}
+}
+
+func TestDispatcher_Tick(t *testing.T) {
+	var mu sync.Mutex
+	var received []*http.Request
+	var bodies [][]byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, r)
+		bodies = append(bodies, body)
+		mu.Unlock()
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("maintenance"))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	jobs := []webhook.Job{
+		{DeliveryID: 1, URL: srv.URL + "/ok", Secret: "whsec_a", EventID: 7, Event: "task.created", WorkspaceID: 3, TaskID: 5, Payload: []byte(`{"id":5,"title":"Report"}`)},
+		{DeliveryID: 2, Attempts: 0, URL: srv.URL + "/down", Secret: "whsec_b", EventID: 7, Event: "task.created", Payload: []byte(`{}`)},
+		{DeliveryID: 3, Attempts: 2, URL: srv.URL + "/down", Secret: "whsec_b", EventID: 8, Event: "task.deleted", Payload: []byte(`{}`)},
+	}
+	dispatched := false
+	attempts := make(map[int64]*webhook.Attempt)
+	repo := &MockWebhookRepository{
+		DispatchMock: func(limit int) (int, error) {
+			dispatched = true
+			return 2, nil
+		},
+		ClaimMock: func(limit int, lease time.Duration) ([]webhook.Job, error) {
+			if lease <= dispatcherCfg.Timeout {
+				t.Errorf("lease %v must outlast request timeout", lease)
+			}
+			return jobs, nil
+		},
+		RecordMock: func(attempt *webhook.Attempt) error {
+			mu.Lock()
+			attempts[attempt.DeliveryID] = attempt
+			mu.Unlock()
+			return nil
+		},
+	}
+	dispatcher := webhook.NewDispatcher(repo, srv.Client(), dispatcherCfg, mockLogger())
+
+	n, err := dispatcher.Tick(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dispatched || n != 3 || len(received) != 3 {
+		t.Fatalf("unexpected dispatched=%v attempts=%d requests=%d", dispatched, n, len(received))
+	}
+
+	for i, r := range received {
+		if r.URL.Path != "/ok" {
+			continue
+		}
+		ts, _ := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+		if !webhook.Verify("whsec_a", ts, bodies[i], r.Header.Get(webhook.HeaderSignature)) {
+			t.Error("invalid signature")
+		}
+		if r.Header.Get(webhook.HeaderEvent) != "task.created" || r.Header.Get(webhook.HeaderDelivery) != "1" {
+			t.Errorf("unexpected headers %v", r.Header)
+		}
+		var body webhook.Body
+		if err = json.Unmarshal(bodies[i], &body); err != nil {
+			t.Fatal(err)
+		}
+		if body.ID != 7 || body.Event != "task.created" || body.WorkspaceID != 3 || string(body.Task) != `{"id":5,"title":"Report"}` {
+			t.Errorf("unexpected body %s", bodies[i])
+		}
+	}
+
+	if a := attempts[1]; a.Status != webhook.StatusSucceeded || *a.ResponseStatus != http.StatusNoContent || a.Error != nil {
+		t.Errorf("unexpected attempt %+v", a)
+	}
+	retry := attempts[2]
+	if retry.Status != webhook.StatusPending || *retry.ResponseStatus != 503 || *retry.ResponseBody != "maintenance" {
+		t.Errorf("unexpected attempt %+v", retry)
+	}
+	if wait := time.Until(retry.NextAttemptAt); wait < 25*time.Second || wait > 30*time.Second {
+		t.Errorf("expected retry in ~30s, got %v", wait)
+	}
+	if a := attempts[3]; a.Status != webhook.StatusFailed {
+		t.Errorf("expected last attempt to fail delivery, got %+v", a)
+	}
+}
+
+func TestDispatcher_Tick_Cancelled(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	repo := &MockWebhookRepository{
+		DispatchMock: func(limit int) (int, error) {
+			return 0, nil
+		},
+		ClaimMock: func(limit int, lease time.Duration) ([]webhook.Job, error) {
+			time.AfterFunc(50*time.Millisecond, cancel)
+			return []webhook.Job{{DeliveryID: 1, URL: srv.URL, Payload: []byte(`{}`)}}, nil
+		},
+		RecordMock: func(attempt *webhook.Attempt) error {
+			t.Error("interrupted attempt must not be recorded")
+			return nil
+		},
+	}
+	dispatcher := webhook.NewDispatcher(repo, srv.Client(), dispatcherCfg, mockLogger())
+
+	if _, err := dispatcher.Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
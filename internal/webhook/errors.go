@@ -0,0 +1,11 @@
+package webhook
+
+import "errors"
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("delivery not found")
+	ErrForbidden        = errors.New("only workspace owners and admins can manage webhooks")
+	ErrInvalidURL       = errors.New("webhook url must be an absolute http or https url")
+	ErrUnknownEvent     = errors.New("unknown webhook event")
+)
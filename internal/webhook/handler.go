@@ -0,0 +1,275 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/pkg/logger"
+	"github.com/melnik-dev/go_todo_jwt/pkg/middleware"
+	"github.com/melnik-dev/go_todo_jwt/pkg/response"
+	"github.com/sirupsen/logrus"
+)
+
+type HandlerDeps struct {
+	WebhookService IService
+	*configs.Config
+	// Workspace выбирает рабочее пространство запроса
+	Workspace gin.HandlerFunc
+}
+
+type Handler struct {
+	WebhookService IService
+	*configs.Config
+}
+
+func NewHandler(r *gin.Engine, deps *HandlerDeps) {
+	handler := &Handler{
+		WebhookService: deps.WebhookService,
+		Config:         deps.Config,
+	}
+	webhooks := r.Group("/webhooks")
+	webhooks.Use(middleware.IsAuthed(handler.Config.JWT.Secret))
+	if deps.Workspace != nil {
+		webhooks.Use(deps.Workspace)
+	}
+	webhooks.GET("/", handler.GetAll)
+	webhooks.POST("/", handler.Create)
+	webhooks.GET("/:id", handler.Get)
+	webhooks.PUT("/:id", handler.Update)
+	webhooks.DELETE("/:id", handler.Delete)
+	webhooks.GET("/:id/deliveries", handler.GetDeliveries)
+	webhooks.POST("/:id/deliveries/:delivery_id/redeliver", handler.Redeliver)
+}
+
+func (h *Handler) GetAll(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to GetAll webhooks")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	webhooks, err := h.WebhookService.GetAll(c.Request.Context(), userID)
+	if err != nil {
+		if !writeError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to GetAll webhooks")
+			response.InternalServerError(c, "Failed to get webhooks")
+		}
+		return
+	}
+
+	logHandle.Debug("GetAll webhooks successfully")
+	response.Success(c, http.StatusOK, gin.H{"webhooks": webhooks})
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Create webhook")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind request")
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	webhook, err := h.WebhookService.Create(c.Request.Context(), userID, &req)
+	if err != nil {
+		if !writeError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to Create webhook")
+			response.InternalServerError(c, "Failed to create webhook")
+		}
+		return
+	}
+
+	logHandle.Debug("Create webhook successfully")
+	response.Success(c, http.StatusCreated, webhook)
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Get webhook")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri URIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind ID")
+		response.BadRequest(c, "Invalid webhook ID")
+		return
+	}
+
+	webhook, err := h.WebhookService.Get(c.Request.Context(), userID, uri.ID)
+	if err != nil {
+		if !writeError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to Get webhook")
+			response.InternalServerError(c, "Failed to get webhook")
+		}
+		return
+	}
+
+	logHandle.Debug("Get webhook successfully")
+	response.Success(c, http.StatusOK, webhook)
+}
+
+func (h *Handler) Update(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Update webhook")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri URIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind ID")
+		response.BadRequest(c, "Invalid webhook ID")
+		return
+	}
+
+	var req UpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind request")
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	webhook, err := h.WebhookService.Update(c.Request.Context(), userID, uri.ID, &req)
+	if err != nil {
+		if !writeError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to Update webhook")
+			response.InternalServerError(c, "Failed to update webhook")
+		}
+		return
+	}
+
+	logHandle.Debug("Update webhook successfully")
+	response.Success(c, http.StatusOK, webhook)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Delete webhook")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri URIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind ID")
+		response.BadRequest(c, "Invalid webhook ID")
+		return
+	}
+
+	if err := h.WebhookService.Delete(c.Request.Context(), userID, uri.ID); err != nil {
+		if !writeError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to Delete webhook")
+			response.InternalServerError(c, "Failed to delete webhook")
+		}
+		return
+	}
+
+	logHandle.Debug("Delete webhook successfully")
+	response.Success(c, http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+func (h *Handler) GetDeliveries(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to GetDeliveries")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri URIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind ID")
+		response.BadRequest(c, "Invalid webhook ID")
+		return
+	}
+
+	var query DeliveryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind query")
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	deliveries, err := h.WebhookService.GetDeliveries(c.Request.Context(), userID, uri.ID, &query)
+	if err != nil {
+		if !writeError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to GetDeliveries")
+			response.InternalServerError(c, "Failed to get deliveries")
+		}
+		return
+	}
+
+	logHandle.Debug("GetDeliveries successfully")
+	response.Success(c, http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+func (h *Handler) Redeliver(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Redeliver")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri DeliveryURIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind ID")
+		response.BadRequest(c, "Invalid delivery ID")
+		return
+	}
+
+	delivery, err := h.WebhookService.Redeliver(c.Request.Context(), userID, uri.ID, uri.DeliveryID)
+	if err != nil {
+		if !writeError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to Redeliver")
+			response.InternalServerError(c, "Failed to redeliver")
+		}
+		return
+	}
+
+	logHandle.Debug("Redeliver successfully")
+	response.Success(c, http.StatusAccepted, delivery)
+}
+
+// writeError отвечает на ошибки проверки, доступа и отсутствия; false - ошибка не из их числа
+func writeError(c *gin.Context, logHandle *logrus.Entry, err error) bool {
+	switch {
+	case errors.Is(err, ErrInvalidURL), errors.Is(err, ErrUnknownEvent):
+		logHandle.Warn(err.Error())
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, ErrWebhookNotFound), errors.Is(err, ErrDeliveryNotFound):
+		logHandle.Warn(err.Error())
+		response.NotFound(c, err.Error())
+	case errors.Is(err, ErrForbidden):
+		logHandle.Warn(err.Error())
+		response.Forbidden(c, err.Error())
+	default:
+		return false
+	}
+	return true
+}
+
+func handlerLogger(c *gin.Context) *logrus.Entry {
+	return logger.FromContext(c).WithField("layer", "Handler webhook layer")
+}
@@ -0,0 +1,123 @@
+package webhook_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/internal/webhook"
+	"github.com/sirupsen/logrus"
+)
+
+// MockWebhookService встраивает webhook.IService, чтобы переопределять только нужные методы
+type MockWebhookService struct {
+	webhook.IService
+	CreateMock    func(userID int, req *webhook.CreateRequest) (*webhook.Webhook, error)
+	RedeliverMock func(userID, id int, deliveryID int64) (*webhook.Delivery, error)
+}
+
+func (m *MockWebhookService) Create(ctx context.Context, userID int, req *webhook.CreateRequest) (*webhook.Webhook, error) {
+	return m.CreateMock(userID, req)
+}
+
+func (m *MockWebhookService) Redeliver(ctx context.Context, userID, id int, deliveryID int64) (*webhook.Delivery, error) {
+	return m.RedeliverMock(userID, id, deliveryID)
+}
+
+func mockGin() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		c.Set("logger", logrus.NewEntry(logger))
+		c.Set("user_id", 42)
+		c.Next()
+	})
+	return r
+}
+
+func TestHandler_Create(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		err      error
+		wantCode int
+	}{
+		{name: "success", body: `{"url":"https://example.com","events":["task.created"]}`, wantCode: http.StatusCreated},
+		{name: "no events", body: `{"url":"https://example.com","events":[]}`, wantCode: http.StatusBadRequest},
+		{name: "unknown event", body: `{"url":"https://example.com","events":["x"]}`, err: webhook.ErrUnknownEvent, wantCode: http.StatusBadRequest},
+		{name: "member", body: `{"url":"https://example.com","events":["task.created"]}`, err: webhook.ErrForbidden, wantCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &webhook.Handler{
+				WebhookService: &MockWebhookService{
+					CreateMock: func(userID int, req *webhook.CreateRequest) (*webhook.Webhook, error) {
+						if tt.err != nil {
+							return nil, tt.err
+						}
+						return &webhook.Webhook{ID: 1, URL: req.URL, Events: req.Events, Secret: "whsec_1"}, nil
+					},
+				},
+			}
+
+			r := mockGin()
+			r.POST("/webhooks/", handler.Create)
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, w.Code)
+			}
+			if w.Code == http.StatusCreated && !strings.Contains(w.Body.String(), `"secret":"whsec_1"`) {
+				t.Errorf("secret must be returned on create: %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_Redeliver(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		err      error
+		wantCode int
+	}{
+		{name: "success", path: "/webhooks/1/deliveries/10/redeliver", wantCode: http.StatusAccepted},
+		{name: "not found", path: "/webhooks/1/deliveries/10/redeliver", err: webhook.ErrDeliveryNotFound, wantCode: http.StatusNotFound},
+		{name: "invalid id", path: "/webhooks/1/deliveries/abc/redeliver", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &webhook.Handler{
+				WebhookService: &MockWebhookService{
+					RedeliverMock: func(userID, id int, deliveryID int64) (*webhook.Delivery, error) {
+						if tt.err != nil {
+							return nil, tt.err
+						}
+						return &webhook.Delivery{ID: 11, WebhookID: id, Status: webhook.StatusPending}, nil
+					},
+				},
+			}
+
+			r := mockGin()
+			r.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", handler.Redeliver)
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, w.Code)
+			}
+		})
+	}
+}
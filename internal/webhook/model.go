@@ -0,0 +1,85 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// События задач; их пишет в outbox триггер tasks_webhook_event
+const (
+	EventTaskCreated   = "task.created"
+	EventTaskUpdated   = "task.updated"
+	EventTaskCompleted = "task.completed"
+	EventTaskDeleted   = "task.deleted"
+)
+
+var Events = []string{
+	EventTaskCreated,
+	EventTaskUpdated,
+	EventTaskCompleted,
+	EventTaskDeleted,
+}
+
+// Статусы доставки
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Webhook - адрес рабочего пространства, на который отправляются события.
+// Secret возвращается клиенту только при создании.
+type Webhook struct {
+	ID          int            `db:"id" json:"id"`
+	WorkspaceID int            `db:"workspace_id" json:"workspace_id"`
+	UserID      *int           `db:"user_id" json:"created_by"`
+	URL         string         `db:"url" json:"url"`
+	Description string         `db:"description" json:"description"`
+	Events      pq.StringArray `db:"events" json:"events"`
+	Secret      string         `db:"secret" json:"secret,omitempty"`
+	Active      bool           `db:"active" json:"active"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+}
+
+// Delivery - запись журнала доставок: результат последней попытки и когда будет следующая
+type Delivery struct {
+	ID             int64      `db:"id" json:"id"`
+	WebhookID      int        `db:"webhook_id" json:"webhook_id"`
+	EventID        int64      `db:"event_id" json:"event_id"`
+	Event          string     `db:"event" json:"event"`
+	Status         string     `db:"status" json:"status"`
+	Attempts       int        `db:"attempts" json:"attempts"`
+	NextAttemptAt  time.Time  `db:"next_attempt_at" json:"next_attempt_at"`
+	LastAttemptAt  *time.Time `db:"last_attempt_at" json:"last_attempt_at"`
+	ResponseStatus *int       `db:"response_status" json:"response_status"`
+	ResponseBody   *string    `db:"response_body" json:"response_body"`
+	Error          *string    `db:"error" json:"error"`
+	DurationMs     *int       `db:"duration_ms" json:"duration_ms"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+}
+
+// Job - захваченная диспетчером доставка со всем, что нужно для запроса
+type Job struct {
+	DeliveryID  int64     `db:"id"`
+	Attempts    int       `db:"attempts"`
+	URL         string    `db:"url"`
+	Secret      string    `db:"secret"`
+	EventID     int64     `db:"event_id"`
+	Event       string    `db:"event"`
+	WorkspaceID int       `db:"workspace_id"`
+	TaskID      int       `db:"task_id"`
+	Payload     []byte    `db:"payload"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// Attempt - результат попытки доставки, который диспетчер сохраняет в журнал
+type Attempt struct {
+	DeliveryID     int64
+	Status         string
+	NextAttemptAt  time.Time
+	ResponseStatus *int
+	ResponseBody   *string
+	Error          *string
+	Duration       time.Duration
+}
@@ -0,0 +1,31 @@
+package webhook
+
+type URIParam struct {
+	ID int `uri:"id" binding:"required,min=1"`
+}
+
+type DeliveryURIParam struct {
+	ID         int   `uri:"id" binding:"required,min=1"`
+	DeliveryID int64 `uri:"delivery_id" binding:"required,min=1"`
+}
+
+type CreateRequest struct {
+	URL         string   `json:"url" binding:"required,max=2000"`
+	Description string   `json:"description" binding:"max=255"`
+	Events      []string `json:"events" binding:"required,min=1,dive,required"`
+}
+
+// UpdateRequest меняет только переданные поля
+type UpdateRequest struct {
+	URL         *string  `json:"url" binding:"omitempty,max=2000"`
+	Description *string  `json:"description" binding:"omitempty,max=255"`
+	Events      []string `json:"events" binding:"omitempty,min=1,dive,required"`
+	Active      *bool    `json:"active"`
+}
+
+// DeliveryQuery - страница журнала от новых к старым; BeforeID - id последней
+// доставки предыдущей страницы
+type DeliveryQuery struct {
+	Limit    int   `form:"limit" binding:"omitempty,min=1,max=100"`
+	BeforeID int64 `form:"before_id" binding:"omitempty,min=1"`
+}
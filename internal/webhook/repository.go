@@ -0,0 +1,334 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/sirupsen/logrus"
+)
+
+// Методы управления вебхуками работают в рабочем пространстве из ctx через db.Tenant.
+// Dispatch, Claim и Record вызывает фоновый диспетчер для всех пространств сразу.
+type IRepository interface {
+	Role(ctx context.Context, userID int) (string, error)
+	Create(ctx context.Context, webhook *Webhook) error
+	GetAll(ctx context.Context) ([]Webhook, error)
+	Get(ctx context.Context, id int) (*Webhook, error)
+	Update(ctx context.Context, webhook *Webhook) error
+	Delete(ctx context.Context, id int) error
+	GetDeliveries(ctx context.Context, webhookID int, query *DeliveryQuery) ([]Delivery, error)
+	Redeliver(ctx context.Context, webhookID int, deliveryID int64) (*Delivery, error)
+	Dispatch(ctx context.Context, limit int) (int, error)
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]Job, error)
+	Record(ctx context.Context, attempt *Attempt) error
+}
+
+type Repository struct {
+	db     *db.Db
+	logger *logrus.Logger
+}
+
+func NewRepository(db *db.Db, logger *logrus.Logger) *Repository {
+	return &Repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const webhookColumns = `id, workspace_id, user_id, url, description, events, secret, active, created_at`
+
+const deliveryColumns = `d.id, d.webhook_id, d.event_id, e.type AS event, d.status, d.attempts, d.next_attempt_at,
+					d.last_attempt_at, d.response_status, d.response_body, d.error, d.duration_ms, d.created_at`
+
+// Role возвращает роль пользователя в текущем рабочем пространстве; пустая строка - не участник
+func (r *Repository) Role(ctx context.Context, userID int) (string, error) {
+	logRepo := repositoryLogger(r.logger).WithField("user_id", userID)
+	logRepo.Debug("Attempting to get Role")
+
+	var role string
+	query := `SELECT COALESCE((SELECT role FROM workspace_members
+				WHERE workspace_id = current_setting('app.workspace_id')::INTEGER AND user_id = $1), '')`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		return tx.GetContext(ctx, &role, query, userID)
+	})
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to get Role database")
+		return "", err
+	}
+
+	logRepo.WithField("role", role).Debug("Role database successfully")
+	return role, nil
+}
+
+func (r *Repository) Create(ctx context.Context, webhook *Webhook) error {
+	logRepo := repositoryLogger(r.logger).WithField("url", webhook.URL)
+	logRepo.Debug("Attempting to Create webhook")
+
+	query := `INSERT INTO webhooks (workspace_id, user_id, url, description, events, secret)
+				VALUES (current_setting('app.workspace_id')::INTEGER, $1, $2, $3, $4, $5)
+				RETURNING id, workspace_id, active, created_at`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		return tx.QueryRowxContext(ctx, query, webhook.UserID, webhook.URL, webhook.Description, webhook.Events, webhook.Secret).
+			Scan(&webhook.ID, &webhook.WorkspaceID, &webhook.Active, &webhook.CreatedAt)
+	})
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to Create webhook database")
+		return err
+	}
+
+	logRepo.WithField("webhook_id", webhook.ID).Debug("Create webhook database successfully")
+	return nil
+}
+
+func (r *Repository) GetAll(ctx context.Context) ([]Webhook, error) {
+	logRepo := repositoryLogger(r.logger)
+	logRepo.Debug("Attempting to GetAll webhooks")
+
+	webhooks := make([]Webhook, 0)
+	query := `SELECT ` + webhookColumns + ` FROM webhooks ORDER BY id`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		return tx.SelectContext(ctx, &webhooks, query)
+	})
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to GetAll webhooks database")
+		return nil, err
+	}
+
+	logRepo.Debug("GetAll webhooks database successfully")
+	return webhooks, nil
+}
+
+func (r *Repository) Get(ctx context.Context, id int) (*Webhook, error) {
+	logRepo := repositoryLogger(r.logger).WithField("webhook_id", id)
+	logRepo.Debug("Attempting to Get webhook")
+
+	var webhook Webhook
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		return tx.GetContext(ctx, &webhook, query, id)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logRepo.Warn(ErrWebhookNotFound.Error())
+			return nil, ErrWebhookNotFound
+		}
+		logRepo.WithError(err).Error("Failed to Get webhook database")
+		return nil, err
+	}
+
+	logRepo.Debug("Get webhook database successfully")
+	return &webhook, nil
+}
+
+func (r *Repository) Update(ctx context.Context, webhook *Webhook) error {
+	logRepo := repositoryLogger(r.logger).WithField("webhook_id", webhook.ID)
+	logRepo.Debug("Attempting to Update webhook")
+
+	query := `UPDATE webhooks SET url = $1, description = $2, events = $3, active = $4 WHERE id = $5`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, query, webhook.URL, webhook.Description, webhook.Events, webhook.Active, webhook.ID)
+		if err != nil {
+			return err
+		}
+		return requireAffected(result, ErrWebhookNotFound)
+	})
+	if err != nil {
+		logRepo.WithError(err).Warn("Failed to Update webhook database")
+		return err
+	}
+
+	logRepo.Debug("Update webhook database successfully")
+	return nil
+}
+
+// Delete удаляет вебхук вместе с журналом доставок
+func (r *Repository) Delete(ctx context.Context, id int) error {
+	logRepo := repositoryLogger(r.logger).WithField("webhook_id", id)
+	logRepo.Debug("Attempting to Delete webhook")
+
+	query := `DELETE FROM webhooks WHERE id = $1`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, query, id)
+		if err != nil {
+			return err
+		}
+		return requireAffected(result, ErrWebhookNotFound)
+	})
+	if err != nil {
+		logRepo.WithError(err).Warn("Failed to Delete webhook database")
+		return err
+	}
+
+	logRepo.Debug("Delete webhook database successfully")
+	return nil
+}
+
+// GetDeliveries возвращает журнал доставок вебхука от новых к старым
+func (r *Repository) GetDeliveries(ctx context.Context, webhookID int, query *DeliveryQuery) ([]Delivery, error) {
+	logRepo := repositoryLogger(r.logger).WithField("webhook_id", webhookID)
+	logRepo.Debug("Attempting to GetDeliveries")
+
+	deliveries := make([]Delivery, 0)
+	sqlQuery := `SELECT ` + deliveryColumns + `
+				FROM webhook_deliveries d
+				JOIN webhooks w ON w.id = d.webhook_id
+				JOIN webhook_events e ON e.id = d.event_id
+				WHERE d.webhook_id = $1 AND ($2 = 0 OR d.id < $2)
+				ORDER BY d.id DESC
+				LIMIT $3`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		return tx.SelectContext(ctx, &deliveries, sqlQuery, webhookID, query.BeforeID, query.Limit)
+	})
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to GetDeliveries database")
+		return nil, err
+	}
+
+	logRepo.Debug("GetDeliveries database successfully")
+	return deliveries, nil
+}
+
+// Redeliver ставит событие доставки в очередь ещё раз; исходная запись журнала не меняется
+func (r *Repository) Redeliver(ctx context.Context, webhookID int, deliveryID int64) (*Delivery, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"webhook_id":  webhookID,
+		"delivery_id": deliveryID,
+	})
+	logRepo.Debug("Attempting to Redeliver")
+
+	var delivery Delivery
+	query := `WITH created AS (
+					INSERT INTO webhook_deliveries (webhook_id, event_id)
+					SELECT o.webhook_id, o.event_id
+					FROM webhook_deliveries o
+					JOIN webhooks w ON w.id = o.webhook_id
+					WHERE o.id = $1 AND o.webhook_id = $2
+					RETURNING *
+				)
+				SELECT ` + deliveryColumns + ` FROM created d JOIN webhook_events e ON e.id = d.event_id`
+
+	err := r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		return tx.GetContext(ctx, &delivery, query, deliveryID, webhookID)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logRepo.Warn(ErrDeliveryNotFound.Error())
+			return nil, ErrDeliveryNotFound
+		}
+		logRepo.WithError(err).Error("Failed to Redeliver database")
+		return nil, err
+	}
+
+	logRepo.WithField("new_delivery_id", delivery.ID).Debug("Redeliver database successfully")
+	return &delivery, nil
+}
+
+// Dispatch разносит до limit событий из outbox по подписанным на них активным
+// вебхукам пространства и отмечает события разнесёнными. Возвращает число событий.
+func (r *Repository) Dispatch(ctx context.Context, limit int) (int, error) {
+	logRepo := repositoryLogger(r.logger)
+	logRepo.Debug("Attempting to Dispatch events")
+
+	query := `WITH events AS (
+					SELECT id, workspace_id, type FROM webhook_events
+					WHERE dispatched_at IS NULL
+					ORDER BY id
+					LIMIT $1
+					FOR UPDATE SKIP LOCKED
+				), deliveries AS (
+					INSERT INTO webhook_deliveries (webhook_id, event_id)
+					SELECT w.id, e.id FROM events e
+					JOIN webhooks w ON w.workspace_id = e.workspace_id AND w.active AND e.type = ANY(w.events)
+				)
+				UPDATE webhook_events SET dispatched_at = NOW() WHERE id IN (SELECT id FROM events)`
+
+	result, err := r.db.ExecContext(ctx, query, limit)
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to Dispatch events database")
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to get rows affected")
+		return 0, err
+	}
+
+	logRepo.WithField("events", n).Debug("Dispatch events database successfully")
+	return int(n), nil
+}
+
+// Claim захватывает до limit доставок, которым пора выполниться, сдвигая их
+// следующую попытку на lease. Если процесс упадёт, не записав результат,
+// доставка повторится после окончания lease.
+func (r *Repository) Claim(ctx context.Context, limit int, lease time.Duration) ([]Job, error) {
+	logRepo := repositoryLogger(r.logger)
+	logRepo.Debug("Attempting to Claim deliveries")
+
+	jobs := make([]Job, 0)
+	query := `UPDATE webhook_deliveries d SET next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+				FROM webhooks w, webhook_events e
+				WHERE d.id IN (
+					SELECT p.id FROM webhook_deliveries p
+					JOIN webhooks pw ON pw.id = p.webhook_id
+					WHERE p.status = 'pending' AND p.next_attempt_at <= NOW() AND pw.active
+					ORDER BY p.next_attempt_at
+					LIMIT $1
+					FOR UPDATE OF p SKIP LOCKED
+				) AND w.id = d.webhook_id AND e.id = d.event_id
+				RETURNING d.id, d.attempts, w.url, w.secret, e.id AS event_id, e.type AS event,
+					e.workspace_id, e.task_id, e.payload, e.created_at`
+
+	if err := r.db.SelectContext(ctx, &jobs, query, limit, int64(lease/time.Second)); err != nil {
+		logRepo.WithError(err).Error("Failed to Claim deliveries database")
+		return nil, err
+	}
+
+	logRepo.WithField("deliveries", len(jobs)).Debug("Claim deliveries database successfully")
+	return jobs, nil
+}
+
+// Record сохраняет результат попытки доставки
+func (r *Repository) Record(ctx context.Context, attempt *Attempt) error {
+	logRepo := repositoryLogger(r.logger).WithField("delivery_id", attempt.DeliveryID)
+	logRepo.Debug("Attempting to Record attempt")
+
+	query := `UPDATE webhook_deliveries SET status = $1, attempts = attempts + 1, next_attempt_at = $2,
+				last_attempt_at = NOW(), response_status = $3, response_body = $4, error = $5, duration_ms = $6
+				WHERE id = $7`
+
+	_, err := r.db.ExecContext(ctx, query, attempt.Status, attempt.NextAttemptAt, attempt.ResponseStatus,
+		attempt.ResponseBody, attempt.Error, attempt.Duration.Milliseconds(), attempt.DeliveryID)
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to Record attempt database")
+		return err
+	}
+
+	logRepo.Debug("Record attempt database successfully")
+	return nil
+}
+
+func requireAffected(result sql.Result, notFound error) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
+
+func repositoryLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Repository webhook layer")
+}
@@ -0,0 +1,134 @@
+package webhook_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/melnik-dev/go_todo_jwt/internal/webhook"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+)
+
+func mockDB() (*webhook.Repository, sqlmock.Sqlmock, error) {
+	mockDb, mock, err := sqlmock.New()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pgDB := sqlx.NewDb(mockDb, "sqlMock")
+
+	repo := webhook.NewRepository(&db.Db{
+		DB: pgDB,
+	}, mockLogger())
+
+	return repo, mock, err
+}
+
+var ctx = db.WithWorkspace(context.Background(), 1)
+
+func expectTenant(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT set_config('role', $1, true), set_config('app.workspace_id', $2, true)`)).
+		WithArgs(db.TenantRole, "1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestWebhookRepository_Create_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userID := 42
+	events := pq.StringArray{"task.created"}
+	expectTenant(mock)
+	mock.ExpectQuery(`INSERT INTO webhooks \(workspace_id, user_id, url, description, events, secret\)`).
+		WithArgs(&userID, "https://example.com", "", events, "whsec_1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "workspace_id", "active", "created_at"}).AddRow(3, 1, true, time.Now()))
+	mock.ExpectCommit()
+
+	w := &webhook.Webhook{UserID: &userID, URL: "https://example.com", Events: events, Secret: "whsec_1"}
+	if err = repo.Create(ctx, w); err != nil {
+		t.Fatal(err)
+	}
+	if w.ID != 3 || w.WorkspaceID != 1 || !w.Active {
+		t.Errorf("unexpected webhook %+v", w)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWebhookRepository_Redeliver_FailNotFound(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectTenant(mock)
+	mock.ExpectQuery(`INSERT INTO webhook_deliveries \(webhook_id, event_id\)`).
+		WithArgs(int64(10), 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	if _, err = repo.Redeliver(ctx, 3, 10); err != webhook.ErrDeliveryNotFound {
+		t.Fatalf("expected %v, got %v", webhook.ErrDeliveryNotFound, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWebhookRepository_Dispatch_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectExec(`FOR UPDATE SKIP LOCKED(.|\n)*INSERT INTO webhook_deliveries(.|\n)*UPDATE webhook_events SET dispatched_at = NOW\(\)`).
+		WithArgs(50).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+
+	n, err := repo.Dispatch(context.Background(), 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 {
+		t.Errorf("expected 4 events, got %d", n)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWebhookRepository_Claim_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	columns := []string{"id", "attempts", "url", "secret", "event_id", "event", "workspace_id", "task_id", "payload", "created_at"}
+	mock.ExpectQuery(`UPDATE webhook_deliveries d SET next_attempt_at = NOW\(\) \+ \$2 \* INTERVAL '1 second'`).
+		WithArgs(50, int64(70)).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, 0, "https://example.com", "whsec_1", 7, "task.created", 1, 5, []byte(`{"id":5}`), time.Now()))
+
+	jobs, err := repo.Claim(context.Background(), 50, 70*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 || jobs[0].DeliveryID != 1 || string(jobs[0].Payload) != `{"id":5}` {
+		t.Errorf("unexpected jobs %+v", jobs)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,286 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"slices"
+
+	"github.com/melnik-dev/go_todo_jwt/internal/workspace"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultLimit - размер страницы журнала доставок по умолчанию
+const defaultLimit = 50
+
+type IService interface {
+	GetAll(ctx context.Context, userID int) ([]Webhook, error)
+	Create(ctx context.Context, userID int, req *CreateRequest) (*Webhook, error)
+	Get(ctx context.Context, userID, id int) (*Webhook, error)
+	Update(ctx context.Context, userID, id int, req *UpdateRequest) (*Webhook, error)
+	Delete(ctx context.Context, userID, id int) error
+	GetDeliveries(ctx context.Context, userID, id int, query *DeliveryQuery) ([]Delivery, error)
+	Redeliver(ctx context.Context, userID, id int, deliveryID int64) (*Delivery, error)
+}
+
+type Service struct {
+	webhookRepo IRepository
+	logger      *logrus.Logger
+}
+
+func NewService(webhookRepo IRepository, logger *logrus.Logger) *Service {
+	return &Service{
+		webhookRepo: webhookRepo,
+		logger:      logger,
+	}
+}
+
+func (s *Service) GetAll(ctx context.Context, userID int) ([]Webhook, error) {
+	logServ := serviceLogger(s.logger).WithField("user_id", userID)
+	logServ.Debug("Attempting to GetAll webhooks")
+
+	if err := s.require(ctx, userID); err != nil {
+		logServ.WithError(err).Warn("Failed to GetAll webhooks")
+		return nil, err
+	}
+
+	webhooks, err := s.webhookRepo.GetAll(ctx)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to GetAll webhooks")
+		return nil, err
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	logServ.Debug("GetAll webhooks successfully")
+	return webhooks, nil
+}
+
+// Create регистрирует вебхук в текущем рабочем пространстве и возвращает его
+// вместе с секретом подписи; позже секрет не показывается
+func (s *Service) Create(ctx context.Context, userID int, req *CreateRequest) (*Webhook, error) {
+	logServ := serviceLogger(s.logger).WithField("user_id", userID)
+	logServ.Debug("Attempting to Create webhook")
+
+	if err := validate(req.URL, req.Events); err != nil {
+		logServ.WithError(err).Warn("Invalid webhook")
+		return nil, err
+	}
+	if err := s.require(ctx, userID); err != nil {
+		logServ.WithError(err).Warn("Failed to Create webhook")
+		return nil, err
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		logServ.WithError(err).Error("Failed to generate secret")
+		return nil, err
+	}
+	webhook := &Webhook{
+		UserID:      &userID,
+		URL:         req.URL,
+		Description: req.Description,
+		Events:      compact(req.Events),
+		Secret:      secret,
+	}
+	if err = s.webhookRepo.Create(ctx, webhook); err != nil {
+		logServ.WithError(err).Error("Failed to Create webhook")
+		return nil, err
+	}
+
+	logServ.WithField("webhook_id", webhook.ID).Debug("Create webhook successfully")
+	return webhook, nil
+}
+
+func (s *Service) Get(ctx context.Context, userID, id int) (*Webhook, error) {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id":    userID,
+		"webhook_id": id,
+	})
+	logServ.Debug("Attempting to Get webhook")
+
+	if err := s.require(ctx, userID); err != nil {
+		logServ.WithError(err).Warn("Failed to Get webhook")
+		return nil, err
+	}
+
+	webhook, err := s.webhookRepo.Get(ctx, id)
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to Get webhook")
+		return nil, err
+	}
+	webhook.Secret = ""
+
+	logServ.Debug("Get webhook successfully")
+	return webhook, nil
+}
+
+// Update меняет переданные поля; выключенный вебхук не получает новых событий
+func (s *Service) Update(ctx context.Context, userID, id int, req *UpdateRequest) (*Webhook, error) {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id":    userID,
+		"webhook_id": id,
+	})
+	logServ.Debug("Attempting to Update webhook")
+
+	if err := s.require(ctx, userID); err != nil {
+		logServ.WithError(err).Warn("Failed to Update webhook")
+		return nil, err
+	}
+
+	webhook, err := s.webhookRepo.Get(ctx, id)
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to Get webhook")
+		return nil, err
+	}
+	if req.URL != nil {
+		webhook.URL = *req.URL
+	}
+	if req.Description != nil {
+		webhook.Description = *req.Description
+	}
+	if len(req.Events) > 0 {
+		webhook.Events = compact(req.Events)
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+	if err = validate(webhook.URL, webhook.Events); err != nil {
+		logServ.WithError(err).Warn("Invalid webhook")
+		return nil, err
+	}
+
+	if err = s.webhookRepo.Update(ctx, webhook); err != nil {
+		logServ.WithError(err).Warn("Failed to Update webhook")
+		return nil, err
+	}
+	webhook.Secret = ""
+
+	logServ.Debug("Update webhook successfully")
+	return webhook, nil
+}
+
+func (s *Service) Delete(ctx context.Context, userID, id int) error {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id":    userID,
+		"webhook_id": id,
+	})
+	logServ.Debug("Attempting to Delete webhook")
+
+	if err := s.require(ctx, userID); err != nil {
+		logServ.WithError(err).Warn("Failed to Delete webhook")
+		return err
+	}
+
+	if err := s.webhookRepo.Delete(ctx, id); err != nil {
+		logServ.WithError(err).Warn("Failed to Delete webhook")
+		return err
+	}
+
+	logServ.Debug("Delete webhook successfully")
+	return nil
+}
+
+// GetDeliveries возвращает страницу журнала доставок вебхука
+func (s *Service) GetDeliveries(ctx context.Context, userID, id int, query *DeliveryQuery) ([]Delivery, error) {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id":    userID,
+		"webhook_id": id,
+	})
+	logServ.Debug("Attempting to GetDeliveries")
+
+	if err := s.require(ctx, userID); err != nil {
+		logServ.WithError(err).Warn("Failed to GetDeliveries")
+		return nil, err
+	}
+	if _, err := s.webhookRepo.Get(ctx, id); err != nil {
+		logServ.WithError(err).Warn("Failed to Get webhook")
+		return nil, err
+	}
+
+	if query.Limit == 0 {
+		query.Limit = defaultLimit
+	}
+	deliveries, err := s.webhookRepo.GetDeliveries(ctx, id, query)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to GetDeliveries")
+		return nil, err
+	}
+
+	logServ.Debug("GetDeliveries successfully")
+	return deliveries, nil
+}
+
+// Redeliver ставит событие из журнала в очередь доставки ещё раз
+func (s *Service) Redeliver(ctx context.Context, userID, id int, deliveryID int64) (*Delivery, error) {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id":     userID,
+		"webhook_id":  id,
+		"delivery_id": deliveryID,
+	})
+	logServ.Debug("Attempting to Redeliver")
+
+	if err := s.require(ctx, userID); err != nil {
+		logServ.WithError(err).Warn("Failed to Redeliver")
+		return nil, err
+	}
+
+	delivery, err := s.webhookRepo.Redeliver(ctx, id, deliveryID)
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to Redeliver")
+		return nil, err
+	}
+
+	logServ.WithField("new_delivery_id", delivery.ID).Debug("Redeliver successfully")
+	return delivery, nil
+}
+
+// require пускает только владельцев и администраторов рабочего пространства
+func (s *Service) require(ctx context.Context, userID int) error {
+	role, err := s.webhookRepo.Role(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if role != workspace.RoleOwner && role != workspace.RoleAdmin {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func validate(rawURL string, events []string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+	for _, event := range events {
+		if !slices.Contains(Events, event) {
+			return ErrUnknownEvent
+		}
+	}
+	return nil
+}
+
+// compact убирает повторы событий, сохраняя порядок
+func compact(events []string) []string {
+	result := make([]string, 0, len(events))
+	for _, event := range events {
+		if !slices.Contains(result, event) {
+			result = append(result, event)
+		}
+	}
+	return result
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+func serviceLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Service webhook layer")
+}
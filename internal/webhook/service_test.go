@@ -0,0 +1,220 @@
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/melnik-dev/go_todo_jwt/internal/webhook"
+	"github.com/sirupsen/logrus"
+)
+
+type MockWebhookRepository struct {
+	RoleMock          func(userID int) (string, error)
+	CreateMock        func(w *webhook.Webhook) error
+	GetAllMock        func() ([]webhook.Webhook, error)
+	GetMock           func(id int) (*webhook.Webhook, error)
+	UpdateMock        func(w *webhook.Webhook) error
+	DeleteMock        func(id int) error
+	GetDeliveriesMock func(webhookID int, query *webhook.DeliveryQuery) ([]webhook.Delivery, error)
+	RedeliverMock     func(webhookID int, deliveryID int64) (*webhook.Delivery, error)
+	DispatchMock      func(limit int) (int, error)
+	ClaimMock         func(limit int, lease time.Duration) ([]webhook.Job, error)
+	RecordMock        func(attempt *webhook.Attempt) error
+}
+
+func (m *MockWebhookRepository) Role(ctx context.Context, userID int) (string, error) {
+	return m.RoleMock(userID)
+}
+
+func (m *MockWebhookRepository) Create(ctx context.Context, w *webhook.Webhook) error {
+	return m.CreateMock(w)
+}
+
+func (m *MockWebhookRepository) GetAll(ctx context.Context) ([]webhook.Webhook, error) {
+	return m.GetAllMock()
+}
+
+func (m *MockWebhookRepository) Get(ctx context.Context, id int) (*webhook.Webhook, error) {
+	return m.GetMock(id)
+}
+
+func (m *MockWebhookRepository) Update(ctx context.Context, w *webhook.Webhook) error {
+	return m.UpdateMock(w)
+}
+
+func (m *MockWebhookRepository) Delete(ctx context.Context, id int) error {
+	return m.DeleteMock(id)
+}
+
+func (m *MockWebhookRepository) GetDeliveries(ctx context.Context, webhookID int, query *webhook.DeliveryQuery) ([]webhook.Delivery, error) {
+	return m.GetDeliveriesMock(webhookID, query)
+}
+
+func (m *MockWebhookRepository) Redeliver(ctx context.Context, webhookID int, deliveryID int64) (*webhook.Delivery, error) {
+	return m.RedeliverMock(webhookID, deliveryID)
+}
+
+func (m *MockWebhookRepository) Dispatch(ctx context.Context, limit int) (int, error) {
+	return m.DispatchMock(limit)
+}
+
+func (m *MockWebhookRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]webhook.Job, error) {
+	return m.ClaimMock(limit, lease)
+}
+
+func (m *MockWebhookRepository) Record(ctx context.Context, attempt *webhook.Attempt) error {
+	return m.RecordMock(attempt)
+}
+
+func mockLogger() *logrus.Logger {
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	return l
+}
+
+func role(r string) func(userID int) (string, error) {
+	return func(userID int) (string, error) {
+		return r, nil
+	}
+}
+
+func TestService_Create(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    string
+		req     webhook.CreateRequest
+		wantErr error
+	}{
+		{name: "owner", role: "owner", req: webhook.CreateRequest{URL: "https://ci.example.com/hook", Events: []string{"task.created", "task.created", "task.deleted"}}},
+		{name: "admin", role: "admin", req: webhook.CreateRequest{URL: "http://localhost:9000", Events: []string{"task.completed"}}},
+		{name: "member", role: "member", req: webhook.CreateRequest{URL: "https://ci.example.com/hook", Events: []string{"task.created"}}, wantErr: webhook.ErrForbidden},
+		{name: "relative url", role: "owner", req: webhook.CreateRequest{URL: "/hook", Events: []string{"task.created"}}, wantErr: webhook.ErrInvalidURL},
+		{name: "unknown event", role: "owner", req: webhook.CreateRequest{URL: "https://ci.example.com", Events: []string{"task.archived"}}, wantErr: webhook.ErrUnknownEvent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created *webhook.Webhook
+			repo := &MockWebhookRepository{
+				RoleMock: role(tt.role),
+				CreateMock: func(w *webhook.Webhook) error {
+					w.ID = 1
+					created = w
+					return nil
+				},
+			}
+			service := webhook.NewService(repo, mockLogger())
+
+			w, err := service.Create(context.Background(), 42, &tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				if created != nil {
+					t.Error("Create must not be called")
+				}
+				return
+			}
+			if !strings.HasPrefix(w.Secret, "whsec_") || len(w.Secret) != 70 {
+				t.Errorf("unexpected secret %q", w.Secret)
+			}
+			if *w.UserID != 42 || len(w.Events) != len(distinct(tt.req.Events)) {
+				t.Errorf("unexpected webhook %+v", w)
+			}
+		})
+	}
+}
+
+func distinct(events []string) map[string]bool {
+	m := make(map[string]bool)
+	for _, e := range events {
+		m[e] = true
+	}
+	return m
+}
+
+func TestService_GetAll_HidesSecret(t *testing.T) {
+	repo := &MockWebhookRepository{
+		RoleMock: role("admin"),
+		GetAllMock: func() ([]webhook.Webhook, error) {
+			return []webhook.Webhook{{ID: 1, Secret: "whsec_1"}, {ID: 2, Secret: "whsec_2"}}, nil
+		},
+	}
+	service := webhook.NewService(repo, mockLogger())
+
+	webhooks, err := service.GetAll(context.Background(), 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range webhooks {
+		if w.Secret != "" {
+			t.Errorf("secret of webhook %d must be hidden", w.ID)
+		}
+	}
+}
+
+func TestService_Update(t *testing.T) {
+	active := false
+	var saved *webhook.Webhook
+	repo := &MockWebhookRepository{
+		RoleMock: role("owner"),
+		GetMock: func(id int) (*webhook.Webhook, error) {
+			return &webhook.Webhook{ID: id, URL: "https://old.example.com", Events: []string{"task.created"}, Active: true, Secret: "whsec_1"}, nil
+		},
+		UpdateMock: func(w *webhook.Webhook) error {
+			saved = w
+			return nil
+		},
+	}
+	service := webhook.NewService(repo, mockLogger())
+
+	w, err := service.Update(context.Background(), 42, 1, &webhook.UpdateRequest{Active: &active, Events: []string{"task.updated"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Active || saved.URL != "https://old.example.com" || saved.Events[0] != "task.updated" {
+		t.Errorf("unexpected saved webhook %+v", saved)
+	}
+	if w.Secret != "" {
+		t.Error("secret must be hidden")
+	}
+
+	_, err = service.Update(context.Background(), 42, 1, &webhook.UpdateRequest{Events: []string{"task.moved"}})
+	if !errors.Is(err, webhook.ErrUnknownEvent) {
+		t.Fatalf("expected %v, got %v", webhook.ErrUnknownEvent, err)
+	}
+}
+
+func TestService_GetDeliveries_DefaultLimit(t *testing.T) {
+	repo := &MockWebhookRepository{
+		RoleMock: role("owner"),
+		GetMock: func(id int) (*webhook.Webhook, error) {
+			return nil, webhook.ErrWebhookNotFound
+		},
+	}
+	service := webhook.NewService(repo, mockLogger())
+
+	_, err := service.GetDeliveries(context.Background(), 42, 1, &webhook.DeliveryQuery{})
+	if !errors.Is(err, webhook.ErrWebhookNotFound) {
+		t.Fatalf("expected %v, got %v", webhook.ErrWebhookNotFound, err)
+	}
+
+	var limit int
+	repo.GetMock = func(id int) (*webhook.Webhook, error) {
+		return &webhook.Webhook{ID: id}, nil
+	}
+	repo.GetDeliveriesMock = func(webhookID int, query *webhook.DeliveryQuery) ([]webhook.Delivery, error) {
+		limit = query.Limit
+		return []webhook.Delivery{}, nil
+	}
+	if _, err = service.GetDeliveries(context.Background(), 42, 1, &webhook.DeliveryQuery{}); err != nil {
+		t.Fatal(err)
+	}
+	if limit != 50 {
+		t.Errorf("expected default limit 50, got %d", limit)
+	}
+}
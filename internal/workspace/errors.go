@@ -0,0 +1,12 @@
+package workspace
+
+import "errors"
+
+var (
+	ErrWorkspaceNotFound  = errors.New("workspace not found")
+	ErrWorkspaceForbidden = errors.New("not enough permissions for workspace")
+	ErrPersonalWorkspace  = errors.New("personal workspace cannot be shared or deleted")
+	ErrMemberNotFound     = errors.New("workspace member not found")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrLastOwner          = errors.New("workspace must keep at least one owner")
+)
@@ -0,0 +1,272 @@
+package workspace
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/pkg/jwt"
+	"github.com/melnik-dev/go_todo_jwt/pkg/logger"
+	"github.com/melnik-dev/go_todo_jwt/pkg/middleware"
+	"github.com/melnik-dev/go_todo_jwt/pkg/response"
+	"github.com/sirupsen/logrus"
+)
+
+type HandlerDeps struct {
+	WorkspaceService IService
+	*configs.Config
+}
+
+type Handler struct {
+	WorkspaceService IService
+	*configs.Config
+}
+
+func NewHandler(r *gin.Engine, deps *HandlerDeps) {
+	handler := &Handler{
+		WorkspaceService: deps.WorkspaceService,
+		Config:           deps.Config,
+	}
+	workspace := r.Group("/workspace")
+	workspace.Use(middleware.IsAuthed(handler.Config.JWT.Secret))
+	workspace.POST("/create", handler.Create)
+	workspace.GET("/", handler.GetAll)
+	workspace.DELETE("/:id", handler.Delete)
+	workspace.POST("/:id/token", handler.IssueToken)
+	workspace.GET("/:id/members", handler.GetMembers)
+	workspace.POST("/:id/members", handler.AddMember)
+	workspace.DELETE("/:id/members/:user_id", handler.RemoveMember)
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Create workspace")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind request")
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	workspace, err := h.WorkspaceService.Create(c.Request.Context(), userID, req.Name)
+	if err != nil {
+		logHandle.WithError(err).Error("Failed to Create workspace")
+		response.InternalServerError(c, "Failed to create workspace")
+		return
+	}
+
+	logHandle.Debug("Create workspace successfully")
+	response.Success(c, http.StatusCreated, workspace)
+}
+
+func (h *Handler) GetAll(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to GetAll workspaces")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	workspaces, err := h.WorkspaceService.GetAll(c.Request.Context(), userID)
+	if err != nil {
+		logHandle.WithError(err).Error("Failed to GetAll workspaces")
+		response.InternalServerError(c, "Failed to get workspaces")
+		return
+	}
+
+	logHandle.Debug("GetAll workspaces successfully")
+	response.Success(c, http.StatusOK, workspaces)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to Delete workspace")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri URIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind workspace id")
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.WorkspaceService.Delete(c.Request.Context(), userID, uri.ID); err != nil {
+		if !writeError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to Delete workspace")
+			response.InternalServerError(c, "Failed to delete workspace")
+		}
+		return
+	}
+
+	logHandle.Debug("Delete workspace successfully")
+	response.Success(c, http.StatusOK, gin.H{"message": "Workspace deleted successfully"})
+}
+
+// IssueToken выпускает JWT, привязанный к пространству: с ним запросы работают
+// в этом пространстве без заголовка X-Workspace-ID
+func (h *Handler) IssueToken(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to IssueToken")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri URIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind workspace id")
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	workspaceID, err := h.WorkspaceService.Resolve(c.Request.Context(), userID, uri.ID)
+	if err != nil {
+		if !writeError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to Resolve workspace")
+			response.InternalServerError(c, "Failed to issue token")
+		}
+		return
+	}
+
+	token, err := jwt.NewJWT(h.Config.JWT.Secret).Create(jwt.Data{
+		UserId:      userID,
+		WorkspaceID: workspaceID,
+		TokenTTL:    h.Config.JWT.TokenTTL,
+	})
+	if err != nil {
+		logHandle.WithError(err).Error("Failed to create JWT")
+		response.InternalServerError(c, "Failed to issue token")
+		return
+	}
+
+	logHandle.Debug("IssueToken successfully")
+	response.Success(c, http.StatusOK, TokenResponse{Token: token})
+}
+
+func (h *Handler) GetMembers(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to GetMembers")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri URIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind workspace id")
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	members, err := h.WorkspaceService.GetMembers(c.Request.Context(), userID, uri.ID)
+	if err != nil {
+		if !writeError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to GetMembers")
+			response.InternalServerError(c, "Failed to get members")
+		}
+		return
+	}
+
+	logHandle.Debug("GetMembers successfully")
+	response.Success(c, http.StatusOK, members)
+}
+
+func (h *Handler) AddMember(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to AddMember")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri URIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind workspace id")
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	var req AddMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind request")
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	member, err := h.WorkspaceService.AddMember(c.Request.Context(), userID, uri.ID, &req)
+	if err != nil {
+		if !writeError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to AddMember")
+			response.InternalServerError(c, "Failed to add member")
+		}
+		return
+	}
+
+	logHandle.Debug("AddMember successfully")
+	response.Success(c, http.StatusOK, member)
+}
+
+func (h *Handler) RemoveMember(c *gin.Context) {
+	logHandle := handlerLogger(c)
+	logHandle.Debug("Received request to RemoveMember")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var uri MemberURIParam
+	if err := c.ShouldBindUri(&uri); err != nil {
+		logHandle.WithError(err).Warn("Failed to bind member")
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.WorkspaceService.RemoveMember(c.Request.Context(), userID, uri.ID, uri.UserID); err != nil {
+		if !writeError(c, logHandle, err) {
+			logHandle.WithError(err).Error("Failed to RemoveMember")
+			response.InternalServerError(c, "Failed to remove member")
+		}
+		return
+	}
+
+	logHandle.Debug("RemoveMember successfully")
+	response.Success(c, http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+// writeError отвечает на известные ошибки сервиса и сообщает, была ли ошибка обработана
+func writeError(c *gin.Context, logHandle *logrus.Entry, err error) bool {
+	switch {
+	case errors.Is(err, ErrWorkspaceNotFound), errors.Is(err, ErrMemberNotFound), errors.Is(err, ErrUserNotFound):
+		logHandle.Warn(err.Error())
+		response.NotFound(c, err.Error())
+	case errors.Is(err, ErrWorkspaceForbidden):
+		logHandle.Warn(err.Error())
+		response.Forbidden(c, err.Error())
+	case errors.Is(err, ErrPersonalWorkspace), errors.Is(err, ErrLastOwner):
+		logHandle.Warn(err.Error())
+		response.Error(c, http.StatusConflict, err.Error())
+	default:
+		return false
+	}
+	return true
+}
+
+func handlerLogger(c *gin.Context) *logrus.Entry {
+	return logger.FromContext(c).WithField("layer", "Handler workspace layer")
+}
@@ -0,0 +1,134 @@
+package workspace_test
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/internal/workspace"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/melnik-dev/go_todo_jwt/pkg/middleware"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// MockWorkspaceService встраивает workspace.IService, чтобы переопределять только нужные методы
+type MockWorkspaceService struct {
+	workspace.IService
+	ResolveMock      func(userID, workspaceID int) (int, error)
+	RemoveMemberMock func(userID, workspaceID, memberID int) error
+}
+
+func (m *MockWorkspaceService) Resolve(_ context.Context, userID, workspaceID int) (int, error) {
+	return m.ResolveMock(userID, workspaceID)
+}
+
+func (m *MockWorkspaceService) RemoveMember(_ context.Context, userID, workspaceID, memberID int) error {
+	return m.RemoveMemberMock(userID, workspaceID, memberID)
+}
+
+func mockGin(claim int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		c.Set("logger", logrus.NewEntry(logger))
+		c.Set("user_id", 42)
+		if claim > 0 {
+			c.Set(middleware.TokenWorkspaceKey, claim)
+		}
+		c.Next()
+	})
+	return r
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name          string
+		header        string
+		claim         int
+		wantCode      int
+		wantWorkspace int
+	}{
+		{name: "personal by default", wantCode: http.StatusOK, wantWorkspace: 3},
+		{name: "from claim", claim: 5, wantCode: http.StatusOK, wantWorkspace: 5},
+		{name: "header overrides claim", header: "5", claim: 9, wantCode: http.StatusOK, wantWorkspace: 5},
+		{name: "not a member", header: "9", wantCode: http.StatusForbidden},
+		{name: "invalid header", header: "abc", wantCode: http.StatusBadRequest},
+	}
+
+	service := &MockWorkspaceService{
+		ResolveMock: func(userID, workspaceID int) (int, error) {
+			switch workspaceID {
+			case 0:
+				return 3, nil
+			case 5:
+				return 5, nil
+			}
+			return 0, workspace.ErrWorkspaceNotFound
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got int
+			r := mockGin(tt.claim)
+			r.Use(workspace.Middleware(service))
+			r.GET("/task/", func(c *gin.Context) {
+				got, _ = db.WorkspaceFromContext(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/task/", nil)
+			if tt.header != "" {
+				req.Header.Set(workspace.HeaderWorkspace, tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, w.Code)
+			}
+			if got != tt.wantWorkspace {
+				t.Errorf("expected workspace %d, got %d", tt.wantWorkspace, got)
+			}
+		})
+	}
+}
+
+func TestHandler_RemoveMember(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{name: "success", wantCode: http.StatusOK},
+		{name: "not found", err: workspace.ErrWorkspaceNotFound, wantCode: http.StatusNotFound},
+		{name: "forbidden", err: workspace.ErrWorkspaceForbidden, wantCode: http.StatusForbidden},
+		{name: "last owner", err: workspace.ErrLastOwner, wantCode: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &workspace.Handler{
+				WorkspaceService: &MockWorkspaceService{
+					RemoveMemberMock: func(userID, workspaceID, memberID int) error {
+						return tt.err
+					},
+				},
+			}
+
+			r := mockGin(0)
+			r.DELETE("/workspace/:id/members/:user_id", handler.RemoveMember)
+			req := httptest.NewRequest(http.MethodDelete, "/workspace/5/members/7", nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package workspace
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/melnik-dev/go_todo_jwt/pkg/logger"
+	"github.com/melnik-dev/go_todo_jwt/pkg/middleware"
+	"github.com/melnik-dev/go_todo_jwt/pkg/response"
+)
+
+// HeaderWorkspace - заголовок, которым клиент выбирает рабочее пространство запроса
+const HeaderWorkspace = "X-Workspace-ID"
+
+// Middleware выбирает рабочее пространство запроса: заголовок X-Workspace-ID,
+// затем workspace_id из JWT, иначе личное пространство пользователя.
+// Выбранное пространство кладётся в контекст запроса (db.WithWorkspace),
+// откуда его берут репозитории. Ставится после аутентификации.
+func Middleware(workspaceService IService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		wsLogger := logger.FromContext(c)
+
+		userID, ok := middleware.GetUserID(c)
+		if !ok {
+			c.Abort()
+			return
+		}
+
+		requested := c.GetInt(middleware.TokenWorkspaceKey)
+		if header := c.GetHeader(HeaderWorkspace); header != "" {
+			id, err := strconv.Atoi(header)
+			if err != nil || id < 1 {
+				wsLogger.WithField("header", header).Warn("Invalid workspace header")
+				response.AbortWithStatus(c, http.StatusBadRequest, "Invalid "+HeaderWorkspace+" header")
+				return
+			}
+			requested = id
+		}
+
+		workspaceID, err := workspaceService.Resolve(c.Request.Context(), userID, requested)
+		if err != nil {
+			if errors.Is(err, ErrWorkspaceNotFound) {
+				wsLogger.WithField("workspace_id", requested).Warn("User is not a member of workspace")
+				response.AbortWithStatus(c, http.StatusForbidden, ErrWorkspaceNotFound.Error())
+				return
+			}
+			wsLogger.WithError(err).Error("Failed to resolve workspace")
+			response.AbortWithStatus(c, http.StatusInternalServerError, "Failed to resolve workspace")
+			return
+		}
+
+		c.Set("workspace_id", workspaceID)
+		c.Request = c.Request.WithContext(db.WithWorkspace(c.Request.Context(), workspaceID))
+		c.Next()
+	}
+}
@@ -0,0 +1,36 @@
+package workspace
+
+import "time"
+
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+var roleRanks = map[string]int{
+	RoleMember: 1,
+	RoleAdmin:  2,
+	RoleOwner:  3,
+}
+
+type Workspace struct {
+	ID             int       `db:"id" json:"id"`
+	Name           string    `db:"name" json:"name"`
+	PersonalUserID *int      `db:"personal_user_id" json:"-"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	// Role - роль текущего пользователя в пространстве
+	Role string `db:"role" json:"role,omitempty"`
+}
+
+func (w *Workspace) Personal() bool {
+	return w.PersonalUserID != nil
+}
+
+type Member struct {
+	WorkspaceID int       `db:"workspace_id" json:"workspace_id"`
+	UserID      int       `db:"user_id" json:"user_id"`
+	Username    string    `db:"username" json:"username"`
+	Role        string    `db:"role" json:"role"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,23 @@
+package workspace
+
+type URIParam struct {
+	ID int `uri:"id" binding:"required,min=1"`
+}
+
+type MemberURIParam struct {
+	ID     int `uri:"id" binding:"required,min=1"`
+	UserID int `uri:"user_id" binding:"required,min=1"`
+}
+
+type CreateRequest struct {
+	Name string `json:"name" binding:"required,min=1,max=100"`
+}
+
+type AddMemberRequest struct {
+	Username string `json:"username" binding:"required"`
+	Role     string `json:"role" binding:"required,oneof=owner admin member"`
+}
+
+type TokenResponse struct {
+	Token string `json:"token"`
+}
@@ -0,0 +1,237 @@
+package workspace
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/sirupsen/logrus"
+)
+
+// Членство в пространствах не изолируется RLS: по нему как раз выбирается
+// пространство запроса, поэтому все методы явно фильтруют по пользователю.
+type IRepository interface {
+	Create(ctx context.Context, workspace *Workspace, ownerID int) (*Workspace, error)
+	Get(ctx context.Context, workspaceID, userID int) (*Workspace, error)
+	GetAll(ctx context.Context, userID int) ([]Workspace, error)
+	Personal(ctx context.Context, userID int) (int, error)
+	Delete(ctx context.Context, workspaceID int) error
+	GetMembers(ctx context.Context, workspaceID int) ([]Member, error)
+	SaveMember(ctx context.Context, member *Member) error
+	RemoveMember(ctx context.Context, workspaceID, userID int) error
+}
+
+type Repository struct {
+	db     *db.Db
+	logger *logrus.Logger
+}
+
+func NewRepository(db *db.Db, logger *logrus.Logger) *Repository {
+	return &Repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Create создаёт пространство и делает ownerID его владельцем в одной транзакции
+func (r *Repository) Create(ctx context.Context, workspace *Workspace, ownerID int) (*Workspace, error) {
+	logRepo := repositoryLogger(r.logger).WithField("user_id", ownerID)
+	logRepo.Debug("Attempting to Create workspace")
+
+	// во внешней транзакции TxManager создание присоединяется к ней
+	err := db.NewTxManager(r.db).WithinTx(ctx, func(ctx context.Context) error {
+		query := `INSERT INTO workspaces (name) VALUES ($1) RETURNING id, created_at`
+		if err := r.db.QueryRowContext(ctx, query, workspace.Name).Scan(&workspace.ID, &workspace.CreatedAt); err != nil {
+			logRepo.WithError(err).Error("Failed to Create database")
+			return err
+		}
+
+		query = `INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, $3)`
+		if _, err := r.db.ExecContext(ctx, query, workspace.ID, ownerID, RoleOwner); err != nil {
+			logRepo.WithError(err).Error("Failed to add owner database")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	workspace.Role = RoleOwner
+
+	logRepo.WithField("workspace_id", workspace.ID).Debug("Create database successfully")
+	return workspace, nil
+}
+
+// Get возвращает пространство с ролью пользователя; не участнику - ErrWorkspaceNotFound
+func (r *Repository) Get(ctx context.Context, workspaceID, userID int) (*Workspace, error) {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"user_id":      userID,
+		"workspace_id": workspaceID,
+	})
+	logRepo.Debug("Attempting to Get workspace")
+
+	var workspace Workspace
+	query := `SELECT w.*, m.role FROM workspaces w
+				JOIN workspace_members m ON m.workspace_id = w.id
+				WHERE w.id = $1 AND m.user_id = $2`
+
+	err := r.db.GetContext(ctx, &workspace, query, workspaceID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logRepo.Warn(ErrWorkspaceNotFound.Error())
+			return nil, ErrWorkspaceNotFound
+		}
+		logRepo.WithError(err).Error("Failed to Get database")
+		return nil, err
+	}
+
+	logRepo.Debug("Get database successfully")
+	return &workspace, nil
+}
+
+func (r *Repository) GetAll(ctx context.Context, userID int) ([]Workspace, error) {
+	logRepo := repositoryLogger(r.logger).WithField("user_id", userID)
+	logRepo.Debug("Attempting to GetAll workspaces")
+
+	workspaces := make([]Workspace, 0)
+	query := `SELECT w.*, m.role FROM workspaces w
+				JOIN workspace_members m ON m.workspace_id = w.id
+				WHERE m.user_id = $1
+				ORDER BY w.personal_user_id IS NULL, w.id`
+
+	if err := r.db.SelectContext(ctx, &workspaces, query, userID); err != nil {
+		logRepo.WithError(err).Error("Failed to GetAll database")
+		return nil, err
+	}
+
+	logRepo.Debug("GetAll database successfully")
+	return workspaces, nil
+}
+
+// Personal возвращает id личного пространства пользователя
+func (r *Repository) Personal(ctx context.Context, userID int) (int, error) {
+	logRepo := repositoryLogger(r.logger).WithField("user_id", userID)
+	logRepo.Debug("Attempting to get Personal workspace")
+
+	var workspaceID int
+	query := `SELECT id FROM workspaces WHERE personal_user_id = $1`
+
+	err := r.db.GetContext(ctx, &workspaceID, query, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logRepo.Warn(ErrWorkspaceNotFound.Error())
+			return 0, ErrWorkspaceNotFound
+		}
+		logRepo.WithError(err).Error("Failed to get Personal workspace database")
+		return 0, err
+	}
+
+	logRepo.WithField("workspace_id", workspaceID).Debug("Personal workspace database successfully")
+	return workspaceID, nil
+}
+
+// Delete удаляет общее пространство вместе с его задачами и проектами
+func (r *Repository) Delete(ctx context.Context, workspaceID int) error {
+	logRepo := repositoryLogger(r.logger).WithField("workspace_id", workspaceID)
+	logRepo.Debug("Attempting to Delete workspace")
+
+	query := `DELETE FROM workspaces WHERE id = $1 AND personal_user_id IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, workspaceID)
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to Delete database")
+		return err
+	}
+
+	row, err := result.RowsAffected()
+	if err != nil {
+		logRepo.WithError(err).Error("Failed rows affected by Delete database")
+		return err
+	}
+
+	if row == 0 {
+		logRepo.Warn(ErrWorkspaceNotFound.Error())
+		return ErrWorkspaceNotFound
+	}
+
+	logRepo.Debug("Delete database successfully")
+	return nil
+}
+
+func (r *Repository) GetMembers(ctx context.Context, workspaceID int) ([]Member, error) {
+	logRepo := repositoryLogger(r.logger).WithField("workspace_id", workspaceID)
+	logRepo.Debug("Attempting to GetMembers")
+
+	members := make([]Member, 0)
+	query := `SELECT m.workspace_id, m.user_id, u.username, m.role, m.created_at
+				FROM workspace_members m
+				JOIN users u ON u.id = m.user_id
+				WHERE m.workspace_id = $1
+				ORDER BY m.created_at, m.user_id`
+
+	if err := r.db.SelectContext(ctx, &members, query, workspaceID); err != nil {
+		logRepo.WithError(err).Error("Failed to GetMembers database")
+		return nil, err
+	}
+
+	logRepo.Debug("GetMembers database successfully")
+	return members, nil
+}
+
+// SaveMember добавляет участника или меняет роль существующего
+func (r *Repository) SaveMember(ctx context.Context, member *Member) error {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"workspace_id": member.WorkspaceID,
+		"user_id":      member.UserID,
+		"role":         member.Role,
+	})
+	logRepo.Debug("Attempting to SaveMember")
+
+	query := `INSERT INTO workspace_members (workspace_id, user_id, role)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (workspace_id, user_id) DO UPDATE SET role = EXCLUDED.role
+				RETURNING created_at`
+
+	row := r.db.QueryRowContext(ctx, query, member.WorkspaceID, member.UserID, member.Role)
+	if err := row.Scan(&member.CreatedAt); err != nil {
+		logRepo.WithError(err).Error("Failed to SaveMember database")
+		return err
+	}
+
+	logRepo.Debug("SaveMember database successfully")
+	return nil
+}
+
+func (r *Repository) RemoveMember(ctx context.Context, workspaceID, userID int) error {
+	logRepo := repositoryLogger(r.logger).WithFields(logrus.Fields{
+		"workspace_id": workspaceID,
+		"user_id":      userID,
+	})
+	logRepo.Debug("Attempting to RemoveMember")
+
+	query := `DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, workspaceID, userID)
+	if err != nil {
+		logRepo.WithError(err).Error("Failed to RemoveMember database")
+		return err
+	}
+
+	row, err := result.RowsAffected()
+	if err != nil {
+		logRepo.WithError(err).Error("Failed rows affected by RemoveMember database")
+		return err
+	}
+
+	if row == 0 {
+		logRepo.Warn(ErrMemberNotFound.Error())
+		return ErrMemberNotFound
+	}
+
+	logRepo.Debug("RemoveMember database successfully")
+	return nil
+}
+
+func repositoryLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Repository workspace layer")
+}
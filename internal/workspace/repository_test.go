@@ -0,0 +1,135 @@
+package workspace_test
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/melnik-dev/go_todo_jwt/internal/workspace"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func mockDB() (*workspace.Repository, sqlmock.Sqlmock, error) {
+	mockDb, mock, err := sqlmock.New()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pgDB := sqlx.NewDb(mockDb, "sqlMock")
+
+	repo := workspace.NewRepository(&db.Db{
+		DB: pgDB,
+	}, mockLogger())
+
+	return repo, mock, err
+}
+
+func TestWorkspaceRepository_Create_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO workspaces (name) VALUES ($1) RETURNING id, created_at`)).
+		WithArgs("Team").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(5, time.Now()))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, $3)`)).
+		WithArgs(5, 42, workspace.RoleOwner).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ws, err := repo.Create(context.Background(), &workspace.Workspace{Name: "Team"}, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.ID != 5 || ws.Role != workspace.RoleOwner {
+		t.Errorf("unexpected workspace %+v", ws)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorkspaceRepository_Get_FailNotMember(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery(`JOIN workspace_members m ON m.workspace_id = w.id`).
+		WithArgs(5, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "personal_user_id", "created_at", "role"}))
+
+	if _, err = repo.Get(context.Background(), 5, 7); err != workspace.ErrWorkspaceNotFound {
+		t.Fatalf("expected %v, got %v", workspace.ErrWorkspaceNotFound, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorkspaceRepository_Personal_Success(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM workspaces WHERE personal_user_id = $1`)).
+		WithArgs(42).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+
+	workspaceID, err := repo.Personal(context.Background(), 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if workspaceID != 3 {
+		t.Errorf("expected 3, got %d", workspaceID)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorkspaceRepository_Delete_FailPersonal(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM workspaces WHERE id = $1 AND personal_user_id IS NULL`)).
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err = repo.Delete(context.Background(), 3); err != workspace.ErrWorkspaceNotFound {
+		t.Fatalf("expected %v, got %v", workspace.ErrWorkspaceNotFound, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorkspaceRepository_RemoveMember_FailNotFound(t *testing.T) {
+	repo, mock, err := mockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`)).
+		WithArgs(5, 7).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err = repo.RemoveMember(context.Background(), 5, 7); err != workspace.ErrMemberNotFound {
+		t.Fatalf("expected %v, got %v", workspace.ErrMemberNotFound, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
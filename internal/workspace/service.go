@@ -0,0 +1,287 @@
+package workspace
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/melnik-dev/go_todo_jwt/internal/user"
+	"github.com/sirupsen/logrus"
+)
+
+type IService interface {
+	Create(ctx context.Context, userID int, name string) (*Workspace, error)
+	GetAll(ctx context.Context, userID int) ([]Workspace, error)
+	Delete(ctx context.Context, userID, workspaceID int) error
+	GetMembers(ctx context.Context, userID, workspaceID int) ([]Member, error)
+	AddMember(ctx context.Context, userID, workspaceID int, req *AddMemberRequest) (*Member, error)
+	RemoveMember(ctx context.Context, userID, workspaceID, memberID int) error
+	Resolve(ctx context.Context, userID, workspaceID int) (int, error)
+}
+
+type Service struct {
+	workspaceRepo IRepository
+	userRepo      user.IRepository
+	logger        *logrus.Logger
+}
+
+func NewService(workspaceRepo IRepository, userRepo user.IRepository, logger *logrus.Logger) *Service {
+	return &Service{
+		workspaceRepo: workspaceRepo,
+		userRepo:      userRepo,
+		logger:        logger,
+	}
+}
+
+func (s *Service) Create(ctx context.Context, userID int, name string) (*Workspace, error) {
+	logServ := serviceLogger(s.logger).WithField("user_id", userID)
+	logServ.Debug("Attempting to Create workspace")
+
+	workspace, err := s.workspaceRepo.Create(ctx, &Workspace{Name: name}, userID)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to Create workspace")
+		return nil, err
+	}
+
+	logServ.WithField("workspace_id", workspace.ID).Debug("Create workspace successfully")
+	return workspace, nil
+}
+
+func (s *Service) GetAll(ctx context.Context, userID int) ([]Workspace, error) {
+	logServ := serviceLogger(s.logger).WithField("user_id", userID)
+	logServ.Debug("Attempting to GetAll workspaces")
+
+	workspaces, err := s.workspaceRepo.GetAll(ctx, userID)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to GetAll workspaces")
+		return nil, err
+	}
+
+	logServ.Debug("GetAll workspaces successfully")
+	return workspaces, nil
+}
+
+func (s *Service) Delete(ctx context.Context, userID, workspaceID int) error {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id":      userID,
+		"workspace_id": workspaceID,
+	})
+	logServ.Debug("Attempting to Delete workspace")
+
+	workspace, err := s.require(ctx, userID, workspaceID, RoleOwner)
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to Delete workspace")
+		return err
+	}
+	if workspace.Personal() {
+		logServ.Warn(ErrPersonalWorkspace.Error())
+		return ErrPersonalWorkspace
+	}
+
+	if err = s.workspaceRepo.Delete(ctx, workspaceID); err != nil {
+		logServ.WithError(err).Error("Failed to Delete workspace")
+		return err
+	}
+
+	logServ.Debug("Delete workspace successfully")
+	return nil
+}
+
+func (s *Service) GetMembers(ctx context.Context, userID, workspaceID int) ([]Member, error) {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id":      userID,
+		"workspace_id": workspaceID,
+	})
+	logServ.Debug("Attempting to GetMembers")
+
+	if _, err := s.require(ctx, userID, workspaceID, RoleMember); err != nil {
+		logServ.WithError(err).Warn("Failed to GetMembers")
+		return nil, err
+	}
+
+	members, err := s.workspaceRepo.GetMembers(ctx, workspaceID)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to GetMembers")
+		return nil, err
+	}
+
+	logServ.Debug("GetMembers successfully")
+	return members, nil
+}
+
+// AddMember добавляет пользователя в пространство или меняет его роль.
+// Участниками управляют админы, назначать и снимать владельцев - только владельцы.
+func (s *Service) AddMember(ctx context.Context, userID, workspaceID int, req *AddMemberRequest) (*Member, error) {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id":      userID,
+		"workspace_id": workspaceID,
+		"role":         req.Role,
+	})
+	logServ.Debug("Attempting to AddMember")
+
+	workspace, err := s.require(ctx, userID, workspaceID, RoleAdmin)
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to AddMember")
+		return nil, err
+	}
+	if workspace.Personal() {
+		logServ.Warn(ErrPersonalWorkspace.Error())
+		return nil, ErrPersonalWorkspace
+	}
+
+	target, err := s.userRepo.Get(ctx, req.Username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logServ.Warn(ErrUserNotFound.Error())
+			return nil, ErrUserNotFound
+		}
+		logServ.WithError(err).Error("Failed to Get user")
+		return nil, err
+	}
+
+	members, err := s.workspaceRepo.GetMembers(ctx, workspaceID)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to GetMembers")
+		return nil, err
+	}
+	current := findMember(members, target.ID)
+	if err = checkRoleChange(workspace.Role, current, req.Role, members); err != nil {
+		logServ.WithError(err).Warn("Failed to AddMember")
+		return nil, err
+	}
+
+	member := &Member{WorkspaceID: workspaceID, UserID: target.ID, Username: target.Name, Role: req.Role}
+	if err = s.workspaceRepo.SaveMember(ctx, member); err != nil {
+		logServ.WithError(err).Error("Failed to SaveMember")
+		return nil, err
+	}
+
+	logServ.WithField("member_id", member.UserID).Debug("AddMember successfully")
+	return member, nil
+}
+
+// RemoveMember исключает участника. Покинуть пространство может любой участник,
+// кроме последнего владельца.
+func (s *Service) RemoveMember(ctx context.Context, userID, workspaceID, memberID int) error {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id":      userID,
+		"workspace_id": workspaceID,
+		"member_id":    memberID,
+	})
+	logServ.Debug("Attempting to RemoveMember")
+
+	minRole := RoleAdmin
+	if memberID == userID {
+		minRole = RoleMember
+	}
+	workspace, err := s.require(ctx, userID, workspaceID, minRole)
+	if err != nil {
+		logServ.WithError(err).Warn("Failed to RemoveMember")
+		return err
+	}
+	if workspace.Personal() {
+		logServ.Warn(ErrPersonalWorkspace.Error())
+		return ErrPersonalWorkspace
+	}
+
+	members, err := s.workspaceRepo.GetMembers(ctx, workspaceID)
+	if err != nil {
+		logServ.WithError(err).Error("Failed to GetMembers")
+		return err
+	}
+	current := findMember(members, memberID)
+	if current == nil {
+		logServ.Warn(ErrMemberNotFound.Error())
+		return ErrMemberNotFound
+	}
+	if memberID != userID {
+		if err = checkRoleChange(workspace.Role, current, "", members); err != nil {
+			logServ.WithError(err).Warn("Failed to RemoveMember")
+			return err
+		}
+	} else if current.Role == RoleOwner && countOwners(members) == 1 {
+		logServ.Warn(ErrLastOwner.Error())
+		return ErrLastOwner
+	}
+
+	if err = s.workspaceRepo.RemoveMember(ctx, workspaceID, memberID); err != nil {
+		logServ.WithError(err).Warn("Failed to RemoveMember")
+		return err
+	}
+
+	logServ.Debug("RemoveMember successfully")
+	return nil
+}
+
+// Resolve проверяет, что пользователь состоит в пространстве workspaceID,
+// и возвращает его id. При workspaceID = 0 возвращается личное пространство.
+func (s *Service) Resolve(ctx context.Context, userID, workspaceID int) (int, error) {
+	logServ := serviceLogger(s.logger).WithFields(logrus.Fields{
+		"user_id":      userID,
+		"workspace_id": workspaceID,
+	})
+	logServ.Debug("Attempting to Resolve workspace")
+
+	if workspaceID == 0 {
+		personalID, err := s.workspaceRepo.Personal(ctx, userID)
+		if err != nil {
+			logServ.WithError(err).Error("Failed to get Personal workspace")
+			return 0, err
+		}
+		return personalID, nil
+	}
+
+	if _, err := s.workspaceRepo.Get(ctx, workspaceID, userID); err != nil {
+		logServ.WithError(err).Warn("Failed to Resolve workspace")
+		return 0, err
+	}
+
+	logServ.Debug("Resolve workspace successfully")
+	return workspaceID, nil
+}
+
+// require возвращает пространство, если роль пользователя в нём не ниже minRole
+func (s *Service) require(ctx context.Context, userID, workspaceID int, minRole string) (*Workspace, error) {
+	workspace, err := s.workspaceRepo.Get(ctx, workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if roleRanks[workspace.Role] < roleRanks[minRole] {
+		return nil, ErrWorkspaceForbidden
+	}
+	return workspace, nil
+}
+
+// checkRoleChange проверяет смену роли участника current на newRole (пустая роль - исключение)
+func checkRoleChange(actorRole string, current *Member, newRole string, members []Member) error {
+	touchesOwner := newRole == RoleOwner || current != nil && current.Role == RoleOwner
+	if touchesOwner && actorRole != RoleOwner {
+		return ErrWorkspaceForbidden
+	}
+	if current != nil && current.Role == RoleOwner && newRole != RoleOwner && countOwners(members) == 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+func findMember(members []Member, userID int) *Member {
+	for i := range members {
+		if members[i].UserID == userID {
+			return &members[i]
+		}
+	}
+	return nil
+}
+
+func countOwners(members []Member) int {
+	owners := 0
+	for _, member := range members {
+		if member.Role == RoleOwner {
+			owners++
+		}
+	}
+	return owners
+}
+
+func serviceLogger(l *logrus.Logger) *logrus.Entry {
+	return l.WithField("layer", "Service workspace layer")
+}
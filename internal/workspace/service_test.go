@@ -0,0 +1,218 @@
+package workspace_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/melnik-dev/go_todo_jwt/internal/user"
+	"github.com/melnik-dev/go_todo_jwt/internal/workspace"
+	"github.com/sirupsen/logrus"
+	"io"
+	"testing"
+)
+
+type MockWorkspaceRepository struct {
+	CreateMock       func(ws *workspace.Workspace, ownerID int) (*workspace.Workspace, error)
+	GetMock          func(workspaceID, userID int) (*workspace.Workspace, error)
+	GetAllMock       func(userID int) ([]workspace.Workspace, error)
+	PersonalMock     func(userID int) (int, error)
+	DeleteMock       func(workspaceID int) error
+	GetMembersMock   func(workspaceID int) ([]workspace.Member, error)
+	SaveMemberMock   func(member *workspace.Member) error
+	RemoveMemberMock func(workspaceID, userID int) error
+}
+
+func (m *MockWorkspaceRepository) Create(_ context.Context, ws *workspace.Workspace, ownerID int) (*workspace.Workspace, error) {
+	return m.CreateMock(ws, ownerID)
+}
+
+func (m *MockWorkspaceRepository) Get(_ context.Context, workspaceID, userID int) (*workspace.Workspace, error) {
+	return m.GetMock(workspaceID, userID)
+}
+
+func (m *MockWorkspaceRepository) GetAll(_ context.Context, userID int) ([]workspace.Workspace, error) {
+	return m.GetAllMock(userID)
+}
+
+func (m *MockWorkspaceRepository) Personal(_ context.Context, userID int) (int, error) {
+	return m.PersonalMock(userID)
+}
+
+func (m *MockWorkspaceRepository) Delete(_ context.Context, workspaceID int) error {
+	return m.DeleteMock(workspaceID)
+}
+
+func (m *MockWorkspaceRepository) GetMembers(_ context.Context, workspaceID int) ([]workspace.Member, error) {
+	return m.GetMembersMock(workspaceID)
+}
+
+func (m *MockWorkspaceRepository) SaveMember(_ context.Context, member *workspace.Member) error {
+	return m.SaveMemberMock(member)
+}
+
+func (m *MockWorkspaceRepository) RemoveMember(_ context.Context, workspaceID, userID int) error {
+	return m.RemoveMemberMock(workspaceID, userID)
+}
+
+// MockUserRepository встраивает user.IRepository, чтобы переопределять только нужные методы
+type MockUserRepository struct {
+	user.IRepository
+	GetMock func(username string) (*user.User, error)
+}
+
+func (m *MockUserRepository) Get(_ context.Context, username string) (*user.User, error) {
+	return m.GetMock(username)
+}
+
+func mockLogger() *logrus.Logger {
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	return l
+}
+
+func mockUsers() *MockUserRepository {
+	return &MockUserRepository{
+		GetMock: func(username string) (*user.User, error) {
+			switch username {
+			case "alice":
+				return &user.User{ID: 42, Name: "alice"}, nil
+			case "bob":
+				return &user.User{ID: 7, Name: "bob"}, nil
+			}
+			return nil, sql.ErrNoRows
+		},
+	}
+}
+
+// mockTeam - общее пространство 5: alice (42) владелец, bob (7) с ролью bobRole
+func mockTeam(actorRole, bobRole string) *MockWorkspaceRepository {
+	return &MockWorkspaceRepository{
+		GetMock: func(workspaceID, userID int) (*workspace.Workspace, error) {
+			return &workspace.Workspace{ID: workspaceID, Name: "Team", Role: actorRole}, nil
+		},
+		GetMembersMock: func(workspaceID int) ([]workspace.Member, error) {
+			members := []workspace.Member{{WorkspaceID: workspaceID, UserID: 42, Username: "alice", Role: workspace.RoleOwner}}
+			if bobRole != "" {
+				members = append(members, workspace.Member{WorkspaceID: workspaceID, UserID: 7, Username: "bob", Role: bobRole})
+			}
+			return members, nil
+		},
+		SaveMemberMock:   func(member *workspace.Member) error { return nil },
+		RemoveMemberMock: func(workspaceID, userID int) error { return nil },
+	}
+}
+
+func TestService_AddMember(t *testing.T) {
+	tests := []struct {
+		name      string
+		actorRole string
+		bobRole   string
+		username  string
+		role      string
+		wantErr   error
+	}{
+		{name: "owner adds member", actorRole: workspace.RoleOwner, username: "bob", role: workspace.RoleMember},
+		{name: "admin adds admin", actorRole: workspace.RoleAdmin, username: "bob", role: workspace.RoleAdmin},
+		{name: "member cannot add", actorRole: workspace.RoleMember, username: "bob", role: workspace.RoleMember, wantErr: workspace.ErrWorkspaceForbidden},
+		{name: "admin cannot grant owner", actorRole: workspace.RoleAdmin, username: "bob", role: workspace.RoleOwner, wantErr: workspace.ErrWorkspaceForbidden},
+		{name: "admin cannot demote owner", actorRole: workspace.RoleAdmin, username: "alice", role: workspace.RoleMember, wantErr: workspace.ErrWorkspaceForbidden},
+		{name: "last owner", actorRole: workspace.RoleOwner, username: "alice", role: workspace.RoleAdmin, wantErr: workspace.ErrLastOwner},
+		{name: "second owner can demote", actorRole: workspace.RoleOwner, bobRole: workspace.RoleOwner, username: "alice", role: workspace.RoleAdmin},
+		{name: "unknown user", actorRole: workspace.RoleOwner, username: "carol", role: workspace.RoleMember, wantErr: workspace.ErrUserNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := workspace.NewService(mockTeam(tt.actorRole, tt.bobRole), mockUsers(), mockLogger())
+
+			member, err := service.AddMember(context.Background(), 42, 5, &workspace.AddMemberRequest{Username: tt.username, Role: tt.role})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && member.Role != tt.role {
+				t.Errorf("expected role %s, got %s", tt.role, member.Role)
+			}
+		})
+	}
+}
+
+func TestService_AddMember_FailPersonal(t *testing.T) {
+	personalUserID := 42
+	repo := &MockWorkspaceRepository{
+		GetMock: func(workspaceID, userID int) (*workspace.Workspace, error) {
+			return &workspace.Workspace{ID: workspaceID, PersonalUserID: &personalUserID, Role: workspace.RoleOwner}, nil
+		},
+	}
+	service := workspace.NewService(repo, mockUsers(), mockLogger())
+
+	_, err := service.AddMember(context.Background(), 42, 3, &workspace.AddMemberRequest{Username: "bob", Role: workspace.RoleMember})
+	if !errors.Is(err, workspace.ErrPersonalWorkspace) {
+		t.Fatalf("expected %v, got %v", workspace.ErrPersonalWorkspace, err)
+	}
+}
+
+func TestService_RemoveMember(t *testing.T) {
+	tests := []struct {
+		name      string
+		actorID   int
+		actorRole string
+		bobRole   string
+		memberID  int
+		wantErr   error
+	}{
+		{name: "owner removes member", actorID: 42, actorRole: workspace.RoleOwner, bobRole: workspace.RoleMember, memberID: 7},
+		{name: "member leaves", actorID: 7, actorRole: workspace.RoleMember, bobRole: workspace.RoleMember, memberID: 7},
+		{name: "member cannot remove others", actorID: 7, actorRole: workspace.RoleMember, bobRole: workspace.RoleMember, memberID: 42, wantErr: workspace.ErrWorkspaceForbidden},
+		{name: "last owner cannot leave", actorID: 42, actorRole: workspace.RoleOwner, bobRole: workspace.RoleMember, memberID: 42, wantErr: workspace.ErrLastOwner},
+		{name: "not a member", actorID: 42, actorRole: workspace.RoleOwner, memberID: 7, wantErr: workspace.ErrMemberNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := workspace.NewService(mockTeam(tt.actorRole, tt.bobRole), mockUsers(), mockLogger())
+
+			err := service.RemoveMember(context.Background(), tt.actorID, 5, tt.memberID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestService_Resolve(t *testing.T) {
+	repo := &MockWorkspaceRepository{
+		PersonalMock: func(userID int) (int, error) {
+			return 3, nil
+		},
+		GetMock: func(workspaceID, userID int) (*workspace.Workspace, error) {
+			if workspaceID != 5 {
+				return nil, workspace.ErrWorkspaceNotFound
+			}
+			return &workspace.Workspace{ID: workspaceID, Role: workspace.RoleMember}, nil
+		},
+	}
+	service := workspace.NewService(repo, mockUsers(), mockLogger())
+
+	tests := []struct {
+		name      string
+		requested int
+		want      int
+		wantErr   error
+	}{
+		{name: "personal by default", requested: 0, want: 3},
+		{name: "member", requested: 5, want: 5},
+		{name: "not a member", requested: 9, wantErr: workspace.ErrWorkspaceNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := service.Resolve(context.Background(), 42, tt.requested)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+DROP POLICY task_tombstones_workspace_isolation ON task_tombstones;
+
+DROP POLICY projects_workspace_isolation ON projects;
+
+DROP POLICY tasks_workspace_isolation ON tasks;
+
+DROP TRIGGER projects_set_workspace_id ON projects;
+
+DROP TRIGGER tasks_set_workspace_id ON tasks;
+
+DROP FUNCTION set_workspace_id;
+
+DROP TRIGGER users_personal_workspace ON users;
+
+DROP FUNCTION create_personal_workspace;
+
+DROP VIEW task_access;
+
+DROP VIEW project_access;
+
+DROP TRIGGER projects_shares_cleanup ON projects;
+
+DROP TRIGGER tasks_shares_cleanup ON tasks;
+
+DROP FUNCTION shares_cleanup;
+
+DROP FUNCTION share_rank;
+
+DROP TABLE shares;
+
+DROP TRIGGER tasks_field_changes ON tasks;
+
+DROP FUNCTION tasks_field_changes;
+
+DROP TABLE task_field_changes;
+
+DROP TRIGGER webhook_events_notify ON webhook_events;
+
+DROP FUNCTION webhook_events_notify;
+
+DROP TRIGGER tasks_webhook_event_delete ON tasks;
+
+DROP TABLE webhook_deliveries;
+
+DROP TRIGGER tasks_webhook_event_update ON tasks;
+
+DROP TRIGGER tasks_webhook_event ON tasks;
+
+DROP FUNCTION tasks_webhook_event;
+
+DROP TABLE webhook_events;
+
+DROP POLICY webhooks_workspace_isolation ON webhooks;
+
+DROP TABLE webhooks;
+
+DROP TABLE reminder_settings;
+
+DROP TABLE task_reminders;
+
+DROP TABLE notification_preferences;
+
+DROP TABLE notifications;
+
+DROP TRIGGER attachments_deletion ON attachments;
+
+DROP FUNCTION attachments_enqueue_deletion;
+
+DROP TABLE attachment_deletions;
+
+DROP TABLE attachments;
+
+DROP TABLE comment_mentions;
+
+DROP TABLE comment_revisions;
+
+DROP TABLE comments;
+
+DROP TABLE task_assignments;
+
+DROP TABLE app_passwords;
+
+DROP TRIGGER tasks_tombstone ON tasks;
+
+DROP TRIGGER tasks_touch ON tasks;
+
+DROP FUNCTION tasks_tombstone;
+
+DROP FUNCTION tasks_touch;
+
+DROP TABLE task_tombstones;
+
+DROP TABLE feed_tokens;
+
+DROP TABLE idempotency_keys;
+
+DROP TABLE tasks;
+
+DROP TABLE projects;
+
+DROP SEQUENCE task_change_seq;
+
+DROP TABLE workspace_members;
+
+DROP TABLE workspaces;
+
+DROP TABLE users;
+
+DROP OWNED BY todo_tenant;
+
+DROP ROLE todo_tenant;
\ No newline at end of file
@@ -0,0 +1,593 @@
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    username VARCHAR(50) UNIQUE NOT NULL,
+    password VARCHAR(255) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS tasks (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id),
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    completed BOOLEAN DEFAULT FALSE
+);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key VARCHAR(255) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    content_type VARCHAR(255) NOT NULL DEFAULT '',
+    response_body BYTEA,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS uid VARCHAR(255) NOT NULL DEFAULT gen_random_uuid()::text;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS due_at TIMESTAMPTZ;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS completed_at TIMESTAMPTZ;
+CREATE UNIQUE INDEX IF NOT EXISTS tasks_user_id_uid_idx ON tasks (user_id, uid);
+
+CREATE TABLE IF NOT EXISTS feed_tokens (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Счётчик изменений задач: по нему строятся ETag и sync-token для синхронизации
+CREATE SEQUENCE IF NOT EXISTS task_change_seq;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS change_seq BIGINT NOT NULL DEFAULT nextval('task_change_seq');
+
+CREATE TABLE IF NOT EXISTS task_tombstones (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    uid VARCHAR(255) NOT NULL,
+    change_seq BIGINT NOT NULL DEFAULT nextval('task_change_seq'),
+    deleted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, uid)
+);
+
+CREATE OR REPLACE FUNCTION tasks_touch() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at = NOW();
+    NEW.change_seq = nextval('task_change_seq');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER tasks_touch BEFORE UPDATE ON tasks
+    FOR EACH ROW EXECUTE FUNCTION tasks_touch();
+
+CREATE OR REPLACE FUNCTION tasks_tombstone() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO task_tombstones (user_id, uid) VALUES (OLD.user_id, OLD.uid)
+    ON CONFLICT (user_id, uid) DO UPDATE
+    SET change_seq = nextval('task_change_seq'), deleted_at = NOW();
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER tasks_tombstone AFTER DELETE ON tasks
+    FOR EACH ROW EXECUTE FUNCTION tasks_tombstone();
+
+CREATE TABLE IF NOT EXISTS app_passwords (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    password_hash VARCHAR(64) UNIQUE NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ
+);
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS extras JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+CREATE TABLE IF NOT EXISTS projects (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS project_id INTEGER REFERENCES projects(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS tasks_project_id_idx ON tasks (project_id);
+
+CREATE TABLE IF NOT EXISTS shares (
+    id SERIAL PRIMARY KEY,
+    resource_type VARCHAR(16) NOT NULL CHECK (resource_type IN ('task', 'project')),
+    resource_id INTEGER NOT NULL,
+    owner_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(16) NOT NULL CHECK (role IN ('viewer', 'editor', 'owner')),
+    status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    responded_at TIMESTAMPTZ,
+    UNIQUE (resource_type, resource_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS shares_user_id_idx ON shares (user_id, status);
+
+CREATE OR REPLACE FUNCTION share_rank(role VARCHAR) RETURNS INTEGER AS $$
+    SELECT CASE role WHEN 'viewer' THEN 1 WHEN 'editor' THEN 2 WHEN 'owner' THEN 3 ELSE 0 END
+$$ LANGUAGE sql IMMUTABLE;
+
+-- у shares нет внешнего ключа на ресурс, поэтому приглашения удаляются триггером
+CREATE OR REPLACE FUNCTION shares_cleanup() RETURNS trigger AS $$
+BEGIN
+    DELETE FROM shares WHERE resource_type = TG_ARGV[0] AND resource_id = OLD.id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER tasks_shares_cleanup AFTER DELETE ON tasks
+    FOR EACH ROW EXECUTE FUNCTION shares_cleanup('task');
+
+CREATE OR REPLACE TRIGGER projects_shares_cleanup AFTER DELETE ON projects
+    FOR EACH ROW EXECUTE FUNCTION shares_cleanup('project');
+
+-- project_access и task_access - все роли пользователей в проектах и задачах:
+-- владение, принятые приглашения и (для задач) доступ через проект.
+-- rank: 1 - viewer, 2 - editor, 3 - owner; shared_by_id пуст для собственных ресурсов.
+CREATE OR REPLACE VIEW project_access AS
+    SELECT p.id AS project_id, p.user_id, 3 AS rank, NULL::INTEGER AS shared_by_id
+    FROM projects p
+    UNION ALL
+    SELECT s.resource_id, s.user_id, share_rank(s.role), s.owner_id
+    FROM shares s
+    WHERE s.resource_type = 'project' AND s.status = 'accepted';
+
+CREATE OR REPLACE VIEW task_access AS
+    SELECT t.id AS task_id, t.user_id, 3 AS rank, NULL::INTEGER AS shared_by_id
+    FROM tasks t
+    UNION ALL
+    SELECT s.resource_id, s.user_id, share_rank(s.role), s.owner_id
+    FROM shares s
+    WHERE s.resource_type = 'task' AND s.status = 'accepted'
+    UNION ALL
+    SELECT t.id, pa.user_id, pa.rank, pa.shared_by_id
+    FROM tasks t
+    JOIN project_access pa ON pa.project_id = t.project_id;
+
+CREATE TABLE IF NOT EXISTS workspaces (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    -- личное пространство пользователя, создаётся при регистрации
+    personal_user_id INTEGER UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id INTEGER NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(16) NOT NULL CHECK (role IN ('owner', 'admin', 'member')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (workspace_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS workspace_members_user_id_idx ON workspace_members (user_id);
+
+CREATE OR REPLACE FUNCTION create_personal_workspace() RETURNS trigger AS $$
+BEGIN
+    WITH w AS (
+        INSERT INTO workspaces (name, personal_user_id) VALUES (NEW.username, NEW.id) RETURNING id
+    )
+    INSERT INTO workspace_members (workspace_id, user_id, role) SELECT id, NEW.id, 'owner' FROM w;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER users_personal_workspace AFTER INSERT ON users
+    FOR EACH ROW EXECUTE FUNCTION create_personal_workspace();
+
+WITH w AS (
+    INSERT INTO workspaces (name, personal_user_id)
+    SELECT u.username, u.id FROM users u
+    WHERE NOT EXISTS (SELECT 1 FROM workspaces WHERE personal_user_id = u.id)
+    RETURNING id, personal_user_id
+)
+INSERT INTO workspace_members (workspace_id, user_id, role) SELECT id, personal_user_id, 'owner' FROM w;
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS workspace_id INTEGER REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS workspace_id INTEGER REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE task_tombstones ADD COLUMN IF NOT EXISTS workspace_id INTEGER REFERENCES workspaces(id) ON DELETE CASCADE;
+
+UPDATE tasks t SET workspace_id = w.id FROM workspaces w WHERE t.workspace_id IS NULL AND w.personal_user_id = t.user_id;
+UPDATE projects p SET workspace_id = w.id FROM workspaces w WHERE p.workspace_id IS NULL AND w.personal_user_id = p.user_id;
+UPDATE task_tombstones t SET workspace_id = w.id FROM workspaces w WHERE t.workspace_id IS NULL AND w.personal_user_id = t.user_id;
+
+-- рабочее пространство новой строки - выбранное в запросе (app.workspace_id),
+-- а вне запроса - личное пространство владельца
+CREATE OR REPLACE FUNCTION set_workspace_id() RETURNS trigger AS $$
+BEGIN
+    NEW.workspace_id := COALESCE(
+        NEW.workspace_id,
+        NULLIF(current_setting('app.workspace_id', true), '')::INTEGER,
+        (SELECT id FROM workspaces WHERE personal_user_id = NEW.user_id));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER tasks_set_workspace_id BEFORE INSERT ON tasks
+    FOR EACH ROW EXECUTE FUNCTION set_workspace_id();
+
+CREATE OR REPLACE TRIGGER projects_set_workspace_id BEFORE INSERT ON projects
+    FOR EACH ROW EXECUTE FUNCTION set_workspace_id();
+
+ALTER TABLE tasks ALTER COLUMN workspace_id SET NOT NULL;
+ALTER TABLE projects ALTER COLUMN workspace_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS tasks_workspace_id_idx ON tasks (workspace_id);
+CREATE INDEX IF NOT EXISTS projects_workspace_id_idx ON projects (workspace_id);
+
+CREATE OR REPLACE FUNCTION tasks_tombstone() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO task_tombstones (user_id, uid, workspace_id) VALUES (OLD.user_id, OLD.uid, OLD.workspace_id)
+    ON CONFLICT (user_id, uid) DO UPDATE
+    SET change_seq = nextval('task_change_seq'), deleted_at = NOW(), workspace_id = EXCLUDED.workspace_id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Изоляция рабочих пространств. Запросы из API выполняются под ролью todo_tenant
+-- (SET LOCAL ROLE) с app.workspace_id и видят только строки своего пространства.
+-- Владелец таблиц политиками не ограничен: от его имени работают системные задачи.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'todo_tenant') THEN
+        CREATE ROLE todo_tenant NOLOGIN;
+    END IF;
+    EXECUTE format('GRANT todo_tenant TO %I', current_user);
+END;
+$$;
+
+GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO todo_tenant;
+GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public TO todo_tenant;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO todo_tenant;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT USAGE, SELECT ON SEQUENCES TO todo_tenant;
+
+-- представления доступа должны проверять политики от имени вызывающего
+ALTER VIEW project_access SET (security_invoker = true);
+ALTER VIEW task_access SET (security_invoker = true);
+
+ALTER TABLE tasks ENABLE ROW LEVEL SECURITY;
+ALTER TABLE projects ENABLE ROW LEVEL SECURITY;
+ALTER TABLE task_tombstones ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tasks_workspace_isolation ON tasks;
+CREATE POLICY tasks_workspace_isolation ON tasks TO todo_tenant
+    USING (workspace_id = current_setting('app.workspace_id')::INTEGER);
+
+DROP POLICY IF EXISTS projects_workspace_isolation ON projects;
+CREATE POLICY projects_workspace_isolation ON projects TO todo_tenant
+    USING (workspace_id = current_setting('app.workspace_id')::INTEGER);
+
+DROP POLICY IF EXISTS task_tombstones_workspace_isolation ON task_tombstones;
+CREATE POLICY task_tombstones_workspace_isolation ON task_tombstones TO todo_tenant
+    USING (workspace_id = current_setting('app.workspace_id')::INTEGER);
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS assignee_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS tasks_assignee_id_idx ON tasks (assignee_id);
+
+-- история назначений; assignee_id пуст, если исполнителя сняли
+CREATE TABLE IF NOT EXISTS task_assignments (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    assignee_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    assigned_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS task_assignments_task_id_idx ON task_assignments (task_id, id);
+
+-- исполнитель работает с задачей как редактор
+CREATE OR REPLACE VIEW task_access WITH (security_invoker = true) AS
+    SELECT t.id AS task_id, t.user_id, 3 AS rank, NULL::INTEGER AS shared_by_id
+    FROM tasks t
+    UNION ALL
+    SELECT s.resource_id, s.user_id, share_rank(s.role), s.owner_id
+    FROM shares s
+    WHERE s.resource_type = 'task' AND s.status = 'accepted'
+    UNION ALL
+    SELECT t.id, pa.user_id, pa.rank, pa.shared_by_id
+    FROM tasks t
+    JOIN project_access pa ON pa.project_id = t.project_id
+    UNION ALL
+    SELECT t.id, t.assignee_id, 2, t.user_id
+    FROM tasks t
+    WHERE t.assignee_id IS NOT NULL;
+
+-- комментарии к задачам; удалённый комментарий остаётся в ветке без текста
+CREATE TABLE IF NOT EXISTS comments (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    parent_id INTEGER REFERENCES comments(id) ON DELETE CASCADE,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    body TEXT NOT NULL,
+    body_html TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    edited_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS comments_task_id_idx ON comments (task_id, id);
+
+-- прежние версии текста; created_at - когда версия была написана
+CREATE TABLE IF NOT EXISTS comment_revisions (
+    id SERIAL PRIMARY KEY,
+    comment_id INTEGER NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS comment_revisions_comment_id_idx ON comment_revisions (comment_id, id);
+
+CREATE TABLE IF NOT EXISTS comment_mentions (
+    comment_id INTEGER NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (comment_id, user_id)
+);
+
+-- вложения задач; содержимое лежит во внешнем хранилище по storage_key
+CREATE TABLE IF NOT EXISTS attachments (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    storage_key VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS attachments_task_id_idx ON attachments (task_id, id);
+CREATE INDEX IF NOT EXISTS attachments_user_id_idx ON attachments (user_id);
+
+-- файлы удалённых вложений (в том числе вместе с задачей), которые ещё нужно стереть из хранилища
+CREATE TABLE IF NOT EXISTS attachment_deletions (
+    storage_key VARCHAR(255) PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE OR REPLACE FUNCTION attachments_enqueue_deletion() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO attachment_deletions (storage_key) VALUES (OLD.storage_key)
+    ON CONFLICT DO NOTHING;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER attachments_deletion AFTER DELETE ON attachments
+    FOR EACH ROW EXECUTE FUNCTION attachments_enqueue_deletion();
+
+-- входящие уведомления; task_id и project_id без внешних ключей, чтобы
+-- уведомление об удалении задачи пережило саму задачу
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    type VARCHAR(50) NOT NULL,
+    task_id INTEGER,
+    project_id INTEGER,
+    text TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    read_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS notifications_user_id_idx ON notifications (user_id, id);
+CREATE INDEX IF NOT EXISTS notifications_unread_idx ON notifications (user_id) WHERE read_at IS NULL;
+
+-- отсутствие строки означает, что тип уведомлений включён
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    PRIMARY KEY (user_id, type)
+);
+
+-- напоминания о сроке задачи за offset_seconds до due_at; sent_for - срок, о котором
+-- уже напомнили, поэтому перенос срока снова включает напоминание
+CREATE TABLE IF NOT EXISTS task_reminders (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    offset_seconds INTEGER NOT NULL CHECK (offset_seconds >= 0),
+    sent_for TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (task_id, offset_seconds)
+);
+
+-- куда доставлять напоминания, кроме входящих
+CREATE TABLE IF NOT EXISTS reminder_settings (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    email VARCHAR(255),
+    webhook_url TEXT
+);
+
+-- исходящие вебхуки рабочего пространства; secret подписывает тела запросов (HMAC-SHA256)
+CREATE TABLE IF NOT EXISTS webhooks (
+    id SERIAL PRIMARY KEY,
+    workspace_id INTEGER NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    url TEXT NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    events VARCHAR(50)[] NOT NULL,
+    secret VARCHAR(100) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS webhooks_workspace_id_idx ON webhooks (workspace_id);
+
+ALTER TABLE webhooks ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS webhooks_workspace_isolation ON webhooks;
+CREATE POLICY webhooks_workspace_isolation ON webhooks TO todo_tenant
+    USING (workspace_id = current_setting('app.workspace_id')::INTEGER);
+
+-- outbox событий задач: строка пишется триггером в той же транзакции, что и изменение задачи,
+-- и разносится по вебхукам фоновым диспетчером (dispatched_at). workspace_id без внешнего
+-- ключа: задачи удаляются каскадом вместе с пространством, и их события не должны ему мешать
+CREATE TABLE IF NOT EXISTS webhook_events (
+    id BIGSERIAL PRIMARY KEY,
+    workspace_id INTEGER NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    task_id INTEGER NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    dispatched_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS webhook_events_pending_idx ON webhook_events (id) WHERE dispatched_at IS NULL;
+
+CREATE OR REPLACE FUNCTION tasks_webhook_event() RETURNS trigger AS $$
+DECLARE
+    event_type VARCHAR(50);
+    task tasks;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        event_type := 'task.created';
+        task := NEW;
+    ELSIF TG_OP = 'DELETE' THEN
+        event_type := 'task.deleted';
+        task := OLD;
+    ELSIF NEW.completed AND NOT OLD.completed THEN
+        event_type := 'task.completed';
+        task := NEW;
+    ELSE
+        event_type := 'task.updated';
+        task := NEW;
+    END IF;
+
+    INSERT INTO webhook_events (workspace_id, type, task_id, payload)
+    VALUES (task.workspace_id, event_type, task.id, to_jsonb(task));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER tasks_webhook_event AFTER INSERT OR DELETE ON tasks
+    FOR EACH ROW EXECUTE FUNCTION tasks_webhook_event();
+
+-- служебные поля меняет tasks_touch при любом UPDATE, поэтому они не считаются изменением
+CREATE OR REPLACE TRIGGER tasks_webhook_event_update AFTER UPDATE ON tasks
+    FOR EACH ROW WHEN (to_jsonb(OLD) - 'updated_at' - 'change_seq' IS DISTINCT FROM to_jsonb(NEW) - 'updated_at' - 'change_seq')
+    EXECUTE FUNCTION tasks_webhook_event();
+
+-- доставки событий; повторная доставка создаёт новую строку для того же события
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL REFERENCES webhook_events(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_attempt_at TIMESTAMPTZ,
+    response_status INTEGER,
+    response_body TEXT,
+    error TEXT,
+    duration_ms INTEGER,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_id_idx ON webhook_deliveries (webhook_id, id);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+
+-- audience - пользователи с доступом к задаче на момент события; по нему поток /events
+-- решает, кому показывать событие. Для удаления событие пишется BEFORE DELETE, пока
+-- приглашения и доступ через проект ещё на месте.
+ALTER TABLE webhook_events ADD COLUMN IF NOT EXISTS audience INTEGER[] NOT NULL DEFAULT '{}';
+
+CREATE OR REPLACE FUNCTION tasks_webhook_event() RETURNS trigger AS $$
+DECLARE
+    event_type VARCHAR(50);
+    task tasks;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        event_type := 'task.created';
+        task := NEW;
+    ELSIF TG_OP = 'DELETE' THEN
+        event_type := 'task.deleted';
+        task := OLD;
+    ELSIF NEW.completed AND NOT OLD.completed THEN
+        event_type := 'task.completed';
+        task := NEW;
+    ELSE
+        event_type := 'task.updated';
+        task := NEW;
+    END IF;
+
+    INSERT INTO webhook_events (workspace_id, type, task_id, payload, audience)
+    VALUES (task.workspace_id, event_type, task.id, to_jsonb(task), ARRAY(
+        SELECT user_id FROM task_access WHERE task_id = task.id
+        UNION
+        SELECT task.user_id
+        UNION
+        SELECT task.assignee_id WHERE task.assignee_id IS NOT NULL));
+
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER tasks_webhook_event AFTER INSERT ON tasks
+    FOR EACH ROW EXECUTE FUNCTION tasks_webhook_event();
+
+CREATE OR REPLACE TRIGGER tasks_webhook_event_delete BEFORE DELETE ON tasks
+    FOR EACH ROW EXECUTE FUNCTION tasks_webhook_event();
+
+-- экземпляры приложения узнают о новых событиях через LISTEN task_events
+CREATE OR REPLACE FUNCTION webhook_events_notify() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('task_events', NEW.id::TEXT);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER webhook_events_notify AFTER INSERT ON webhook_events
+    FOR EACH ROW EXECUTE FUNCTION webhook_events_notify();
+
+-- Часы полей задач для синхронизации офлайн-клиентов (POST /sync): каким изменением
+-- и когда последний раз менялось каждое поле. Время изменения берётся из app.changed_at,
+-- если его выставил синхронизирующий клиент, иначе NOW().
+CREATE TABLE IF NOT EXISTS task_field_changes (
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    field VARCHAR(32) NOT NULL,
+    change_seq BIGINT NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (task_id, field)
+);
+
+CREATE OR REPLACE FUNCTION tasks_field_changes() RETURNS trigger AS $$
+DECLARE
+    changed_at TIMESTAMPTZ := COALESCE(NULLIF(current_setting('app.changed_at', true), '')::TIMESTAMPTZ, NOW());
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO task_field_changes (task_id, field, change_seq, changed_at)
+        SELECT NEW.id, f, NEW.change_seq, changed_at
+        FROM unnest(ARRAY['title', 'description', 'completed', 'due_at']) AS f;
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO task_field_changes (task_id, field, change_seq, changed_at)
+    SELECT NEW.id, f.field, NEW.change_seq, changed_at
+    FROM (VALUES
+        ('title', NEW.title IS DISTINCT FROM OLD.title),
+        ('description', NEW.description IS DISTINCT FROM OLD.description),
+        ('completed', NEW.completed IS DISTINCT FROM OLD.completed),
+        ('due_at', NEW.due_at IS DISTINCT FROM OLD.due_at)) AS f(field, changed)
+    WHERE f.changed
+    ON CONFLICT (task_id, field) DO UPDATE
+    SET change_seq = EXCLUDED.change_seq, changed_at = EXCLUDED.changed_at;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER tasks_field_changes AFTER INSERT OR UPDATE ON tasks
+    FOR EACH ROW EXECUTE FUNCTION tasks_field_changes();
+
+-- у задач, созданных до появления часов, все поля считаются изменёнными их последним изменением
+INSERT INTO task_field_changes (task_id, field, change_seq, changed_at)
+SELECT t.id, f, t.change_seq, t.updated_at
+FROM tasks t, unnest(ARRAY['title', 'description', 'completed', 'due_at']) AS f
+ON CONFLICT (task_id, field) DO NOTHING;
+
+-- время удаления, как и время изменения полей, может задать синхронизирующий клиент
+CREATE OR REPLACE FUNCTION tasks_tombstone() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO task_tombstones (user_id, uid, workspace_id, deleted_at)
+    VALUES (OLD.user_id, OLD.uid, OLD.workspace_id,
+        COALESCE(NULLIF(current_setting('app.changed_at', true), '')::TIMESTAMPTZ, NOW()))
+    ON CONFLICT (user_id, uid) DO UPDATE
+    SET change_seq = nextval('task_change_seq'), deleted_at = EXCLUDED.deleted_at, workspace_id = EXCLUDED.workspace_id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,2 @@
+-- Отключённый пользователь не может войти; его данные сохраняются
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMPTZ;
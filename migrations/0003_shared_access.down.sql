@@ -0,0 +1,11 @@
+ALTER FUNCTION tasks_tombstone() SECURITY INVOKER RESET search_path;
+
+DROP POLICY IF EXISTS projects_workspace_isolation ON projects;
+CREATE POLICY projects_workspace_isolation ON projects TO todo_tenant
+    USING (workspace_id = current_setting('app.workspace_id')::INTEGER);
+
+DROP POLICY IF EXISTS tasks_workspace_isolation ON tasks;
+CREATE POLICY tasks_workspace_isolation ON tasks TO todo_tenant
+    USING (workspace_id = current_setting('app.workspace_id')::INTEGER);
+
+DROP FUNCTION IF EXISTS shared_with_workspace;
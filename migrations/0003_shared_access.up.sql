@@ -0,0 +1,26 @@
+-- Приглашения (shares) выдаются пользователю, а не рабочему пространству, и
+-- принятые задачи и проекты видны в личном пространстве приглашённого:
+-- политики пропускают их, если app.workspace_id - личное пространство того,
+-- кто принял приглашение. Права внутри ресурса по-прежнему даёт task_access.
+CREATE OR REPLACE FUNCTION shared_with_workspace(resource_type VARCHAR, resource_id INTEGER) RETURNS BOOLEAN AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM shares s
+        JOIN workspaces w ON w.personal_user_id = s.user_id
+        WHERE w.id = NULLIF(current_setting('app.workspace_id', true), '')::INTEGER
+            AND s.resource_type = $1 AND s.resource_id = $2 AND s.status = 'accepted')
+$$ LANGUAGE sql STABLE;
+
+DROP POLICY IF EXISTS tasks_workspace_isolation ON tasks;
+CREATE POLICY tasks_workspace_isolation ON tasks TO todo_tenant
+    USING (workspace_id = current_setting('app.workspace_id')::INTEGER
+        OR shared_with_workspace('task', id)
+        OR (project_id IS NOT NULL AND shared_with_workspace('project', project_id)));
+
+DROP POLICY IF EXISTS projects_workspace_isolation ON projects;
+CREATE POLICY projects_workspace_isolation ON projects TO todo_tenant
+    USING (workspace_id = current_setting('app.workspace_id')::INTEGER
+        OR shared_with_workspace('project', id));
+
+-- надгробие задачи пишется в пространство владельца, даже когда её удаляет
+-- приглашённый с ролью owner из своего пространства
+ALTER FUNCTION tasks_tombstone() SECURITY DEFINER SET search_path = public;
@@ -0,0 +1,18 @@
+-- Исполнитель видит задачу как зритель: переназначать её и менять поля
+-- он не может. Отметить выполнение ему разрешает отдельное условие
+-- в UPDATE задачи (см. task.Repository.Update).
+CREATE OR REPLACE VIEW task_access WITH (security_invoker = true) AS
+    SELECT t.id AS task_id, t.user_id, 3 AS rank, NULL::INTEGER AS shared_by_id
+    FROM tasks t
+    UNION ALL
+    SELECT s.resource_id, s.user_id, share_rank(s.role), s.owner_id
+    FROM shares s
+    WHERE s.resource_type = 'task' AND s.status = 'accepted'
+    UNION ALL
+    SELECT t.id, pa.user_id, pa.rank, pa.shared_by_id
+    FROM tasks t
+    JOIN project_access pa ON pa.project_id = t.project_id
+    UNION ALL
+    SELECT t.id, t.assignee_id, 1, t.user_id
+    FROM tasks t
+    WHERE t.assignee_id IS NOT NULL;
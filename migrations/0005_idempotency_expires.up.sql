@@ -0,0 +1,2 @@
+-- idempotency.Cleaner удаляет просроченные ключи по expires_at
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS attachment_deletions_next_attempt_at_idx;
+ALTER TABLE attachment_deletions DROP COLUMN IF EXISTS next_attempt_at;
+ALTER TABLE attachment_deletions DROP COLUMN IF EXISTS attempts;
@@ -0,0 +1,5 @@
+-- Ключ, который не удалось стереть, откладывается с растущей паузой и не
+-- держит голову очереди
+ALTER TABLE attachment_deletions ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE attachment_deletions ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+CREATE INDEX IF NOT EXISTS attachment_deletions_next_attempt_at_idx ON attachment_deletions (next_attempt_at);
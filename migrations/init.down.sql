@@ -1,3 +0,0 @@
-DROP TABLE tasks;
-
-DROP TABLE users;
\ No newline at end of file
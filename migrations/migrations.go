@@ -0,0 +1,9 @@
+// Package migrations встраивает SQL-миграции схемы в бинарник.
+// Файлы называются NNNN_name.up.sql и NNNN_name.down.sql; применённую миграцию
+// не редактируют - изменения схемы добавляются новым номером.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
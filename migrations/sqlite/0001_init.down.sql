@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS task_assignments;
+DROP TABLE IF EXISTS task_tombstones;
+DROP TABLE IF EXISTS tasks;
+DROP TABLE IF EXISTS task_change_seq;
+DROP TABLE IF EXISTS app_passwords;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,67 @@
+-- Схема SQLite: только пользователи и задачи. Триггеров Postgres здесь нет:
+-- номер изменения, время изменения и надгробия удалённых задач пишут
+-- репозитории (см. task.SQLiteRepository)
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username TEXT NOT NULL UNIQUE,
+    password TEXT NOT NULL,
+    disabled_at DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS app_passwords (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    password_hash TEXT NOT NULL UNIQUE,
+    created_at DATETIME NOT NULL,
+    last_used_at DATETIME
+);
+
+-- общий счётчик изменений задач, как последовательность task_change_seq в Postgres
+CREATE TABLE IF NOT EXISTS task_change_seq (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    value INTEGER NOT NULL
+);
+INSERT OR IGNORE INTO task_change_seq (id, value) VALUES (1, 0);
+
+-- workspace_id - рабочее пространство из контекста запроса; отдельной таблицы
+-- пространств нет, личным пространством пользователя считается его id
+CREATE TABLE IF NOT EXISTS tasks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    workspace_id INTEGER NOT NULL,
+    uid TEXT NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    completed BOOLEAN NOT NULL DEFAULT FALSE,
+    due_at DATETIME,
+    completed_at DATETIME,
+    updated_at DATETIME NOT NULL,
+    change_seq INTEGER NOT NULL,
+    extras TEXT NOT NULL DEFAULT '{}',
+    project_id INTEGER,
+    assignee_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    UNIQUE (user_id, uid)
+);
+CREATE INDEX IF NOT EXISTS tasks_workspace_id_idx ON tasks (workspace_id, id);
+CREATE INDEX IF NOT EXISTS tasks_change_seq_idx ON tasks (user_id, change_seq);
+CREATE INDEX IF NOT EXISTS tasks_assignee_id_idx ON tasks (assignee_id);
+
+CREATE TABLE IF NOT EXISTS task_tombstones (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    uid TEXT NOT NULL,
+    workspace_id INTEGER NOT NULL,
+    change_seq INTEGER NOT NULL,
+    deleted_at DATETIME NOT NULL,
+    PRIMARY KEY (user_id, uid)
+);
+
+-- история назначений; assignee_id пуст, если исполнителя сняли
+CREATE TABLE IF NOT EXISTS task_assignments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    assignee_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    assigned_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS task_assignments_task_id_idx ON task_assignments (task_id, id);
@@ -0,0 +1,9 @@
+// Package sqlite встраивает SQL-миграции схемы SQLite в бинарник. Схема
+// отдельная от Postgres и покрывает только пользователей и задачи; правила
+// именования и неизменности файлов те же, что в migrations.
+package sqlite
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,26 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Ошибки запросов, прерванных отменой контекста. Оборачивают context.Canceled
+// и context.DeadlineExceeded, поэтому errors.Is работает и с ними
+var (
+	ErrCanceled = fmt.Errorf("database query canceled: %w", context.Canceled)
+	ErrTimeout  = fmt.Errorf("database query timed out: %w", context.DeadlineExceeded)
+)
+
+// canceled отличает обрыв запроса от сбоя базы: драйвер при отмене возвращает
+// свою ошибку, по которой не видно, что причиной был контекст
+func canceled(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	return fmt.Errorf("%w: %w", ErrCanceled, err)
+}
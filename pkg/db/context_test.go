@@ -0,0 +1,46 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+)
+
+func TestDb_Canceled(t *testing.T) {
+	mockDb, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := &db.Db{DB: sqlx.NewDb(mockDb, "sqlMock")}
+	query := regexp.QuoteMeta(`SELECT id FROM tasks`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mock.ExpectQuery(query).WillDelayFor(time.Second).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	var id int
+	err = database.GetContext(ctx, &id, `SELECT id FROM tasks`)
+	if !errors.Is(err, db.ErrCanceled) || !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", db.ErrCanceled, err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	mock.ExpectQuery(query).WillDelayFor(time.Second).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	err = database.SelectContext(ctx, &[]int{}, `SELECT id FROM tasks`)
+	if !errors.Is(err, db.ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v, got %v", db.ErrTimeout, err)
+	}
+
+	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	if err = database.GetContext(context.Background(), &id, `SELECT id FROM tasks`); errors.Is(err, db.ErrCanceled) {
+		t.Errorf("unexpected %v for empty result", err)
+	}
+}
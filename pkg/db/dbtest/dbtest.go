@@ -0,0 +1,80 @@
+// Package dbtest подключает тесты к настоящим базам данных: Postgres из
+// переменной окружения и временному файлу SQLite.
+package dbtest
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/melnik-dev/go_todo_jwt/migrations"
+	sqlitemigrations "github.com/melnik-dev/go_todo_jwt/migrations/sqlite"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/melnik-dev/go_todo_jwt/pkg/migrate"
+	"github.com/sirupsen/logrus"
+)
+
+// EnvPostgresDSN - переменная со строкой подключения к тестовой базе Postgres.
+// Без неё тесты на Postgres пропускаются
+const EnvPostgresDSN = "TEST_DATABASE_DSN"
+
+// Postgres подключается к тестовой базе и применяет к ней миграции.
+// Данные между тестами не очищаются: тесты создают своих пользователей
+// с уникальными именами.
+func Postgres(t *testing.T) *db.Db {
+	t.Helper()
+
+	dsn := os.Getenv(EnvPostgresDSN)
+	if dsn == "" {
+		t.Skipf("%s is not set", EnvPostgresDSN)
+	}
+
+	database, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	migrator, err := migrate.New(database, migrations.FS, Logger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return &db.Db{DB: database}
+}
+
+// SQLite создаёт базу SQLite во временном каталоге теста и применяет
+// к ней миграции SQLite
+func SQLite(t *testing.T) *db.Db {
+	t.Helper()
+
+	database, err := db.OpenSQLite(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	migrator, err := migrate.New(database.DB, sqlitemigrations.FS, Logger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return database
+}
+
+// Logger - логгер для репозиториев в тестах, который ничего не пишет
+func Logger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
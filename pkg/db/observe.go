@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// QueryObserver получает длительность запросов; repository и method берутся
+// из вызвавшей запрос функции, например task и GetAll для task.(*Repository).GetAll
+type QueryObserver interface {
+	ObserveQuery(repository, method string, duration time.Duration, err error)
+}
+
+// SetObserver включает измерение запросов. Запрос через Tenant измеряется
+// целиком, вместе со всеми запросами его транзакции
+func (d *Db) SetObserver(observer QueryObserver) {
+	d.observer = observer
+}
+
+// callers кеширует разбор имени функции по адресу вызова
+var callers sync.Map
+
+// observe вызывается через defer из методов Db; вызвавший их метод репозитория
+// находится на два кадра выше
+func (d *Db) observe(start time.Time, err *error) {
+	if d.observer == nil {
+		return
+	}
+	repository, method := "unknown", "unknown"
+	if pc, _, _, ok := runtime.Caller(2); ok {
+		if names, ok := callers.Load(pc); ok {
+			repository, method = names.([2]string)[0], names.([2]string)[1]
+		} else if fn := runtime.FuncForPC(pc); fn != nil {
+			repository, method = callerNames(fn.Name())
+			callers.Store(pc, [2]string{repository, method})
+		}
+	}
+	d.observer.ObserveQuery(repository, method, time.Since(start), *err)
+}
+
+// callerNames разбирает github.com/.../internal/task.(*Repository).GetAll.func1 в task и GetAll
+func callerNames(name string) (string, string) {
+	name = name[strings.LastIndex(name, "/")+1:]
+	parts := strings.Split(name, ".")
+	for len(parts) > 2 && strings.HasPrefix(parts[len(parts)-1], "func") {
+		parts = parts[:len(parts)-1]
+	}
+	return parts[0], parts[len(parts)-1]
+}
+
+func (d *Db) Get(dest any, query string, args ...any) (err error) {
+	defer d.observe(time.Now(), &err)
+	return d.DB.Get(dest, query, args...)
+}
+
+func (d *Db) GetContext(ctx context.Context, dest any, query string, args ...any) (err error) {
+	defer d.observe(time.Now(), &err)
+	return canceled(ctx, sqlx.GetContext(ctx, d.queryer(ctx), dest, query, args...))
+}
+
+func (d *Db) Select(dest any, query string, args ...any) (err error) {
+	defer d.observe(time.Now(), &err)
+	return d.DB.Select(dest, query, args...)
+}
+
+func (d *Db) SelectContext(ctx context.Context, dest any, query string, args ...any) (err error) {
+	defer d.observe(time.Now(), &err)
+	return canceled(ctx, sqlx.SelectContext(ctx, d.queryer(ctx), dest, query, args...))
+}
+
+func (d *Db) Exec(query string, args ...any) (result sql.Result, err error) {
+	defer d.observe(time.Now(), &err)
+	return d.DB.Exec(query, args...)
+}
+
+func (d *Db) ExecContext(ctx context.Context, query string, args ...any) (result sql.Result, err error) {
+	defer d.observe(time.Now(), &err)
+	result, err = d.queryer(ctx).ExecContext(ctx, query, args...)
+	return result, canceled(ctx, err)
+}
+
+// QueryRow измеряет время до первой строки; ошибка сканирования в измерение не попадает
+func (d *Db) QueryRow(query string, args ...any) *sql.Row {
+	var err error
+	defer d.observe(time.Now(), &err)
+	return d.DB.QueryRow(query, args...)
+}
+
+func (d *Db) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	var err error
+	defer d.observe(time.Now(), &err)
+	return d.queryer(ctx).QueryRowContext(ctx, query, args...)
+}
+
+func (d *Db) QueryContext(ctx context.Context, query string, args ...any) (rows *sql.Rows, err error) {
+	defer d.observe(time.Now(), &err)
+	rows, err = d.queryer(ctx).QueryContext(ctx, query, args...)
+	return rows, canceled(ctx, err)
+}
+
+func (d *Db) QueryxContext(ctx context.Context, query string, args ...any) (rows *sqlx.Rows, err error) {
+	defer d.observe(time.Now(), &err)
+	rows, err = d.queryer(ctx).QueryxContext(ctx, query, args...)
+	return rows, canceled(ctx, err)
+}
+
+func (d *Db) QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row {
+	var err error
+	defer d.observe(time.Now(), &err)
+	return d.queryer(ctx).QueryRowxContext(ctx, query, args...)
+}
+
+// PreparexContext готовит запрос в транзакции из ctx, если она есть; время
+// выполнения подготовленного запроса не измеряется
+func (d *Db) PreparexContext(ctx context.Context, query string) (*sqlx.Stmt, error) {
+	return d.queryer(ctx).PreparexContext(ctx, query)
+}
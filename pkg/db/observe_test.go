@@ -0,0 +1,86 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+)
+
+type observed struct {
+	repository string
+	method     string
+	err        error
+}
+
+type mockObserver struct {
+	calls []observed
+}
+
+func (o *mockObserver) ObserveQuery(repository, method string, duration time.Duration, err error) {
+	o.calls = append(o.calls, observed{repository: repository, method: method, err: err})
+}
+
+// Repository повторяет устройство репозиториев приложения
+type Repository struct {
+	db *db.Db
+}
+
+func (r *Repository) Find() error {
+	var id int
+	return r.db.Get(&id, `SELECT id FROM tasks WHERE id = $1`, 1)
+}
+
+func (r *Repository) Update(ctx context.Context) error {
+	return r.db.Tenant(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, `UPDATE tasks SET title = $1`, "new")
+		return err
+	})
+}
+
+func TestDb_Observe(t *testing.T) {
+	mockDb, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	observer := &mockObserver{}
+	pgDB := &db.Db{DB: sqlx.NewDb(mockDb, "sqlMock")}
+	pgDB.SetObserver(observer)
+	repo := &Repository{db: pgDB}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM tasks WHERE id = $1`)).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT set_config('role', $1, true), set_config('app.workspace_id', $2, true)`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE tasks SET title = $1`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	_ = repo.Find()
+	if err = repo.Update(db.WithWorkspace(context.Background(), 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []observed{
+		{repository: "db_test", method: "Find", err: sql.ErrNoRows},
+		{repository: "db_test", method: "Update"},
+	}
+	if len(observer.calls) != len(want) {
+		t.Fatalf("expected %d observations, got %+v", len(want), observer.calls)
+	}
+	for i, w := range want {
+		if observer.calls[i] != w {
+			t.Errorf("observation %d: expected %+v, got %+v", i, w, observer.calls[i])
+		}
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,19 +1,40 @@
 package db
 
 import (
+	"context"
+	"database/sql/driver"
 	"fmt"
+
+	"github.com/XSAM/otelsql"
 	"github.com/jmoiron/sqlx"
 	"github.com/melnik-dev/go_todo_jwt/configs"
 	"github.com/sirupsen/logrus"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Db struct {
 	*sqlx.DB
+	observer QueryObserver
+}
+
+// DSN - строка подключения к Postgres из конфигурации
+func DSN(cfg *configs.Config) string {
+	return fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s sslmode=%s", cfg.DB.Host, cfg.DB.Port, cfg.DB.Username, cfg.DB.DBName, cfg.DB.Password, cfg.DB.SSLMode)
 }
 
 func InitPostgres(cfg *configs.Config, logger *logrus.Logger) (*Db, error) {
-	connStr := fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s sslmode=%s", cfg.DB.Host, cfg.DB.Port, cfg.DB.Username, cfg.DB.DBName, cfg.DB.Password, cfg.DB.SSLMode)
-	db, err := sqlx.Open("postgres", connStr) // Connect
+	// otelsql создаёт спаны запросов внутри трассируемого запроса, включая запросы транзакций
+	sqlDB, err := otelsql.Open("postgres", DSN(cfg),
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitConnPrepare:      true,
+			OmitRows:             true,
+			DisableErrSkip:       true,
+			SpanFilter:           withParentSpan,
+		}),
+	)
 	fields := logrus.Fields{
 		"db_host": cfg.DB.Host,
 		"db_port": cfg.DB.Port,
@@ -24,6 +45,7 @@ func InitPostgres(cfg *configs.Config, logger *logrus.Logger) (*Db, error) {
 		return nil, err
 	}
 
+	db := sqlx.NewDb(sqlDB, "postgres")
 	err = db.Ping()
 	if err != nil {
 		logger.WithError(err).WithFields(fields).Fatal("Failed to ping database")
@@ -31,5 +53,11 @@ func InitPostgres(cfg *configs.Config, logger *logrus.Logger) (*Db, error) {
 	}
 
 	logger.WithFields(fields).Info("Database connected successfully")
-	return &Db{db}, nil
+	return &Db{DB: db}, nil
+}
+
+// withParentSpan пропускает запросы вне трассируемого запроса, например фоновых задач,
+// чтобы каждый из них не становился отдельной трассой
+func withParentSpan(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+	return trace.SpanContextFromContext(ctx).IsValid()
 }
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"net/url"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/sirupsen/logrus"
+	_ "modernc.org/sqlite"
+)
+
+// DriverSQLite - имя драйвера SQLite (modernc.org/sqlite, без cgo)
+const DriverSQLite = "sqlite"
+
+func init() {
+	// sqlx знает только sqlite3 из mattn/go-sqlite3; Rebind должен оставлять "?"
+	sqlx.BindDriver(DriverSQLite, sqlx.QUESTION)
+}
+
+func InitSQLite(cfg *configs.Config, logger *logrus.Logger) (*Db, error) {
+	fields := logrus.Fields{"db_path": cfg.DB.Path}
+
+	db, err := OpenSQLite(cfg.DB.Path)
+	if err != nil {
+		logger.WithError(err).WithFields(fields).Error("Failed to open database")
+		return nil, err
+	}
+
+	logger.WithFields(fields).Info("Database opened successfully")
+	return db, nil
+}
+
+// OpenSQLite открывает файл базы SQLite в режиме WAL: читатели не ждут писателя.
+// Транзакции сразу берут блокировку записи (_txlock=immediate), чтобы
+// конкурентные записи ждали busy_timeout, а не падали с SQLITE_BUSY.
+func OpenSQLite(path string) (*Db, error) {
+	params := url.Values{}
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Set("_txlock", "immediate")
+	params.Set("_time_format", "sqlite")
+
+	db, err := sqlx.Open(DriverSQLite, "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Db{DB: db}, nil
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// TenantRole - роль без прав обхода RLS, под которой выполняются запросы
+// к данным рабочего пространства (см. политики в migrations)
+const TenantRole = "todo_tenant"
+
+var ErrNoWorkspace = errors.New("workspace is not selected")
+
+type workspaceKey struct{}
+
+// WithWorkspace возвращает контекст, запросы из которого видят только
+// данные рабочего пространства workspaceID
+func WithWorkspace(ctx context.Context, workspaceID int) context.Context {
+	return context.WithValue(ctx, workspaceKey{}, workspaceID)
+}
+
+func WorkspaceFromContext(ctx context.Context) (int, bool) {
+	workspaceID, ok := ctx.Value(workspaceKey{}).(int)
+	return workspaceID, ok && workspaceID > 0
+}
+
+// setTenant переключает роль и рабочее пространство до конца транзакции
+const setTenant = `SELECT set_config('role', $1, true), set_config('app.workspace_id', $2, true)`
+
+// Tenant выполняет fn в транзакции под ролью TenantRole с app.workspace_id
+// из ctx. Изоляцию обеспечивают политики RLS, поэтому запросам внутри fn
+// не нужны условия по рабочему пространству. Без рабочего пространства в ctx
+// fn не вызывается. Внутри TxManager.WithinTx fn выполняется в его транзакции
+// после точки сохранения.
+func (d *Db) Tenant(ctx context.Context, fn func(tx *sqlx.Tx) error) (err error) {
+	defer d.observe(time.Now(), &err)
+	defer func() { err = canceled(ctx, err) }()
+
+	workspaceID, ok := WorkspaceFromContext(ctx)
+	if !ok {
+		return ErrNoWorkspace
+	}
+
+	if state := txFromContext(ctx); state != nil {
+		return savepoint(ctx, state, func() error {
+			return nestedTenant(ctx, state.tx, workspaceID, fn)
+		})
+	}
+
+	tx, err := d.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, setTenant, TenantRole, strconv.Itoa(workspaceID)); err != nil {
+		return err
+	}
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// nestedTenant выполняет fn во внешней транзакции и возвращает прежние роль
+// и пространство, чтобы следующие запросы транзакции не попали под RLS
+func nestedTenant(ctx context.Context, tx *sqlx.Tx, workspaceID int, fn func(tx *sqlx.Tx) error) error {
+	var role, workspace string
+	query := `SELECT current_setting('role'), COALESCE(current_setting('app.workspace_id', true), '')`
+	if err := tx.QueryRowContext(ctx, query).Scan(&role, &workspace); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, setTenant, TenantRole, strconv.Itoa(workspaceID)); err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, setTenant, role, workspace)
+	return err
+}
@@ -0,0 +1,146 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// DefaultTxRetries - сколько раз TxManager по умолчанию повторяет транзакцию,
+// прерванную из-за конфликта сериализации или взаимной блокировки
+const DefaultTxRetries = 3
+
+// txRetryDelay - пауза перед первым повтором; с каждым повтором растёт
+const txRetryDelay = 10 * time.Millisecond
+
+type txKey struct{}
+
+// txState - транзакция контекста и счётчик её точек сохранения
+type txState struct {
+	tx         *sqlx.Tx
+	savepoints int
+}
+
+func txFromContext(ctx context.Context) *txState {
+	state, _ := ctx.Value(txKey{}).(*txState)
+	return state
+}
+
+// TxManager объединяет запросы нескольких репозиториев в одну транзакцию.
+// Транзакция передаётся через контекст: методы Db, вызванные с контекстом
+// из WithinTx, выполняются в ней, поэтому репозиториям ничего менять не нужно.
+type TxManager struct {
+	db *Db
+	// Isolation - уровень изоляции внешней транзакции; по умолчанию уровень базы
+	Isolation sql.IsolationLevel
+	// MaxRetries - число повторов после конфликта сериализации
+	MaxRetries int
+}
+
+func NewTxManager(db *Db) *TxManager {
+	return &TxManager{
+		db:         db,
+		MaxRetries: DefaultTxRetries,
+	}
+}
+
+// WithinTx выполняет fn в транзакции и фиксирует её, если fn вернула nil.
+// Вложенный вызов не открывает новую транзакцию, а ставит точку сохранения:
+// ошибка fn откатывает только её изменения. Внешняя транзакция при конфликте
+// сериализации повторяется целиком, поэтому fn не должна иметь побочных
+// эффектов вне базы.
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if state := txFromContext(ctx); state != nil {
+		return savepoint(ctx, state, func() error { return fn(ctx) })
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := m.run(ctx, fn)
+		if err == nil || attempt >= m.MaxRetries || !retryable(err) {
+			return err
+		}
+
+		select {
+		case <-time.After(txRetryDelay << attempt):
+		case <-ctx.Done():
+			return canceled(ctx, err)
+		}
+	}
+}
+
+func (m *TxManager) run(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	tx, err := m.db.DB.BeginTxx(ctx, &sql.TxOptions{Isolation: m.Isolation})
+	if err != nil {
+		return canceled(ctx, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, &txState{tx: tx})); err != nil {
+		return err
+	}
+	return canceled(ctx, tx.Commit())
+}
+
+// savepoint выполняет fn внутри транзакции state после SAVEPOINT и откатывает
+// к нему, если fn вернула ошибку
+func savepoint(ctx context.Context, state *txState, fn func() error) error {
+	state.savepoints++
+	name := fmt.Sprintf("sp_%d", state.savepoints)
+
+	if _, err := state.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return canceled(ctx, err)
+	}
+	if err := fn(); err != nil {
+		if _, rbErr := state.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+	_, err := state.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	return canceled(ctx, err)
+}
+
+// retryable - конфликт сериализации или взаимная блокировка: транзакцию
+// можно безопасно повторить с начала
+func retryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
+}
+
+// IsUniqueViolation сообщает, что запрос нарушил ограничение уникальности
+func IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505"
+	}
+	var liteErr *sqlite.Error
+	return errors.As(err, &liteErr) && liteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
+
+// queryer - соединение для запросов: транзакция из контекста или пул
+type queryer interface {
+	sqlx.ExtContext
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	PreparexContext(ctx context.Context, query string) (*sqlx.Stmt, error)
+}
+
+func (d *Db) queryer(ctx context.Context) queryer {
+	if state := txFromContext(ctx); state != nil {
+		return state.tx
+	}
+	return d.DB
+}
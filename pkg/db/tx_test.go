@@ -0,0 +1,220 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+)
+
+const updateQuery = `UPDATE tasks SET title = $1`
+
+func mockTx(t *testing.T) (*db.Db, *db.TxManager, sqlmock.Sqlmock) {
+	t.Helper()
+	mockDb, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := &db.Db{DB: sqlx.NewDb(mockDb, "sqlMock")}
+	return database, db.NewTxManager(database), mock
+}
+
+func expectUpdate(mock sqlmock.Sqlmock, title string) *sqlmock.ExpectedExec {
+	return mock.ExpectExec(regexp.QuoteMeta(updateQuery)).WithArgs(title)
+}
+
+func update(ctx context.Context, database *db.Db, title string) error {
+	_, err := database.ExecContext(ctx, updateQuery, title)
+	return err
+}
+
+func TestTxManager_WithinTx(t *testing.T) {
+	database, txManager, mock := mockTx(t)
+
+	// оба запроса идут через Db, но попадают в одну транзакцию
+	mock.ExpectBegin()
+	expectUpdate(mock, "a").WillReturnResult(sqlmock.NewResult(0, 1))
+	expectUpdate(mock, "b").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+		if err := update(ctx, database, "a"); err != nil {
+			return err
+		}
+		return update(ctx, database, "b")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTxManager_WithinTx_Rollback(t *testing.T) {
+	database, txManager, mock := mockTx(t)
+
+	mock.ExpectBegin()
+	expectUpdate(mock, "a").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	errFailed := errors.New("failed")
+	err := txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+		if err := update(ctx, database, "a"); err != nil {
+			return err
+		}
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("expected %v, got %v", errFailed, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTxManager_WithinTx_Savepoint(t *testing.T) {
+	database, txManager, mock := mockTx(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SAVEPOINT sp_1`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectUpdate(mock, "inner").WillReturnError(errors.New("constraint violation"))
+	mock.ExpectExec(regexp.QuoteMeta(`ROLLBACK TO SAVEPOINT sp_1`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`SAVEPOINT sp_2`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectUpdate(mock, "second").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`RELEASE SAVEPOINT sp_2`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err := txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+		// ошибка вложенной транзакции откатывает только её
+		if err := txManager.WithinTx(ctx, func(ctx context.Context) error {
+			return update(ctx, database, "inner")
+		}); err == nil {
+			t.Error("expected inner error")
+		}
+		return txManager.WithinTx(ctx, func(ctx context.Context) error {
+			return update(ctx, database, "second")
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTxManager_WithinTx_Retry(t *testing.T) {
+	database, txManager, mock := mockTx(t)
+
+	serialization := &pq.Error{Code: "40001", Message: "could not serialize access"}
+	mock.ExpectBegin()
+	expectUpdate(mock, "a").WillReturnError(serialization)
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	expectUpdate(mock, "a").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	attempts := 0
+	err := txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+		attempts++
+		return update(ctx, database, "a")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTxManager_WithinTx_RetryExhausted(t *testing.T) {
+	database, txManager, mock := mockTx(t)
+	txManager.MaxRetries = 1
+
+	deadlock := &pq.Error{Code: "40P01", Message: "deadlock detected"}
+	for range 2 {
+		mock.ExpectBegin()
+		expectUpdate(mock, "a").WillReturnError(deadlock)
+		mock.ExpectRollback()
+	}
+
+	err := txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+		return update(ctx, database, "a")
+	})
+	if !errors.Is(err, deadlock) {
+		t.Fatalf("expected %v, got %v", deadlock, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTxManager_WithinTx_NoRetry(t *testing.T) {
+	database, txManager, mock := mockTx(t)
+
+	// повторяются только ошибки сериализации
+	unique := &pq.Error{Code: "23505", Message: "duplicate key value"}
+	mock.ExpectBegin()
+	expectUpdate(mock, "a").WillReturnError(unique)
+	mock.ExpectRollback()
+
+	err := txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+		return update(ctx, database, "a")
+	})
+	if !errors.Is(err, unique) {
+		t.Fatalf("expected %v, got %v", unique, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTxManager_Tenant(t *testing.T) {
+	database, txManager, mock := mockTx(t)
+
+	setTenant := regexp.QuoteMeta(`SELECT set_config('role', $1, true), set_config('app.workspace_id', $2, true)`)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SAVEPOINT sp_1`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT current_setting('role')`)).
+		WillReturnRows(sqlmock.NewRows([]string{"role", "workspace"}).AddRow("none", ""))
+	mock.ExpectExec(setTenant).WithArgs(db.TenantRole, "1").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectUpdate(mock, "tenant").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(setTenant).WithArgs("none", "").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`RELEASE SAVEPOINT sp_1`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectUpdate(mock, "owner").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ctx := db.WithWorkspace(context.Background(), 1)
+	err := txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := database.Tenant(ctx, func(tx *sqlx.Tx) error {
+			_, err := tx.ExecContext(ctx, updateQuery, "tenant")
+			return err
+		}); err != nil {
+			return err
+		}
+		// после Tenant запросы транзакции снова выполняются с прежней ролью
+		return update(ctx, database, "owner")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
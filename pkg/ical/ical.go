@@ -0,0 +1,313 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	StatusNeedsAction = "NEEDS-ACTION"
+	StatusInProcess   = "IN-PROCESS"
+	StatusCompleted   = "COMPLETED"
+	StatusCancelled   = "CANCELLED"
+
+	ContentType = "text/calendar; charset=utf-8"
+
+	prodID        = "-//melnik-dev//go_todo_jwt//RU"
+	dateTimeUTC   = "20060102T150405Z"
+	dateTimeLocal = "20060102T150405"
+	dateOnly      = "20060102"
+	maxLineOctets = 75
+)
+
+var ErrMalformed = errors.New("malformed iCalendar data")
+
+// Todo - компонент VTODO (RFC 5545, раздел 3.6.2) в объёме, который нужен задачам
+type Todo struct {
+	UID          string
+	Summary      string
+	Description  string
+	Status       string
+	Due          *time.Time
+	Completed    *time.Time
+	LastModified *time.Time
+	RRule        string
+}
+
+// Encoder пишет VCALENDAR с компонентами VTODO по одному
+type Encoder struct {
+	w   io.Writer
+	now time.Time
+	err error
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w, now: time.Now().UTC()}
+}
+
+func (e *Encoder) Begin(name string) error {
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", prodID)
+	e.line("CALSCALE", "GREGORIAN")
+	if name != "" {
+		e.line("X-WR-CALNAME", escapeText(name))
+	}
+	return e.err
+}
+
+func (e *Encoder) Encode(todo *Todo) error {
+	e.line("BEGIN", "VTODO")
+	e.line("UID", escapeText(todo.UID))
+	e.line("DTSTAMP", e.now.Format(dateTimeUTC))
+	e.line("SUMMARY", escapeText(todo.Summary))
+	if todo.Description != "" {
+		e.line("DESCRIPTION", escapeText(todo.Description))
+	}
+	status := todo.Status
+	if status == "" {
+		status = StatusNeedsAction
+	}
+	e.line("STATUS", status)
+	if todo.Due != nil {
+		e.line("DUE", todo.Due.UTC().Format(dateTimeUTC))
+	}
+	if todo.Completed != nil {
+		e.line("COMPLETED", todo.Completed.UTC().Format(dateTimeUTC))
+	}
+	if todo.LastModified != nil {
+		e.line("LAST-MODIFIED", todo.LastModified.UTC().Format(dateTimeUTC))
+	}
+	if todo.RRule != "" {
+		e.line("RRULE", todo.RRule)
+	}
+	e.line("END", "VTODO")
+	return e.err
+}
+
+func (e *Encoder) End() error {
+	e.line("END", "VCALENDAR")
+	return e.err
+}
+
+// line пишет строку содержимого, перенося её по 75 октетов без разрыва UTF-8 символов
+func (e *Encoder) line(name, value string) {
+	if e.err != nil {
+		return
+	}
+	s := name + ":" + value
+	var b strings.Builder
+	width := 0
+	for _, r := range s {
+		size := len(string(r))
+		if width+size > maxLineOctets {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	b.WriteString("\r\n")
+	_, e.err = io.WriteString(e.w, b.String())
+}
+
+func escapeText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+func unescapeText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// property - разобранная строка содержимого NAME;PARAM=VALUE:value
+type property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Parse читает все VTODO из календаря. Остальные компоненты (VEVENT, VTIMEZONE)
+// и вложенные в VTODO (VALARM) пропускаются.
+func Parse(r io.Reader) ([]Todo, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		todos   []Todo
+		current *Todo
+		stack   []string
+	)
+	for n, raw := range lines {
+		prop, err := parseLine(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrMalformed, n+1, err)
+		}
+
+		switch prop.Name {
+		case "BEGIN":
+			component := strings.ToUpper(prop.Value)
+			stack = append(stack, component)
+			if component == "VTODO" && len(stack) == 2 {
+				current = &Todo{}
+			}
+			continue
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1] != strings.ToUpper(prop.Value) {
+				return nil, fmt.Errorf("%w: line %d: unexpected END:%s", ErrMalformed, n+1, prop.Value)
+			}
+			if current != nil && len(stack) == 2 {
+				todos = append(todos, *current)
+				current = nil
+			}
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		// свойства вложенных компонентов к задаче не относятся
+		if current == nil || len(stack) != 2 {
+			continue
+		}
+		if err := current.set(prop); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrMalformed, n+1, err)
+		}
+	}
+	if len(stack) != 0 {
+		return nil, fmt.Errorf("%w: unterminated %s", ErrMalformed, stack[len(stack)-1])
+	}
+
+	return todos, nil
+}
+
+func (t *Todo) set(prop property) error {
+	var err error
+	switch prop.Name {
+	case "UID":
+		t.UID = unescapeText(prop.Value)
+	case "SUMMARY":
+		t.Summary = unescapeText(prop.Value)
+	case "DESCRIPTION":
+		t.Description = unescapeText(prop.Value)
+	case "STATUS":
+		t.Status = strings.ToUpper(prop.Value)
+	case "DUE":
+		t.Due, err = parseTime(prop)
+	case "COMPLETED":
+		t.Completed, err = parseTime(prop)
+	case "LAST-MODIFIED":
+		t.LastModified, err = parseTime(prop)
+	case "RRULE":
+		t.RRule = prop.Value
+	}
+	return err
+}
+
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	return lines, nil
+}
+
+func parseLine(line string) (property, error) {
+	prop := property{Params: map[string]string{}}
+
+	// двоеточие внутри кавычек в значении параметра не является разделителем
+	inQuotes := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		}
+		if r == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon <= 0 {
+		return prop, fmt.Errorf("missing ':' in %q", line)
+	}
+
+	head := strings.Split(line[:colon], ";")
+	prop.Name = strings.ToUpper(head[0])
+	for _, param := range head[1:] {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return prop, fmt.Errorf("invalid parameter %q", param)
+		}
+		prop.Params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	prop.Value = line[colon+1:]
+	return prop, nil
+}
+
+func parseTime(prop property) (*time.Time, error) {
+	value := prop.Value
+	if prop.Params["VALUE"] == "DATE" || len(value) == len(dateOnly) {
+		t, err := time.Parse(dateOnly, value)
+		if err != nil {
+			return nil, err
+		}
+		return &t, nil
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(dateTimeUTC, value)
+		if err != nil {
+			return nil, err
+		}
+		return &t, nil
+	}
+
+	loc := time.UTC
+	if tzid := prop.Params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation(dateTimeLocal, value, loc)
+	if err != nil {
+		return nil, err
+	}
+	t = t.UTC()
+	return &t, nil
+}
@@ -0,0 +1,118 @@
+package ical_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/melnik-dev/go_todo_jwt/pkg/ical"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncoder_RoundTrip(t *testing.T) {
+	due := time.Date(2026, 5, 1, 12, 30, 0, 0, time.UTC)
+	completed := time.Date(2026, 4, 30, 8, 0, 0, 0, time.UTC)
+	todo := ical.Todo{
+		UID:         "task-1@example.com",
+		Summary:     "Buy milk, bread; eggs",
+		Description: "line 1\nline 2 \\ " + strings.Repeat("очень длинное описание ", 10),
+		Status:      ical.StatusCompleted,
+		Due:         &due,
+		Completed:   &completed,
+		RRule:       "FREQ=WEEKLY;BYDAY=MO",
+	}
+
+	var buf bytes.Buffer
+	enc := ical.NewEncoder(&buf)
+	if err := enc.Begin("Tasks"); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Encode(&todo); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.End(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line is longer than 75 octets: %q", line)
+		}
+	}
+
+	todos, err := ical.Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(todos) != 1 {
+		t.Fatalf("expected 1 todo, got %d", len(todos))
+	}
+
+	got := todos[0]
+	if got.UID != todo.UID || got.Summary != todo.Summary || got.Description != todo.Description ||
+		got.Status != todo.Status || got.RRule != todo.RRule {
+		t.Errorf("expected %+v, got %+v", todo, got)
+	}
+	if got.Due == nil || !got.Due.Equal(due) {
+		t.Errorf("expected due %v, got %v", due, got.Due)
+	}
+	if got.Completed == nil || !got.Completed.Equal(completed) {
+		t.Errorf("expected completed %v, got %v", completed, got.Completed)
+	}
+}
+
+func TestParse_SkipsOtherComponents(t *testing.T) {
+	data := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:event\r\n" +
+		"SUMMARY:Meeting\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:todo\r\n" +
+		"SUMMARY:Long\r\n" +
+		" summary\r\n" +
+		"DUE;TZID=Europe/Moscow:20260501T150000\r\n" +
+		"BEGIN:VALARM\r\n" +
+		"DESCRIPTION:Alarm\r\n" +
+		"END:VALARM\r\n" +
+		"END:VTODO\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:all-day\r\n" +
+		"DUE;VALUE=DATE:20260502\r\n" +
+		"END:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+
+	todos, err := ical.Parse(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(todos) != 2 {
+		t.Fatalf("expected 2 todos, got %d", len(todos))
+	}
+
+	if todos[0].UID != "todo" || todos[0].Summary != "Longsummary" || todos[0].Description != "" {
+		t.Errorf("unexpected first todo: %+v", todos[0])
+	}
+	if want := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC); todos[0].Due == nil || !todos[0].Due.Equal(want) {
+		t.Errorf("expected due %v, got %v", want, todos[0].Due)
+	}
+	if want := time.Date(2026, 5, 2, 0, 0, 0, 0, time.UTC); todos[1].Due == nil || !todos[1].Due.Equal(want) {
+		t.Errorf("expected due %v, got %v", want, todos[1].Due)
+	}
+}
+
+func TestParse_Malformed(t *testing.T) {
+	tests := []string{
+		"BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nEND:VCALENDAR\r\n",
+		"BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\n",
+		"BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nDUE:tomorrow\r\nEND:VTODO\r\nEND:VCALENDAR\r\n",
+		"not a calendar\r\n",
+	}
+
+	for _, data := range tests {
+		if _, err := ical.Parse(strings.NewReader(data)); !errors.Is(err, ical.ErrMalformed) {
+			t.Errorf("expected %v for %q, got %v", ical.ErrMalformed, data, err)
+		}
+	}
+}
@@ -6,8 +6,10 @@ import (
 )
 
 type Data struct {
-	UserId   int
-	TokenTTL time.Duration
+	UserId int
+	// WorkspaceID - рабочее пространство, к которому привязан токен; 0 - не привязан
+	WorkspaceID int
+	TokenTTL    time.Duration
 }
 
 type JWT struct {
@@ -21,10 +23,14 @@ func NewJWT(secret string) *JWT {
 }
 
 func (j *JWT) Create(data Data) (string, error) {
-	claims := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	mapClaims := jwt.MapClaims{
 		"user_id": data.UserId,
 		"exp":     time.Now().Add(data.TokenTTL).Unix(),
-	})
+	}
+	if data.WorkspaceID > 0 {
+		mapClaims["workspace_id"] = data.WorkspaceID
+	}
+	claims := jwt.NewWithClaims(jwt.SigningMethodHS256, mapClaims)
 
 	s, err := claims.SignedString([]byte(j.Secret))
 	if err != nil {
@@ -52,7 +58,11 @@ func (j *JWT) Parse(token string) (bool, *Data) {
 	if !ok {
 		return false, nil
 	}
-	return true, &Data{
+	data := &Data{
 		UserId: int(userIDFloat),
 	}
+	if workspaceID, ok := claims["workspace_id"].(float64); ok {
+		data.WorkspaceID = int(workspaceID)
+	}
+	return true, data
 }
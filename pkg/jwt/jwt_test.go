@@ -47,3 +47,22 @@ func TestJWT_Parse_Expired(t *testing.T) {
 		t.Fatal("Expected expired token to be invalid")
 	}
 }
+
+func TestJWT_WorkspaceClaim(t *testing.T) {
+	jwtService := jwt.NewJWT("secret")
+	token, err := jwtService.Create(jwt.Data{
+		UserId:      1,
+		WorkspaceID: 5,
+		TokenTTL:    time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	isValid, data := jwtService.Parse(token)
+	if !isValid {
+		t.Fatal("Token is invalid")
+	}
+	if data.WorkspaceID != 5 {
+		t.Fatalf("expected workspace 5, got %d", data.WorkspaceID)
+	}
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"context"
 	"fmt"
 	"github.com/melnik-dev/go_todo_jwt/configs"
 	"io"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
@@ -146,7 +148,20 @@ func FromContext(c *gin.Context) *logrus.Entry {
 		}
 	}
 	globalLogger.Warn("Logger not found in Gin context, using main logger")
-	return globalLogger.WithContext(c.Request.Context())
+	return WithTrace(globalLogger.WithContext(c.Request.Context()), c.Request.Context())
+}
+
+// WithTrace добавляет к записи trace_id и span_id текущего спана из ctx,
+// чтобы по логам запроса можно было найти его трассу
+func WithTrace(entry *logrus.Entry, ctx context.Context) *logrus.Entry {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return entry
+	}
+	return entry.WithFields(logrus.Fields{
+		"trace_id": sc.TraceID().String(),
+		"span_id":  sc.SpanID().String(),
+	})
 }
 
 // callerHook - Hook для корректировки отчета о вызывающем объекте
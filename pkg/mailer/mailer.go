@@ -0,0 +1,85 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/sirupsen/logrus"
+)
+
+// Mailer отправляет текстовые письма
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// New возвращает SMTP, если в mail задан host, иначе Log
+func New(cfg configs.ConfMail, logger *logrus.Logger) Mailer {
+	if cfg.Host == "" {
+		return NewLog(logger)
+	}
+	return NewSMTP(cfg)
+}
+
+// SMTP отправляет письма через SMTP-сервер; авторизация PLAIN, если задан username
+type SMTP struct {
+	cfg configs.ConfMail
+}
+
+func NewSMTP(cfg configs.ConfMail) *SMTP {
+	return &SMTP{cfg: cfg}
+}
+
+func (m *SMTP) Send(ctx context.Context, to, subject, body string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if strings.ContainsAny(to, "\r\n") {
+		return fmt.Errorf("invalid recipient %q", to)
+	}
+
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+	addr := net.JoinHostPort(m.cfg.Host, m.cfg.Port)
+	return smtp.SendMail(addr, auth, m.cfg.From, []string{to}, Message(m.cfg.From, to, subject, body, time.Now()))
+}
+
+// Message собирает письмо в формате RFC 5322; тема кодируется для не-ASCII символов
+func Message(from, to, subject, body string, date time.Time) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	b.WriteString("Date: " + date.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// Log только пишет письма в лог; используется, пока SMTP не настроен
+type Log struct {
+	logger *logrus.Logger
+}
+
+func NewLog(logger *logrus.Logger) *Log {
+	return &Log{logger: logger}
+}
+
+func (m *Log) Send(ctx context.Context, to, subject, body string) error {
+	m.logger.WithFields(logrus.Fields{
+		"layer":   "Mailer log layer",
+		"to":      to,
+		"subject": subject,
+	}).Info("Email is not sent: SMTP is not configured")
+	return nil
+}
@@ -0,0 +1,27 @@
+package mailer_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/melnik-dev/go_todo_jwt/pkg/mailer"
+)
+
+func TestMessage(t *testing.T) {
+	date := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	msg := string(mailer.Message("todo@example.com", "alice@example.com", "Напоминание", "line1\nline2", date))
+
+	for _, want := range []string{
+		"From: todo@example.com\r\n",
+		"To: alice@example.com\r\n",
+		"Subject: =?utf-8?q?",
+		"Date: Wed, 01 May 2024 09:30:00 +0000\r\n",
+		"Content-Type: text/plain; charset=utf-8\r\n",
+		"\r\n\r\nline1\r\nline2",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message %q does not contain %q", msg, want)
+		}
+	}
+}
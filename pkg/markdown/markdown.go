@@ -0,0 +1,239 @@
+// Package markdown переводит текст комментариев из Markdown в HTML.
+//
+// Поддерживается подмножество: абзацы, заголовки, списки, цитаты, блоки кода,
+// горизонтальная черта, **жирный**, *курсив*, ~~зачёркнутый~~, `код` и ссылки.
+// Весь исходный текст экранируется, поэтому в результате есть только теги,
+// которые ставит сам пакет, а ссылки допускаются лишь со схемами http, https и mailto.
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// placeholder отмечает в тексте уже готовый HTML (код, ссылки) на время разбора
+const placeholder = "\x00"
+
+var (
+	headingRe   = regexp.MustCompile(`^(#{1,6})\s+(.*?)(?:\s+#+)?\s*$`)
+	ulItemRe    = regexp.MustCompile(`^\s{0,3}[-*+]\s+(.*)$`)
+	olItemRe    = regexp.MustCompile(`^\s{0,3}\d{1,9}[.)]\s+(.*)$`)
+	hrRe        = regexp.MustCompile(`^\s{0,3}([-*_])(\s*[-*_]){2,}\s*$`)
+	quoteRe     = regexp.MustCompile(`^\s{0,3}>\s?(.*)$`)
+	fenceRe     = regexp.MustCompile("^\\s{0,3}```\\s*([A-Za-z0-9_+-]*)\\s*$")
+	codeSpanRe  = regexp.MustCompile("`([^`]+)`")
+	linkRe      = regexp.MustCompile(`\[([^\[\]]+)\]\(([^()\s]+)\)`)
+	autolinkRe  = regexp.MustCompile(`https?://[^\s<>"'\x00]+[^\s<>"'\x00.,;:!?)]`)
+	strongRe    = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*`)
+	strikeRe    = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+	emStarRe    = regexp.MustCompile(`\*(\S(?:.*?\S)?)\*`)
+	emUnderRe   = regexp.MustCompile(`(^|[^\p{L}\p{N}_])_(\S(?:.*?\S)?)_($|[^\p{L}\p{N}_])`)
+	storedRe    = regexp.MustCompile(placeholder + `(\d+)` + placeholder)
+	mentionRe   = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@./])@([A-Za-z0-9]{3,50})\b`)
+	fencedBlkRe = regexp.MustCompile("(?ms)^\\s{0,3}```.*?(^\\s{0,3}```\\s*$|\\z)")
+)
+
+// Render переводит Markdown в безопасный HTML
+func Render(src string) string {
+	lines := strings.Split(normalize(src), "\n")
+	var b strings.Builder
+	renderBlocks(&b, lines)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Mentions возвращает упомянутые через @username имена в порядке появления, без
+// повторов. Упоминания внутри кода и адресов электронной почты не учитываются.
+func Mentions(src string) []string {
+	text := fencedBlkRe.ReplaceAllString(normalize(src), "")
+	text = codeSpanRe.ReplaceAllString(text, " ")
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, m := range mentionRe.FindAllStringSubmatch(text, -1) {
+		key := strings.ToLower(m[1])
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		names = append(names, m[1])
+	}
+	return names
+}
+
+func normalize(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+	return strings.ReplaceAll(src, placeholder, "")
+}
+
+func renderBlocks(b *strings.Builder, lines []string) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+
+		case fenceRe.MatchString(line):
+			lang := fenceRe.FindStringSubmatch(line)[1]
+			i++
+			start := i
+			for i < len(lines) && !isFenceEnd(lines[i]) {
+				i++
+			}
+			code := strings.Join(lines[start:i], "\n")
+			if i < len(lines) {
+				i++ // закрывающая ```
+			}
+			if lang != "" {
+				fmt.Fprintf(b, "<pre><code class=\"language-%s\">", lang)
+			} else {
+				b.WriteString("<pre><code>")
+			}
+			if code != "" {
+				b.WriteString(html.EscapeString(code))
+				b.WriteString("\n")
+			}
+			b.WriteString("</code></pre>\n")
+
+		case headingRe.MatchString(line):
+			m := headingRe.FindStringSubmatch(line)
+			level := len(m[1])
+			fmt.Fprintf(b, "<h%d>%s</h%d>\n", level, renderInline(m[2]), level)
+			i++
+
+		case hrRe.MatchString(line):
+			b.WriteString("<hr>\n")
+			i++
+
+		case quoteRe.MatchString(line):
+			var quoted []string
+			for i < len(lines) && quoteRe.MatchString(lines[i]) {
+				quoted = append(quoted, quoteRe.FindStringSubmatch(lines[i])[1])
+				i++
+			}
+			b.WriteString("<blockquote>\n")
+			renderBlocks(b, quoted)
+			b.WriteString("</blockquote>\n")
+
+		case ulItemRe.MatchString(line):
+			i = renderList(b, lines, i, ulItemRe, "ul")
+
+		case olItemRe.MatchString(line):
+			i = renderList(b, lines, i, olItemRe, "ol")
+
+		default:
+			var para []string
+			for i < len(lines) && strings.TrimSpace(lines[i]) != "" && !startsBlock(lines[i]) {
+				para = append(para, renderInline(strings.TrimSpace(lines[i])))
+				i++
+			}
+			b.WriteString("<p>")
+			b.WriteString(strings.Join(para, "<br>\n"))
+			b.WriteString("</p>\n")
+		}
+	}
+}
+
+// renderList выводит подряд идущие пункты списка и возвращает индекс следующей строки.
+// Строки без маркера продолжают текст предыдущего пункта.
+func renderList(b *strings.Builder, lines []string, i int, itemRe *regexp.Regexp, tag string) int {
+	var items [][]string
+	for i < len(lines) && strings.TrimSpace(lines[i]) != "" {
+		if m := itemRe.FindStringSubmatch(lines[i]); m != nil {
+			items = append(items, []string{m[1]})
+		} else if startsBlock(lines[i]) {
+			break
+		} else {
+			items[len(items)-1] = append(items[len(items)-1], strings.TrimSpace(lines[i]))
+		}
+		i++
+	}
+
+	fmt.Fprintf(b, "<%s>\n", tag)
+	for _, item := range items {
+		rendered := make([]string, len(item))
+		for j, text := range item {
+			rendered[j] = renderInline(text)
+		}
+		fmt.Fprintf(b, "<li>%s</li>\n", strings.Join(rendered, "<br>\n"))
+	}
+	fmt.Fprintf(b, "</%s>\n", tag)
+	return i
+}
+
+func startsBlock(line string) bool {
+	return fenceRe.MatchString(line) || headingRe.MatchString(line) || hrRe.MatchString(line) ||
+		quoteRe.MatchString(line) || ulItemRe.MatchString(line) || olItemRe.MatchString(line)
+}
+
+func isFenceEnd(line string) bool {
+	return strings.TrimSpace(line) == "```"
+}
+
+// renderInline оформляет строку: код и ссылки сразу превращаются в HTML и
+// прячутся за метками, чтобы разметка выделения не попала внутрь них
+func renderInline(text string) string {
+	var stored []string
+	store := func(s string) string {
+		stored = append(stored, s)
+		return placeholder + strconv.Itoa(len(stored)-1) + placeholder
+	}
+
+	parts := codeSpanRe.FindAllStringSubmatchIndex(text, -1)
+	var b strings.Builder
+	last := 0
+	for _, p := range parts {
+		b.WriteString(html.EscapeString(text[last:p[0]]))
+		b.WriteString(store("<code>" + html.EscapeString(text[p[2]:p[3]]) + "</code>"))
+		last = p[1]
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	out := b.String()
+
+	out = linkRe.ReplaceAllStringFunc(out, func(m string) string {
+		sub := linkRe.FindStringSubmatch(m)
+		href, ok := safeURL(sub[2])
+		if !ok {
+			return m
+		}
+		return store(`<a href="` + href + `" rel="nofollow noopener noreferrer">` + emphasize(sub[1]) + "</a>")
+	})
+	out = autolinkRe.ReplaceAllStringFunc(out, func(m string) string {
+		href, ok := safeURL(m)
+		if !ok {
+			return m
+		}
+		return store(`<a href="` + href + `" rel="nofollow noopener noreferrer">` + m + "</a>")
+	})
+	out = emphasize(out)
+
+	// ссылки могут содержать код, поэтому метки раскрываются до неподвижной точки
+	for storedRe.MatchString(out) {
+		out = storedRe.ReplaceAllStringFunc(out, func(m string) string {
+			n, _ := strconv.Atoi(strings.Trim(m, placeholder))
+			return stored[n]
+		})
+	}
+	return out
+}
+
+func emphasize(s string) string {
+	s = strongRe.ReplaceAllString(s, "<strong>$1</strong>")
+	s = strikeRe.ReplaceAllString(s, "<del>$1</del>")
+	s = emStarRe.ReplaceAllString(s, "<em>$1</em>")
+	return emUnderRe.ReplaceAllString(s, "$1<em>$2</em>$3")
+}
+
+// safeURL проверяет схему уже экранированного адреса
+func safeURL(escaped string) (string, bool) {
+	raw := strings.ToLower(strings.TrimSpace(html.UnescapeString(escaped)))
+	for _, scheme := range []string{"http://", "https://", "mailto:"} {
+		if strings.HasPrefix(raw, scheme) {
+			return escaped, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,94 @@
+package markdown_test
+
+import (
+	"github.com/melnik-dev/go_todo_jwt/pkg/markdown"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "paragraphs and line breaks",
+			src:  "first line\nsecond line\n\nnext",
+			want: "<p>first line<br>\nsecond line</p>\n<p>next</p>",
+		},
+		{
+			name: "emphasis",
+			src:  "**bold** *em* _also em_ ~~gone~~ snake_case_name",
+			want: "<p><strong>bold</strong> <em>em</em> <em>also em</em> <del>gone</del> snake_case_name</p>",
+		},
+		{
+			name: "heading",
+			src:  "## Plan for C# ##",
+			want: "<h2>Plan for C#</h2>",
+		},
+		{
+			name: "lists",
+			src:  "- one\n- two\n\n1. first\n2. second",
+			want: "<ul>\n<li>one</li>\n<li>two</li>\n</ul>\n<ol>\n<li>first</li>\n<li>second</li>\n</ol>",
+		},
+		{
+			name: "quote",
+			src:  "> quoted *text*",
+			want: "<blockquote>\n<p>quoted <em>text</em></p>\n</blockquote>",
+		},
+		{
+			name: "code",
+			src:  "use `a*b*c` here\n```go\nif a < b {}\n```",
+			want: "<p>use <code>a*b*c</code> here</p>\n<pre><code class=\"language-go\">if a &lt; b {}\n</code></pre>",
+		},
+		{
+			name: "links",
+			src:  "[docs](https://example.com/a_b_c?x=1&y=2) and https://example.com/path.",
+			want: `<p><a href="https://example.com/a_b_c?x=1&amp;y=2" rel="nofollow noopener noreferrer">docs</a> and ` +
+				`<a href="https://example.com/path" rel="nofollow noopener noreferrer">https://example.com/path</a>.</p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := markdown.Render(tt.src); got != tt.want {
+				t.Errorf("Render(%q)\n got: %q\nwant: %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender_Sanitizes(t *testing.T) {
+	inputs := []string{
+		`<script>alert(1)</script>`,
+		`<img src=x onerror=alert(1)>`,
+		`[click](javascript:alert(1))`,
+		`[click](JavaScript&#58;alert(1))`,
+		`[x](https://example.com/"onmouseover="alert(1))`,
+		"```\n</code></pre><script>alert(1)</script>\n```",
+		"text \x00 0\x00 placeholder",
+	}
+
+	for _, src := range inputs {
+		got := markdown.Render(src)
+		for _, bad := range []string{"<script", "<img", `href="javascript`, `href="JavaScript`, `" onmouseover`, "\x00"} {
+			if strings.Contains(got, bad) {
+				t.Errorf("Render(%q) = %q contains %q", src, got, bad)
+			}
+		}
+	}
+}
+
+func TestMentions(t *testing.T) {
+	src := "@alice please check with @Bob and @alice.\n" +
+		"Mail me at carol@example.com, not `@dave`.\n" +
+		"```\n@erin\n```\n" +
+		"@ab is too short"
+
+	want := []string{"alice", "Bob"}
+	if got := markdown.Mentions(src); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
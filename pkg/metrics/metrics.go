@@ -0,0 +1,114 @@
+// Package metrics собирает метрики Prometheus: HTTP-запросы, запросы к базе,
+// пул соединений, исходы входа и версию сборки.
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "todo"
+
+// unmatchedRoute - маршрут запросов, не попавших ни в один обработчик;
+// сырой путь в метку не пишется, чтобы не раздувать число рядов
+const unmatchedRoute = "unmatched"
+
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	dbDuration   *prometheus.HistogramVec
+	dbErrors     *prometheus.CounterVec
+	logins       *prometheus.CounterVec
+}
+
+func New(app configs.ConfApp) *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route template and status.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route template and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		dbDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Database query duration by repository method.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"repository", "method"}),
+		dbErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "db_query_errors_total",
+			Help:      "Failed database queries by repository method.",
+		}, []string{"repository", "method"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "auth_login_attempts_total",
+			Help:      "Login attempts by outcome: success, failure or lockout.",
+		}, []string{"outcome"}),
+	}
+
+	buildInfo := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "build_info",
+		Help:      "Application build information; always 1.",
+	}, []string{"name", "version", "env", "goversion"})
+	buildInfo.WithLabelValues(app.Name, app.Version, app.Env, runtime.Version()).Set(1)
+
+	m.Registry.MustRegister(
+		m.httpRequests, m.httpDuration, m.dbDuration, m.dbErrors, m.logins, buildInfo,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// RegisterDB добавляет статистику пула соединений sql.DB.Stats() с меткой db_name
+func (m *Metrics) RegisterDB(db *sql.DB, name string) {
+	m.Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// Middleware считает запросы и их длительность. Маршрут берётся по шаблону
+// (/tasks/:id), а не по пути, чтобы число рядов не зависело от идентификаторов
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		m.httpRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		m.httpDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveQuery реализует db.QueryObserver
+func (m *Metrics) ObserveQuery(repository, method string, duration time.Duration, err error) {
+	m.dbDuration.WithLabelValues(repository, method).Observe(duration.Seconds())
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		m.dbErrors.WithLabelValues(repository, method).Inc()
+	}
+}
+
+// LoginAttempt реализует auth.Metrics
+func (m *Metrics) LoginAttempt(outcome string) {
+	m.logins.WithLabelValues(outcome).Inc()
+}
@@ -0,0 +1,72 @@
+package metrics_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/pkg/metrics"
+)
+
+func scrape(t *testing.T, handler http.Handler, token string) (int, string) {
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	body, _ := io.ReadAll(w.Body)
+	return w.Code, string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	m := metrics.New(configs.ConfApp{Name: "todo_jwt", Version: "1.2.3", Env: "test"})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(m.Middleware())
+	r.GET("/tasks/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	for _, path := range []string{"/tasks/1", "/tasks/2", "/missing"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	m.ObserveQuery("task", "GetAll", 3*time.Millisecond, nil)
+	m.ObserveQuery("task", "GetAll", time.Millisecond, errors.New("connection reset"))
+	m.LoginAttempt("lockout")
+
+	code, body := scrape(t, m.Handler(""), "")
+	if code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, code)
+	}
+	for _, line := range []string{
+		`todo_http_requests_total{method="GET",route="/tasks/:id",status="200"} 2`,
+		`todo_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`todo_db_query_duration_seconds_count{method="GetAll",repository="task"} 2`,
+		`todo_db_query_errors_total{method="GetAll",repository="task"} 1`,
+		`todo_auth_login_attempts_total{outcome="lockout"} 1`,
+		`version="1.2.3"`,
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("expected %s in metrics", line)
+		}
+	}
+}
+
+func TestMetrics_Handler_Token(t *testing.T) {
+	handler := metrics.New(configs.ConfApp{}).Handler("secret")
+
+	if code, _ := scrape(t, handler, ""); code != http.StatusUnauthorized {
+		t.Errorf("expected %d without token, got %d", http.StatusUnauthorized, code)
+	}
+	if code, _ := scrape(t, handler, "wrong"); code != http.StatusUnauthorized {
+		t.Errorf("expected %d for wrong token, got %d", http.StatusUnauthorized, code)
+	}
+	if code, _ := scrape(t, handler, "secret"); code != http.StatusOK {
+		t.Errorf("expected %d with token, got %d", http.StatusOK, code)
+	}
+}
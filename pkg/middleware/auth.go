@@ -9,6 +9,9 @@ import (
 	"strings"
 )
 
+// TokenWorkspaceKey - ключ контекста gin с рабочим пространством из JWT
+const TokenWorkspaceKey = "token_workspace_id"
+
 func IsAuthed(jwtSecret string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		auLogger := logger.FromContext(c)
@@ -30,6 +33,9 @@ func IsAuthed(jwtSecret string) gin.HandlerFunc {
 
 		auLogger.WithField("user_id", data.UserId).Debug("User authenticated successfully")
 		c.Set("user_id", data.UserId)
+		if data.WorkspaceID > 0 {
+			c.Set(TokenWorkspaceKey, data.WorkspaceID)
+		}
 
 		c.Next()
 	}
@@ -19,13 +19,14 @@ func Logger() gin.HandlerFunc {
 			requestID = "no-request-id" // Fallback
 		}
 
-		requestLogger := logger.GetLogger().WithFields(logrus.Fields{
+		// trace_id и span_id есть, если раньше отработал middleware трассировки
+		requestLogger := logger.WithTrace(logger.GetLogger().WithFields(logrus.Fields{
 			"request_id": requestID,
 			"method":     c.Request.Method,
 			"path":       path,
 			"ip":         c.ClientIP(),
 			"user_agent": c.Request.UserAgent(),
-		})
+		}), c.Request.Context())
 
 		c.Set("logger", requestLogger)
 
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout ставит срок контексту запроса: запросы к базе, выполняемые с ним,
+// прерываются, когда срок истекает. Маршруты skip (длительные потоки) не ограничиваются
+func Timeout(timeout time.Duration, skip ...string) gin.HandlerFunc {
+	skipped := make(map[string]bool, len(skip))
+	for _, route := range skip {
+		skipped[route] = true
+	}
+
+	return func(c *gin.Context) {
+		if timeout <= 0 || skipped[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,375 @@
+// Package migrate применяет нумерованные SQL-миграции к Postgres и SQLite.
+// Применённые версии с контрольными суммами хранятся в schema_migrations,
+// одновременный запуск с нескольких экземпляров Postgres исключает advisory lock.
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/melnik-dev/go_todo_jwt/pkg/db"
+	"github.com/sirupsen/logrus"
+)
+
+// lockKey - ключ pg_advisory_lock, общий для всех экземпляров приложения
+const lockKey int64 = 4242_0042
+
+const (
+	StateApplied  = "applied"
+	StatePending  = "pending"
+	StateModified = "modified"
+	StateMissing  = "missing"
+)
+
+var (
+	ErrChecksumMismatch = errors.New("applied migration was modified")
+	ErrMissingMigration = errors.New("applied migration is missing in this build")
+	ErrUnknownVersion   = errors.New("unknown migration version")
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+	// Checksum - sha256 текста up; по ней находятся изменённые после применения миграции
+	Checksum string
+}
+
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	State     string     `json:"state"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+type applied struct {
+	Version   int64     `db:"version"`
+	Name      string    `db:"name"`
+	Checksum  string    `db:"checksum"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+	logger     *logrus.Logger
+}
+
+func New(db *sqlx.DB, fsys fs.FS, logger *logrus.Logger) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations, logger: logger}, nil
+}
+
+// Load читает миграции из корня fsys и сортирует их по версии.
+// У каждой версии должен быть up-файл; down-файл необязателен
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: invalid version", entry.Name())
+		}
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d: names %q and %q differ", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+			sum := sha256.Sum256(body)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d: up file is missing", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	return migrations, nil
+}
+
+// Latest - номер последней известной миграции, 0 если их нет
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version возвращает последнюю применённую миграцию без блокировки, 0 если их нет
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	var version int64
+	query := `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`
+	if err := m.db.GetContext(ctx, &version, query); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// Check - проверка готовности: схема базы совпадает с последней миграцией сборки
+func (m *Migrator) Check(ctx context.Context) error {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if version != m.Latest() {
+		return fmt.Errorf("schema version %d, expected %d", version, m.Latest())
+	}
+	return nil
+}
+
+// Up применяет все непримененные миграции и возвращает их количество
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	return m.Goto(ctx, m.Latest())
+}
+
+// Down откатывает steps последних применённых миграций
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	if steps <= 0 {
+		return 0, nil
+	}
+	count := 0
+	err := m.locked(ctx, func(conn *sqlx.Conn, done map[int64]applied) error {
+		if err := m.verify(done); err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && count < steps; i-- {
+			if _, ok := done[m.migrations[i].Version]; !ok {
+				continue
+			}
+			if err := m.rollback(ctx, conn, &m.migrations[i]); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Goto приводит схему к версии version: откатывает применённые миграции новее
+// неё и применяет непримененные до неё включительно. Goto(0) откатывает всё
+func (m *Migrator) Goto(ctx context.Context, version int64) (int, error) {
+	if version != 0 && !slices.ContainsFunc(m.migrations, func(mig Migration) bool { return mig.Version == version }) {
+		return 0, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+	count := 0
+	err := m.locked(ctx, func(conn *sqlx.Conn, done map[int64]applied) error {
+		if err := m.verify(done); err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := &m.migrations[i]
+			if _, ok := done[mig.Version]; !ok || mig.Version <= version {
+				continue
+			}
+			if err := m.rollback(ctx, conn, mig); err != nil {
+				return err
+			}
+			count++
+		}
+		for i := range m.migrations {
+			mig := &m.migrations[i]
+			if _, ok := done[mig.Version]; ok || mig.Version > version {
+				continue
+			}
+			if err := m.apply(ctx, conn, mig); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Status возвращает состояние всех известных и применённых миграций по версиям
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.locked(ctx, func(conn *sqlx.Conn, done map[int64]applied) error {
+		for _, mig := range m.migrations {
+			status := Status{Version: mig.Version, Name: mig.Name, State: StatePending}
+			if a, ok := done[mig.Version]; ok {
+				status.State = StateApplied
+				if a.Checksum != mig.Checksum {
+					status.State = StateModified
+				}
+				status.AppliedAt = &a.AppliedAt
+				delete(done, mig.Version)
+			}
+			statuses = append(statuses, status)
+		}
+		for _, a := range done {
+			statuses = append(statuses, Status{Version: a.Version, Name: a.Name, State: StateMissing, AppliedAt: &a.AppliedAt})
+		}
+		return nil
+	})
+	slices.SortFunc(statuses, func(a, b Status) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	return statuses, err
+}
+
+// locked выполняет fn на отдельном соединении под advisory lock, передавая
+// применённые миграции по версиям
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sqlx.Conn, done map[int64]applied) error) error {
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	query := `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		checksum TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`
+
+	// файл SQLite открывает один процесс, а записи и так идут по очереди
+	if m.db.DriverName() == db.DriverSQLite {
+		query = `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`
+	} else {
+		if _, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+			return err
+		}
+		defer func() {
+			// контекст мог быть отменён, а блокировку нужно снять в любом случае
+			if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockKey); err != nil {
+				m.logger.WithError(err).Error("Failed to release migration lock")
+			}
+		}()
+	}
+
+	if _, err = conn.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	var rows []applied
+	query = `SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version`
+	if err = sqlx.SelectContext(ctx, conn, &rows, query); err != nil {
+		return err
+	}
+	done := make(map[int64]applied, len(rows))
+	for _, a := range rows {
+		done[a.Version] = a
+	}
+	return fn(conn, done)
+}
+
+// verify отказывает, если применённая миграция изменена или отсутствует в сборке:
+// тогда схема базы не соответствует файлам и применять что-то поверх небезопасно
+func (m *Migrator) verify(done map[int64]applied) error {
+	known := make(map[int64]bool, len(m.migrations))
+	for _, mig := range m.migrations {
+		known[mig.Version] = true
+		if a, ok := done[mig.Version]; ok && a.Checksum != mig.Checksum {
+			return fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, mig.Version, mig.Name)
+		}
+	}
+	for version, a := range done {
+		if !known[version] {
+			return fmt.Errorf("%w: %d_%s", ErrMissingMigration, version, a.Name)
+		}
+	}
+	return nil
+}
+
+// apply выполняет up-миграцию и её запись в schema_migrations в одной транзакции
+func (m *Migrator) apply(ctx context.Context, conn *sqlx.Conn, mig *Migration) error {
+	logMig := m.logger.WithFields(logrus.Fields{"version": mig.Version, "name": mig.Name})
+	logMig.Info("Applying migration")
+
+	err := inTx(ctx, conn, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, mig.Up); err != nil {
+			return err
+		}
+		query := `INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`
+		_, err := tx.ExecContext(ctx, query, mig.Version, mig.Name, mig.Checksum)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("migration %d_%s up: %w", mig.Version, mig.Name, err)
+	}
+	return nil
+}
+
+// rollback выполняет down-миграцию и удаляет её запись в одной транзакции
+func (m *Migrator) rollback(ctx context.Context, conn *sqlx.Conn, mig *Migration) error {
+	if mig.Down == "" {
+		return fmt.Errorf("migration %d_%s: down file is missing", mig.Version, mig.Name)
+	}
+	logMig := m.logger.WithFields(logrus.Fields{"version": mig.Version, "name": mig.Name})
+	logMig.Info("Rolling back migration")
+
+	err := inTx(ctx, conn, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, mig.Down); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, mig.Version)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("migration %d_%s down: %w", mig.Version, mig.Name, err)
+	}
+	return nil
+}
+
+func inTx(ctx context.Context, conn *sqlx.Conn, fn func(tx *sqlx.Tx) error) error {
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
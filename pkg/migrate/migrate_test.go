@@ -0,0 +1,248 @@
+package migrate_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/melnik-dev/go_todo_jwt/pkg/migrate"
+	"github.com/sirupsen/logrus"
+)
+
+var files = fstest.MapFS{
+	"0001_init.up.sql":     {Data: []byte("CREATE TABLE a (id INT);")},
+	"0001_init.down.sql":   {Data: []byte("DROP TABLE a;")},
+	"0002_second.up.sql":   {Data: []byte("CREATE TABLE b (id INT);")},
+	"0002_second.down.sql": {Data: []byte("DROP TABLE b;")},
+	"README.md":            {Data: []byte("not a migration")},
+}
+
+func checksum(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
+
+func mockMigrator(t *testing.T) (*migrate.Migrator, sqlmock.Sqlmock) {
+	mockDb, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	migrator, err := migrate.New(sqlx.NewDb(mockDb, "sqlMock"), files, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return migrator, mock
+}
+
+// expectLocked ожидает захват блокировки и чтение применённых миграций
+func expectLocked(mock sqlmock.Sqlmock, applied *sqlmock.Rows) {
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_lock($1)`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`CREATE TABLE IF NOT EXISTS schema_migrations`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version`)).
+		WillReturnRows(applied)
+}
+
+func expectUnlocked(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_unlock($1)`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func appliedRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"version", "name", "checksum", "applied_at"})
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := migrate.Load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 1 || migrations[1].Name != "second" {
+		t.Fatalf("unexpected migrations %+v", migrations)
+	}
+	if migrations[0].Checksum != checksum("CREATE TABLE a (id INT);") || migrations[0].Down != "DROP TABLE a;" {
+		t.Errorf("unexpected migration %+v", migrations[0])
+	}
+
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{name: "without up", files: fstest.MapFS{"0001_init.down.sql": {}}},
+		{name: "names differ", files: fstest.MapFS{"0001_init.up.sql": {}, "0001_other.down.sql": {}}},
+		{name: "zero version", files: fstest.MapFS{"0000_init.up.sql": {}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := migrate.Load(tt.files); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestMigrator_Up(t *testing.T) {
+	migrator, mock := mockMigrator(t)
+
+	expectLocked(mock, appliedRows().AddRow(1, "init", checksum("CREATE TABLE a (id INT);"), time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`CREATE TABLE b (id INT);`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`)).
+		WithArgs(2, "second", checksum("CREATE TABLE b (id INT);")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectUnlocked(mock)
+
+	count, err := migrator.Up(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 migration, got %d", count)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrator_Up_Failed(t *testing.T) {
+	migrator, mock := mockMigrator(t)
+
+	expectLocked(mock, appliedRows())
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`CREATE TABLE a (id INT);`)).
+		WillReturnError(errors.New("syntax error"))
+	mock.ExpectRollback()
+	expectUnlocked(mock)
+
+	if _, err := migrator.Up(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrator_ChecksumMismatch(t *testing.T) {
+	migrator, mock := mockMigrator(t)
+
+	expectLocked(mock, appliedRows().AddRow(1, "init", checksum("edited"), time.Now()))
+	expectUnlocked(mock)
+
+	if _, err := migrator.Up(context.Background()); !errors.Is(err, migrate.ErrChecksumMismatch) {
+		t.Fatalf("expected %v, got %v", migrate.ErrChecksumMismatch, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrator_MissingMigration(t *testing.T) {
+	migrator, mock := mockMigrator(t)
+
+	expectLocked(mock, appliedRows().AddRow(3, "newer", "x", time.Now()))
+	expectUnlocked(mock)
+
+	if _, err := migrator.Down(context.Background(), 1); !errors.Is(err, migrate.ErrMissingMigration) {
+		t.Fatalf("expected %v, got %v", migrate.ErrMissingMigration, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrator_Goto(t *testing.T) {
+	migrator, mock := mockMigrator(t)
+
+	expectLocked(mock, appliedRows().
+		AddRow(1, "init", checksum("CREATE TABLE a (id INT);"), time.Now()).
+		AddRow(2, "second", checksum("CREATE TABLE b (id INT);"), time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DROP TABLE b;`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM schema_migrations WHERE version = $1`)).
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectUnlocked(mock)
+
+	count, err := migrator.Goto(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 migration, got %d", count)
+	}
+
+	if _, err = migrator.Goto(context.Background(), 5); !errors.Is(err, migrate.ErrUnknownVersion) {
+		t.Errorf("expected %v, got %v", migrate.ErrUnknownVersion, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrator_Status(t *testing.T) {
+	migrator, mock := mockMigrator(t)
+
+	expectLocked(mock, appliedRows().
+		AddRow(1, "init", checksum("edited"), time.Now()).
+		AddRow(3, "newer", "x", time.Now()))
+	expectUnlocked(mock)
+
+	statuses, err := migrator.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{migrate.StateModified, migrate.StatePending, migrate.StateMissing}
+	if len(statuses) != len(want) {
+		t.Fatalf("expected %d statuses, got %+v", len(want), statuses)
+	}
+	for i, state := range want {
+		if statuses[i].State != state || statuses[i].Version != int64(i+1) {
+			t.Errorf("status %d: expected %s, got %+v", i, state, statuses[i])
+		}
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrator_Check(t *testing.T) {
+	migrator, mock := mockMigrator(t)
+
+	query := regexp.QuoteMeta(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`)
+	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+
+	if err := migrator.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Check(context.Background()); err == nil {
+		t.Fatal("expected error for outdated schema")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,11 +1,17 @@
 package response
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
+// StatusClientClosedRequest - нестандартный код nginx для запроса, который
+// клиент оборвал, не дождавшись ответа
+const StatusClientClosedRequest = 499
+
 type Response struct {
 	Status  int         `json:"status"`
 	Message string      `json:"message,omitempty"`
@@ -21,6 +27,7 @@ func Success(c *gin.Context, status int, data interface{}) {
 }
 
 func Error(c *gin.Context, status int, message string) {
+	status, message = canceled(c, status, message)
 	c.JSON(status, Response{
 		Status: status,
 		Error:  message,
@@ -28,6 +35,7 @@ func Error(c *gin.Context, status int, message string) {
 }
 
 func AbortWithStatus(c *gin.Context, status int, message string) {
+	status, message = canceled(c, status, message)
 	c.AbortWithStatusJSON(status, Response{
 		Status: status,
 		Error:  message,
@@ -42,6 +50,10 @@ func Unauthorized(c *gin.Context, message string) {
 	Error(c, http.StatusUnauthorized, message)
 }
 
+func Forbidden(c *gin.Context, message string) {
+	Error(c, http.StatusForbidden, message)
+}
+
 func NotFound(c *gin.Context, message string) {
 	Error(c, http.StatusNotFound, message)
 }
@@ -49,3 +61,18 @@ func NotFound(c *gin.Context, message string) {
 func InternalServerError(c *gin.Context, message string) {
 	Error(c, http.StatusInternalServerError, message)
 }
+
+// canceled заменяет 500 на 499, если клиент оборвал запрос, и на 503, если истекло
+// время запроса: ошибка тогда вызвана отменой контекста, а не сбоем сервера
+func canceled(c *gin.Context, status int, message string) (int, string) {
+	if status != http.StatusInternalServerError {
+		return status, message
+	}
+	switch err := c.Request.Context().Err(); {
+	case errors.Is(err, context.Canceled):
+		return StatusClientClosedRequest, "Request canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusServiceUnavailable, "Request timed out"
+	}
+	return status, message
+}
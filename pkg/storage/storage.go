@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/melnik-dev/go_todo_jwt/configs"
+)
+
+var (
+	ErrNotFound   = errors.New("object not found")
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// Storage хранит содержимое файлов по ключу вида "tasks/1/abc".
+// Метаданные (имя, тип, размер) хранятся в БД, а не в хранилище.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// New создаёт хранилище, выбранное в attachments.storage
+func New(cfg configs.ConfAttachments) (Storage, error) {
+	switch cfg.Storage {
+	case "", "local":
+		return NewLocal(cfg.Dir)
+	case "s3":
+		return NewS3(cfg.S3), nil
+	default:
+		return nil, fmt.Errorf("unknown storage %q", cfg.Storage)
+	}
+}
+
+// validKey не пропускает пустые, абсолютные и выходящие за корень ключи
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || path.Clean(key) != key || key == ".." || strings.HasPrefix(key, "../") {
+		return ErrInvalidKey
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+// Package todotxt читает и пишет строки в формате todo.txt
+// (https://github.com/todotxt/todo.txt).
+//
+// Метки +project, @context и key:value выносятся из текста в отдельные поля.
+// Если в строке они стояли не в конце, исходный порядок слов сохраняется
+// в Task.Body, и при записи строка собирается из него.
+package todotxt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	ContentType = "text/plain; charset=utf-8"
+
+	dateLayout = "2006-01-02"
+	// priorityKey - общепринятая метка приоритета для выполненных задач,
+	// у которых приоритет в начале строки не пишется
+	priorityKey = "pri"
+)
+
+var ErrEmptyLine = errors.New("empty todo.txt line")
+
+// Tag - расширение вида key:value
+type Tag struct {
+	Key   string
+	Value string
+}
+
+type Task struct {
+	Completed      bool
+	Priority       string // "A".."Z" или пусто
+	CompletionDate *time.Time
+	CreationDate   *time.Time
+	Text           string
+	Projects       []string
+	Contexts       []string
+	Tags           []Tag
+	// Body - текст вместе с метками в исходном порядке. Parse заполняет его,
+	// только если метки стояли не в конце строки; String пишет Body, пока
+	// в нём те же текст и метки, что в остальных полях.
+	Body string
+}
+
+// Tag возвращает значение первой метки с ключом key
+func (t *Task) Tag(key string) (string, bool) {
+	for _, tag := range t.Tags {
+		if tag.Key == key {
+			return tag.Value, true
+		}
+	}
+	return "", false
+}
+
+// Parse разбирает одну строку todo.txt
+func Parse(line string) (Task, error) {
+	var task Task
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return task, ErrEmptyLine
+	}
+
+	if fields[0] == "x" {
+		task.Completed = true
+		fields = fields[1:]
+	}
+	if !task.Completed && len(fields) > 0 && isPriority(fields[0]) {
+		task.Priority = fields[0][1:2]
+		fields = fields[1:]
+	}
+
+	// у выполненной задачи первая дата - дата выполнения, вторая - создания
+	if date, ok := parseDate(fields); ok {
+		fields = fields[1:]
+		if task.Completed {
+			task.CompletionDate = &date
+			if created, ok := parseDate(fields); ok {
+				task.CreationDate = &created
+				fields = fields[1:]
+			}
+		} else {
+			task.CreationDate = &date
+		}
+	}
+
+	body := parseBody(fields, task.Completed)
+	task.Text = body.Text
+	task.Projects = body.Projects
+	task.Contexts = body.Contexts
+	task.Tags = body.Tags
+	if body.Priority != "" {
+		task.Priority = body.Priority
+	}
+	if raw := strings.Join(fields, " "); raw != task.body() {
+		task.Body = raw
+	}
+
+	return task, nil
+}
+
+// String собирает строку todo.txt. Дата создания у выполненной задачи пишется
+// только вместе с датой выполнения, иначе её прочитают как дату выполнения.
+func (t Task) String() string {
+	var parts []string
+
+	if t.Completed {
+		parts = append(parts, "x")
+		if t.CompletionDate != nil {
+			parts = append(parts, t.CompletionDate.Format(dateLayout))
+			if t.CreationDate != nil {
+				parts = append(parts, t.CreationDate.Format(dateLayout))
+			}
+		}
+	} else {
+		if t.Priority != "" {
+			parts = append(parts, "("+t.Priority+")")
+		}
+		if t.CreationDate != nil {
+			parts = append(parts, t.CreationDate.Format(dateLayout))
+		}
+	}
+
+	if body := t.body(); body != "" {
+		parts = append(parts, body)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// body возвращает описание после приоритета и дат: Body, если он ещё
+// соответствует полям, иначе текст и метки по группам
+func (t Task) body() string {
+	if t.Body != "" && t.matchesBody() {
+		return strings.Join(strings.Fields(t.Body), " ")
+	}
+
+	var parts []string
+	if text := strings.Join(strings.Fields(t.Text), " "); text != "" {
+		parts = append(parts, text)
+	}
+	for _, project := range t.Projects {
+		parts = append(parts, "+"+project)
+	}
+	for _, context := range t.Contexts {
+		parts = append(parts, "@"+context)
+	}
+	for _, tag := range t.Tags {
+		parts = append(parts, tag.Key+":"+tag.Value)
+	}
+	if t.Completed && t.Priority != "" {
+		parts = append(parts, priorityKey+":"+t.Priority)
+	}
+	return strings.Join(parts, " ")
+}
+
+// matchesBody проверяет, что Body не разошёлся с полями после правок:
+// порядок меток внутри групп не важен, важен их состав
+func (t Task) matchesBody() bool {
+	body := parseBody(strings.Fields(t.Body), t.Completed)
+	if body.Text != strings.Join(strings.Fields(t.Text), " ") {
+		return false
+	}
+	if t.Completed && body.Priority != t.Priority {
+		return false
+	}
+	tags := func(tags []Tag) []string {
+		out := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			out = append(out, tag.Key+":"+tag.Value)
+		}
+		return out
+	}
+	return sameItems(body.Projects, t.Projects) &&
+		sameItems(body.Contexts, t.Contexts) &&
+		sameItems(tags(body.Tags), tags(t.Tags))
+}
+
+// parseBody разбирает описание после приоритета и дат. У выполненной задачи
+// первая метка pri:X становится приоритетом.
+func parseBody(fields []string, completed bool) Task {
+	var body Task
+	var text []string
+	for _, field := range fields {
+		switch {
+		case isMarker(field, '+'):
+			body.Projects = append(body.Projects, field[1:])
+		case isMarker(field, '@'):
+			body.Contexts = append(body.Contexts, field[1:])
+		default:
+			if tag, ok := parseTag(field); ok {
+				if completed && tag.Key == priorityKey && body.Priority == "" && isPriorityLetter(tag.Value) {
+					body.Priority = tag.Value
+					continue
+				}
+				body.Tags = append(body.Tags, tag)
+				continue
+			}
+			text = append(text, field)
+		}
+	}
+	body.Text = strings.Join(text, " ")
+	return body
+}
+
+// Scanner читает задачи построчно, пропуская пустые строки
+type Scanner struct {
+	s    *bufio.Scanner
+	line int
+	task Task
+	err  error
+}
+
+func NewScanner(r io.Reader) *Scanner {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	return &Scanner{s: s}
+}
+
+func (s *Scanner) Scan() bool {
+	for s.s.Scan() {
+		s.line++
+		task, err := Parse(s.s.Text())
+		if errors.Is(err, ErrEmptyLine) {
+			continue
+		}
+		s.task = task
+		return true
+	}
+	if err := s.s.Err(); err != nil {
+		s.err = fmt.Errorf("line %d: %w", s.line+1, err)
+	}
+	return false
+}
+
+func (s *Scanner) Task() Task {
+	return s.task
+}
+
+// Line возвращает номер строки последней прочитанной задачи, начиная с 1
+func (s *Scanner) Line() int {
+	return s.line
+}
+
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+// sameItems сравнивает наборы строк без учёта порядка
+func sameItems(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := make(map[string]int, len(a))
+	for _, s := range a {
+		counts[s]++
+	}
+	for _, s := range b {
+		counts[s]--
+		if counts[s] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func isPriority(s string) bool {
+	return len(s) == 3 && s[0] == '(' && s[2] == ')' && isPriorityLetter(s[1:2])
+}
+
+func isPriorityLetter(s string) bool {
+	return len(s) == 1 && s[0] >= 'A' && s[0] <= 'Z'
+}
+
+func isMarker(s string, prefix byte) bool {
+	return len(s) > 1 && s[0] == prefix
+}
+
+func parseDate(fields []string) (time.Time, bool) {
+	if len(fields) == 0 || len(fields[0]) != len(dateLayout) {
+		return time.Time{}, false
+	}
+	date, err := time.Parse(dateLayout, fields[0])
+	return date, err == nil
+}
+
+// parseTag распознаёт key:value, но не трогает ссылки вида https://...
+func parseTag(s string) (Tag, bool) {
+	key, value, ok := strings.Cut(s, ":")
+	if !ok || key == "" || value == "" || strings.HasPrefix(value, "//") {
+		return Tag{}, false
+	}
+	for _, r := range key {
+		if !(r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return Tag{}, false
+		}
+	}
+	return Tag{Key: key, Value: value}, true
+}
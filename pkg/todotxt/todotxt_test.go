@@ -0,0 +1,139 @@
+package todotxt_test
+
+import (
+	"errors"
+	"github.com/melnik-dev/go_todo_jwt/pkg/todotxt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(s string) *time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return &t
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		line string
+		want todotxt.Task
+	}{
+		{
+			line: "(A) 2026-04-01 Call Mom +Family @phone due:2026-05-05",
+			want: todotxt.Task{
+				Priority:     "A",
+				CreationDate: date("2026-04-01"),
+				Text:         "Call Mom",
+				Projects:     []string{"Family"},
+				Contexts:     []string{"phone"},
+				Tags:         []todotxt.Tag{{Key: "due", Value: "2026-05-05"}},
+			},
+		},
+		{
+			line: "x 2026-05-02 2026-04-01 Review https://example.com/pr/1 +work pri:B",
+			want: todotxt.Task{
+				Completed:      true,
+				Priority:       "B",
+				CompletionDate: date("2026-05-02"),
+				CreationDate:   date("2026-04-01"),
+				Text:           "Review https://example.com/pr/1",
+				Projects:       []string{"work"},
+			},
+		},
+		{
+			line: "x 2026-05-02 Done",
+			want: todotxt.Task{
+				Completed:      true,
+				CompletionDate: date("2026-05-02"),
+				Text:           "Done",
+			},
+		},
+		{
+			line: "(a) lowercase is not a priority 2026-13-01 +",
+			want: todotxt.Task{
+				Text: "(a) lowercase is not a priority 2026-13-01 +",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := todotxt.Parse(tt.line)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", tt.line, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q):\nexpected %+v\ngot      %+v", tt.line, tt.want, got)
+		}
+	}
+
+	if _, err := todotxt.Parse("   "); !errors.Is(err, todotxt.ErrEmptyLine) {
+		t.Errorf("expected %v, got %v", todotxt.ErrEmptyLine, err)
+	}
+}
+
+func TestTask_String_RoundTrip(t *testing.T) {
+	lines := []string{
+		"(A) 2026-04-01 Call Mom +Family @phone due:2026-05-05",
+		"x 2026-05-02 2026-04-01 Review +work rec:1w pri:B",
+		"Plain task",
+		"(B) Call +Family Mom @phone about due:2026-05-05 the trip",
+		"x 2026-05-02 Review pri:B the +work draft rec:1w",
+	}
+
+	for _, line := range lines {
+		task, err := todotxt.Parse(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := task.String(); got != line {
+			t.Errorf("expected %q, got %q", line, got)
+		}
+	}
+}
+
+func TestTask_String_EditedBody(t *testing.T) {
+	task, err := todotxt.Parse("Call +Family Mom @phone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Text != "Call Mom" || task.Body != "Call +Family Mom @phone" {
+		t.Fatalf("unexpected task %+v", task)
+	}
+
+	// после правки текста прежний порядок слов уже не подходит
+	task.Text = "Call Dad"
+	if got := task.String(); got != "Call Dad +Family @phone" {
+		t.Errorf("expected line built from fields, got %q", got)
+	}
+}
+
+func TestTask_String_CompletedWithoutCompletionDate(t *testing.T) {
+	task := todotxt.Task{Completed: true, CreationDate: date("2026-04-01"), Text: "Done"}
+
+	if got := task.String(); got != "x Done" {
+		t.Errorf("expected creation date to be dropped, got %q", got)
+	}
+}
+
+func TestScanner(t *testing.T) {
+	s := todotxt.NewScanner(strings.NewReader("first\n\n  \nsecond +p\n"))
+
+	var lines []int
+	var texts []string
+	for s.Scan() {
+		task := s.Task()
+		lines = append(lines, s.Line())
+		texts = append(texts, task.Text)
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(lines, []int{1, 4}) || !reflect.DeepEqual(texts, []string{"first", "second"}) {
+		t.Errorf("unexpected scan result: lines %v, texts %v", lines, texts)
+	}
+}
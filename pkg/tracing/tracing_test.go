@@ -0,0 +1,75 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/melnik-dev/go_todo_jwt/configs"
+	"github.com/melnik-dev/go_todo_jwt/pkg/logger"
+	"github.com/melnik-dev/go_todo_jwt/pkg/tracing"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+const (
+	traceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	traceparent = "00-" + traceID + "-00f067aa0ba902b7-01"
+)
+
+func TestInit_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces", "traces.json")
+	shutdown, err := tracing.Init(configs.ConfTracing{Exporter: "file", FilePath: path, SampleRatio: 1},
+		configs.ConfApp{Name: "todo", Version: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, span := tracing.Start(context.Background(), "task.Service.Create")
+	span.End()
+
+	if err = shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"Name":"task.Service.Create"`) {
+		t.Errorf("span not exported: %s", data)
+	}
+}
+
+func TestInit_Traceparent(t *testing.T) {
+	// без экспортёра спаны не пишутся, но идентификатор трассы берётся из traceparent
+	if _, err := tracing.Init(configs.ConfTracing{Exporter: "none"}, configs.ConfApp{}); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	route := gin.New()
+	route.Use(otelgin.Middleware("todo"))
+
+	var fields logrus.Fields
+	route.GET("/tasks/:id", func(c *gin.Context) {
+		ctx, span := tracing.Start(c.Request.Context(), "task.Service.GetById")
+		defer span.End()
+		fields = logger.WithTrace(logrus.NewEntry(logrus.New()), ctx).Data
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/tasks/1", nil)
+	req.Header.Set("traceparent", traceparent)
+	route.ServeHTTP(httptest.NewRecorder(), req)
+
+	if fields["trace_id"] != traceID {
+		t.Errorf("expected trace_id %s, got %v", traceID, fields["trace_id"])
+	}
+	if fields["span_id"] == nil {
+		t.Error("expected span_id")
+	}
+}